@@ -0,0 +1,46 @@
+// Command normalize_identifiers brings usernames, emails and phone numbers stored
+// before identifiers were normalized into their normalized form. Run it from the
+// project root after migrating, with -dry-run first to see the collisions.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go_todo_api/database"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing anything")
+	flag.Parse()
+
+	db, errDb := database.NewDB(".", false)
+
+	if errDb != nil {
+		fmt.Println(errDb.Error())
+		os.Exit(1)
+	}
+
+	defer db.Close()
+
+	report, err := database.NormalizeUserIdentifiers(context.Background(), db, *dryRun)
+
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf("checked %d users, normalized %d\n", report.Checked, report.Updated)
+
+	for _, collision := range report.Collisions {
+		fmt.Printf("collision on %s %q between users %v\n", collision.Column, collision.Normalized, collision.UserIds)
+	}
+
+	if len(report.Collisions) > 0 {
+		fmt.Println("colliding users were left unchanged, rename them and run again")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,11 @@
+CREATE TABLE
+    user_totps (
+        user_id INT(11) UNSIGNED NOT NULL,
+        secret VARCHAR(64) NOT NULL,
+        is_enabled TINYINT NOT NULL DEFAULT 0,
+        last_used_step BIGINT NOT NULL DEFAULT 0,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        confirmed_at TIMESTAMP NULL,
+        PRIMARY KEY(user_id),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
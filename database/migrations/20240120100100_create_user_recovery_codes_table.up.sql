@@ -0,0 +1,11 @@
+CREATE TABLE
+    user_recovery_codes (
+        id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+        user_id INT(11) UNSIGNED NOT NULL,
+        code_hash CHAR(64) NOT NULL,
+        used_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        UNIQUE KEY (user_id, code_hash),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE
+    api_tokens (
+        id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+        user_id INT(11) UNSIGNED NOT NULL,
+        name VARCHAR(100) NOT NULL,
+        token_hash CHAR(64) NOT NULL UNIQUE,
+        scopes VARCHAR(255) NOT NULL,
+        expires_at TIMESTAMP NULL,
+        last_used_at TIMESTAMP NULL,
+        revoked_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN token_version,
+    DROP COLUMN is_disabled,
+    DROP COLUMN role;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' AFTER phone_number,
+    ADD COLUMN is_disabled TINYINT NOT NULL DEFAULT 0 AFTER role,
+    ADD COLUMN token_version INT(11) UNSIGNED NOT NULL DEFAULT 0 AFTER is_disabled;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE
+    user_identities (
+        id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+        user_id INT(11) UNSIGNED NOT NULL,
+        provider VARCHAR(50) NOT NULL,
+        subject VARCHAR(255) NOT NULL,
+        email VARCHAR(255) NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        UNIQUE (provider, subject),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS oidc_login_states;
\ No newline at end of file
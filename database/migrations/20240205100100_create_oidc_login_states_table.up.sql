@@ -0,0 +1,10 @@
+CREATE TABLE
+    oidc_login_states (
+        state CHAR(43) NOT NULL,
+        provider VARCHAR(50) NOT NULL,
+        nonce CHAR(43) NOT NULL,
+        code_verifier CHAR(43) NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(state)
+    ) ENGINE = InnoDb;
\ No newline at end of file
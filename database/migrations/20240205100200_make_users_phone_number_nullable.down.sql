@@ -0,0 +1 @@
+ALTER TABLE users MODIFY phone_number VARCHAR(20) NOT NULL;
\ No newline at end of file
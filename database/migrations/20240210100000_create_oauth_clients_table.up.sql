@@ -0,0 +1,12 @@
+CREATE TABLE
+    oauth_clients (
+        id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+        client_id VARCHAR(64) NOT NULL UNIQUE,
+        client_secret_hash CHAR(64) NULL,
+        name VARCHAR(100) NOT NULL,
+        redirect_uris TEXT NOT NULL,
+        scopes VARCHAR(255) NOT NULL,
+        grant_types VARCHAR(255) NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id)
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE
+    oauth_authorization_codes (
+        code_hash CHAR(64) NOT NULL,
+        client_id VARCHAR(64) NOT NULL,
+        user_id INT(11) UNSIGNED NOT NULL,
+        redirect_uri TEXT NOT NULL,
+        scopes VARCHAR(255) NOT NULL,
+        code_challenge CHAR(43) NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(code_hash),
+        FOREIGN KEY (client_id) REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS oauth_tokens;
\ No newline at end of file
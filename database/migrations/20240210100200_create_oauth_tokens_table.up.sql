@@ -0,0 +1,16 @@
+CREATE TABLE
+    oauth_tokens (
+        id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+        client_id VARCHAR(64) NOT NULL,
+        user_id INT(11) UNSIGNED NULL,
+        scopes VARCHAR(255) NOT NULL,
+        access_token_hash CHAR(64) NOT NULL UNIQUE,
+        refresh_token_hash CHAR(64) NULL UNIQUE,
+        expires_at TIMESTAMP NOT NULL,
+        refresh_expires_at TIMESTAMP NULL,
+        revoked_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        FOREIGN KEY (client_id) REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE
+    oauth_consents (
+        user_id INT(11) UNSIGNED NOT NULL,
+        client_id VARCHAR(64) NOT NULL,
+        scopes VARCHAR(255) NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+        PRIMARY KEY(user_id, client_id),
+        FOREIGN KEY (client_id) REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE
+    login_attempts (
+        attempt_key VARCHAR(191) NOT NULL,
+        failures INT(11) UNSIGNED NOT NULL,
+        last_failure_at BIGINT NOT NULL,
+        locked_until BIGINT NOT NULL DEFAULT 0,
+        PRIMARY KEY(attempt_key)
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE
+    email_changes (
+        id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+        user_id INT(11) UNSIGNED NOT NULL,
+        old_email VARCHAR(255) NOT NULL,
+        new_email VARCHAR(255) NOT NULL,
+        confirm_token_hash CHAR(64) NOT NULL UNIQUE,
+        revert_token_hash CHAR(64) NOT NULL UNIQUE,
+        expires_at TIMESTAMP NOT NULL,
+        revert_expires_at TIMESTAMP NOT NULL,
+        confirmed_at TIMESTAMP NULL,
+        reverted_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN locale VARCHAR(10) NULL AFTER phone_number;
\ No newline at end of file
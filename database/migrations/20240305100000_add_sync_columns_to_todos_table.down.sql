@@ -0,0 +1,5 @@
+ALTER TABLE todos
+    DROP KEY todos_user_id_change_seq_index,
+    DROP KEY todos_uuid_unique,
+    DROP COLUMN change_seq,
+    DROP COLUMN uuid;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE todos
+    ADD COLUMN uuid CHAR(36) NOT NULL DEFAULT (UUID()) AFTER id,
+    ADD COLUMN change_seq BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER version,
+    ADD UNIQUE KEY todos_uuid_unique (uuid),
+    ADD KEY todos_user_id_change_seq_index (user_id, change_seq);
\ No newline at end of file
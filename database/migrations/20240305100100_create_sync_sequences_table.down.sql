@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sync_sequences;
\ No newline at end of file
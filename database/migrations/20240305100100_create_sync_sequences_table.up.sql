@@ -0,0 +1,7 @@
+CREATE TABLE
+    sync_sequences (
+        user_id INT(11) UNSIGNED NOT NULL,
+        last_seq BIGINT UNSIGNED NOT NULL,
+        PRIMARY KEY(user_id),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS todo_tombstones;
\ No newline at end of file
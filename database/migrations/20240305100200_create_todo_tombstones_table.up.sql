@@ -0,0 +1,10 @@
+CREATE TABLE
+    todo_tombstones (
+        user_id INT(11) UNSIGNED NOT NULL,
+        uuid CHAR(36) NOT NULL,
+        change_seq BIGINT UNSIGNED NOT NULL,
+        deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(user_id, uuid),
+        KEY todo_tombstones_user_id_change_seq_index (user_id, change_seq),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
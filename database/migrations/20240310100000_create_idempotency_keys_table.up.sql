@@ -0,0 +1,11 @@
+CREATE TABLE
+    idempotency_keys (
+        idempotency_key CHAR(64) NOT NULL,
+        request_hash CHAR(64) NOT NULL,
+        status_code SMALLINT UNSIGNED NOT NULL DEFAULT 0,
+        response_header TEXT NULL,
+        response_body MEDIUMBLOB NULL,
+        expires_at BIGINT NOT NULL,
+        PRIMARY KEY(idempotency_key),
+        KEY (expires_at)
+    ) ENGINE = InnoDb;
\ No newline at end of file
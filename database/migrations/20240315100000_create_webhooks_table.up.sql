@@ -0,0 +1,13 @@
+CREATE TABLE
+    webhooks (
+        id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+        user_id INT(11) UNSIGNED NOT NULL,
+        url VARCHAR(2048) NOT NULL,
+        secret VARCHAR(100) NOT NULL,
+        event_types VARCHAR(255) NOT NULL,
+        failure_count INT(11) UNSIGNED NOT NULL DEFAULT 0,
+        disabled_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
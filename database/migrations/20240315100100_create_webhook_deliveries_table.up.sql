@@ -0,0 +1,19 @@
+CREATE TABLE
+    webhook_deliveries (
+        id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+        webhook_id INT(11) UNSIGNED NOT NULL,
+        event_id CHAR(36) NOT NULL,
+        event_type VARCHAR(50) NOT NULL,
+        payload MEDIUMTEXT NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'pending',
+        attempts INT(11) UNSIGNED NOT NULL DEFAULT 0,
+        next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        response_status SMALLINT UNSIGNED NULL,
+        last_error VARCHAR(255) NULL,
+        delivered_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        KEY webhook_deliveries_status_next_attempt_at_index (status, next_attempt_at),
+        KEY webhook_deliveries_webhook_id_index (webhook_id, id),
+        FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE webhook_deliveries
+    DROP KEY webhook_deliveries_webhook_id_event_id_index;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE webhook_deliveries
+    ADD KEY webhook_deliveries_webhook_id_event_id_index (webhook_id, event_id);
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE
+    outbox (
+        id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+        event_id CHAR(36) NOT NULL,
+        user_id INT(11) UNSIGNED NOT NULL,
+        event_type VARCHAR(50) NOT NULL,
+        payload MEDIUMTEXT NOT NULL,
+        published_to VARCHAR(255) NOT NULL DEFAULT '',
+        attempts INT(11) UNSIGNED NOT NULL DEFAULT 0,
+        next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        last_error VARCHAR(255) NULL,
+        published_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        UNIQUE KEY outbox_event_id_unique (event_id),
+        KEY outbox_published_at_next_attempt_at_index (published_at, next_attempt_at)
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE
+    jobs (
+        id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+        job_type VARCHAR(100) NOT NULL,
+        payload TEXT NOT NULL,
+        unique_key VARCHAR(191) NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'pending',
+        attempts INT(11) UNSIGNED NOT NULL DEFAULT 0,
+        run_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        last_error VARCHAR(255) NULL,
+        finished_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        UNIQUE KEY jobs_unique_key_unique (unique_key),
+        KEY jobs_status_run_at_index (status, run_at),
+        KEY jobs_finished_at_index (finished_at)
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE todos
+    DROP KEY todos_remind_at_index,
+    DROP COLUMN remind_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE todos
+    ADD COLUMN remind_at TIMESTAMP NULL AFTER is_done,
+    ADD KEY todos_remind_at_index (remind_at);
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE
+    notification_settings (
+        user_id INT(11) UNSIGNED NOT NULL,
+        channels VARCHAR(255) NOT NULL,
+        timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+        quiet_hours_start CHAR(5) NULL,
+        quiet_hours_end CHAR(5) NULL,
+        updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+        PRIMARY KEY(user_id),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
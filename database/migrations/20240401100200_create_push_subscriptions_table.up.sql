@@ -0,0 +1,14 @@
+CREATE TABLE
+    push_subscriptions (
+        id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+        user_id INT(11) UNSIGNED NOT NULL,
+        channel VARCHAR(20) NOT NULL,
+        endpoint VARCHAR(500) NOT NULL,
+        p256dh VARCHAR(100) NULL,
+        auth VARCHAR(50) NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        UNIQUE KEY push_subscriptions_endpoint_unique (endpoint),
+        KEY push_subscriptions_user_id_channel_index (user_id, channel),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
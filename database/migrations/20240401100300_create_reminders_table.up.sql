@@ -0,0 +1,16 @@
+CREATE TABLE
+    reminders (
+        id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+        todo_id INT(11) UNSIGNED NOT NULL,
+        user_id INT(11) UNSIGNED NOT NULL,
+        remind_at TIMESTAMP NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'pending',
+        sent_to VARCHAR(1000) NOT NULL DEFAULT '',
+        last_error VARCHAR(255) NULL,
+        finished_at TIMESTAMP NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(id),
+        UNIQUE KEY reminders_todo_id_remind_at_unique (todo_id, remind_at),
+        KEY reminders_created_at_index (created_at),
+        FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+    ) ENGINE = InnoDb;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- The backfilled numbers are ordinary change sequences, clients may already
+-- hold sync tokens past them.
+DO 0;
\ No newline at end of file
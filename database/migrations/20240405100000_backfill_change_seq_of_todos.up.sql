@@ -0,0 +1,8 @@
+UPDATE todos
+    JOIN (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY id) AS position
+        FROM todos
+        WHERE change_seq = 0
+    ) unsynced ON unsynced.id = todos.id
+    LEFT JOIN sync_sequences ON sync_sequences.user_id = todos.user_id
+SET todos.change_seq = COALESCE(sync_sequences.last_seq, 0) + unsynced.position;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Sequences only go up, the next change of a user continues from here.
+DO 0;
\ No newline at end of file
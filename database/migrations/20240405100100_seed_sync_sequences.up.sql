@@ -0,0 +1,3 @@
+INSERT INTO sync_sequences (user_id, last_seq)
+    SELECT user_id, MAX(change_seq) FROM todos GROUP BY user_id
+    ON DUPLICATE KEY UPDATE last_seq = GREATEST(last_seq, VALUES(last_seq));
\ No newline at end of file
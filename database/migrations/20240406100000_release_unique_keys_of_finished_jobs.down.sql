@@ -0,0 +1,3 @@
+-- The keys of finished jobs are not kept anywhere else, and jobs queued since
+-- may hold them now.
+DO 0;
@@ -0,0 +1 @@
+UPDATE jobs SET unique_key = NULL WHERE status IN ('succeeded', 'failed') AND unique_key IS NOT NULL;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS job_schedule_runs;
\ No newline at end of file
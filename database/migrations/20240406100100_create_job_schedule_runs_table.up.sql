@@ -0,0 +1,8 @@
+CREATE TABLE
+    job_schedule_runs (
+        schedule_name VARCHAR(100) NOT NULL,
+        run_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY(schedule_name, run_at),
+        KEY job_schedule_runs_created_at_index (created_at)
+    ) ENGINE = InnoDb;
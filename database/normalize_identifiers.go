@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"sort"
+)
+
+// IdentifierCollision is a group of users whose username, email or phone
+// number become the same once normalized. Those users are left as they are, someone has to
+// rename all but one of them by hand before running the migration again.
+type IdentifierCollision struct {
+	Column     string
+	Normalized string
+	UserIds    []int
+}
+
+type IdentifierNormalizationReport struct {
+	Checked    int
+	Updated    int
+	Collisions []IdentifierCollision
+}
+
+type userIdentifiers struct {
+	id          int
+	username    string
+	email       string
+	phoneNumber sql.NullString
+}
+
+// NormalizeUserIdentifiers rewrites existing usernames, emails and phone
+// numbers into the form new ones are stored in. Users caught in a collision are reported and
+// skipped, so the unique indexes keep holding. With dryRun nothing is written.
+func NormalizeUserIdentifiers(ctx context.Context, db *sql.DB, dryRun bool) (IdentifierNormalizationReport, error) {
+	tx, errBegin := db.BeginTx(ctx, nil)
+
+	if errBegin != nil {
+		return IdentifierNormalizationReport{}, errBegin
+	}
+
+	defer tx.Rollback()
+
+	rows, errQuery := tx.QueryContext(ctx, "SELECT id, username, email, phone_number FROM users ORDER BY id FOR UPDATE")
+
+	if errQuery != nil {
+		return IdentifierNormalizationReport{}, errQuery
+	}
+
+	users := []userIdentifiers{}
+
+	for rows.Next() {
+		user := userIdentifiers{}
+
+		if err := rows.Scan(&user.id, &user.username, &user.email, &user.phoneNumber); err != nil {
+			rows.Close()
+			return IdentifierNormalizationReport{}, err
+		}
+
+		users = append(users, user)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return IdentifierNormalizationReport{}, err
+	}
+
+	report := IdentifierNormalizationReport{Checked: len(users)}
+
+	report.Collisions = append(report.Collisions, findCollisions(users, "username", func(user userIdentifiers) string { return helper.NormalizeIdentifier(user.username) })...)
+	report.Collisions = append(report.Collisions, findCollisions(users, "email", func(user userIdentifiers) string { return helper.NormalizeIdentifier(user.email) })...)
+	report.Collisions = append(report.Collisions, findCollisions(users, "phone_number", normalizedPhoneNumber)...)
+
+	skipped := map[int]bool{}
+
+	for _, collision := range report.Collisions {
+		for _, userId := range collision.UserIds {
+			skipped[userId] = true
+		}
+	}
+
+	stmt, errPrepare := tx.PrepareContext(ctx, "UPDATE users SET username = ?, email = ?, phone_number = ? WHERE id = ?")
+
+	if errPrepare != nil {
+		return IdentifierNormalizationReport{}, errPrepare
+	}
+
+	defer stmt.Close()
+
+	for _, user := range users {
+		username := helper.NormalizeIdentifier(user.username)
+		email := helper.NormalizeIdentifier(user.email)
+		phoneNumber := sql.NullString{String: normalizedPhoneNumber(user), Valid: user.phoneNumber.Valid}
+
+		if skipped[user.id] || (username == user.username && email == user.email && phoneNumber == user.phoneNumber) {
+			continue
+		}
+
+		report.Updated++
+
+		if dryRun {
+			continue
+		}
+
+		if _, err := stmt.ExecContext(ctx, username, email, phoneNumber, user.id); err != nil {
+			return IdentifierNormalizationReport{}, err
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	return report, tx.Commit()
+}
+
+// normalizedPhoneNumber leaves users without a phone number out of the
+// collisions, they are stored as NULL.
+func normalizedPhoneNumber(user userIdentifiers) string {
+	if !user.phoneNumber.Valid {
+		return ""
+	}
+
+	return helper.NormalizePhoneNumber(user.phoneNumber.String)
+}
+
+func findCollisions(users []userIdentifiers, column string, normalize func(user userIdentifiers) string) []IdentifierCollision {
+	groups := map[string][]int{}
+
+	for _, user := range users {
+		normalized := normalize(user)
+
+		if normalized == "" {
+			continue
+		}
+
+		groups[normalized] = append(groups[normalized], user.id)
+	}
+
+	collisions := []IdentifierCollision{}
+
+	for normalized, userIds := range groups {
+		if len(userIds) > 1 {
+			collisions = append(collisions, IdentifierCollision{Column: column, Normalized: normalized, UserIds: userIds})
+		}
+	}
+
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].Normalized < collisions[j].Normalized
+	})
+
+	return collisions
+}
@@ -20,33 +20,125 @@ import (
 var userSet = wire.NewSet(
 	repository.NewUserRepository,
 	helper.HashFunction,
+	repository.NewEmailChangeRepository,
+	NewMailer,
+	NewEmailChangeConfig,
 	service.NewUserService,
 	controller.NewUserController,
 )
 
 var authSet = wire.NewSet(
+	NewJwtKeySet,
+	NewLoginAttemptStore,
+	service.DefaultLoginThrottleConfig,
+	service.NewLoginThrottleService,
+	controller.NewJwksController,
+	repository.NewMfaRepository,
 	service.NewAuthService,
 	controller.NewAuthController,
+	service.NewMfaService,
+	controller.NewMfaController,
+)
+
+var oidcSet = wire.NewSet(
+	NewOidcProviders,
+	repository.NewOidcRepository,
+	service.NewOidcService,
+	controller.NewOidcController,
+)
+
+var oauthSet = wire.NewSet(
+	repository.NewOauthRepository,
+	service.NewOauthService,
+	controller.NewOauthController,
+)
+
+var apiTokenSet = wire.NewSet(
+	repository.NewApiTokenRepository,
+	service.NewApiTokenService,
+	controller.NewApiTokenController,
+	middleware.NewAuthMiddleware,
+	NewIdempotencyStore,
+	middleware.NewIdempotencyMiddleware,
+)
+
+var adminSet = wire.NewSet(
+	service.NewAdminService,
+	controller.NewAdminController,
+)
+
+var webhookSet = wire.NewSet(
+	repository.NewWebhookRepository,
+	service.NewWebhookService,
+	controller.NewWebhookController,
+	NewWebhookConfig,
+	service.NewWebhookDispatcher,
+)
+
+var outboxSet = wire.NewSet(
+	repository.NewOutboxRepository,
+	NewOutboxSinks,
+	service.DefaultOutboxConfig,
+	service.NewOutbox,
+)
+
+var notificationSet = wire.NewSet(
+	repository.NewNotificationRepository,
+	repository.NewReminderRepository,
+	NewNotificationChannels,
+	service.NewNotificationService,
+	controller.NewNotificationController,
+	service.DefaultReminderConfig,
+	service.NewReminderScanJob,
+	service.NewReminderJob,
+)
+
+var jobSet = wire.NewSet(
+	repository.NewJobRepository,
+	service.DefaultCleanupConfig,
+	service.NewCleanupJob,
+	NewJobHandlers,
+	NewJobSchedules,
+	service.DefaultJobConfig,
+	service.NewJobRunner,
 )
 
 var todoSet = wire.NewSet(
 	repository.NewTodoRepository,
+	repository.NewSyncRepository,
+	helper.NewMemoryEventBus,
 	service.NewTodoService,
+	service.NewSyncService,
+	NewTodoControllerConfig,
 	controller.NewTodoController,
+	controller.NewSyncController,
+	controller.DefaultEventControllerConfig,
+	controller.NewEventController,
+	controller.DefaultWebsocketControllerConfig,
+	controller.NewWebsocketController,
 )
 
-func InitializeServer() (*http.Server, func()) {
+func InitializeServer() (*App, func(), error) {
 	wire.Build(
 		NewDB,
 		validator.NewValidator,
 		userSet,
 		authSet,
+		oidcSet,
+		oauthSet,
+		apiTokenSet,
+		adminSet,
 		todoSet,
+		webhookSet,
+		outboxSet,
+		notificationSet,
+		jobSet,
 		router.NewRouter,
 		wire.Bind(new(http.Handler), new(*httprouter.Router)),
 		middleware.NewLogMiddleware,
 		NewServer,
+		NewApp,
 	)
 
-	return nil, nil
+	return nil, nil, nil
 }
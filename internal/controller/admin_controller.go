@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type AdminController interface {
+	FindUsers(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	DisableUser(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	EnableUser(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	ForceLogout(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	ResetPassword(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	UnlockUser(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type AdminControllerImpl struct {
+	adminService service.AdminService
+}
+
+func NewAdminController(adminService service.AdminService) AdminController {
+	return &AdminControllerImpl{
+		adminService: adminService,
+	}
+}
+
+func queryInt(r *http.Request, key string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(key)
+
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+func (adminController *AdminControllerImpl) FindUsers(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	page, errCastPage := queryInt(r, "page", 1)
+
+	if errCastPage != nil {
+		helper.WriteErrorResponse(w, errCastPage)
+		return
+	}
+
+	perPage, errCastPerPage := queryInt(r, "per_page", 20)
+
+	if errCastPerPage != nil {
+		helper.WriteErrorResponse(w, errCastPerPage)
+		return
+	}
+
+	userSearchRequest := request.UserSearchRequest{
+		Query:   r.URL.Query().Get("q"),
+		Page:    page,
+		PerPage: perPage,
+	}
+
+	pageResponse, err := adminController.adminService.FindUsers(r.Context(), userSearchRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "users.found",
+		Data:       pageResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (adminController *AdminControllerImpl) DisableUser(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	userId, errCastToInt := strconv.Atoi(params.ByName("userId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	err := adminController.adminService.DisableUser(r.Context(), principal.UserId, userId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (adminController *AdminControllerImpl) EnableUser(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userId, errCastToInt := strconv.Atoi(params.ByName("userId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	err := adminController.adminService.EnableUser(r.Context(), userId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (adminController *AdminControllerImpl) ForceLogout(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userId, errCastToInt := strconv.Atoi(params.ByName("userId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	err := adminController.adminService.ForceLogout(r.Context(), userId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (adminController *AdminControllerImpl) ResetPassword(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userId, errCastToInt := strconv.Atoi(params.ByName("userId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	passwordResetRequest := request.PasswordResetRequest{
+		UserId: userId,
+	}
+
+	if errReadBody := helper.ReadRequestBody(r, &passwordResetRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	err := adminController.adminService.ResetPassword(r.Context(), passwordResetRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (adminController *AdminControllerImpl) UnlockUser(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userId, errCastToInt := strconv.Atoi(params.ByName("userId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	err := adminController.adminService.UnlockUser(r.Context(), userId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type ApiTokenController interface {
+	Create(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	GetUserTokens(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Revoke(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type ApiTokenControllerImpl struct {
+	apiTokenService service.ApiTokenService
+}
+
+func NewApiTokenController(apiTokenService service.ApiTokenService) ApiTokenController {
+	return &ApiTokenControllerImpl{
+		apiTokenService: apiTokenService,
+	}
+}
+
+func (apiTokenController *ApiTokenControllerImpl) Create(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	apiTokenCreateRequest := request.ApiTokenCreateRequest{
+		Username: principal.Username,
+	}
+
+	if errReadBody := helper.ReadRequestBody(r, &apiTokenCreateRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	apiTokenCreateResponse, err := apiTokenController.apiTokenService.Create(r.Context(), apiTokenCreateRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusCreated,
+		Message:    "api_token.created",
+		Data:       apiTokenCreateResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (apiTokenController *ApiTokenControllerImpl) GetUserTokens(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	apiTokenResponses, err := apiTokenController.apiTokenService.FindUserTokens(r.Context(), principal.Username)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "api_tokens.found",
+		Data:       apiTokenResponses,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (apiTokenController *ApiTokenControllerImpl) Revoke(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	tokenIdString := params.ByName("tokenId")
+
+	tokenId, errCastToInt := strconv.Atoi(tokenIdString)
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	err := apiTokenController.apiTokenService.Revoke(r.Context(), principal.Username, tokenId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
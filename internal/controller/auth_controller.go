@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"errors"
 	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
 	"go_todo_api/internal/service"
 	"net/http"
 
@@ -11,6 +13,7 @@ import (
 
 type AuthController interface {
 	Login(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	LoginMfa(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 	RefreshToken(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 }
 
@@ -34,8 +37,61 @@ func (authController *AuthControllerImpl) Login(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	userLoginRequest.IpAddress = helper.ClientIp(r)
+
 	loginResponse, err := authController.authService.Login(r.Context(), userLoginRequest)
 
+	writeLoginResponse(w, loginResponse, err)
+}
+
+// writeLoginResponse answers a first-factor login, which either succeeds or
+// asks the client to continue with an mfa code.
+func writeLoginResponse(w http.ResponseWriter, loginResponse response.LoginResponse, err error) {
+	var errMfaRequired *helper.MfaRequiredError
+
+	if errors.As(err, &errMfaRequired) {
+		responseData := helper.ResponseData{
+			StatusCode: http.StatusOK,
+			Message:    "auth.mfa_required",
+			Data: response.MfaChallengeResponse{
+				MfaRequired: true,
+				MfaToken:    errMfaRequired.MfaToken,
+				ExpiresAt:   errMfaRequired.ExpiresAt,
+			},
+		}
+
+		helper.WriteResponse(w, responseData)
+		return
+	}
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "auth.login_success",
+		Data:       loginResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (authController *AuthControllerImpl) LoginMfa(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	mfaLoginRequest := request.MfaLoginRequest{}
+
+	errReadRequestBody := helper.ReadRequestBody(r, &mfaLoginRequest)
+
+	if errReadRequestBody != nil {
+		helper.WriteErrorResponse(w, errReadRequestBody)
+		return
+	}
+
+	mfaLoginRequest.IpAddress = helper.ClientIp(r)
+
+	loginResponse, err := authController.authService.LoginMfa(r.Context(), mfaLoginRequest)
+
 	if err != nil {
 		helper.WriteErrorResponse(w, err)
 		return
@@ -43,7 +99,7 @@ func (authController *AuthControllerImpl) Login(w http.ResponseWriter, r *http.R
 
 	responseData := helper.ResponseData{
 		StatusCode: http.StatusOK,
-		Message:    "login success",
+		Message:    "auth.login_success",
 		Data:       loginResponse,
 	}
 
@@ -69,7 +125,7 @@ func (authController *AuthControllerImpl) RefreshToken(w http.ResponseWriter, r
 
 	responseData := helper.ResponseData{
 		StatusCode: http.StatusOK,
-		Message:    "refresh token success",
+		Message:    "auth.token_refreshed",
 		Data:       refreshTokenResponse,
 	}
 
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type EventController interface {
+	Stream(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+// EventControllerConfig sets how often an idle stream sends a comment, so
+// proxies don't close it and clients notice a dead connection.
+type EventControllerConfig struct {
+	HeartbeatInterval time.Duration
+}
+
+func DefaultEventControllerConfig() EventControllerConfig {
+	return EventControllerConfig{
+		HeartbeatInterval: 15 * time.Second,
+	}
+}
+
+type EventControllerImpl struct {
+	eventBus helper.EventBus
+	config   EventControllerConfig
+}
+
+func NewEventController(eventBus helper.EventBus, config EventControllerConfig) EventController {
+	return &EventControllerImpl{
+		eventBus: eventBus,
+		config:   config,
+	}
+}
+
+// Stream sends the caller's events as Server-Sent Events until the client
+// goes away. A client resuming with a Last-Event-ID the server no longer has
+// gets a "reset" event first and should fetch its todos again.
+func (eventController *EventControllerImpl) Stream(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	if principal.UserId == 0 {
+		helper.WriteErrorResponse(w, helper.ErrForbidden)
+		return
+	}
+
+	lastEventId, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+
+	responseController := http.NewResponseController(w)
+
+	subscription := eventController.eventBus.Subscribe(principal.UserId, lastEventId)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !subscription.Resumed {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+
+	for _, event := range subscription.Backlog {
+		writeEvent(w, event)
+	}
+
+	if err := responseController.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventController.config.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-subscription.Events:
+			// Dropped for falling behind, the client reconnects and resumes.
+			if !open {
+				return
+			}
+
+			writeEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err := responseController.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event helper.Event) {
+	data, err := json.Marshal(event.Data)
+
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+}
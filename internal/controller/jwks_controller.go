@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"encoding/json"
+	"go_todo_api/internal/helper"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type JwksController interface {
+	GetJwks(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type JwksControllerImpl struct {
+	jwtKeySet *helper.JwtKeySet
+}
+
+func NewJwksController(jwtKeySet *helper.JwtKeySet) JwksController {
+	return &JwksControllerImpl{
+		jwtKeySet: jwtKeySet,
+	}
+}
+
+// GetJwks serves the bare JWK Set document rather than the standard response
+// envelope, since that is the shape JWT libraries expect to fetch.
+func (jwksController *JwksControllerImpl) GetJwks(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	json.NewEncoder(w).Encode(jwksController.jwtKeySet.Jwks())
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type MfaController interface {
+	EnrollTotp(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	ConfirmTotp(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	DisableTotp(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type MfaControllerImpl struct {
+	mfaService service.MfaService
+}
+
+func NewMfaController(mfaService service.MfaService) MfaController {
+	return &MfaControllerImpl{
+		mfaService: mfaService,
+	}
+}
+
+func (mfaController *MfaControllerImpl) EnrollTotp(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	totpEnrollResponse, err := mfaController.mfaService.EnrollTotp(r.Context(), principal.Username)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "mfa.totp_enrollment_started",
+		Data:       totpEnrollResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (mfaController *MfaControllerImpl) ConfirmTotp(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	totpConfirmRequest := request.TotpConfirmRequest{
+		Username: principal.Username,
+	}
+
+	if errReadBody := helper.ReadRequestBody(r, &totpConfirmRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	recoveryCodesResponse, err := mfaController.mfaService.ConfirmTotp(r.Context(), totpConfirmRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "mfa.totp_enabled",
+		Data:       recoveryCodesResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (mfaController *MfaControllerImpl) DisableTotp(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	totpDisableRequest := request.TotpDisableRequest{
+		Username: principal.Username,
+	}
+
+	if errReadBody := helper.ReadRequestBody(r, &totpDisableRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	totpDisableRequest.IpAddress = helper.ClientIp(r)
+
+	err := mfaController.mfaService.DisableTotp(r.Context(), totpDisableRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type NotificationController interface {
+	GetSettings(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	UpdateSettings(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Subscribe(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	GetSubscriptions(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Unsubscribe(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type NotificationControllerImpl struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationController(notificationService service.NotificationService) NotificationController {
+	return &NotificationControllerImpl{
+		notificationService: notificationService,
+	}
+}
+
+func (notificationController *NotificationControllerImpl) GetSettings(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	settingsResponse, err := notificationController.notificationService.FindSettings(r.Context(), principal.UserId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "notification_settings.found",
+		Data:       settingsResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (notificationController *NotificationControllerImpl) UpdateSettings(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	settingsRequest := request.NotificationSettingsRequest{
+		UserId: principal.UserId,
+	}
+
+	if errReadBody := helper.ReadRequestBody(r, &settingsRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	settingsResponse, err := notificationController.notificationService.UpdateSettings(r.Context(), settingsRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "notification_settings.updated",
+		Data:       settingsResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (notificationController *NotificationControllerImpl) Subscribe(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	subscriptionRequest := request.PushSubscriptionCreateRequest{
+		UserId: principal.UserId,
+	}
+
+	if errReadBody := helper.ReadRequestBody(r, &subscriptionRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	subscriptionResponse, err := notificationController.notificationService.Subscribe(r.Context(), subscriptionRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusCreated,
+		Message:    "push_subscription.created",
+		Data:       subscriptionResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (notificationController *NotificationControllerImpl) GetSubscriptions(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	subscriptionResponses, err := notificationController.notificationService.FindSubscriptions(r.Context(), principal.UserId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "push_subscriptions.found",
+		Data:       subscriptionResponses,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (notificationController *NotificationControllerImpl) Unsubscribe(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	subscriptionId, errCastToInt := strconv.Atoi(params.ByName("subscriptionId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	err := notificationController.notificationService.Unsubscribe(r.Context(), principal.UserId, subscriptionId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type OauthController interface {
+	GetConsent(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Authorize(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Token(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	RegisterClient(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	FindClients(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	DeleteClient(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type OauthControllerImpl struct {
+	oauthService service.OauthService
+}
+
+func NewOauthController(oauthService service.OauthService) OauthController {
+	return &OauthControllerImpl{
+		oauthService: oauthService,
+	}
+}
+
+// GetConsent reads the authorization request from the query string and
+// returns what the consent screen should show the logged in user.
+func (oauthController *OauthControllerImpl) GetConsent(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	query := r.URL.Query()
+
+	oauthAuthorizeRequest := request.OauthAuthorizeRequest{
+		UserId:              principal.UserId,
+		ResponseType:        query.Get("response_type"),
+		ClientId:            query.Get("client_id"),
+		RedirectUri:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	oauthConsentResponse, err := oauthController.oauthService.GetConsent(r.Context(), oauthAuthorizeRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "oauth.consent_required",
+		Data:       oauthConsentResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (oauthController *OauthControllerImpl) Authorize(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	oauthAuthorizeRequest := request.OauthAuthorizeRequest{}
+
+	if errReadBody := helper.ReadRequestBody(r, &oauthAuthorizeRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	oauthAuthorizeRequest.UserId = principal.UserId
+
+	oauthAuthorizeResponse, err := oauthController.oauthService.Authorize(r.Context(), oauthAuthorizeRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "oauth.authorization_completed",
+		Data:       oauthAuthorizeResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+// Token is the RFC 6749 token endpoint. Clients authenticate with HTTP basic
+// auth or with client_id and client_secret in the form body.
+func (oauthController *OauthControllerImpl) Token(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	if errParseForm := r.ParseForm(); errParseForm != nil {
+		helper.WriteOauthResponse(w, nil, helper.NewOauthError("invalid_request", "malformed form body"))
+		return
+	}
+
+	oauthTokenRequest := request.OauthTokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectUri:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+		ClientId:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+
+	if clientId, clientSecret, ok := r.BasicAuth(); ok {
+		oauthTokenRequest.ClientId = clientId
+		oauthTokenRequest.ClientSecret = clientSecret
+	}
+
+	oauthTokenResponse, err := oauthController.oauthService.Token(r.Context(), oauthTokenRequest)
+
+	helper.WriteOauthResponse(w, oauthTokenResponse, err)
+}
+
+func (oauthController *OauthControllerImpl) RegisterClient(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	oauthClientCreateRequest := request.OauthClientCreateRequest{}
+
+	if errReadBody := helper.ReadRequestBody(r, &oauthClientCreateRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	oauthClientCreateResponse, err := oauthController.oauthService.RegisterClient(r.Context(), oauthClientCreateRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusCreated,
+		Message:    "oauth_client.created",
+		Data:       oauthClientCreateResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (oauthController *OauthControllerImpl) FindClients(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	oauthClientResponses, err := oauthController.oauthService.FindClients(r.Context())
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "oauth_clients.found",
+		Data:       oauthClientResponses,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (oauthController *OauthControllerImpl) DeleteClient(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	err := oauthController.oauthService.DeleteClient(r.Context(), params.ByName("clientId"))
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
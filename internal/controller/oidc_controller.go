@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type OidcController interface {
+	StartLogin(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Callback(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type OidcControllerImpl struct {
+	oidcService service.OidcService
+}
+
+func NewOidcController(oidcService service.OidcService) OidcController {
+	return &OidcControllerImpl{
+		oidcService: oidcService,
+	}
+}
+
+func (oidcController *OidcControllerImpl) StartLogin(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	authUrl, err := oidcController.oidcService.StartLogin(r.Context(), params.ByName("provider"))
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	http.Redirect(w, r, authUrl, http.StatusFound)
+}
+
+func (oidcController *OidcControllerImpl) Callback(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	query := r.URL.Query()
+
+	// The provider reports a denied consent or any other failure in the redirect itself.
+	if providerError := query.Get("error"); providerError != "" {
+		helper.WriteErrorResponse(w, fmt.Errorf("%w: %s", helper.ErrOidcLoginFailed, providerError))
+		return
+	}
+
+	oidcCallbackRequest := request.OidcCallbackRequest{
+		Provider: params.ByName("provider"),
+		Code:     query.Get("code"),
+		State:    query.Get("state"),
+	}
+
+	loginResponse, err := oidcController.oidcService.CompleteLogin(r.Context(), oidcCallbackRequest)
+
+	writeLoginResponse(w, loginResponse, err)
+}
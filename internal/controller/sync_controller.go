@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type SyncController interface {
+	Pull(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Push(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type SyncControllerImpl struct {
+	syncService service.SyncService
+}
+
+func NewSyncController(syncService service.SyncService) SyncController {
+	return &SyncControllerImpl{
+		syncService: syncService,
+	}
+}
+
+func (syncController *SyncControllerImpl) Pull(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userId, ok := syncUserId(w, r)
+
+	if !ok {
+		return
+	}
+
+	pullResponse, err := syncController.syncService.Pull(r.Context(), userId, r.URL.Query().Get("since"))
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "sync.changes_found",
+		Data:       pullResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (syncController *SyncControllerImpl) Push(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userId, ok := syncUserId(w, r)
+
+	if !ok {
+		return
+	}
+
+	syncPushRequest := request.SyncPushRequest{}
+
+	if errReadBody := helper.ReadRequestBody(r, &syncPushRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	pushResponse, err := syncController.syncService.Push(r.Context(), userId, syncPushRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "sync.mutations_processed",
+		Data:       pushResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+// syncUserId only lets principals that act for a user sync, a client
+// credentials token has no todos of its own.
+func syncUserId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return 0, false
+	}
+
+	if principal.UserId == 0 {
+		helper.WriteErrorResponse(w, helper.ErrForbidden)
+		return 0, false
+	}
+
+	return principal.UserId, true
+}
@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"context"
 	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
 	"go_todo_api/internal/service"
 	"net/http"
 	"strconv"
@@ -19,17 +21,32 @@ type TodoController interface {
 	Remove(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 }
 
+// TodoControllerConfig decides whether writes to a todo must name the version
+// they are based on with If-Match, or may overwrite whatever is stored.
+type TodoControllerConfig struct {
+	RequireIfMatch bool
+}
+
 type TodoControllerImpl struct {
 	todoService service.TodoService
+	config      TodoControllerConfig
 }
 
-func NewTodoController(todoService service.TodoService) TodoController {
+func NewTodoController(todoService service.TodoService, config TodoControllerConfig) TodoController {
 	return &TodoControllerImpl{
 		todoService: todoService,
+		config:      config,
 	}
 }
 
 func (todoController *TodoControllerImpl) CreateTodo(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, errPrincipal := todoPrincipal(r.Context())
+
+	if errPrincipal != nil {
+		helper.WriteErrorResponse(w, errPrincipal)
+		return
+	}
+
 	todoCreateRequest := request.TodoCreateRequest{}
 
 	errReadBody := helper.ReadRequestBody(r, &todoCreateRequest)
@@ -39,16 +56,22 @@ func (todoController *TodoControllerImpl) CreateTodo(w http.ResponseWriter, r *h
 		return
 	}
 
-	err := todoController.todoService.Create(r.Context(), todoCreateRequest)
+	todoCreateRequest.UserId = principal.UserId
+
+	todoResponse, err := todoController.todoService.Create(r.Context(), todoCreateRequest)
 
 	if err != nil {
 		helper.WriteErrorResponse(w, err)
 		return
 	}
 
+	w.Header().Set("Location", "/api/todo/"+strconv.Itoa(todoResponse.Id))
+	w.Header().Set("ETag", helper.ETag(todoResponse.Version))
+
 	responseData := helper.ResponseData{
 		StatusCode: http.StatusCreated,
-		Message:    "new todo created",
+		Message:    "todo.created",
+		Data:       todoResponse,
 	}
 
 	helper.WriteResponse(w, responseData)
@@ -64,16 +87,24 @@ func (todoController *TodoControllerImpl) Get(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	todoResponse, err := todoController.todoService.Find(r.Context(), todoId)
+	todoResponse, err := todoController.ownTodo(r.Context(), todoId)
 
 	if err != nil {
 		helper.WriteErrorResponse(w, err)
 		return
 	}
 
+	etag := helper.ETag(todoResponse.Version)
+	w.Header().Set("ETag", etag)
+
+	if helper.IfNoneMatch(r, etag) {
+		helper.WriteResponse(w, helper.ResponseData{StatusCode: http.StatusNotModified})
+		return
+	}
+
 	responseData := helper.ResponseData{
 		StatusCode: http.StatusOK,
-		Message:    "todo found",
+		Message:    "todo.found",
 		Data:       todoResponse,
 	}
 
@@ -99,7 +130,7 @@ func (todoController *TodoControllerImpl) GetUserTodos(w http.ResponseWriter, r
 
 	responseData := helper.ResponseData{
 		StatusCode: http.StatusOK,
-		Message:    "todos found",
+		Message:    "todos.found",
 		Data:       todoResponses,
 	}
 
@@ -116,8 +147,21 @@ func (todoController *TodoControllerImpl) Update(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if _, err := todoController.ownTodo(r.Context(), todoId); err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	version, errIfMatch := helper.IfMatchVersion(r, todoController.config.RequireIfMatch)
+
+	if errIfMatch != nil {
+		helper.WriteErrorResponse(w, errIfMatch)
+		return
+	}
+
 	todoUpdateRequest := request.TodoUpdateRequest{
-		Id: todoId,
+		Id:      todoId,
+		Version: version,
 	}
 
 	if errReadBody := helper.ReadRequestBody(r, &todoUpdateRequest); errReadBody != nil {
@@ -125,14 +169,20 @@ func (todoController *TodoControllerImpl) Update(w http.ResponseWriter, r *http.
 		return
 	}
 
-	err := todoController.todoService.Update(r.Context(), todoUpdateRequest)
+	todoResponse, err := todoController.todoService.Update(r.Context(), todoUpdateRequest)
 
 	if err != nil {
 		helper.WriteErrorResponse(w, err)
 		return
 	}
 
-	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+	w.Header().Set("ETag", helper.ETag(todoResponse.Version))
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "todo.updated",
+		Data:       todoResponse,
+	}
 
 	helper.WriteResponse(w, responseData)
 }
@@ -147,14 +197,32 @@ func (todoController *TodoControllerImpl) UpdateTodoCompletion(w http.ResponseWr
 		return
 	}
 
-	err := todoController.todoService.UpdateTodoCompletion(r.Context(), todoId)
+	if _, err := todoController.ownTodo(r.Context(), todoId); err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	version, errIfMatch := helper.IfMatchVersion(r, todoController.config.RequireIfMatch)
+
+	if errIfMatch != nil {
+		helper.WriteErrorResponse(w, errIfMatch)
+		return
+	}
+
+	todoResponse, err := todoController.todoService.UpdateTodoCompletion(r.Context(), todoId, version)
 
 	if err != nil {
 		helper.WriteErrorResponse(w, err)
 		return
 	}
 
-	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+	w.Header().Set("ETag", helper.ETag(todoResponse.Version))
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "todo.updated",
+		Data:       todoResponse,
+	}
 
 	helper.WriteResponse(w, responseData)
 }
@@ -169,7 +237,19 @@ func (todoController *TodoControllerImpl) Remove(w http.ResponseWriter, r *http.
 		return
 	}
 
-	err := todoController.todoService.Remove(r.Context(), todoId)
+	if _, err := todoController.ownTodo(r.Context(), todoId); err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	version, errIfMatch := helper.IfMatchVersion(r, todoController.config.RequireIfMatch)
+
+	if errIfMatch != nil {
+		helper.WriteErrorResponse(w, errIfMatch)
+		return
+	}
+
+	err := todoController.todoService.Remove(r.Context(), todoId, version)
 
 	if err != nil {
 		helper.WriteErrorResponse(w, err)
@@ -180,3 +260,41 @@ func (todoController *TodoControllerImpl) Remove(w http.ResponseWriter, r *http.
 
 	helper.WriteResponse(w, responseData)
 }
+
+// todoPrincipal only lets principals that act for a user at todos, a client
+// credentials token has no todos of its own.
+func todoPrincipal(ctx context.Context) (helper.Principal, error) {
+	principal, ok := helper.GetPrincipal(ctx)
+
+	if !ok {
+		return helper.Principal{}, helper.ErrorTokenInvalid
+	}
+
+	if principal.UserId == 0 {
+		return helper.Principal{}, helper.ErrForbidden
+	}
+
+	return principal, nil
+}
+
+// ownTodo finds a todo of the caller, or of anyone for an admin. Todos of
+// other users are reported as missing.
+func (todoController *TodoControllerImpl) ownTodo(ctx context.Context, todoId int) (response.TodoResponse, error) {
+	principal, err := todoPrincipal(ctx)
+
+	if err != nil {
+		return response.TodoResponse{}, err
+	}
+
+	todo, errFind := todoController.todoService.Find(ctx, todoId)
+
+	if errFind != nil {
+		return response.TodoResponse{}, errFind
+	}
+
+	if todo.UserId != principal.UserId && !principal.HasRole(helper.RoleAdmin) {
+		return response.TodoResponse{}, helper.ErrTodoNotFound
+	}
+
+	return todo, nil
+}
@@ -15,6 +15,8 @@ type UserController interface {
 	Get(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 	Update(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 	Remove(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	ConfirmEmail(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	RevertEmail(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 }
 
 type UserControllerImpl struct {
@@ -44,7 +46,7 @@ func (userController *UserControllerImpl) CreateUser(w http.ResponseWriter, r *h
 
 	responseData := helper.ResponseData{
 		StatusCode: http.StatusCreated,
-		Message:    "new user created",
+		Message:    "user.created",
 	}
 
 	helper.WriteResponse(w, responseData)
@@ -69,7 +71,7 @@ func (userController *UserControllerImpl) Get(w http.ResponseWriter, r *http.Req
 
 	responseData := helper.ResponseData{
 		StatusCode: http.StatusOK,
-		Message:    "user found",
+		Message:    "user.found",
 		Data:       userResponse,
 	}
 
@@ -132,3 +134,49 @@ func (userController *UserControllerImpl) Remove(w http.ResponseWriter, r *http.
 
 	helper.WriteResponse(w, responseData)
 }
+
+func (userController *UserControllerImpl) ConfirmEmail(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	emailTokenRequest := request.EmailTokenRequest{}
+
+	if errReadBody := helper.ReadRequestBody(r, &emailTokenRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	err := userController.userService.ConfirmEmail(r.Context(), emailTokenRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "user.email_changed",
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (userController *UserControllerImpl) RevertEmail(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	emailTokenRequest := request.EmailTokenRequest{}
+
+	if errReadBody := helper.ReadRequestBody(r, &emailTokenRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	err := userController.userService.RevertEmail(r.Context(), emailTokenRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "user.email_change_reverted",
+	}
+
+	helper.WriteResponse(w, responseData)
+}
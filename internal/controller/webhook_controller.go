@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type WebhookController interface {
+	Create(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	GetUserWebhooks(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Remove(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Enable(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	GetDeliveries(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	Redeliver(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+type WebhookControllerImpl struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookController(webhookService service.WebhookService) WebhookController {
+	return &WebhookControllerImpl{
+		webhookService: webhookService,
+	}
+}
+
+func (webhookController *WebhookControllerImpl) Create(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	webhookCreateRequest := request.WebhookCreateRequest{
+		UserId: principal.UserId,
+	}
+
+	if errReadBody := helper.ReadRequestBody(r, &webhookCreateRequest); errReadBody != nil {
+		helper.WriteErrorResponse(w, errReadBody)
+		return
+	}
+
+	webhookCreateResponse, err := webhookController.webhookService.Create(r.Context(), webhookCreateRequest)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusCreated,
+		Message:    "webhook.created",
+		Data:       webhookCreateResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (webhookController *WebhookControllerImpl) GetUserWebhooks(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	webhookResponses, err := webhookController.webhookService.FindUserWebhooks(r.Context(), principal.UserId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "webhooks.found",
+		Data:       webhookResponses,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (webhookController *WebhookControllerImpl) Remove(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	webhookId, errCastToInt := strconv.Atoi(params.ByName("webhookId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	err := webhookController.webhookService.Remove(r.Context(), principal.UserId, webhookId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{StatusCode: http.StatusNoContent}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (webhookController *WebhookControllerImpl) Enable(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	webhookId, errCastToInt := strconv.Atoi(params.ByName("webhookId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	webhookResponse, err := webhookController.webhookService.Enable(r.Context(), principal.UserId, webhookId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "webhook.enabled",
+		Data:       webhookResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (webhookController *WebhookControllerImpl) GetDeliveries(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	webhookId, errCastToInt := strconv.Atoi(params.ByName("webhookId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	deliveryResponses, err := webhookController.webhookService.FindDeliveries(r.Context(), principal.UserId, webhookId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusOK,
+		Message:    "webhook_deliveries.found",
+		Data:       deliveryResponses,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
+
+func (webhookController *WebhookControllerImpl) Redeliver(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	webhookId, errCastToInt := strconv.Atoi(params.ByName("webhookId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	deliveryId, errCastToInt := strconv.Atoi(params.ByName("deliveryId"))
+
+	if errCastToInt != nil {
+		helper.WriteErrorResponse(w, errCastToInt)
+		return
+	}
+
+	deliveryResponse, err := webhookController.webhookService.Redeliver(r.Context(), principal.UserId, webhookId, deliveryId)
+
+	if err != nil {
+		helper.WriteErrorResponse(w, err)
+		return
+	}
+
+	responseData := helper.ResponseData{
+		StatusCode: http.StatusAccepted,
+		Message:    "webhook_delivery.created",
+		Data:       deliveryResponse,
+	}
+
+	helper.WriteResponse(w, responseData)
+}
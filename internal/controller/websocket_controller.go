@@ -0,0 +1,400 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	websocketTopicTodos      = "todos"
+	websocketTopicTodoPrefix = "todo:"
+)
+
+type WebsocketController interface {
+	Connect(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+}
+
+// WebsocketControllerConfig bounds what one connection may hold up. A client
+// that lets SendQueueSize messages pile up is disconnected, nobody writing a
+// todo ever waits for it.
+type WebsocketControllerConfig struct {
+	HeartbeatInterval time.Duration
+	WriteTimeout      time.Duration
+	SendQueueSize     int
+	MaxMessageSize    int
+}
+
+func DefaultWebsocketControllerConfig() WebsocketControllerConfig {
+	return WebsocketControllerConfig{
+		HeartbeatInterval: 15 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		SendQueueSize:     64,
+		MaxMessageSize:    64 << 10,
+	}
+}
+
+type WebsocketControllerImpl struct {
+	todoService service.TodoService
+	eventBus    helper.EventBus
+	todoConfig  TodoControllerConfig
+	config      WebsocketControllerConfig
+}
+
+func NewWebsocketController(todoService service.TodoService, eventBus helper.EventBus, todoConfig TodoControllerConfig, config WebsocketControllerConfig) WebsocketController {
+	return &WebsocketControllerImpl{
+		todoService: todoService,
+		eventBus:    eventBus,
+		todoConfig:  todoConfig,
+		config:      config,
+	}
+}
+
+// Connect upgrades the request to a WebSocket. The client subscribes to
+// "todos", all of its todos, or to "todo:<id>", and writes todos with
+// create, update and complete. Every message is answered with an ack or an
+// error carrying its id.
+func (websocketController *WebsocketControllerImpl) Connect(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	principal, ok := helper.GetPrincipal(r.Context())
+
+	if !ok {
+		helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+		return
+	}
+
+	if principal.UserId == 0 {
+		helper.WriteErrorResponse(w, helper.ErrForbidden)
+		return
+	}
+
+	session := &websocketSession{
+		controller: websocketController,
+		principal:  principal,
+		locale:     helper.ResponseLocale(w),
+		requestId:  w.Header().Get(helper.RequestIdHeader),
+		topics:     map[string]bool{},
+		send:       make(chan response.WebsocketResponse, websocketController.config.SendQueueSize),
+	}
+
+	server := websocket.Server{
+		// The connection is authorized by a bearer token, which a page on
+		// another origin doesn't have, so any origin may connect.
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			return nil
+		},
+		Handler: session.serve,
+	}
+
+	server.ServeHTTP(w, r)
+}
+
+// websocketSession is one connection. Messages from the client are handled
+// one at a time, a single writer sends the answers and the events.
+type websocketSession struct {
+	controller *WebsocketControllerImpl
+	principal  helper.Principal
+	locale     string
+	requestId  string
+	conn       *websocket.Conn
+	mutex      sync.Mutex
+	topics     map[string]bool
+	send       chan response.WebsocketResponse
+}
+
+func (session *websocketSession) serve(conn *websocket.Conn) {
+	session.conn = conn
+	conn.MaxPayloadBytes = session.controller.config.MaxMessageSize
+
+	ctx, cancel := context.WithCancel(conn.Request().Context())
+	defer cancel()
+
+	subscription := session.controller.eventBus.Subscribe(session.principal.UserId, 0)
+	defer subscription.Close()
+
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(2)
+
+	go func() {
+		defer waitGroup.Done()
+		session.write(ctx)
+	}()
+
+	go func() {
+		defer waitGroup.Done()
+		session.forward(ctx, subscription.Events)
+	}()
+
+	session.read(ctx)
+
+	cancel()
+	conn.Close()
+	waitGroup.Wait()
+}
+
+// read returns when the connection breaks. Closing it from another goroutine
+// is how the others end the session.
+func (session *websocketSession) read(ctx context.Context) {
+	for {
+		var data []byte
+
+		if err := websocket.Message.Receive(session.conn, &data); err != nil {
+			return
+		}
+
+		message := request.WebsocketRequest{}
+		var result any
+		var err error
+
+		if errUnmarshal := json.Unmarshal(data, &message); errUnmarshal != nil {
+			err = fmt.Errorf("%w: %w", helper.ErrMalformedBody, errUnmarshal)
+		} else {
+			result, err = session.handle(ctx, message)
+		}
+
+		answer := response.WebsocketResponse{Type: response.WebsocketAck, Id: message.Id, Data: result}
+
+		if err != nil {
+			problem := helper.Problem(err, session.locale, session.requestId)
+			answer = response.WebsocketResponse{Type: response.WebsocketError, Id: message.Id, Error: &problem}
+		}
+
+		// Answers wait for room in the queue, a client that stops reading
+		// stops being read from.
+		select {
+		case session.send <- answer:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (session *websocketSession) handle(ctx context.Context, message request.WebsocketRequest) (any, error) {
+	switch message.Type {
+	case request.WebsocketSubscribe:
+		return nil, session.subscribe(ctx, message.Topic)
+	case request.WebsocketUnsubscribe:
+		session.mutex.Lock()
+		defer session.mutex.Unlock()
+
+		delete(session.topics, message.Topic)
+
+		return nil, nil
+	case request.WebsocketCreate:
+		return session.create(ctx, message.Data)
+	case request.WebsocketUpdate:
+		return session.update(ctx, message.Data)
+	case request.WebsocketComplete:
+		return session.complete(ctx, message.Data)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", helper.ErrWebsocketMessageInvalid, message.Type)
+	}
+}
+
+func (session *websocketSession) subscribe(ctx context.Context, topic string) error {
+	if topic != websocketTopicTodos {
+		todoIdString, ok := strings.CutPrefix(topic, websocketTopicTodoPrefix)
+		todoId, errCastToInt := strconv.Atoi(todoIdString)
+
+		if !ok || errCastToInt != nil {
+			return fmt.Errorf("%w: unknown topic %q", helper.ErrWebsocketMessageInvalid, topic)
+		}
+
+		if _, err := session.ownTodo(ctx, todoId); err != nil {
+			return err
+		}
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.topics[topic] = true
+
+	return nil
+}
+
+func (session *websocketSession) create(ctx context.Context, data json.RawMessage) (any, error) {
+	if err := session.principal.CheckScope(helper.ScopeTodosWrite); err != nil {
+		return nil, err
+	}
+
+	todoCreateRequest := request.TodoCreateRequest{}
+
+	if err := decodeWebsocketData(data, &todoCreateRequest); err != nil {
+		return nil, err
+	}
+
+	todoCreateRequest.UserId = session.principal.UserId
+
+	return session.controller.todoService.Create(ctx, todoCreateRequest)
+}
+
+func (session *websocketSession) update(ctx context.Context, data json.RawMessage) (any, error) {
+	todoRequest, errTodoRequest := session.todoRequest(ctx, data)
+
+	if errTodoRequest != nil {
+		return nil, errTodoRequest
+	}
+
+	todoUpdateRequest := request.TodoUpdateRequest{}
+
+	if err := decodeWebsocketData(data, &todoUpdateRequest); err != nil {
+		return nil, err
+	}
+
+	todoUpdateRequest.Id = todoRequest.Id
+	todoUpdateRequest.Version = todoRequest.Version
+
+	return session.controller.todoService.Update(ctx, todoUpdateRequest)
+}
+
+func (session *websocketSession) complete(ctx context.Context, data json.RawMessage) (any, error) {
+	todoRequest, errTodoRequest := session.todoRequest(ctx, data)
+
+	if errTodoRequest != nil {
+		return nil, errTodoRequest
+	}
+
+	return session.controller.todoService.UpdateTodoCompletion(ctx, todoRequest.Id, todoRequest.Version)
+}
+
+// todoRequest reads which todo a write is for, and checks it may be written
+// to by this connection.
+func (session *websocketSession) todoRequest(ctx context.Context, data json.RawMessage) (request.WebsocketTodoRequest, error) {
+	if err := session.principal.CheckScope(helper.ScopeTodosWrite); err != nil {
+		return request.WebsocketTodoRequest{}, err
+	}
+
+	todoRequest := request.WebsocketTodoRequest{}
+
+	if err := decodeWebsocketData(data, &todoRequest); err != nil {
+		return request.WebsocketTodoRequest{}, err
+	}
+
+	if todoRequest.Version == 0 && session.controller.todoConfig.RequireIfMatch {
+		return request.WebsocketTodoRequest{}, helper.ErrPreconditionRequired
+	}
+
+	if _, err := session.ownTodo(ctx, todoRequest.Id); err != nil {
+		return request.WebsocketTodoRequest{}, err
+	}
+
+	return todoRequest, nil
+}
+
+// ownTodo finds a todo of the connected user. Todos of other users are
+// reported as missing.
+func (session *websocketSession) ownTodo(ctx context.Context, todoId int) (response.TodoResponse, error) {
+	todo, err := session.controller.todoService.Find(ctx, todoId)
+
+	if err != nil {
+		return response.TodoResponse{}, err
+	}
+
+	if todo.UserId != session.principal.UserId {
+		return response.TodoResponse{}, helper.ErrTodoNotFound
+	}
+
+	return todo, nil
+}
+
+// forward queues the events on subscribed topics without ever waiting. A
+// client too slow to keep up is disconnected, it fetches its todos again
+// after reconnecting.
+func (session *websocketSession) forward(ctx context.Context, events <-chan helper.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-events:
+			if !open {
+				session.conn.Close()
+				return
+			}
+
+			topic, ok := session.topic(event)
+
+			if !ok {
+				continue
+			}
+
+			select {
+			case session.send <- response.WebsocketResponse{Type: response.WebsocketEvent, Topic: topic, Event: event.Type, Data: event.Data}:
+			default:
+				session.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// topic picks the subscribed topic an event belongs to, the todo's own topic
+// first.
+func (session *websocketSession) topic(event helper.Event) (string, bool) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	todoId := 0
+
+	switch data := event.Data.(type) {
+	case response.TodoResponse:
+		todoId = data.Id
+	case response.TodoDeletedResponse:
+		todoId = data.Id
+	}
+
+	todoTopic := websocketTopicTodoPrefix + strconv.Itoa(todoId)
+
+	if todoId != 0 && session.topics[todoTopic] {
+		return todoTopic, true
+	}
+
+	if session.topics[websocketTopicTodos] {
+		return websocketTopicTodos, true
+	}
+
+	return "", false
+}
+
+func (session *websocketSession) write(ctx context.Context) {
+	heartbeat := time.NewTicker(session.controller.config.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var message response.WebsocketResponse
+
+		select {
+		case <-ctx.Done():
+			return
+		case message = <-session.send:
+		case <-heartbeat.C:
+			message = response.WebsocketResponse{Type: response.WebsocketHeartbeat}
+		}
+
+		session.conn.SetWriteDeadline(time.Now().Add(session.controller.config.WriteTimeout))
+
+		if err := websocket.JSON.Send(session.conn, message); err != nil {
+			session.conn.Close()
+			return
+		}
+	}
+}
+
+func decodeWebsocketData(data json.RawMessage, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", helper.ErrMalformedBody, err)
+	}
+
+	return nil
+}
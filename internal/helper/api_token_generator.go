@@ -0,0 +1,23 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+const ApiTokenPrefix = "gta_"
+
+func GenerateApiToken() (string, error) {
+	raw := make([]byte, 32)
+
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return ApiTokenPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func IsApiToken(token string) bool {
+	return strings.HasPrefix(token, ApiTokenPrefix)
+}
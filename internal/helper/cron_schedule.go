@@ -0,0 +1,175 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// CronSchedule is a standard five field cron expression, minute hour
+// day-of-month month day-of-week. Each field takes *, numbers, ranges, lists
+// and steps, and day-of-week takes 7 for Sunday too. Like cron, a time
+// matches either day field when both are restricted.
+type CronSchedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+func ParseCronSchedule(spec string) (CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if expression, ok := cronDescriptors[spec]; ok {
+		spec = expression
+	}
+
+	fields := strings.Fields(spec)
+
+	if len(fields) != 5 {
+		return CronSchedule{}, fmt.Errorf("cron schedule %q needs 5 fields", spec)
+	}
+
+	schedule := CronSchedule{
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}
+
+	bounds := []struct {
+		field *uint64
+		min   int
+		max   int
+	}{
+		{&schedule.minute, 0, 59},
+		{&schedule.hour, 0, 23},
+		{&schedule.dayOfMonth, 1, 31},
+		{&schedule.month, 1, 12},
+		{&schedule.dayOfWeek, 0, 7},
+	}
+
+	for i, bound := range bounds {
+		bits, err := parseCronField(fields[i], bound.min, bound.max)
+
+		if err != nil {
+			return CronSchedule{}, fmt.Errorf("cron schedule %q: %w", spec, err)
+		}
+
+		*bound.field = bits
+	}
+
+	// Sunday is both 0 and 7.
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek |= 1
+	}
+
+	return schedule, nil
+}
+
+func parseCronField(field string, min int, max int) (uint64, error) {
+	bits := uint64(0)
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+
+		if hasStep {
+			var err error
+
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+		}
+
+		start, end := min, max
+
+		if rangePart != "*" {
+			startPart, endPart, isRange := strings.Cut(rangePart, "-")
+
+			var errStart, errEnd error
+
+			start, errStart = strconv.Atoi(startPart)
+			end = start
+
+			if isRange {
+				end, errEnd = strconv.Atoi(endPart)
+			} else if hasStep {
+				end = max
+			}
+
+			if errStart != nil || errEnd != nil || start < min || end > max || start > end {
+				return 0, fmt.Errorf("%q is out of %d-%d", part, min, max)
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first time after the given one the schedule matches, in
+// the location of after. It is the zero time for a schedule that never
+// matches, such as February 30th.
+func (schedule CronSchedule) Next(after time.Time) time.Time {
+	location := after.Location()
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+
+	for next.Before(limit) {
+		if schedule.month&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		if !schedule.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		if schedule.hour&(1<<uint(next.Hour())) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+
+		if schedule.minute&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+
+		return next
+	}
+
+	return time.Time{}
+}
+
+func (schedule CronSchedule) matchesDay(t time.Time) bool {
+	dayMatches := schedule.dayOfMonth&(1<<uint(t.Day())) != 0
+	weekdayMatches := schedule.dayOfWeek&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case schedule.anyDay && schedule.anyWeekday:
+		return true
+	case schedule.anyDay:
+		return weekdayMatches
+	case schedule.anyWeekday:
+		return dayMatches
+	default:
+		return dayMatches || weekdayMatches
+	}
+}
@@ -0,0 +1,18 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// GenerateEmailToken makes the secret put in links mailed to a user, only its
+// HashToken is stored.
+func GenerateEmailToken() (string, error) {
+	raw := make([]byte, 32)
+
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -1,30 +1,92 @@
 package helper
 
 import (
+	"encoding/json"
 	"errors"
+	"go_todo_api/internal/model/response"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
+const ProblemContentType = "application/problem+json"
+
+// WriteErrorResponse answers with an RFC 7807 problem. Only AppErrors and the
+// other errors classified here are described to the client, anything else is
+// logged and reported as a bare internal error.
 func WriteErrorResponse(w http.ResponseWriter, err error) {
-	responseData := ResponseData{}
-
-	if errors.Is(ErrNotFound, err) {
-		responseData.StatusCode = http.StatusNotFound
-		responseData.Message = "data not found"
-	} else if errors.Is(ErrLoginFailed, err) || err.Error() == "token has invalid claims: token is expired" || errors.Is(ErrBearerTokenMissing, err) {
-		responseData.StatusCode = http.StatusUnauthorized
-		responseData.Message = "unauthorized"
-	} else if _, ok := err.(validator.ValidationErrors); ok {
-		responseData.StatusCode = http.StatusBadRequest
-		responseData.Message = "validation error"
-	} else {
-		responseData.StatusCode = http.StatusInternalServerError
-		responseData.Message = "internal server error"
+	problem := newProblem(w.Header(), err, ResponseLocale(w), w.Header().Get(RequestIdHeader))
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+
+	json.NewEncoder(w).Encode(problem)
+}
+
+// Problem describes err the way WriteErrorResponse does, for errors answered
+// outside of an HTTP response, like on a WebSocket.
+func Problem(err error, locale string, requestId string) response.ProblemResponse {
+	return newProblem(http.Header{}, err, locale, requestId)
+}
+
+func newProblem(header http.Header, err error, locale string, requestId string) response.ProblemResponse {
+	problem := toProblem(header, err, locale)
+
+	if problem.Status >= http.StatusInternalServerError {
+		logInternalError(requestId, err)
 	}
 
-	responseData.Err = err
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+
+	if requestId != "" {
+		problem.Instance = "urn:request:" + requestId
+	}
+
+	return problem
+}
+
+func toProblem(header http.Header, err error, locale string) response.ProblemResponse {
+	var appError *AppError
+	var throttledError *LoginThrottledError
+	var validationErrors validator.ValidationErrors
+	var oauthError *OauthError
+	var numError *strconv.NumError
+	var constraintError *ConstraintError
+
+	switch {
+	case errors.As(err, &throttledError):
+		header.Set("Retry-After", strconv.Itoa(int(math.Ceil(throttledError.RetryAfter.Seconds()))))
+		return response.ProblemResponse{Status: http.StatusTooManyRequests, Code: "auth.login_throttled", Detail: Message(locale, "auth.login_throttled")}
+	case errors.As(err, &validationErrors):
+		return response.ProblemResponse{Status: http.StatusBadRequest, Code: "request.validation_failed", Detail: Message(locale, "request.validation_failed"), Errors: FieldErrors(validationErrors, locale)}
+	case errors.As(err, &oauthError):
+		return response.ProblemResponse{Status: http.StatusBadRequest, Code: "oauth." + oauthError.Code, Detail: oauthError.Description}
+	case errors.As(err, &numError):
+		return response.ProblemResponse{Status: http.StatusBadRequest, Code: ErrInvalidParameter.Code, Detail: Message(locale, ErrInvalidParameter.Code)}
+	case errors.As(err, &constraintError):
+		detail := constraintError.Field + " " + Message(locale, constraintError.parent.Code)
+		fieldError := response.FieldErrorResponse{Field: constraintError.Field, Rule: constraintError.Rule, Message: detail}
+		return response.ProblemResponse{Status: constraintError.parent.Status, Code: constraintError.parent.Code, Detail: detail, Errors: []response.FieldErrorResponse{fieldError}}
+	case errors.As(err, &appError) && appError.Status < http.StatusInternalServerError:
+		// The whole chain is shown, wrapping adds context like the missing scope.
+		return response.ProblemResponse{Status: appError.Status, Code: appError.Code, Detail: localizeDetail(locale, appError, err.Error())}
+	default:
+		return response.ProblemResponse{Status: http.StatusInternalServerError, Code: "internal_error", Detail: Message(locale, "internal_error")}
+	}
+}
+
+// localizeDetail swaps the AppError's own detail for its translation and
+// keeps whatever context was wrapped around it.
+func localizeDetail(locale string, appError *AppError, detail string) string {
+	message, ok := lookupMessage(locale, appError.Code)
+
+	if !ok {
+		return detail
+	}
 
-	WriteResponse(w, responseData)
+	return strings.Replace(detail, appError.Detail, message, 1)
 }
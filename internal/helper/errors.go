@@ -1,11 +1,122 @@
 package helper
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// AppError is an error meant for the client. Code is stable and machine
+// readable, clients should branch on it rather than on Detail.
+type AppError struct {
+	Status int
+	Code   string
+	Detail string
+	parent error
+}
+
+func NewAppError(status int, code string, detail string) *AppError {
+	return &AppError{Status: status, Code: code, Detail: detail}
+}
+
+// NewNotFoundError makes a not found error with its own code that still
+// matches ErrNotFound.
+func NewNotFoundError(code string, detail string) *AppError {
+	return &AppError{Status: http.StatusNotFound, Code: code, Detail: detail, parent: ErrNotFound}
+}
+
+func (err *AppError) Error() string {
+	return err.Detail
+}
+
+func (err *AppError) Unwrap() error {
+	return err.parent
+}
+
+var (
+	ErrLoginFailed                    = NewAppError(http.StatusUnauthorized, "auth.login_failed", "invalid username or password")
+	ErrNotFound                       = NewAppError(http.StatusNotFound, "resource.not_found", "data not found")
+	ErrorTokenInvalid                 = NewAppError(http.StatusUnauthorized, "auth.token_invalid", "token invalid")
+	ErrTokenExpired                   = NewAppError(http.StatusUnauthorized, "auth.token_expired", "token expired")
+	ErrBearerTokenMissing             = NewAppError(http.StatusUnauthorized, "auth.token_missing", "bearer token missing")
+	ErrForbidden                      = NewAppError(http.StatusForbidden, "auth.forbidden", "forbidden")
+	ErrAccountDisabled                = NewAppError(http.StatusForbidden, "auth.account_disabled", "account disabled")
+	ErrMfaCodeInvalid                 = NewAppError(http.StatusUnauthorized, "mfa.code_invalid", "invalid mfa code")
+	ErrMfaAlreadyEnabled              = NewAppError(http.StatusConflict, "mfa.already_enabled", "two-factor authentication already enabled")
+	ErrMfaNotEnabled                  = NewAppError(http.StatusConflict, "mfa.not_enabled", "two-factor authentication not enabled")
+	ErrOidcLoginFailed                = NewAppError(http.StatusUnauthorized, "oidc.login_failed", "oidc login failed")
+	ErrOidcEmailConflict              = NewAppError(http.StatusConflict, "oidc.email_conflict", "an account with this email already exists, sign in with a password to link it")
+	ErrEmailTaken                     = NewAppError(http.StatusConflict, "user.email_taken", "email address already in use")
+	ErrMalformedBody                  = NewAppError(http.StatusBadRequest, "request.malformed_body", "malformed request body")
+	ErrInvalidParameter               = NewAppError(http.StatusBadRequest, "request.invalid_parameter", "invalid path parameter")
+	ErrPreconditionFailed             = NewAppError(http.StatusPreconditionFailed, "request.precondition_failed", "resource was modified, fetch it again")
+	ErrPreconditionRequired           = NewAppError(http.StatusPreconditionRequired, "request.precondition_required", "If-Match header required")
+	ErrSyncTokenInvalid               = NewAppError(http.StatusBadRequest, "sync.token_invalid", "invalid sync token")
+	ErrIdempotencyKeyInvalid          = NewAppError(http.StatusBadRequest, "request.idempotency_key_invalid", "Idempotency-Key must be 1 to 255 characters")
+	ErrIdempotencyKeyInProgress       = NewAppError(http.StatusConflict, "request.idempotency_key_in_progress", "a request with this Idempotency-Key is still being processed")
+	ErrIdempotencyKeyReused           = NewAppError(http.StatusUnprocessableEntity, "request.idempotency_key_reused", "Idempotency-Key was already used for a different request")
+	ErrWebsocketMessageInvalid        = NewAppError(http.StatusBadRequest, "websocket.message_invalid", "unknown message type or topic")
+	ErrConflict                       = NewAppError(http.StatusConflict, "resource.conflict", "already exists")
+	ErrReferenceNotFound              = NewAppError(http.StatusUnprocessableEntity, "resource.reference_not_found", "refers to a missing resource")
+	ErrNotificationChannelUnavailable = NewAppError(http.StatusUnprocessableEntity, "notification.channel_unavailable", "this notification channel is not available on this server")
+
+	ErrTodoNotFound            = NewNotFoundError("todo.not_found", "todo not found")
+	ErrUserNotFound            = NewNotFoundError("user.not_found", "user not found")
+	ErrApiTokenNotFound        = NewNotFoundError("api_token.not_found", "api token not found")
+	ErrOauthClientNotFound     = NewNotFoundError("oauth_client.not_found", "oauth client not found")
+	ErrWebhookNotFound         = NewNotFoundError("webhook.not_found", "webhook not found")
+	ErrWebhookDeliveryNotFound = NewNotFoundError("webhook_delivery.not_found", "webhook delivery not found")
+	ErrSubscriptionNotFound    = NewNotFoundError("push_subscription.not_found", "push subscription not found")
+)
 
+// Internal errors are never shown to the client.
 var (
-	ErrLoginFailed        = errors.New("invalid username or password")
-	ErrNotFound           = errors.New("data not found")
-	ErrRowsNotAffected    = errors.New("no rows affected")
-	ErrorTokenInvalid     = errors.New("token invalid")
-	ErrBearerTokenMissing = errors.New("bearer token missing")
+	ErrRowsNotAffected = errors.New("no rows affected")
+	ErrJwtKeyNotFound  = errors.New("jwt signing key not found")
+	// ErrPushSubscriptionGone is a push service saying a device unsubscribed
+	// or its token expired, it won't take messages for it again.
+	ErrPushSubscriptionGone = errors.New("push subscription gone")
 )
+
+// ConstraintError is a write the database rejected because of a unique or
+// foreign key constraint. Field is the column the client has to change.
+type ConstraintError struct {
+	Field  string
+	Rule   string
+	parent *AppError
+}
+
+func NewConflictError(field string) *ConstraintError {
+	return &ConstraintError{Field: field, Rule: "unique", parent: ErrConflict}
+}
+
+func NewReferenceNotFoundError(field string) *ConstraintError {
+	return &ConstraintError{Field: field, Rule: "exists", parent: ErrReferenceNotFound}
+}
+
+func (err *ConstraintError) Error() string {
+	return err.Field + " " + err.parent.Detail
+}
+
+func (err *ConstraintError) Unwrap() error {
+	return err.parent
+}
+
+type MfaRequiredError struct {
+	MfaToken  string
+	ExpiresAt int64
+}
+
+func (err *MfaRequiredError) Error() string {
+	return "mfa required"
+}
+
+// LoginThrottledError rejects a login attempt without checking the password
+// until RetryAfter has passed.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (err *LoginThrottledError) Error() string {
+	return "too many failed login attempts"
+}
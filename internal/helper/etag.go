@@ -0,0 +1,56 @@
+package helper
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag is the strong entity tag of a resource version.
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatchVersion reads the version a write is conditioned on, 0 means any
+// version. A tag that isn't ours can never match, so it fails right away.
+func IfMatchVersion(r *http.Request, required bool) (int, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	if ifMatch == "" {
+		if required {
+			return 0, ErrPreconditionRequired
+		}
+
+		return 0, nil
+	}
+
+	if ifMatch == "*" {
+		return 0, nil
+	}
+
+	if len(ifMatch) < 2 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		return 0, ErrPreconditionFailed
+	}
+
+	version, err := strconv.Atoi(ifMatch[1 : len(ifMatch)-1])
+
+	if err != nil || version <= 0 {
+		return 0, ErrPreconditionFailed
+	}
+
+	return version, nil
+}
+
+// IfNoneMatch reports whether the client already has the etag, weak tags
+// included since this only saves a download.
+func IfNoneMatch(r *http.Request, etag string) bool {
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
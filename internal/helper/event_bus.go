@@ -0,0 +1,141 @@
+package helper
+
+import (
+	"sync"
+)
+
+const (
+	EventTodoCreated   = "todo.created"
+	EventTodoUpdated   = "todo.updated"
+	EventTodoCompleted = "todo.completed"
+	EventTodoDeleted   = "todo.deleted"
+)
+
+const (
+	// eventBufferSize is how many recent events, of all users, are kept for
+	// clients resuming with Last-Event-ID.
+	eventBufferSize = 1000
+	// eventSubscriberBuffer is how far a subscriber may fall behind before it
+	// is dropped, it can resume from the buffer after reconnecting.
+	eventSubscriberBuffer = 64
+)
+
+// Event is something that happened to one user's data. Its id is the id of
+// the outbox event it came from, the same on every instance and across
+// restarts.
+type Event struct {
+	Id     int64
+	UserId int
+	Type   string
+	Data   any
+}
+
+// EventBus delivers events to the streams open in this process. It keeps no
+// state across instances, the outbox feeds every instance's bus on its own.
+type EventBus interface {
+	Publish(eventId int64, userId int, eventType string, data any)
+	// Subscribe streams the user's events. With a lastEventId the events this
+	// bus got after it are in Backlog. Resumed is false when the bus doesn't
+	// hold that event, because it left the buffer or came before the process
+	// started.
+	Subscribe(userId int, lastEventId int64) *EventSubscription
+}
+
+type EventSubscription struct {
+	Backlog []Event
+	Resumed bool
+	// Events is closed when the subscriber falls behind or is closed.
+	Events <-chan Event
+	close  func()
+}
+
+func (subscription *EventSubscription) Close() {
+	subscription.close()
+}
+
+type MemoryEventBus struct {
+	mutex sync.Mutex
+	// buffer is in the order the events were published, which isn't always
+	// the order of their ids.
+	buffer      []Event
+	subscribers map[int]map[chan Event]bool
+}
+
+func NewMemoryEventBus() EventBus {
+	return &MemoryEventBus{
+		subscribers: map[int]map[chan Event]bool{},
+	}
+}
+
+func (bus *MemoryEventBus) Publish(eventId int64, userId int, eventType string, data any) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	event := Event{Id: eventId, UserId: userId, Type: eventType, Data: data}
+
+	if len(bus.buffer) == eventBufferSize {
+		bus.buffer = bus.buffer[1:]
+	}
+
+	bus.buffer = append(bus.buffer, event)
+
+	for events := range bus.subscribers[userId] {
+		select {
+		case events <- event:
+		default:
+			bus.unsubscribe(userId, events)
+		}
+	}
+}
+
+func (bus *MemoryEventBus) Subscribe(userId int, lastEventId int64) *EventSubscription {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	subscription := &EventSubscription{Resumed: true}
+
+	if lastEventId > 0 {
+		subscription.Resumed = false
+
+		for _, event := range bus.buffer {
+			if subscription.Resumed && event.UserId == userId {
+				subscription.Backlog = append(subscription.Backlog, event)
+			}
+
+			if event.Id == lastEventId {
+				subscription.Resumed = true
+			}
+		}
+	}
+
+	events := make(chan Event, eventSubscriberBuffer)
+
+	if bus.subscribers[userId] == nil {
+		bus.subscribers[userId] = map[chan Event]bool{}
+	}
+
+	bus.subscribers[userId][events] = true
+
+	subscription.Events = events
+	subscription.close = func() {
+		bus.mutex.Lock()
+		defer bus.mutex.Unlock()
+
+		bus.unsubscribe(userId, events)
+	}
+
+	return subscription
+}
+
+func (bus *MemoryEventBus) unsubscribe(userId int, events chan Event) {
+	if !bus.subscribers[userId][events] {
+		return
+	}
+
+	delete(bus.subscribers[userId], events)
+	close(events)
+
+	if len(bus.subscribers[userId]) == 0 {
+		delete(bus.subscribers, userId)
+	}
+}
@@ -0,0 +1,252 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+type FcmConfig struct {
+	// Endpoint is where the FCM API is, it only changes to point at a stand-in.
+	Endpoint string
+	Timeout  time.Duration
+}
+
+// FcmMessage is a notification for one device registration token. Messages
+// with the same CollapseKey replace each other while the device is offline.
+type FcmMessage struct {
+	Token       string
+	Title       string
+	Body        string
+	Data        map[string]string
+	CollapseKey string
+}
+
+type fcmCredentials struct {
+	ProjectId   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenUri    string `json:"token_uri"`
+}
+
+// FcmClient sends notifications through the Firebase Cloud Messaging HTTP v1
+// API as a service account. Access tokens are cached until shortly before
+// they expire.
+type FcmClient struct {
+	config      FcmConfig
+	credentials fcmCredentials
+	signingKey  *rsa.PrivateKey
+	httpClient  *http.Client
+
+	mutex          sync.Mutex
+	accessToken    string
+	accessTokenExp time.Time
+}
+
+// NewFcmClient takes the service account key file Firebase hands out.
+func NewFcmClient(serviceAccount []byte, config FcmConfig) (*FcmClient, error) {
+	credentials := fcmCredentials{}
+
+	if err := json.Unmarshal(serviceAccount, &credentials); err != nil {
+		return nil, fmt.Errorf("fcm service account: %w", err)
+	}
+
+	if credentials.ProjectId == "" || credentials.ClientEmail == "" || credentials.TokenUri == "" {
+		return nil, errors.New("fcm service account needs project_id, client_email and token_uri")
+	}
+
+	block, _ := pem.Decode([]byte(credentials.PrivateKey))
+
+	if block == nil {
+		return nil, errors.New("fcm service account private_key is not PEM")
+	}
+
+	parsedKey, errParse := x509.ParsePKCS8PrivateKey(block.Bytes)
+
+	if errParse != nil {
+		return nil, fmt.Errorf("fcm service account private_key: %w", errParse)
+	}
+
+	signingKey, ok := parsedKey.(*rsa.PrivateKey)
+
+	if !ok {
+		return nil, errors.New("fcm service account private_key is not RSA")
+	}
+
+	return &FcmClient{
+		config:      config,
+		credentials: credentials,
+		signingKey:  signingKey,
+		httpClient:  &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Send returns ErrPushSubscriptionGone when FCM no longer knows the token.
+func (client *FcmClient) Send(ctx context.Context, message FcmMessage) error {
+	accessToken, err := client.token(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	body, errMarshal := json.Marshal(map[string]any{
+		"message": map[string]any{
+			"token": message.Token,
+			"notification": map[string]string{
+				"title": message.Title,
+				"body":  message.Body,
+			},
+			"data": message.Data,
+			"android": map[string]any{
+				"collapse_key": message.CollapseKey,
+				"priority":     "HIGH",
+			},
+			"apns": map[string]any{
+				"headers": map[string]string{"apns-collapse-id": message.CollapseKey},
+			},
+		},
+	})
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	sendUrl := strings.TrimSuffix(client.config.Endpoint, "/") + "/v1/projects/" + url.PathEscape(client.credentials.ProjectId) + "/messages:send"
+
+	sendRequest, errRequest := http.NewRequestWithContext(ctx, http.MethodPost, sendUrl, bytes.NewReader(body))
+
+	if errRequest != nil {
+		return errRequest
+	}
+
+	sendRequest.Header.Set("Authorization", "Bearer "+accessToken)
+	sendRequest.Header.Set("Content-Type", "application/json")
+
+	sendResponse, errDo := client.httpClient.Do(sendRequest)
+
+	if errDo != nil {
+		return errDo
+	}
+
+	defer sendResponse.Body.Close()
+
+	if sendResponse.StatusCode >= 200 && sendResponse.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(sendResponse.Body, 64<<10))
+
+		return nil
+	}
+
+	fcmError := struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}{}
+
+	json.NewDecoder(io.LimitReader(sendResponse.Body, 64<<10)).Decode(&fcmError)
+
+	for _, detail := range fcmError.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return ErrPushSubscriptionGone
+		}
+	}
+
+	if sendResponse.StatusCode == http.StatusNotFound {
+		return ErrPushSubscriptionGone
+	}
+
+	if sendResponse.StatusCode == http.StatusUnauthorized {
+		client.mutex.Lock()
+		client.accessToken = ""
+		client.mutex.Unlock()
+	}
+
+	return fmt.Errorf("fcm: unexpected status %d %s", sendResponse.StatusCode, fcmError.Error.Status)
+}
+
+// token returns an access token, getting a new one with a JWT grant
+// (RFC 7523) signed by the service account when the cached one is about to
+// expire.
+func (client *FcmClient) token(ctx context.Context) (string, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.accessToken != "" && time.Now().Before(client.accessTokenExp) {
+		return client.accessToken, nil
+	}
+
+	now := time.Now()
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   client.credentials.ClientEmail,
+		"scope": fcmScope,
+		"aud":   client.credentials.TokenUri,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(client.signingKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+
+	tokenRequest, errRequest := http.NewRequestWithContext(ctx, http.MethodPost, client.credentials.TokenUri, strings.NewReader(form.Encode()))
+
+	if errRequest != nil {
+		return "", errRequest
+	}
+
+	tokenRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	tokenResponse, errDo := client.httpClient.Do(tokenRequest)
+
+	if errDo != nil {
+		return "", errDo
+	}
+
+	defer tokenResponse.Body.Close()
+
+	if tokenResponse.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fcm token endpoint responded with status %d", tokenResponse.StatusCode)
+	}
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+
+	if err := json.NewDecoder(tokenResponse.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	if token.AccessToken == "" {
+		return "", errors.New("fcm token endpoint returned no access_token")
+	}
+
+	client.accessToken = token.AccessToken
+	client.accessTokenExp = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+
+	return client.accessToken, nil
+}
@@ -0,0 +1,23 @@
+package helper
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeIdentifier is the form usernames and emails are stored and looked
+// up in: trimmed, case-folded and NFKC normalized, so "Budi", " budi " and
+// the fullwidth "Ｂｕｄｉ" are all the same user.
+func NormalizeIdentifier(identifier string) string {
+	folded := cases.Fold().String(norm.NFKC.String(strings.TrimSpace(identifier)))
+
+	// Case folding can produce sequences that NFKC composes differently.
+	return norm.NFKC.String(folded)
+}
+
+// IsEmailIdentifier tells a login by email apart from a login by username.
+func IsEmailIdentifier(identifier string) bool {
+	return strings.Contains(identifier, "@")
+}
@@ -0,0 +1,252 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"go_todo_api/internal/model/response"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const jwtIssuer = "go-todo-restful-api"
+
+// JwtKey is a single signing or verification key. Keys without a private half
+// can only verify, which is how retired keys are kept around during rotation.
+type JwtKey struct {
+	Kid        string
+	Method     jwt.SigningMethod
+	PrivateKey any
+	PublicKey  any
+}
+
+// JwtKeySet signs tokens with the active key and verifies them with any key
+// it knows about, selected by the "kid" header.
+type JwtKeySet struct {
+	signingKey JwtKey
+	keys       map[string]JwtKey
+	legacyKey  []byte
+}
+
+// NewJwtKeySet builds a key set signing with signingKid. legacyHmacKey, when
+// not empty, verifies HS256 tokens issued before key ids were introduced and
+// signs new ones when no asymmetric key is configured.
+func NewJwtKeySet(signingKid string, legacyHmacKey []byte, keys ...JwtKey) (*JwtKeySet, error) {
+	keySet := &JwtKeySet{
+		keys:      map[string]JwtKey{},
+		legacyKey: legacyHmacKey,
+	}
+
+	for _, key := range keys {
+		if key.Kid == "" {
+			return nil, fmt.Errorf("jwt key without kid")
+		}
+
+		keySet.keys[key.Kid] = key
+	}
+
+	if signingKid == "" {
+		if len(keys) > 0 {
+			return nil, fmt.Errorf("%w: no signing key id configured", ErrJwtKeyNotFound)
+		}
+
+		if len(legacyHmacKey) == 0 {
+			return nil, fmt.Errorf("%w: no keys configured", ErrJwtKeyNotFound)
+		}
+
+		keySet.signingKey = JwtKey{Method: jwt.SigningMethodHS256, PrivateKey: legacyHmacKey, PublicKey: legacyHmacKey}
+
+		return keySet, nil
+	}
+
+	signingKey, ok := keySet.keys[signingKid]
+
+	if !ok || signingKey.PrivateKey == nil {
+		return nil, fmt.Errorf("%w: %s", ErrJwtKeyNotFound, signingKid)
+	}
+
+	keySet.signingKey = signingKey
+
+	return keySet, nil
+}
+
+// LoadJwtKeySet reads every "<kid>.pem" file in dir. A file holds either a
+// PKCS#8 private key (RSA or Ed25519) or a PKIX public key for a retired kid.
+func LoadJwtKeySet(dir string, signingKid string, legacyHmacKey string) (*JwtKeySet, error) {
+	keys := []JwtKey{}
+
+	if dir != "" {
+		paths, errGlob := filepath.Glob(filepath.Join(dir, "*.pem"))
+
+		if errGlob != nil {
+			return nil, errGlob
+		}
+
+		for _, path := range paths {
+			pemBytes, errRead := os.ReadFile(path)
+
+			if errRead != nil {
+				return nil, errRead
+			}
+
+			key, errParse := ParseJwtKey(strings.TrimSuffix(filepath.Base(path), ".pem"), pemBytes)
+
+			if errParse != nil {
+				return nil, fmt.Errorf("%s: %w", path, errParse)
+			}
+
+			keys = append(keys, key)
+		}
+	}
+
+	return NewJwtKeySet(signingKid, []byte(legacyHmacKey), keys...)
+}
+
+func ParseJwtKey(kid string, pemBytes []byte) (JwtKey, error) {
+	block, _ := pem.Decode(pemBytes)
+
+	if block == nil {
+		return JwtKey{}, fmt.Errorf("no pem block found")
+	}
+
+	var privateKey any
+	var publicKey any
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+
+		if err != nil {
+			return JwtKey{}, err
+		}
+
+		signer, ok := parsedKey.(crypto.Signer)
+
+		if !ok {
+			return JwtKey{}, fmt.Errorf("unsupported private key type %T", parsedKey)
+		}
+
+		privateKey = parsedKey
+		publicKey = signer.Public()
+	case "PUBLIC KEY":
+		parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+
+		if err != nil {
+			return JwtKey{}, err
+		}
+
+		publicKey = parsedKey
+	default:
+		return JwtKey{}, fmt.Errorf("unsupported pem block %s", block.Type)
+	}
+
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return JwtKey{Kid: kid, Method: jwt.SigningMethodRS256, PrivateKey: privateKey, PublicKey: publicKey}, nil
+	case ed25519.PublicKey:
+		return JwtKey{Kid: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: privateKey, PublicKey: publicKey}, nil
+	default:
+		return JwtKey{}, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+func (keySet *JwtKeySet) GenerateJWT(sub string, exp int64, extraClaims jwt.MapClaims) (string, error) {
+	claims := jwt.MapClaims{
+		"iat": time.Now().Unix(),
+		"nbf": time.Now().Unix(),
+		"iss": jwtIssuer,
+		"sub": sub,
+		"exp": exp,
+	}
+
+	for key, value := range extraClaims {
+		claims[key] = value
+	}
+
+	token := jwt.NewWithClaims(keySet.signingKey.Method, claims)
+
+	if keySet.signingKey.Kid != "" {
+		token.Header["kid"] = keySet.signingKey.Kid
+	}
+
+	return token.SignedString(keySet.signingKey.PrivateKey)
+}
+
+func (keySet *JwtKeySet) ValidateJWT(token string) (*jwt.Token, error) {
+	validatedToken, errParseToken := jwt.Parse(token, keySet.verificationKey)
+
+	if errParseToken != nil {
+		// The parsed token is still returned so callers can read the claims of an expired token.
+		if errors.Is(errParseToken, jwt.ErrTokenExpired) {
+			return validatedToken, fmt.Errorf("%w: %w", ErrTokenExpired, errParseToken)
+		}
+
+		// Bad signatures and unknown kids are reported as an invalid token.
+		return validatedToken, fmt.Errorf("%w: %w", ErrorTokenInvalid, errParseToken)
+	}
+
+	return validatedToken, nil
+}
+
+func (keySet *JwtKeySet) verificationKey(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	if kid == "" {
+		if len(keySet.legacyKey) == 0 || t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("signing method invalid")
+		}
+
+		return keySet.legacyKey, nil
+	}
+
+	key, ok := keySet.keys[kid]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJwtKeyNotFound, kid)
+	}
+
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("signing method invalid")
+	}
+
+	return key.PublicKey, nil
+}
+
+// Jwks lists the public half of every asymmetric key, sorted by kid.
+func (keySet *JwtKeySet) Jwks() response.JwksResponse {
+	jwks := response.JwksResponse{Keys: []response.Jwk{}}
+
+	for _, key := range keySet.keys {
+		jwk := response.Jwk{Kid: key.Kid, Use: "sig", Alg: key.Method.Alg()}
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
@@ -0,0 +1,68 @@
+package helper
+
+import (
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	LocaleEnglish    = "en"
+	LocaleIndonesian = "id"
+	DefaultLocale    = LocaleEnglish
+)
+
+// ContentLanguageHeader carries the negotiated locale on the response, the
+// response writers read it back to pick the catalog.
+const ContentLanguageHeader = "Content-Language"
+
+var SupportedLocales = []string{LocaleEnglish, LocaleIndonesian}
+
+// The first tag is the fallback when nothing in Accept-Language matches.
+var localeMatcher = language.NewMatcher([]language.Tag{language.English, language.Indonesian})
+
+func IsSupportedLocale(locale string) bool {
+	for _, supportedLocale := range SupportedLocales {
+		if locale == supportedLocale {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NegotiateLocale picks the supported locale closest to an Accept-Language
+// header, e.g. "id-ID,id;q=0.9,en;q=0.8" gives "id".
+func NegotiateLocale(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+
+	_, index, confidence := localeMatcher.Match(tags...)
+
+	if confidence == language.No {
+		return DefaultLocale
+	}
+
+	return SupportedLocales[index]
+}
+
+// SetResponseLocale is ignored for unsupported locales, so a stale user
+// preference can't switch a response to a language we don't ship.
+func SetResponseLocale(w http.ResponseWriter, locale string) {
+	if IsSupportedLocale(locale) {
+		w.Header().Set(ContentLanguageHeader, locale)
+	}
+}
+
+func ResponseLocale(w http.ResponseWriter) string {
+	locale := w.Header().Get(ContentLanguageHeader)
+
+	if !IsSupportedLocale(locale) {
+		return DefaultLocale
+	}
+
+	return locale
+}
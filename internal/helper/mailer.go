@@ -0,0 +1,69 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
+
+// LogMailer prints mails instead of sending them, for development.
+type LogMailer struct {
+}
+
+func NewLogMailer() Mailer {
+	return &LogMailer{}
+}
+
+func (mailer *LogMailer) Send(ctx context.Context, mail Mail) error {
+	fmt.Printf("mail to %s: %s\n%s\n", mail.To, mail.Subject, mail.Body)
+
+	return nil
+}
+
+type SmtpMailerConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type SmtpMailer struct {
+	config SmtpMailerConfig
+}
+
+func NewSmtpMailer(config SmtpMailerConfig) Mailer {
+	return &SmtpMailer{
+		config: config,
+	}
+}
+
+func (mailer *SmtpMailer) Send(ctx context.Context, mail Mail) error {
+	var auth smtp.Auth
+
+	if mailer.config.Username != "" {
+		auth = smtp.PlainAuth("", mailer.config.Username, mailer.config.Password, mailer.config.Host)
+	}
+
+	message := strings.Join([]string{
+		"From: " + mailer.config.From,
+		"To: " + mail.To,
+		"Subject: " + mail.Subject,
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		mail.Body,
+	}, "\r\n")
+
+	return smtp.SendMail(mailer.config.Host+":"+mailer.config.Port, auth, mailer.config.From, []string{mail.To}, []byte(message))
+}
@@ -0,0 +1,177 @@
+package helper
+
+// messageCatalogs hold every message shown to clients, keyed by message id.
+// Errors are keyed by their code, the Detail of an AppError is only the
+// fallback for codes missing from a catalog.
+var messageCatalogs = map[string]map[string]string{
+	LocaleEnglish: {
+		"user.created":                  "new user created",
+		"user.found":                    "user found",
+		"user.email_changed":            "email changed",
+		"user.email_change_reverted":    "email change reverted",
+		"users.found":                   "users found",
+		"todo.created":                  "new todo created",
+		"todo.found":                    "todo found",
+		"todo.updated":                  "todo updated",
+		"todos.found":                   "todos found",
+		"auth.login_success":            "login success",
+		"auth.token_refreshed":          "refresh token success",
+		"auth.mfa_required":             "mfa required",
+		"mfa.totp_enrollment_started":   "totp enrollment started",
+		"mfa.totp_enabled":              "totp enabled",
+		"api_token.created":             "new api token created",
+		"api_tokens.found":              "api tokens found",
+		"oauth_client.created":          "new oauth client registered",
+		"oauth_clients.found":           "oauth clients found",
+		"webhook.created":               "new webhook registered",
+		"webhooks.found":                "webhooks found",
+		"webhook.enabled":               "webhook enabled",
+		"webhook_deliveries.found":      "webhook deliveries found",
+		"webhook_delivery.created":      "webhook delivery queued",
+		"notification_settings.found":   "notification settings found",
+		"notification_settings.updated": "notification settings updated",
+		"push_subscription.created":     "push subscription registered",
+		"push_subscriptions.found":      "push subscriptions found",
+		"oauth.consent_required":        "consent required",
+		"oauth.authorization_completed": "authorization completed",
+		"sync.changes_found":            "changes found",
+		"sync.mutations_processed":      "mutations processed",
+
+		"internal_error":                      "internal server error",
+		"request.validation_failed":           "validation error",
+		"request.malformed_body":              "malformed request body",
+		"request.invalid_parameter":           "invalid path parameter",
+		"request.precondition_failed":         "resource was modified, fetch it again",
+		"request.precondition_required":       "If-Match header required",
+		"resource.not_found":                  "data not found",
+		"sync.token_invalid":                  "invalid sync token",
+		"request.idempotency_key_invalid":     "Idempotency-Key must be 1 to 255 characters",
+		"request.idempotency_key_in_progress": "a request with this Idempotency-Key is still being processed",
+		"request.idempotency_key_reused":      "Idempotency-Key was already used for a different request",
+		"websocket.message_invalid":           "unknown message type or topic",
+		"resource.conflict":                   "already exists",
+		"resource.reference_not_found":        "refers to a missing resource",
+		"auth.login_failed":                   "invalid username or password",
+		"auth.login_throttled":                "too many failed login attempts",
+		"auth.token_invalid":                  "token invalid",
+		"auth.token_expired":                  "token expired",
+		"auth.token_missing":                  "bearer token missing",
+		"auth.forbidden":                      "forbidden",
+		"auth.account_disabled":               "account disabled",
+		"mfa.code_invalid":                    "invalid mfa code",
+		"mfa.already_enabled":                 "two-factor authentication already enabled",
+		"mfa.not_enabled":                     "two-factor authentication not enabled",
+		"oidc.login_failed":                   "oidc login failed",
+		"oidc.email_conflict":                 "an account with this email already exists, sign in with a password to link it",
+		"user.email_taken":                    "email address already in use",
+		"user.not_found":                      "user not found",
+		"todo.not_found":                      "todo not found",
+		"api_token.not_found":                 "api token not found",
+		"oauth_client.not_found":              "oauth client not found",
+		"webhook.not_found":                   "webhook not found",
+		"webhook_delivery.not_found":          "webhook delivery not found",
+		"push_subscription.not_found":         "push subscription not found",
+		"notification.channel_unavailable":    "this notification channel is not available on this server",
+	},
+	LocaleIndonesian: {
+		"user.created":                  "user baru dibuat",
+		"user.found":                    "user ditemukan",
+		"user.email_changed":            "email diubah",
+		"user.email_change_reverted":    "perubahan email dibatalkan",
+		"users.found":                   "user ditemukan",
+		"todo.created":                  "todo baru dibuat",
+		"todo.found":                    "todo ditemukan",
+		"todo.updated":                  "todo diperbarui",
+		"todos.found":                   "todo ditemukan",
+		"auth.login_success":            "login berhasil",
+		"auth.token_refreshed":          "refresh token berhasil",
+		"auth.mfa_required":             "mfa diperlukan",
+		"mfa.totp_enrollment_started":   "pendaftaran totp dimulai",
+		"mfa.totp_enabled":              "totp diaktifkan",
+		"api_token.created":             "api token baru dibuat",
+		"api_tokens.found":              "api token ditemukan",
+		"oauth_client.created":          "oauth client baru didaftarkan",
+		"oauth_clients.found":           "oauth client ditemukan",
+		"webhook.created":               "webhook baru didaftarkan",
+		"webhooks.found":                "webhook ditemukan",
+		"webhook.enabled":               "webhook diaktifkan",
+		"webhook_deliveries.found":      "pengiriman webhook ditemukan",
+		"webhook_delivery.created":      "pengiriman webhook diantrekan",
+		"notification_settings.found":   "pengaturan notifikasi ditemukan",
+		"notification_settings.updated": "pengaturan notifikasi diperbarui",
+		"push_subscription.created":     "langganan push didaftarkan",
+		"push_subscriptions.found":      "langganan push ditemukan",
+		"oauth.consent_required":        "persetujuan diperlukan",
+		"oauth.authorization_completed": "otorisasi selesai",
+		"sync.changes_found":            "perubahan ditemukan",
+		"sync.mutations_processed":      "mutasi diproses",
+
+		"internal_error":                      "terjadi kesalahan pada server",
+		"request.validation_failed":           "validasi gagal",
+		"request.malformed_body":              "body request tidak valid",
+		"request.invalid_parameter":           "parameter path tidak valid",
+		"request.precondition_failed":         "data sudah diubah, ambil ulang terlebih dahulu",
+		"request.precondition_required":       "header If-Match wajib diisi",
+		"resource.not_found":                  "data tidak ditemukan",
+		"sync.token_invalid":                  "sync token tidak valid",
+		"request.idempotency_key_invalid":     "Idempotency-Key harus terdiri dari 1 sampai 255 karakter",
+		"request.idempotency_key_in_progress": "request dengan Idempotency-Key ini masih diproses",
+		"request.idempotency_key_reused":      "Idempotency-Key sudah dipakai untuk request yang berbeda",
+		"websocket.message_invalid":           "tipe pesan atau topik tidak dikenal",
+		"resource.conflict":                   "sudah digunakan",
+		"resource.reference_not_found":        "merujuk ke data yang tidak ada",
+		"auth.login_failed":                   "username atau password salah",
+		"auth.login_throttled":                "terlalu banyak percobaan login yang gagal",
+		"auth.token_invalid":                  "token tidak valid",
+		"auth.token_expired":                  "token kedaluwarsa",
+		"auth.token_missing":                  "bearer token tidak ada",
+		"auth.forbidden":                      "akses ditolak",
+		"auth.account_disabled":               "akun dinonaktifkan",
+		"mfa.code_invalid":                    "kode mfa tidak valid",
+		"mfa.already_enabled":                 "autentikasi dua faktor sudah aktif",
+		"mfa.not_enabled":                     "autentikasi dua faktor belum aktif",
+		"oidc.login_failed":                   "login oidc gagal",
+		"oidc.email_conflict":                 "akun dengan email ini sudah ada, masuk dengan password untuk menautkannya",
+		"user.email_taken":                    "alamat email sudah digunakan",
+		"user.not_found":                      "user tidak ditemukan",
+		"todo.not_found":                      "todo tidak ditemukan",
+		"api_token.not_found":                 "api token tidak ditemukan",
+		"oauth_client.not_found":              "oauth client tidak ditemukan",
+		"webhook.not_found":                   "webhook tidak ditemukan",
+		"webhook_delivery.not_found":          "pengiriman webhook tidak ditemukan",
+		"push_subscription.not_found":         "langganan push tidak ditemukan",
+		"notification.channel_unavailable":    "saluran notifikasi ini tidak tersedia di server ini",
+	},
+}
+
+func lookupMessage(locale string, messageId string) (string, bool) {
+	if message, ok := messageCatalogs[locale][messageId]; ok {
+		return message, true
+	}
+
+	message, ok := messageCatalogs[DefaultLocale][messageId]
+
+	return message, ok
+}
+
+// Message translates a message id, falling back to English and then to the
+// id itself.
+func Message(locale string, messageId string) string {
+	if message, ok := lookupMessage(locale, messageId); ok {
+		return message
+	}
+
+	return messageId
+}
+
+// MessageIds lists the ids of a catalog, for checking catalogs against each
+// other.
+func MessageIds(locale string) []string {
+	messageIds := make([]string, 0, len(messageCatalogs[locale]))
+
+	for messageId := range messageCatalogs[locale] {
+		messageIds = append(messageIds, messageId)
+	}
+
+	return messageIds
+}
@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	OauthAccessTokenPrefix  = "gto_"
+	OauthRefreshTokenPrefix = "gtr_"
+	OauthClientSecretPrefix = "gtcs_"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// OauthError is an error reported to oauth clients with one of the error codes
+// from RFC 6749, e.g. invalid_grant or invalid_client.
+type OauthError struct {
+	Code        string
+	Description string
+}
+
+func (err *OauthError) Error() string {
+	return err.Code + ": " + err.Description
+}
+
+func NewOauthError(code string, description string) error {
+	return &OauthError{Code: code, Description: description}
+}
+
+func GenerateOauthToken(prefix string) (string, error) {
+	raw := make([]byte, 32)
+
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return prefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func IsOauthAccessToken(token string) bool {
+	return strings.HasPrefix(token, OauthAccessTokenPrefix)
+}
+
+// WriteOauthResponse writes a token endpoint response, which RFC 6749 defines
+// as a bare JSON object rather than the standard response envelope.
+func WriteOauthResponse(w http.ResponseWriter, data any, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err == nil {
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+
+	var oauthError *OauthError
+
+	if !errors.As(err, &oauthError) {
+		logInternalError(w.Header().Get(RequestIdHeader), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "server_error"})
+		return
+	}
+
+	if oauthError.Code == "invalid_client" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"error": oauthError.Code, "error_description": oauthError.Description})
+}
@@ -0,0 +1,355 @@
+package helper
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidcJwksRefreshInterval limits how often an unknown kid triggers a refetch
+// of the provider's keys.
+const oidcJwksRefreshInterval = time.Minute
+
+type OidcProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
+type OidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// OidcProvider is an OpenID Connect relying party for a single provider. The
+// discovery document and signing keys are fetched on first use and cached.
+type OidcProvider struct {
+	config     OidcProviderConfig
+	httpClient *http.Client
+
+	mutex                 sync.Mutex
+	authorizationEndpoint string
+	tokenEndpoint         string
+	jwksUri               string
+	keys                  map[string]any
+	keysFetchedAt         time.Time
+}
+
+type OidcProviders map[string]*OidcProvider
+
+func NewOidcProvider(config OidcProviderConfig, httpClient *http.Client) *OidcProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &OidcProvider{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+func (provider *OidcProvider) Name() string {
+	return provider.config.Name
+}
+
+func GenerateOidcState() (string, error) {
+	randomBytes := make([]byte, 32)
+
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// PkceChallenge derives the S256 code challenge sent with the authorization request.
+func PkceChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (provider *OidcProvider) AuthCodeUrl(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	if err := provider.discover(ctx); err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.config.ClientId},
+		"redirect_uri":          {provider.config.RedirectUrl},
+		"scope":                 {strings.Join(provider.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {PkceChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+
+	if strings.Contains(provider.authorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return provider.authorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades an authorization code for the provider's raw ID token.
+func (provider *OidcProvider) Exchange(ctx context.Context, code string, codeVerifier string) (string, error) {
+	if err := provider.discover(ctx); err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.config.RedirectUrl},
+		"client_id":     {provider.config.ClientId},
+		"client_secret": {provider.config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+
+	httpRequest, errRequest := http.NewRequestWithContext(ctx, http.MethodPost, provider.tokenEndpoint, strings.NewReader(form.Encode()))
+
+	if errRequest != nil {
+		return "", errRequest
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpRequest.Header.Set("Accept", "application/json")
+
+	httpResponse, errDo := provider.httpClient.Do(httpRequest)
+
+	if errDo != nil {
+		return "", errDo
+	}
+
+	defer httpResponse.Body.Close()
+
+	tokenResponse := struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+
+	if err := json.NewDecoder(httpResponse.Body).Decode(&tokenResponse); err != nil {
+		return "", err
+	}
+
+	if tokenResponse.Error != "" {
+		return "", fmt.Errorf("%w: %s %s", ErrOidcLoginFailed, tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+
+	if httpResponse.StatusCode != http.StatusOK || tokenResponse.IdToken == "" {
+		return "", fmt.Errorf("%w: token endpoint returned %d without an id token", ErrOidcLoginFailed, httpResponse.StatusCode)
+	}
+
+	return tokenResponse.IdToken, nil
+}
+
+// VerifyIdToken checks the signature against the provider's JWKS along with
+// the issuer, audience, expiry and nonce of the ID token.
+func (provider *OidcProvider) VerifyIdToken(ctx context.Context, rawIdToken string, nonce string) (OidcClaims, error) {
+	claims := OidcClaims{}
+
+	_, errParse := jwt.ParseWithClaims(rawIdToken, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return provider.verificationKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(provider.config.Issuer),
+		jwt.WithAudience(provider.config.ClientId),
+		jwt.WithExpirationRequired(),
+	)
+
+	if errParse != nil {
+		return OidcClaims{}, fmt.Errorf("%w: %w", ErrOidcLoginFailed, errParse)
+	}
+
+	if claims.Subject == "" {
+		return OidcClaims{}, fmt.Errorf("%w: id token has no subject", ErrOidcLoginFailed)
+	}
+
+	if claims.Nonce != nonce {
+		return OidcClaims{}, fmt.Errorf("%w: nonce mismatch", ErrOidcLoginFailed)
+	}
+
+	return claims, nil
+}
+
+func (provider *OidcProvider) discover(ctx context.Context) error {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.tokenEndpoint != "" {
+		return nil
+	}
+
+	discovery := struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JwksUri               string `json:"jwks_uri"`
+	}{}
+
+	discoveryUrl := strings.TrimSuffix(provider.config.Issuer, "/") + "/.well-known/openid-configuration"
+
+	if err := provider.getJson(ctx, discoveryUrl, &discovery); err != nil {
+		return err
+	}
+
+	if discovery.Issuer != provider.config.Issuer {
+		return fmt.Errorf("oidc discovery issuer %s does not match %s", discovery.Issuer, provider.config.Issuer)
+	}
+
+	provider.authorizationEndpoint = discovery.AuthorizationEndpoint
+	provider.tokenEndpoint = discovery.TokenEndpoint
+	provider.jwksUri = discovery.JwksUri
+
+	return nil
+}
+
+func (provider *OidcProvider) verificationKey(ctx context.Context, kid string) (any, error) {
+	if err := provider.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if key, ok := provider.keys[kid]; ok {
+		return key, nil
+	}
+
+	// The provider may have rotated its keys since the last fetch.
+	if time.Since(provider.keysFetchedAt) < oidcJwksRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %s", kid)
+	}
+
+	jwks := struct {
+		Keys []oidcJwk `json:"keys"`
+	}{}
+
+	if err := provider.getJson(ctx, provider.jwksUri, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]any{}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := parseJwkPublicKey(jwk)
+
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	provider.keys = keys
+	provider.keysFetchedAt = time.Now()
+
+	if key, ok := provider.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown key id %s", kid)
+}
+
+func (provider *OidcProvider) getJson(ctx context.Context, url string, target any) error {
+	httpRequest, errRequest := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if errRequest != nil {
+		return errRequest
+	}
+
+	httpResponse, errDo := provider.httpClient.Do(httpRequest)
+
+	if errDo != nil {
+		return errDo
+	}
+
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, httpResponse.StatusCode)
+	}
+
+	return json.NewDecoder(httpResponse.Body).Decode(target)
+}
+
+type oidcJwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJwkPublicKey(jwk oidcJwk) (any, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch jwk.Kty {
+	case "RSA":
+		n, errN := decode(jwk.N)
+		e, errE := decode(jwk.E)
+
+		if errN != nil || errE != nil {
+			return nil, fmt.Errorf("invalid rsa jwk")
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[jwk.Crv]
+
+		x, errX := decode(jwk.X)
+		y, errY := decode(jwk.Y)
+
+		if !ok || errX != nil || errY != nil {
+			return nil, fmt.Errorf("invalid ec jwk")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, errX := decode(jwk.X)
+
+		if jwk.Crv != "Ed25519" || errX != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid okp jwk")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported jwk type %s", jwk.Kty)
+	}
+}
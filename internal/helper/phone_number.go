@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// DefaultPhoneRegion is the country assumed for numbers written without a
+// country code, like "0812...".
+const DefaultPhoneRegion = "ID"
+
+// NormalizePhoneNumber formats a number as E.164. Input that can't be parsed
+// is only trimmed, so validation can still reject it.
+func NormalizePhoneNumber(phoneNumber string) string {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+
+	parsed, err := phonenumbers.Parse(phoneNumber, DefaultPhoneRegion)
+
+	if err != nil {
+		return phoneNumber
+	}
+
+	return phonenumbers.Format(parsed, phonenumbers.E164)
+}
+
+func IsValidPhoneNumber(phoneNumber string) bool {
+	parsed, err := phonenumbers.Parse(phoneNumber, DefaultPhoneRegion)
+
+	if err != nil {
+		return false
+	}
+
+	return phonenumbers.IsValidNumber(parsed)
+}
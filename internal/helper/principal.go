@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	ScopeTodosRead  = "todos:read"
+	ScopeTodosWrite = "todos:write"
+	ScopeUserRead   = "user:read"
+	ScopeUserWrite  = "user:write"
+)
+
+var AllScopes = []string{ScopeTodosRead, ScopeTodosWrite, ScopeUserRead, ScopeUserWrite}
+
+const (
+	AuthMethodSession  = "session"
+	AuthMethodApiToken = "api_token"
+	AuthMethodOauth    = "oauth"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Principal is the authenticated caller, whatever credential was used to
+// authenticate the request. ClientId is only set for oauth tokens, and a
+// client_credentials token has no UserId. Locale is the user's preferred
+// locale, empty when they haven't picked one.
+type Principal struct {
+	UserId     int
+	Username   string
+	Locale     string
+	AuthMethod string
+	ClientId   string
+	Scopes     []string
+	Roles      []string
+}
+
+func (principal Principal) HasScope(scope string) bool {
+	for _, principalScope := range principal.Scopes {
+		if principalScope == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (principal Principal) HasRole(role string) bool {
+	for _, principalRole := range principal.Roles {
+		if principalRole == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (principal Principal) CheckScope(scope string) error {
+	if !principal.HasScope(scope) {
+		return fmt.Errorf("%w: missing required scope %s", ErrForbidden, scope)
+	}
+
+	return nil
+}
+
+type principalContextKey struct{}
+
+func SetPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+func GetPrincipal(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+
+	return principal, ok
+}
+
+// ScopesSubset reports whether every scope in requested is in allowed.
+func ScopesSubset(requested []string, allowed []string) bool {
+	for _, scope := range requested {
+		found := false
+
+		for _, allowedScope := range allowed {
+			if scope == allowedScope {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RolesFor expands a user's stored role, an admin is also a regular user.
+func RolesFor(role string) []string {
+	if role == RoleAdmin {
+		return []string{RoleUser, RoleAdmin}
+	}
+
+	return []string{RoleUser}
+}
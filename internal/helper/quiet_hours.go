@@ -0,0 +1,44 @@
+package helper
+
+import "time"
+
+// QuietHoursDelay is how long until quiet hours from start to end, "15:04"
+// times in timezone, are over, 0 when now is outside them or there are none.
+// Quiet hours like 22:00 to 07:00 span midnight.
+func QuietHoursDelay(start string, end string, timezone string, now time.Time) time.Duration {
+	startTime, errStart := time.Parse("15:04", start)
+	endTime, errEnd := time.Parse("15:04", end)
+
+	if errStart != nil || errEnd != nil || startTime.Equal(endTime) {
+		return 0
+	}
+
+	location, errLocation := time.LoadLocation(timezone)
+
+	if errLocation != nil {
+		location = time.UTC
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := startTime.Hour()*60 + startTime.Minute()
+	endMinute := endTime.Hour()*60 + endTime.Minute()
+
+	quiet := startMinute <= minute && minute < endMinute
+
+	if startMinute > endMinute {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+
+	if !quiet {
+		return 0
+	}
+
+	endsAt := time.Date(local.Year(), local.Month(), local.Day(), endTime.Hour(), endTime.Minute(), 0, 0, location)
+
+	if !endsAt.After(local) {
+		endsAt = time.Date(local.Year(), local.Month(), local.Day()+1, endTime.Hour(), endTime.Minute(), 0, 0, location)
+	}
+
+	return endsAt.Sub(now)
+}
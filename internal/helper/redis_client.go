@@ -0,0 +1,182 @@
+package helper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisError is an error reply, the command reached Redis and was refused.
+type RedisError string
+
+func (err RedisError) Error() string {
+	return string(err)
+}
+
+// RedisClient speaks just enough of the Redis protocol to run commands one
+// at a time over a single connection, which is all publishing events needs.
+type RedisClient struct {
+	address  string
+	password string
+	timeout  time.Duration
+	mutex    sync.Mutex
+	conn     net.Conn
+	reader   *bufio.Reader
+}
+
+func NewRedisClient(address string, password string) *RedisClient {
+	return &RedisClient{
+		address:  address,
+		password: password,
+		timeout:  5 * time.Second,
+	}
+}
+
+// Do runs a command and returns its reply, a string, an int64, nil, or a
+// []any of those. A connection that broke is dialed again on the next
+// command.
+func (client *RedisClient) Do(ctx context.Context, args ...string) (any, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.conn == nil {
+		if err := client.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := client.do(ctx, args)
+
+	var redisError RedisError
+
+	if err != nil && !errors.As(err, &redisError) {
+		client.conn.Close()
+		client.conn = nil
+	}
+
+	return reply, err
+}
+
+func (client *RedisClient) Close() error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.conn == nil {
+		return nil
+	}
+
+	err := client.conn.Close()
+	client.conn = nil
+
+	return err
+}
+
+func (client *RedisClient) dial(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: client.timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", client.address)
+
+	if err != nil {
+		return err
+	}
+
+	client.conn = conn
+	client.reader = bufio.NewReader(conn)
+
+	if client.password == "" {
+		return nil
+	}
+
+	if _, err := client.do(ctx, []string{"AUTH", client.password}); err != nil {
+		client.conn.Close()
+		client.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (client *RedisClient) do(ctx context.Context, args []string) (any, error) {
+	deadline, ok := ctx.Deadline()
+
+	if !ok {
+		deadline = time.Now().Add(client.timeout)
+	}
+
+	client.conn.SetDeadline(deadline)
+
+	command := strings.Builder{}
+	command.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+
+	for _, arg := range args {
+		command.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+
+	if _, err := io.WriteString(client.conn, command.String()); err != nil {
+		return nil, err
+	}
+
+	return client.readReply()
+}
+
+func (client *RedisClient) readReply() (any, error) {
+	line, err := client.reader.ReadString('\n')
+
+	if err != nil {
+		return nil, err
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+
+	if line == "" {
+		return nil, fmt.Errorf("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, RedisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		length, errLength := strconv.Atoi(line[1:])
+
+		if errLength != nil || length < 0 {
+			return nil, errLength
+		}
+
+		data := make([]byte, length+2)
+
+		if _, err := io.ReadFull(client.reader, data); err != nil {
+			return nil, err
+		}
+
+		return string(data[:length]), nil
+	case '*':
+		length, errLength := strconv.Atoi(line[1:])
+
+		if errLength != nil || length < 0 {
+			return nil, errLength
+		}
+
+		replies := make([]any, length)
+
+		for i := range replies {
+			if replies[i], err = client.readReply(); err != nil {
+				return nil, err
+			}
+		}
+
+		return replies, nil
+	default:
+		return nil, fmt.Errorf("unknown redis reply %q", line)
+	}
+}
@@ -0,0 +1,38 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestId keeps the id a proxy in front of the app already assigned, as
+// long as it is safe to log, and makes a new one otherwise.
+func RequestId(incoming string) string {
+	if requestIdPattern.MatchString(incoming) {
+		return incoming
+	}
+
+	raw := make([]byte, 16)
+
+	if _, err := rand.Read(raw); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(raw)
+}
+
+// logInternalError keeps the details of an error the client only sees as a
+// 500, the request id in the response is the way to find them.
+func logInternalError(requestId string, err error) {
+	logrus.WithFields(logrus.Fields{
+		"request_id": requestId,
+		"error":      err.Error(),
+	}).Error("internal server error")
+}
@@ -2,6 +2,8 @@ package helper
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 )
 
@@ -11,8 +13,20 @@ func ReadRequestBody(r *http.Request, v any) error {
 	err := decoder.Decode(v)
 
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrMalformedBody, err)
 	}
 
 	return nil
 }
+
+// ClientIp is the address the request came from. X-Forwarded-For is ignored
+// on purpose, any client can set it to dodge per-ip limits.
+func ClientIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -8,9 +8,11 @@ import (
 
 type ResponseData struct {
 	StatusCode int
-	Message    string
-	Data       any
-	Err        error
+	// Message is a message id, it is translated into the locale negotiated
+	// for the response.
+	Message string
+	Data    any
+	Err     error
 }
 
 func WriteResponse(w http.ResponseWriter, responseData ResponseData) error {
@@ -21,7 +23,7 @@ func WriteResponse(w http.ResponseWriter, responseData ResponseData) error {
 	}
 
 	standardResponse := response.StandardResponse{
-		Message: responseData.Message,
+		Message: Message(ResponseLocale(w), responseData.Message),
 		Data:    responseData.Data,
 	}
 
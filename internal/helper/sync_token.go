@@ -0,0 +1,62 @@
+package helper
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const syncTokenPrefix = "seq:"
+
+// EncodeSyncToken wraps a change sequence number. Clients must treat the
+// token as opaque, so the format can change without breaking them.
+func EncodeSyncToken(changeSeq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(changeSeq, 10)))
+}
+
+// DecodeSyncToken reads a token from EncodeSyncToken, an empty token starts
+// from the beginning.
+func DecodeSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+
+	if err != nil {
+		return 0, ErrSyncTokenInvalid
+	}
+
+	changeSeq, err := strconv.ParseInt(strings.TrimPrefix(string(decoded), syncTokenPrefix), 10, 64)
+
+	if err != nil || changeSeq < 0 || !strings.HasPrefix(string(decoded), syncTokenPrefix) {
+		return 0, ErrSyncTokenInvalid
+	}
+
+	return changeSeq, nil
+}
+
+// NewUuid makes the public identifier of a todo created by the server.
+// Version 7 uuids start with a timestamp, so they index like increasing ids.
+func NewUuid() (string, error) {
+	id, err := uuid.NewV7()
+
+	if err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
+
+// IsUuidV7 accepts the 36 character form of a version 7 uuid, in either case.
+func IsUuidV7(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+
+	id, err := uuid.Parse(value)
+
+	return err == nil && id.Version() == 7
+}
@@ -0,0 +1,30 @@
+package helper
+
+import "time"
+
+// ParseTimestamp reads an RFC 3339 time as unix seconds, 0 for an empty or
+// invalid one. Times clients send are kept as unix seconds, so they mean the
+// same whatever time zone the database session is in.
+func ParseTimestamp(value string) int64 {
+	if value == "" {
+		return 0
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return 0
+	}
+
+	return parsed.Unix()
+}
+
+// FormatTimestamp writes unix seconds as an RFC 3339 time in UTC, 0 as an
+// empty string.
+func FormatTimestamp(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
@@ -1,38 +1,40 @@
 package helper
 
 import (
-	"os"
-	"time"
-
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/joho/godotenv"
 )
 
-func GenerateJWT(sub string, exp int64) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"iat": time.Now().Unix(),
-			"nbf": time.Now().Unix(),
-			"iss": "go-todo-restful-api",
-			"sub": sub,
-			"exp": exp,
-		})
+const TokenTypeMfa = "mfa"
+
+func GetTokenType(token *jwt.Token) string {
+	if token == nil {
+		return ""
+	}
 
-	errEnvLoad := godotenv.Load("config.env")
+	claims, ok := token.Claims.(jwt.MapClaims)
 
-	if errEnvLoad != nil {
-		return "", errEnvLoad
+	if !ok {
+		return ""
 	}
 
-	key := os.Getenv("JWT_KEY")
+	tokenType, _ := claims["typ"].(string)
 
-	keyByte := []byte(key)
+	return tokenType
+}
 
-	tokenStr, errSignToken := token.SignedString(keyByte)
+// GetTokenVersion reads the "ver" claim, tokens issued before versioning count as version 0.
+func GetTokenVersion(token *jwt.Token) int {
+	if token == nil {
+		return 0
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
 
-	if errSignToken != nil {
-		return "", errSignToken
+	if !ok {
+		return 0
 	}
 
-	return tokenStr, nil
+	version, _ := claims["ver"].(float64)
+
+	return int(version)
 }
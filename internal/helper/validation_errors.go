@@ -0,0 +1,130 @@
+package helper
+
+import (
+	"go_todo_api/internal/model/response"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	idtranslations "github.com/go-playground/validator/v10/translations/id"
+)
+
+var validationTranslators = ut.New(en.New(), en.New(), id.New())
+
+// customValidationMessages are the messages of the rules this app registers
+// itself, and of the built in ones the validator has no message for in that
+// locale. The rest come with the validator.
+var customValidationMessages = map[string]map[string]string{
+	LocaleEnglish: {
+		"phone":         "{0} must be a valid phone number",
+		"uuid7":         "{0} must be a version 7 UUID",
+		"http_url":      "{0} must be an http or https URL",
+		"timezone":      "{0} must be an IANA time zone like Asia/Jakarta",
+		"required_with": "{0} is required when the field it goes with is set",
+		"base64rawurl":  "{0} must be base64url without padding",
+	},
+	LocaleIndonesian: {
+		"phone":         "{0} harus berupa nomor telepon yang valid",
+		"uuid7":         "{0} harus berupa UUID versi 7",
+		"http_url":      "{0} harus berupa URL http atau https",
+		"timezone":      "{0} harus berupa zona waktu IANA seperti Asia/Jakarta",
+		"required_with": "{0} wajib diisi jika kolom pasangannya diisi",
+		"required_if":   "{0} wajib diisi",
+		"base64rawurl":  "{0} harus berupa base64url tanpa padding",
+		"datetime":      "{0} tidak sesuai format yang diminta",
+	},
+}
+
+// RegisterValidationTranslations names fields after their JSON key and
+// registers the messages used by FieldErrors in every supported locale.
+func RegisterValidationTranslations(validate *validator.Validate) error {
+	validate.RegisterTagNameFunc(jsonFieldName)
+
+	enTranslator := validationTranslator(LocaleEnglish)
+
+	if err := entranslations.RegisterDefaultTranslations(validate, enTranslator); err != nil {
+		return err
+	}
+
+	idTranslator := validationTranslator(LocaleIndonesian)
+
+	if err := idtranslations.RegisterDefaultTranslations(validate, idTranslator); err != nil {
+		return err
+	}
+
+	for locale, messages := range customValidationMessages {
+		for tag, message := range messages {
+			if err := registerValidationMessage(validate, validationTranslator(locale), tag, message); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validationTranslator(locale string) ut.Translator {
+	translator, _ := validationTranslators.GetTranslator(locale)
+
+	return translator
+}
+
+func registerValidationMessage(validate *validator.Validate, translator ut.Translator, tag string, message string) error {
+	return validate.RegisterTranslation(tag, translator, func(translator ut.Translator) error {
+		return translator.Add(tag, message, true)
+	}, func(translator ut.Translator, fieldError validator.FieldError) string {
+		translated, _ := translator.T(tag, fieldError.Field())
+		return translated
+	})
+}
+
+// jsonFieldName falls back to the snake case field name, which is what
+// clients send for fields without a json tag.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	if name != "" && name != "-" {
+		return name
+	}
+
+	return snakeCase(field.Name)
+}
+
+func snakeCase(name string) string {
+	builder := strings.Builder{}
+
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				builder.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+func FieldErrors(validationErrors validator.ValidationErrors, locale string) []response.FieldErrorResponse {
+	translator := validationTranslator(locale)
+
+	fieldErrors := make([]response.FieldErrorResponse, 0, len(validationErrors))
+
+	for _, fieldError := range validationErrors {
+		fieldErrors = append(fieldErrors, response.FieldErrorResponse{
+			Field:   fieldError.Field(),
+			Rule:    fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Message: fieldError.Translate(translator),
+		})
+	}
+
+	return fieldErrors
+}
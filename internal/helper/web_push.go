@@ -0,0 +1,263 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+// webPushRecordSize is the one record an encrypted message is sent in, what
+// push services accept at most.
+const webPushRecordSize = 4096
+
+// WebPushMaxPayload is the most a message can carry once the header, padding
+// delimiter and tag are taken off the record.
+const WebPushMaxPayload = webPushRecordSize - 86 - 1 - 16
+
+type WebPushConfig struct {
+	// VapidPrivateKey is the raw P-256 private key, base64url encoded, that
+	// identifies this server to push services.
+	VapidPrivateKey string
+	// Subject is a mailto: or https: URL push services can reach the
+	// operator at.
+	Subject string
+	Timeout time.Duration
+	// Ttl is how long a push service keeps a message for a device that is
+	// offline.
+	Ttl time.Duration
+	// AllowPrivateNetworks lets subscriptions point at loopback and private
+	// addresses, which is only safe when every user is trusted.
+	AllowPrivateNetworks bool
+}
+
+// WebPushMessage is a payload for one browser subscription. Messages with the
+// same Topic replace each other while still queued at the push service.
+type WebPushMessage struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+	Topic    string
+	Payload  []byte
+}
+
+// WebPushClient sends Web Push messages (RFC 8030), encrypted for the
+// subscription (RFC 8291) and signed with VAPID (RFC 8292).
+type WebPushClient struct {
+	config     WebPushConfig
+	signingKey *ecdsa.PrivateKey
+	publicKey  string
+	httpClient *http.Client
+}
+
+func NewWebPushClient(config WebPushConfig) (*WebPushClient, error) {
+	rawKey, err := base64.RawURLEncoding.DecodeString(config.VapidPrivateKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("vapid private key is not base64url: %w", err)
+	}
+
+	privateKey, errKey := ecdh.P256().NewPrivateKey(rawKey)
+
+	if errKey != nil {
+		return nil, fmt.Errorf("vapid private key: %w", errKey)
+	}
+
+	publicKey := privateKey.PublicKey().Bytes()
+
+	signingKey := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(publicKey[1:33]),
+			Y:     new(big.Int).SetBytes(publicKey[33:]),
+		},
+		D: new(big.Int).SetBytes(rawKey),
+	}
+
+	return &WebPushClient{
+		config:     config,
+		signingKey: signingKey,
+		publicKey:  base64.RawURLEncoding.EncodeToString(publicKey),
+		httpClient: NewPublicHttpClient(config.Timeout, config.AllowPrivateNetworks),
+	}, nil
+}
+
+// PublicKey is the applicationServerKey browsers subscribe with.
+func (client *WebPushClient) PublicKey() string {
+	return client.publicKey
+}
+
+// Send returns ErrPushSubscriptionGone when the push service no longer knows
+// the subscription.
+func (client *WebPushClient) Send(ctx context.Context, message WebPushMessage) error {
+	endpoint, err := url.Parse(message.Endpoint)
+
+	if err != nil || endpoint.Host == "" {
+		return fmt.Errorf("invalid web push endpoint")
+	}
+
+	body, errEncrypt := EncryptWebPush(message.P256dh, message.Auth, message.Payload)
+
+	if errEncrypt != nil {
+		return errEncrypt
+	}
+
+	authorization, errVapid := client.vapid(endpoint.Scheme + "://" + endpoint.Host)
+
+	if errVapid != nil {
+		return errVapid
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, client.config.Timeout)
+	defer cancel()
+
+	pushRequest, errRequest := http.NewRequestWithContext(ctx, http.MethodPost, message.Endpoint, bytes.NewReader(body))
+
+	if errRequest != nil {
+		return errRequest
+	}
+
+	pushRequest.Header.Set("Authorization", authorization)
+	pushRequest.Header.Set("Content-Encoding", "aes128gcm")
+	pushRequest.Header.Set("Content-Type", "application/octet-stream")
+	pushRequest.Header.Set("TTL", strconv.Itoa(int(client.config.Ttl.Seconds())))
+	pushRequest.Header.Set("Urgency", "high")
+
+	if message.Topic != "" {
+		pushRequest.Header.Set("Topic", message.Topic)
+	}
+
+	pushResponse, errDo := client.httpClient.Do(pushRequest)
+
+	if errDo != nil {
+		return errDo
+	}
+
+	defer pushResponse.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(pushResponse.Body, 64<<10))
+
+	switch {
+	case pushResponse.StatusCode == http.StatusNotFound || pushResponse.StatusCode == http.StatusGone:
+		return ErrPushSubscriptionGone
+	case pushResponse.StatusCode < 200 || pushResponse.StatusCode >= 300:
+		return fmt.Errorf("web push: unexpected status %d", pushResponse.StatusCode)
+	}
+
+	return nil
+}
+
+func (client *WebPushClient) vapid(audience string) (string, error) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": audience,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": client.config.Subject,
+	}).SignedString(client.signingKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	return "vapid t=" + token + ", k=" + client.publicKey, nil
+}
+
+// EncryptWebPush encrypts payload for the subscription with the given keys,
+// base64url encoded as browsers report them, as one aes128gcm record.
+func EncryptWebPush(p256dh string, auth string, payload []byte) ([]byte, error) {
+	if len(payload) > WebPushMaxPayload {
+		return nil, fmt.Errorf("web push payload of %d bytes is over %d", len(payload), WebPushMaxPayload)
+	}
+
+	rawReceiverKey, errReceiverKey := base64.RawURLEncoding.DecodeString(p256dh)
+	authSecret, errAuth := base64.RawURLEncoding.DecodeString(auth)
+
+	if errReceiverKey != nil || errAuth != nil || len(authSecret) != 16 {
+		return nil, errors.New("invalid web push subscription keys")
+	}
+
+	receiverKey, errKey := ecdh.P256().NewPublicKey(rawReceiverKey)
+
+	if errKey != nil {
+		return nil, errors.New("invalid web push subscription keys")
+	}
+
+	senderKey, errGenerate := ecdh.P256().GenerateKey(rand.Reader)
+
+	if errGenerate != nil {
+		return nil, errGenerate
+	}
+
+	sharedSecret, errShared := senderKey.ECDH(receiverKey)
+
+	if errShared != nil {
+		return nil, errShared
+	}
+
+	salt := make([]byte, 16)
+
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	senderPublicKey := senderKey.PublicKey().Bytes()
+	contentKey, nonce := webPushKeys(sharedSecret, authSecret, salt, rawReceiverKey, senderPublicKey)
+
+	block, errCipher := aes.NewCipher(contentKey)
+
+	if errCipher != nil {
+		return nil, errCipher
+	}
+
+	gcm, errGcm := cipher.NewGCM(block)
+
+	if errGcm != nil {
+		return nil, errGcm
+	}
+
+	header := make([]byte, 0, 86)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(senderPublicKey)))
+	header = append(header, senderPublicKey...)
+
+	// 0x02 marks the last record, no padding follows it.
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// webPushKeys derives the content encryption key and nonce of RFC 8291
+// section 3.4 from the ECDH secret of the two keys.
+func webPushKeys(sharedSecret []byte, authSecret []byte, salt []byte, receiverKey []byte, senderKey []byte) ([]byte, []byte) {
+	keyInfo := append([]byte("WebPush: info\x00"), receiverKey...)
+	keyInfo = append(keyInfo, senderKey...)
+
+	inputKey := make([]byte, 32)
+	contentKey := make([]byte, 16)
+	nonce := make([]byte, 12)
+
+	// Reading less than one hash length from HKDF can't fail.
+	io.ReadFull(hkdf.New(sha256.New, sharedSecret, authSecret, keyInfo), inputKey)
+	io.ReadFull(hkdf.New(sha256.New, inputKey, salt, []byte("Content-Encoding: aes128gcm\x00")), contentKey)
+	io.ReadFull(hkdf.New(sha256.New, inputKey, salt, []byte("Content-Encoding: nonce\x00")), nonce)
+
+	return contentKey, nonce
+}
@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	WebhookSecretPrefix    = "whsec_"
+	WebhookIdHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+func GenerateWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return WebhookSecretPrefix + hex.EncodeToString(raw), nil
+}
+
+// SignWebhook is the X-Webhook-Signature of a delivery sent at timestamp,
+// "t=<timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>">". Signing the
+// timestamp lets receivers turn away old deliveries replayed by someone else.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return "t=" + strconv.FormatInt(timestamp, 10) + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
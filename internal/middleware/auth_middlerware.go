@@ -1,14 +1,32 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"go_todo_api/internal/helper"
+	"go_todo_api/internal/service"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
 
-func AuthMiddleware(next httprouter.Handle) httprouter.Handle {
+type AuthMiddleware struct {
+	authService     service.AuthService
+	apiTokenService service.ApiTokenService
+	oauthService    service.OauthService
+}
+
+func NewAuthMiddleware(authService service.AuthService, apiTokenService service.ApiTokenService, oauthService service.OauthService) *AuthMiddleware {
+	return &AuthMiddleware{
+		authService:     authService,
+		apiTokenService: apiTokenService,
+		oauthService:    oauthService,
+	}
+}
+
+func (middleware *AuthMiddleware) Authenticate(next httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		authorizationHeader := r.Header.Get("Authorization")
 
@@ -19,13 +37,136 @@ func AuthMiddleware(next httprouter.Handle) httprouter.Handle {
 
 		tokenString := strings.Replace(authorizationHeader, "Bearer ", "", -1)
 
-		_, err := helper.ValidateJWT(tokenString)
+		principal, err := middleware.authenticateToken(r.Context(), tokenString)
 
 		if err != nil {
 			helper.WriteErrorResponse(w, err)
 			return
 		}
 
+		if principal.Locale != "" {
+			helper.SetResponseLocale(w, principal.Locale)
+		}
+
+		next(w, r.WithContext(helper.SetPrincipal(r.Context(), principal)), params)
+	}
+}
+
+// AuthenticateQuery also takes the token from the access_token query
+// parameter, browsers can't set headers on a WebSocket handshake. The request
+// log only keeps the path, so the token isn't written there.
+func (middleware *AuthMiddleware) AuthenticateQuery(next httprouter.Handle) httprouter.Handle {
+	authenticate := middleware.Authenticate(next)
+
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		if accessToken := r.URL.Query().Get("access_token"); accessToken != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+
+		authenticate(w, r, params)
+	}
+}
+
+// authenticateToken picks the credential type from the token format. Every
+// credential type resolves to the same principal, so route permissions don't
+// care how the caller logged in.
+func (middleware *AuthMiddleware) authenticateToken(ctx context.Context, token string) (helper.Principal, error) {
+	if helper.IsApiToken(token) {
+		return middleware.apiTokenService.Authenticate(ctx, token)
+	}
+
+	if helper.IsOauthAccessToken(token) {
+		return middleware.oauthService.Authenticate(ctx, token)
+	}
+
+	return middleware.authService.Authenticate(ctx, token)
+}
+
+func RequireScope(scope string) func(next httprouter.Handle) httprouter.Handle {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+			principal, ok := helper.GetPrincipal(r.Context())
+
+			if !ok {
+				helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+				return
+			}
+
+			if err := principal.CheckScope(scope); err != nil {
+				helper.WriteErrorResponse(w, err)
+				return
+			}
+
+			next(w, r, params)
+		}
+	}
+}
+
+// RequireSession guards endpoints that manage credentials, they can't be used
+// with an api token.
+func RequireSession(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		principal, ok := helper.GetPrincipal(r.Context())
+
+		if !ok {
+			helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+			return
+		}
+
+		if principal.AuthMethod != helper.AuthMethodSession {
+			helper.WriteErrorResponse(w, fmt.Errorf("%w: endpoint requires a login session", helper.ErrForbidden))
+			return
+		}
+
 		next(w, r, params)
 	}
 }
+
+func RequireRole(role string) func(next httprouter.Handle) httprouter.Handle {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+			principal, ok := helper.GetPrincipal(r.Context())
+
+			if !ok {
+				helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+				return
+			}
+
+			if !principal.HasRole(role) {
+				helper.WriteErrorResponse(w, fmt.Errorf("%w: missing required role %s", helper.ErrForbidden, role))
+				return
+			}
+
+			next(w, r, params)
+		}
+	}
+}
+
+// RequireSelfOrAdmin only lets the user named by the route parameter, or an
+// admin, through. Principals that act for no user never match one.
+func RequireSelfOrAdmin(userIdParam string) func(next httprouter.Handle) httprouter.Handle {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+			principal, ok := helper.GetPrincipal(r.Context())
+
+			if !ok {
+				helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+				return
+			}
+
+			userId, errCastToInt := strconv.Atoi(params.ByName(userIdParam))
+
+			if errCastToInt != nil {
+				helper.WriteErrorResponse(w, errCastToInt)
+				return
+			}
+
+			if (principal.UserId == 0 || principal.UserId != userId) && !principal.HasRole(helper.RoleAdmin) {
+				helper.WriteErrorResponse(w, fmt.Errorf("%w: not allowed to access another user", helper.ErrForbidden))
+				return
+			}
+
+			next(w, r, params)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyTtl        = 24 * time.Hour
+	idempotencyLockTimeout   = time.Minute
+	idempotencyKeyMaxLength  = 255
+)
+
+type IdempotencyMiddleware struct {
+	store repository.IdempotencyStore
+}
+
+func NewIdempotencyMiddleware(store repository.IdempotencyStore) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		store: store,
+	}
+}
+
+// Idempotent answers a request repeated with the same Idempotency-Key with
+// the response to the first one, for 24 hours. Keys belong to the caller, two
+// users can use the same key. It must run after Authenticate. Server errors
+// are not kept, the request can be retried with the same key.
+func (middleware *IdempotencyMiddleware) Idempotent(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+
+		if idempotencyKey == "" {
+			next(w, r, params)
+			return
+		}
+
+		if len(idempotencyKey) > idempotencyKeyMaxLength {
+			helper.WriteErrorResponse(w, helper.ErrIdempotencyKeyInvalid)
+			return
+		}
+
+		principal, ok := helper.GetPrincipal(r.Context())
+
+		if !ok {
+			helper.WriteErrorResponse(w, helper.ErrorTokenInvalid)
+			return
+		}
+
+		body, errReadBody := io.ReadAll(r.Body)
+
+		if errReadBody != nil {
+			helper.WriteErrorResponse(w, fmt.Errorf("%w: %w", helper.ErrMalformedBody, errReadBody))
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := helper.HashToken(idempotencyScope(principal) + "\n" + idempotencyKey)
+		requestHash := helper.HashToken(r.Method + " " + r.URL.RequestURI() + "\n" + string(body))
+		now := time.Now()
+
+		record, reserved, errReserve := middleware.store.Reserve(r.Context(), key, requestHash, now, idempotencyLockTimeout)
+
+		if errReserve != nil {
+			helper.WriteErrorResponse(w, errReserve)
+			return
+		}
+
+		if !reserved {
+			replayIdempotentResponse(w, record, requestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+
+		next(recorder, r, params)
+
+		if recorder.statusCode == 0 {
+			recorder.WriteHeader(http.StatusOK)
+		}
+
+		// Errors from the store are dropped, the response already went out. A
+		// key that couldn't be stored stays claimed until the lock times out.
+		if recorder.statusCode >= http.StatusInternalServerError {
+			middleware.store.Release(r.Context(), key)
+			return
+		}
+
+		middleware.store.Complete(r.Context(), entity.IdempotencyRecord{
+			Key:         key,
+			RequestHash: requestHash,
+			StatusCode:  recorder.statusCode,
+			Header:      recorder.header,
+			Body:        recorder.body.Bytes(),
+			ExpiresAt:   now.Add(idempotencyKeyTtl).Unix(),
+		})
+	}
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, record entity.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		helper.WriteErrorResponse(w, helper.ErrIdempotencyKeyReused)
+		return
+	}
+
+	if record.StatusCode == 0 {
+		helper.WriteErrorResponse(w, helper.ErrIdempotencyKeyInProgress)
+		return
+	}
+
+	for name, values := range record.Header {
+		// The replay has a request id of its own.
+		if name == helper.RequestIdHeader {
+			continue
+		}
+
+		w.Header()[name] = values
+	}
+
+	w.Header().Set(IdempotentReplayedHeader, strconv.FormatBool(true))
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// idempotencyScope keeps the keys of users apart, and those of client
+// credentials tokens, which act for no user.
+func idempotencyScope(principal helper.Principal) string {
+	if principal.UserId == 0 {
+		return "client:" + principal.ClientId
+	}
+
+	return "user:" + strconv.Itoa(principal.UserId)
+}
+
+// responseRecorder passes the response through and keeps a copy of it. The
+// headers are copied when they are sent, later changes never reach the client.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+}
+
+func (recorder *responseRecorder) WriteHeader(statusCode int) {
+	if recorder.statusCode != 0 {
+		return
+	}
+
+	recorder.statusCode = statusCode
+	recorder.header = recorder.ResponseWriter.Header().Clone()
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Unwrap lets http.ResponseController reach the writer underneath.
+func (recorder *responseRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.WriteHeader(http.StatusOK)
+	}
+
+	recorder.body.Write(data)
+
+	return recorder.ResponseWriter.Write(data)
+}
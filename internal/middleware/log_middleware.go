@@ -24,6 +24,14 @@ func (middleware *LogMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	requestUrl := r.URL.Path
 	requestMethod := r.Method
 
+	// Set before anything else, error responses read it back from the header.
+	requestId := helper.RequestId(r.Header.Get(helper.RequestIdHeader))
+	w.Header().Set(helper.RequestIdHeader, requestId)
+
+	// Authenticated requests may switch it to the user's preferred locale.
+	w.Header().Set(helper.ContentLanguageHeader, helper.NegotiateLocale(r.Header.Get("Accept-Language")))
+	w.Header().Add("Vary", "Accept-Language")
+
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 
@@ -36,11 +44,13 @@ func (middleware *LogMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	logger.SetOutput(file)
 
 	entry := logger.WithFields(logrus.Fields{
+		"request_id":     requestId,
 		"request_url":    requestUrl,
 		"request_method": requestMethod,
 	})
 
 	entry.Info("API Request Occured")
 
+	// w is passed on unwrapped, event streams flush through it.
 	middleware.handler.ServeHTTP(w, r)
 }
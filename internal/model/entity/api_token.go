@@ -0,0 +1,14 @@
+package entity
+
+type ApiToken struct {
+	Id         int
+	UserId     int
+	Username   string
+	Locale     string
+	Name       string
+	TokenHash  string
+	Scopes     string
+	ExpiresAt  string
+	LastUsedAt string
+	CreatedAt  string
+}
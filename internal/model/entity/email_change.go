@@ -0,0 +1,11 @@
+package entity
+
+type EmailChange struct {
+	Id               int
+	UserId           int
+	OldEmail         string
+	NewEmail         string
+	ConfirmTokenHash string
+	RevertTokenHash  string
+	ConfirmedAt      string
+}
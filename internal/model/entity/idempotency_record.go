@@ -0,0 +1,13 @@
+package entity
+
+// IdempotencyRecord is the response to a request sent with an Idempotency-Key.
+// StatusCode is 0 while the first request is still running. ExpiresAt is in
+// unix seconds.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Header      map[string][]string
+	Body        []byte
+	ExpiresAt   int64
+}
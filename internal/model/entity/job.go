@@ -0,0 +1,25 @@
+package entity
+
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is a piece of work waiting in the queue, or done with. While a job is
+// running its RunAt is when its claim runs out, after which another runner
+// may take it over. UniqueKey, when set, keeps the same job from being
+// queued twice.
+type Job struct {
+	Id         int64
+	Type       string
+	Payload    string
+	UniqueKey  string
+	Status     string
+	Attempts   int
+	RunAt      string
+	LastError  string
+	FinishedAt string
+	CreatedAt  string
+}
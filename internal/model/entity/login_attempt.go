@@ -0,0 +1,10 @@
+package entity
+
+// LoginAttempt counts the recent failed logins for a username or an ip
+// address. Times are unix seconds, LockedUntil is 0 when not locked.
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt int64
+	LockedUntil   int64
+}
@@ -0,0 +1,29 @@
+package entity
+
+const (
+	NotificationChannelEmail   = "email"
+	NotificationChannelWebPush = "web_push"
+	NotificationChannelFcm     = "fcm"
+)
+
+// NotificationSettings are how a user wants reminders. Channels is space
+// separated. Quiet hours are "15:04" times in Timezone, both empty when the
+// user has none, and may span midnight.
+type NotificationSettings struct {
+	UserId          int
+	Channels        string
+	Timezone        string
+	QuietHoursStart string
+	QuietHoursEnd   string
+	UpdatedAt       string
+}
+
+// DefaultNotificationSettings apply to users who never changed theirs, every
+// channel at any hour.
+func DefaultNotificationSettings(userId int) NotificationSettings {
+	return NotificationSettings{
+		UserId:   userId,
+		Channels: NotificationChannelEmail + " " + NotificationChannelWebPush + " " + NotificationChannelFcm,
+		Timezone: "UTC",
+	}
+}
@@ -0,0 +1,11 @@
+package entity
+
+type OauthAuthorizationCode struct {
+	CodeHash      string
+	ClientId      string
+	UserId        int
+	RedirectUri   string
+	Scopes        string
+	CodeChallenge string
+	ExpiresAt     string
+}
@@ -0,0 +1,12 @@
+package entity
+
+type OauthClient struct {
+	Id               int
+	ClientId         string
+	ClientSecretHash string
+	Name             string
+	RedirectUris     string
+	Scopes           string
+	GrantTypes       string
+	CreatedAt        string
+}
@@ -0,0 +1,7 @@
+package entity
+
+type OauthConsent struct {
+	UserId   int
+	ClientId string
+	Scopes   string
+}
@@ -0,0 +1,14 @@
+package entity
+
+type OauthToken struct {
+	Id               int
+	ClientId         string
+	UserId           int
+	Username         string
+	Role             string
+	Locale           string
+	Scopes           string
+	AccessTokenHash  string
+	RefreshTokenHash string
+	ExpiresAt        string
+}
@@ -0,0 +1,19 @@
+package entity
+
+// OutboxEvent is an event recorded with the write it is about, waiting to be
+// published. EventId stays the same however often it is published, so
+// consumers can drop duplicates. PublishedTo lists the sinks, space
+// separated, that already took it.
+type OutboxEvent struct {
+	Id            int64
+	EventId       string
+	UserId        int
+	EventType     string
+	Payload       string
+	PublishedTo   string
+	Attempts      int
+	NextAttemptAt string
+	LastError     string
+	PublishedAt   string
+	CreatedAt     string
+}
@@ -0,0 +1,14 @@
+package entity
+
+// PushSubscription is a device reminders are pushed to. For web push Endpoint
+// is the browser's push service URL and P256dh and Auth its keys, for FCM
+// Endpoint is the registration token.
+type PushSubscription struct {
+	Id        int
+	UserId    int
+	Channel   string
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	CreatedAt string
+}
@@ -0,0 +1,22 @@
+package entity
+
+const (
+	ReminderPending = "pending"
+	ReminderSent    = "sent"
+	ReminderSkipped = "skipped"
+)
+
+// Reminder is one reminder of a todo, there is at most one for each time the
+// todo was set to remind at. RemindAt is in unix seconds. SentTo lists the
+// channels, space separated, that already took it.
+type Reminder struct {
+	Id         int64
+	TodoId     int
+	UserId     int
+	RemindAt   int64
+	Status     string
+	SentTo     string
+	LastError  string
+	FinishedAt string
+	CreatedAt  string
+}
@@ -1,11 +1,16 @@
 package entity
 
+// Todo RemindAt is in unix seconds, 0 when no reminder is set.
 type Todo struct {
 	Id          int
+	Uuid        string
 	UserId      int
 	Title       string
 	Description string
 	IsDone      bool
+	RemindAt    int64
+	Version     int
+	ChangeSeq   int64
 	CreatedAt   string
 	UpdatedAt   string
 }
@@ -0,0 +1,10 @@
+package entity
+
+// TodoTombstone remembers a deleted todo so clients that synced it before
+// learn about the deletion.
+type TodoTombstone struct {
+	UserId    int
+	Uuid      string
+	ChangeSeq int64
+	DeletedAt string
+}
@@ -1,12 +1,17 @@
 package entity
 
 type User struct {
-	Id          int
-	Username    string
-	Password    string
-	Name        string
-	Email       string
-	PhoneNumber string
-	CreatedAt   string
-	UpdatedAt   string
+	Id           int
+	Username     string
+	Password     string
+	Name         string
+	Email        string
+	PendingEmail string
+	PhoneNumber  string
+	Locale       string
+	Role         string
+	IsDisabled   bool
+	TokenVersion int
+	CreatedAt    string
+	UpdatedAt    string
 }
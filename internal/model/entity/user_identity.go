@@ -0,0 +1,10 @@
+package entity
+
+type UserIdentity struct {
+	Id        int
+	UserId    int
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt string
+}
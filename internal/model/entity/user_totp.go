@@ -0,0 +1,8 @@
+package entity
+
+type UserTotp struct {
+	UserId       int
+	Secret       string
+	IsEnabled    bool
+	LastUsedStep int64
+}
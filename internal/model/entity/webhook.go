@@ -0,0 +1,15 @@
+package entity
+
+// Webhook is an endpoint a user wants todo events posted to. EventTypes is
+// space separated. Secret signs the deliveries, so unlike api tokens it is
+// kept as is.
+type Webhook struct {
+	Id           int
+	UserId       int
+	Url          string
+	Secret       string
+	EventTypes   string
+	FailureCount int
+	DisabledAt   string
+	CreatedAt    string
+}
@@ -0,0 +1,27 @@
+package entity
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event on its way to one webhook, with the outcome
+// of the last attempt. Url and Secret come from the webhook, they are only
+// set on deliveries read for sending.
+type WebhookDelivery struct {
+	Id             int
+	WebhookId      int
+	EventId        string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttemptAt  string
+	ResponseStatus int
+	LastError      string
+	DeliveredAt    string
+	CreatedAt      string
+	Url            string
+	Secret         string
+}
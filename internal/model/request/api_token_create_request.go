@@ -0,0 +1,8 @@
+package request
+
+type ApiTokenCreateRequest struct {
+	Username      string   `json:"-" validate:"required"`
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=todos:read todos:write user:read user:write"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=365"`
+}
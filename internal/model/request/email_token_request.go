@@ -0,0 +1,5 @@
+package request
+
+type EmailTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,7 @@
+package request
+
+type MfaLoginRequest struct {
+	MfaToken  string `json:"mfa_token" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+	IpAddress string `json:"-"`
+}
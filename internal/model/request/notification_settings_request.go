@@ -0,0 +1,11 @@
+package request
+
+// NotificationSettingsRequest replaces the user's settings. Quiet hours are
+// "15:04" times in Timezone, give both or neither.
+type NotificationSettingsRequest struct {
+	UserId          int      `json:"-" validate:"required"`
+	Channels        []string `json:"channels" validate:"required,dive,oneof=email web_push fcm"`
+	Timezone        string   `json:"timezone" validate:"required,timezone"`
+	QuietHoursStart string   `json:"quiet_hours_start" validate:"required_with=QuietHoursEnd,omitempty,datetime=15:04"`
+	QuietHoursEnd   string   `json:"quiet_hours_end" validate:"required_with=QuietHoursStart,omitempty,datetime=15:04"`
+}
@@ -0,0 +1,13 @@
+package request
+
+type OauthAuthorizeRequest struct {
+	UserId              int    `json:"-" validate:"required"`
+	ResponseType        string `json:"response_type" validate:"required,eq=code"`
+	ClientId            string `json:"client_id" validate:"required"`
+	RedirectUri         string `json:"redirect_uri" validate:"required"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge" validate:"required,len=43"`
+	CodeChallengeMethod string `json:"code_challenge_method" validate:"required,eq=S256"`
+	Approve             bool   `json:"approve"`
+}
@@ -0,0 +1,9 @@
+package request
+
+type OauthClientCreateRequest struct {
+	Name         string   `json:"name" validate:"required,max=100"`
+	RedirectUris []string `json:"redirect_uris" validate:"dive,url"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,oneof=todos:read todos:write user:read user:write"`
+	GrantTypes   []string `json:"grant_types" validate:"required,min=1,dive,oneof=authorization_code refresh_token client_credentials"`
+	Confidential bool     `json:"confidential"`
+}
@@ -0,0 +1,14 @@
+package request
+
+// OauthTokenRequest is read from the form body of the token endpoint, its
+// errors are reported as oauth errors rather than validation errors.
+type OauthTokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectUri  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	ClientId     string
+	ClientSecret string
+}
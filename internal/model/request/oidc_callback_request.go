@@ -0,0 +1,7 @@
+package request
+
+type OidcCallbackRequest struct {
+	Provider string `validate:"required"`
+	Code     string `validate:"required"`
+	State    string `validate:"required"`
+}
@@ -0,0 +1,6 @@
+package request
+
+type PasswordResetRequest struct {
+	UserId   int    `json:"-" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
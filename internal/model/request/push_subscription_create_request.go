@@ -0,0 +1,19 @@
+package request
+
+// PushSubscriptionCreateRequest registers a device. Browsers send the
+// PushSubscription they got, endpoint and keys, on the web_push channel.
+// Apps send their registration token on the fcm channel.
+type PushSubscriptionCreateRequest struct {
+	UserId   int                   `json:"-" validate:"required"`
+	Channel  string                `json:"channel" validate:"required,oneof=web_push fcm"`
+	Endpoint string                `json:"endpoint" validate:"required_if=Channel web_push,omitempty,max=500,http_url"`
+	Keys     *PushSubscriptionKeys `json:"keys" validate:"required_if=Channel web_push"`
+	Token    string                `json:"token" validate:"required_if=Channel fcm,max=500"`
+}
+
+// PushSubscriptionKeys are the P-256 public key and the 16 byte auth secret
+// of a browser subscription, base64url encoded.
+type PushSubscriptionKeys struct {
+	P256dh string `json:"p256dh" validate:"required,len=87,base64rawurl"`
+	Auth   string `json:"auth" validate:"required,len=22,base64rawurl"`
+}
@@ -0,0 +1,23 @@
+package request
+
+const (
+	SyncOpUpsert = "upsert"
+	SyncOpDelete = "delete"
+)
+
+type SyncPushRequest struct {
+	Mutations []SyncMutationRequest `json:"mutations" validate:"required,max=100,dive"`
+}
+
+// SyncMutationRequest is a change the client made offline. BaseVersion is the
+// version the client last saw, 0 for a todo it created itself. The id of a
+// todo the client created must be a version 7 UUID, see the validator.
+type SyncMutationRequest struct {
+	Op          string `json:"op" validate:"required,oneof=upsert delete"`
+	Id          string `json:"id" validate:"required"`
+	BaseVersion int    `json:"base_version" validate:"min=0"`
+	Title       string `json:"title" validate:"required_if=Op upsert"`
+	Description string `json:"description"`
+	IsDone      bool   `json:"is_done"`
+	RemindAt    string `json:"remind_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
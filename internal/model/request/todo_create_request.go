@@ -1,7 +1,11 @@
 package request
 
+// TodoCreateRequest takes an optional id, an offline client can name a todo
+// before it is ever synced. RemindAt is an RFC 3339 time.
 type TodoCreateRequest struct {
-	UserId      int    `json:"user_id" validate:"required"`
+	Id          string `json:"id" validate:"omitempty,uuid7"`
+	UserId      int    `json:"-" validate:"required"`
 	Title       string `validate:"required"`
 	Description string
+	RemindAt    string `json:"remind_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 }
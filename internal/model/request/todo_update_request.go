@@ -4,5 +4,8 @@ type TodoUpdateRequest struct {
 	Id          int    `validate:"required"`
 	Title       string `validate:"required"`
 	Description string
-	IsDone      bool `json:"is_done"`
+	IsDone      bool   `json:"is_done"`
+	RemindAt    string `json:"remind_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	// Version comes from If-Match, 0 updates whatever version is stored.
+	Version int `json:"-"`
 }
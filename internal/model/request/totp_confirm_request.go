@@ -0,0 +1,6 @@
+package request
+
+type TotpConfirmRequest struct {
+	Username string `json:"-" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
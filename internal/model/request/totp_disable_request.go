@@ -0,0 +1,7 @@
+package request
+
+type TotpDisableRequest struct {
+	Username  string `json:"-" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+	IpAddress string `json:"-"`
+}
@@ -1,9 +1,9 @@
 package request
 
 type UserCreateRequest struct {
-	Username    string `validate:"required"`
+	Username    string `validate:"required,excludes=@"`
 	Password    string `validate:"required"`
 	Name        string `validate:"required"`
-	Email       string `validate:"required"`
-	PhoneNumber string `json:"phone_number" validate:"required"`
+	Email       string `validate:"required,email"`
+	PhoneNumber string `json:"phone_number" validate:"required,phone"`
 }
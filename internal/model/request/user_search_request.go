@@ -0,0 +1,7 @@
+package request
+
+type UserSearchRequest struct {
+	Query   string
+	Page    int `validate:"min=1"`
+	PerPage int `validate:"min=1,max=100"`
+}
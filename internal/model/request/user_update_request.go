@@ -2,8 +2,9 @@ package request
 
 type UserUpdateRequest struct {
 	Id          int    `validate:"required"`
-	Username    string `validate:"required"`
+	Username    string `validate:"required,excludes=@"`
 	Name        string `validate:"required"`
-	Email       string `validate:"required"`
-	PhoneNumber string `json:"phone_number" validate:"required"`
+	Email       string `validate:"required,email"`
+	PhoneNumber string `json:"phone_number" validate:"omitempty,phone"`
+	Locale      string `validate:"omitempty,oneof=en id"`
 }
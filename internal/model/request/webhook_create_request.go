@@ -0,0 +1,7 @@
+package request
+
+type WebhookCreateRequest struct {
+	UserId     int      `json:"-" validate:"required"`
+	Url        string   `json:"url" validate:"required,max=2048,http_url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=todo.created todo.updated todo.completed todo.deleted"`
+}
@@ -0,0 +1,27 @@
+package request
+
+import "encoding/json"
+
+const (
+	WebsocketSubscribe   = "subscribe"
+	WebsocketUnsubscribe = "unsubscribe"
+	WebsocketCreate      = "create"
+	WebsocketUpdate      = "update"
+	WebsocketComplete    = "complete"
+)
+
+// WebsocketRequest is a message from the client. Id is picked by the client
+// and comes back on the answer, so it can tell the answers apart.
+type WebsocketRequest struct {
+	Id    string          `json:"id"`
+	Type  string          `json:"type"`
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// WebsocketTodoRequest names the todo a write is for and the version it is
+// based on, like the path and If-Match do over HTTP.
+type WebsocketTodoRequest struct {
+	Id      int `json:"id"`
+	Version int `json:"version"`
+}
@@ -0,0 +1,6 @@
+package response
+
+type ApiTokenCreateResponse struct {
+	ApiTokenResponse
+	Token string `json:"token"`
+}
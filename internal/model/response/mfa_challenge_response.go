@@ -0,0 +1,7 @@
+package response
+
+type MfaChallengeResponse struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
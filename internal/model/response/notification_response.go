@@ -0,0 +1,20 @@
+package response
+
+// NotificationSettingsResponse tells clients which channels this server can
+// send on. WebPushPublicKey is the applicationServerKey browsers subscribe
+// with, empty when web push is off.
+type NotificationSettingsResponse struct {
+	Channels          []string `json:"channels"`
+	Timezone          string   `json:"timezone"`
+	QuietHoursStart   string   `json:"quiet_hours_start"`
+	QuietHoursEnd     string   `json:"quiet_hours_end"`
+	AvailableChannels []string `json:"available_channels"`
+	WebPushPublicKey  string   `json:"web_push_public_key"`
+}
+
+type PushSubscriptionResponse struct {
+	Id        int    `json:"id"`
+	Channel   string `json:"channel"`
+	Endpoint  string `json:"endpoint"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package response
+
+type OauthClientResponse struct {
+	ClientId     string   `json:"client_id"`
+	Name         string   `json:"name"`
+	RedirectUris []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grant_types"`
+	Confidential bool     `json:"confidential"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+type OauthClientCreateResponse struct {
+	OauthClientResponse
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+type OauthConsentResponse struct {
+	ClientId          string   `json:"client_id"`
+	ClientName        string   `json:"client_name"`
+	RedirectUri       string   `json:"redirect_uri"`
+	Scopes            []string `json:"scopes"`
+	PreviouslyGranted bool     `json:"previously_granted"`
+}
+
+type OauthAuthorizeResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+type OauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
@@ -0,0 +1,8 @@
+package response
+
+type PageResponse struct {
+	Items   any `json:"items"`
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+}
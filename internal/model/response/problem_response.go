@@ -0,0 +1,13 @@
+package response
+
+// ProblemResponse is an RFC 7807 problem details body. Code is the stable
+// error code, Errors lists the failing fields of a validation error.
+type ProblemResponse struct {
+	Type     string               `json:"type"`
+	Title    string               `json:"title"`
+	Status   int                  `json:"status"`
+	Detail   string               `json:"detail,omitempty"`
+	Instance string               `json:"instance,omitempty"`
+	Code     string               `json:"code"`
+	Errors   []FieldErrorResponse `json:"errors,omitempty"`
+}
@@ -0,0 +1,37 @@
+package response
+
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict"
+)
+
+// SyncTodoResponse names a todo by its uuid, the id clients keep offline.
+type SyncTodoResponse struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	IsDone      bool   `json:"is_done"`
+	RemindAt    string `json:"remind_at"`
+	Version     int    `json:"version"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+type SyncPullResponse struct {
+	Todos     []SyncTodoResponse `json:"todos"`
+	Deleted   []string           `json:"deleted"`
+	NextToken string             `json:"next_token"`
+	HasMore   bool               `json:"has_more"`
+}
+
+// SyncMutationResponse carries the todo as the server has it, so a client can
+// resolve a conflict or pick up the new version of an applied change.
+type SyncMutationResponse struct {
+	Id     string            `json:"id"`
+	Status string            `json:"status"`
+	Todo   *SyncTodoResponse `json:"todo,omitempty"`
+}
+
+type SyncPushResponse struct {
+	Results []SyncMutationResponse `json:"results"`
+}
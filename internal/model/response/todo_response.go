@@ -2,10 +2,18 @@ package response
 
 type TodoResponse struct {
 	Id          int    `json:"id"`
+	Uuid        string `json:"uuid"`
 	UserId      int    `json:"user_id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	IsDone      bool   `json:"is_done"`
+	RemindAt    string `json:"remind_at"`
+	Version     int    `json:"version"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
+
+type TodoDeletedResponse struct {
+	Id   int    `json:"id"`
+	Uuid string `json:"uuid"`
+}
@@ -0,0 +1,6 @@
+package response
+
+type TotpEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
@@ -1,10 +1,14 @@
 package response
 
 type UserResponse struct {
-	Id          int    `json:"id"`
-	Username    string `json:"username"`
-	Name        string `json:"name"`
-	Email       string `json:"email"`
-	PhoneNumber string `json:"phone_number"`
-	CreatedAt   string `json:"created_at"`
+	Id           int    `json:"id"`
+	Username     string `json:"username"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	PendingEmail string `json:"pending_email"`
+	PhoneNumber  string `json:"phone_number"`
+	Locale       string `json:"locale"`
+	Role         string `json:"role"`
+	IsDisabled   bool   `json:"is_disabled"`
+	CreatedAt    string `json:"created_at"`
 }
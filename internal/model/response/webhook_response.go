@@ -0,0 +1,40 @@
+package response
+
+type WebhookResponse struct {
+	Id           int      `json:"id"`
+	Url          string   `json:"url"`
+	EventTypes   []string `json:"event_types"`
+	IsActive     bool     `json:"is_active"`
+	FailureCount int      `json:"failure_count"`
+	DisabledAt   string   `json:"disabled_at"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+// WebhookCreateResponse is the only response carrying the secret deliveries
+// are signed with.
+type WebhookCreateResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryResponse struct {
+	Id             int    `json:"id"`
+	EventId        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at"`
+	ResponseStatus int    `json:"response_status"`
+	LastError      string `json:"last_error"`
+	DeliveredAt    string `json:"delivered_at"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// WebhookEventResponse is the body posted to a webhook. Id stays the same
+// across retries and redeliveries, receivers can use it to drop duplicates.
+type WebhookEventResponse struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	CreatedAt string `json:"created_at"`
+	Data      any    `json:"data"`
+}
@@ -0,0 +1,20 @@
+package response
+
+const (
+	WebsocketAck       = "ack"
+	WebsocketError     = "error"
+	WebsocketEvent     = "event"
+	WebsocketHeartbeat = "heartbeat"
+)
+
+// WebsocketResponse is a message to the client. An ack or an error answers
+// the client's message with the same Id, an event tells about a change to a
+// todo on a subscribed topic.
+type WebsocketResponse struct {
+	Type  string           `json:"type"`
+	Id    string           `json:"id,omitempty"`
+	Topic string           `json:"topic,omitempty"`
+	Event string           `json:"event,omitempty"`
+	Data  any              `json:"data,omitempty"`
+	Error *ProblemResponse `json:"error,omitempty"`
+}
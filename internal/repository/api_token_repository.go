@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+)
+
+type ApiTokenRepository interface {
+	GetActiveByHash(ctx context.Context, db *sql.DB, tokenHash string) (entity.ApiToken, error)
+	GetUserTokens(ctx context.Context, db *sql.DB, userId int) ([]entity.ApiToken, error)
+	Get(ctx context.Context, db *sql.DB, tokenId int) (entity.ApiToken, error)
+	Insert(ctx context.Context, db *sql.DB, apiToken entity.ApiToken, expiresInDays int) (int, error)
+	UpdateLastUsed(ctx context.Context, db *sql.DB, tokenId int) error
+	Revoke(ctx context.Context, db *sql.DB, userId int, tokenId int) error
+}
+
+type ApiTokenRepositoryImpl struct {
+}
+
+func NewApiTokenRepository() ApiTokenRepository {
+	return &ApiTokenRepositoryImpl{}
+}
+
+const apiTokenColumns = "api_tokens.id, api_tokens.user_id, users.username, users.locale, api_tokens.name, api_tokens.token_hash, api_tokens.scopes, api_tokens.expires_at, api_tokens.last_used_at, api_tokens.created_at"
+
+func scanApiToken(rows *sql.Rows) (entity.ApiToken, error) {
+	apiToken := entity.ApiToken{}
+	locale := sql.NullString{}
+	expiresAt := sql.NullString{}
+	lastUsedAt := sql.NullString{}
+
+	err := rows.Scan(&apiToken.Id, &apiToken.UserId, &apiToken.Username, &locale, &apiToken.Name, &apiToken.TokenHash, &apiToken.Scopes, &expiresAt, &lastUsedAt, &apiToken.CreatedAt)
+
+	if err != nil {
+		return entity.ApiToken{}, err
+	}
+
+	apiToken.Locale = locale.String
+	apiToken.ExpiresAt = expiresAt.String
+	apiToken.LastUsedAt = lastUsedAt.String
+
+	return apiToken, nil
+}
+
+func (repository ApiTokenRepositoryImpl) GetActiveByHash(ctx context.Context, db *sql.DB, tokenHash string) (entity.ApiToken, error) {
+	query := "SELECT " + apiTokenColumns + " FROM api_tokens JOIN users ON users.id = api_tokens.user_id WHERE api_tokens.token_hash = ? AND api_tokens.revoked_at IS NULL AND users.is_disabled = 0 AND (api_tokens.expires_at IS NULL OR api_tokens.expires_at > CURRENT_TIMESTAMP) LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.ApiToken{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, tokenHash)
+
+	if queryErr != nil {
+		return entity.ApiToken{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanApiToken(rows)
+	}
+
+	return entity.ApiToken{}, helper.ErrNotFound
+}
+
+func (repository ApiTokenRepositoryImpl) GetUserTokens(ctx context.Context, db *sql.DB, userId int) ([]entity.ApiToken, error) {
+	query := "SELECT " + apiTokenColumns + " FROM api_tokens JOIN users ON users.id = api_tokens.user_id WHERE api_tokens.user_id = ? AND api_tokens.revoked_at IS NULL ORDER BY api_tokens.id"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, userId)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	apiTokens := []entity.ApiToken{}
+
+	for rows.Next() {
+		apiToken, err := scanApiToken(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		apiTokens = append(apiTokens, apiToken)
+	}
+
+	return apiTokens, nil
+}
+
+func (repository ApiTokenRepositoryImpl) Get(ctx context.Context, db *sql.DB, tokenId int) (entity.ApiToken, error) {
+	query := "SELECT " + apiTokenColumns + " FROM api_tokens JOIN users ON users.id = api_tokens.user_id WHERE api_tokens.id = ? LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.ApiToken{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, tokenId)
+
+	if queryErr != nil {
+		return entity.ApiToken{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanApiToken(rows)
+	}
+
+	return entity.ApiToken{}, helper.ErrNotFound
+}
+
+func (repository ApiTokenRepositoryImpl) Insert(ctx context.Context, db *sql.DB, apiToken entity.ApiToken, expiresInDays int) (int, error) {
+	// Expiry is computed by the database so it shares a clock with the expiry check in GetActiveByHash.
+	query := "INSERT INTO api_tokens (user_id, name, token_hash, scopes, expires_at) VALUES (?, ?, ?, ?, IF(? > 0, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? DAY), NULL))"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return 0, errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, apiToken.UserId, apiToken.Name, apiToken.TokenHash, apiToken.Scopes, expiresInDays, expiresInDays)
+
+	if errExec != nil {
+		return 0, translateMysqlError(errExec)
+	}
+
+	lastInsertId, errLastInsertId := sqlResult.LastInsertId()
+
+	if errLastInsertId != nil {
+		return 0, errLastInsertId
+	}
+
+	return int(lastInsertId), nil
+}
+
+func (repository ApiTokenRepositoryImpl) UpdateLastUsed(ctx context.Context, db *sql.DB, tokenId int) error {
+	// Only written once a minute so busy scripts don't turn every request into a write.
+	query := "UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ? AND (last_used_at IS NULL OR last_used_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL 1 MINUTE))"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, tokenId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+func (repository ApiTokenRepositoryImpl) Revoke(ctx context.Context, db *sql.DB, userId int, tokenId int) error {
+	query := "UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND revoked_at IS NULL"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, tokenId, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+)
+
+type EmailChangeRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, emailChange entity.EmailChange, expiresInHours int, revertExpiresInDays int) error
+	DeletePending(ctx context.Context, tx *sql.Tx, userId int) error
+	GetPendingByConfirmHash(ctx context.Context, db *sql.DB, confirmTokenHash string) (entity.EmailChange, error)
+	GetByRevertHash(ctx context.Context, db *sql.DB, revertTokenHash string) (entity.EmailChange, error)
+	MarkConfirmed(ctx context.Context, tx *sql.Tx, emailChangeId int) error
+	MarkReverted(ctx context.Context, tx *sql.Tx, emailChangeId int) error
+}
+
+type EmailChangeRepositoryImpl struct {
+}
+
+func NewEmailChangeRepository() EmailChangeRepository {
+	return &EmailChangeRepositoryImpl{}
+}
+
+const emailChangeColumns = "id, user_id, old_email, new_email, confirm_token_hash, revert_token_hash, confirmed_at"
+
+func (repository EmailChangeRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, emailChange entity.EmailChange, expiresInHours int, revertExpiresInDays int) error {
+	query := "INSERT INTO email_changes (user_id, old_email, new_email, confirm_token_hash, revert_token_hash, expires_at, revert_expires_at) VALUES (?, ?, ?, ?, ?, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? HOUR), DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? DAY))"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, emailChange.UserId, emailChange.OldEmail, emailChange.NewEmail, emailChange.ConfirmTokenHash, emailChange.RevertTokenHash, expiresInHours, revertExpiresInDays)
+
+	if errExec != nil {
+		return translateMysqlError(errExec)
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeletePending drops the changes still waiting for confirmation, confirmed
+// ones are kept so the old address can still revert them.
+func (repository EmailChangeRepositoryImpl) DeletePending(ctx context.Context, tx *sql.Tx, userId int) error {
+	query := "DELETE FROM email_changes WHERE user_id = ? AND confirmed_at IS NULL"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+func (repository EmailChangeRepositoryImpl) GetPendingByConfirmHash(ctx context.Context, db *sql.DB, confirmTokenHash string) (entity.EmailChange, error) {
+	query := "SELECT " + emailChangeColumns + " FROM email_changes WHERE confirm_token_hash = ? AND confirmed_at IS NULL AND reverted_at IS NULL AND expires_at > CURRENT_TIMESTAMP LIMIT 1"
+
+	return repository.getEmailChange(ctx, db, query, confirmTokenHash)
+}
+
+func (repository EmailChangeRepositoryImpl) GetByRevertHash(ctx context.Context, db *sql.DB, revertTokenHash string) (entity.EmailChange, error) {
+	query := "SELECT " + emailChangeColumns + " FROM email_changes WHERE revert_token_hash = ? AND reverted_at IS NULL AND revert_expires_at > CURRENT_TIMESTAMP LIMIT 1"
+
+	return repository.getEmailChange(ctx, db, query, revertTokenHash)
+}
+
+func (repository EmailChangeRepositoryImpl) getEmailChange(ctx context.Context, db *sql.DB, query string, tokenHash string) (entity.EmailChange, error) {
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.EmailChange{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, tokenHash)
+
+	if queryErr != nil {
+		return entity.EmailChange{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		emailChange := entity.EmailChange{}
+		confirmedAt := sql.NullString{}
+
+		err := rows.Scan(&emailChange.Id, &emailChange.UserId, &emailChange.OldEmail, &emailChange.NewEmail, &emailChange.ConfirmTokenHash, &emailChange.RevertTokenHash, &confirmedAt)
+
+		if err != nil {
+			return entity.EmailChange{}, err
+		}
+
+		emailChange.ConfirmedAt = confirmedAt.String
+
+		return emailChange, nil
+	}
+
+	return entity.EmailChange{}, helper.ErrNotFound
+}
+
+// MarkConfirmed only matches a change that is still pending, so a token used
+// twice at the same time is only honored once.
+func (repository EmailChangeRepositoryImpl) MarkConfirmed(ctx context.Context, tx *sql.Tx, emailChangeId int) error {
+	query := "UPDATE email_changes SET confirmed_at = CURRENT_TIMESTAMP WHERE id = ? AND confirmed_at IS NULL AND reverted_at IS NULL"
+
+	return repository.mark(ctx, tx, query, emailChangeId)
+}
+
+func (repository EmailChangeRepositoryImpl) MarkReverted(ctx context.Context, tx *sql.Tx, emailChangeId int) error {
+	query := "UPDATE email_changes SET reverted_at = CURRENT_TIMESTAMP WHERE id = ? AND reverted_at IS NULL"
+
+	return repository.mark(ctx, tx, query, emailChangeId)
+}
+
+func (repository EmailChangeRepositoryImpl) mark(ctx context.Context, tx *sql.Tx, query string, emailChangeId int) error {
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, emailChangeId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
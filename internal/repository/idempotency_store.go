@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"sync"
+	"time"
+)
+
+// IdempotencyStore keeps the responses to requests sent with an
+// Idempotency-Key. Like LoginAttemptStore it owns its storage, the memory one
+// only works for a single instance.
+type IdempotencyStore interface {
+	// Reserve claims key for a request until lockTimeout has passed. If the
+	// key is already claimed or answered, the stored record is returned and
+	// reserved is false. Expired records are claimed again.
+	Reserve(ctx context.Context, key string, requestHash string, now time.Time, lockTimeout time.Duration) (record entity.IdempotencyRecord, reserved bool, err error)
+	Complete(ctx context.Context, record entity.IdempotencyRecord) error
+	// Release gives up a claim, so the request can be retried with the same key.
+	Release(ctx context.Context, key string) error
+}
+
+// memoryIdempotencySweepSize is how many records the memory store holds
+// before it drops the expired ones.
+const memoryIdempotencySweepSize = 10000
+
+type MemoryIdempotencyStore struct {
+	mutex   sync.Mutex
+	records map[string]entity.IdempotencyRecord
+}
+
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: map[string]entity.IdempotencyRecord{},
+	}
+}
+
+func (store *MemoryIdempotencyStore) Reserve(ctx context.Context, key string, requestHash string, now time.Time, lockTimeout time.Duration) (entity.IdempotencyRecord, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if record, ok := store.records[key]; ok && record.ExpiresAt >= now.Unix() {
+		return record, false, nil
+	}
+
+	if len(store.records) >= memoryIdempotencySweepSize {
+		store.sweep(now)
+	}
+
+	store.records[key] = entity.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(lockTimeout).Unix(),
+	}
+
+	return entity.IdempotencyRecord{}, true, nil
+}
+
+func (store *MemoryIdempotencyStore) Complete(ctx context.Context, record entity.IdempotencyRecord) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.records[record.Key] = record
+
+	return nil
+}
+
+func (store *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.records, key)
+
+	return nil
+}
+
+func (store *MemoryIdempotencyStore) sweep(now time.Time) {
+	for key, record := range store.records {
+		if record.ExpiresAt < now.Unix() {
+			delete(store.records, key)
+		}
+	}
+}
+
+type DbIdempotencyStore struct {
+	db *sql.DB
+}
+
+func NewDbIdempotencyStore(db *sql.DB) IdempotencyStore {
+	return &DbIdempotencyStore{
+		db: db,
+	}
+}
+
+// Reserve relies on the primary key, when two instances claim the same key at
+// once only one insert succeeds and the other reads its record.
+func (store *DbIdempotencyStore) Reserve(ctx context.Context, key string, requestHash string, now time.Time, lockTimeout time.Duration) (entity.IdempotencyRecord, bool, error) {
+	if err := store.exec(ctx, "DELETE FROM idempotency_keys WHERE idempotency_key = ? AND expires_at < ?", key, now.Unix()); err != nil {
+		return entity.IdempotencyRecord{}, false, err
+	}
+
+	errInsert := store.exec(ctx, "INSERT INTO idempotency_keys (idempotency_key, request_hash, expires_at) VALUES (?, ?, ?)", key, requestHash, now.Add(lockTimeout).Unix())
+
+	if errInsert == nil {
+		return entity.IdempotencyRecord{}, true, nil
+	}
+
+	if !errors.Is(translateMysqlError(errInsert), helper.ErrConflict) {
+		return entity.IdempotencyRecord{}, false, errInsert
+	}
+
+	record, errGet := store.get(ctx, key)
+
+	// Released between the insert and the read, the other request is
+	// still deciding.
+	if errors.Is(errGet, helper.ErrNotFound) {
+		return entity.IdempotencyRecord{Key: key, RequestHash: requestHash}, false, nil
+	}
+
+	if errGet != nil {
+		return entity.IdempotencyRecord{}, false, errGet
+	}
+
+	return record, false, nil
+}
+
+func (store *DbIdempotencyStore) Complete(ctx context.Context, record entity.IdempotencyRecord) error {
+	header, errMarshal := json.Marshal(record.Header)
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	return store.exec(ctx, "UPDATE idempotency_keys SET status_code = ?, response_header = ?, response_body = ?, expires_at = ? WHERE idempotency_key = ?", record.StatusCode, header, record.Body, record.ExpiresAt, record.Key)
+}
+
+func (store *DbIdempotencyStore) Release(ctx context.Context, key string) error {
+	return store.exec(ctx, "DELETE FROM idempotency_keys WHERE idempotency_key = ?", key)
+}
+
+func (store *DbIdempotencyStore) get(ctx context.Context, key string) (entity.IdempotencyRecord, error) {
+	query := "SELECT idempotency_key, request_hash, status_code, response_header, response_body, expires_at FROM idempotency_keys WHERE idempotency_key = ? LIMIT 1"
+
+	stmt, err := store.db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.IdempotencyRecord{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, key)
+
+	if queryErr != nil {
+		return entity.IdempotencyRecord{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		record := entity.IdempotencyRecord{}
+		header := sql.NullString{}
+
+		err := rows.Scan(&record.Key, &record.RequestHash, &record.StatusCode, &header, &record.Body, &record.ExpiresAt)
+
+		if err != nil {
+			return entity.IdempotencyRecord{}, err
+		}
+
+		if header.Valid {
+			if err := json.Unmarshal([]byte(header.String), &record.Header); err != nil {
+				return entity.IdempotencyRecord{}, err
+			}
+		}
+
+		return record, nil
+	}
+
+	return entity.IdempotencyRecord{}, helper.ErrNotFound
+}
+
+func (store *DbIdempotencyStore) exec(ctx context.Context, query string, args ...any) error {
+	stmt, errPrepare := store.db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, args...)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
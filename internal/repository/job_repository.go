@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"strings"
+	"time"
+)
+
+type JobRepository interface {
+	Insert(ctx context.Context, db *sql.DB, job entity.Job, delay time.Duration) error
+	InsertInTx(ctx context.Context, tx *sql.Tx, job entity.Job, delay time.Duration) error
+	GetDueForUpdate(ctx context.Context, tx *sql.Tx, jobTypes []string, limit int) ([]entity.Job, error)
+	Start(ctx context.Context, tx *sql.Tx, jobId int64, lease time.Duration) error
+	Complete(ctx context.Context, db *sql.DB, job entity.Job) error
+	RecordFailure(ctx context.Context, db *sql.DB, job entity.Job, retryIn time.Duration) error
+	Release(ctx context.Context, db *sql.DB, job entity.Job) error
+	DeleteFinished(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error)
+	InsertScheduleRun(ctx context.Context, tx *sql.Tx, scheduleName string, runAt time.Time) error
+	DeleteScheduleRuns(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error)
+}
+
+type JobRepositoryImpl struct {
+}
+
+func NewJobRepository() JobRepository {
+	return &JobRepositoryImpl{}
+}
+
+const (
+	jobColumns     = "id, job_type, payload, unique_key, status, attempts, run_at, last_error, finished_at, created_at"
+	insertJobQuery = "INSERT INTO jobs (job_type, payload, unique_key, run_at) VALUES (?, ?, NULLIF(?, ''), DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND)) ON DUPLICATE KEY UPDATE id = id"
+)
+
+// Insert queues the job to run after delay. A job with the UniqueKey of one
+// still pending or running is left out without an error, finished jobs give
+// up their key.
+func (repository JobRepositoryImpl) Insert(ctx context.Context, db *sql.DB, job entity.Job, delay time.Duration) error {
+	_, err := repository.exec(ctx, db, insertJobQuery, job.Type, job.Payload, job.UniqueKey, int(delay.Seconds()))
+
+	return err
+}
+
+// InsertInTx is Insert as part of tx, the job is only queued if tx commits.
+func (repository JobRepositoryImpl) InsertInTx(ctx context.Context, tx *sql.Tx, job entity.Job, delay time.Duration) error {
+	stmt, errPrepare := tx.PrepareContext(ctx, insertJobQuery)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, job.Type, job.Payload, job.UniqueKey, int(delay.Seconds()))
+
+	return errExec
+}
+
+// GetDueForUpdate locks the jobs of the given types that are due, pending
+// ones and running ones whose claim ran out, oldest first. Jobs another
+// transaction locked are skipped rather than waited for.
+func (repository JobRepositoryImpl) GetDueForUpdate(ctx context.Context, tx *sql.Tx, jobTypes []string, limit int) ([]entity.Job, error) {
+	if len(jobTypes) == 0 {
+		return []entity.Job{}, nil
+	}
+
+	query := "SELECT " + jobColumns + " FROM jobs WHERE status IN (?, ?) AND run_at <= CURRENT_TIMESTAMP AND job_type IN (?" + strings.Repeat(", ?", len(jobTypes)-1) + ") ORDER BY run_at, id LIMIT ? FOR UPDATE SKIP LOCKED"
+
+	args := []any{entity.JobPending, entity.JobRunning}
+
+	for _, jobType := range jobTypes {
+		args = append(args, jobType)
+	}
+
+	args = append(args, limit)
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, args...)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	jobs := []entity.Job{}
+
+	for rows.Next() {
+		job := entity.Job{}
+		uniqueKey := sql.NullString{}
+		lastError := sql.NullString{}
+		finishedAt := sql.NullString{}
+
+		err := rows.Scan(&job.Id, &job.Type, &job.Payload, &uniqueKey, &job.Status, &job.Attempts, &job.RunAt, &lastError, &finishedAt, &job.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		job.UniqueKey = uniqueKey.String
+		job.LastError = lastError.String
+		job.FinishedAt = finishedAt.String
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// Start counts an attempt of the job and claims it for lease.
+func (repository JobRepositoryImpl) Start(ctx context.Context, tx *sql.Tx, jobId int64, lease time.Duration) error {
+	query := "UPDATE jobs SET status = ?, attempts = attempts + 1, run_at = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND) WHERE id = ?"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, entity.JobRunning, int(lease.Seconds()), jobId)
+
+	return errExec
+}
+
+// Complete, RecordFailure and Release only save the outcome of the attempt
+// that still holds the job. Once its claim ran out and another runner took
+// the job over, they fail with ErrRowsNotAffected.
+func (repository JobRepositoryImpl) Complete(ctx context.Context, db *sql.DB, job entity.Job) error {
+	query := "UPDATE jobs SET status = ?, unique_key = NULL, last_error = NULL, finished_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ? AND attempts = ?"
+
+	return repository.execClaimed(ctx, db, query, entity.JobSucceeded, job.Id, entity.JobRunning, job.Attempts)
+}
+
+// RecordFailure saves the error of the last attempt, with the job either
+// pending again after retryIn or failed for good.
+func (repository JobRepositoryImpl) RecordFailure(ctx context.Context, db *sql.DB, job entity.Job, retryIn time.Duration) error {
+	query := "UPDATE jobs SET status = ?, unique_key = IF(? = ?, NULL, unique_key), last_error = ?, run_at = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND), finished_at = IF(? = ?, CURRENT_TIMESTAMP, NULL) WHERE id = ? AND status = ? AND attempts = ?"
+
+	return repository.execClaimed(ctx, db, query, job.Status, job.Status, entity.JobFailed, job.LastError, int(retryIn.Seconds()), job.Status, entity.JobFailed, job.Id, entity.JobRunning, job.Attempts)
+}
+
+// Release gives back a job that was cut short by a shutdown, without
+// counting the attempt.
+func (repository JobRepositoryImpl) Release(ctx context.Context, db *sql.DB, job entity.Job) error {
+	query := "UPDATE jobs SET status = ?, attempts = GREATEST(attempts, 1) - 1, run_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ? AND attempts = ?"
+
+	return repository.execClaimed(ctx, db, query, entity.JobPending, job.Id, entity.JobRunning, job.Attempts)
+}
+
+// DeleteFinished deletes up to limit jobs that finished more than olderThan
+// ago and returns how many it deleted.
+func (repository JobRepositoryImpl) DeleteFinished(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error) {
+	query := "DELETE FROM jobs WHERE finished_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND) LIMIT ?"
+
+	sqlResult, err := repository.exec(ctx, db, query, int(olderThan.Seconds()), limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return sqlResult.RowsAffected()
+}
+
+// InsertScheduleRun records that a run of the schedule was queued. It fails
+// with ErrConflict when another instance queued that run first.
+func (repository JobRepositoryImpl) InsertScheduleRun(ctx context.Context, tx *sql.Tx, scheduleName string, runAt time.Time) error {
+	query := "INSERT INTO job_schedule_runs (schedule_name, run_at) VALUES (?, FROM_UNIXTIME(?))"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, scheduleName, runAt.Unix())
+
+	return translateMysqlError(errExec)
+}
+
+// DeleteScheduleRuns deletes up to limit schedule runs queued more than
+// olderThan ago and returns how many it deleted.
+func (repository JobRepositoryImpl) DeleteScheduleRuns(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error) {
+	query := "DELETE FROM job_schedule_runs WHERE created_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND) LIMIT ?"
+
+	sqlResult, err := repository.exec(ctx, db, query, int(olderThan.Seconds()), limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return sqlResult.RowsAffected()
+}
+
+func (repository JobRepositoryImpl) execClaimed(ctx context.Context, db *sql.DB, query string, args ...any) error {
+	sqlResult, err := repository.exec(ctx, db, query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+func (repository JobRepositoryImpl) exec(ctx context.Context, db *sql.DB, query string, args ...any) (sql.Result, error) {
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	return stmt.ExecContext(ctx, args...)
+}
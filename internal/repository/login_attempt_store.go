@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"sync"
+	"time"
+)
+
+// LoginAttemptStore keeps the failed login counters. Unlike the other
+// repositories it owns its storage, so a single instance can use memory while
+// several instances behind a load balancer share the database one.
+type LoginAttemptStore interface {
+	Get(ctx context.Context, key string) (entity.LoginAttempt, error)
+	// Reserve counts an attempt before it's made, starting over when the
+	// previous one is older than window, and locks the key for as long as
+	// lockDuration says for the new count. A key that is locked already fails
+	// with a LoginThrottledError and isn't counted. Both happen at once, so
+	// concurrent attempts can't all slip in before the lock.
+	Reserve(ctx context.Context, key string, now time.Time, window time.Duration, lockDuration func(failures int) time.Duration) (entity.LoginAttempt, error)
+	// Release gives back one reserved attempt, leaving any lock in place.
+	Release(ctx context.Context, key string) error
+	Reset(ctx context.Context, key string) error
+}
+
+func reserveLoginAttempt(loginAttempt entity.LoginAttempt, now time.Time, window time.Duration, lockDuration func(failures int) time.Duration) (entity.LoginAttempt, error) {
+	if loginAttempt.LockedUntil > now.Unix() {
+		return loginAttempt, &helper.LoginThrottledError{RetryAfter: time.Unix(loginAttempt.LockedUntil, 0).Sub(now)}
+	}
+
+	if loginAttempt.LastFailureAt < now.Add(-window).Unix() {
+		loginAttempt.Failures = 0
+	}
+
+	loginAttempt.Failures++
+	loginAttempt.LastFailureAt = now.Unix()
+
+	if duration := lockDuration(loginAttempt.Failures); duration > 0 {
+		loginAttempt.LockedUntil = now.Add(duration).Unix()
+	}
+
+	return loginAttempt, nil
+}
+
+// memoryLoginAttemptSweepSize is how many counters the memory store holds
+// before it drops the ones that no longer matter.
+const memoryLoginAttemptSweepSize = 10000
+
+type MemoryLoginAttemptStore struct {
+	mutex    sync.Mutex
+	attempts map[string]entity.LoginAttempt
+}
+
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts: map[string]entity.LoginAttempt{},
+	}
+}
+
+func (store *MemoryLoginAttemptStore) Get(ctx context.Context, key string) (entity.LoginAttempt, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	loginAttempt, ok := store.attempts[key]
+
+	if !ok {
+		return entity.LoginAttempt{}, helper.ErrNotFound
+	}
+
+	return loginAttempt, nil
+}
+
+func (store *MemoryLoginAttemptStore) Reserve(ctx context.Context, key string, now time.Time, window time.Duration, lockDuration func(failures int) time.Duration) (entity.LoginAttempt, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if len(store.attempts) >= memoryLoginAttemptSweepSize {
+		store.sweep(now, window)
+	}
+
+	loginAttempt, ok := store.attempts[key]
+
+	if !ok {
+		loginAttempt = entity.LoginAttempt{Key: key}
+	}
+
+	loginAttempt, err := reserveLoginAttempt(loginAttempt, now, window, lockDuration)
+
+	if err != nil {
+		return loginAttempt, err
+	}
+
+	store.attempts[key] = loginAttempt
+
+	return loginAttempt, nil
+}
+
+func (store *MemoryLoginAttemptStore) Release(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	loginAttempt, ok := store.attempts[key]
+
+	if !ok || loginAttempt.Failures == 0 {
+		return nil
+	}
+
+	loginAttempt.Failures--
+
+	store.attempts[key] = loginAttempt
+
+	return nil
+}
+
+func (store *MemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.attempts, key)
+
+	return nil
+}
+
+// sweep drops counters whose failures have aged out and which aren't locked.
+func (store *MemoryLoginAttemptStore) sweep(now time.Time, window time.Duration) {
+	for key, loginAttempt := range store.attempts {
+		if loginAttempt.LastFailureAt < now.Add(-window).Unix() && loginAttempt.LockedUntil <= now.Unix() {
+			delete(store.attempts, key)
+		}
+	}
+}
+
+type DbLoginAttemptStore struct {
+	db *sql.DB
+}
+
+func NewDbLoginAttemptStore(db *sql.DB) LoginAttemptStore {
+	return &DbLoginAttemptStore{
+		db: db,
+	}
+}
+
+func (store *DbLoginAttemptStore) Get(ctx context.Context, key string) (entity.LoginAttempt, error) {
+	query := "SELECT attempt_key, failures, last_failure_at, locked_until FROM login_attempts WHERE attempt_key = ? LIMIT 1"
+
+	stmt, err := store.db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.LoginAttempt{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, key)
+
+	if queryErr != nil {
+		return entity.LoginAttempt{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		loginAttempt := entity.LoginAttempt{}
+
+		err := rows.Scan(&loginAttempt.Key, &loginAttempt.Failures, &loginAttempt.LastFailureAt, &loginAttempt.LockedUntil)
+
+		if err != nil {
+			return entity.LoginAttempt{}, err
+		}
+
+		return loginAttempt, nil
+	}
+
+	return entity.LoginAttempt{}, helper.ErrNotFound
+}
+
+// Reserve makes sure the row exists and then updates it under a row lock, so
+// reservations on several instances wait for each other.
+func (store *DbLoginAttemptStore) Reserve(ctx context.Context, key string, now time.Time, window time.Duration, lockDuration func(failures int) time.Duration) (entity.LoginAttempt, error) {
+	insertQuery := "INSERT IGNORE INTO login_attempts (attempt_key, failures, last_failure_at) VALUES (?, 0, 0)"
+
+	insertStmt, errPrepareInsert := store.db.PrepareContext(ctx, insertQuery)
+
+	if errPrepareInsert != nil {
+		return entity.LoginAttempt{}, errPrepareInsert
+	}
+
+	if _, errInsert := insertStmt.ExecContext(ctx, key); errInsert != nil {
+		return entity.LoginAttempt{}, errInsert
+	}
+
+	tx, errTxBegin := store.db.BeginTx(ctx, nil)
+
+	if errTxBegin != nil {
+		return entity.LoginAttempt{}, errTxBegin
+	}
+
+	defer tx.Rollback()
+
+	selectQuery := "SELECT attempt_key, failures, last_failure_at, locked_until FROM login_attempts WHERE attempt_key = ? FOR UPDATE"
+
+	selectStmt, errPrepareSelect := tx.PrepareContext(ctx, selectQuery)
+
+	if errPrepareSelect != nil {
+		return entity.LoginAttempt{}, errPrepareSelect
+	}
+
+	loginAttempt := entity.LoginAttempt{}
+
+	errScan := selectStmt.QueryRowContext(ctx, key).Scan(&loginAttempt.Key, &loginAttempt.Failures, &loginAttempt.LastFailureAt, &loginAttempt.LockedUntil)
+
+	if errScan != nil {
+		return entity.LoginAttempt{}, errScan
+	}
+
+	loginAttempt, errReserve := reserveLoginAttempt(loginAttempt, now, window, lockDuration)
+
+	if errReserve != nil {
+		return loginAttempt, errReserve
+	}
+
+	updateQuery := "UPDATE login_attempts SET failures = ?, last_failure_at = ?, locked_until = ? WHERE attempt_key = ?"
+
+	updateStmt, errPrepareUpdate := tx.PrepareContext(ctx, updateQuery)
+
+	if errPrepareUpdate != nil {
+		return entity.LoginAttempt{}, errPrepareUpdate
+	}
+
+	_, errUpdate := updateStmt.ExecContext(ctx, loginAttempt.Failures, loginAttempt.LastFailureAt, loginAttempt.LockedUntil, key)
+
+	if errUpdate != nil {
+		return entity.LoginAttempt{}, errUpdate
+	}
+
+	if errCommit := tx.Commit(); errCommit != nil {
+		return entity.LoginAttempt{}, errCommit
+	}
+
+	return loginAttempt, nil
+}
+
+func (store *DbLoginAttemptStore) Release(ctx context.Context, key string) error {
+	query := "UPDATE login_attempts SET failures = failures - 1 WHERE attempt_key = ? AND failures > 0"
+
+	stmt, errPrepare := store.db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, key)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+func (store *DbLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	query := "DELETE FROM login_attempts WHERE attempt_key = ?"
+
+	stmt, errPrepare := store.db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, key)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
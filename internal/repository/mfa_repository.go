@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+)
+
+type MfaRepository interface {
+	GetTotp(ctx context.Context, db *sql.DB, userId int) (entity.UserTotp, error)
+	SaveTotp(ctx context.Context, db *sql.DB, userTotp entity.UserTotp) error
+	EnableTotp(ctx context.Context, tx *sql.Tx, userId int) error
+	DeleteTotp(ctx context.Context, tx *sql.Tx, userId int) error
+	UpdateTotpLastUsedStep(ctx context.Context, db *sql.DB, userId int, step int64) error
+	InsertRecoveryCodes(ctx context.Context, tx *sql.Tx, userId int, codeHashes []string) error
+	DeleteRecoveryCodes(ctx context.Context, tx *sql.Tx, userId int) error
+	UseRecoveryCode(ctx context.Context, db *sql.DB, userId int, codeHash string) error
+}
+
+type MfaRepositoryImpl struct {
+}
+
+func NewMfaRepository() MfaRepository {
+	return &MfaRepositoryImpl{}
+}
+
+func (repository MfaRepositoryImpl) GetTotp(ctx context.Context, db *sql.DB, userId int) (entity.UserTotp, error) {
+	query := "SELECT user_id, secret, is_enabled, last_used_step FROM user_totps WHERE user_id = ? LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.UserTotp{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, userId)
+
+	if queryErr != nil {
+		return entity.UserTotp{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		userTotp := entity.UserTotp{}
+
+		err := rows.Scan(&userTotp.UserId, &userTotp.Secret, &userTotp.IsEnabled, &userTotp.LastUsedStep)
+
+		if err != nil {
+			return entity.UserTotp{}, err
+		}
+
+		return userTotp, nil
+	}
+
+	return entity.UserTotp{}, helper.ErrNotFound
+}
+
+func (repository MfaRepositoryImpl) SaveTotp(ctx context.Context, db *sql.DB, userTotp entity.UserTotp) error {
+	query := "INSERT INTO user_totps (user_id, secret, is_enabled) VALUES (?, ?, 0) ON DUPLICATE KEY UPDATE secret = VALUES(secret), is_enabled = 0, last_used_step = 0, confirmed_at = NULL"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, userTotp.UserId, userTotp.Secret)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository MfaRepositoryImpl) EnableTotp(ctx context.Context, tx *sql.Tx, userId int) error {
+	query := "UPDATE user_totps SET is_enabled = 1, confirmed_at = CURRENT_TIMESTAMP WHERE user_id = ? AND is_enabled = 0"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository MfaRepositoryImpl) DeleteTotp(ctx context.Context, tx *sql.Tx, userId int) error {
+	query := "DELETE FROM user_totps WHERE user_id = ?"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository MfaRepositoryImpl) UpdateTotpLastUsedStep(ctx context.Context, db *sql.DB, userId int, step int64) error {
+	// The step condition makes a code single use, even across concurrent requests.
+	query := "UPDATE user_totps SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, step, userId, step)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository MfaRepositoryImpl) InsertRecoveryCodes(ctx context.Context, tx *sql.Tx, userId int, codeHashes []string) error {
+	query := "INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	defer stmt.Close()
+
+	for _, codeHash := range codeHashes {
+		sqlResult, errExec := stmt.ExecContext(ctx, userId, codeHash)
+
+		if errExec != nil {
+			return errExec
+		}
+
+		err := helper.CheckRowsAffected(sqlResult)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repository MfaRepositoryImpl) DeleteRecoveryCodes(ctx context.Context, tx *sql.Tx, userId int) error {
+	query := "DELETE FROM user_recovery_codes WHERE user_id = ?"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+func (repository MfaRepositoryImpl) UseRecoveryCode(ctx context.Context, db *sql.DB, userId int, codeHash string) error {
+	query := "UPDATE user_recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE user_id = ? AND code_hash = ? AND used_at IS NULL"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, userId, codeHash)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
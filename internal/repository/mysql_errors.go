@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+	"go_todo_api/internal/helper"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlErrDuplicateEntry  = 1062
+	mysqlErrNoReferencedRow = 1452
+)
+
+var (
+	// MySQL 8 prefixes the key with the table name ("for key 'users.email'"),
+	// older versions don't.
+	duplicateKeyPattern = regexp.MustCompile(`for key '([^']+)'`)
+	foreignKeyPattern   = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`\\)")
+)
+
+// translateMysqlError turns constraint violations into errors naming the
+// offending column, other errors are returned as they are. Unique keys are
+// named after their first column, which is what MySQL does for keys declared
+// inline.
+func translateMysqlError(err error) error {
+	var mysqlError *mysql.MySQLError
+
+	if !errors.As(err, &mysqlError) {
+		return err
+	}
+
+	switch mysqlError.Number {
+	case mysqlErrDuplicateEntry:
+		if match := duplicateKeyPattern.FindStringSubmatch(mysqlError.Message); match != nil {
+			key := match[1]
+
+			if index := strings.LastIndex(key, "."); index >= 0 {
+				key = key[index+1:]
+			}
+
+			return helper.NewConflictError(key)
+		}
+	case mysqlErrNoReferencedRow:
+		if match := foreignKeyPattern.FindStringSubmatch(mysqlError.Message); match != nil {
+			return helper.NewReferenceNotFoundError(match[1])
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+)
+
+type NotificationRepository interface {
+	GetSettings(ctx context.Context, db *sql.DB, userId int) (entity.NotificationSettings, error)
+	SaveSettings(ctx context.Context, db *sql.DB, settings entity.NotificationSettings) error
+	SaveSubscription(ctx context.Context, db *sql.DB, subscription entity.PushSubscription) (int, error)
+	GetSubscription(ctx context.Context, db *sql.DB, userId int, subscriptionId int) (entity.PushSubscription, error)
+	GetUserSubscriptions(ctx context.Context, db *sql.DB, userId int, channel string) ([]entity.PushSubscription, error)
+	DeleteSubscription(ctx context.Context, db *sql.DB, userId int, subscriptionId int) error
+}
+
+type NotificationRepositoryImpl struct {
+}
+
+func NewNotificationRepository() NotificationRepository {
+	return &NotificationRepositoryImpl{}
+}
+
+const pushSubscriptionColumns = "id, user_id, channel, endpoint, p256dh, auth, created_at"
+
+func (repository NotificationRepositoryImpl) GetSettings(ctx context.Context, db *sql.DB, userId int) (entity.NotificationSettings, error) {
+	query := "SELECT user_id, channels, timezone, quiet_hours_start, quiet_hours_end, updated_at FROM notification_settings WHERE user_id = ? LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.NotificationSettings{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, userId)
+
+	if queryErr != nil {
+		return entity.NotificationSettings{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		settings := entity.NotificationSettings{}
+		quietHoursStart := sql.NullString{}
+		quietHoursEnd := sql.NullString{}
+
+		err := rows.Scan(&settings.UserId, &settings.Channels, &settings.Timezone, &quietHoursStart, &quietHoursEnd, &settings.UpdatedAt)
+
+		if err != nil {
+			return entity.NotificationSettings{}, err
+		}
+
+		settings.QuietHoursStart = quietHoursStart.String
+		settings.QuietHoursEnd = quietHoursEnd.String
+
+		return settings, nil
+	}
+
+	return entity.NotificationSettings{}, helper.ErrNotFound
+}
+
+func (repository NotificationRepositoryImpl) SaveSettings(ctx context.Context, db *sql.DB, settings entity.NotificationSettings) error {
+	query := "INSERT INTO notification_settings (user_id, channels, timezone, quiet_hours_start, quiet_hours_end) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, '')) ON DUPLICATE KEY UPDATE channels = VALUES(channels), timezone = VALUES(timezone), quiet_hours_start = VALUES(quiet_hours_start), quiet_hours_end = VALUES(quiet_hours_end)"
+
+	_, err := repository.exec(ctx, db, query, settings.UserId, settings.Channels, settings.Timezone, settings.QuietHoursStart, settings.QuietHoursEnd)
+
+	return err
+}
+
+// SaveSubscription adds the subscription and returns its id. An endpoint that
+// is already subscribed moves to the new user and keys, a device signed in
+// to another account only gets that account's reminders.
+func (repository NotificationRepositoryImpl) SaveSubscription(ctx context.Context, db *sql.DB, subscription entity.PushSubscription) (int, error) {
+	query := "INSERT INTO push_subscriptions (user_id, channel, endpoint, p256dh, auth) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, '')) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), user_id = VALUES(user_id), channel = VALUES(channel), p256dh = VALUES(p256dh), auth = VALUES(auth)"
+
+	sqlResult, err := repository.exec(ctx, db, query, subscription.UserId, subscription.Channel, subscription.Endpoint, subscription.P256dh, subscription.Auth)
+
+	if err != nil {
+		return 0, translateMysqlError(err)
+	}
+
+	lastInsertId, errLastInsertId := sqlResult.LastInsertId()
+
+	if errLastInsertId != nil {
+		return 0, errLastInsertId
+	}
+
+	return int(lastInsertId), nil
+}
+
+func (repository NotificationRepositoryImpl) GetSubscription(ctx context.Context, db *sql.DB, userId int, subscriptionId int) (entity.PushSubscription, error) {
+	query := "SELECT " + pushSubscriptionColumns + " FROM push_subscriptions WHERE id = ? AND user_id = ? LIMIT 1"
+
+	subscriptions, err := repository.query(ctx, db, query, subscriptionId, userId)
+
+	if err != nil {
+		return entity.PushSubscription{}, err
+	}
+
+	if len(subscriptions) == 0 {
+		return entity.PushSubscription{}, helper.ErrNotFound
+	}
+
+	return subscriptions[0], nil
+}
+
+// GetUserSubscriptions returns the user's subscriptions to channel, or to
+// every channel when it is empty.
+func (repository NotificationRepositoryImpl) GetUserSubscriptions(ctx context.Context, db *sql.DB, userId int, channel string) ([]entity.PushSubscription, error) {
+	query := "SELECT " + pushSubscriptionColumns + " FROM push_subscriptions WHERE user_id = ? AND (? = '' OR channel = ?) ORDER BY id"
+
+	return repository.query(ctx, db, query, userId, channel, channel)
+}
+
+func (repository NotificationRepositoryImpl) DeleteSubscription(ctx context.Context, db *sql.DB, userId int, subscriptionId int) error {
+	query := "DELETE FROM push_subscriptions WHERE id = ? AND user_id = ?"
+
+	sqlResult, err := repository.exec(ctx, db, query, subscriptionId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+func (repository NotificationRepositoryImpl) query(ctx context.Context, db *sql.DB, query string, args ...any) ([]entity.PushSubscription, error) {
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, args...)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	subscriptions := []entity.PushSubscription{}
+
+	for rows.Next() {
+		subscription := entity.PushSubscription{}
+		p256dh := sql.NullString{}
+		auth := sql.NullString{}
+
+		err := rows.Scan(&subscription.Id, &subscription.UserId, &subscription.Channel, &subscription.Endpoint, &p256dh, &auth, &subscription.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		subscription.P256dh = p256dh.String
+		subscription.Auth = auth.String
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+func (repository NotificationRepositoryImpl) exec(ctx context.Context, db *sql.DB, query string, args ...any) (sql.Result, error) {
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	return stmt.ExecContext(ctx, args...)
+}
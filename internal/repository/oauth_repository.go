@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+)
+
+type OauthRepository interface {
+	InsertClient(ctx context.Context, db *sql.DB, oauthClient entity.OauthClient) (int, error)
+	GetClient(ctx context.Context, db *sql.DB, clientId string) (entity.OauthClient, error)
+	GetClients(ctx context.Context, db *sql.DB) ([]entity.OauthClient, error)
+	DeleteClient(ctx context.Context, db *sql.DB, clientId string) error
+	GetConsent(ctx context.Context, db *sql.DB, userId int, clientId string) (entity.OauthConsent, error)
+	SaveConsent(ctx context.Context, db *sql.DB, oauthConsent entity.OauthConsent) error
+	InsertAuthorizationCode(ctx context.Context, db *sql.DB, authorizationCode entity.OauthAuthorizationCode) error
+	ConsumeAuthorizationCode(ctx context.Context, db *sql.DB, codeHash string) (entity.OauthAuthorizationCode, error)
+	InsertToken(ctx context.Context, tx *sql.Tx, oauthToken entity.OauthToken, expiresInSeconds int, refreshExpiresInDays int) error
+	GetActiveTokenByAccessHash(ctx context.Context, db *sql.DB, accessTokenHash string) (entity.OauthToken, error)
+	GetActiveTokenByRefreshHash(ctx context.Context, db *sql.DB, refreshTokenHash string) (entity.OauthToken, error)
+	RevokeToken(ctx context.Context, tx *sql.Tx, tokenId int) error
+	RevokeUserTokens(ctx context.Context, db *sql.DB, userId int) error
+}
+
+type OauthRepositoryImpl struct {
+}
+
+func NewOauthRepository() OauthRepository {
+	return &OauthRepositoryImpl{}
+}
+
+const oauthClientColumns = "id, client_id, client_secret_hash, name, redirect_uris, scopes, grant_types, created_at"
+
+func scanOauthClient(rows *sql.Rows) (entity.OauthClient, error) {
+	oauthClient := entity.OauthClient{}
+	clientSecretHash := sql.NullString{}
+
+	err := rows.Scan(&oauthClient.Id, &oauthClient.ClientId, &clientSecretHash, &oauthClient.Name, &oauthClient.RedirectUris, &oauthClient.Scopes, &oauthClient.GrantTypes, &oauthClient.CreatedAt)
+
+	if err != nil {
+		return entity.OauthClient{}, err
+	}
+
+	oauthClient.ClientSecretHash = clientSecretHash.String
+
+	return oauthClient, nil
+}
+
+const oauthTokenColumns = "oauth_tokens.id, oauth_tokens.client_id, oauth_tokens.user_id, users.username, users.role, users.locale, oauth_tokens.scopes, oauth_tokens.access_token_hash, oauth_tokens.refresh_token_hash, oauth_tokens.expires_at"
+
+func scanOauthToken(rows *sql.Rows) (entity.OauthToken, error) {
+	oauthToken := entity.OauthToken{}
+	userId := sql.NullInt64{}
+	username := sql.NullString{}
+	role := sql.NullString{}
+	locale := sql.NullString{}
+	refreshTokenHash := sql.NullString{}
+
+	err := rows.Scan(&oauthToken.Id, &oauthToken.ClientId, &userId, &username, &role, &locale, &oauthToken.Scopes, &oauthToken.AccessTokenHash, &refreshTokenHash, &oauthToken.ExpiresAt)
+
+	if err != nil {
+		return entity.OauthToken{}, err
+	}
+
+	oauthToken.UserId = int(userId.Int64)
+	oauthToken.Username = username.String
+	oauthToken.Role = role.String
+	oauthToken.Locale = locale.String
+	oauthToken.RefreshTokenHash = refreshTokenHash.String
+
+	return oauthToken, nil
+}
+
+func (repository OauthRepositoryImpl) InsertClient(ctx context.Context, db *sql.DB, oauthClient entity.OauthClient) (int, error) {
+	query := "INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, scopes, grant_types) VALUES (?, ?, ?, ?, ?, ?)"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return 0, errPrepare
+	}
+
+	clientSecretHash := sql.NullString{String: oauthClient.ClientSecretHash, Valid: oauthClient.ClientSecretHash != ""}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, oauthClient.ClientId, clientSecretHash, oauthClient.Name, oauthClient.RedirectUris, oauthClient.Scopes, oauthClient.GrantTypes)
+
+	if errExec != nil {
+		return 0, translateMysqlError(errExec)
+	}
+
+	lastInsertId, errLastInsertId := sqlResult.LastInsertId()
+
+	if errLastInsertId != nil {
+		return 0, errLastInsertId
+	}
+
+	return int(lastInsertId), nil
+}
+
+func (repository OauthRepositoryImpl) GetClient(ctx context.Context, db *sql.DB, clientId string) (entity.OauthClient, error) {
+	query := "SELECT " + oauthClientColumns + " FROM oauth_clients WHERE client_id = ? LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.OauthClient{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, clientId)
+
+	if queryErr != nil {
+		return entity.OauthClient{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanOauthClient(rows)
+	}
+
+	return entity.OauthClient{}, helper.ErrNotFound
+}
+
+func (repository OauthRepositoryImpl) GetClients(ctx context.Context, db *sql.DB) ([]entity.OauthClient, error) {
+	query := "SELECT " + oauthClientColumns + " FROM oauth_clients ORDER BY id"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	oauthClients := []entity.OauthClient{}
+
+	for rows.Next() {
+		oauthClient, err := scanOauthClient(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		oauthClients = append(oauthClients, oauthClient)
+	}
+
+	return oauthClients, nil
+}
+
+func (repository OauthRepositoryImpl) DeleteClient(ctx context.Context, db *sql.DB, clientId string) error {
+	query := "DELETE FROM oauth_clients WHERE client_id = ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, clientId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+func (repository OauthRepositoryImpl) GetConsent(ctx context.Context, db *sql.DB, userId int, clientId string) (entity.OauthConsent, error) {
+	query := "SELECT user_id, client_id, scopes FROM oauth_consents WHERE user_id = ? AND client_id = ? LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.OauthConsent{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, userId, clientId)
+
+	if queryErr != nil {
+		return entity.OauthConsent{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		oauthConsent := entity.OauthConsent{}
+
+		err := rows.Scan(&oauthConsent.UserId, &oauthConsent.ClientId, &oauthConsent.Scopes)
+
+		if err != nil {
+			return entity.OauthConsent{}, err
+		}
+
+		return oauthConsent, nil
+	}
+
+	return entity.OauthConsent{}, helper.ErrNotFound
+}
+
+func (repository OauthRepositoryImpl) SaveConsent(ctx context.Context, db *sql.DB, oauthConsent entity.OauthConsent) error {
+	query := "INSERT INTO oauth_consents (user_id, client_id, scopes) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE scopes = VALUES(scopes)"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, oauthConsent.UserId, oauthConsent.ClientId, oauthConsent.Scopes)
+
+	if errExec != nil {
+		return translateMysqlError(errExec)
+	}
+
+	return nil
+}
+
+func (repository OauthRepositoryImpl) InsertAuthorizationCode(ctx context.Context, db *sql.DB, authorizationCode entity.OauthAuthorizationCode) error {
+	query := "INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at) VALUES (?, ?, ?, ?, ?, ?, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL 10 MINUTE))"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, authorizationCode.CodeHash, authorizationCode.ClientId, authorizationCode.UserId, authorizationCode.RedirectUri, authorizationCode.Scopes, authorizationCode.CodeChallenge)
+
+	if errExec != nil {
+		return translateMysqlError(errExec)
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+// ConsumeAuthorizationCode returns an unexpired authorization code and deletes
+// it, so a code can only be exchanged once.
+func (repository OauthRepositoryImpl) ConsumeAuthorizationCode(ctx context.Context, db *sql.DB, codeHash string) (entity.OauthAuthorizationCode, error) {
+	query := "SELECT code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at FROM oauth_authorization_codes WHERE code_hash = ? AND expires_at > CURRENT_TIMESTAMP LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.OauthAuthorizationCode{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, codeHash)
+
+	if queryErr != nil {
+		return entity.OauthAuthorizationCode{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return entity.OauthAuthorizationCode{}, helper.ErrNotFound
+	}
+
+	authorizationCode := entity.OauthAuthorizationCode{}
+
+	errScan := rows.Scan(&authorizationCode.CodeHash, &authorizationCode.ClientId, &authorizationCode.UserId, &authorizationCode.RedirectUri, &authorizationCode.Scopes, &authorizationCode.CodeChallenge, &authorizationCode.ExpiresAt)
+
+	if errScan != nil {
+		return entity.OauthAuthorizationCode{}, errScan
+	}
+
+	rows.Close()
+
+	deleteStmt, errPrepareDelete := db.PrepareContext(ctx, "DELETE FROM oauth_authorization_codes WHERE code_hash = ?")
+
+	if errPrepareDelete != nil {
+		return entity.OauthAuthorizationCode{}, errPrepareDelete
+	}
+
+	sqlResult, errExec := deleteStmt.ExecContext(ctx, codeHash)
+
+	if errExec != nil {
+		return entity.OauthAuthorizationCode{}, errExec
+	}
+
+	// Another request exchanged the code between the select and the delete.
+	if err := helper.CheckRowsAffected(sqlResult); err != nil {
+		return entity.OauthAuthorizationCode{}, helper.ErrNotFound
+	}
+
+	return authorizationCode, nil
+}
+
+func (repository OauthRepositoryImpl) InsertToken(ctx context.Context, tx *sql.Tx, oauthToken entity.OauthToken, expiresInSeconds int, refreshExpiresInDays int) error {
+	query := "INSERT INTO oauth_tokens (client_id, user_id, scopes, access_token_hash, refresh_token_hash, expires_at, refresh_expires_at) VALUES (?, ?, ?, ?, ?, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND), IF(? IS NULL, NULL, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? DAY)))"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	userId := sql.NullInt64{Int64: int64(oauthToken.UserId), Valid: oauthToken.UserId != 0}
+	refreshTokenHash := sql.NullString{String: oauthToken.RefreshTokenHash, Valid: oauthToken.RefreshTokenHash != ""}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, oauthToken.ClientId, userId, oauthToken.Scopes, oauthToken.AccessTokenHash, refreshTokenHash, expiresInSeconds, refreshTokenHash, refreshExpiresInDays)
+
+	if errExec != nil {
+		return translateMysqlError(errExec)
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+func (repository OauthRepositoryImpl) GetActiveTokenByAccessHash(ctx context.Context, db *sql.DB, accessTokenHash string) (entity.OauthToken, error) {
+	query := "SELECT " + oauthTokenColumns + " FROM oauth_tokens LEFT JOIN users ON users.id = oauth_tokens.user_id WHERE oauth_tokens.access_token_hash = ? AND oauth_tokens.revoked_at IS NULL AND oauth_tokens.expires_at > CURRENT_TIMESTAMP AND (oauth_tokens.user_id IS NULL OR users.is_disabled = 0) LIMIT 1"
+
+	return repository.getToken(ctx, db, query, accessTokenHash)
+}
+
+func (repository OauthRepositoryImpl) GetActiveTokenByRefreshHash(ctx context.Context, db *sql.DB, refreshTokenHash string) (entity.OauthToken, error) {
+	query := "SELECT " + oauthTokenColumns + " FROM oauth_tokens LEFT JOIN users ON users.id = oauth_tokens.user_id WHERE oauth_tokens.refresh_token_hash = ? AND oauth_tokens.revoked_at IS NULL AND oauth_tokens.refresh_expires_at > CURRENT_TIMESTAMP AND (oauth_tokens.user_id IS NULL OR users.is_disabled = 0) LIMIT 1"
+
+	return repository.getToken(ctx, db, query, refreshTokenHash)
+}
+
+func (repository OauthRepositoryImpl) getToken(ctx context.Context, db *sql.DB, query string, tokenHash string) (entity.OauthToken, error) {
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.OauthToken{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, tokenHash)
+
+	if queryErr != nil {
+		return entity.OauthToken{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanOauthToken(rows)
+	}
+
+	return entity.OauthToken{}, helper.ErrNotFound
+}
+
+// RevokeToken fails with ErrRowsNotAffected when the token was already
+// revoked, which is how a refresh token used twice concurrently is caught.
+func (repository OauthRepositoryImpl) RevokeToken(ctx context.Context, tx *sql.Tx, tokenId int) error {
+	query := "UPDATE oauth_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, tokenId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+// RevokeUserTokens revokes every token the user granted to an app, a user
+// without any is fine.
+func (repository OauthRepositoryImpl) RevokeUserTokens(ctx context.Context, db *sql.DB, userId int) error {
+	query := "UPDATE oauth_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, userId)
+
+	return errExec
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+)
+
+type OidcRepository interface {
+	GetIdentity(ctx context.Context, db *sql.DB, provider string, subject string) (entity.UserIdentity, error)
+	InsertIdentity(ctx context.Context, tx *sql.Tx, userIdentity entity.UserIdentity) error
+	InsertLoginState(ctx context.Context, db *sql.DB, loginState entity.OidcLoginState) error
+	ConsumeLoginState(ctx context.Context, db *sql.DB, state string) (entity.OidcLoginState, error)
+}
+
+type OidcRepositoryImpl struct {
+}
+
+func NewOidcRepository() OidcRepository {
+	return &OidcRepositoryImpl{}
+}
+
+func (repository OidcRepositoryImpl) GetIdentity(ctx context.Context, db *sql.DB, provider string, subject string) (entity.UserIdentity, error) {
+	query := "SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE provider = ? AND subject = ? LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.UserIdentity{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, provider, subject)
+
+	if queryErr != nil {
+		return entity.UserIdentity{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		userIdentity := entity.UserIdentity{}
+
+		err := rows.Scan(&userIdentity.Id, &userIdentity.UserId, &userIdentity.Provider, &userIdentity.Subject, &userIdentity.Email, &userIdentity.CreatedAt)
+
+		if err != nil {
+			return entity.UserIdentity{}, err
+		}
+
+		return userIdentity, nil
+	}
+
+	return entity.UserIdentity{}, helper.ErrNotFound
+}
+
+func (repository OidcRepositoryImpl) InsertIdentity(ctx context.Context, tx *sql.Tx, userIdentity entity.UserIdentity) error {
+	query := "INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, userIdentity.UserId, userIdentity.Provider, userIdentity.Subject, userIdentity.Email)
+
+	if errExec != nil {
+		return translateMysqlError(errExec)
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+func (repository OidcRepositoryImpl) InsertLoginState(ctx context.Context, db *sql.DB, loginState entity.OidcLoginState) error {
+	query := "INSERT INTO oidc_login_states (state, provider, nonce, code_verifier, expires_at) VALUES (?, ?, ?, ?, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL 10 MINUTE))"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, loginState.State, loginState.Provider, loginState.Nonce, loginState.CodeVerifier)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+// ConsumeLoginState returns an unexpired login state and deletes it, so a
+// callback can only be completed once.
+func (repository OidcRepositoryImpl) ConsumeLoginState(ctx context.Context, db *sql.DB, state string) (entity.OidcLoginState, error) {
+	query := "SELECT state, provider, nonce, code_verifier, expires_at FROM oidc_login_states WHERE state = ? AND expires_at > CURRENT_TIMESTAMP LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.OidcLoginState{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, state)
+
+	if queryErr != nil {
+		return entity.OidcLoginState{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return entity.OidcLoginState{}, helper.ErrNotFound
+	}
+
+	loginState := entity.OidcLoginState{}
+
+	errScan := rows.Scan(&loginState.State, &loginState.Provider, &loginState.Nonce, &loginState.CodeVerifier, &loginState.ExpiresAt)
+
+	if errScan != nil {
+		return entity.OidcLoginState{}, errScan
+	}
+
+	rows.Close()
+
+	deleteStmt, errPrepareDelete := db.PrepareContext(ctx, "DELETE FROM oidc_login_states WHERE state = ?")
+
+	if errPrepareDelete != nil {
+		return entity.OidcLoginState{}, errPrepareDelete
+	}
+
+	sqlResult, errExec := deleteStmt.ExecContext(ctx, state)
+
+	if errExec != nil {
+		return entity.OidcLoginState{}, errExec
+	}
+
+	// Another request consumed the state between the select and the delete.
+	if err := helper.CheckRowsAffected(sqlResult); err != nil {
+		return entity.OidcLoginState{}, helper.ErrNotFound
+	}
+
+	return loginState, nil
+}
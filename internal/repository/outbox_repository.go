@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"strings"
+	"time"
+)
+
+type OutboxRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, event entity.OutboxEvent) error
+	GetPending(ctx context.Context, db *sql.DB, limit int) ([]entity.OutboxEvent, error)
+	GetLastId(ctx context.Context, db *sql.DB) (int64, error)
+	GetAfter(ctx context.Context, db *sql.DB, afterId int64, limit int) ([]entity.OutboxEvent, error)
+	GetByIds(ctx context.Context, db *sql.DB, eventIds []int64) ([]entity.OutboxEvent, error)
+	Claim(ctx context.Context, db *sql.DB, eventId int64, lease time.Duration) error
+	MarkPublished(ctx context.Context, db *sql.DB, eventId int64) error
+	RecordFailure(ctx context.Context, db *sql.DB, event entity.OutboxEvent, retryIn time.Duration) error
+	DeletePublished(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error)
+}
+
+type OutboxRepositoryImpl struct {
+}
+
+func NewOutboxRepository() OutboxRepository {
+	return &OutboxRepositoryImpl{}
+}
+
+const outboxColumns = "id, event_id, user_id, event_type, payload, published_to, attempts, next_attempt_at, last_error, published_at, created_at"
+
+func (repository OutboxRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, event entity.OutboxEvent) error {
+	query := "INSERT INTO outbox (event_id, user_id, event_type, payload) VALUES (?, ?, ?, ?)"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, event.EventId, event.UserId, event.EventType, event.Payload)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+// GetPending returns the unpublished events that are due, oldest first.
+func (repository OutboxRepositoryImpl) GetPending(ctx context.Context, db *sql.DB, limit int) ([]entity.OutboxEvent, error) {
+	query := "SELECT " + outboxColumns + " FROM outbox WHERE published_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP ORDER BY id LIMIT ?"
+
+	return repository.getEvents(ctx, db, query, limit)
+}
+
+func (repository OutboxRepositoryImpl) GetLastId(ctx context.Context, db *sql.DB) (int64, error) {
+	query := "SELECT COALESCE(MAX(id), 0) FROM outbox"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return 0, errPrepare
+	}
+
+	lastId := int64(0)
+
+	err := stmt.QueryRowContext(ctx).Scan(&lastId)
+
+	return lastId, err
+}
+
+// GetAfter returns the events written after afterId, published or not,
+// oldest first.
+func (repository OutboxRepositoryImpl) GetAfter(ctx context.Context, db *sql.DB, afterId int64, limit int) ([]entity.OutboxEvent, error) {
+	query := "SELECT " + outboxColumns + " FROM outbox WHERE id > ? ORDER BY id LIMIT ?"
+
+	return repository.getEvents(ctx, db, query, afterId, limit)
+}
+
+func (repository OutboxRepositoryImpl) GetByIds(ctx context.Context, db *sql.DB, eventIds []int64) ([]entity.OutboxEvent, error) {
+	if len(eventIds) == 0 {
+		return []entity.OutboxEvent{}, nil
+	}
+
+	args := []any{}
+
+	for _, eventId := range eventIds {
+		args = append(args, eventId)
+	}
+
+	query := "SELECT " + outboxColumns + " FROM outbox WHERE id IN (?" + strings.Repeat(", ?", len(eventIds)-1) + ") ORDER BY id"
+
+	return repository.getEvents(ctx, db, query, args...)
+}
+
+// Claim moves the next attempt of the event behind the lease, so no other
+// relay picks it up while it is being published. Only one of two relays
+// claiming the same event succeeds, the other gets ErrRowsNotAffected.
+func (repository OutboxRepositoryImpl) Claim(ctx context.Context, db *sql.DB, eventId int64, lease time.Duration) error {
+	query := "UPDATE outbox SET next_attempt_at = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND) WHERE id = ? AND published_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP"
+
+	sqlResult, err := repository.exec(ctx, db, query, int(lease.Seconds()), eventId)
+
+	if err != nil {
+		return err
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+func (repository OutboxRepositoryImpl) MarkPublished(ctx context.Context, db *sql.DB, eventId int64) error {
+	query := "UPDATE outbox SET published_at = CURRENT_TIMESTAMP, last_error = NULL WHERE id = ?"
+
+	_, err := repository.exec(ctx, db, query, eventId)
+
+	return err
+}
+
+// RecordFailure saves the sinks that took the event so far and when the
+// others are tried again.
+func (repository OutboxRepositoryImpl) RecordFailure(ctx context.Context, db *sql.DB, event entity.OutboxEvent, retryIn time.Duration) error {
+	query := "UPDATE outbox SET published_to = ?, attempts = ?, last_error = ?, next_attempt_at = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND) WHERE id = ?"
+
+	_, err := repository.exec(ctx, db, query, event.PublishedTo, event.Attempts, event.LastError, int(retryIn.Seconds()), event.Id)
+
+	return err
+}
+
+// DeletePublished deletes up to limit events published more than olderThan
+// ago and returns how many it deleted.
+func (repository OutboxRepositoryImpl) DeletePublished(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error) {
+	query := "DELETE FROM outbox WHERE published_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND) LIMIT ?"
+
+	sqlResult, err := repository.exec(ctx, db, query, int(olderThan.Seconds()), limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return sqlResult.RowsAffected()
+}
+
+func (repository OutboxRepositoryImpl) exec(ctx context.Context, db *sql.DB, query string, args ...any) (sql.Result, error) {
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (repository OutboxRepositoryImpl) getEvents(ctx context.Context, db *sql.DB, query string, args ...any) ([]entity.OutboxEvent, error) {
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, args...)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	events := []entity.OutboxEvent{}
+
+	for rows.Next() {
+		event := entity.OutboxEvent{}
+		lastError := sql.NullString{}
+		publishedAt := sql.NullString{}
+
+		err := rows.Scan(&event.Id, &event.EventId, &event.UserId, &event.EventType, &event.Payload, &event.PublishedTo, &event.Attempts, &event.NextAttemptAt, &lastError, &publishedAt, &event.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		event.LastError = lastError.String
+		event.PublishedAt = publishedAt.String
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"time"
+)
+
+type ReminderRepository interface {
+	GetDue(ctx context.Context, db *sql.DB, window time.Duration, limit int) ([]entity.Reminder, error)
+	Insert(ctx context.Context, tx *sql.Tx, reminder entity.Reminder) (int64, error)
+	Get(ctx context.Context, db *sql.DB, reminderId int64) (entity.Reminder, error)
+	Update(ctx context.Context, db *sql.DB, reminder entity.Reminder) error
+	AddSentTo(ctx context.Context, db *sql.DB, reminderId int64, targetKey string) error
+	DeleteOld(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error)
+}
+
+type ReminderRepositoryImpl struct {
+}
+
+func NewReminderRepository() ReminderRepository {
+	return &ReminderRepositoryImpl{}
+}
+
+// GetDue returns the reminders that should exist but don't yet, one for each
+// open todo whose reminder time passed less than window ago. Todos due longer
+// ago than that, say while reminders were switched off, are not reminded of.
+func (repository ReminderRepositoryImpl) GetDue(ctx context.Context, db *sql.DB, window time.Duration, limit int) ([]entity.Reminder, error) {
+	query := "SELECT t.id, t.user_id, UNIX_TIMESTAMP(t.remind_at) FROM todos t WHERE t.remind_at <= CURRENT_TIMESTAMP AND t.remind_at > DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND) AND t.is_done = 0 AND NOT EXISTS (SELECT 1 FROM reminders r WHERE r.todo_id = t.id AND r.remind_at = t.remind_at) ORDER BY t.remind_at, t.id LIMIT ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, int(window.Seconds()), limit)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	reminders := []entity.Reminder{}
+
+	for rows.Next() {
+		reminder := entity.Reminder{Status: entity.ReminderPending}
+
+		err := rows.Scan(&reminder.TodoId, &reminder.UserId, &reminder.RemindAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, nil
+}
+
+// Insert returns an error matching helper.ErrConflict when the todo already
+// has a reminder for the same time.
+func (repository ReminderRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, reminder entity.Reminder) (int64, error) {
+	query := "INSERT INTO reminders (todo_id, user_id, remind_at) VALUES (?, ?, FROM_UNIXTIME(?))"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return 0, errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, reminder.TodoId, reminder.UserId, reminder.RemindAt)
+
+	if errExec != nil {
+		return 0, translateMysqlError(errExec)
+	}
+
+	return sqlResult.LastInsertId()
+}
+
+func (repository ReminderRepositoryImpl) Get(ctx context.Context, db *sql.DB, reminderId int64) (entity.Reminder, error) {
+	query := "SELECT id, todo_id, user_id, UNIX_TIMESTAMP(remind_at), status, sent_to, last_error, finished_at, created_at FROM reminders WHERE id = ? LIMIT 1"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return entity.Reminder{}, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, reminderId)
+
+	if queryErr != nil {
+		return entity.Reminder{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		reminder := entity.Reminder{}
+		lastError := sql.NullString{}
+		finishedAt := sql.NullString{}
+
+		err := rows.Scan(&reminder.Id, &reminder.TodoId, &reminder.UserId, &reminder.RemindAt, &reminder.Status, &reminder.SentTo, &lastError, &finishedAt, &reminder.CreatedAt)
+
+		if err != nil {
+			return entity.Reminder{}, err
+		}
+
+		reminder.LastError = lastError.String
+		reminder.FinishedAt = finishedAt.String
+
+		return reminder, nil
+	}
+
+	return entity.Reminder{}, helper.ErrNotFound
+}
+
+// Update saves the status, channels and error of the reminder. It counts as
+// finished once it is no longer pending.
+func (repository ReminderRepositoryImpl) Update(ctx context.Context, db *sql.DB, reminder entity.Reminder) error {
+	query := "UPDATE reminders SET status = ?, sent_to = ?, last_error = NULLIF(?, ''), finished_at = IF(? = ?, NULL, CURRENT_TIMESTAMP) WHERE id = ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, reminder.Status, reminder.SentTo, reminder.LastError, reminder.Status, entity.ReminderPending, reminder.Id)
+
+	return errExec
+}
+
+// AddSentTo records that the reminder reached one more target, leaving the
+// rest of it as it is.
+func (repository ReminderRepositoryImpl) AddSentTo(ctx context.Context, db *sql.DB, reminderId int64, targetKey string) error {
+	query := "UPDATE reminders SET sent_to = TRIM(CONCAT(sent_to, ' ', ?)) WHERE id = ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, targetKey, reminderId)
+
+	return errExec
+}
+
+// DeleteOld deletes up to limit reminders created more than olderThan ago
+// and returns how many it deleted. Pending ones whose job gave up go too.
+// Deleting them doesn't get them sent again, as long as olderThan is past the
+// window GetDue looks at.
+func (repository ReminderRepositoryImpl) DeleteOld(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error) {
+	query := "DELETE FROM reminders WHERE created_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND) LIMIT ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return 0, errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, int(olderThan.Seconds()), limit)
+
+	if errExec != nil {
+		return 0, errExec
+	}
+
+	return sqlResult.RowsAffected()
+}
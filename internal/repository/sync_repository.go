@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/model/entity"
+)
+
+type SyncRepository interface {
+	NextChangeSeq(ctx context.Context, tx *sql.Tx, userId int) (int64, error)
+	InsertTombstone(ctx context.Context, tx *sql.Tx, tombstone entity.TodoTombstone) error
+	DeleteTombstone(ctx context.Context, tx *sql.Tx, userId int, uuid string) error
+	GetTombstonesSince(ctx context.Context, db *sql.DB, userId int, since int64, limit int) ([]entity.TodoTombstone, error)
+}
+
+type SyncRepositoryImpl struct {
+}
+
+func NewSyncRepository() SyncRepository {
+	return &SyncRepositoryImpl{}
+}
+
+// NextChangeSeq hands out the user's next change sequence number. The row
+// stays locked until the transaction ends, so a user's changes commit in the
+// order of their numbers and a client never skips one that commits late.
+func (repository SyncRepositoryImpl) NextChangeSeq(ctx context.Context, tx *sql.Tx, userId int) (int64, error) {
+	query := "INSERT INTO sync_sequences (user_id, last_seq) VALUES (?, LAST_INSERT_ID(1)) ON DUPLICATE KEY UPDATE last_seq = LAST_INSERT_ID(last_seq + 1)"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return 0, errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, userId)
+
+	if errExec != nil {
+		return 0, errExec
+	}
+
+	return sqlResult.LastInsertId()
+}
+
+// InsertTombstone replaces an older tombstone, a todo can be deleted again
+// after a client recreated it with the same uuid.
+func (repository SyncRepositoryImpl) InsertTombstone(ctx context.Context, tx *sql.Tx, tombstone entity.TodoTombstone) error {
+	query := "INSERT INTO todo_tombstones (user_id, uuid, change_seq) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE change_seq = VALUES(change_seq), deleted_at = CURRENT_TIMESTAMP"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, tombstone.UserId, tombstone.Uuid, tombstone.ChangeSeq)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+func (repository SyncRepositoryImpl) DeleteTombstone(ctx context.Context, tx *sql.Tx, userId int, uuid string) error {
+	query := "DELETE FROM todo_tombstones WHERE user_id = ? AND uuid = ?"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, userId, uuid)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+func (repository SyncRepositoryImpl) GetTombstonesSince(ctx context.Context, db *sql.DB, userId int, since int64, limit int) ([]entity.TodoTombstone, error) {
+	query := "SELECT user_id, uuid, change_seq, deleted_at FROM todo_tombstones WHERE user_id = ? AND change_seq > ? ORDER BY change_seq LIMIT ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, userId, since, limit)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	tombstones := []entity.TodoTombstone{}
+
+	for rows.Next() {
+		tombstone := entity.TodoTombstone{}
+
+		err := rows.Scan(&tombstone.UserId, &tombstone.Uuid, &tombstone.ChangeSeq, &tombstone.DeletedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tombstones = append(tombstones, tombstone)
+	}
+
+	return tombstones, nil
+}
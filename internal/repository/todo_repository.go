@@ -8,13 +8,18 @@ import (
 	"go_todo_api/internal/model/request"
 )
 
+// TodoRepository writes go through a transaction, every write takes the
+// change sequence number the sync endpoint orders changes by.
 type TodoRepository interface {
 	Get(ctx context.Context, db *sql.DB, todoId int) (entity.Todo, error)
+	GetByUuid(ctx context.Context, db *sql.DB, userId int, uuid string) (entity.Todo, error)
+	GetInTx(ctx context.Context, tx *sql.Tx, todoId int) (entity.Todo, error)
 	GetUserTodos(ctx context.Context, db *sql.DB, userId int) ([]entity.Todo, error)
-	Insert(ctx context.Context, db *sql.DB, todo request.TodoCreateRequest) error
-	Update(ctx context.Context, db *sql.DB, todo request.TodoUpdateRequest) error
-	UpdateTodoCompletion(ctx context.Context, db *sql.DB, todoId int) error
-	Delete(ctx context.Context, db *sql.DB, todoId int) error
+	GetChangedSince(ctx context.Context, db *sql.DB, userId int, since int64, limit int) ([]entity.Todo, error)
+	Insert(ctx context.Context, tx *sql.Tx, todo entity.Todo) (int, error)
+	Update(ctx context.Context, tx *sql.Tx, todo request.TodoUpdateRequest, changeSeq int64) error
+	UpdateTodoCompletion(ctx context.Context, tx *sql.Tx, todoId int, version int, changeSeq int64) error
+	Delete(ctx context.Context, tx *sql.Tx, todoId int, version int) error
 }
 
 type TodoRepositoryImpl struct {
@@ -24,10 +29,44 @@ func NewTodoRepository() TodoRepository {
 	return &TodoRepositoryImpl{}
 }
 
+const todoColumns = "id, uuid, user_id, title, description, is_done, UNIX_TIMESTAMP(remind_at), version, change_seq, created_at, updated_at"
+
+func scanTodo(rows *sql.Rows) (entity.Todo, error) {
+	todo := entity.Todo{}
+	description := sql.NullString{}
+	remindAt := sql.NullInt64{}
+	updatedAt := sql.NullString{}
+
+	err := rows.Scan(&todo.Id, &todo.Uuid, &todo.UserId, &todo.Title, &description, &todo.IsDone, &remindAt, &todo.Version, &todo.ChangeSeq, &todo.CreatedAt, &updatedAt)
+
+	if err != nil {
+		return entity.Todo{}, err
+	}
+
+	todo.Description = description.String
+	todo.RemindAt = remindAt.Int64
+	todo.UpdatedAt = updatedAt.String
+
+	return todo, nil
+}
+
 func (repository TodoRepositoryImpl) Get(ctx context.Context, db *sql.DB, todoId int) (entity.Todo, error) {
-	query := "SELECT id, user_id, title, description, is_done, created_at, updated_at FROM todos WHERE id = ? LIMIT 1"
+	query := "SELECT " + todoColumns + " FROM todos WHERE id = ? LIMIT 1"
 
-	stmt, err := db.PrepareContext(ctx, query)
+	return repository.getTodo(ctx, db, query, todoId)
+}
+
+func (repository TodoRepositoryImpl) GetByUuid(ctx context.Context, db *sql.DB, userId int, uuid string) (entity.Todo, error) {
+	query := "SELECT " + todoColumns + " FROM todos WHERE user_id = ? AND uuid = ? LIMIT 1"
+
+	return repository.getTodo(ctx, db, query, userId, uuid)
+}
+
+// GetInTx reads the todo as the transaction's writes left it.
+func (repository TodoRepositoryImpl) GetInTx(ctx context.Context, tx *sql.Tx, todoId int) (entity.Todo, error) {
+	query := "SELECT " + todoColumns + " FROM todos WHERE id = ? LIMIT 1"
+
+	stmt, err := tx.PrepareContext(ctx, query)
 
 	if err != nil {
 		return entity.Todo{}, err
@@ -42,30 +81,54 @@ func (repository TodoRepositoryImpl) Get(ctx context.Context, db *sql.DB, todoId
 	defer rows.Close()
 
 	if rows.Next() {
-		todo := entity.Todo{}
+		return scanTodo(rows)
+	}
 
-		err := rows.Scan(&todo.Id, &todo.UserId, &todo.Title, &todo.Description, &todo.IsDone, &todo.CreatedAt, &todo.UpdatedAt)
+	return entity.Todo{}, helper.ErrNotFound
+}
 
-		if err != nil {
-			return entity.Todo{}, err
-		}
+func (repository TodoRepositoryImpl) getTodo(ctx context.Context, db *sql.DB, query string, args ...any) (entity.Todo, error) {
+	stmt, err := db.PrepareContext(ctx, query)
 
-		return todo, nil
+	if err != nil {
+		return entity.Todo{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, args...)
+
+	if queryErr != nil {
+		return entity.Todo{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanTodo(rows)
 	}
 
 	return entity.Todo{}, helper.ErrNotFound
 }
 
 func (repository TodoRepositoryImpl) GetUserTodos(ctx context.Context, db *sql.DB, userId int) ([]entity.Todo, error) {
-	query := "SELECT id, user_id, title, description, is_done, created_at, updated_at FROM todos WHERE user_id = ?"
+	query := "SELECT " + todoColumns + " FROM todos WHERE user_id = ?"
+
+	return repository.getTodos(ctx, db, query, userId)
+}
+
+func (repository TodoRepositoryImpl) GetChangedSince(ctx context.Context, db *sql.DB, userId int, since int64, limit int) ([]entity.Todo, error) {
+	query := "SELECT " + todoColumns + " FROM todos WHERE user_id = ? AND change_seq > ? ORDER BY change_seq LIMIT ?"
 
+	return repository.getTodos(ctx, db, query, userId, since, limit)
+}
+
+func (repository TodoRepositoryImpl) getTodos(ctx context.Context, db *sql.DB, query string, args ...any) ([]entity.Todo, error) {
 	stmt, errPrepare := db.PrepareContext(ctx, query)
 
 	if errPrepare != nil {
 		return nil, errPrepare
 	}
 
-	rows, queryErr := stmt.QueryContext(ctx, userId)
+	rows, queryErr := stmt.QueryContext(ctx, args...)
 
 	if queryErr != nil {
 		return nil, queryErr
@@ -76,9 +139,7 @@ func (repository TodoRepositoryImpl) GetUserTodos(ctx context.Context, db *sql.D
 	todos := []entity.Todo{}
 
 	for rows.Next() {
-		todo := entity.Todo{}
-
-		err := rows.Scan(&todo.Id, &todo.UserId, &todo.Title, &todo.Description, &todo.IsDone, &todo.CreatedAt, &todo.UpdatedAt)
+		todo, err := scanTodo(rows)
 
 		if err != nil {
 			return nil, err
@@ -90,40 +151,40 @@ func (repository TodoRepositoryImpl) GetUserTodos(ctx context.Context, db *sql.D
 	return todos, nil
 }
 
-func (repository TodoRepositoryImpl) Insert(ctx context.Context, db *sql.DB, todo request.TodoCreateRequest) error {
-	query := "INSERT INTO todos (user_id, title, description) VALUES (?, ?, ?)"
+func (repository TodoRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, todo entity.Todo) (int, error) {
+	query := "INSERT INTO todos (uuid, user_id, title, description, is_done, remind_at, change_seq) VALUES (?, ?, ?, ?, ?, FROM_UNIXTIME(NULLIF(?, 0)), ?)"
 
-	stmt, errPrepare := db.PrepareContext(ctx, query)
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
 
 	if errPrepare != nil {
-		return errPrepare
+		return 0, errPrepare
 	}
 
-	sqlResult, errExec := stmt.ExecContext(ctx, todo.UserId, todo.Title, todo.Description)
+	sqlResult, errExec := stmt.ExecContext(ctx, todo.Uuid, todo.UserId, todo.Title, todo.Description, todo.IsDone, todo.RemindAt, todo.ChangeSeq)
 
 	if errExec != nil {
-		return errExec
+		return 0, translateMysqlError(errExec)
 	}
 
-	err := helper.CheckRowsAffected(sqlResult)
+	lastInsertId, errLastInsertId := sqlResult.LastInsertId()
 
-	if err != nil {
-		return err
+	if errLastInsertId != nil {
+		return 0, errLastInsertId
 	}
 
-	return nil
+	return int(lastInsertId), nil
 }
 
-func (repository TodoRepositoryImpl) Update(ctx context.Context, db *sql.DB, todo request.TodoUpdateRequest) error {
-	query := "UPDATE todos SET title=?, description=?, is_done=? WHERE id=?"
+func (repository TodoRepositoryImpl) Update(ctx context.Context, tx *sql.Tx, todo request.TodoUpdateRequest, changeSeq int64) error {
+	query := "UPDATE todos SET title=?, description=?, is_done=?, remind_at=FROM_UNIXTIME(NULLIF(?, 0)), version=version+1, change_seq=? WHERE id=? AND (? = 0 OR version = ?)"
 
-	stmt, errPrepare := db.PrepareContext(ctx, query)
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
 
 	if errPrepare != nil {
 		return errPrepare
 	}
 
-	sqlResult, errExec := stmt.ExecContext(ctx, todo.Title, todo.Description, todo.IsDone, todo.Id)
+	sqlResult, errExec := stmt.ExecContext(ctx, todo.Title, todo.Description, todo.IsDone, helper.ParseTimestamp(todo.RemindAt), changeSeq, todo.Id, todo.Version, todo.Version)
 
 	if errExec != nil {
 		return errExec
@@ -138,16 +199,16 @@ func (repository TodoRepositoryImpl) Update(ctx context.Context, db *sql.DB, tod
 	return nil
 }
 
-func (repository TodoRepositoryImpl) UpdateTodoCompletion(ctx context.Context, db *sql.DB, todoId int) error {
-	query := "UPDATE todos SET is_done = NOT is_done WHERE id = ?"
+func (repository TodoRepositoryImpl) UpdateTodoCompletion(ctx context.Context, tx *sql.Tx, todoId int, version int, changeSeq int64) error {
+	query := "UPDATE todos SET is_done = NOT is_done, version = version + 1, change_seq = ? WHERE id = ? AND (? = 0 OR version = ?)"
 
-	stmt, errPrepare := db.PrepareContext(ctx, query)
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
 
 	if errPrepare != nil {
 		return errPrepare
 	}
 
-	sqlResult, errExec := stmt.ExecContext(ctx, todoId)
+	sqlResult, errExec := stmt.ExecContext(ctx, changeSeq, todoId, version, version)
 
 	if errExec != nil {
 		return errExec
@@ -162,16 +223,16 @@ func (repository TodoRepositoryImpl) UpdateTodoCompletion(ctx context.Context, d
 	return nil
 }
 
-func (repository TodoRepositoryImpl) Delete(ctx context.Context, db *sql.DB, todoId int) error {
-	query := "DELETE FROM todos WHERE id = ?"
+func (repository TodoRepositoryImpl) Delete(ctx context.Context, tx *sql.Tx, todoId int, version int) error {
+	query := "DELETE FROM todos WHERE id = ? AND (? = 0 OR version = ?)"
 
-	stmt, errPrepare := db.PrepareContext(ctx, query)
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
 
 	if errPrepare != nil {
 		return errPrepare
 	}
 
-	sqlResult, errExec := stmt.ExecContext(ctx, todoId)
+	sqlResult, errExec := stmt.ExecContext(ctx, todoId, version, version)
 
 	if errExec != nil {
 		return errExec
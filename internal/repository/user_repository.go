@@ -6,15 +6,25 @@ import (
 	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
+	"strings"
 )
 
 type UserRepository interface {
 	Get(ctx context.Context, db *sql.DB, userId int) (entity.User, error)
 	GetByUsername(ctx context.Context, db *sql.DB, userName string) (entity.User, error)
+	GetByEmail(ctx context.Context, db *sql.DB, email string) (entity.User, error)
 	Insert(ctx context.Context, db *sql.DB, user request.UserCreateRequest) error
+	InsertUser(ctx context.Context, tx *sql.Tx, user entity.User) (int, error)
 	Update(ctx context.Context, db *sql.DB, user request.UserUpdateRequest) error
 	Delete(ctx context.Context, tx *sql.Tx, userId int) error
 	DeleteUserTodo(ctx context.Context, tx *sql.Tx, userId int) error
+	Search(ctx context.Context, db *sql.DB, query string, limit int, offset int) ([]entity.User, error)
+	CountSearch(ctx context.Context, db *sql.DB, query string) (int, error)
+	UpdateDisabled(ctx context.Context, db *sql.DB, userId int, isDisabled bool) error
+	UpdatePassword(ctx context.Context, db *sql.DB, userId int, password string) error
+	IncrementTokenVersion(ctx context.Context, db *sql.DB, userId int) error
+	UpdatePendingEmail(ctx context.Context, tx *sql.Tx, userId int, pendingEmail string) error
+	UpdateEmail(ctx context.Context, tx *sql.Tx, userId int, email string) error
 }
 
 type UserRepositoryImpl struct {
@@ -24,8 +34,38 @@ func NewUserRepository() UserRepository {
 	return &UserRepositoryImpl{}
 }
 
+const userColumns = "id, username, password, name, email, pending_email, phone_number, locale, role, is_disabled, token_version, created_at, updated_at"
+
+func scanUser(rows *sql.Rows) (entity.User, error) {
+	user := entity.User{}
+	pendingEmail := sql.NullString{}
+	phoneNumber := sql.NullString{}
+	locale := sql.NullString{}
+	updatedAt := sql.NullString{}
+
+	err := rows.Scan(&user.Id, &user.Username, &user.Password, &user.Name, &user.Email, &pendingEmail, &phoneNumber, &locale, &user.Role, &user.IsDisabled, &user.TokenVersion, &user.CreatedAt, &updatedAt)
+
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	user.PendingEmail = pendingEmail.String
+	user.PhoneNumber = phoneNumber.String
+	user.Locale = locale.String
+	user.UpdatedAt = updatedAt.String
+
+	return user, nil
+}
+
+// searchPattern escapes LIKE wildcards so the query is matched literally.
+func searchPattern(query string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+
+	return "%" + replacer.Replace(query) + "%"
+}
+
 func (repository UserRepositoryImpl) Get(ctx context.Context, db *sql.DB, userId int) (entity.User, error) {
-	query := "SELECT id, username, password, name, email, phone_number, created_at, updated_at FROM users WHERE id = ? LIMIT 1"
+	query := "SELECT " + userColumns + " FROM users WHERE id = ? LIMIT 1"
 
 	stmt, err := db.PrepareContext(ctx, query)
 
@@ -42,22 +82,14 @@ func (repository UserRepositoryImpl) Get(ctx context.Context, db *sql.DB, userId
 	defer rows.Close()
 
 	if rows.Next() {
-		user := entity.User{}
-
-		err := rows.Scan(&user.Id, &user.Username, &user.Password, &user.Name, &user.Email, &user.PhoneNumber, &user.CreatedAt, &user.UpdatedAt)
-
-		if err != nil {
-			return entity.User{}, err
-		}
-
-		return user, nil
+		return scanUser(rows)
 	}
 
 	return entity.User{}, helper.ErrNotFound
 }
 
 func (repository UserRepositoryImpl) GetByUsername(ctx context.Context, db *sql.DB, username string) (entity.User, error) {
-	query := "SELECT id, username, password, name, email, phone_number, created_at, updated_at FROM users WHERE username = ? LIMIT 1"
+	query := "SELECT " + userColumns + " FROM users WHERE username = ? LIMIT 1"
 
 	stmt, err := db.PrepareContext(ctx, query)
 
@@ -74,15 +106,31 @@ func (repository UserRepositoryImpl) GetByUsername(ctx context.Context, db *sql.
 	defer rows.Close()
 
 	if rows.Next() {
-		user := entity.User{}
+		return scanUser(rows)
+	}
 
-		err := rows.Scan(&user.Id, &user.Username, &user.Password, &user.Name, &user.Email, &user.PhoneNumber, &user.CreatedAt, &user.UpdatedAt)
+	return entity.User{}, helper.ErrNotFound
+}
 
-		if err != nil {
-			return entity.User{}, err
-		}
+func (repository UserRepositoryImpl) GetByEmail(ctx context.Context, db *sql.DB, email string) (entity.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE email = ? LIMIT 1"
 
-		return user, nil
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, email)
+
+	if queryErr != nil {
+		return entity.User{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanUser(rows)
 	}
 
 	return entity.User{}, helper.ErrNotFound
@@ -100,7 +148,7 @@ func (repository UserRepositoryImpl) Insert(ctx context.Context, db *sql.DB, use
 	sqlResult, errExec := stmt.ExecContext(ctx, user.Username, user.Password, user.Name, user.Email, user.PhoneNumber)
 
 	if errExec != nil {
-		return errExec
+		return translateMysqlError(errExec)
 	}
 
 	err := helper.CheckRowsAffected(sqlResult)
@@ -112,8 +160,36 @@ func (repository UserRepositoryImpl) Insert(ctx context.Context, db *sql.DB, use
 	return nil
 }
 
+// InsertUser stores a user created by the app itself rather than by sign up,
+// an empty phone number is stored as NULL.
+func (repository UserRepositoryImpl) InsertUser(ctx context.Context, tx *sql.Tx, user entity.User) (int, error) {
+	query := "INSERT INTO users (username, password, name, email, phone_number) VALUES (?, ?, ?, ?, ?)"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return 0, errPrepare
+	}
+
+	phoneNumber := sql.NullString{String: user.PhoneNumber, Valid: user.PhoneNumber != ""}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, user.Username, user.Password, user.Name, user.Email, phoneNumber)
+
+	if errExec != nil {
+		return 0, translateMysqlError(errExec)
+	}
+
+	lastInsertId, errLastInsertId := sqlResult.LastInsertId()
+
+	if errLastInsertId != nil {
+		return 0, errLastInsertId
+	}
+
+	return int(lastInsertId), nil
+}
+
 func (repository UserRepositoryImpl) Update(ctx context.Context, db *sql.DB, user request.UserUpdateRequest) error {
-	query := "UPDATE users SET username=?, name=?, email=?, phone_number=? WHERE id=?"
+	query := "UPDATE users SET username=?, name=?, email=?, phone_number=NULLIF(?, ''), locale=NULLIF(?, '') WHERE id=?"
 
 	stmt, errPrepare := db.PrepareContext(ctx, query)
 
@@ -121,10 +197,10 @@ func (repository UserRepositoryImpl) Update(ctx context.Context, db *sql.DB, use
 		return errPrepare
 	}
 
-	sqlResult, errExec := stmt.ExecContext(ctx, user.Username, user.Name, user.Email, user.PhoneNumber, user.Id)
+	sqlResult, errExec := stmt.ExecContext(ctx, user.Username, user.Name, user.Email, user.PhoneNumber, user.Locale, user.Id)
 
 	if errExec != nil {
-		return errExec
+		return translateMysqlError(errExec)
 	}
 
 	errRowsNotAffected := helper.CheckRowsAffected(sqlResult)
@@ -177,3 +253,171 @@ func (repository UserRepositoryImpl) DeleteUserTodo(ctx context.Context, tx *sql
 
 	return nil
 }
+
+func (repository UserRepositoryImpl) Search(ctx context.Context, db *sql.DB, query string, limit int, offset int) ([]entity.User, error) {
+	sqlQuery := "SELECT " + userColumns + " FROM users WHERE username LIKE ? OR name LIKE ? OR email LIKE ? ORDER BY id LIMIT ? OFFSET ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, sqlQuery)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	pattern := searchPattern(query)
+
+	rows, queryErr := stmt.QueryContext(ctx, pattern, pattern, pattern, limit, offset)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	users := []entity.User{}
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (repository UserRepositoryImpl) CountSearch(ctx context.Context, db *sql.DB, query string) (int, error) {
+	sqlQuery := "SELECT COUNT(*) FROM users WHERE username LIKE ? OR name LIKE ? OR email LIKE ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, sqlQuery)
+
+	if errPrepare != nil {
+		return 0, errPrepare
+	}
+
+	pattern := searchPattern(query)
+
+	total := 0
+
+	err := stmt.QueryRowContext(ctx, pattern, pattern, pattern).Scan(&total)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (repository UserRepositoryImpl) UpdateDisabled(ctx context.Context, db *sql.DB, userId int, isDisabled bool) error {
+	query := "UPDATE users SET is_disabled = ? WHERE id = ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, isDisabled, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+func (repository UserRepositoryImpl) UpdatePassword(ctx context.Context, db *sql.DB, userId int, password string) error {
+	query := "UPDATE users SET password = ? WHERE id = ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, password, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IncrementTokenVersion invalidates every access and refresh token issued to the user so far.
+func (repository UserRepositoryImpl) IncrementTokenVersion(ctx context.Context, db *sql.DB, userId int) error {
+	query := "UPDATE users SET token_version = token_version + 1 WHERE id = ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePendingEmail stores the address waiting for confirmation, an empty
+// pendingEmail clears it.
+func (repository UserRepositoryImpl) UpdatePendingEmail(ctx context.Context, tx *sql.Tx, userId int, pendingEmail string) error {
+	query := "UPDATE users SET pending_email = ? WHERE id = ?"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	_, errExec := stmt.ExecContext(ctx, sql.NullString{String: pendingEmail, Valid: pendingEmail != ""}, userId)
+
+	if errExec != nil {
+		return errExec
+	}
+
+	return nil
+}
+
+// UpdateEmail switches the user to email and clears any pending address.
+func (repository UserRepositoryImpl) UpdateEmail(ctx context.Context, tx *sql.Tx, userId int, email string) error {
+	query := "UPDATE users SET email = ?, pending_email = NULL WHERE id = ?"
+
+	stmt, errPrepare := tx.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	sqlResult, errExec := stmt.ExecContext(ctx, email, userId)
+
+	if errExec != nil {
+		return translateMysqlError(errExec)
+	}
+
+	err := helper.CheckRowsAffected(sqlResult)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"time"
+)
+
+type WebhookRepository interface {
+	Insert(ctx context.Context, db *sql.DB, webhook entity.Webhook) (int, error)
+	Get(ctx context.Context, db *sql.DB, userId int, webhookId int) (entity.Webhook, error)
+	GetUserWebhooks(ctx context.Context, db *sql.DB, userId int) ([]entity.Webhook, error)
+	GetActiveUserWebhooks(ctx context.Context, db *sql.DB, userId int) ([]entity.Webhook, error)
+	Delete(ctx context.Context, db *sql.DB, userId int, webhookId int) error
+	Enable(ctx context.Context, db *sql.DB, userId int, webhookId int) error
+	RecordSuccess(ctx context.Context, db *sql.DB, webhookId int) error
+	RecordFailure(ctx context.Context, db *sql.DB, webhookId int, disableAfter int) error
+	InsertDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery) (int, error)
+	InsertEventDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery) error
+	GetDelivery(ctx context.Context, db *sql.DB, webhookId int, deliveryId int) (entity.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, db *sql.DB, webhookId int, limit int) ([]entity.WebhookDelivery, error)
+	GetDueDeliveries(ctx context.Context, db *sql.DB, limit int) ([]entity.WebhookDelivery, error)
+	ClaimDelivery(ctx context.Context, db *sql.DB, deliveryId int, lease time.Duration) error
+	UpdateDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery, retryIn time.Duration) error
+}
+
+type WebhookRepositoryImpl struct {
+}
+
+func NewWebhookRepository() WebhookRepository {
+	return &WebhookRepositoryImpl{}
+}
+
+const webhookColumns = "id, user_id, url, secret, event_types, failure_count, disabled_at, created_at"
+
+const webhookDeliveryColumns = "webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event_id, webhook_deliveries.event_type, webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.next_attempt_at, webhook_deliveries.response_status, webhook_deliveries.last_error, webhook_deliveries.delivered_at, webhook_deliveries.created_at"
+
+func scanWebhook(rows *sql.Rows) (entity.Webhook, error) {
+	webhook := entity.Webhook{}
+	disabledAt := sql.NullString{}
+
+	err := rows.Scan(&webhook.Id, &webhook.UserId, &webhook.Url, &webhook.Secret, &webhook.EventTypes, &webhook.FailureCount, &disabledAt, &webhook.CreatedAt)
+
+	if err != nil {
+		return entity.Webhook{}, err
+	}
+
+	webhook.DisabledAt = disabledAt.String
+
+	return webhook, nil
+}
+
+func scanWebhookDelivery(rows *sql.Rows, extra ...any) (entity.WebhookDelivery, error) {
+	delivery := entity.WebhookDelivery{}
+	responseStatus := sql.NullInt64{}
+	lastError := sql.NullString{}
+	deliveredAt := sql.NullString{}
+
+	dest := []any{&delivery.Id, &delivery.WebhookId, &delivery.EventId, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &responseStatus, &lastError, &deliveredAt, &delivery.CreatedAt}
+
+	err := rows.Scan(append(dest, extra...)...)
+
+	if err != nil {
+		return entity.WebhookDelivery{}, err
+	}
+
+	delivery.ResponseStatus = int(responseStatus.Int64)
+	delivery.LastError = lastError.String
+	delivery.DeliveredAt = deliveredAt.String
+
+	return delivery, nil
+}
+
+func (repository WebhookRepositoryImpl) Insert(ctx context.Context, db *sql.DB, webhook entity.Webhook) (int, error) {
+	query := "INSERT INTO webhooks (user_id, url, secret, event_types) VALUES (?, ?, ?, ?)"
+
+	sqlResult, err := repository.exec(ctx, db, query, webhook.UserId, webhook.Url, webhook.Secret, webhook.EventTypes)
+
+	if err != nil {
+		return 0, translateMysqlError(err)
+	}
+
+	lastInsertId, errLastInsertId := sqlResult.LastInsertId()
+
+	if errLastInsertId != nil {
+		return 0, errLastInsertId
+	}
+
+	return int(lastInsertId), nil
+}
+
+func (repository WebhookRepositoryImpl) Get(ctx context.Context, db *sql.DB, userId int, webhookId int) (entity.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE id = ? AND user_id = ? LIMIT 1"
+
+	webhooks, err := repository.query(ctx, db, query, webhookId, userId)
+
+	if err != nil {
+		return entity.Webhook{}, err
+	}
+
+	if len(webhooks) == 0 {
+		return entity.Webhook{}, helper.ErrNotFound
+	}
+
+	return webhooks[0], nil
+}
+
+func (repository WebhookRepositoryImpl) GetUserWebhooks(ctx context.Context, db *sql.DB, userId int) ([]entity.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE user_id = ? ORDER BY id"
+
+	return repository.query(ctx, db, query, userId)
+}
+
+func (repository WebhookRepositoryImpl) GetActiveUserWebhooks(ctx context.Context, db *sql.DB, userId int) ([]entity.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE user_id = ? AND disabled_at IS NULL ORDER BY id"
+
+	return repository.query(ctx, db, query, userId)
+}
+
+func (repository WebhookRepositoryImpl) Delete(ctx context.Context, db *sql.DB, userId int, webhookId int) error {
+	query := "DELETE FROM webhooks WHERE id = ? AND user_id = ?"
+
+	sqlResult, err := repository.exec(ctx, db, query, webhookId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+func (repository WebhookRepositoryImpl) Enable(ctx context.Context, db *sql.DB, userId int, webhookId int) error {
+	query := "UPDATE webhooks SET disabled_at = NULL, failure_count = 0 WHERE id = ? AND user_id = ?"
+
+	sqlResult, err := repository.exec(ctx, db, query, webhookId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+func (repository WebhookRepositoryImpl) RecordSuccess(ctx context.Context, db *sql.DB, webhookId int) error {
+	query := "UPDATE webhooks SET failure_count = 0 WHERE id = ? AND failure_count > 0"
+
+	_, err := repository.exec(ctx, db, query, webhookId)
+
+	return err
+}
+
+// RecordFailure counts a failed attempt and disables the webhook once
+// disableAfter attempts in a row have failed. MySQL assigns left to right, so
+// disabled_at sees the new count.
+func (repository WebhookRepositoryImpl) RecordFailure(ctx context.Context, db *sql.DB, webhookId int, disableAfter int) error {
+	query := "UPDATE webhooks SET failure_count = failure_count + 1, disabled_at = IF(disabled_at IS NULL AND failure_count >= ?, CURRENT_TIMESTAMP, disabled_at) WHERE id = ?"
+
+	_, err := repository.exec(ctx, db, query, disableAfter, webhookId)
+
+	return err
+}
+
+func (repository WebhookRepositoryImpl) InsertDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery) (int, error) {
+	query := "INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload) VALUES (?, ?, ?, ?)"
+
+	sqlResult, err := repository.exec(ctx, db, query, delivery.WebhookId, delivery.EventId, delivery.EventType, delivery.Payload)
+
+	if err != nil {
+		return 0, translateMysqlError(err)
+	}
+
+	lastInsertId, errLastInsertId := sqlResult.LastInsertId()
+
+	if errLastInsertId != nil {
+		return 0, errLastInsertId
+	}
+
+	return int(lastInsertId), nil
+}
+
+// InsertEventDelivery adds the delivery unless the webhook already has one of
+// the event.
+func (repository WebhookRepositoryImpl) InsertEventDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery) error {
+	query := "INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload) SELECT ?, ?, ?, ? FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM webhook_deliveries WHERE webhook_id = ? AND event_id = ?)"
+
+	_, err := repository.exec(ctx, db, query, delivery.WebhookId, delivery.EventId, delivery.EventType, delivery.Payload, delivery.WebhookId, delivery.EventId)
+
+	return err
+}
+
+func (repository WebhookRepositoryImpl) GetDelivery(ctx context.Context, db *sql.DB, webhookId int, deliveryId int) (entity.WebhookDelivery, error) {
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE id = ? AND webhook_id = ? LIMIT 1"
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return entity.WebhookDelivery{}, err
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, deliveryId, webhookId)
+
+	if queryErr != nil {
+		return entity.WebhookDelivery{}, queryErr
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanWebhookDelivery(rows)
+	}
+
+	return entity.WebhookDelivery{}, helper.ErrNotFound
+}
+
+// GetDeliveries returns the newest deliveries first.
+func (repository WebhookRepositoryImpl) GetDeliveries(ctx context.Context, db *sql.DB, webhookId int, limit int) ([]entity.WebhookDelivery, error) {
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, webhookId, limit)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	deliveries := []entity.WebhookDelivery{}
+
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// GetDueDeliveries returns the pending deliveries whose next attempt is due,
+// with the url and secret of their webhook. Deliveries of a disabled webhook
+// wait until it is enabled again.
+func (repository WebhookRepositoryImpl) GetDueDeliveries(ctx context.Context, db *sql.DB, limit int) ([]entity.WebhookDelivery, error) {
+	query := "SELECT " + webhookDeliveryColumns + ", webhooks.url, webhooks.secret FROM webhook_deliveries JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id WHERE webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= CURRENT_TIMESTAMP AND webhooks.disabled_at IS NULL ORDER BY webhook_deliveries.next_attempt_at LIMIT ?"
+
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, entity.WebhookDeliveryPending, limit)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	deliveries := []entity.WebhookDelivery{}
+
+	for rows.Next() {
+		url, secret := "", ""
+
+		delivery, err := scanWebhookDelivery(rows, &url, &secret)
+
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.Url = url
+		delivery.Secret = secret
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDelivery pushes the next attempt back by lease, so no other instance
+// picks the delivery up while it is being sent. Only one of two instances
+// claiming the same delivery succeeds, the other gets ErrRowsNotAffected.
+// A claim that is never followed by UpdateDelivery runs out and the delivery
+// is tried again.
+func (repository WebhookRepositoryImpl) ClaimDelivery(ctx context.Context, db *sql.DB, deliveryId int, lease time.Duration) error {
+	query := "UPDATE webhook_deliveries SET next_attempt_at = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND) WHERE id = ? AND status = ? AND next_attempt_at <= CURRENT_TIMESTAMP"
+
+	sqlResult, err := repository.exec(ctx, db, query, int(lease.Seconds()), deliveryId, entity.WebhookDeliveryPending)
+
+	if err != nil {
+		return err
+	}
+
+	return helper.CheckRowsAffected(sqlResult)
+}
+
+// UpdateDelivery saves the outcome of an attempt. retryIn is when a pending
+// delivery is tried next.
+func (repository WebhookRepositoryImpl) UpdateDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery, retryIn time.Duration) error {
+	query := "UPDATE webhook_deliveries SET status = ?, attempts = ?, response_status = NULLIF(?, 0), last_error = NULLIF(?, ''), next_attempt_at = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND), delivered_at = IF(? = ?, CURRENT_TIMESTAMP, delivered_at) WHERE id = ?"
+
+	_, err := repository.exec(ctx, db, query, delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.LastError, int(retryIn.Seconds()), delivery.Status, entity.WebhookDeliverySucceeded, delivery.Id)
+
+	return err
+}
+
+func (repository WebhookRepositoryImpl) query(ctx context.Context, db *sql.DB, query string, args ...any) ([]entity.Webhook, error) {
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	rows, queryErr := stmt.QueryContext(ctx, args...)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	webhooks := []entity.Webhook{}
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (repository WebhookRepositoryImpl) exec(ctx context.Context, db *sql.DB, query string, args ...any) (sql.Result, error) {
+	stmt, errPrepare := db.PrepareContext(ctx, query)
+
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+
+	return stmt.ExecContext(ctx, args...)
+}
@@ -2,28 +2,96 @@ package router
 
 import (
 	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
 	"go_todo_api/internal/middleware"
 
 	"github.com/julienschmidt/httprouter"
 )
 
-func NewRouter(userController controller.UserController, todoController controller.TodoController, authController controller.AuthController) *httprouter.Router {
+func NewRouter(authMiddleware *middleware.AuthMiddleware, idempotencyMiddleware *middleware.IdempotencyMiddleware, userController controller.UserController, todoController controller.TodoController, authController controller.AuthController, mfaController controller.MfaController, apiTokenController controller.ApiTokenController, adminController controller.AdminController, jwksController controller.JwksController, oidcController controller.OidcController, oauthController controller.OauthController, syncController controller.SyncController, eventController controller.EventController, websocketController controller.WebsocketController, webhookController controller.WebhookController, notificationController controller.NotificationController) *httprouter.Router {
 	router := httprouter.New()
 
+	authenticated := authMiddleware.Authenticate
+	session := func(next httprouter.Handle) httprouter.Handle {
+		return authenticated(middleware.RequireSession(next))
+	}
+	scoped := func(scope string, next httprouter.Handle) httprouter.Handle {
+		return authenticated(middleware.RequireScope(scope)(next))
+	}
+	self := func(scope string, next httprouter.Handle) httprouter.Handle {
+		return scoped(scope, middleware.RequireSelfOrAdmin("userId")(next))
+	}
+	admin := func(next httprouter.Handle) httprouter.Handle {
+		return session(middleware.RequireRole(helper.RoleAdmin)(next))
+	}
+	// Routes whose responses hand out secrets or credentials are left out,
+	// those responses must not be stored.
+	idempotent := idempotencyMiddleware.Idempotent
+
+	router.GET("/.well-known/jwks.json", jwksController.GetJwks)
+
 	router.POST("/api/login", authController.Login)
+	router.POST("/api/login/mfa", authController.LoginMfa)
 	router.POST("/api/token/refresh", authController.RefreshToken)
 
-	router.POST("/api/user", middleware.AuthMiddleware(userController.CreateUser))
-	router.GET("/api/user/:userId", middleware.AuthMiddleware(userController.Get))
-	router.PUT("/api/user/:userId", middleware.AuthMiddleware(userController.Update))
-	router.DELETE("/api/user/:userId", middleware.AuthMiddleware(userController.Remove))
-
-	router.POST("/api/todo", middleware.AuthMiddleware(todoController.CreateTodo))
-	router.GET("/api/user/:userId/todo", middleware.AuthMiddleware(todoController.GetUserTodos))
-	router.GET("/api/todo/:todoId", middleware.AuthMiddleware(todoController.Get))
-	router.PUT("/api/todo/:todoId", middleware.AuthMiddleware(todoController.Update))
-	router.PATCH("/api/todo/completion/:todoId", middleware.AuthMiddleware(todoController.UpdateTodoCompletion))
-	router.DELETE("/api/todo/:todoId", middleware.AuthMiddleware(todoController.Remove))
+	router.GET("/api/login/oidc/:provider", oidcController.StartLogin)
+	router.GET("/api/login/oidc/:provider/callback", oidcController.Callback)
+
+	router.GET("/oauth/authorize", session(oauthController.GetConsent))
+	router.POST("/oauth/authorize", session(oauthController.Authorize))
+	router.POST("/oauth/token", oauthController.Token)
+
+	router.POST("/api/me/mfa/totp", session(mfaController.EnrollTotp))
+	router.POST("/api/me/mfa/totp/confirm", session(mfaController.ConfirmTotp))
+	router.DELETE("/api/me/mfa/totp", session(mfaController.DisableTotp))
+
+	router.GET("/api/me/events", scoped(helper.ScopeTodosRead, eventController.Stream))
+	router.GET("/ws", authMiddleware.AuthenticateQuery(middleware.RequireScope(helper.ScopeTodosRead)(websocketController.Connect)))
+
+	router.POST("/api/me/tokens", session(apiTokenController.Create))
+	router.GET("/api/me/tokens", session(apiTokenController.GetUserTokens))
+	router.DELETE("/api/me/tokens/:tokenId", session(apiTokenController.Revoke))
+
+	router.POST("/api/me/webhooks", session(webhookController.Create))
+	router.GET("/api/me/webhooks", session(webhookController.GetUserWebhooks))
+	router.DELETE("/api/me/webhooks/:webhookId", session(webhookController.Remove))
+	router.POST("/api/me/webhooks/:webhookId/enable", session(idempotent(webhookController.Enable)))
+	router.GET("/api/me/webhooks/:webhookId/deliveries", session(webhookController.GetDeliveries))
+	router.POST("/api/me/webhooks/:webhookId/deliveries/:deliveryId/redeliver", session(idempotent(webhookController.Redeliver)))
+
+	router.GET("/api/me/notifications/settings", session(notificationController.GetSettings))
+	router.PUT("/api/me/notifications/settings", session(notificationController.UpdateSettings))
+	router.POST("/api/me/notifications/subscriptions", session(notificationController.Subscribe))
+	router.GET("/api/me/notifications/subscriptions", session(notificationController.GetSubscriptions))
+	router.DELETE("/api/me/notifications/subscriptions/:subscriptionId", session(notificationController.Unsubscribe))
+
+	router.POST("/api/user", scoped(helper.ScopeUserWrite, idempotent(userController.CreateUser)))
+	router.GET("/api/user/:userId", self(helper.ScopeUserRead, userController.Get))
+	router.PUT("/api/user/:userId", self(helper.ScopeUserWrite, userController.Update))
+	router.DELETE("/api/user/:userId", self(helper.ScopeUserWrite, userController.Remove))
+	router.POST("/api/email/confirm", userController.ConfirmEmail)
+	router.POST("/api/email/revert", userController.RevertEmail)
+
+	router.GET("/api/admin/users", admin(adminController.FindUsers))
+	router.POST("/api/admin/users/:userId/disable", admin(idempotent(adminController.DisableUser)))
+	router.POST("/api/admin/users/:userId/enable", admin(idempotent(adminController.EnableUser)))
+	router.POST("/api/admin/users/:userId/logout", admin(idempotent(adminController.ForceLogout)))
+	router.POST("/api/admin/users/:userId/password", admin(idempotent(adminController.ResetPassword)))
+	router.POST("/api/admin/users/:userId/unlock", admin(idempotent(adminController.UnlockUser)))
+
+	router.POST("/api/admin/oauth/clients", admin(oauthController.RegisterClient))
+	router.GET("/api/admin/oauth/clients", admin(oauthController.FindClients))
+	router.DELETE("/api/admin/oauth/clients/:clientId", admin(oauthController.DeleteClient))
+
+	router.POST("/api/todo", scoped(helper.ScopeTodosWrite, idempotent(todoController.CreateTodo)))
+	router.GET("/api/user/:userId/todo", self(helper.ScopeTodosRead, todoController.GetUserTodos))
+	router.GET("/api/todo/:todoId", scoped(helper.ScopeTodosRead, todoController.Get))
+	router.PUT("/api/todo/:todoId", scoped(helper.ScopeTodosWrite, todoController.Update))
+	router.PATCH("/api/todo/completion/:todoId", scoped(helper.ScopeTodosWrite, idempotent(todoController.UpdateTodoCompletion)))
+	router.DELETE("/api/todo/:todoId", scoped(helper.ScopeTodosWrite, todoController.Remove))
+
+	router.GET("/api/sync", scoped(helper.ScopeTodosRead, syncController.Pull))
+	router.POST("/api/sync", scoped(helper.ScopeTodosWrite, idempotent(syncController.Push)))
 
 	return router
 }
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	customvalidator "go_todo_api/internal/validator"
+)
+
+type AdminService interface {
+	FindUsers(ctx context.Context, userSearchRequest request.UserSearchRequest) (response.PageResponse, error)
+	DisableUser(ctx context.Context, adminUserId int, userId int) error
+	EnableUser(ctx context.Context, userId int) error
+	ForceLogout(ctx context.Context, userId int) error
+	ResetPassword(ctx context.Context, passwordResetRequest request.PasswordResetRequest) error
+	UnlockUser(ctx context.Context, userId int) error
+}
+
+type AdminServiceImpl struct {
+	db              *sql.DB
+	userRepository  repository.UserRepository
+	oauthRepository repository.OauthRepository
+	validate       customvalidator.CustomValidator
+	passwordHasher func(password string) (string, error)
+	loginThrottle  LoginThrottleService
+}
+
+func NewAdminService(db *sql.DB, userRepository repository.UserRepository, oauthRepository repository.OauthRepository, validate customvalidator.CustomValidator, passwordHasher func(password string) (string, error), loginThrottle LoginThrottleService) AdminService {
+	return &AdminServiceImpl{
+		db:             db,
+		userRepository:  userRepository,
+		oauthRepository: oauthRepository,
+		validate:        validate,
+		passwordHasher:  passwordHasher,
+		loginThrottle:   loginThrottle,
+	}
+}
+
+func (adminService *AdminServiceImpl) FindUsers(ctx context.Context, userSearchRequest request.UserSearchRequest) (response.PageResponse, error) {
+	if err := adminService.validate.StructCtx(ctx, userSearchRequest); err != nil {
+		return response.PageResponse{}, err
+	}
+
+	offset := (userSearchRequest.Page - 1) * userSearchRequest.PerPage
+
+	users, errSearch := adminService.userRepository.Search(ctx, adminService.db, userSearchRequest.Query, userSearchRequest.PerPage, offset)
+
+	if errSearch != nil {
+		return response.PageResponse{}, errSearch
+	}
+
+	total, errCount := adminService.userRepository.CountSearch(ctx, adminService.db, userSearchRequest.Query)
+
+	if errCount != nil {
+		return response.PageResponse{}, errCount
+	}
+
+	userResponses := []response.UserResponse{}
+
+	for _, user := range users {
+		userResponses = append(userResponses, toUserResponse(user))
+	}
+
+	pageResponse := response.PageResponse{
+		Items:   userResponses,
+		Page:    userSearchRequest.Page,
+		PerPage: userSearchRequest.PerPage,
+		Total:   total,
+	}
+
+	return pageResponse, nil
+}
+
+func (adminService *AdminServiceImpl) DisableUser(ctx context.Context, adminUserId int, userId int) error {
+	if adminUserId == userId {
+		return fmt.Errorf("%w: admins can't disable their own account", helper.ErrForbidden)
+	}
+
+	if _, err := adminService.userRepository.Get(ctx, adminService.db, userId); err != nil {
+		return err
+	}
+
+	if err := adminService.userRepository.UpdateDisabled(ctx, adminService.db, userId, true); err != nil {
+		return err
+	}
+
+	return adminService.endSessions(ctx, userId)
+}
+
+func (adminService *AdminServiceImpl) EnableUser(ctx context.Context, userId int) error {
+	if _, err := adminService.userRepository.Get(ctx, adminService.db, userId); err != nil {
+		return err
+	}
+
+	return adminService.userRepository.UpdateDisabled(ctx, adminService.db, userId, false)
+}
+
+func (adminService *AdminServiceImpl) ForceLogout(ctx context.Context, userId int) error {
+	if _, err := adminService.userRepository.Get(ctx, adminService.db, userId); err != nil {
+		return err
+	}
+
+	return adminService.endSessions(ctx, userId)
+}
+
+func (adminService *AdminServiceImpl) ResetPassword(ctx context.Context, passwordResetRequest request.PasswordResetRequest) error {
+	if err := adminService.validate.StructCtx(ctx, passwordResetRequest); err != nil {
+		return err
+	}
+
+	if _, err := adminService.userRepository.Get(ctx, adminService.db, passwordResetRequest.UserId); err != nil {
+		return err
+	}
+
+	hashedPassword, errHashingPassword := adminService.passwordHasher(passwordResetRequest.Password)
+
+	if errHashingPassword != nil {
+		return errHashingPassword
+	}
+
+	if err := adminService.userRepository.UpdatePassword(ctx, adminService.db, passwordResetRequest.UserId, hashedPassword); err != nil {
+		return err
+	}
+
+	// Sessions started with the old password are ended.
+	return adminService.endSessions(ctx, passwordResetRequest.UserId)
+}
+
+// endSessions signs the user out everywhere. Bumping the token version ends
+// its own sessions, the tokens it granted to OAuth apps are revoked apart.
+func (adminService *AdminServiceImpl) endSessions(ctx context.Context, userId int) error {
+	if err := adminService.userRepository.IncrementTokenVersion(ctx, adminService.db, userId); err != nil {
+		return err
+	}
+
+	return adminService.oauthRepository.RevokeUserTokens(ctx, adminService.db, userId)
+}
+
+// UnlockUser lifts a lockout on the user's username, failures counted
+// against an ip address run out on their own.
+func (adminService *AdminServiceImpl) UnlockUser(ctx context.Context, userId int) error {
+	user, err := adminService.userRepository.Get(ctx, adminService.db, userId)
+
+	if err != nil {
+		return err
+	}
+
+	usernames := []string{user.Username, mfaLoginAccount(user.Username)}
+
+	if user.Email != "" {
+		usernames = append(usernames, user.Email)
+	}
+
+	return adminService.loginThrottle.Unlock(ctx, usernames...)
+}
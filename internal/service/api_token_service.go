@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	customvalidator "go_todo_api/internal/validator"
+	"strings"
+)
+
+type ApiTokenService interface {
+	Create(ctx context.Context, apiTokenCreateRequest request.ApiTokenCreateRequest) (response.ApiTokenCreateResponse, error)
+	FindUserTokens(ctx context.Context, username string) ([]response.ApiTokenResponse, error)
+	Revoke(ctx context.Context, username string, tokenId int) error
+	Authenticate(ctx context.Context, token string) (helper.Principal, error)
+}
+
+type ApiTokenServiceImpl struct {
+	db                 *sql.DB
+	userRepository     repository.UserRepository
+	apiTokenRepository repository.ApiTokenRepository
+	validate           customvalidator.CustomValidator
+}
+
+func NewApiTokenService(db *sql.DB, userRepository repository.UserRepository, apiTokenRepository repository.ApiTokenRepository, validate customvalidator.CustomValidator) ApiTokenService {
+	return &ApiTokenServiceImpl{
+		db:                 db,
+		userRepository:     userRepository,
+		apiTokenRepository: apiTokenRepository,
+		validate:           validate,
+	}
+}
+
+func (apiTokenService *ApiTokenServiceImpl) Create(ctx context.Context, apiTokenCreateRequest request.ApiTokenCreateRequest) (response.ApiTokenCreateResponse, error) {
+	if err := apiTokenService.validate.StructCtx(ctx, apiTokenCreateRequest); err != nil {
+		return response.ApiTokenCreateResponse{}, err
+	}
+
+	user, errGetUser := apiTokenService.userRepository.GetByUsername(ctx, apiTokenService.db, apiTokenCreateRequest.Username)
+
+	if errGetUser != nil {
+		return response.ApiTokenCreateResponse{}, errGetUser
+	}
+
+	token, errGenerateToken := helper.GenerateApiToken()
+
+	if errGenerateToken != nil {
+		return response.ApiTokenCreateResponse{}, errGenerateToken
+	}
+
+	apiToken := entity.ApiToken{
+		UserId:    user.Id,
+		Name:      apiTokenCreateRequest.Name,
+		TokenHash: helper.HashToken(token),
+		Scopes:    strings.Join(apiTokenCreateRequest.Scopes, " "),
+	}
+
+	tokenId, errInsert := apiTokenService.apiTokenRepository.Insert(ctx, apiTokenService.db, apiToken, apiTokenCreateRequest.ExpiresInDays)
+
+	if errInsert != nil {
+		return response.ApiTokenCreateResponse{}, errInsert
+	}
+
+	createdApiToken, errGetToken := apiTokenService.apiTokenRepository.Get(ctx, apiTokenService.db, tokenId)
+
+	if errGetToken != nil {
+		return response.ApiTokenCreateResponse{}, errGetToken
+	}
+
+	apiTokenCreateResponse := response.ApiTokenCreateResponse{
+		ApiTokenResponse: toApiTokenResponse(createdApiToken),
+		Token:            token,
+	}
+
+	return apiTokenCreateResponse, nil
+}
+
+func (apiTokenService *ApiTokenServiceImpl) FindUserTokens(ctx context.Context, username string) ([]response.ApiTokenResponse, error) {
+	user, errGetUser := apiTokenService.userRepository.GetByUsername(ctx, apiTokenService.db, username)
+
+	if errGetUser != nil {
+		return nil, errGetUser
+	}
+
+	apiTokens, err := apiTokenService.apiTokenRepository.GetUserTokens(ctx, apiTokenService.db, user.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	apiTokenResponses := []response.ApiTokenResponse{}
+
+	for _, apiToken := range apiTokens {
+		apiTokenResponses = append(apiTokenResponses, toApiTokenResponse(apiToken))
+	}
+
+	return apiTokenResponses, nil
+}
+
+func (apiTokenService *ApiTokenServiceImpl) Revoke(ctx context.Context, username string, tokenId int) error {
+	user, errGetUser := apiTokenService.userRepository.GetByUsername(ctx, apiTokenService.db, username)
+
+	if errGetUser != nil {
+		return errGetUser
+	}
+
+	err := apiTokenService.apiTokenRepository.Revoke(ctx, apiTokenService.db, user.Id, tokenId)
+
+	if err != nil {
+		if errors.Is(helper.ErrRowsNotAffected, err) {
+			return helper.ErrApiTokenNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (apiTokenService *ApiTokenServiceImpl) Authenticate(ctx context.Context, token string) (helper.Principal, error) {
+	apiToken, err := apiTokenService.apiTokenRepository.GetActiveByHash(ctx, apiTokenService.db, helper.HashToken(token))
+
+	if err != nil {
+		if errors.Is(err, helper.ErrNotFound) {
+			return helper.Principal{}, helper.ErrorTokenInvalid
+		}
+		return helper.Principal{}, err
+	}
+
+	errUpdateLastUsed := apiTokenService.apiTokenRepository.UpdateLastUsed(ctx, apiTokenService.db, apiToken.Id)
+
+	if errUpdateLastUsed != nil {
+		return helper.Principal{}, errUpdateLastUsed
+	}
+
+	// Api tokens never carry the admin role, admin endpoints need a login session.
+	principal := helper.Principal{
+		UserId:     apiToken.UserId,
+		Username:   apiToken.Username,
+		Locale:     apiToken.Locale,
+		AuthMethod: helper.AuthMethodApiToken,
+		Scopes:     strings.Fields(apiToken.Scopes),
+		Roles:      []string{helper.RoleUser},
+	}
+
+	return principal, nil
+}
+
+func toApiTokenResponse(apiToken entity.ApiToken) response.ApiTokenResponse {
+	return response.ApiTokenResponse{
+		Id:         apiToken.Id,
+		Name:       apiToken.Name,
+		Scopes:     strings.Fields(apiToken.Scopes),
+		ExpiresAt:  apiToken.ExpiresAt,
+		LastUsedAt: apiToken.LastUsedAt,
+		CreatedAt:  apiToken.CreatedAt,
+	}
+}
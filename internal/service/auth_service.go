@@ -5,29 +5,41 @@ import (
 	"database/sql"
 	"errors"
 	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/model/response"
 	"go_todo_api/internal/repository"
 	customvalidator "go_todo_api/internal/validator"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthService interface {
 	Login(ctx context.Context, loginRequest request.UserLoginRequest) (response.LoginResponse, error)
+	LoginMfa(ctx context.Context, mfaLoginRequest request.MfaLoginRequest) (response.LoginResponse, error)
 	RefreshToken(ctx context.Context, tokenRefreshRequest request.RefreshTokenRequest) (response.RefreshTokenResponse, error)
+	Authenticate(ctx context.Context, token string) (helper.Principal, error)
+	LoginUser(ctx context.Context, user entity.User) (response.LoginResponse, error)
 }
 
 type AuthServiceImpl struct {
 	db             *sql.DB
 	userRepository repository.UserRepository
+	mfaRepository  repository.MfaRepository
 	validate       customvalidator.CustomValidator
+	jwtKeySet      *helper.JwtKeySet
+	loginThrottle  LoginThrottleService
 }
 
-func NewAuthService(db *sql.DB, userRepository repository.UserRepository, validate customvalidator.CustomValidator) AuthService {
+func NewAuthService(db *sql.DB, userRepository repository.UserRepository, mfaRepository repository.MfaRepository, validate customvalidator.CustomValidator, jwtKeySet *helper.JwtKeySet, loginThrottle LoginThrottleService) AuthService {
 	return &AuthServiceImpl{
 		db:             db,
 		userRepository: userRepository,
+		mfaRepository:  mfaRepository,
 		validate:       validate,
+		jwtKeySet:      jwtKeySet,
+		loginThrottle:  loginThrottle,
 	}
 }
 
@@ -38,45 +50,164 @@ func (authService *AuthServiceImpl) Login(ctx context.Context, loginRequest requ
 		return response.LoginResponse{}, errValidation
 	}
 
-	user, err := authService.userRepository.GetByUsername(ctx, authService.db, loginRequest.Username)
+	identifier := helper.NormalizeIdentifier(loginRequest.Username)
 
-	if err != nil {
-		if errors.Is(helper.ErrNotFound, err) {
-			return response.LoginResponse{}, helper.ErrLoginFailed
-		}
+	user, err := authService.getLoginUser(ctx, identifier)
+
+	if err != nil && !errors.Is(helper.ErrNotFound, err) {
 		return response.LoginResponse{}, err
 	}
 
-	if !helper.CheckPassword(loginRequest.Password, user.Password) {
+	// Failures are counted per account whether its username or its email was
+	// typed, only identifiers of no account are counted as typed.
+	account := identifier
+
+	if err == nil {
+		account = user.Username
+	}
+
+	if errReserve := authService.loginThrottle.Reserve(ctx, account, loginRequest.IpAddress); errReserve != nil {
+		return response.LoginResponse{}, errReserve
+	}
+
+	// Unknown usernames count as failures too, so probing for accounts is throttled the same way.
+	if err != nil || !helper.CheckPassword(loginRequest.Password, user.Password) {
 		return response.LoginResponse{}, helper.ErrLoginFailed
 	}
 
+	if err := authService.loginThrottle.RecordSuccess(ctx, account, loginRequest.IpAddress); err != nil {
+		return response.LoginResponse{}, err
+	}
+
+	return authService.LoginUser(ctx, user)
+}
+
+// getLoginUser looks the identifier up as an email when it has an "@". Usernames
+// can't contain one anymore, but older accounts might, so those are still
+// tried as a username.
+func (authService *AuthServiceImpl) getLoginUser(ctx context.Context, identifier string) (entity.User, error) {
+	if helper.IsEmailIdentifier(identifier) {
+		user, err := authService.userRepository.GetByEmail(ctx, authService.db, identifier)
+
+		if !errors.Is(err, helper.ErrNotFound) {
+			return user, err
+		}
+	}
+
+	return authService.userRepository.GetByUsername(ctx, authService.db, identifier)
+}
+
+// LoginUser finishes a login for a user whose identity is already proven,
+// either by password or by an external identity provider.
+func (authService *AuthServiceImpl) LoginUser(ctx context.Context, user entity.User) (response.LoginResponse, error) {
+	if user.IsDisabled {
+		return response.LoginResponse{}, helper.ErrAccountDisabled
+	}
+
+	userTotp, errGetTotp := authService.mfaRepository.GetTotp(ctx, authService.db, user.Id)
+
+	if errGetTotp != nil && !errors.Is(helper.ErrNotFound, errGetTotp) {
+		return response.LoginResponse{}, errGetTotp
+	}
+
+	if userTotp.IsEnabled {
+		mfaTokenExp := time.Now().Add(time.Duration(5) * time.Minute).Unix()
+
+		mfaTokenStr, errGenerateMfaToken := authService.jwtKeySet.GenerateJWT(user.Username, mfaTokenExp, jwt.MapClaims{"typ": helper.TokenTypeMfa})
+
+		if errGenerateMfaToken != nil {
+			return response.LoginResponse{}, errGenerateMfaToken
+		}
+
+		return response.LoginResponse{}, &helper.MfaRequiredError{MfaToken: mfaTokenStr, ExpiresAt: mfaTokenExp}
+	}
+
+	return authService.createLoginResponse(user)
+}
+
+func (authService *AuthServiceImpl) LoginMfa(ctx context.Context, mfaLoginRequest request.MfaLoginRequest) (response.LoginResponse, error) {
+	errValidation := authService.validate.StructCtx(ctx, mfaLoginRequest)
+
+	if errValidation != nil {
+		return response.LoginResponse{}, errValidation
+	}
+
+	mfaToken, errValidateMfaToken := authService.jwtKeySet.ValidateJWT(mfaLoginRequest.MfaToken)
+
+	if errValidateMfaToken != nil {
+		return response.LoginResponse{}, errValidateMfaToken
+	}
+
+	if helper.GetTokenType(mfaToken) != helper.TokenTypeMfa {
+		return response.LoginResponse{}, helper.ErrorTokenInvalid
+	}
+
+	sub, errGetSub := mfaToken.Claims.GetSubject()
+
+	if errGetSub != nil {
+		return response.LoginResponse{}, errGetSub
+	}
+
+	user, errGetUser := authService.userRepository.GetByUsername(ctx, authService.db, sub)
+
+	if errGetUser != nil {
+		if errors.Is(helper.ErrNotFound, errGetUser) {
+			return response.LoginResponse{}, helper.ErrorTokenInvalid
+		}
+		return response.LoginResponse{}, errGetUser
+	}
+
+	if user.IsDisabled {
+		return response.LoginResponse{}, helper.ErrAccountDisabled
+	}
+
+	userTotp, errGetTotp := authService.mfaRepository.GetTotp(ctx, authService.db, user.Id)
+
+	if errGetTotp != nil && !errors.Is(helper.ErrNotFound, errGetTotp) {
+		return response.LoginResponse{}, errGetTotp
+	}
+
+	if !userTotp.IsEnabled {
+		return response.LoginResponse{}, helper.ErrorTokenInvalid
+	}
+
+	account := mfaLoginAccount(user.Username)
+
+	if err := authService.loginThrottle.Reserve(ctx, account, mfaLoginRequest.IpAddress); err != nil {
+		return response.LoginResponse{}, err
+	}
+
+	if err := verifyMfaCode(ctx, authService.db, authService.mfaRepository, userTotp, mfaLoginRequest.Code); err != nil {
+		return response.LoginResponse{}, err
+	}
+
+	if err := authService.loginThrottle.RecordSuccess(ctx, account, mfaLoginRequest.IpAddress); err != nil {
+		return response.LoginResponse{}, err
+	}
+
+	return authService.createLoginResponse(user)
+}
+
+func (authService *AuthServiceImpl) createLoginResponse(user entity.User) (response.LoginResponse, error) {
 	accessTokenExp := time.Now().Add(time.Duration(15) * time.Minute).Unix()
 	refreshTokenExp := time.Now().Add(time.Duration(720) * time.Hour).Unix()
 
-	accessTokenStr, errGenerateAccessToken := helper.GenerateJWT(user.Username, accessTokenExp)
+	versionClaims := jwt.MapClaims{"ver": user.TokenVersion}
+
+	accessTokenStr, errGenerateAccessToken := authService.jwtKeySet.GenerateJWT(user.Username, accessTokenExp, versionClaims)
 
 	if errGenerateAccessToken != nil {
 		return response.LoginResponse{}, errGenerateAccessToken
 	}
 
-	refreshTokenStr, errGenerateRefreshToken := helper.GenerateJWT(user.Username, refreshTokenExp)
+	refreshTokenStr, errGenerateRefreshToken := authService.jwtKeySet.GenerateJWT(user.Username, refreshTokenExp, versionClaims)
 
 	if errGenerateRefreshToken != nil {
 		return response.LoginResponse{}, errGenerateRefreshToken
 	}
 
-	userResponse := response.UserResponse{
-		Id:          user.Id,
-		Username:    user.Username,
-		Name:        user.Name,
-		Email:       user.Email,
-		PhoneNumber: user.PhoneNumber,
-		CreatedAt:   user.CreatedAt,
-	}
-
 	loginResponse := response.LoginResponse{
-		UserResponse: userResponse,
+		UserResponse: toUserResponse(user),
 		AccessToken:  accessTokenStr,
 		RefreshToken: refreshTokenStr,
 	}
@@ -92,28 +223,65 @@ func (authService *AuthServiceImpl) RefreshToken(ctx context.Context, refreshTok
 	}
 
 	// Validate refresh token.
-	_, errValidateRefreshToken := helper.ValidateJWT(refreshTokenRequest.RefreshToken)
+	requestRefreshToken, errValidateRefreshToken := authService.jwtKeySet.ValidateJWT(refreshTokenRequest.RefreshToken)
 
 	if errValidateRefreshToken != nil {
 		// Can make new type error: refresh token invalid
 		return response.RefreshTokenResponse{}, errValidateRefreshToken
 	}
 
+	// An mfa challenge token proves the password only, it can't be refreshed into an access token.
+	if helper.GetTokenType(requestRefreshToken) == helper.TokenTypeMfa {
+		return response.RefreshTokenResponse{}, helper.ErrorTokenInvalid
+	}
+
+	refreshSub, errGetRefreshSub := requestRefreshToken.Claims.GetSubject()
+
+	if errGetRefreshSub != nil {
+		return response.RefreshTokenResponse{}, errGetRefreshSub
+	}
+
+	user, errGetUser := authService.userRepository.GetByUsername(ctx, authService.db, refreshSub)
+
+	if errGetUser != nil {
+		if errors.Is(errGetUser, helper.ErrNotFound) {
+			return response.RefreshTokenResponse{}, helper.ErrorTokenInvalid
+		}
+		return response.RefreshTokenResponse{}, errGetUser
+	}
+
+	if user.IsDisabled {
+		return response.RefreshTokenResponse{}, helper.ErrAccountDisabled
+	}
+
+	// Tokens issued before a forced logout carry an older version.
+	if helper.GetTokenVersion(requestRefreshToken) != user.TokenVersion {
+		return response.RefreshTokenResponse{}, helper.ErrorTokenInvalid
+	}
+
 	// Validate access token
-	requestAccessToken, errValidateAccessToken := helper.ValidateJWT(refreshTokenRequest.AccessToken)
+	requestAccessToken, errValidateAccessToken := authService.jwtKeySet.ValidateJWT(refreshTokenRequest.AccessToken)
 
 	refreshTokenResponse := response.RefreshTokenResponse{}
 
 	if errValidateAccessToken != nil {
-		if errValidateAccessToken.Error() == "token has invalid claims: token is expired" { // Access token valid, but expired.
+		if errors.Is(errValidateAccessToken, helper.ErrTokenExpired) { // Access token valid, but expired.
+			if helper.GetTokenType(requestAccessToken) == helper.TokenTypeMfa {
+				return response.RefreshTokenResponse{}, helper.ErrorTokenInvalid
+			}
+
 			sub, errGetSub := requestAccessToken.Claims.GetSubject()
 
 			if errGetSub != nil {
 				return response.RefreshTokenResponse{}, errGetSub
 			}
 
+			if sub != user.Username {
+				return response.RefreshTokenResponse{}, helper.ErrorTokenInvalid
+			}
+
 			newAccessTokenExp := time.Now().Add(time.Duration(15) * time.Minute).Unix()
-			newAccessTokenStr, errGenerateAccessToken := helper.GenerateJWT(sub, newAccessTokenExp)
+			newAccessTokenStr, errGenerateAccessToken := authService.jwtKeySet.GenerateJWT(sub, newAccessTokenExp, jwt.MapClaims{"ver": user.TokenVersion})
 
 			if errGenerateAccessToken != nil {
 				return response.RefreshTokenResponse{}, errGenerateAccessToken
@@ -121,9 +289,56 @@ func (authService *AuthServiceImpl) RefreshToken(ctx context.Context, refreshTok
 
 			refreshTokenResponse.AccessToken = newAccessTokenStr
 		} else { // Invalid access token.
-			return response.RefreshTokenResponse{}, errValidateRefreshToken
+			return response.RefreshTokenResponse{}, errValidateAccessToken
 		}
 	}
 
 	return refreshTokenResponse, nil
 }
+
+func (authService *AuthServiceImpl) Authenticate(ctx context.Context, token string) (helper.Principal, error) {
+	validatedToken, errValidateToken := authService.jwtKeySet.ValidateJWT(token)
+
+	if errValidateToken != nil {
+		return helper.Principal{}, errValidateToken
+	}
+
+	if helper.GetTokenType(validatedToken) == helper.TokenTypeMfa {
+		return helper.Principal{}, helper.ErrorTokenInvalid
+	}
+
+	sub, errGetSub := validatedToken.Claims.GetSubject()
+
+	if errGetSub != nil {
+		return helper.Principal{}, helper.ErrorTokenInvalid
+	}
+
+	user, errGetUser := authService.userRepository.GetByUsername(ctx, authService.db, sub)
+
+	if errGetUser != nil {
+		if errors.Is(errGetUser, helper.ErrNotFound) {
+			return helper.Principal{}, helper.ErrorTokenInvalid
+		}
+		return helper.Principal{}, errGetUser
+	}
+
+	if user.IsDisabled {
+		return helper.Principal{}, helper.ErrAccountDisabled
+	}
+
+	if helper.GetTokenVersion(validatedToken) != user.TokenVersion {
+		return helper.Principal{}, helper.ErrorTokenInvalid
+	}
+
+	// A login session carries every scope, only api tokens are narrowed down.
+	principal := helper.Principal{
+		UserId:     user.Id,
+		Username:   user.Username,
+		Locale:     user.Locale,
+		AuthMethod: helper.AuthMethodSession,
+		Scopes:     helper.AllScopes,
+		Roles:      helper.RolesFor(user.Role),
+	}
+
+	return principal, nil
+}
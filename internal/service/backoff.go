@@ -0,0 +1,15 @@
+package service
+
+import "time"
+
+// exponentialBackoff is how long to wait after the given number of failed
+// attempts, initialBackoff after the first, doubling up to maxBackoff.
+func exponentialBackoff(initialBackoff time.Duration, maxBackoff time.Duration, attempts int) time.Duration {
+	backoff := initialBackoff
+
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxBackoff)
+}
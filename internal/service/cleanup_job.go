@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"time"
+)
+
+const CleanupJobType = "cleanup"
+
+// CleanupConfig decides how long published events, finished jobs, schedule
+// runs and reminders are kept. They are deleted BatchSize rows at a time, to keep locks short.
+type CleanupConfig struct {
+	Retention time.Duration
+	BatchSize int
+}
+
+func DefaultCleanupConfig() CleanupConfig {
+	return CleanupConfig{
+		Retention: 7 * 24 * time.Hour,
+		BatchSize: 1000,
+	}
+}
+
+// CleanupJob deletes the outbox events, jobs, schedule runs and reminders
+// that are done with, which nothing reads once they are past retention.
+type CleanupJob struct {
+	db                 *sql.DB
+	outboxRepository   repository.OutboxRepository
+	jobRepository      repository.JobRepository
+	reminderRepository repository.ReminderRepository
+	config             CleanupConfig
+}
+
+func NewCleanupJob(db *sql.DB, outboxRepository repository.OutboxRepository, jobRepository repository.JobRepository, reminderRepository repository.ReminderRepository, config CleanupConfig) *CleanupJob {
+	return &CleanupJob{
+		db:                 db,
+		outboxRepository:   outboxRepository,
+		jobRepository:      jobRepository,
+		reminderRepository: reminderRepository,
+		config:             config,
+	}
+}
+
+func (cleanupJob *CleanupJob) Type() string {
+	return CleanupJobType
+}
+
+func (cleanupJob *CleanupJob) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (cleanupJob *CleanupJob) Handle(ctx context.Context, job entity.Job) error {
+	deletes := []func(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error){
+		cleanupJob.outboxRepository.DeletePublished,
+		cleanupJob.jobRepository.DeleteFinished,
+		cleanupJob.jobRepository.DeleteScheduleRuns,
+		cleanupJob.reminderRepository.DeleteOld,
+	}
+
+	for _, deleteBatch := range deletes {
+		for {
+			deleted, err := deleteBatch(ctx, cleanupJob.db, cleanupJob.config.Retention, cleanupJob.config.BatchSize)
+
+			if err != nil {
+				return err
+			}
+
+			if deleted < int64(cleanupJob.config.BatchSize) {
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// JobConfig decides how jobs are run. A job that fails is retried after
+// InitialBackoff, doubling up to MaxBackoff, until MaxAttempts were made.
+// Timeout bounds a run of jobs whose handler has none of its own. On
+// shutdown running jobs get DrainTimeout to finish, the ones still running
+// then are cancelled and queued again.
+type JobConfig struct {
+	PollInterval   time.Duration
+	Concurrency    int
+	Timeout        time.Duration
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	DrainTimeout   time.Duration
+}
+
+func DefaultJobConfig() JobConfig {
+	return JobConfig{
+		PollInterval:   time.Second,
+		Concurrency:    4,
+		Timeout:        time.Minute,
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Hour,
+		DrainTimeout:   30 * time.Second,
+	}
+}
+
+// JobHandler runs the jobs of one type. A job may run more than once, when
+// it failed or its runner stopped before saving that it succeeded.
+type JobHandler interface {
+	// Type is saved with the jobs, it must not change.
+	Type() string
+	// Timeout bounds a run, zero for JobConfig.Timeout.
+	Timeout() time.Duration
+	Handle(ctx context.Context, job entity.Job) error
+}
+
+// JobSchedule queues a job of JobType with Payload each time Cron matches,
+// in UTC. Name tells the schedule's jobs apart, it must not change.
+type JobSchedule struct {
+	Name    string
+	Cron    helper.CronSchedule
+	JobType string
+	Payload any
+}
+
+// JobRunner runs jobs from a queue in the database that all instances share.
+// Each job is claimed by one runner at a time, and a scheduled job is queued
+// once however many instances are running.
+type JobRunner interface {
+	// Enqueue queues a job to run after delay. With a uniqueKey, a job already
+	// queued with the same key is not queued again.
+	Enqueue(ctx context.Context, jobType string, payload any, uniqueKey string, delay time.Duration) error
+	// Run queues scheduled jobs and runs jobs until ctx is done, then drains
+	// the ones running.
+	Run(ctx context.Context)
+	// RunDue runs a batch of the jobs that are due and waits for them.
+	RunDue(ctx context.Context) error
+	// Schedule queues the scheduled jobs that are due at now.
+	Schedule(ctx context.Context, now time.Time) error
+}
+
+type JobRunnerImpl struct {
+	db            *sql.DB
+	jobRepository repository.JobRepository
+	handlers      map[string]JobHandler
+	jobTypes      []string
+	schedules     []JobSchedule
+	config        JobConfig
+	mutex         sync.Mutex
+	nextRuns      []time.Time
+	wake          chan struct{}
+}
+
+func NewJobRunner(db *sql.DB, jobRepository repository.JobRepository, handlers []JobHandler, schedules []JobSchedule, config JobConfig) JobRunner {
+	runner := &JobRunnerImpl{
+		db:            db,
+		jobRepository: jobRepository,
+		handlers:      map[string]JobHandler{},
+		schedules:     schedules,
+		config:        config,
+		nextRuns:      make([]time.Time, len(schedules)),
+		wake:          make(chan struct{}, 1),
+	}
+
+	for _, handler := range handlers {
+		runner.handlers[handler.Type()] = handler
+		runner.jobTypes = append(runner.jobTypes, handler.Type())
+	}
+
+	now := time.Now().UTC()
+
+	for i, schedule := range schedules {
+		runner.nextRuns[i] = schedule.Cron.Next(now)
+	}
+
+	return runner
+}
+
+func (runner *JobRunnerImpl) Enqueue(ctx context.Context, jobType string, payload any, uniqueKey string, delay time.Duration) error {
+	data, errMarshal := json.Marshal(payload)
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	job := entity.Job{
+		Type:      jobType,
+		Payload:   string(data),
+		UniqueKey: uniqueKey,
+	}
+
+	if err := runner.jobRepository.Insert(ctx, runner.db, job, max(delay, 0)); err != nil {
+		return err
+	}
+
+	if delay <= 0 {
+		runner.notify()
+	}
+
+	return nil
+}
+
+// Schedule queues a job for each schedule whose next run came. The run is
+// recorded in the same transaction as its job, so instances queueing the
+// same run add one job, even after it finished. Runs missed while no
+// instance was up are skipped.
+func (runner *JobRunnerImpl) Schedule(ctx context.Context, now time.Time) error {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	now = now.UTC()
+
+	for i, schedule := range runner.schedules {
+		nextRun := runner.nextRuns[i]
+
+		if nextRun.IsZero() || now.Before(nextRun) {
+			continue
+		}
+
+		uniqueKey := fmt.Sprintf("schedule:%s:%s", schedule.Name, nextRun.Format(time.RFC3339))
+
+		if err := runner.queueScheduled(ctx, schedule, nextRun, uniqueKey); err != nil {
+			return err
+		}
+
+		runner.nextRuns[i] = schedule.Cron.Next(now)
+	}
+
+	return nil
+}
+
+func (runner *JobRunnerImpl) queueScheduled(ctx context.Context, schedule JobSchedule, runAt time.Time, uniqueKey string) error {
+	data, errMarshal := json.Marshal(schedule.Payload)
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	tx, errTxBegin := runner.db.BeginTx(ctx, nil)
+
+	if errTxBegin != nil {
+		return errTxBegin
+	}
+
+	errRun := runner.jobRepository.InsertScheduleRun(ctx, tx, schedule.Name, runAt)
+
+	// Another instance queued this run.
+	if errors.Is(errRun, helper.ErrConflict) {
+		tx.Rollback()
+		return nil
+	}
+
+	if errRun != nil {
+		tx.Rollback()
+		return errRun
+	}
+
+	job := entity.Job{
+		Type:      schedule.JobType,
+		Payload:   string(data),
+		UniqueKey: uniqueKey,
+	}
+
+	if err := runner.jobRepository.InsertInTx(ctx, tx, job, 0); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	runner.notify()
+
+	return nil
+}
+
+func (runner *JobRunnerImpl) Run(ctx context.Context) {
+	// Jobs outlive ctx until the drain is over.
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	running := sync.WaitGroup{}
+	slots := make(chan struct{}, runner.config.Concurrency)
+
+	poll := time.NewTicker(runner.config.PollInterval)
+	defer poll.Stop()
+
+	for {
+		if err := runner.Schedule(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			logrus.WithField("error", err.Error()).Error("scheduled jobs not queued")
+		}
+
+		if free := cap(slots) - len(slots); free > 0 {
+			jobs, err := runner.claim(ctx, free)
+
+			if err != nil && ctx.Err() == nil {
+				logrus.WithField("error", err.Error()).Error("jobs not claimed")
+			}
+
+			for _, job := range jobs {
+				slots <- struct{}{}
+				running.Add(1)
+
+				go func(job entity.Job) {
+					defer running.Done()
+					defer func() {
+						<-slots
+						runner.notify()
+					}()
+
+					runner.run(jobCtx, job)
+				}(job)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			runner.drain(&running, cancelJobs)
+			return
+		case <-poll.C:
+		case <-runner.wake:
+		}
+	}
+}
+
+func (runner *JobRunnerImpl) RunDue(ctx context.Context) error {
+	jobs, err := runner.claim(ctx, runner.config.Concurrency)
+
+	if err != nil {
+		return err
+	}
+
+	waitGroup := sync.WaitGroup{}
+
+	for _, job := range jobs {
+		waitGroup.Add(1)
+
+		go func(job entity.Job) {
+			defer waitGroup.Done()
+			runner.run(ctx, job)
+		}(job)
+	}
+
+	waitGroup.Wait()
+
+	return nil
+}
+
+func (runner *JobRunnerImpl) notify() {
+	select {
+	case runner.wake <- struct{}{}:
+	default:
+	}
+}
+
+// drain waits for the running jobs, and cancels them once DrainTimeout is
+// over.
+func (runner *JobRunnerImpl) drain(running *sync.WaitGroup, cancelJobs context.CancelFunc) {
+	drained := make(chan struct{})
+
+	go func() {
+		running.Wait()
+		close(drained)
+	}()
+
+	timeout := time.NewTimer(runner.config.DrainTimeout)
+	defer timeout.Stop()
+
+	select {
+	case <-drained:
+	case <-timeout.C:
+		cancelJobs()
+		<-drained
+	}
+}
+
+// claim locks up to limit due jobs and starts them in one transaction, so no
+// other runner can claim them in between.
+func (runner *JobRunnerImpl) claim(ctx context.Context, limit int) ([]entity.Job, error) {
+	tx, errTxBegin := runner.db.BeginTx(ctx, nil)
+
+	if errTxBegin != nil {
+		return nil, errTxBegin
+	}
+
+	jobs, errGet := runner.jobRepository.GetDueForUpdate(ctx, tx, runner.jobTypes, limit)
+
+	if errGet != nil {
+		tx.Rollback()
+		return nil, errGet
+	}
+
+	for i := range jobs {
+		// The claim lasts long enough for the run and for saving its outcome.
+		if err := runner.jobRepository.Start(ctx, tx, jobs[i].Id, runner.timeout(jobs[i].Type)+30*time.Second); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		jobs[i].Status = entity.JobRunning
+		jobs[i].Attempts++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// run runs the job within its timeout and saves how it went.
+func (runner *JobRunnerImpl) run(ctx context.Context, job entity.Job) {
+	errHandle := runner.handle(ctx, job)
+
+	logFields := logrus.Fields{
+		"job_id":   job.Id,
+		"job_type": job.Type,
+		"attempts": job.Attempts,
+	}
+
+	// Cancelled by a shutdown, the job is left for the next runner.
+	if ctx.Err() != nil {
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if err := runner.jobRepository.Release(saveCtx, runner.db, job); err != nil && !errors.Is(err, helper.ErrRowsNotAffected) {
+			logrus.WithFields(logFields).WithField("error", err.Error()).Error("job not released")
+		}
+
+		return
+	}
+
+	var errSave error
+
+	if errHandle == nil {
+		errSave = runner.jobRepository.Complete(ctx, runner.db, job)
+	} else {
+		job.Status = entity.JobPending
+		job.LastError = truncate(errHandle.Error(), 255)
+		retryIn := time.Duration(0)
+
+		if job.Attempts >= runner.config.MaxAttempts {
+			job.Status = entity.JobFailed
+		} else {
+			retryIn = exponentialBackoff(runner.config.InitialBackoff, runner.config.MaxBackoff, job.Attempts)
+		}
+
+		logrus.WithFields(logFields).WithField("error", errHandle.Error()).Error("job failed")
+
+		errSave = runner.jobRepository.RecordFailure(ctx, runner.db, job, retryIn)
+	}
+
+	// The claim ran out while the job ran, the runner that took it over
+	// saves its own outcome.
+	if errors.Is(errSave, helper.ErrRowsNotAffected) {
+		logrus.WithFields(logFields).Warn("job outcome dropped, its claim was lost")
+		return
+	}
+
+	if errSave != nil {
+		logrus.WithFields(logFields).WithField("error", errSave.Error()).Error("job outcome not saved")
+	}
+}
+
+// handle calls the job's handler, a panic fails the job rather than the
+// process.
+func (runner *JobRunnerImpl) handle(ctx context.Context, job entity.Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, runner.timeout(job.Type))
+	defer cancel()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return runner.handlers[job.Type].Handle(ctx, job)
+}
+
+func (runner *JobRunnerImpl) timeout(jobType string) time.Duration {
+	if timeout := runner.handlers[jobType].Timeout(); timeout > 0 {
+		return timeout
+	}
+
+	return runner.config.Timeout
+}
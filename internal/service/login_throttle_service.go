@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"go_todo_api/internal/repository"
+	"strings"
+	"time"
+)
+
+// LoginThrottlePolicy decides how long a key is locked after its nth failure.
+// The first FreeAttempts failures cost nothing, after that the delay doubles
+// from BaseDelay up to MaxDelay, and LockoutAfter failures lock the key for
+// LockoutDuration.
+type LoginThrottlePolicy struct {
+	Window          time.Duration
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+}
+
+// LoginThrottleConfig has a stricter policy per username than per ip address,
+// since many users may share an address.
+type LoginThrottleConfig struct {
+	Username LoginThrottlePolicy
+	Ip       LoginThrottlePolicy
+}
+
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		Username: LoginThrottlePolicy{
+			Window:          15 * time.Minute,
+			FreeAttempts:    3,
+			BaseDelay:       time.Second,
+			MaxDelay:        time.Minute,
+			LockoutAfter:    10,
+			LockoutDuration: 15 * time.Minute,
+		},
+		Ip: LoginThrottlePolicy{
+			Window:          15 * time.Minute,
+			FreeAttempts:    20,
+			BaseDelay:       time.Second,
+			MaxDelay:        time.Minute,
+			LockoutAfter:    100,
+			LockoutDuration: 15 * time.Minute,
+		},
+	}
+}
+
+func (policy LoginThrottlePolicy) lockDuration(failures int) time.Duration {
+	if failures >= policy.LockoutAfter {
+		return policy.LockoutDuration
+	}
+
+	if failures <= policy.FreeAttempts {
+		return 0
+	}
+
+	delay := policy.BaseDelay
+
+	for i := policy.FreeAttempts + 1; i < failures && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, policy.MaxDelay)
+}
+
+type LoginThrottleService interface {
+	Reserve(ctx context.Context, username string, ipAddress string) error
+	RecordSuccess(ctx context.Context, username string, ipAddress string) error
+	Unlock(ctx context.Context, usernames ...string) error
+}
+
+type LoginThrottleServiceImpl struct {
+	loginAttemptStore repository.LoginAttemptStore
+	config            LoginThrottleConfig
+}
+
+func NewLoginThrottleService(loginAttemptStore repository.LoginAttemptStore, config LoginThrottleConfig) LoginThrottleService {
+	return &LoginThrottleServiceImpl{
+		loginAttemptStore: loginAttemptStore,
+		config:            config,
+	}
+}
+
+func usernameAttemptKey(username string) string {
+	return "username:" + strings.ToLower(username)
+}
+
+// mfaLoginAccount counts mfa codes apart from passwords, so that knowing the
+// password doesn't reset the guesses at the code.
+func mfaLoginAccount(username string) string {
+	return "mfa:" + username
+}
+
+func ipAttemptKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+// Reserve counts an attempt as failed before it's made, and fails with a
+// LoginThrottledError while the username or the ip address is locked. Counting
+// first means parallel guesses can't all be checked before the lock lands.
+func (loginThrottleService *LoginThrottleServiceImpl) Reserve(ctx context.Context, username string, ipAddress string) error {
+	now := time.Now()
+	usernameKey := usernameAttemptKey(username)
+
+	if err := loginThrottleService.reserve(ctx, usernameKey, now, loginThrottleService.config.Username); err != nil {
+		return err
+	}
+
+	if ipAddress == "" {
+		return nil
+	}
+
+	if err := loginThrottleService.reserve(ctx, ipAttemptKey(ipAddress), now, loginThrottleService.config.Ip); err != nil {
+		// The attempt isn't made, so the username doesn't pay for it.
+		if errRelease := loginThrottleService.loginAttemptStore.Release(ctx, usernameKey); errRelease != nil {
+			return errRelease
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (loginThrottleService *LoginThrottleServiceImpl) reserve(ctx context.Context, key string, now time.Time, policy LoginThrottlePolicy) error {
+	_, err := loginThrottleService.loginAttemptStore.Reserve(ctx, key, now, policy.Window, policy.lockDuration)
+
+	return err
+}
+
+// RecordSuccess clears the username counter, while the ip address only gets
+// back the attempt that succeeded. Clearing it too would let an attacker with
+// one valid account reset it between guesses.
+func (loginThrottleService *LoginThrottleServiceImpl) RecordSuccess(ctx context.Context, username string, ipAddress string) error {
+	if err := loginThrottleService.loginAttemptStore.Reset(ctx, usernameAttemptKey(username)); err != nil {
+		return err
+	}
+
+	if ipAddress == "" {
+		return nil
+	}
+
+	return loginThrottleService.loginAttemptStore.Release(ctx, ipAttemptKey(ipAddress))
+}
+
+// Unlock clears the counters of all the given usernames, an account may have
+// been guessed at under its email before it was counted under its username.
+func (loginThrottleService *LoginThrottleServiceImpl) Unlock(ctx context.Context, usernames ...string) error {
+	for _, username := range usernames {
+		if err := loginThrottleService.loginAttemptStore.Reset(ctx, usernameAttemptKey(username)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	customvalidator "go_todo_api/internal/validator"
+	"time"
+)
+
+type MfaService interface {
+	EnrollTotp(ctx context.Context, username string) (response.TotpEnrollResponse, error)
+	ConfirmTotp(ctx context.Context, totpConfirmRequest request.TotpConfirmRequest) (response.RecoveryCodesResponse, error)
+	DisableTotp(ctx context.Context, totpDisableRequest request.TotpDisableRequest) error
+}
+
+type MfaServiceImpl struct {
+	db             *sql.DB
+	userRepository repository.UserRepository
+	mfaRepository  repository.MfaRepository
+	validate       customvalidator.CustomValidator
+	loginThrottle  LoginThrottleService
+}
+
+func NewMfaService(db *sql.DB, userRepository repository.UserRepository, mfaRepository repository.MfaRepository, validate customvalidator.CustomValidator, loginThrottle LoginThrottleService) MfaService {
+	return &MfaServiceImpl{
+		db:             db,
+		userRepository: userRepository,
+		mfaRepository:  mfaRepository,
+		validate:       validate,
+		loginThrottle:  loginThrottle,
+	}
+}
+
+func (mfaService *MfaServiceImpl) EnrollTotp(ctx context.Context, username string) (response.TotpEnrollResponse, error) {
+	user, errGetUser := mfaService.userRepository.GetByUsername(ctx, mfaService.db, username)
+
+	if errGetUser != nil {
+		return response.TotpEnrollResponse{}, errGetUser
+	}
+
+	userTotp, errGetTotp := mfaService.mfaRepository.GetTotp(ctx, mfaService.db, user.Id)
+
+	if errGetTotp != nil && !errors.Is(helper.ErrNotFound, errGetTotp) {
+		return response.TotpEnrollResponse{}, errGetTotp
+	}
+
+	if userTotp.IsEnabled {
+		return response.TotpEnrollResponse{}, helper.ErrMfaAlreadyEnabled
+	}
+
+	secret, errGenerateSecret := helper.GenerateTotpSecret()
+
+	if errGenerateSecret != nil {
+		return response.TotpEnrollResponse{}, errGenerateSecret
+	}
+
+	err := mfaService.mfaRepository.SaveTotp(ctx, mfaService.db, entity.UserTotp{UserId: user.Id, Secret: secret})
+
+	if err != nil {
+		return response.TotpEnrollResponse{}, err
+	}
+
+	totpEnrollResponse := response.TotpEnrollResponse{
+		Secret:          secret,
+		ProvisioningUri: helper.TotpProvisioningUri(helper.TotpIssuer, user.Username, secret),
+	}
+
+	return totpEnrollResponse, nil
+}
+
+func (mfaService *MfaServiceImpl) ConfirmTotp(ctx context.Context, totpConfirmRequest request.TotpConfirmRequest) (response.RecoveryCodesResponse, error) {
+	if err := mfaService.validate.StructCtx(ctx, totpConfirmRequest); err != nil {
+		return response.RecoveryCodesResponse{}, err
+	}
+
+	user, errGetUser := mfaService.userRepository.GetByUsername(ctx, mfaService.db, totpConfirmRequest.Username)
+
+	if errGetUser != nil {
+		return response.RecoveryCodesResponse{}, errGetUser
+	}
+
+	userTotp, errGetTotp := mfaService.mfaRepository.GetTotp(ctx, mfaService.db, user.Id)
+
+	if errGetTotp != nil {
+		if errors.Is(helper.ErrNotFound, errGetTotp) {
+			return response.RecoveryCodesResponse{}, helper.ErrMfaNotEnabled
+		}
+		return response.RecoveryCodesResponse{}, errGetTotp
+	}
+
+	if userTotp.IsEnabled {
+		return response.RecoveryCodesResponse{}, helper.ErrMfaAlreadyEnabled
+	}
+
+	// Recovery codes are not valid before enrollment is confirmed, so only a TOTP code is accepted here.
+	step, ok := helper.ValidateTotp(userTotp.Secret, totpConfirmRequest.Code, time.Now())
+
+	if !ok {
+		return response.RecoveryCodesResponse{}, helper.ErrMfaCodeInvalid
+	}
+
+	if err := mfaService.mfaRepository.UpdateTotpLastUsedStep(ctx, mfaService.db, user.Id, step); err != nil {
+		if errors.Is(helper.ErrRowsNotAffected, err) {
+			return response.RecoveryCodesResponse{}, helper.ErrMfaCodeInvalid
+		}
+		return response.RecoveryCodesResponse{}, err
+	}
+
+	recoveryCodes, errGenerateCodes := helper.GenerateRecoveryCodes(helper.RecoveryCodeCount)
+
+	if errGenerateCodes != nil {
+		return response.RecoveryCodesResponse{}, errGenerateCodes
+	}
+
+	codeHashes := make([]string, 0, len(recoveryCodes))
+
+	for _, recoveryCode := range recoveryCodes {
+		codeHashes = append(codeHashes, helper.HashToken(recoveryCode))
+	}
+
+	tx, errTxBegin := mfaService.db.Begin()
+
+	if errTxBegin != nil {
+		return response.RecoveryCodesResponse{}, errTxBegin
+	}
+
+	if err := mfaService.mfaRepository.EnableTotp(ctx, tx, user.Id); err != nil {
+		tx.Rollback()
+		return response.RecoveryCodesResponse{}, err
+	}
+
+	if err := mfaService.mfaRepository.DeleteRecoveryCodes(ctx, tx, user.Id); err != nil {
+		tx.Rollback()
+		return response.RecoveryCodesResponse{}, err
+	}
+
+	if err := mfaService.mfaRepository.InsertRecoveryCodes(ctx, tx, user.Id, codeHashes); err != nil {
+		tx.Rollback()
+		return response.RecoveryCodesResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return response.RecoveryCodesResponse{}, err
+	}
+
+	return response.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (mfaService *MfaServiceImpl) DisableTotp(ctx context.Context, totpDisableRequest request.TotpDisableRequest) error {
+	if err := mfaService.validate.StructCtx(ctx, totpDisableRequest); err != nil {
+		return err
+	}
+
+	user, errGetUser := mfaService.userRepository.GetByUsername(ctx, mfaService.db, totpDisableRequest.Username)
+
+	if errGetUser != nil {
+		return errGetUser
+	}
+
+	userTotp, errGetTotp := mfaService.mfaRepository.GetTotp(ctx, mfaService.db, user.Id)
+
+	if errGetTotp != nil && !errors.Is(helper.ErrNotFound, errGetTotp) {
+		return errGetTotp
+	}
+
+	if !userTotp.IsEnabled {
+		return helper.ErrMfaNotEnabled
+	}
+
+	// Codes guessed here count against the same limit as those guessed at login.
+	account := mfaLoginAccount(user.Username)
+
+	if err := mfaService.loginThrottle.Reserve(ctx, account, totpDisableRequest.IpAddress); err != nil {
+		return err
+	}
+
+	if err := verifyMfaCode(ctx, mfaService.db, mfaService.mfaRepository, userTotp, totpDisableRequest.Code); err != nil {
+		return err
+	}
+
+	if err := mfaService.loginThrottle.RecordSuccess(ctx, account, totpDisableRequest.IpAddress); err != nil {
+		return err
+	}
+
+	tx, errTxBegin := mfaService.db.Begin()
+
+	if errTxBegin != nil {
+		return errTxBegin
+	}
+
+	if err := mfaService.mfaRepository.DeleteRecoveryCodes(ctx, tx, user.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := mfaService.mfaRepository.DeleteTotp(ctx, tx, user.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// verifyMfaCode accepts either a current TOTP code or an unused recovery code.
+func verifyMfaCode(ctx context.Context, db *sql.DB, mfaRepository repository.MfaRepository, userTotp entity.UserTotp, code string) error {
+	if step, ok := helper.ValidateTotp(userTotp.Secret, code, time.Now()); ok {
+		err := mfaRepository.UpdateTotpLastUsedStep(ctx, db, userTotp.UserId, step)
+
+		if errors.Is(helper.ErrRowsNotAffected, err) {
+			return helper.ErrMfaCodeInvalid
+		}
+
+		return err
+	}
+
+	err := mfaRepository.UseRecoveryCode(ctx, db, userTotp.UserId, helper.HashToken(helper.NormalizeRecoveryCode(code)))
+
+	if errors.Is(helper.ErrRowsNotAffected, err) {
+		return helper.ErrMfaCodeInvalid
+	}
+
+	return err
+}
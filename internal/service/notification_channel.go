@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Notification is what a user is told, the same on every channel. Id is the
+// same each time the same notification is sent, push services use it to
+// replace copies that weren't delivered yet.
+type Notification struct {
+	Id    string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// NotificationTarget is one place a channel delivers to, the user's email
+// address or one of their devices. Key names it in Reminder.SentTo.
+type NotificationTarget struct {
+	Key          string
+	Email        string
+	Subscription entity.PushSubscription
+}
+
+// NotificationChannel is a way of reaching users. Send to a target that no
+// longer exists, like an unsubscribed device, succeeds without sending.
+type NotificationChannel interface {
+	Name() string
+	Targets(ctx context.Context, user entity.User) ([]NotificationTarget, error)
+	Send(ctx context.Context, target NotificationTarget, notification Notification) error
+}
+
+type EmailChannel struct {
+	mailer helper.Mailer
+}
+
+func NewEmailChannel(mailer helper.Mailer) *EmailChannel {
+	return &EmailChannel{
+		mailer: mailer,
+	}
+}
+
+func (channel *EmailChannel) Name() string {
+	return entity.NotificationChannelEmail
+}
+
+func (channel *EmailChannel) Targets(ctx context.Context, user entity.User) ([]NotificationTarget, error) {
+	if user.Email == "" {
+		return []NotificationTarget{}, nil
+	}
+
+	return []NotificationTarget{{Key: entity.NotificationChannelEmail, Email: user.Email}}, nil
+}
+
+func (channel *EmailChannel) Send(ctx context.Context, target NotificationTarget, notification Notification) error {
+	return channel.mailer.Send(ctx, helper.Mail{
+		To:      target.Email,
+		Subject: notification.Title,
+		Body:    notification.Body,
+	})
+}
+
+// pushChannel reaches the devices a user subscribed on one push channel.
+type pushChannel struct {
+	db                     *sql.DB
+	notificationRepository repository.NotificationRepository
+	name                   string
+}
+
+func (channel *pushChannel) Name() string {
+	return channel.name
+}
+
+func (channel *pushChannel) Targets(ctx context.Context, user entity.User) ([]NotificationTarget, error) {
+	subscriptions, err := channel.notificationRepository.GetUserSubscriptions(ctx, channel.db, user.Id, channel.name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []NotificationTarget{}
+
+	for _, subscription := range subscriptions {
+		targets = append(targets, NotificationTarget{Key: channel.name + ":" + strconv.Itoa(subscription.Id), Subscription: subscription})
+	}
+
+	return targets, nil
+}
+
+// gone removes a subscription the push service says is gone, it would fail
+// the same way every time.
+func (channel *pushChannel) gone(ctx context.Context, target NotificationTarget, err error) error {
+	if !errors.Is(err, helper.ErrPushSubscriptionGone) {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{"channel": channel.name, "subscription_id": target.Subscription.Id}).Info("push subscription gone, removing it")
+
+	errDelete := channel.notificationRepository.DeleteSubscription(ctx, channel.db, target.Subscription.UserId, target.Subscription.Id)
+
+	if errDelete != nil && !errors.Is(errDelete, helper.ErrRowsNotAffected) {
+		return errDelete
+	}
+
+	return nil
+}
+
+type WebPushChannel struct {
+	pushChannel
+	client *helper.WebPushClient
+}
+
+func NewWebPushChannel(db *sql.DB, notificationRepository repository.NotificationRepository, client *helper.WebPushClient) *WebPushChannel {
+	return &WebPushChannel{
+		pushChannel: pushChannel{db: db, notificationRepository: notificationRepository, name: entity.NotificationChannelWebPush},
+		client:      client,
+	}
+}
+
+// PublicKey is the VAPID key browsers need to subscribe.
+func (channel *WebPushChannel) PublicKey() string {
+	return channel.client.PublicKey()
+}
+
+// Send pushes the notification as JSON for the service worker to show.
+func (channel *WebPushChannel) Send(ctx context.Context, target NotificationTarget, notification Notification) error {
+	payload, err := json.Marshal(map[string]any{
+		"id":    notification.Id,
+		"title": notification.Title,
+		"body":  notification.Body,
+		"data":  notification.Data,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	errSend := channel.client.Send(ctx, helper.WebPushMessage{
+		Endpoint: target.Subscription.Endpoint,
+		P256dh:   target.Subscription.P256dh,
+		Auth:     target.Subscription.Auth,
+		Topic:    notification.Id,
+		Payload:  payload,
+	})
+
+	return channel.gone(ctx, target, errSend)
+}
+
+type FcmChannel struct {
+	pushChannel
+	client *helper.FcmClient
+}
+
+func NewFcmChannel(db *sql.DB, notificationRepository repository.NotificationRepository, client *helper.FcmClient) *FcmChannel {
+	return &FcmChannel{
+		pushChannel: pushChannel{db: db, notificationRepository: notificationRepository, name: entity.NotificationChannelFcm},
+		client:      client,
+	}
+}
+
+func (channel *FcmChannel) Send(ctx context.Context, target NotificationTarget, notification Notification) error {
+	errSend := channel.client.Send(ctx, helper.FcmMessage{
+		Token:       target.Subscription.Endpoint,
+		Title:       notification.Title,
+		Body:        notification.Body,
+		Data:        notification.Data,
+		CollapseKey: notification.Id,
+	})
+
+	return channel.gone(ctx, target, errSend)
+}
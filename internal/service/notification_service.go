@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	customvalidator "go_todo_api/internal/validator"
+	"strings"
+)
+
+type NotificationService interface {
+	FindSettings(ctx context.Context, userId int) (response.NotificationSettingsResponse, error)
+	UpdateSettings(ctx context.Context, notificationSettingsRequest request.NotificationSettingsRequest) (response.NotificationSettingsResponse, error)
+	Subscribe(ctx context.Context, pushSubscriptionCreateRequest request.PushSubscriptionCreateRequest) (response.PushSubscriptionResponse, error)
+	FindSubscriptions(ctx context.Context, userId int) ([]response.PushSubscriptionResponse, error)
+	Unsubscribe(ctx context.Context, userId int, subscriptionId int) error
+}
+
+type NotificationServiceImpl struct {
+	db                     *sql.DB
+	notificationRepository repository.NotificationRepository
+	channels               []NotificationChannel
+	validate               customvalidator.CustomValidator
+}
+
+func NewNotificationService(db *sql.DB, notificationRepository repository.NotificationRepository, channels []NotificationChannel, validate customvalidator.CustomValidator) NotificationService {
+	return &NotificationServiceImpl{
+		db:                     db,
+		notificationRepository: notificationRepository,
+		channels:               channels,
+		validate:               validate,
+	}
+}
+
+func (notificationService *NotificationServiceImpl) FindSettings(ctx context.Context, userId int) (response.NotificationSettingsResponse, error) {
+	settings, err := findNotificationSettings(ctx, notificationService.db, notificationService.notificationRepository, userId)
+
+	if err != nil {
+		return response.NotificationSettingsResponse{}, err
+	}
+
+	return notificationService.toNotificationSettingsResponse(settings), nil
+}
+
+func (notificationService *NotificationServiceImpl) UpdateSettings(ctx context.Context, notificationSettingsRequest request.NotificationSettingsRequest) (response.NotificationSettingsResponse, error) {
+	if err := notificationService.validate.StructCtx(ctx, notificationSettingsRequest); err != nil {
+		return response.NotificationSettingsResponse{}, err
+	}
+
+	settings := entity.NotificationSettings{
+		UserId:          notificationSettingsRequest.UserId,
+		Channels:        strings.Join(notificationSettingsRequest.Channels, " "),
+		Timezone:        notificationSettingsRequest.Timezone,
+		QuietHoursStart: notificationSettingsRequest.QuietHoursStart,
+		QuietHoursEnd:   notificationSettingsRequest.QuietHoursEnd,
+	}
+
+	if err := notificationService.notificationRepository.SaveSettings(ctx, notificationService.db, settings); err != nil {
+		return response.NotificationSettingsResponse{}, err
+	}
+
+	return notificationService.FindSettings(ctx, notificationSettingsRequest.UserId)
+}
+
+func (notificationService *NotificationServiceImpl) Subscribe(ctx context.Context, pushSubscriptionCreateRequest request.PushSubscriptionCreateRequest) (response.PushSubscriptionResponse, error) {
+	if err := notificationService.validate.StructCtx(ctx, pushSubscriptionCreateRequest); err != nil {
+		return response.PushSubscriptionResponse{}, err
+	}
+
+	if notificationService.channel(pushSubscriptionCreateRequest.Channel) == nil {
+		return response.PushSubscriptionResponse{}, helper.ErrNotificationChannelUnavailable
+	}
+
+	subscription := entity.PushSubscription{
+		UserId:   pushSubscriptionCreateRequest.UserId,
+		Channel:  pushSubscriptionCreateRequest.Channel,
+		Endpoint: pushSubscriptionCreateRequest.Endpoint,
+	}
+
+	if subscription.Channel == entity.NotificationChannelFcm {
+		subscription.Endpoint = pushSubscriptionCreateRequest.Token
+	}
+
+	if keys := pushSubscriptionCreateRequest.Keys; keys != nil && subscription.Channel == entity.NotificationChannelWebPush {
+		subscription.P256dh = keys.P256dh
+		subscription.Auth = keys.Auth
+	}
+
+	subscriptionId, errSave := notificationService.notificationRepository.SaveSubscription(ctx, notificationService.db, subscription)
+
+	if errSave != nil {
+		return response.PushSubscriptionResponse{}, errSave
+	}
+
+	savedSubscription, errGet := notificationService.notificationRepository.GetSubscription(ctx, notificationService.db, subscription.UserId, subscriptionId)
+
+	if errGet != nil {
+		return response.PushSubscriptionResponse{}, errGet
+	}
+
+	return toPushSubscriptionResponse(savedSubscription), nil
+}
+
+func (notificationService *NotificationServiceImpl) FindSubscriptions(ctx context.Context, userId int) ([]response.PushSubscriptionResponse, error) {
+	subscriptions, err := notificationService.notificationRepository.GetUserSubscriptions(ctx, notificationService.db, userId, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptionResponses := []response.PushSubscriptionResponse{}
+
+	for _, subscription := range subscriptions {
+		subscriptionResponses = append(subscriptionResponses, toPushSubscriptionResponse(subscription))
+	}
+
+	return subscriptionResponses, nil
+}
+
+func (notificationService *NotificationServiceImpl) Unsubscribe(ctx context.Context, userId int, subscriptionId int) error {
+	err := notificationService.notificationRepository.DeleteSubscription(ctx, notificationService.db, userId, subscriptionId)
+
+	if errors.Is(err, helper.ErrRowsNotAffected) {
+		return helper.ErrSubscriptionNotFound
+	}
+
+	return err
+}
+
+func (notificationService *NotificationServiceImpl) channel(name string) NotificationChannel {
+	for _, channel := range notificationService.channels {
+		if channel.Name() == name {
+			return channel
+		}
+	}
+
+	return nil
+}
+
+func (notificationService *NotificationServiceImpl) toNotificationSettingsResponse(settings entity.NotificationSettings) response.NotificationSettingsResponse {
+	settingsResponse := response.NotificationSettingsResponse{
+		Channels:          strings.Fields(settings.Channels),
+		Timezone:          settings.Timezone,
+		QuietHoursStart:   settings.QuietHoursStart,
+		QuietHoursEnd:     settings.QuietHoursEnd,
+		AvailableChannels: []string{},
+	}
+
+	for _, channel := range notificationService.channels {
+		settingsResponse.AvailableChannels = append(settingsResponse.AvailableChannels, channel.Name())
+
+		if webPushChannel, ok := channel.(*WebPushChannel); ok {
+			settingsResponse.WebPushPublicKey = webPushChannel.PublicKey()
+		}
+	}
+
+	return settingsResponse
+}
+
+// findNotificationSettings returns the defaults for users who never saved
+// their settings.
+func findNotificationSettings(ctx context.Context, db *sql.DB, notificationRepository repository.NotificationRepository, userId int) (entity.NotificationSettings, error) {
+	settings, err := notificationRepository.GetSettings(ctx, db, userId)
+
+	if errors.Is(err, helper.ErrNotFound) {
+		return entity.DefaultNotificationSettings(userId), nil
+	}
+
+	return settings, err
+}
+
+func toPushSubscriptionResponse(subscription entity.PushSubscription) response.PushSubscriptionResponse {
+	return response.PushSubscriptionResponse{
+		Id:        subscription.Id,
+		Channel:   subscription.Channel,
+		Endpoint:  subscription.Endpoint,
+		CreatedAt: subscription.CreatedAt,
+	}
+}
@@ -0,0 +1,517 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	customvalidator "go_todo_api/internal/validator"
+	"net/url"
+	"strings"
+)
+
+const (
+	oauthAccessTokenExpiresInSeconds = 3600
+	oauthRefreshTokenExpiresInDays   = 30
+)
+
+type OauthService interface {
+	RegisterClient(ctx context.Context, oauthClientCreateRequest request.OauthClientCreateRequest) (response.OauthClientCreateResponse, error)
+	FindClients(ctx context.Context) ([]response.OauthClientResponse, error)
+	DeleteClient(ctx context.Context, clientId string) error
+	GetConsent(ctx context.Context, oauthAuthorizeRequest request.OauthAuthorizeRequest) (response.OauthConsentResponse, error)
+	Authorize(ctx context.Context, oauthAuthorizeRequest request.OauthAuthorizeRequest) (response.OauthAuthorizeResponse, error)
+	Token(ctx context.Context, oauthTokenRequest request.OauthTokenRequest) (response.OauthTokenResponse, error)
+	Authenticate(ctx context.Context, token string) (helper.Principal, error)
+}
+
+type OauthServiceImpl struct {
+	db              *sql.DB
+	oauthRepository repository.OauthRepository
+	validate        customvalidator.CustomValidator
+}
+
+func NewOauthService(db *sql.DB, oauthRepository repository.OauthRepository, validate customvalidator.CustomValidator) OauthService {
+	return &OauthServiceImpl{
+		db:              db,
+		oauthRepository: oauthRepository,
+		validate:        validate,
+	}
+}
+
+func (oauthService *OauthServiceImpl) RegisterClient(ctx context.Context, oauthClientCreateRequest request.OauthClientCreateRequest) (response.OauthClientCreateResponse, error) {
+	if err := oauthService.validate.StructCtx(ctx, oauthClientCreateRequest); err != nil {
+		return response.OauthClientCreateResponse{}, err
+	}
+
+	grantTypes := oauthClientCreateRequest.GrantTypes
+
+	if helper.ScopesSubset([]string{helper.GrantTypeAuthorizationCode}, grantTypes) && len(oauthClientCreateRequest.RedirectUris) == 0 {
+		return response.OauthClientCreateResponse{}, helper.NewOauthError("invalid_client_metadata", "authorization_code clients need at least one redirect uri")
+	}
+
+	if helper.ScopesSubset([]string{helper.GrantTypeClientCredentials}, grantTypes) && !oauthClientCreateRequest.Confidential {
+		return response.OauthClientCreateResponse{}, helper.NewOauthError("invalid_client_metadata", "client_credentials is only allowed for confidential clients")
+	}
+
+	clientId, errClientId := helper.GenerateOidcState()
+
+	if errClientId != nil {
+		return response.OauthClientCreateResponse{}, errClientId
+	}
+
+	oauthClient := entity.OauthClient{
+		ClientId:     clientId,
+		Name:         oauthClientCreateRequest.Name,
+		RedirectUris: strings.Join(oauthClientCreateRequest.RedirectUris, " "),
+		Scopes:       strings.Join(oauthClientCreateRequest.Scopes, " "),
+		GrantTypes:   strings.Join(grantTypes, " "),
+	}
+
+	clientSecret := ""
+
+	if oauthClientCreateRequest.Confidential {
+		generatedSecret, errSecret := helper.GenerateOauthToken(helper.OauthClientSecretPrefix)
+
+		if errSecret != nil {
+			return response.OauthClientCreateResponse{}, errSecret
+		}
+
+		clientSecret = generatedSecret
+		oauthClient.ClientSecretHash = helper.HashToken(clientSecret)
+	}
+
+	if _, err := oauthService.oauthRepository.InsertClient(ctx, oauthService.db, oauthClient); err != nil {
+		return response.OauthClientCreateResponse{}, err
+	}
+
+	// The secret is only ever shown here, only its hash is stored.
+	oauthClientCreateResponse := response.OauthClientCreateResponse{
+		OauthClientResponse: toOauthClientResponse(oauthClient),
+		ClientSecret:        clientSecret,
+	}
+
+	return oauthClientCreateResponse, nil
+}
+
+func (oauthService *OauthServiceImpl) FindClients(ctx context.Context) ([]response.OauthClientResponse, error) {
+	oauthClients, err := oauthService.oauthRepository.GetClients(ctx, oauthService.db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	oauthClientResponses := []response.OauthClientResponse{}
+
+	for _, oauthClient := range oauthClients {
+		oauthClientResponses = append(oauthClientResponses, toOauthClientResponse(oauthClient))
+	}
+
+	return oauthClientResponses, nil
+}
+
+func (oauthService *OauthServiceImpl) DeleteClient(ctx context.Context, clientId string) error {
+	err := oauthService.oauthRepository.DeleteClient(ctx, oauthService.db, clientId)
+
+	if errors.Is(err, helper.ErrRowsNotAffected) {
+		return helper.ErrOauthClientNotFound
+	}
+
+	return err
+}
+
+// GetConsent describes what the consent screen should ask the user for.
+func (oauthService *OauthServiceImpl) GetConsent(ctx context.Context, oauthAuthorizeRequest request.OauthAuthorizeRequest) (response.OauthConsentResponse, error) {
+	oauthClient, scopes, err := oauthService.checkAuthorizeRequest(ctx, oauthAuthorizeRequest)
+
+	if err != nil {
+		return response.OauthConsentResponse{}, err
+	}
+
+	oauthConsent, errGetConsent := oauthService.oauthRepository.GetConsent(ctx, oauthService.db, oauthAuthorizeRequest.UserId, oauthClient.ClientId)
+
+	if errGetConsent != nil && !errors.Is(errGetConsent, helper.ErrNotFound) {
+		return response.OauthConsentResponse{}, errGetConsent
+	}
+
+	oauthConsentResponse := response.OauthConsentResponse{
+		ClientId:          oauthClient.ClientId,
+		ClientName:        oauthClient.Name,
+		RedirectUri:       oauthAuthorizeRequest.RedirectUri,
+		Scopes:            scopes,
+		PreviouslyGranted: errGetConsent == nil && helper.ScopesSubset(scopes, strings.Fields(oauthConsent.Scopes)),
+	}
+
+	return oauthConsentResponse, nil
+}
+
+// Authorize records the user's decision and returns where the user agent
+// should be sent next, carrying either a code or an access_denied error.
+func (oauthService *OauthServiceImpl) Authorize(ctx context.Context, oauthAuthorizeRequest request.OauthAuthorizeRequest) (response.OauthAuthorizeResponse, error) {
+	oauthClient, scopes, err := oauthService.checkAuthorizeRequest(ctx, oauthAuthorizeRequest)
+
+	if err != nil {
+		return response.OauthAuthorizeResponse{}, err
+	}
+
+	redirectParams := url.Values{}
+
+	if oauthAuthorizeRequest.State != "" {
+		redirectParams.Set("state", oauthAuthorizeRequest.State)
+	}
+
+	if !oauthAuthorizeRequest.Approve {
+		redirectParams.Set("error", "access_denied")
+
+		return response.OauthAuthorizeResponse{RedirectTo: withQuery(oauthAuthorizeRequest.RedirectUri, redirectParams)}, nil
+	}
+
+	oauthConsent := entity.OauthConsent{
+		UserId:   oauthAuthorizeRequest.UserId,
+		ClientId: oauthClient.ClientId,
+		Scopes:   strings.Join(scopes, " "),
+	}
+
+	if err := oauthService.oauthRepository.SaveConsent(ctx, oauthService.db, oauthConsent); err != nil {
+		return response.OauthAuthorizeResponse{}, err
+	}
+
+	code, errCode := helper.GenerateOidcState()
+
+	if errCode != nil {
+		return response.OauthAuthorizeResponse{}, errCode
+	}
+
+	authorizationCode := entity.OauthAuthorizationCode{
+		CodeHash:      helper.HashToken(code),
+		ClientId:      oauthClient.ClientId,
+		UserId:        oauthAuthorizeRequest.UserId,
+		RedirectUri:   oauthAuthorizeRequest.RedirectUri,
+		Scopes:        oauthConsent.Scopes,
+		CodeChallenge: oauthAuthorizeRequest.CodeChallenge,
+	}
+
+	if err := oauthService.oauthRepository.InsertAuthorizationCode(ctx, oauthService.db, authorizationCode); err != nil {
+		return response.OauthAuthorizeResponse{}, err
+	}
+
+	redirectParams.Set("code", code)
+
+	return response.OauthAuthorizeResponse{RedirectTo: withQuery(oauthAuthorizeRequest.RedirectUri, redirectParams)}, nil
+}
+
+// checkAuthorizeRequest fails without a redirect when the client or redirect
+// uri is unknown, since the redirect uri can't be trusted then.
+func (oauthService *OauthServiceImpl) checkAuthorizeRequest(ctx context.Context, oauthAuthorizeRequest request.OauthAuthorizeRequest) (entity.OauthClient, []string, error) {
+	if err := oauthService.validate.StructCtx(ctx, oauthAuthorizeRequest); err != nil {
+		return entity.OauthClient{}, nil, err
+	}
+
+	oauthClient, errGetClient := oauthService.oauthRepository.GetClient(ctx, oauthService.db, oauthAuthorizeRequest.ClientId)
+
+	if errGetClient != nil {
+		if errors.Is(errGetClient, helper.ErrNotFound) {
+			return entity.OauthClient{}, nil, helper.NewOauthError("invalid_client", "unknown client")
+		}
+		return entity.OauthClient{}, nil, errGetClient
+	}
+
+	if !helper.ScopesSubset([]string{helper.GrantTypeAuthorizationCode}, strings.Fields(oauthClient.GrantTypes)) {
+		return entity.OauthClient{}, nil, helper.NewOauthError("unauthorized_client", "client may not use the authorization code grant")
+	}
+
+	if !helper.ScopesSubset([]string{oauthAuthorizeRequest.RedirectUri}, strings.Fields(oauthClient.RedirectUris)) {
+		return entity.OauthClient{}, nil, helper.NewOauthError("invalid_request", "redirect uri is not registered for this client")
+	}
+
+	scopes, errScopes := requestedScopes(oauthAuthorizeRequest.Scope, oauthClient)
+
+	if errScopes != nil {
+		return entity.OauthClient{}, nil, errScopes
+	}
+
+	return oauthClient, scopes, nil
+}
+
+func (oauthService *OauthServiceImpl) Token(ctx context.Context, oauthTokenRequest request.OauthTokenRequest) (response.OauthTokenResponse, error) {
+	if oauthTokenRequest.GrantType == "" {
+		return response.OauthTokenResponse{}, helper.NewOauthError("invalid_request", "grant_type is required")
+	}
+
+	oauthClient, errClient := oauthService.authenticateClient(ctx, oauthTokenRequest)
+
+	if errClient != nil {
+		return response.OauthTokenResponse{}, errClient
+	}
+
+	grantTypes := strings.Fields(oauthClient.GrantTypes)
+
+	if !helper.ScopesSubset([]string{oauthTokenRequest.GrantType}, grantTypes) {
+		switch oauthTokenRequest.GrantType {
+		case helper.GrantTypeAuthorizationCode, helper.GrantTypeRefreshToken, helper.GrantTypeClientCredentials:
+			return response.OauthTokenResponse{}, helper.NewOauthError("unauthorized_client", "client may not use the "+oauthTokenRequest.GrantType+" grant")
+		default:
+			return response.OauthTokenResponse{}, helper.NewOauthError("unsupported_grant_type", "grant type "+oauthTokenRequest.GrantType+" is not supported")
+		}
+	}
+
+	withRefreshToken := helper.ScopesSubset([]string{helper.GrantTypeRefreshToken}, grantTypes)
+
+	switch oauthTokenRequest.GrantType {
+	case helper.GrantTypeAuthorizationCode:
+		return oauthService.exchangeAuthorizationCode(ctx, oauthClient, oauthTokenRequest, withRefreshToken)
+	case helper.GrantTypeRefreshToken:
+		return oauthService.exchangeRefreshToken(ctx, oauthClient, oauthTokenRequest)
+	default:
+		scopes, errScopes := requestedScopes(oauthTokenRequest.Scope, oauthClient)
+
+		if errScopes != nil {
+			return response.OauthTokenResponse{}, errScopes
+		}
+
+		// A client_credentials token acts as the client itself, not on behalf of a user.
+		return oauthService.issueTokens(ctx, nil, entity.OauthToken{ClientId: oauthClient.ClientId, Scopes: strings.Join(scopes, " ")}, false)
+	}
+}
+
+func (oauthService *OauthServiceImpl) authenticateClient(ctx context.Context, oauthTokenRequest request.OauthTokenRequest) (entity.OauthClient, error) {
+	errInvalidClient := helper.NewOauthError("invalid_client", "client authentication failed")
+
+	if oauthTokenRequest.ClientId == "" {
+		return entity.OauthClient{}, errInvalidClient
+	}
+
+	oauthClient, errGetClient := oauthService.oauthRepository.GetClient(ctx, oauthService.db, oauthTokenRequest.ClientId)
+
+	if errGetClient != nil {
+		if errors.Is(errGetClient, helper.ErrNotFound) {
+			return entity.OauthClient{}, errInvalidClient
+		}
+		return entity.OauthClient{}, errGetClient
+	}
+
+	// Public clients have no secret, they prove themselves with PKCE instead.
+	if oauthClient.ClientSecretHash == "" {
+		if oauthTokenRequest.ClientSecret != "" {
+			return entity.OauthClient{}, errInvalidClient
+		}
+
+		return oauthClient, nil
+	}
+
+	secretHash := helper.HashToken(oauthTokenRequest.ClientSecret)
+
+	if subtle.ConstantTimeCompare([]byte(secretHash), []byte(oauthClient.ClientSecretHash)) != 1 {
+		return entity.OauthClient{}, errInvalidClient
+	}
+
+	return oauthClient, nil
+}
+
+func (oauthService *OauthServiceImpl) exchangeAuthorizationCode(ctx context.Context, oauthClient entity.OauthClient, oauthTokenRequest request.OauthTokenRequest, withRefreshToken bool) (response.OauthTokenResponse, error) {
+	errInvalidGrant := helper.NewOauthError("invalid_grant", "authorization code is invalid, expired or already used")
+
+	authorizationCode, errConsume := oauthService.oauthRepository.ConsumeAuthorizationCode(ctx, oauthService.db, helper.HashToken(oauthTokenRequest.Code))
+
+	if errConsume != nil {
+		if errors.Is(errConsume, helper.ErrNotFound) {
+			return response.OauthTokenResponse{}, errInvalidGrant
+		}
+		return response.OauthTokenResponse{}, errConsume
+	}
+
+	if authorizationCode.ClientId != oauthClient.ClientId || authorizationCode.RedirectUri != oauthTokenRequest.RedirectUri {
+		return response.OauthTokenResponse{}, errInvalidGrant
+	}
+
+	challenge := helper.PkceChallenge(oauthTokenRequest.CodeVerifier)
+
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(authorizationCode.CodeChallenge)) != 1 {
+		return response.OauthTokenResponse{}, helper.NewOauthError("invalid_grant", "code verifier does not match the code challenge")
+	}
+
+	oauthToken := entity.OauthToken{
+		ClientId: oauthClient.ClientId,
+		UserId:   authorizationCode.UserId,
+		Scopes:   authorizationCode.Scopes,
+	}
+
+	return oauthService.issueTokens(ctx, nil, oauthToken, withRefreshToken)
+}
+
+// exchangeRefreshToken rotates the refresh token, the old one stops working
+// as soon as the new pair is issued.
+func (oauthService *OauthServiceImpl) exchangeRefreshToken(ctx context.Context, oauthClient entity.OauthClient, oauthTokenRequest request.OauthTokenRequest) (response.OauthTokenResponse, error) {
+	errInvalidGrant := helper.NewOauthError("invalid_grant", "refresh token is invalid, expired or revoked")
+
+	oldToken, errGetToken := oauthService.oauthRepository.GetActiveTokenByRefreshHash(ctx, oauthService.db, helper.HashToken(oauthTokenRequest.RefreshToken))
+
+	if errGetToken != nil {
+		if errors.Is(errGetToken, helper.ErrNotFound) {
+			return response.OauthTokenResponse{}, errInvalidGrant
+		}
+		return response.OauthTokenResponse{}, errGetToken
+	}
+
+	if oldToken.ClientId != oauthClient.ClientId {
+		return response.OauthTokenResponse{}, errInvalidGrant
+	}
+
+	scopes := strings.Fields(oldToken.Scopes)
+
+	// A refresh may narrow the scopes, never widen them.
+	if oauthTokenRequest.Scope != "" {
+		scopes = strings.Fields(oauthTokenRequest.Scope)
+
+		if !helper.ScopesSubset(scopes, strings.Fields(oldToken.Scopes)) {
+			return response.OauthTokenResponse{}, helper.NewOauthError("invalid_scope", "requested scope exceeds the original grant")
+		}
+	}
+
+	tx, errBegin := oauthService.db.Begin()
+
+	if errBegin != nil {
+		return response.OauthTokenResponse{}, errBegin
+	}
+
+	if err := oauthService.oauthRepository.RevokeToken(ctx, tx, oldToken.Id); err != nil {
+		tx.Rollback()
+
+		if errors.Is(err, helper.ErrRowsNotAffected) {
+			return response.OauthTokenResponse{}, errInvalidGrant
+		}
+		return response.OauthTokenResponse{}, err
+	}
+
+	newToken := entity.OauthToken{
+		ClientId: oauthClient.ClientId,
+		UserId:   oldToken.UserId,
+		Scopes:   strings.Join(scopes, " "),
+	}
+
+	return oauthService.issueTokens(ctx, tx, newToken, true)
+}
+
+// issueTokens stores and returns a new token pair, committing tx when given
+// or running in a transaction of its own otherwise.
+func (oauthService *OauthServiceImpl) issueTokens(ctx context.Context, tx *sql.Tx, oauthToken entity.OauthToken, withRefreshToken bool) (response.OauthTokenResponse, error) {
+	if tx == nil {
+		newTx, errBegin := oauthService.db.Begin()
+
+		if errBegin != nil {
+			return response.OauthTokenResponse{}, errBegin
+		}
+
+		tx = newTx
+	}
+
+	accessToken, errAccessToken := helper.GenerateOauthToken(helper.OauthAccessTokenPrefix)
+
+	if errAccessToken != nil {
+		tx.Rollback()
+		return response.OauthTokenResponse{}, errAccessToken
+	}
+
+	oauthToken.AccessTokenHash = helper.HashToken(accessToken)
+
+	oauthTokenResponse := response.OauthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   oauthAccessTokenExpiresInSeconds,
+		Scope:       oauthToken.Scopes,
+	}
+
+	if withRefreshToken {
+		refreshToken, errRefreshToken := helper.GenerateOauthToken(helper.OauthRefreshTokenPrefix)
+
+		if errRefreshToken != nil {
+			tx.Rollback()
+			return response.OauthTokenResponse{}, errRefreshToken
+		}
+
+		oauthToken.RefreshTokenHash = helper.HashToken(refreshToken)
+		oauthTokenResponse.RefreshToken = refreshToken
+	}
+
+	if err := oauthService.oauthRepository.InsertToken(ctx, tx, oauthToken, oauthAccessTokenExpiresInSeconds, oauthRefreshTokenExpiresInDays); err != nil {
+		tx.Rollback()
+		return response.OauthTokenResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return response.OauthTokenResponse{}, err
+	}
+
+	return oauthTokenResponse, nil
+}
+
+func (oauthService *OauthServiceImpl) Authenticate(ctx context.Context, token string) (helper.Principal, error) {
+	oauthToken, err := oauthService.oauthRepository.GetActiveTokenByAccessHash(ctx, oauthService.db, helper.HashToken(token))
+
+	if err != nil {
+		if errors.Is(err, helper.ErrNotFound) {
+			return helper.Principal{}, helper.ErrorTokenInvalid
+		}
+		return helper.Principal{}, err
+	}
+
+	principal := helper.Principal{
+		UserId:     oauthToken.UserId,
+		Username:   oauthToken.Username,
+		Locale:     oauthToken.Locale,
+		AuthMethod: helper.AuthMethodOauth,
+		ClientId:   oauthToken.ClientId,
+		Scopes:     strings.Fields(oauthToken.Scopes),
+	}
+
+	// Like api tokens, oauth tokens never carry the admin role.
+	if oauthToken.UserId != 0 {
+		principal.Roles = []string{helper.RoleUser}
+	}
+
+	return principal, nil
+}
+
+// requestedScopes defaults an empty scope to everything the client may ask for.
+func requestedScopes(scope string, oauthClient entity.OauthClient) ([]string, error) {
+	clientScopes := strings.Fields(oauthClient.Scopes)
+
+	if strings.TrimSpace(scope) == "" {
+		return clientScopes, nil
+	}
+
+	scopes := strings.Fields(scope)
+
+	if !helper.ScopesSubset(scopes, clientScopes) {
+		return nil, helper.NewOauthError("invalid_scope", "requested scope is not allowed for this client")
+	}
+
+	return scopes, nil
+}
+
+func withQuery(redirectUri string, params url.Values) string {
+	separator := "?"
+
+	if strings.Contains(redirectUri, "?") {
+		separator = "&"
+	}
+
+	return redirectUri + separator + params.Encode()
+}
+
+func toOauthClientResponse(oauthClient entity.OauthClient) response.OauthClientResponse {
+	return response.OauthClientResponse{
+		ClientId:     oauthClient.ClientId,
+		Name:         oauthClient.Name,
+		RedirectUris: strings.Fields(oauthClient.RedirectUris),
+		Scopes:       strings.Fields(oauthClient.Scopes),
+		GrantTypes:   strings.Fields(oauthClient.GrantTypes),
+		Confidential: oauthClient.ClientSecretHash != "",
+		CreatedAt:    oauthClient.CreatedAt,
+	}
+}
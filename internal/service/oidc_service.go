@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	customvalidator "go_todo_api/internal/validator"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+type OidcService interface {
+	StartLogin(ctx context.Context, providerName string) (string, error)
+	CompleteLogin(ctx context.Context, oidcCallbackRequest request.OidcCallbackRequest) (response.LoginResponse, error)
+}
+
+type OidcServiceImpl struct {
+	db             *sql.DB
+	userRepository repository.UserRepository
+	oidcRepository repository.OidcRepository
+	authService    AuthService
+	validate       customvalidator.CustomValidator
+	passwordHasher func(password string) (string, error)
+	providers      helper.OidcProviders
+}
+
+func NewOidcService(db *sql.DB, userRepository repository.UserRepository, oidcRepository repository.OidcRepository, authService AuthService, validate customvalidator.CustomValidator, passwordHasher func(password string) (string, error), providers helper.OidcProviders) OidcService {
+	return &OidcServiceImpl{
+		db:             db,
+		userRepository: userRepository,
+		oidcRepository: oidcRepository,
+		authService:    authService,
+		validate:       validate,
+		passwordHasher: passwordHasher,
+		providers:      providers,
+	}
+}
+
+// StartLogin stores a fresh state, nonce and PKCE verifier and returns the
+// provider URL the client should be redirected to.
+func (oidcService *OidcServiceImpl) StartLogin(ctx context.Context, providerName string) (string, error) {
+	provider, ok := oidcService.providers[providerName]
+
+	if !ok {
+		return "", helper.ErrNotFound
+	}
+
+	loginState := entity.OidcLoginState{Provider: providerName}
+
+	for _, value := range []*string{&loginState.State, &loginState.Nonce, &loginState.CodeVerifier} {
+		randomValue, err := helper.GenerateOidcState()
+
+		if err != nil {
+			return "", err
+		}
+
+		*value = randomValue
+	}
+
+	if err := oidcService.oidcRepository.InsertLoginState(ctx, oidcService.db, loginState); err != nil {
+		return "", err
+	}
+
+	return provider.AuthCodeUrl(ctx, loginState.State, loginState.Nonce, loginState.CodeVerifier)
+}
+
+func (oidcService *OidcServiceImpl) CompleteLogin(ctx context.Context, oidcCallbackRequest request.OidcCallbackRequest) (response.LoginResponse, error) {
+	if err := oidcService.validate.StructCtx(ctx, oidcCallbackRequest); err != nil {
+		return response.LoginResponse{}, err
+	}
+
+	provider, ok := oidcService.providers[oidcCallbackRequest.Provider]
+
+	if !ok {
+		return response.LoginResponse{}, helper.ErrNotFound
+	}
+
+	loginState, errConsume := oidcService.oidcRepository.ConsumeLoginState(ctx, oidcService.db, oidcCallbackRequest.State)
+
+	if errConsume != nil {
+		if errors.Is(errConsume, helper.ErrNotFound) {
+			return response.LoginResponse{}, fmt.Errorf("%w: unknown or expired state", helper.ErrOidcLoginFailed)
+		}
+		return response.LoginResponse{}, errConsume
+	}
+
+	if loginState.Provider != oidcCallbackRequest.Provider {
+		return response.LoginResponse{}, fmt.Errorf("%w: state was issued for another provider", helper.ErrOidcLoginFailed)
+	}
+
+	rawIdToken, errExchange := provider.Exchange(ctx, oidcCallbackRequest.Code, loginState.CodeVerifier)
+
+	if errExchange != nil {
+		return response.LoginResponse{}, errExchange
+	}
+
+	claims, errVerify := provider.VerifyIdToken(ctx, rawIdToken, loginState.Nonce)
+
+	if errVerify != nil {
+		return response.LoginResponse{}, errVerify
+	}
+
+	user, errResolve := oidcService.resolveUser(ctx, oidcCallbackRequest.Provider, claims)
+
+	if errResolve != nil {
+		return response.LoginResponse{}, errResolve
+	}
+
+	return oidcService.authService.LoginUser(ctx, user)
+}
+
+// resolveUser finds the user linked to the identity, links it to the user with
+// the same verified email, or creates a new user for it.
+func (oidcService *OidcServiceImpl) resolveUser(ctx context.Context, providerName string, claims helper.OidcClaims) (entity.User, error) {
+	userIdentity, errGetIdentity := oidcService.oidcRepository.GetIdentity(ctx, oidcService.db, providerName, claims.Subject)
+
+	if errGetIdentity == nil {
+		return oidcService.userRepository.Get(ctx, oidcService.db, userIdentity.UserId)
+	}
+
+	if !errors.Is(errGetIdentity, helper.ErrNotFound) {
+		return entity.User{}, errGetIdentity
+	}
+
+	if claims.Email == "" {
+		return entity.User{}, fmt.Errorf("%w: id token has no email", helper.ErrOidcLoginFailed)
+	}
+
+	claims.Email = helper.NormalizeIdentifier(claims.Email)
+
+	user, errGetUser := oidcService.userRepository.GetByEmail(ctx, oidcService.db, claims.Email)
+
+	if errGetUser != nil && !errors.Is(errGetUser, helper.ErrNotFound) {
+		return entity.User{}, errGetUser
+	}
+
+	// An unverified email could belong to anyone, so it's never used to take over an account.
+	if errGetUser == nil && !claims.EmailVerified {
+		return entity.User{}, helper.ErrOidcEmailConflict
+	}
+
+	tx, errBegin := oidcService.db.Begin()
+
+	if errBegin != nil {
+		return entity.User{}, errBegin
+	}
+
+	if errGetUser != nil {
+		user, errGetUser = oidcService.createUser(ctx, tx, claims)
+
+		if errGetUser != nil {
+			tx.Rollback()
+			return entity.User{}, errGetUser
+		}
+	}
+
+	newIdentity := entity.UserIdentity{
+		UserId:   user.Id,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+
+	if err := oidcService.oidcRepository.InsertIdentity(ctx, tx, newIdentity); err != nil {
+		tx.Rollback()
+		return entity.User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+func (oidcService *OidcServiceImpl) createUser(ctx context.Context, tx *sql.Tx, claims helper.OidcClaims) (entity.User, error) {
+	username, errUsername := oidcService.availableUsername(ctx, claims)
+
+	if errUsername != nil {
+		return entity.User{}, errUsername
+	}
+
+	// Nobody knows this password, the user signs in through the provider
+	// until an admin resets it.
+	randomPassword, errRandom := helper.GenerateOidcState()
+
+	if errRandom != nil {
+		return entity.User{}, errRandom
+	}
+
+	hashedPassword, errHashingPassword := oidcService.passwordHasher(randomPassword)
+
+	if errHashingPassword != nil {
+		return entity.User{}, errHashingPassword
+	}
+
+	name := claims.Name
+
+	if name == "" {
+		name = username
+	}
+
+	user := entity.User{
+		Username: username,
+		Password: hashedPassword,
+		Name:     name,
+		Email:    claims.Email,
+		Role:     helper.RoleUser,
+	}
+
+	userId, errInsert := oidcService.userRepository.InsertUser(ctx, tx, user)
+
+	if errInsert != nil {
+		return entity.User{}, errInsert
+	}
+
+	user.Id = userId
+
+	return user, nil
+}
+
+var usernameDisallowed = regexp.MustCompile(`[^a-z0-9._]+`)
+
+func (oidcService *OidcServiceImpl) availableUsername(ctx context.Context, claims helper.OidcClaims) (string, error) {
+	base := claims.PreferredUsername
+
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	base = usernameDisallowed.ReplaceAllString(strings.ToLower(base), "")
+
+	if len(base) > 30 {
+		base = base[:30]
+	}
+
+	if base == "" {
+		base = "user"
+	}
+
+	username := base
+
+	for attempt := 0; attempt < 5; attempt++ {
+		_, err := oidcService.userRepository.GetByUsername(ctx, oidcService.db, username)
+
+		if errors.Is(err, helper.ErrNotFound) {
+			return username, nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		suffix, errRandom := rand.Int(rand.Reader, big.NewInt(10000))
+
+		if errRandom != nil {
+			return "", errRandom
+		}
+
+		username = fmt.Sprintf("%s_%04d", base, suffix.Int64())
+	}
+
+	return "", fmt.Errorf("%w: no free username for %s", helper.ErrOidcLoginFailed, base)
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OutboxConfig decides how the relay works through the outbox. An event a
+// sink refused is tried again after InitialBackoff, doubling up to
+// MaxBackoff, for as long as it takes. Lease is how long a relay has to
+// publish an event before another one may pick it up. The feed waits up to
+// GapTimeout for an event that was numbered before the ones it read, but
+// committed after them.
+type OutboxConfig struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	Lease          time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	GapTimeout     time.Duration
+}
+
+func DefaultOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		PollInterval:   2 * time.Second,
+		BatchSize:      100,
+		Lease:          time.Minute,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+		GapTimeout:     time.Minute,
+	}
+}
+
+// OutboxSink is somewhere the relay publishes events. An event may reach a
+// sink more than once, consumers tell duplicates apart by its EventId.
+type OutboxSink interface {
+	// Name is saved with the events the sink took, it must not change.
+	Name() string
+	Publish(ctx context.Context, event entity.OutboxEvent) error
+}
+
+// Outbox records events in the transaction of the write they are about, and
+// relays them to the sinks once it committed. An event is never published
+// for a write that rolled back, nor lost for one that committed. Besides,
+// every instance feeds every event to the streams open on it, whichever
+// instance relays it.
+type Outbox interface {
+	Add(ctx context.Context, tx *sql.Tx, userId int, eventType string, data any) error
+	// Notify wakes the relay and the feed after a transaction that added
+	// events committed.
+	Notify()
+	// Run relays and feeds events until ctx is done.
+	Run(ctx context.Context)
+	Relay(ctx context.Context) error
+	Feed(ctx context.Context) error
+}
+
+type OutboxImpl struct {
+	db               *sql.DB
+	outboxRepository repository.OutboxRepository
+	eventBus         helper.EventBus
+	sinks            []OutboxSink
+	config           OutboxConfig
+	wake             chan struct{}
+	feedWake         chan struct{}
+	feedStarted      bool
+	feedLastId       int64
+	// feedGaps are the ids skipped by the feed, until when they may still
+	// show up.
+	feedGaps map[int64]time.Time
+}
+
+func NewOutbox(db *sql.DB, outboxRepository repository.OutboxRepository, eventBus helper.EventBus, sinks []OutboxSink, config OutboxConfig) Outbox {
+	return &OutboxImpl{
+		db:               db,
+		outboxRepository: outboxRepository,
+		eventBus:         eventBus,
+		sinks:            sinks,
+		config:           config,
+		wake:             make(chan struct{}, 1),
+		feedWake:         make(chan struct{}, 1),
+		feedGaps:         map[int64]time.Time{},
+	}
+}
+
+func (outbox *OutboxImpl) Add(ctx context.Context, tx *sql.Tx, userId int, eventType string, data any) error {
+	eventId, errUuid := helper.NewUuid()
+
+	if errUuid != nil {
+		return errUuid
+	}
+
+	payload, errMarshal := json.Marshal(data)
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	event := entity.OutboxEvent{
+		EventId:   eventId,
+		UserId:    userId,
+		EventType: eventType,
+		Payload:   string(payload),
+	}
+
+	return outbox.outboxRepository.Insert(ctx, tx, event)
+}
+
+func (outbox *OutboxImpl) Notify() {
+	for _, wake := range []chan struct{}{outbox.wake, outbox.feedWake} {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (outbox *OutboxImpl) Run(ctx context.Context) {
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(2)
+
+	go func() {
+		defer waitGroup.Done()
+		outbox.loop(ctx, outbox.wake, outbox.Relay)
+	}()
+
+	go func() {
+		defer waitGroup.Done()
+
+		if outbox.eventBus != nil {
+			outbox.loop(ctx, outbox.feedWake, outbox.Feed)
+		}
+	}()
+
+	waitGroup.Wait()
+}
+
+func (outbox *OutboxImpl) loop(ctx context.Context, wake <-chan struct{}, work func(ctx context.Context) error) {
+	poll := time.NewTicker(outbox.config.PollInterval)
+	defer poll.Stop()
+
+	for {
+		if err := work(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithField("error", err.Error()).Error("outbox events not loaded")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-wake:
+		}
+	}
+}
+
+// Relay publishes a batch of the pending events, in the order they were
+// written. An event a sink refused waits for its next attempt, the events
+// after it go on without it.
+func (outbox *OutboxImpl) Relay(ctx context.Context) error {
+	events, err := outbox.outboxRepository.GetPending(ctx, outbox.db, outbox.config.BatchSize)
+
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err := outbox.publish(ctx, event); err != nil && ctx.Err() == nil {
+			logrus.WithFields(logrus.Fields{
+				"event_id":   event.EventId,
+				"event_type": event.EventType,
+				"error":      err.Error(),
+			}).Error("outbox event not published")
+		}
+	}
+
+	// A full batch means more may be waiting.
+	if len(events) == outbox.config.BatchSize {
+		outbox.Notify()
+	}
+
+	return nil
+}
+
+// publish claims the event and hands it to the sinks that didn't take it
+// yet. It is marked published once all of them did.
+func (outbox *OutboxImpl) publish(ctx context.Context, event entity.OutboxEvent) error {
+	errClaim := outbox.outboxRepository.Claim(ctx, outbox.db, event.Id, outbox.config.Lease)
+
+	if errors.Is(errClaim, helper.ErrRowsNotAffected) {
+		return nil
+	}
+
+	if errClaim != nil {
+		return errClaim
+	}
+
+	var errPublish error
+
+	for _, sink := range outbox.sinks {
+		if containsField(event.PublishedTo, sink.Name()) {
+			continue
+		}
+
+		if err := sink.Publish(ctx, event); err != nil {
+			errPublish = errors.Join(errPublish, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+
+		event.PublishedTo = strings.TrimSpace(event.PublishedTo + " " + sink.Name())
+	}
+
+	// Cut short by a shutdown, the claim runs out and the event is published
+	// again.
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if errPublish == nil {
+		return outbox.outboxRepository.MarkPublished(ctx, outbox.db, event.Id)
+	}
+
+	event.Attempts++
+	event.LastError = truncate(errPublish.Error(), 255)
+
+	if err := outbox.outboxRepository.RecordFailure(ctx, outbox.db, event, exponentialBackoff(outbox.config.InitialBackoff, outbox.config.MaxBackoff, event.Attempts)); err != nil {
+		return err
+	}
+
+	return errPublish
+}
+
+// Feed hands the events written since its last call to the event bus, the
+// first call only finds where to start. Ids are taken when an event is
+// written but show up when it commits, so an id skipped over is looked for
+// again until GapTimeout, it may belong to a transaction still running. Most
+// belong to ones that rolled back.
+func (outbox *OutboxImpl) Feed(ctx context.Context) error {
+	if !outbox.feedStarted {
+		lastId, err := outbox.outboxRepository.GetLastId(ctx, outbox.db)
+
+		if err != nil {
+			return err
+		}
+
+		outbox.feedLastId = lastId
+		outbox.feedStarted = true
+
+		return nil
+	}
+
+	now := time.Now()
+	gapIds := []int64{}
+
+	for eventId, until := range outbox.feedGaps {
+		if now.After(until) {
+			delete(outbox.feedGaps, eventId)
+			continue
+		}
+
+		gapIds = append(gapIds, eventId)
+	}
+
+	lateEvents, errLate := outbox.outboxRepository.GetByIds(ctx, outbox.db, gapIds)
+
+	if errLate != nil {
+		return errLate
+	}
+
+	for _, event := range lateEvents {
+		delete(outbox.feedGaps, event.Id)
+		outbox.feedEvent(event)
+	}
+
+	events, err := outbox.outboxRepository.GetAfter(ctx, outbox.db, outbox.feedLastId, outbox.config.BatchSize)
+
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		// A jump far ahead is a gap in the numbering, not transactions.
+		if event.Id-outbox.feedLastId <= int64(outbox.config.BatchSize) {
+			for eventId := outbox.feedLastId + 1; eventId < event.Id; eventId++ {
+				outbox.feedGaps[eventId] = now.Add(outbox.config.GapTimeout)
+			}
+		}
+
+		outbox.feedLastId = event.Id
+		outbox.feedEvent(event)
+	}
+
+	if len(events) == outbox.config.BatchSize {
+		select {
+		case outbox.feedWake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// feedEvent decodes the data back into the todo responses it was made from.
+func (outbox *OutboxImpl) feedEvent(event entity.OutboxEvent) {
+	var data any
+	var err error
+
+	if event.EventType == helper.EventTodoDeleted {
+		todoDeletedResponse := response.TodoDeletedResponse{}
+		err = json.Unmarshal([]byte(event.Payload), &todoDeletedResponse)
+		data = todoDeletedResponse
+	} else {
+		todoResponse := response.TodoResponse{}
+		err = json.Unmarshal([]byte(event.Payload), &todoResponse)
+		data = todoResponse
+	}
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"event_id": event.EventId, "error": err.Error()}).Error("outbox event not fed")
+		return
+	}
+
+	outbox.eventBus.Publish(event.Id, event.UserId, event.EventType, data)
+}
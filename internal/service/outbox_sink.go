@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"strconv"
+)
+
+// RedisStreamSink appends events to a Redis stream, for consumers outside
+// this service. The stream keeps about its newest maxLength entries.
+type RedisStreamSink struct {
+	client    *helper.RedisClient
+	stream    string
+	maxLength int
+}
+
+func NewRedisStreamSink(client *helper.RedisClient, stream string) *RedisStreamSink {
+	return &RedisStreamSink{
+		client:    client,
+		stream:    stream,
+		maxLength: 100000,
+	}
+}
+
+func (sink *RedisStreamSink) Name() string {
+	return "redis"
+}
+
+func (sink *RedisStreamSink) Publish(ctx context.Context, event entity.OutboxEvent) error {
+	_, err := sink.client.Do(ctx, "XADD", sink.stream, "MAXLEN", "~", strconv.Itoa(sink.maxLength), "*",
+		"event_id", event.EventId,
+		"event_type", event.EventType,
+		"user_id", strconv.Itoa(event.UserId),
+		"created_at", event.CreatedAt,
+		"payload", event.Payload,
+	)
+
+	return err
+}
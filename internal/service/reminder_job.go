@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ReminderScanJobType = "reminder_scan"
+	ReminderJobType     = "reminder"
+)
+
+// ReminderConfig decides which reminders are still sent. A todo whose
+// reminder time passed more than Window ago, say while the jobs weren't
+// running, is not reminded of any more. Due reminders are queued BatchSize
+// at a time.
+type ReminderConfig struct {
+	Window    time.Duration
+	BatchSize int
+}
+
+func DefaultReminderConfig() ReminderConfig {
+	return ReminderConfig{
+		Window:    24 * time.Hour,
+		BatchSize: 500,
+	}
+}
+
+type reminderPayload struct {
+	ReminderId int64 `json:"reminder_id"`
+}
+
+// ReminderScanJob queues a reminder job for each todo whose reminder time
+// came. The reminder row is unique for the todo and time and is added in the
+// same transaction as its job, so however many scans run at once each
+// reminder is queued once. Reminders that come during the user's quiet hours
+// are queued to run when they end.
+type ReminderScanJob struct {
+	db                     *sql.DB
+	reminderRepository     repository.ReminderRepository
+	notificationRepository repository.NotificationRepository
+	jobRepository          repository.JobRepository
+	config                 ReminderConfig
+}
+
+func NewReminderScanJob(db *sql.DB, reminderRepository repository.ReminderRepository, notificationRepository repository.NotificationRepository, jobRepository repository.JobRepository, config ReminderConfig) *ReminderScanJob {
+	return &ReminderScanJob{
+		db:                     db,
+		reminderRepository:     reminderRepository,
+		notificationRepository: notificationRepository,
+		jobRepository:          jobRepository,
+		config:                 config,
+	}
+}
+
+func (scanJob *ReminderScanJob) Type() string {
+	return ReminderScanJobType
+}
+
+func (scanJob *ReminderScanJob) Timeout() time.Duration {
+	return 5 * time.Minute
+}
+
+func (scanJob *ReminderScanJob) Handle(ctx context.Context, job entity.Job) error {
+	settings := map[int]entity.NotificationSettings{}
+
+	for {
+		reminders, err := scanJob.reminderRepository.GetDue(ctx, scanJob.db, scanJob.config.Window, scanJob.config.BatchSize)
+
+		if err != nil {
+			return err
+		}
+
+		for _, reminder := range reminders {
+			userSettings, ok := settings[reminder.UserId]
+
+			if !ok {
+				userSettings, err = findNotificationSettings(ctx, scanJob.db, scanJob.notificationRepository, reminder.UserId)
+
+				if err != nil {
+					return err
+				}
+
+				settings[reminder.UserId] = userSettings
+			}
+
+			delay := helper.QuietHoursDelay(userSettings.QuietHoursStart, userSettings.QuietHoursEnd, userSettings.Timezone, time.Now())
+
+			// Jobs are delayed by whole seconds, rounded down it would run
+			// just before quiet hours end.
+			errQueue := scanJob.queue(ctx, reminder, (delay + time.Second - 1).Truncate(time.Second))
+
+			// Another scan got to it first.
+			if errQueue != nil && !errors.Is(errQueue, helper.ErrConflict) {
+				return errQueue
+			}
+		}
+
+		if len(reminders) < scanJob.config.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (scanJob *ReminderScanJob) queue(ctx context.Context, reminder entity.Reminder, delay time.Duration) error {
+	tx, errTxBegin := scanJob.db.BeginTx(ctx, nil)
+
+	if errTxBegin != nil {
+		return errTxBegin
+	}
+
+	reminderId, errInsert := scanJob.reminderRepository.Insert(ctx, tx, reminder)
+
+	if errInsert != nil {
+		tx.Rollback()
+		return errInsert
+	}
+
+	payload, errMarshal := json.Marshal(reminderPayload{ReminderId: reminderId})
+
+	if errMarshal != nil {
+		tx.Rollback()
+		return errMarshal
+	}
+
+	job := entity.Job{
+		Type:      ReminderJobType,
+		Payload:   string(payload),
+		UniqueKey: "reminder:" + strconv.FormatInt(reminderId, 10),
+	}
+
+	if err := scanJob.jobRepository.InsertInTx(ctx, tx, job, delay); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReminderJob sends one reminder on each channel the user has switched on.
+// Each target it reached is saved on the reminder right after, so a retry
+// after some failed only sends to the rest. Delivery is at least once, a run
+// cut short between a send and its save sends to that target again.
+type ReminderJob struct {
+	db                     *sql.DB
+	reminderRepository     repository.ReminderRepository
+	todoRepository         repository.TodoRepository
+	userRepository         repository.UserRepository
+	notificationRepository repository.NotificationRepository
+	channels               []NotificationChannel
+}
+
+func NewReminderJob(db *sql.DB, reminderRepository repository.ReminderRepository, todoRepository repository.TodoRepository, userRepository repository.UserRepository, notificationRepository repository.NotificationRepository, channels []NotificationChannel) *ReminderJob {
+	return &ReminderJob{
+		db:                     db,
+		reminderRepository:     reminderRepository,
+		todoRepository:         todoRepository,
+		userRepository:         userRepository,
+		notificationRepository: notificationRepository,
+		channels:               channels,
+	}
+}
+
+func (reminderJob *ReminderJob) Type() string {
+	return ReminderJobType
+}
+
+func (reminderJob *ReminderJob) Timeout() time.Duration {
+	return time.Minute
+}
+
+func (reminderJob *ReminderJob) Handle(ctx context.Context, job entity.Job) error {
+	payload := reminderPayload{}
+
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return err
+	}
+
+	reminder, err := reminderJob.reminderRepository.Get(ctx, reminderJob.db, payload.ReminderId)
+
+	// Its todo was deleted.
+	if errors.Is(err, helper.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if reminder.Status != entity.ReminderPending {
+		return nil
+	}
+
+	todo, errTodo := reminderJob.todoRepository.Get(ctx, reminderJob.db, reminder.TodoId)
+
+	if errTodo != nil && !errors.Is(errTodo, helper.ErrNotFound) {
+		return errTodo
+	}
+
+	// The todo was done or moved to another time while the reminder waited.
+	if errTodo != nil || todo.IsDone || todo.RemindAt != reminder.RemindAt {
+		return reminderJob.finish(ctx, reminder, entity.ReminderSkipped)
+	}
+
+	user, errUser := reminderJob.userRepository.Get(ctx, reminderJob.db, reminder.UserId)
+
+	if errUser != nil && !errors.Is(errUser, helper.ErrNotFound) {
+		return errUser
+	}
+
+	if errUser != nil || user.IsDisabled {
+		return reminderJob.finish(ctx, reminder, entity.ReminderSkipped)
+	}
+
+	settings, errSettings := findNotificationSettings(ctx, reminderJob.db, reminderJob.notificationRepository, user.Id)
+
+	if errSettings != nil {
+		return errSettings
+	}
+
+	notification := toReminderNotification(reminder, todo)
+	failures := []error{}
+
+	for _, channel := range reminderJob.channels {
+		if !containsField(settings.Channels, channel.Name()) {
+			continue
+		}
+
+		targets, errTargets := channel.Targets(ctx, user)
+
+		if errTargets != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", channel.Name(), errTargets))
+			continue
+		}
+
+		for _, target := range targets {
+			if containsField(reminder.SentTo, target.Key) {
+				continue
+			}
+
+			if err := channel.Send(ctx, target, notification); err != nil {
+				failures = append(failures, fmt.Errorf("%s: %w", target.Key, err))
+				continue
+			}
+
+			if err := reminderJob.reminderRepository.AddSentTo(ctx, reminderJob.db, reminder.Id, target.Key); err != nil {
+				return err
+			}
+
+			reminder.SentTo = strings.TrimSpace(reminder.SentTo + " " + target.Key)
+		}
+	}
+
+	if len(failures) > 0 {
+		errSend := errors.Join(failures...)
+		reminder.LastError = truncate(errSend.Error(), 255)
+
+		if err := reminderJob.reminderRepository.Update(ctx, reminderJob.db, reminder); err != nil {
+			return err
+		}
+
+		return errSend
+	}
+
+	reminder.LastError = ""
+
+	return reminderJob.finish(ctx, reminder, entity.ReminderSent)
+}
+
+func (reminderJob *ReminderJob) finish(ctx context.Context, reminder entity.Reminder, status string) error {
+	reminder.Status = status
+
+	return reminderJob.reminderRepository.Update(ctx, reminderJob.db, reminder)
+}
+
+func toReminderNotification(reminder entity.Reminder, todo entity.Todo) Notification {
+	body := todo.Description
+
+	if body == "" {
+		body = todo.Title
+	}
+
+	return Notification{
+		Id:    "reminder-" + strconv.FormatInt(reminder.Id, 10),
+		Title: "Reminder: " + truncate(todo.Title, 200),
+		Body:  truncate(body, 1000),
+		Data: map[string]string{
+			"todo_id":   strconv.Itoa(todo.Id),
+			"todo_uuid": todo.Uuid,
+			"remind_at": helper.FormatTimestamp(reminder.RemindAt),
+		},
+	}
+}
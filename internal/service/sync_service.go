@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	customvalidator "go_todo_api/internal/validator"
+	"strings"
+)
+
+const syncPageSize = 500
+
+type SyncService interface {
+	Pull(ctx context.Context, userId int, since string) (response.SyncPullResponse, error)
+	Push(ctx context.Context, userId int, pushRequest request.SyncPushRequest) (response.SyncPushResponse, error)
+}
+
+type SyncServiceImpl struct {
+	db             *sql.DB
+	todoRepository repository.TodoRepository
+	syncRepository repository.SyncRepository
+	outbox         Outbox
+	validate       customvalidator.CustomValidator
+}
+
+func NewSyncService(db *sql.DB, todoRepository repository.TodoRepository, syncRepository repository.SyncRepository, outbox Outbox, validate customvalidator.CustomValidator) SyncService {
+	return &SyncServiceImpl{
+		db:             db,
+		todoRepository: todoRepository,
+		syncRepository: syncRepository,
+		outbox:         outbox,
+		validate:       validate,
+	}
+}
+
+// Pull returns what changed after the token, oldest change first. A page
+// never splits a change sequence number, so the next token picks up exactly
+// where the page ended.
+func (syncService *SyncServiceImpl) Pull(ctx context.Context, userId int, since string) (response.SyncPullResponse, error) {
+	sinceSeq, errToken := helper.DecodeSyncToken(since)
+
+	if errToken != nil {
+		return response.SyncPullResponse{}, errToken
+	}
+
+	todos, errTodos := syncService.todoRepository.GetChangedSince(ctx, syncService.db, userId, sinceSeq, syncPageSize+1)
+
+	if errTodos != nil {
+		return response.SyncPullResponse{}, errTodos
+	}
+
+	// A first sync has nothing to forget.
+	tombstones := []entity.TodoTombstone{}
+
+	if sinceSeq > 0 {
+		var errTombstones error
+
+		tombstones, errTombstones = syncService.syncRepository.GetTombstonesSince(ctx, syncService.db, userId, sinceSeq, syncPageSize+1)
+
+		if errTombstones != nil {
+			return response.SyncPullResponse{}, errTombstones
+		}
+	}
+
+	pullResponse := response.SyncPullResponse{
+		Todos:   []response.SyncTodoResponse{},
+		Deleted: []string{},
+	}
+
+	lastSeq := sinceSeq
+	todoIndex, tombstoneIndex := 0, 0
+
+	for taken := 0; taken < syncPageSize; taken++ {
+		if todoIndex < len(todos) && (tombstoneIndex >= len(tombstones) || todos[todoIndex].ChangeSeq < tombstones[tombstoneIndex].ChangeSeq) {
+			pullResponse.Todos = append(pullResponse.Todos, toSyncTodoResponse(todos[todoIndex]))
+			lastSeq = todos[todoIndex].ChangeSeq
+			todoIndex++
+		} else if tombstoneIndex < len(tombstones) {
+			pullResponse.Deleted = append(pullResponse.Deleted, tombstones[tombstoneIndex].Uuid)
+			lastSeq = tombstones[tombstoneIndex].ChangeSeq
+			tombstoneIndex++
+		} else {
+			break
+		}
+	}
+
+	pullResponse.NextToken = helper.EncodeSyncToken(lastSeq)
+	pullResponse.HasMore = todoIndex < len(todos) || tombstoneIndex < len(tombstones)
+
+	return pullResponse, nil
+}
+
+// Push applies the mutations one by one, each in its own transaction. A
+// mutation based on a version the server no longer has is not applied, its
+// result carries the server's todo so the client can merge and retry.
+func (syncService *SyncServiceImpl) Push(ctx context.Context, userId int, pushRequest request.SyncPushRequest) (response.SyncPushResponse, error) {
+	errValidation := syncService.validate.StructCtx(ctx, pushRequest)
+
+	if errValidation != nil {
+		return response.SyncPushResponse{}, errValidation
+	}
+
+	pushResponse := response.SyncPushResponse{
+		Results: []response.SyncMutationResponse{},
+	}
+
+	for _, mutation := range pushRequest.Mutations {
+		var result response.SyncMutationResponse
+		var err error
+
+		mutation.Id = strings.ToLower(mutation.Id)
+
+		if mutation.Op == request.SyncOpDelete {
+			result, err = syncService.delete(ctx, userId, mutation)
+		} else {
+			result, err = syncService.upsert(ctx, userId, mutation)
+		}
+
+		if err != nil {
+			return response.SyncPushResponse{}, err
+		}
+
+		pushResponse.Results = append(pushResponse.Results, result)
+	}
+
+	return pushResponse, nil
+}
+
+func (syncService *SyncServiceImpl) upsert(ctx context.Context, userId int, mutation request.SyncMutationRequest) (response.SyncMutationResponse, error) {
+	todo, found, errGet := syncService.getTodo(ctx, userId, mutation.Id)
+
+	if errGet != nil {
+		return response.SyncMutationResponse{}, errGet
+	}
+
+	if !found {
+		// The client edited a todo that was deleted meanwhile.
+		if mutation.BaseVersion != 0 {
+			return syncConflict(mutation.Id, nil), nil
+		}
+
+		newTodo := entity.Todo{
+			Uuid:        mutation.Id,
+			UserId:      userId,
+			Title:       mutation.Title,
+			Description: mutation.Description,
+			IsDone:      mutation.IsDone,
+			RemindAt:    helper.ParseTimestamp(mutation.RemindAt),
+		}
+
+		err := writeTodoChange(ctx, syncService.db, syncService.syncRepository, syncService.outbox, userId, func(tx *sql.Tx, changeSeq int64) error {
+			newTodo.ChangeSeq = changeSeq
+
+			todoId, err := syncService.todoRepository.Insert(ctx, tx, newTodo)
+
+			if err != nil {
+				return err
+			}
+
+			if err := syncService.syncRepository.DeleteTombstone(ctx, tx, userId, mutation.Id); err != nil {
+				return err
+			}
+
+			_, errEvent := addTodoEvent(ctx, tx, syncService.todoRepository, syncService.outbox, helper.EventTodoCreated, todoId)
+
+			return errEvent
+		})
+
+		if errors.Is(err, helper.ErrConflict) {
+			return syncService.conflict(ctx, userId, mutation.Id)
+		}
+
+		if err != nil {
+			return response.SyncMutationResponse{}, err
+		}
+
+		return syncService.applied(ctx, userId, mutation.Id)
+	}
+
+	// A create sent twice, because the first response never arrived, is not a
+	// conflict.
+	if mutation.BaseVersion == 0 {
+		if todo.Title == mutation.Title && todo.Description == mutation.Description && todo.IsDone == mutation.IsDone && todo.RemindAt == helper.ParseTimestamp(mutation.RemindAt) {
+			return syncApplied(todo), nil
+		}
+		return syncConflict(mutation.Id, &todo), nil
+	}
+
+	if mutation.BaseVersion != todo.Version {
+		return syncConflict(mutation.Id, &todo), nil
+	}
+
+	todoUpdateRequest := request.TodoUpdateRequest{
+		Id:          todo.Id,
+		Title:       mutation.Title,
+		Description: mutation.Description,
+		IsDone:      mutation.IsDone,
+		RemindAt:    mutation.RemindAt,
+		Version:     mutation.BaseVersion,
+	}
+
+	err := writeTodoChange(ctx, syncService.db, syncService.syncRepository, syncService.outbox, userId, func(tx *sql.Tx, changeSeq int64) error {
+		if err := syncService.todoRepository.Update(ctx, tx, todoUpdateRequest, changeSeq); err != nil {
+			return err
+		}
+
+		_, errEvent := addTodoEvent(ctx, tx, syncService.todoRepository, syncService.outbox, helper.EventTodoUpdated, todo.Id)
+
+		return errEvent
+	})
+
+	if errors.Is(err, helper.ErrRowsNotAffected) {
+		return syncService.conflict(ctx, userId, mutation.Id)
+	}
+
+	if err != nil {
+		return response.SyncMutationResponse{}, err
+	}
+
+	return syncService.applied(ctx, userId, mutation.Id)
+}
+
+func (syncService *SyncServiceImpl) delete(ctx context.Context, userId int, mutation request.SyncMutationRequest) (response.SyncMutationResponse, error) {
+	todo, found, errGet := syncService.getTodo(ctx, userId, mutation.Id)
+
+	if errGet != nil {
+		return response.SyncMutationResponse{}, errGet
+	}
+
+	if !found {
+		return response.SyncMutationResponse{Id: mutation.Id, Status: response.SyncStatusApplied}, nil
+	}
+
+	err := writeTodoChange(ctx, syncService.db, syncService.syncRepository, syncService.outbox, userId, func(tx *sql.Tx, changeSeq int64) error {
+		if err := syncService.todoRepository.Delete(ctx, tx, todo.Id, mutation.BaseVersion); err != nil {
+			return err
+		}
+
+		tombstone := entity.TodoTombstone{
+			UserId:    userId,
+			Uuid:      todo.Uuid,
+			ChangeSeq: changeSeq,
+		}
+
+		if err := syncService.syncRepository.InsertTombstone(ctx, tx, tombstone); err != nil {
+			return err
+		}
+
+		return syncService.outbox.Add(ctx, tx, userId, helper.EventTodoDeleted, response.TodoDeletedResponse{Id: todo.Id, Uuid: todo.Uuid})
+	})
+
+	if errors.Is(err, helper.ErrRowsNotAffected) {
+		return syncService.conflict(ctx, userId, mutation.Id)
+	}
+
+	if err != nil {
+		return response.SyncMutationResponse{}, err
+	}
+
+	return response.SyncMutationResponse{Id: mutation.Id, Status: response.SyncStatusApplied}, nil
+}
+
+func (syncService *SyncServiceImpl) getTodo(ctx context.Context, userId int, uuid string) (entity.Todo, bool, error) {
+	todo, err := syncService.todoRepository.GetByUuid(ctx, syncService.db, userId, uuid)
+
+	if errors.Is(err, helper.ErrNotFound) {
+		return entity.Todo{}, false, nil
+	}
+
+	if err != nil {
+		return entity.Todo{}, false, err
+	}
+
+	return todo, true, nil
+}
+
+// applied reads back the todo a mutation wrote.
+func (syncService *SyncServiceImpl) applied(ctx context.Context, userId int, uuid string) (response.SyncMutationResponse, error) {
+	todo, found, err := syncService.getTodo(ctx, userId, uuid)
+
+	if err != nil {
+		return response.SyncMutationResponse{}, err
+	}
+
+	if !found {
+		return response.SyncMutationResponse{Id: uuid, Status: response.SyncStatusApplied}, nil
+	}
+
+	return syncApplied(todo), nil
+}
+
+// conflict reads the todo again after a write lost a race, it may have been
+// deleted or taken by another user in the meantime.
+func (syncService *SyncServiceImpl) conflict(ctx context.Context, userId int, uuid string) (response.SyncMutationResponse, error) {
+	todo, found, err := syncService.getTodo(ctx, userId, uuid)
+
+	if err != nil {
+		return response.SyncMutationResponse{}, err
+	}
+
+	if !found {
+		return syncConflict(uuid, nil), nil
+	}
+
+	return syncConflict(uuid, &todo), nil
+}
+
+func syncApplied(todo entity.Todo) response.SyncMutationResponse {
+	todoResponse := toSyncTodoResponse(todo)
+
+	return response.SyncMutationResponse{Id: todo.Uuid, Status: response.SyncStatusApplied, Todo: &todoResponse}
+}
+
+func syncConflict(uuid string, todo *entity.Todo) response.SyncMutationResponse {
+	result := response.SyncMutationResponse{Id: uuid, Status: response.SyncStatusConflict}
+
+	if todo != nil {
+		todoResponse := toSyncTodoResponse(*todo)
+		result.Todo = &todoResponse
+	}
+
+	return result
+}
+
+func toSyncTodoResponse(todo entity.Todo) response.SyncTodoResponse {
+	return response.SyncTodoResponse{
+		Id:          todo.Uuid,
+		Title:       todo.Title,
+		Description: todo.Description,
+		IsDone:      todo.IsDone,
+		RemindAt:    helper.FormatTimestamp(todo.RemindAt),
+		Version:     todo.Version,
+		CreatedAt:   todo.CreatedAt,
+		UpdatedAt:   todo.UpdatedAt,
+	}
+}
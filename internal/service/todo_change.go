@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+)
+
+// writeTodoChange runs a todo write in a transaction together with taking
+// the user's next change sequence number, which the write stamps on the rows
+// it touches. The events the write adds to the outbox commit with it.
+func writeTodoChange(ctx context.Context, db *sql.DB, syncRepository repository.SyncRepository, outbox Outbox, userId int, write func(tx *sql.Tx, changeSeq int64) error) error {
+	tx, errTxBegin := db.BeginTx(ctx, nil)
+
+	if errTxBegin != nil {
+		return errTxBegin
+	}
+
+	changeSeq, errChangeSeq := syncRepository.NextChangeSeq(ctx, tx, userId)
+
+	if errChangeSeq != nil {
+		tx.Rollback()
+		return errChangeSeq
+	}
+
+	if err := write(tx, changeSeq); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	outbox.Notify()
+
+	return nil
+}
+
+// addTodoEvent adds an event with the todo as the write in tx left it, and
+// returns the todo.
+func addTodoEvent(ctx context.Context, tx *sql.Tx, todoRepository repository.TodoRepository, outbox Outbox, eventType string, todoId int) (response.TodoResponse, error) {
+	todo, err := todoRepository.GetInTx(ctx, tx, todoId)
+
+	if err != nil {
+		return response.TodoResponse{}, err
+	}
+
+	todoResponse := toTodoResponse(todo)
+
+	return todoResponse, outbox.Add(ctx, tx, todo.UserId, eventType, todoResponse)
+}
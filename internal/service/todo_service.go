@@ -3,31 +3,39 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/model/response"
 	"go_todo_api/internal/repository"
 	customvalidator "go_todo_api/internal/validator"
+	"strings"
 )
 
 type TodoService interface {
 	Find(ctx context.Context, todoId int) (response.TodoResponse, error)
 	FindUserTodos(ctx context.Context, userId int) ([]response.TodoResponse, error)
-	Create(ctx context.Context, todo request.TodoCreateRequest) error
-	Update(ctx context.Context, todo request.TodoUpdateRequest) error
-	UpdateTodoCompletion(ctx context.Context, todoId int) error
-	Remove(ctx context.Context, todoId int) error
+	Create(ctx context.Context, todo request.TodoCreateRequest) (response.TodoResponse, error)
+	Update(ctx context.Context, todo request.TodoUpdateRequest) (response.TodoResponse, error)
+	UpdateTodoCompletion(ctx context.Context, todoId int, version int) (response.TodoResponse, error)
+	Remove(ctx context.Context, todoId int, version int) error
 }
 
 type TodoServiceImpl struct {
 	db             *sql.DB
 	todoRepository repository.TodoRepository
+	syncRepository repository.SyncRepository
+	outbox         Outbox
 	validate       customvalidator.CustomValidator
 }
 
-func NewTodoService(db *sql.DB, todoRepository repository.TodoRepository, validate customvalidator.CustomValidator) TodoService {
+func NewTodoService(db *sql.DB, todoRepository repository.TodoRepository, syncRepository repository.SyncRepository, outbox Outbox, validate customvalidator.CustomValidator) TodoService {
 	return &TodoServiceImpl{
 		db:             db,
 		todoRepository: todoRepository,
+		syncRepository: syncRepository,
+		outbox:         outbox,
 		validate:       validate,
 	}
 }
@@ -36,21 +44,13 @@ func (todoService *TodoServiceImpl) Find(ctx context.Context, todoId int) (respo
 	todo, err := todoService.todoRepository.Get(ctx, todoService.db, todoId)
 
 	if err != nil {
+		if errors.Is(err, helper.ErrNotFound) {
+			return response.TodoResponse{}, helper.ErrTodoNotFound
+		}
 		return response.TodoResponse{}, err
 	}
 
-	todoResponse := response.TodoResponse{
-		Id:          todo.Id,
-		UserId:      todo.UserId,
-		Title:       todo.Title,
-		Description: todo.Description,
-		IsDone:      todo.IsDone,
-		CreatedAt:   todo.CreatedAt,
-		UpdatedAt:   todo.UpdatedAt,
-	}
-
-	return todoResponse, nil
-
+	return toTodoResponse(todo), nil
 }
 
 func (todoService *TodoServiceImpl) FindUserTodos(ctx context.Context, userId int) ([]response.TodoResponse, error) {
@@ -63,70 +63,203 @@ func (todoService *TodoServiceImpl) FindUserTodos(ctx context.Context, userId in
 	todoResponses := []response.TodoResponse{}
 
 	for _, todo := range todos {
-		todoResponse := response.TodoResponse{
-			Id:          todo.Id,
-			UserId:      todo.UserId,
-			Title:       todo.Title,
-			Description: todo.Description,
-			IsDone:      todo.IsDone,
-			CreatedAt:   todo.CreatedAt,
-			UpdatedAt:   todo.UpdatedAt,
-		}
-
-		todoResponses = append(todoResponses, todoResponse)
+		todoResponses = append(todoResponses, toTodoResponse(todo))
 	}
 
 	return todoResponses, nil
 }
 
-func (todoService *TodoServiceImpl) Create(ctx context.Context, todo request.TodoCreateRequest) error {
+// Create keeps the id a client picked for the todo, or gives it a new one.
+func (todoService *TodoServiceImpl) Create(ctx context.Context, todo request.TodoCreateRequest) (response.TodoResponse, error) {
 	errValidation := todoService.validate.StructCtx(ctx, todo)
 
 	if errValidation != nil {
-		return errValidation
+		return response.TodoResponse{}, errValidation
 	}
 
-	err := todoService.todoRepository.Insert(ctx, todoService.db, todo)
+	uuid := strings.ToLower(todo.Id)
+
+	if uuid == "" {
+		newUuid, errUuid := helper.NewUuid()
+
+		if errUuid != nil {
+			return response.TodoResponse{}, errUuid
+		}
+
+		uuid = newUuid
+	}
+
+	newTodo := entity.Todo{
+		Uuid:        uuid,
+		UserId:      todo.UserId,
+		Title:       todo.Title,
+		Description: todo.Description,
+		RemindAt:    helper.ParseTimestamp(todo.RemindAt),
+	}
+
+	todoId := 0
+
+	err := writeTodoChange(ctx, todoService.db, todoService.syncRepository, todoService.outbox, todo.UserId, func(tx *sql.Tx, changeSeq int64) error {
+		newTodo.ChangeSeq = changeSeq
+
+		var errInsert error
+		todoId, errInsert = todoService.todoRepository.Insert(ctx, tx, newTodo)
+
+		if errInsert != nil {
+			return errInsert
+		}
+
+		_, errEvent := addTodoEvent(ctx, tx, todoService.todoRepository, todoService.outbox, helper.EventTodoCreated, todoId)
+
+		return errEvent
+	})
 
 	if err != nil {
-		return err
+		// The uuid is the only unique column a client picks.
+		if errors.Is(err, helper.ErrConflict) {
+			return response.TodoResponse{}, helper.NewConflictError("id")
+		}
+		return response.TodoResponse{}, err
 	}
 
-	return nil
+	return todoService.Find(ctx, todoId)
 }
 
-func (todoService *TodoServiceImpl) Update(ctx context.Context, todo request.TodoUpdateRequest) error {
+func (todoService *TodoServiceImpl) Update(ctx context.Context, todo request.TodoUpdateRequest) (response.TodoResponse, error) {
 	errValidation := todoService.validate.StructCtx(ctx, todo)
 
 	if errValidation != nil {
-		return errValidation
+		return response.TodoResponse{}, errValidation
+	}
+
+	currentTodo, errGet := todoService.get(ctx, todo.Id)
+
+	if errGet != nil {
+		return response.TodoResponse{}, errGet
 	}
 
-	err := todoService.todoRepository.Update(ctx, todoService.db, todo)
+	todoResponse := response.TodoResponse{}
+
+	err := writeTodoChange(ctx, todoService.db, todoService.syncRepository, todoService.outbox, currentTodo.UserId, func(tx *sql.Tx, changeSeq int64) error {
+		if err := todoService.todoRepository.Update(ctx, tx, todo, changeSeq); err != nil {
+			return err
+		}
+
+		var errEvent error
+		todoResponse, errEvent = addTodoEvent(ctx, tx, todoService.todoRepository, todoService.outbox, helper.EventTodoUpdated, todo.Id)
+
+		return errEvent
+	})
 
 	if err != nil {
-		return err
+		return response.TodoResponse{}, todoService.writeError(ctx, todo.Id, err)
 	}
 
-	return nil
+	return todoResponse, nil
 }
 
-func (todoService *TodoServiceImpl) UpdateTodoCompletion(ctx context.Context, todoId int) error {
-	err := todoService.todoRepository.UpdateTodoCompletion(ctx, todoService.db, todoId)
+func (todoService *TodoServiceImpl) UpdateTodoCompletion(ctx context.Context, todoId int, version int) (response.TodoResponse, error) {
+	currentTodo, errGet := todoService.get(ctx, todoId)
+
+	if errGet != nil {
+		return response.TodoResponse{}, errGet
+	}
+
+	todoResponse := response.TodoResponse{}
+
+	err := writeTodoChange(ctx, todoService.db, todoService.syncRepository, todoService.outbox, currentTodo.UserId, func(tx *sql.Tx, changeSeq int64) error {
+		if err := todoService.todoRepository.UpdateTodoCompletion(ctx, tx, todoId, version, changeSeq); err != nil {
+			return err
+		}
+
+		var errEvent error
+		todoResponse, errEvent = addTodoEvent(ctx, tx, todoService.todoRepository, todoService.outbox, helper.EventTodoCompleted, todoId)
+
+		return errEvent
+	})
 
 	if err != nil {
-		return err
+		return response.TodoResponse{}, todoService.writeError(ctx, todoId, err)
+	}
+
+	return todoResponse, nil
+}
+
+func (todoService *TodoServiceImpl) Remove(ctx context.Context, todoId int, version int) error {
+	currentTodo, errGet := todoService.get(ctx, todoId)
+
+	if errGet != nil {
+		return errGet
+	}
+
+	err := writeTodoChange(ctx, todoService.db, todoService.syncRepository, todoService.outbox, currentTodo.UserId, func(tx *sql.Tx, changeSeq int64) error {
+		if err := todoService.todoRepository.Delete(ctx, tx, todoId, version); err != nil {
+			return err
+		}
+
+		tombstone := entity.TodoTombstone{
+			UserId:    currentTodo.UserId,
+			Uuid:      currentTodo.Uuid,
+			ChangeSeq: changeSeq,
+		}
+
+		if err := todoService.syncRepository.InsertTombstone(ctx, tx, tombstone); err != nil {
+			return err
+		}
+
+		return todoService.outbox.Add(ctx, tx, currentTodo.UserId, helper.EventTodoDeleted, response.TodoDeletedResponse{Id: currentTodo.Id, Uuid: currentTodo.Uuid})
+	})
+
+	if err != nil {
+		return todoService.writeError(ctx, todoId, err)
 	}
 
 	return nil
 }
 
-func (todoService *TodoServiceImpl) Remove(ctx context.Context, todoId int) error {
-	err := todoService.todoRepository.Delete(ctx, todoService.db, todoId)
+func (todoService *TodoServiceImpl) get(ctx context.Context, todoId int) (entity.Todo, error) {
+	todo, err := todoService.todoRepository.Get(ctx, todoService.db, todoId)
 
 	if err != nil {
+		if errors.Is(err, helper.ErrNotFound) {
+			return entity.Todo{}, helper.ErrTodoNotFound
+		}
+		return entity.Todo{}, err
+	}
+
+	return todo, nil
+}
+
+// writeError tells apart the two reasons a conditional write touches no
+// rows: the todo is gone, or another client changed it first.
+func (todoService *TodoServiceImpl) writeError(ctx context.Context, todoId int, err error) error {
+	if !errors.Is(err, helper.ErrRowsNotAffected) {
 		return err
 	}
 
-	return nil
+	_, errGet := todoService.todoRepository.Get(ctx, todoService.db, todoId)
+
+	if errGet != nil {
+		if errors.Is(errGet, helper.ErrNotFound) {
+			return helper.ErrTodoNotFound
+		}
+		return errGet
+	}
+
+	return helper.ErrPreconditionFailed
+}
+
+func toTodoResponse(todo entity.Todo) response.TodoResponse {
+	return response.TodoResponse{
+		Id:          todo.Id,
+		Uuid:        todo.Uuid,
+		UserId:      todo.UserId,
+		Title:       todo.Title,
+		Description: todo.Description,
+		IsDone:      todo.IsDone,
+		RemindAt:    helper.FormatTimestamp(todo.RemindAt),
+		Version:     todo.Version,
+		CreatedAt:   todo.CreatedAt,
+		UpdatedAt:   todo.UpdatedAt,
+	}
 }
@@ -3,32 +3,58 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/model/response"
 	"go_todo_api/internal/repository"
 	customvalidator "go_todo_api/internal/validator"
+	"net/url"
 )
 
+const (
+	emailChangeExpiresInHours      = 24
+	emailChangeRevertExpiresInDays = 7
+)
+
+// EmailChangeConfig holds the pages the mailed links point to. They get the
+// token as a "token" query parameter and post it to the confirm or revert
+// endpoint.
+type EmailChangeConfig struct {
+	ConfirmUrl string
+	RevertUrl  string
+}
+
 type UserService interface {
 	Find(ctx context.Context, userId int) (response.UserResponse, error)
 	Create(ctx context.Context, user request.UserCreateRequest) error
 	Update(ctx context.Context, user request.UserUpdateRequest) error
 	Remove(ctx context.Context, userId int) error
+	ConfirmEmail(ctx context.Context, emailTokenRequest request.EmailTokenRequest) error
+	RevertEmail(ctx context.Context, emailTokenRequest request.EmailTokenRequest) error
 }
 
 type UserServiceImpl struct {
-	db             *sql.DB
-	userRepository repository.UserRepository
-	validate       customvalidator.CustomValidator
-	passwordHasher func(password string) (string, error)
+	db                    *sql.DB
+	userRepository        repository.UserRepository
+	validate              customvalidator.CustomValidator
+	passwordHasher        func(password string) (string, error)
+	emailChangeRepository repository.EmailChangeRepository
+	mailer                helper.Mailer
+	emailChangeConfig     EmailChangeConfig
 }
 
-func NewUserService(db *sql.DB, userRepository repository.UserRepository, validate customvalidator.CustomValidator, passwordHasher func(password string) (string, error)) UserService {
+func NewUserService(db *sql.DB, userRepository repository.UserRepository, validate customvalidator.CustomValidator, passwordHasher func(password string) (string, error), emailChangeRepository repository.EmailChangeRepository, mailer helper.Mailer, emailChangeConfig EmailChangeConfig) UserService {
 	return &UserServiceImpl{
-		db:             db,
-		userRepository: userRepository,
-		validate:       validate,
-		passwordHasher: passwordHasher,
+		db:                    db,
+		userRepository:        userRepository,
+		validate:              validate,
+		passwordHasher:        passwordHasher,
+		emailChangeRepository: emailChangeRepository,
+		mailer:                mailer,
+		emailChangeConfig:     emailChangeConfig,
 	}
 }
 
@@ -36,22 +62,20 @@ func (userService *UserServiceImpl) Find(ctx context.Context, userId int) (respo
 	user, err := userService.userRepository.Get(ctx, userService.db, userId)
 
 	if err != nil {
+		if errors.Is(err, helper.ErrNotFound) {
+			return response.UserResponse{}, helper.ErrUserNotFound
+		}
 		return response.UserResponse{}, err
 	}
 
-	userResponse := response.UserResponse{
-		Id:          user.Id,
-		Username:    user.Username,
-		Name:        user.Name,
-		Email:       user.Email,
-		PhoneNumber: user.PhoneNumber,
-		CreatedAt:   user.CreatedAt,
-	}
-
-	return userResponse, nil
+	return toUserResponse(user), nil
 }
 
 func (userService *UserServiceImpl) Create(ctx context.Context, user request.UserCreateRequest) error {
+	user.Username = helper.NormalizeIdentifier(user.Username)
+	user.Email = helper.NormalizeIdentifier(user.Email)
+	user.PhoneNumber = helper.NormalizePhoneNumber(user.PhoneNumber)
+
 	if err := userService.validate.StructCtx(ctx, user); err != nil {
 		return err
 	}
@@ -73,18 +97,223 @@ func (userService *UserServiceImpl) Create(ctx context.Context, user request.Use
 	return nil
 }
 
+// Update saves the profile right away, but a new email only becomes pending
+// until the link sent to it is followed.
 func (userService *UserServiceImpl) Update(ctx context.Context, user request.UserUpdateRequest) error {
+	user.Username = helper.NormalizeIdentifier(user.Username)
+	user.Email = helper.NormalizeIdentifier(user.Email)
+	user.PhoneNumber = helper.NormalizePhoneNumber(user.PhoneNumber)
+
 	if err := userService.validate.StructCtx(ctx, user); err != nil {
 		return err
 	}
 
-	err := userService.userRepository.Update(ctx, userService.db, user)
+	currentUser, errGetUser := userService.userRepository.Get(ctx, userService.db, user.Id)
 
-	if err != nil {
+	if errGetUser != nil {
+		return errGetUser
+	}
+
+	newEmail := user.Email
+	user.Email = currentUser.Email
+
+	// Clients that don't know about locales leave the preference as it is.
+	if user.Locale == "" {
+		user.Locale = currentUser.Locale
+	}
+
+	// Leaving the phone number out keeps it, like the locale.
+	if user.PhoneNumber == "" {
+		user.PhoneNumber = currentUser.PhoneNumber
+	}
+
+	if user.Username != currentUser.Username || user.Name != currentUser.Name || user.PhoneNumber != currentUser.PhoneNumber || user.Locale != currentUser.Locale {
+		err := userService.userRepository.Update(ctx, userService.db, user)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if newEmail == currentUser.Email {
+		return nil
+	}
+
+	return userService.requestEmailChange(ctx, currentUser, newEmail)
+}
+
+func (userService *UserServiceImpl) requestEmailChange(ctx context.Context, user entity.User, newEmail string) error {
+	if err := userService.checkEmailAvailable(ctx, newEmail); err != nil {
 		return err
 	}
 
-	return nil
+	confirmToken, errConfirmToken := helper.GenerateEmailToken()
+
+	if errConfirmToken != nil {
+		return errConfirmToken
+	}
+
+	revertToken, errRevertToken := helper.GenerateEmailToken()
+
+	if errRevertToken != nil {
+		return errRevertToken
+	}
+
+	tx, errTxBegin := userService.db.Begin()
+
+	if errTxBegin != nil {
+		return errTxBegin
+	}
+
+	if err := userService.emailChangeRepository.DeletePending(ctx, tx, user.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	emailChange := entity.EmailChange{
+		UserId:           user.Id,
+		OldEmail:         user.Email,
+		NewEmail:         newEmail,
+		ConfirmTokenHash: helper.HashToken(confirmToken),
+		RevertTokenHash:  helper.HashToken(revertToken),
+	}
+
+	if err := userService.emailChangeRepository.Insert(ctx, tx, emailChange, emailChangeExpiresInHours, emailChangeRevertExpiresInDays); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := userService.userRepository.UpdatePendingEmail(ctx, tx, user.Id, newEmail); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	confirmMail := helper.Mail{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body:    fmt.Sprintf("The account %s asked to use this email address. Confirm it within %d hours by opening %s\n\nIf you didn't ask for this, ignore this email.", user.Username, emailChangeExpiresInHours, withQuery(userService.emailChangeConfig.ConfirmUrl, url.Values{"token": {confirmToken}})),
+	}
+
+	if err := userService.mailer.Send(ctx, confirmMail); err != nil {
+		return err
+	}
+
+	noticeMail := helper.Mail{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body:    fmt.Sprintf("Someone asked to change the email address of the account %s to %s. If it wasn't you, undo the change within %d days by opening %s", user.Username, newEmail, emailChangeRevertExpiresInDays, withQuery(userService.emailChangeConfig.RevertUrl, url.Values{"token": {revertToken}})),
+	}
+
+	return userService.mailer.Send(ctx, noticeMail)
+}
+
+func (userService *UserServiceImpl) checkEmailAvailable(ctx context.Context, email string) error {
+	_, err := userService.userRepository.GetByEmail(ctx, userService.db, email)
+
+	if err == nil {
+		return helper.ErrEmailTaken
+	}
+
+	if errors.Is(err, helper.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (userService *UserServiceImpl) ConfirmEmail(ctx context.Context, emailTokenRequest request.EmailTokenRequest) error {
+	if err := userService.validate.StructCtx(ctx, emailTokenRequest); err != nil {
+		return err
+	}
+
+	emailChange, errGetEmailChange := userService.emailChangeRepository.GetPendingByConfirmHash(ctx, userService.db, helper.HashToken(emailTokenRequest.Token))
+
+	if errGetEmailChange != nil {
+		if errors.Is(errGetEmailChange, helper.ErrNotFound) {
+			return helper.ErrorTokenInvalid
+		}
+		return errGetEmailChange
+	}
+
+	if err := userService.checkEmailAvailable(ctx, emailChange.NewEmail); err != nil {
+		return err
+	}
+
+	tx, errTxBegin := userService.db.Begin()
+
+	if errTxBegin != nil {
+		return errTxBegin
+	}
+
+	if err := userService.emailChangeRepository.MarkConfirmed(ctx, tx, emailChange.Id); err != nil {
+		tx.Rollback()
+		if errors.Is(err, helper.ErrRowsNotAffected) {
+			return helper.ErrorTokenInvalid
+		}
+		return err
+	}
+
+	if err := userService.userRepository.UpdateEmail(ctx, tx, emailChange.UserId, emailChange.NewEmail); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevertEmail is for the old address: it cancels a pending change or undoes a
+// confirmed one, and logs the user out everywhere since whoever made the
+// change may have taken over the account.
+func (userService *UserServiceImpl) RevertEmail(ctx context.Context, emailTokenRequest request.EmailTokenRequest) error {
+	if err := userService.validate.StructCtx(ctx, emailTokenRequest); err != nil {
+		return err
+	}
+
+	emailChange, errGetEmailChange := userService.emailChangeRepository.GetByRevertHash(ctx, userService.db, helper.HashToken(emailTokenRequest.Token))
+
+	if errGetEmailChange != nil {
+		if errors.Is(errGetEmailChange, helper.ErrNotFound) {
+			return helper.ErrorTokenInvalid
+		}
+		return errGetEmailChange
+	}
+
+	tx, errTxBegin := userService.db.Begin()
+
+	if errTxBegin != nil {
+		return errTxBegin
+	}
+
+	if err := userService.emailChangeRepository.MarkReverted(ctx, tx, emailChange.Id); err != nil {
+		tx.Rollback()
+		if errors.Is(err, helper.ErrRowsNotAffected) {
+			return helper.ErrorTokenInvalid
+		}
+		return err
+	}
+
+	var errRevert error
+
+	if emailChange.ConfirmedAt != "" {
+		errRevert = userService.userRepository.UpdateEmail(ctx, tx, emailChange.UserId, emailChange.OldEmail)
+	} else {
+		errRevert = userService.userRepository.UpdatePendingEmail(ctx, tx, emailChange.UserId, "")
+	}
+
+	if errRevert != nil {
+		tx.Rollback()
+		return errRevert
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return userService.userRepository.IncrementTokenVersion(ctx, userService.db, emailChange.UserId)
 }
 
 func (userService *UserServiceImpl) Remove(ctx context.Context, userId int) error {
@@ -110,3 +339,18 @@ func (userService *UserServiceImpl) Remove(ctx context.Context, userId int) erro
 	tx.Commit()
 	return nil
 }
+
+func toUserResponse(user entity.User) response.UserResponse {
+	return response.UserResponse{
+		Id:           user.Id,
+		Username:     user.Username,
+		Name:         user.Name,
+		Email:        user.Email,
+		PendingEmail: user.PendingEmail,
+		PhoneNumber:  user.PhoneNumber,
+		Locale:       user.Locale,
+		Role:         user.Role,
+		IsDisabled:   user.IsDisabled,
+		CreatedAt:    user.CreatedAt,
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookConfig decides how hard a delivery is tried. An attempt that fails
+// is retried after InitialBackoff, doubling up to MaxBackoff, until
+// MaxAttempts were made. A webhook whose last DisableAfter attempts all
+// failed is disabled.
+type WebhookConfig struct {
+	Timeout        time.Duration
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	DisableAfter   int
+	PollInterval   time.Duration
+	BatchSize      int
+	Concurrency    int
+	// AllowPrivateNetworks lets webhooks reach loopback and private
+	// addresses, which is only safe when every user is trusted.
+	AllowPrivateNetworks bool
+}
+
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Timeout:        10 * time.Second,
+		MaxAttempts:    8,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     time.Hour,
+		DisableAfter:   20,
+		PollInterval:   5 * time.Second,
+		BatchSize:      20,
+		Concurrency:    4,
+	}
+}
+
+func (config WebhookConfig) backoff(attempts int) time.Duration {
+	return exponentialBackoff(config.InitialBackoff, config.MaxBackoff, attempts)
+}
+
+// WebhookDispatcher turns events from the outbox into webhook deliveries and
+// sends them, away from the requests that caused them.
+type WebhookDispatcher interface {
+	OutboxSink
+	// Run sends deliveries until ctx is done.
+	Run(ctx context.Context)
+	DeliverDue(ctx context.Context) error
+}
+
+type WebhookDispatcherImpl struct {
+	db                *sql.DB
+	webhookRepository repository.WebhookRepository
+	config            WebhookConfig
+	client            *http.Client
+	wake              chan struct{}
+}
+
+func NewWebhookDispatcher(db *sql.DB, webhookRepository repository.WebhookRepository, config WebhookConfig) WebhookDispatcher {
+	return &WebhookDispatcherImpl{
+		db:                db,
+		webhookRepository: webhookRepository,
+		config:            config,
+		client:            helper.NewPublicHttpClient(config.Timeout, config.AllowPrivateNetworks),
+		wake:              make(chan struct{}, 1),
+	}
+}
+
+func (dispatcher *WebhookDispatcherImpl) Run(ctx context.Context) {
+	poll := time.NewTicker(dispatcher.config.PollInterval)
+	defer poll.Stop()
+
+	for {
+		if err := dispatcher.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithField("error", err.Error()).Error("webhook deliveries not loaded")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-dispatcher.wake:
+		}
+	}
+}
+
+func (dispatcher *WebhookDispatcherImpl) Name() string {
+	return "webhooks"
+}
+
+// Publish adds a delivery of the event for each active webhook of its user
+// that wants it. Publishing an event again adds none for the webhooks that
+// already have one.
+func (dispatcher *WebhookDispatcherImpl) Publish(ctx context.Context, event entity.OutboxEvent) error {
+	webhooks, err := dispatcher.webhookRepository.GetActiveUserWebhooks(ctx, dispatcher.db, event.UserId)
+
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+
+	for _, webhook := range webhooks {
+		if !containsField(webhook.EventTypes, event.EventType) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(response.WebhookEventResponse{
+				Id:        event.EventId,
+				Type:      event.EventType,
+				CreatedAt: event.CreatedAt,
+				Data:      json.RawMessage(event.Payload),
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		delivery := entity.WebhookDelivery{
+			WebhookId: webhook.Id,
+			EventId:   event.EventId,
+			EventType: event.EventType,
+			Payload:   string(payload),
+		}
+
+		if err := dispatcher.webhookRepository.InsertEventDelivery(ctx, dispatcher.db, delivery); err != nil {
+			return err
+		}
+	}
+
+	if payload != nil {
+		select {
+		case dispatcher.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// DeliverDue sends a batch of the deliveries that are due and waits for them.
+// Deliveries another instance claimed first are skipped.
+func (dispatcher *WebhookDispatcherImpl) DeliverDue(ctx context.Context) error {
+	deliveries, err := dispatcher.webhookRepository.GetDueDeliveries(ctx, dispatcher.db, dispatcher.config.BatchSize)
+
+	if err != nil {
+		return err
+	}
+
+	waitGroup := sync.WaitGroup{}
+	defer waitGroup.Wait()
+
+	slots := make(chan struct{}, dispatcher.config.Concurrency)
+
+	for _, delivery := range deliveries {
+		slots <- struct{}{}
+		waitGroup.Add(1)
+
+		go func(delivery entity.WebhookDelivery) {
+			defer waitGroup.Done()
+			defer func() { <-slots }()
+
+			if err := dispatcher.deliver(ctx, delivery); err != nil && ctx.Err() == nil {
+				logrus.WithFields(logrus.Fields{
+					"delivery_id": delivery.Id,
+					"error":       err.Error(),
+				}).Error("webhook delivery failed")
+			}
+		}(delivery)
+	}
+
+	return nil
+}
+
+// deliver claims the delivery, sends it and saves how it went. The claim
+// lasts long enough for the attempt and for saving its outcome.
+func (dispatcher *WebhookDispatcherImpl) deliver(ctx context.Context, delivery entity.WebhookDelivery) error {
+	errClaim := dispatcher.webhookRepository.ClaimDelivery(ctx, dispatcher.db, delivery.Id, dispatcher.config.Timeout+30*time.Second)
+
+	if errors.Is(errClaim, helper.ErrRowsNotAffected) {
+		return nil
+	}
+
+	if errClaim != nil {
+		return errClaim
+	}
+
+	statusCode, errSend := dispatcher.send(ctx, delivery)
+
+	// Cut short by a shutdown, the claim runs out and the delivery is tried
+	// again.
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	delivery.Attempts++
+	delivery.ResponseStatus = statusCode
+	retryIn := time.Duration(0)
+
+	if errSend == nil {
+		delivery.Status = entity.WebhookDeliverySucceeded
+		delivery.LastError = ""
+
+		if err := dispatcher.webhookRepository.RecordSuccess(ctx, dispatcher.db, delivery.WebhookId); err != nil {
+			return err
+		}
+	} else {
+		delivery.Status = entity.WebhookDeliveryPending
+		delivery.LastError = truncate(errSend.Error(), 255)
+
+		if delivery.Attempts >= dispatcher.config.MaxAttempts {
+			delivery.Status = entity.WebhookDeliveryFailed
+		} else {
+			retryIn = dispatcher.config.backoff(delivery.Attempts)
+		}
+
+		if err := dispatcher.webhookRepository.RecordFailure(ctx, dispatcher.db, delivery.WebhookId, dispatcher.config.DisableAfter); err != nil {
+			return err
+		}
+	}
+
+	return dispatcher.webhookRepository.UpdateDelivery(ctx, dispatcher.db, delivery, retryIn)
+}
+
+// send posts the delivery, any response other than a 2xx is a failure.
+func (dispatcher *WebhookDispatcherImpl) send(ctx context.Context, delivery entity.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dispatcher.config.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+
+	webhookRequest, errRequest := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+
+	if errRequest != nil {
+		return 0, errRequest
+	}
+
+	webhookRequest.Header.Set("Content-Type", "application/json")
+	webhookRequest.Header.Set("User-Agent", "go_todo_api-webhooks")
+	webhookRequest.Header.Set(helper.WebhookIdHeader, delivery.EventId)
+	webhookRequest.Header.Set(helper.WebhookEventHeader, delivery.EventType)
+	webhookRequest.Header.Set(helper.WebhookSignatureHeader, helper.SignWebhook(delivery.Secret, time.Now().Unix(), body))
+
+	webhookResponse, errDo := dispatcher.client.Do(webhookRequest)
+
+	if errDo != nil {
+		return 0, errDo
+	}
+
+	defer webhookResponse.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(webhookResponse.Body, 64<<10))
+
+	if webhookResponse.StatusCode < 200 || webhookResponse.StatusCode >= 300 {
+		return webhookResponse.StatusCode, fmt.Errorf("unexpected status %d", webhookResponse.StatusCode)
+	}
+
+	return webhookResponse.StatusCode, nil
+}
+
+func containsField(fields string, field string) bool {
+	for _, candidate := range strings.Fields(fields) {
+		if candidate == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func truncate(text string, length int) string {
+	if len(text) <= length {
+		return text
+	}
+
+	return strings.ToValidUTF8(text[:length], "")
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	customvalidator "go_todo_api/internal/validator"
+	"strings"
+)
+
+// webhookDeliveryPageSize is how many of its latest deliveries a webhook
+// lists.
+const webhookDeliveryPageSize = 100
+
+type WebhookService interface {
+	Create(ctx context.Context, webhookCreateRequest request.WebhookCreateRequest) (response.WebhookCreateResponse, error)
+	FindUserWebhooks(ctx context.Context, userId int) ([]response.WebhookResponse, error)
+	Remove(ctx context.Context, userId int, webhookId int) error
+	Enable(ctx context.Context, userId int, webhookId int) (response.WebhookResponse, error)
+	FindDeliveries(ctx context.Context, userId int, webhookId int) ([]response.WebhookDeliveryResponse, error)
+	Redeliver(ctx context.Context, userId int, webhookId int, deliveryId int) (response.WebhookDeliveryResponse, error)
+}
+
+type WebhookServiceImpl struct {
+	db                *sql.DB
+	webhookRepository repository.WebhookRepository
+	validate          customvalidator.CustomValidator
+}
+
+func NewWebhookService(db *sql.DB, webhookRepository repository.WebhookRepository, validate customvalidator.CustomValidator) WebhookService {
+	return &WebhookServiceImpl{
+		db:                db,
+		webhookRepository: webhookRepository,
+		validate:          validate,
+	}
+}
+
+func (webhookService *WebhookServiceImpl) Create(ctx context.Context, webhookCreateRequest request.WebhookCreateRequest) (response.WebhookCreateResponse, error) {
+	if err := webhookService.validate.StructCtx(ctx, webhookCreateRequest); err != nil {
+		return response.WebhookCreateResponse{}, err
+	}
+
+	secret, errSecret := helper.GenerateWebhookSecret()
+
+	if errSecret != nil {
+		return response.WebhookCreateResponse{}, errSecret
+	}
+
+	webhook := entity.Webhook{
+		UserId:     webhookCreateRequest.UserId,
+		Url:        webhookCreateRequest.Url,
+		Secret:     secret,
+		EventTypes: strings.Join(webhookCreateRequest.EventTypes, " "),
+	}
+
+	webhookId, errInsert := webhookService.webhookRepository.Insert(ctx, webhookService.db, webhook)
+
+	if errInsert != nil {
+		return response.WebhookCreateResponse{}, errInsert
+	}
+
+	createdWebhook, errGet := webhookService.get(ctx, webhookCreateRequest.UserId, webhookId)
+
+	if errGet != nil {
+		return response.WebhookCreateResponse{}, errGet
+	}
+
+	webhookCreateResponse := response.WebhookCreateResponse{
+		WebhookResponse: toWebhookResponse(createdWebhook),
+		Secret:          secret,
+	}
+
+	return webhookCreateResponse, nil
+}
+
+func (webhookService *WebhookServiceImpl) FindUserWebhooks(ctx context.Context, userId int) ([]response.WebhookResponse, error) {
+	webhooks, err := webhookService.webhookRepository.GetUserWebhooks(ctx, webhookService.db, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhookResponses := []response.WebhookResponse{}
+
+	for _, webhook := range webhooks {
+		webhookResponses = append(webhookResponses, toWebhookResponse(webhook))
+	}
+
+	return webhookResponses, nil
+}
+
+func (webhookService *WebhookServiceImpl) Remove(ctx context.Context, userId int, webhookId int) error {
+	err := webhookService.webhookRepository.Delete(ctx, webhookService.db, userId, webhookId)
+
+	if errors.Is(err, helper.ErrRowsNotAffected) {
+		return helper.ErrWebhookNotFound
+	}
+
+	return err
+}
+
+// Enable turns a webhook that was disabled for failing back on. Deliveries
+// still pending go out again.
+func (webhookService *WebhookServiceImpl) Enable(ctx context.Context, userId int, webhookId int) (response.WebhookResponse, error) {
+	if _, err := webhookService.get(ctx, userId, webhookId); err != nil {
+		return response.WebhookResponse{}, err
+	}
+
+	if err := webhookService.webhookRepository.Enable(ctx, webhookService.db, userId, webhookId); err != nil {
+		return response.WebhookResponse{}, err
+	}
+
+	webhook, errGet := webhookService.get(ctx, userId, webhookId)
+
+	if errGet != nil {
+		return response.WebhookResponse{}, errGet
+	}
+
+	return toWebhookResponse(webhook), nil
+}
+
+func (webhookService *WebhookServiceImpl) FindDeliveries(ctx context.Context, userId int, webhookId int) ([]response.WebhookDeliveryResponse, error) {
+	if _, err := webhookService.get(ctx, userId, webhookId); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := webhookService.webhookRepository.GetDeliveries(ctx, webhookService.db, webhookId, webhookDeliveryPageSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryResponses := []response.WebhookDeliveryResponse{}
+
+	for _, delivery := range deliveries {
+		deliveryResponses = append(deliveryResponses, toWebhookDeliveryResponse(delivery))
+	}
+
+	return deliveryResponses, nil
+}
+
+// Redeliver queues the event of a past delivery again, as a new delivery
+// with the same event id.
+func (webhookService *WebhookServiceImpl) Redeliver(ctx context.Context, userId int, webhookId int, deliveryId int) (response.WebhookDeliveryResponse, error) {
+	if _, err := webhookService.get(ctx, userId, webhookId); err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+
+	delivery, errGetDelivery := webhookService.webhookRepository.GetDelivery(ctx, webhookService.db, webhookId, deliveryId)
+
+	if errGetDelivery != nil {
+		if errors.Is(errGetDelivery, helper.ErrNotFound) {
+			return response.WebhookDeliveryResponse{}, helper.ErrWebhookDeliveryNotFound
+		}
+		return response.WebhookDeliveryResponse{}, errGetDelivery
+	}
+
+	newDeliveryId, errInsert := webhookService.webhookRepository.InsertDelivery(ctx, webhookService.db, delivery)
+
+	if errInsert != nil {
+		return response.WebhookDeliveryResponse{}, errInsert
+	}
+
+	newDelivery, errGetNewDelivery := webhookService.webhookRepository.GetDelivery(ctx, webhookService.db, webhookId, newDeliveryId)
+
+	if errGetNewDelivery != nil {
+		return response.WebhookDeliveryResponse{}, errGetNewDelivery
+	}
+
+	return toWebhookDeliveryResponse(newDelivery), nil
+}
+
+func (webhookService *WebhookServiceImpl) get(ctx context.Context, userId int, webhookId int) (entity.Webhook, error) {
+	webhook, err := webhookService.webhookRepository.Get(ctx, webhookService.db, userId, webhookId)
+
+	if err != nil {
+		if errors.Is(err, helper.ErrNotFound) {
+			return entity.Webhook{}, helper.ErrWebhookNotFound
+		}
+		return entity.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func toWebhookResponse(webhook entity.Webhook) response.WebhookResponse {
+	return response.WebhookResponse{
+		Id:           webhook.Id,
+		Url:          webhook.Url,
+		EventTypes:   strings.Fields(webhook.EventTypes),
+		IsActive:     webhook.DisabledAt == "",
+		FailureCount: webhook.FailureCount,
+		DisabledAt:   webhook.DisabledAt,
+		CreatedAt:    webhook.CreatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery entity.WebhookDelivery) response.WebhookDeliveryResponse {
+	return response.WebhookDeliveryResponse{
+		Id:             delivery.Id,
+		EventId:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
@@ -2,6 +2,10 @@ package validator
 
 import (
 	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"strings"
+	"sync"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -10,6 +14,55 @@ type CustomValidator interface {
 	StructCtx(ctx context.Context, s interface{}) (err error)
 }
 
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+)
+
+// NewValidator always returns the same instance, the error messages can only
+// be registered once per translator.
 func NewValidator() CustomValidator {
-	return validator.New()
+	validateOnce.Do(func() {
+		validate = validator.New()
+
+		validate.RegisterValidation("phone", validatePhoneNumber)
+		validate.RegisterValidation("uuid7", validateUuidV7)
+		validate.RegisterStructValidation(validateSyncMutation, request.SyncMutationRequest{})
+
+		if err := helper.RegisterValidationTranslations(validate); err != nil {
+			panic(err)
+		}
+	})
+
+	return validate
+}
+
+func validatePhoneNumber(fieldLevel validator.FieldLevel) bool {
+	return helper.IsValidPhoneNumber(fieldLevel.Field().String())
+}
+
+func validateUuidV7(fieldLevel validator.FieldLevel) bool {
+	return helper.IsUuidV7(fieldLevel.Field().String())
+}
+
+// validateSyncMutation holds todos created offline to the same ids as those
+// created through POST /api/todo. Todos from before those ids keep theirs, so
+// the other mutations take any UUID.
+func validateSyncMutation(structLevel validator.StructLevel) {
+	mutation := structLevel.Current().Interface().(request.SyncMutationRequest)
+
+	if mutation.Id == "" {
+		return
+	}
+
+	if mutation.Op == request.SyncOpUpsert && mutation.BaseVersion == 0 {
+		if !helper.IsUuidV7(mutation.Id) {
+			structLevel.ReportError(mutation.Id, "id", "Id", "uuid7", "")
+		}
+		return
+	}
+
+	if structLevel.Validator().Var(strings.ToLower(mutation.Id), "uuid") != nil {
+		structLevel.ReportError(mutation.Id, "id", "Id", "uuid", "")
+	}
 }
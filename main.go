@@ -5,10 +5,19 @@ import (
 	"database/sql"
 	"fmt"
 	"go_todo_api/database"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
 	"go_todo_api/internal/middleware"
+	"go_todo_api/internal/repository"
+	"go_todo_api/internal/service"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
+	"time"
+	// Quiet hours are in the user's time zone, which the host may not have.
+	_ "time/tzdata"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
@@ -40,6 +49,372 @@ func NewDB() (*sql.DB, func()) {
 	return db, cleanup
 }
 
+// NewJwtKeySet loads the token signing keys once at startup. JWT_KEYS_DIR holds
+// one "<kid>.pem" file per key and JWT_SIGNING_KEY_ID picks the one new tokens
+// are signed with, JWT_KEY is only kept to verify tokens issued before.
+func NewJwtKeySet() (*helper.JwtKeySet, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return nil, errEnvLoad
+	}
+
+	return helper.LoadJwtKeySet(os.Getenv("JWT_KEYS_DIR"), os.Getenv("JWT_SIGNING_KEY_ID"), os.Getenv("JWT_KEY"))
+}
+
+// NewOidcProviders reads the social login providers named in OIDC_PROVIDERS,
+// each configured by OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and
+// _REDIRECT_URL. Discovery happens on first use, so a provider being down
+// doesn't keep the server from starting.
+func NewOidcProviders() (helper.OidcProviders, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return nil, errEnvLoad
+	}
+
+	providers := helper.OidcProviders{}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		config := helper.OidcProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientId:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectUrl:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+
+		if config.Issuer == "" || config.ClientId == "" || config.RedirectUrl == "" {
+			return nil, fmt.Errorf("oidc provider %s needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+
+		providers[name] = helper.NewOidcProvider(config, httpClient)
+	}
+
+	return providers, nil
+}
+
+// NewLoginAttemptStore picks where failed logins are counted. LOGIN_ATTEMPT_STORE
+// is "memory" (the default) for a single instance, or "database" when several
+// instances have to share the counters.
+func NewLoginAttemptStore(db *sql.DB) (repository.LoginAttemptStore, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return nil, errEnvLoad
+	}
+
+	switch os.Getenv("LOGIN_ATTEMPT_STORE") {
+	case "", "memory":
+		return repository.NewMemoryLoginAttemptStore(), nil
+	case "database":
+		return repository.NewDbLoginAttemptStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown LOGIN_ATTEMPT_STORE %s, use memory or database", os.Getenv("LOGIN_ATTEMPT_STORE"))
+	}
+}
+
+// NewIdempotencyStore picks where responses to requests with an
+// Idempotency-Key are kept. IDEMPOTENCY_STORE is "memory" (the default) for a
+// single instance, or "database" when several instances serve the same users.
+func NewIdempotencyStore(db *sql.DB) (repository.IdempotencyStore, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return nil, errEnvLoad
+	}
+
+	switch os.Getenv("IDEMPOTENCY_STORE") {
+	case "", "memory":
+		return repository.NewMemoryIdempotencyStore(), nil
+	case "database":
+		return repository.NewDbIdempotencyStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown IDEMPOTENCY_STORE %s, use memory or database", os.Getenv("IDEMPOTENCY_STORE"))
+	}
+}
+
+// NewMailer picks how mails go out. MAIL_DRIVER is "log" (the default) to
+// print them, or "smtp" to send them through SMTP_HOST and SMTP_PORT, with
+// SMTP_USERNAME and SMTP_PASSWORD when the server needs them, from MAIL_FROM.
+func NewMailer() (helper.Mailer, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return nil, errEnvLoad
+	}
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case "", "log":
+		return helper.NewLogMailer(), nil
+	case "smtp":
+		config := helper.SmtpMailerConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+
+		if config.Host == "" || config.Port == "" || config.From == "" {
+			return nil, fmt.Errorf("smtp mailer needs SMTP_HOST, SMTP_PORT and MAIL_FROM")
+		}
+
+		return helper.NewSmtpMailer(config), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %s, use log or smtp", os.Getenv("MAIL_DRIVER"))
+	}
+}
+
+// NewEmailChangeConfig reads the pages EMAIL_CONFIRM_URL and EMAIL_REVERT_URL
+// that email change links open.
+func NewEmailChangeConfig() (service.EmailChangeConfig, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return service.EmailChangeConfig{}, errEnvLoad
+	}
+
+	config := service.EmailChangeConfig{
+		ConfirmUrl: os.Getenv("EMAIL_CONFIRM_URL"),
+		RevertUrl:  os.Getenv("EMAIL_REVERT_URL"),
+	}
+
+	if config.ConfirmUrl == "" || config.RevertUrl == "" {
+		return service.EmailChangeConfig{}, fmt.Errorf("email changes need EMAIL_CONFIRM_URL and EMAIL_REVERT_URL")
+	}
+
+	return config, nil
+}
+
+// NewTodoControllerConfig requires If-Match on todo writes unless
+// TODO_REQUIRE_IF_MATCH is "false", for clients that don't send it yet.
+func NewTodoControllerConfig() (controller.TodoControllerConfig, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return controller.TodoControllerConfig{}, errEnvLoad
+	}
+
+	switch os.Getenv("TODO_REQUIRE_IF_MATCH") {
+	case "", "true":
+		return controller.TodoControllerConfig{RequireIfMatch: true}, nil
+	case "false":
+		return controller.TodoControllerConfig{RequireIfMatch: false}, nil
+	default:
+		return controller.TodoControllerConfig{}, fmt.Errorf("unknown TODO_REQUIRE_IF_MATCH %s, use true or false", os.Getenv("TODO_REQUIRE_IF_MATCH"))
+	}
+}
+
+// NewWebhookConfig lets webhooks reach private addresses when
+// WEBHOOK_ALLOW_PRIVATE_NETWORKS is "true", for local development.
+func NewWebhookConfig() (service.WebhookConfig, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return service.WebhookConfig{}, errEnvLoad
+	}
+
+	config := service.DefaultWebhookConfig()
+
+	switch os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") {
+	case "", "false":
+		config.AllowPrivateNetworks = false
+	case "true":
+		config.AllowPrivateNetworks = true
+	default:
+		return service.WebhookConfig{}, fmt.Errorf("unknown WEBHOOK_ALLOW_PRIVATE_NETWORKS %s, use true or false", os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS"))
+	}
+
+	return config, nil
+}
+
+// NewOutboxSinks picks where the outbox publishes todo events. They always
+// reach the webhooks. OUTBOX_BROKER "redis" also appends them to the Redis
+// stream OUTBOX_REDIS_STREAM (todo-events by default) at REDIS_ADDR, with
+// REDIS_PASSWORD when it is set.
+func NewOutboxSinks(webhookDispatcher service.WebhookDispatcher) ([]service.OutboxSink, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return nil, errEnvLoad
+	}
+
+	sinks := []service.OutboxSink{webhookDispatcher}
+
+	switch os.Getenv("OUTBOX_BROKER") {
+	case "", "none":
+		return sinks, nil
+	case "redis":
+		if os.Getenv("REDIS_ADDR") == "" {
+			return nil, fmt.Errorf("redis outbox broker needs REDIS_ADDR")
+		}
+
+		stream := os.Getenv("OUTBOX_REDIS_STREAM")
+
+		if stream == "" {
+			stream = "todo-events"
+		}
+
+		client := helper.NewRedisClient(os.Getenv("REDIS_ADDR"), os.Getenv("REDIS_PASSWORD"))
+
+		return append(sinks, service.NewRedisStreamSink(client, stream)), nil
+	default:
+		return nil, fmt.Errorf("unknown OUTBOX_BROKER %s, use none or redis", os.Getenv("OUTBOX_BROKER"))
+	}
+}
+
+// NewNotificationChannels lists how reminders reach users. Email always
+// goes through the mailer. Web push is on when WEB_PUSH_VAPID_PRIVATE_KEY is
+// set, the base64url P-256 private key, with WEB_PUSH_SUBJECT the mailto: or
+// https: contact push services see. WEB_PUSH_ALLOW_PRIVATE_NETWORKS "true"
+// lets subscriptions reach private addresses, for local development. FCM is
+// on when FCM_CREDENTIALS_FILE names a service account key file, and goes to
+// FCM_ENDPOINT when set.
+func NewNotificationChannels(db *sql.DB, notificationRepository repository.NotificationRepository, mailer helper.Mailer) ([]service.NotificationChannel, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return nil, errEnvLoad
+	}
+
+	channels := []service.NotificationChannel{service.NewEmailChannel(mailer)}
+
+	if vapidPrivateKey := os.Getenv("WEB_PUSH_VAPID_PRIVATE_KEY"); vapidPrivateKey != "" {
+		config := helper.WebPushConfig{
+			VapidPrivateKey: vapidPrivateKey,
+			Subject:         os.Getenv("WEB_PUSH_SUBJECT"),
+			Timeout:         10 * time.Second,
+			Ttl:             24 * time.Hour,
+		}
+
+		if config.Subject == "" {
+			return nil, fmt.Errorf("web push needs WEB_PUSH_SUBJECT")
+		}
+
+		switch os.Getenv("WEB_PUSH_ALLOW_PRIVATE_NETWORKS") {
+		case "", "false":
+			config.AllowPrivateNetworks = false
+		case "true":
+			config.AllowPrivateNetworks = true
+		default:
+			return nil, fmt.Errorf("unknown WEB_PUSH_ALLOW_PRIVATE_NETWORKS %s, use true or false", os.Getenv("WEB_PUSH_ALLOW_PRIVATE_NETWORKS"))
+		}
+
+		client, err := helper.NewWebPushClient(config)
+
+		if err != nil {
+			return nil, err
+		}
+
+		channels = append(channels, service.NewWebPushChannel(db, notificationRepository, client))
+	}
+
+	if credentialsFile := os.Getenv("FCM_CREDENTIALS_FILE"); credentialsFile != "" {
+		serviceAccount, errRead := os.ReadFile(credentialsFile)
+
+		if errRead != nil {
+			return nil, errRead
+		}
+
+		config := helper.FcmConfig{
+			Endpoint: os.Getenv("FCM_ENDPOINT"),
+			Timeout:  10 * time.Second,
+		}
+
+		if config.Endpoint == "" {
+			config.Endpoint = "https://fcm.googleapis.com"
+		}
+
+		client, err := helper.NewFcmClient(serviceAccount, config)
+
+		if err != nil {
+			return nil, err
+		}
+
+		channels = append(channels, service.NewFcmChannel(db, notificationRepository, client))
+	}
+
+	return channels, nil
+}
+
+// NewJobHandlers lists the jobs this instance runs, a job type without a
+// handler here stays queued for an instance that has one.
+func NewJobHandlers(cleanupJob *service.CleanupJob, reminderScanJob *service.ReminderScanJob, reminderJob *service.ReminderJob) []service.JobHandler {
+	return []service.JobHandler{cleanupJob, reminderScanJob, reminderJob}
+}
+
+// NewJobSchedules reads when scheduled jobs run, as cron schedules in UTC or
+// "off". JOB_CLEANUP_SCHEDULE is the cleanup of published events, finished
+// jobs and old reminders, daily at 03:00 by default. JOB_REMINDER_SCHEDULE
+// is the look for reminders that came due, every minute by default.
+func NewJobSchedules() ([]service.JobSchedule, error) {
+	errEnvLoad := godotenv.Load("config.env")
+
+	if errEnvLoad != nil {
+		return nil, errEnvLoad
+	}
+
+	jobs := []struct {
+		name        string
+		env         string
+		defaultSpec string
+		jobType     string
+	}{
+		{"cleanup", "JOB_CLEANUP_SCHEDULE", "0 3 * * *", service.CleanupJobType},
+		{"reminders", "JOB_REMINDER_SCHEDULE", "* * * * *", service.ReminderScanJobType},
+	}
+
+	schedules := []service.JobSchedule{}
+
+	for _, job := range jobs {
+		spec := os.Getenv(job.env)
+
+		switch spec {
+		case "off":
+			continue
+		case "":
+			spec = job.defaultSpec
+		}
+
+		cron, err := helper.ParseCronSchedule(spec)
+
+		if err != nil {
+			return nil, fmt.Errorf("bad %s: %w", job.env, err)
+		}
+
+		schedules = append(schedules, service.JobSchedule{Name: job.name, Cron: cron, JobType: job.jobType})
+	}
+
+	return schedules, nil
+}
+
+// App is what main runs, the HTTP server and the work done beside it.
+type App struct {
+	Server            *http.Server
+	Outbox            service.Outbox
+	WebhookDispatcher service.WebhookDispatcher
+	JobRunner         service.JobRunner
+}
+
+func NewApp(server *http.Server, outbox service.Outbox, webhookDispatcher service.WebhookDispatcher, jobRunner service.JobRunner) *App {
+	return &App{
+		Server:            server,
+		Outbox:            outbox,
+		WebhookDispatcher: webhookDispatcher,
+		JobRunner:         jobRunner,
+	}
+}
+
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -53,7 +428,32 @@ func main() {
 		}
 	}()
 
-	server, closeDb := InitializeServer()
+	app, closeDb, errInitialize := InitializeServer()
+
+	if errInitialize != nil {
+		fmt.Println(errInitialize.Error())
+		return
+	}
+
+	server := app.Server
+
+	workers := sync.WaitGroup{}
+	workers.Add(3)
+
+	go func() {
+		defer workers.Done()
+		app.Outbox.Run(ctx)
+	}()
+
+	go func() {
+		defer workers.Done()
+		app.WebhookDispatcher.Run(ctx)
+	}()
+
+	go func() {
+		defer workers.Done()
+		app.JobRunner.Run(ctx)
+	}()
 
 	go func() {
 		fmt.Println("Server running on:", "http://"+server.Addr)
@@ -67,6 +467,9 @@ func main() {
 	<-ctx.Done()
 
 	fmt.Println("Cleaning App...")
+	// Events, deliveries and jobs in flight must finish with the database
+	// still open.
+	workers.Wait()
 	fmt.Println("Closing DB...")
 	closeDb()
 	fmt.Println("DB Closed...")
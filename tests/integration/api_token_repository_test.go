@@ -0,0 +1,48 @@
+package integration
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	testhelper "go_todo_api/tests/test_helper"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiTokenRepositoryLifecycle(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	userId := int(testhelper.InsertSingleUser(db))
+
+	apiTokenRepository := repository.NewApiTokenRepository()
+
+	ctx := context.Background()
+
+	apiToken := entity.ApiToken{
+		UserId:    userId,
+		Name:      "ci",
+		TokenHash: helper.HashToken("gta_integration"),
+		Scopes:    "todos:read",
+	}
+
+	tokenId, errInsert := apiTokenRepository.Insert(ctx, db, apiToken, 30)
+	assert.Nil(t, errInsert)
+
+	activeToken, errGetActive := apiTokenRepository.GetActiveByHash(ctx, db, apiToken.TokenHash)
+	assert.Nil(t, errGetActive)
+	assert.Equal(t, tokenId, activeToken.Id)
+	assert.Equal(t, "budi", activeToken.Username)
+	assert.NotEmpty(t, activeToken.ExpiresAt)
+
+	assert.Nil(t, apiTokenRepository.UpdateLastUsed(ctx, db, tokenId))
+	assert.Nil(t, apiTokenRepository.Revoke(ctx, db, userId, tokenId))
+
+	_, errRevoked := apiTokenRepository.GetActiveByHash(ctx, db, apiToken.TokenHash)
+	assert.ErrorIs(t, errRevoked, helper.ErrNotFound)
+}
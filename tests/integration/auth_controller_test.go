@@ -25,7 +25,7 @@ func TestAuthControllerInitialize(t *testing.T) {
 	defer db.Close()
 
 	userRepository := repository.NewUserRepository()
-	authService := service.NewAuthService(db, userRepository, validator.New())
+	authService := service.NewAuthService(db, userRepository, repository.NewMfaRepository(), validator.New(), testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
 	authController := controller.NewAuthController(authService)
 
 	assert.NotNil(t, authController)
@@ -47,7 +47,7 @@ func TestAuthControllerLogin(t *testing.T) {
 	recorder := httptest.NewRecorder()
 
 	userRepository := repository.NewUserRepository()
-	authService := service.NewAuthService(db, userRepository, validator.New())
+	authService := service.NewAuthService(db, userRepository, repository.NewMfaRepository(), validator.New(), testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
 	authController := controller.NewAuthController(authService)
 
 	params := httprouter.Params{}
@@ -20,7 +20,7 @@ func TestAuthServiceInitialize(t *testing.T) {
 	defer db.Close()
 
 	userRepository := repository.NewUserRepository()
-	authService := service.NewAuthService(db, userRepository, validator.New())
+	authService := service.NewAuthService(db, userRepository, repository.NewMfaRepository(), validator.New(), testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
 
 	assert.NotNil(t, authService)
 }
@@ -40,7 +40,7 @@ func TestAuthServiceLogin(t *testing.T) {
 	}
 
 	userRepository := repository.NewUserRepository()
-	authService := service.NewAuthService(db, userRepository, validator.New())
+	authService := service.NewAuthService(db, userRepository, repository.NewMfaRepository(), validator.New(), testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
 
 	userResponse, err := authService.Login(context.Background(), userLoginRequest)
 
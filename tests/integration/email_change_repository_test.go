@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	testhelper "go_todo_api/tests/test_helper"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailChangeRepositoryConfirmOnce(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	userId := int(testhelper.InsertSingleUser(db))
+
+	userRepository := repository.NewUserRepository()
+	emailChangeRepository := repository.NewEmailChangeRepository()
+
+	ctx := context.Background()
+
+	emailChange := entity.EmailChange{
+		UserId:           userId,
+		OldEmail:         "budi@example.xyz",
+		NewEmail:         "budi@example.com",
+		ConfirmTokenHash: helper.HashToken("confirm"),
+		RevertTokenHash:  helper.HashToken("revert"),
+	}
+
+	tx, errTxBegin := db.Begin()
+	assert.Nil(t, errTxBegin)
+	assert.Nil(t, emailChangeRepository.Insert(ctx, tx, emailChange, 24, 7))
+	assert.Nil(t, userRepository.UpdatePendingEmail(ctx, tx, userId, emailChange.NewEmail))
+	assert.Nil(t, tx.Commit())
+
+	user, errGetUser := userRepository.Get(ctx, db, userId)
+	assert.Nil(t, errGetUser)
+	assert.Equal(t, "budi@example.com", user.PendingEmail)
+
+	pendingEmailChange, errGetPending := emailChangeRepository.GetPendingByConfirmHash(ctx, db, helper.HashToken("confirm"))
+	assert.Nil(t, errGetPending)
+
+	tx, errTxBegin = db.Begin()
+	assert.Nil(t, errTxBegin)
+	assert.Nil(t, emailChangeRepository.MarkConfirmed(ctx, tx, pendingEmailChange.Id))
+	assert.Nil(t, userRepository.UpdateEmail(ctx, tx, userId, pendingEmailChange.NewEmail))
+	assert.Nil(t, tx.Commit())
+
+	_, errConfirmedTwice := emailChangeRepository.GetPendingByConfirmHash(ctx, db, helper.HashToken("confirm"))
+	assert.ErrorIs(t, errConfirmedTwice, helper.ErrNotFound)
+
+	confirmedEmailChange, errGetByRevert := emailChangeRepository.GetByRevertHash(ctx, db, helper.HashToken("revert"))
+	assert.Nil(t, errGetByRevert)
+	assert.NotEmpty(t, confirmedEmailChange.ConfirmedAt)
+}
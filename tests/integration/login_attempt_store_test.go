@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDbLoginAttemptStoreLifecycle(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	loginAttemptStore := repository.NewDbLoginAttemptStore(db)
+
+	ctx := context.Background()
+	now := time.Now()
+
+	defer loginAttemptStore.Reset(ctx, "username:integration")
+
+	lockAfterTwo := func(failures int) time.Duration {
+		if failures >= 2 {
+			return time.Minute
+		}
+		return 0
+	}
+
+	loginAttemptStore.Reserve(ctx, "username:integration", now, 15*time.Minute, lockAfterTwo)
+
+	loginAttempt, errReserve := loginAttemptStore.Reserve(ctx, "username:integration", now, 15*time.Minute, lockAfterTwo)
+	assert.Nil(t, errReserve)
+	assert.Equal(t, 2, loginAttempt.Failures)
+
+	_, errLocked := loginAttemptStore.Reserve(ctx, "username:integration", now, 15*time.Minute, lockAfterTwo)
+	assert.ErrorAs(t, errLocked, new(*helper.LoginThrottledError))
+
+	assert.Nil(t, loginAttemptStore.Release(ctx, "username:integration"))
+
+	loginAttempt, errGet := loginAttemptStore.Get(ctx, "username:integration")
+	assert.Nil(t, errGet)
+	assert.Equal(t, 1, loginAttempt.Failures)
+	assert.Equal(t, now.Add(time.Minute).Unix(), loginAttempt.LockedUntil)
+
+	assert.Nil(t, loginAttemptStore.Reset(ctx, "username:integration"))
+
+	_, errReset := loginAttemptStore.Get(ctx, "username:integration")
+	assert.ErrorIs(t, errReset, helper.ErrNotFound)
+}
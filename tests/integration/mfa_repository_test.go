@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	testhelper "go_todo_api/tests/test_helper"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMfaRepositoryTotpLifecycle(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	userId := int(testhelper.InsertSingleUser(db))
+
+	mfaRepository := repository.NewMfaRepository()
+
+	ctx := context.Background()
+
+	errSave := mfaRepository.SaveTotp(ctx, db, entity.UserTotp{UserId: userId, Secret: "JBSWY3DPEHPK3PXP"})
+	assert.Nil(t, errSave)
+
+	userTotp, errGet := mfaRepository.GetTotp(ctx, db, userId)
+	assert.Nil(t, errGet)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", userTotp.Secret)
+	assert.False(t, userTotp.IsEnabled)
+
+	tx, errTx := db.Begin()
+	assert.Nil(t, errTx)
+
+	assert.Nil(t, mfaRepository.EnableTotp(ctx, tx, userId))
+	assert.Nil(t, mfaRepository.InsertRecoveryCodes(ctx, tx, userId, []string{helper.HashToken("abcd-efgh")}))
+	assert.Nil(t, tx.Commit())
+
+	userTotp, _ = mfaRepository.GetTotp(ctx, db, userId)
+	assert.True(t, userTotp.IsEnabled)
+
+	assert.Nil(t, mfaRepository.UpdateTotpLastUsedStep(ctx, db, userId, 100))
+	assert.ErrorIs(t, mfaRepository.UpdateTotpLastUsedStep(ctx, db, userId, 100), helper.ErrRowsNotAffected)
+
+	assert.Nil(t, mfaRepository.UseRecoveryCode(ctx, db, userId, helper.HashToken("abcd-efgh")))
+	assert.ErrorIs(t, mfaRepository.UseRecoveryCode(ctx, db, userId, helper.HashToken("abcd-efgh")), helper.ErrRowsNotAffected)
+}
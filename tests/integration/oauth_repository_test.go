@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOauthRepositoryTokenLifecycle(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	oauthRepository := repository.NewOauthRepository()
+
+	ctx := context.Background()
+
+	clientId, _ := helper.GenerateOidcState()
+
+	_, errInsertClient := oauthRepository.InsertClient(ctx, db, entity.OauthClient{ClientId: clientId, Name: "Integration App", Scopes: "todos:read", GrantTypes: "client_credentials"})
+	assert.Nil(t, errInsertClient)
+
+	defer oauthRepository.DeleteClient(ctx, db, clientId)
+
+	accessToken, _ := helper.GenerateOauthToken(helper.OauthAccessTokenPrefix)
+
+	tx, errTxBegin := db.Begin()
+	assert.Nil(t, errTxBegin)
+
+	errInsertToken := oauthRepository.InsertToken(ctx, tx, entity.OauthToken{ClientId: clientId, Scopes: "todos:read", AccessTokenHash: helper.HashToken(accessToken)}, 3600, 30)
+	assert.Nil(t, errInsertToken)
+
+	assert.Nil(t, tx.Commit())
+
+	oauthToken, errGetToken := oauthRepository.GetActiveTokenByAccessHash(ctx, db, helper.HashToken(accessToken))
+	assert.Nil(t, errGetToken)
+	assert.Equal(t, 0, oauthToken.UserId)
+
+	tx, errTxBegin = db.Begin()
+	assert.Nil(t, errTxBegin)
+
+	assert.Nil(t, oauthRepository.RevokeToken(ctx, tx, oauthToken.Id))
+	assert.Nil(t, tx.Commit())
+
+	_, errRevoked := oauthRepository.GetActiveTokenByAccessHash(ctx, db, helper.HashToken(accessToken))
+	assert.ErrorIs(t, errRevoked, helper.ErrNotFound)
+}
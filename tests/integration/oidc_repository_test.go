@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOidcRepositoryCreateUserWithIdentity(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	userRepository := repository.NewUserRepository()
+	oidcRepository := repository.NewOidcRepository()
+
+	ctx := context.Background()
+
+	tx, errTxBegin := db.Begin()
+	assert.Nil(t, errTxBegin)
+
+	userId, errInsertUser := userRepository.InsertUser(ctx, tx, entity.User{Username: "athena", Password: "hash", Name: "Athena", Email: "athena@example.xyz"})
+	assert.Nil(t, errInsertUser)
+
+	errInsertIdentity := oidcRepository.InsertIdentity(ctx, tx, entity.UserIdentity{UserId: userId, Provider: "google", Subject: "1234", Email: "athena@example.xyz"})
+	assert.Nil(t, errInsertIdentity)
+
+	assert.Nil(t, tx.Commit())
+
+	userIdentity, errGetIdentity := oidcRepository.GetIdentity(ctx, db, "google", "1234")
+	assert.Nil(t, errGetIdentity)
+	assert.Equal(t, userId, userIdentity.UserId)
+
+	user, errGetUser := userRepository.GetByEmail(ctx, db, "athena@example.xyz")
+	assert.Nil(t, errGetUser)
+	assert.Equal(t, "", user.PhoneNumber)
+}
+
+func TestOidcRepositoryLoginStateIsConsumedOnce(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	oidcRepository := repository.NewOidcRepository()
+
+	ctx := context.Background()
+
+	state, _ := helper.GenerateOidcState()
+
+	errInsert := oidcRepository.InsertLoginState(ctx, db, entity.OidcLoginState{State: state, Provider: "google", Nonce: "nonce", CodeVerifier: "verifier"})
+	assert.Nil(t, errInsert)
+
+	loginState, errConsume := oidcRepository.ConsumeLoginState(ctx, db, state)
+	assert.Nil(t, errConsume)
+	assert.Equal(t, "google", loginState.Provider)
+
+	_, errConsumeAgain := oidcRepository.ConsumeLoginState(ctx, db, state)
+	assert.ErrorIs(t, errConsumeAgain, helper.ErrNotFound)
+}
@@ -1,22 +1,25 @@
 package integration
 
 import (
+	"database/sql"
 	"encoding/json"
 	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/model/response"
 	"go_todo_api/internal/repository"
 	"go_todo_api/internal/service"
 	testhelper "go_todo_api/tests/test_helper"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
+	"go_todo_api/internal/validator"
 )
 
 func TestTodoControllerInitialize(t *testing.T) {
@@ -27,8 +30,8 @@ func TestTodoControllerInitialize(t *testing.T) {
 	defer db.Close()
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
-	todoController := controller.NewTodoController(todoService)
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
+	todoController := controller.NewTodoController(todoService, controller.TodoControllerConfig{})
 
 	assert.NotNil(t, todoController)
 }
@@ -55,11 +58,12 @@ func TestTodoControllerCreate(t *testing.T) {
 	requestBody := strings.NewReader(string(jsonTodoCreateRequest))
 
 	request := httptest.NewRequest("POST", "http://localhost:8080/api/todo", requestBody)
+	request = asUser(request, int(userLastInsertId))
 	recorder := httptest.NewRecorder()
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
-	todoController := controller.NewTodoController(todoService)
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
+	todoController := controller.NewTodoController(todoService, controller.TodoControllerConfig{})
 
 	params := httprouter.Params{}
 
@@ -68,6 +72,8 @@ func TestTodoControllerCreate(t *testing.T) {
 	result := recorder.Result()
 
 	assert.Equal(t, 201, result.StatusCode)
+	assert.Regexp(t, "^/api/todo/[0-9]+$", result.Header.Get("Location"))
+	assert.Equal(t, `"1"`, result.Header.Get("ETag"))
 }
 
 func TestTodoControllerGetById(t *testing.T) {
@@ -80,11 +86,12 @@ func TestTodoControllerGetById(t *testing.T) {
 	todoLastInsertId := testhelper.InsertSingleTodo(db)
 
 	request := httptest.NewRequest("GET", "http://localhost:8080/api/todo/"+strconv.Itoa(int(todoLastInsertId)), nil)
+	request = asTodoOwner(db, request, todoLastInsertId)
 	recorder := httptest.NewRecorder()
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
-	todoController := controller.NewTodoController(todoService)
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
+	todoController := controller.NewTodoController(todoService, controller.TodoControllerConfig{})
 
 	params := httprouter.Params{
 		{
@@ -126,11 +133,12 @@ func TestTodoControllerUpdate(t *testing.T) {
 	}`)
 
 	request := httptest.NewRequest("PUT", "http://localhost:8080/api/todo/"+strconv.Itoa(int(todoLastInsertId)), requestBody)
+	request = asTodoOwner(db, request, todoLastInsertId)
 	recorder := httptest.NewRecorder()
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
-	todoController := controller.NewTodoController(todoService)
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
+	todoController := controller.NewTodoController(todoService, controller.TodoControllerConfig{})
 
 	params := httprouter.Params{
 		{
@@ -143,7 +151,8 @@ func TestTodoControllerUpdate(t *testing.T) {
 
 	result := recorder.Result()
 
-	assert.Equal(t, 204, result.StatusCode)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.NotEmpty(t, result.Header.Get("ETag"))
 }
 
 func TestTodoControllerUpdateTodoCompletion(t *testing.T) {
@@ -156,11 +165,12 @@ func TestTodoControllerUpdateTodoCompletion(t *testing.T) {
 	todoLastInsertId := testhelper.InsertSingleTodo(db)
 
 	request := httptest.NewRequest("PATCH", "http://localhost:8080/api/todo/completion/"+strconv.Itoa(int(todoLastInsertId)), nil)
+	request = asTodoOwner(db, request, todoLastInsertId)
 	recorder := httptest.NewRecorder()
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
-	todoController := controller.NewTodoController(todoService)
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
+	todoController := controller.NewTodoController(todoService, controller.TodoControllerConfig{})
 
 	params := httprouter.Params{
 		{
@@ -173,7 +183,8 @@ func TestTodoControllerUpdateTodoCompletion(t *testing.T) {
 
 	result := recorder.Result()
 
-	assert.Equal(t, 204, result.StatusCode)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.NotEmpty(t, result.Header.Get("ETag"))
 }
 
 func TestTodoControllerRemove(t *testing.T) {
@@ -186,11 +197,12 @@ func TestTodoControllerRemove(t *testing.T) {
 	todoLastInsertId := testhelper.InsertSingleTodo(db)
 
 	request := httptest.NewRequest("DELETE", "http://localhost:8080/api/todo/"+strconv.Itoa(int(todoLastInsertId)), nil)
+	request = asTodoOwner(db, request, todoLastInsertId)
 	recorder := httptest.NewRecorder()
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
-	todoController := controller.NewTodoController(todoService)
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
+	todoController := controller.NewTodoController(todoService, controller.TodoControllerConfig{})
 
 	params := httprouter.Params{
 		{
@@ -205,3 +217,19 @@ func TestTodoControllerRemove(t *testing.T) {
 
 	assert.Equal(t, 204, result.StatusCode)
 }
+
+func asUser(request *http.Request, userId int) *http.Request {
+	principal := helper.Principal{UserId: userId, AuthMethod: helper.AuthMethodSession, Scopes: helper.AllScopes, Roles: []string{helper.RoleUser}}
+
+	return request.WithContext(helper.SetPrincipal(request.Context(), principal))
+}
+
+func asTodoOwner(db *sql.DB, request *http.Request, todoId int64) *http.Request {
+	userId := 0
+
+	if err := db.QueryRow("SELECT user_id FROM todos WHERE id = ?", todoId).Scan(&userId); err != nil {
+		panic(err)
+	}
+
+	return asUser(request, userId)
+}
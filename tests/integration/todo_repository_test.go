@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/repository"
 	testhelper "go_todo_api/tests/test_helper"
@@ -49,15 +50,28 @@ func TestTodoRepositoryInsert(t *testing.T) {
 
 	todoRepository := repository.NewTodoRepository()
 
-	todoCreateRequest := request.TodoCreateRequest{
+	todo := entity.Todo{
+		Uuid:        "018e1c2a-7b3c-7d4e-8f90-123456789abc",
 		UserId:      int(userLastInsertId),
 		Title:       "todo test",
 		Description: "todo single insertion test",
+		ChangeSeq:   1,
 	}
 
-	err := todoRepository.Insert(context.Background(), db, todoCreateRequest)
+	tx, errBegin := db.Begin()
+
+	assert.Nil(t, errBegin)
+
+	todoId, err := todoRepository.Insert(context.Background(), tx, todo)
 
 	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+
+	insertedTodo, errGet := todoRepository.GetByUuid(context.Background(), db, todo.UserId, todo.Uuid)
+
+	assert.Nil(t, errGet)
+	assert.Equal(t, todoId, insertedTodo.Id)
+	assert.Equal(t, int64(1), insertedTodo.ChangeSeq)
 }
 
 func TestTodoRepositoryUpdate(t *testing.T) {
@@ -78,9 +92,14 @@ func TestTodoRepositoryUpdate(t *testing.T) {
 		IsDone:      true,
 	}
 
-	err := todoRepository.Update(context.Background(), db, todoUpdateRequest)
+	tx, errBegin := db.Begin()
+
+	assert.Nil(t, errBegin)
+
+	err := todoRepository.Update(context.Background(), tx, todoUpdateRequest, 2)
 
 	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
 }
 
 func TestTodoRepositoryDelete(t *testing.T) {
@@ -94,7 +113,12 @@ func TestTodoRepositoryDelete(t *testing.T) {
 
 	todoRepository := repository.NewTodoRepository()
 
-	err := todoRepository.Delete(context.Background(), db, int(todoLastInsertId))
+	tx, errBegin := db.Begin()
+
+	assert.Nil(t, errBegin)
+
+	err := todoRepository.Delete(context.Background(), tx, int(todoLastInsertId), 1)
 
 	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
 }
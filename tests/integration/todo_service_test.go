@@ -2,14 +2,15 @@ package integration
 
 import (
 	"context"
+	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/repository"
 	"go_todo_api/internal/service"
 	testhelper "go_todo_api/tests/test_helper"
 	"testing"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
+	"go_todo_api/internal/validator"
 )
 
 func TestTodoServiceInitialize(t *testing.T) {
@@ -20,7 +21,7 @@ func TestTodoServiceInitialize(t *testing.T) {
 	defer db.Close()
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
 
 	assert.NotNil(t, todoService)
 }
@@ -41,11 +42,42 @@ func TestTodoServiceCreate(t *testing.T) {
 	}
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
 
-	err := todoService.Create(context.Background(), todoCreateRequest)
+	todoResponse, err := todoService.Create(context.Background(), todoCreateRequest)
 
 	assert.Nil(t, err)
+	assert.NotZero(t, todoResponse.Id)
+	assert.NotEmpty(t, todoResponse.Uuid)
+	assert.Equal(t, 1, todoResponse.Version)
+}
+
+func TestTodoServiceCreateWithClientId(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	userLastInsertId := testhelper.InsertSingleUser(db)
+
+	todoCreateRequest := request.TodoCreateRequest{
+		Id:     "018E1C2A-7B3C-7D4E-8F90-123456789ABC",
+		UserId: int(userLastInsertId),
+		Title:  "todo created offline",
+	}
+
+	todoRepository := repository.NewTodoRepository()
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
+
+	todoResponse, err := todoService.Create(context.Background(), todoCreateRequest)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "018e1c2a-7b3c-7d4e-8f90-123456789abc", todoResponse.Uuid)
+
+	_, errDuplicate := todoService.Create(context.Background(), todoCreateRequest)
+
+	assert.ErrorIs(t, errDuplicate, helper.ErrConflict)
 }
 
 func TestTodoServiceFindById(t *testing.T) {
@@ -58,7 +90,7 @@ func TestTodoServiceFindById(t *testing.T) {
 	todoLastInsertid := testhelper.InsertSingleTodo(db)
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
 
 	todoResponse, err := todoService.Find(context.Background(), int(todoLastInsertid))
 
@@ -83,11 +115,12 @@ func TestTodoServiceUpdate(t *testing.T) {
 	}
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
 
-	err := todoService.Update(context.Background(), todoUpdateRequest)
+	todoResponse, err := todoService.Update(context.Background(), todoUpdateRequest)
 
 	assert.Nil(t, err)
+	assert.Equal(t, "Update Todo", todoResponse.Title)
 }
 
 func TestTodoServiceUpdateTodoCompletion(t *testing.T) {
@@ -100,11 +133,12 @@ func TestTodoServiceUpdateTodoCompletion(t *testing.T) {
 	todoLastInserId := testhelper.InsertSingleTodo(db)
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
 
-	err := todoService.UpdateTodoCompletion(context.Background(), int(todoLastInserId))
+	todoResponse, err := todoService.UpdateTodoCompletion(context.Background(), int(todoLastInserId), 0)
 
 	assert.Nil(t, err)
+	assert.True(t, todoResponse.IsDone)
 }
 
 func TestTodoServiceRemove(t *testing.T) {
@@ -117,9 +151,9 @@ func TestTodoServiceRemove(t *testing.T) {
 	todoLastInserId := testhelper.InsertSingleTodo(db)
 
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, validator.New())
+	todoService := service.NewTodoService(db, todoRepository, repository.NewSyncRepository(), service.NewOutbox(db, repository.NewOutboxRepository(), nil, nil, service.DefaultOutboxConfig()), validator.NewValidator())
 
-	err := todoService.Remove(context.Background(), int(todoLastInserId))
+	err := todoService.Remove(context.Background(), int(todoLastInserId), 1)
 
 	assert.Nil(t, err)
 }
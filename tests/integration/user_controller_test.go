@@ -7,6 +7,7 @@ import (
 	"go_todo_api/internal/model/response"
 	"go_todo_api/internal/repository"
 	"go_todo_api/internal/service"
+	validator "go_todo_api/internal/validator"
 	testhelper "go_todo_api/tests/test_helper"
 	"io"
 	"net/http/httptest"
@@ -14,7 +15,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,7 +25,7 @@ func TestUserControllerInitialize(t *testing.T) {
 	assert.Nil(t, errDbConn)
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 	userController := controller.NewUserController(userService)
 
 	assert.NotNil(t, userController)
@@ -42,7 +42,7 @@ func TestUserControllerCreate(t *testing.T) {
     	"password": "rahasia",
     	"name": "Budi",
 		"email": "budi@example.xyz",
-		"phone_number": "0375812938"
+		"phone_number": "081375812938"
 	}
 	`)
 
@@ -52,7 +52,7 @@ func TestUserControllerCreate(t *testing.T) {
 	recorder := httptest.NewRecorder()
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 	userController := controller.NewUserController(userService)
 
 	userController.CreateUser(recorder, request, params)
@@ -80,7 +80,7 @@ func TestUserControllerGetById(t *testing.T) {
 	recorder := httptest.NewRecorder()
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 	userController := controller.NewUserController(userService)
 
 	userController.Get(recorder, request, params)
@@ -111,7 +111,7 @@ func TestUserControllerUpdate(t *testing.T) {
 		"username": "budiman",
 		"name": "Budiman",
 		"email": "budiman@example.xyz",
-		"phone_number": "0512345"
+		"phone_number": "081251234567"
 	}`)
 
 	request := httptest.NewRequest("PUT", "http://localhost:8080/api/user/"+strconv.Itoa(int(userLastInsertId)), jsonRequest)
@@ -125,7 +125,7 @@ func TestUserControllerUpdate(t *testing.T) {
 	recorder := httptest.NewRecorder()
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 	userController := controller.NewUserController(userService)
 
 	userController.Update(recorder, request, params)
@@ -153,7 +153,7 @@ func TestUserControllerRemove(t *testing.T) {
 	recorder := httptest.NewRecorder()
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 	userController := controller.NewUserController(userService)
 
 	userController.Remove(recorder, request, params)
@@ -130,3 +130,28 @@ func TestUserRepositoryDelete(t *testing.T) {
 
 	assert.Nil(t, err)
 }
+
+func TestUserRepositorySearch(t *testing.T) {
+	db, errDbConn := setupDb()
+
+	assert.Nil(t, errDbConn)
+
+	defer db.Close()
+
+	testhelper.InsertSingleUser(db)
+
+	userRepository := repository.NewUserRepository()
+
+	ctx := context.Background()
+
+	users, errSearch := userRepository.Search(ctx, db, "bud", 20, 0)
+
+	assert.Nil(t, errSearch)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "user", users[0].Role)
+
+	total, errCount := userRepository.CountSearch(ctx, db, "nobody")
+
+	assert.Nil(t, errCount)
+	assert.Equal(t, 0, total)
+}
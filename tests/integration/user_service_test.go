@@ -6,10 +6,10 @@ import (
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/repository"
 	"go_todo_api/internal/service"
+	validator "go_todo_api/internal/validator"
 	testhelper "go_todo_api/tests/test_helper"
 	"testing"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,7 +19,7 @@ func TestUserServiceInitialize(t *testing.T) {
 	assert.Nil(t, errDbConn)
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 
 	assert.NotNil(t, userService)
 }
@@ -34,11 +34,11 @@ func TestUserServiceCreate(t *testing.T) {
 		Password:    "rahasia",
 		Name:        "Budi",
 		Email:       "budi@example.xyz",
-		PhoneNumber: "08767890123",
+		PhoneNumber: "087767890123",
 	}
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 
 	err := userService.Create(context.Background(), userCreateRequest)
 
@@ -53,7 +53,7 @@ func TestUserServiceFindById(t *testing.T) {
 	userLastInsertId := testhelper.InsertSingleUser(db)
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 
 	user, err := userService.Find(context.Background(), int(userLastInsertId))
 
@@ -74,11 +74,11 @@ func TestUserServiceUpdate(t *testing.T) {
 		Username:    "budiman",
 		Name:        "Budiman",
 		Email:       "budiman@example.xyz",
-		PhoneNumber: "0512345",
+		PhoneNumber: "081251234567",
 	}
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 
 	err := userService.Update(context.Background(), userUpdateRequest)
 
@@ -93,7 +93,7 @@ func TestUserServiceRemove(t *testing.T) {
 	userLastInsertId := testhelper.InsertSingleUser(db)
 
 	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(db, userRepository, validator.New(), helper.HashFunction())
+	userService := service.NewUserService(db, userRepository, validator.NewValidator(), helper.HashFunction(), repository.NewEmailChangeRepository(), helper.NewLogMailer(), service.EmailChangeConfig{})
 
 	err := userService.Remove(context.Background(), int(userLastInsertId))
 
@@ -0,0 +1,201 @@
+package testhelper
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"go_todo_api/internal/helper"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MockOidcProvider is a local OpenID Connect provider that consents to every
+// authorization request and signs ID tokens carrying Claims.
+type MockOidcProvider struct {
+	Server       *httptest.Server
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	Claims       jwt.MapClaims
+
+	mutex      sync.Mutex
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	grants     map[string]mockOidcGrant
+}
+
+type mockOidcGrant struct {
+	redirectUri   string
+	nonce         string
+	codeChallenge string
+}
+
+func NewMockOidcProvider(clientId string, clientSecret string) *MockOidcProvider {
+	publicKey, privateKey, errGenerateKey := ed25519.GenerateKey(nil)
+
+	if errGenerateKey != nil {
+		panic(errGenerateKey)
+	}
+
+	mockProvider := &MockOidcProvider{
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		Claims:       jwt.MapClaims{},
+		privateKey:   privateKey,
+		publicKey:    publicKey,
+		grants:       map[string]mockOidcGrant{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", mockProvider.discovery)
+	mux.HandleFunc("/authorize", mockProvider.authorize)
+	mux.HandleFunc("/token", mockProvider.token)
+	mux.HandleFunc("/jwks", mockProvider.jwks)
+
+	mockProvider.Server = httptest.NewServer(mux)
+	mockProvider.Issuer = mockProvider.Server.URL
+
+	return mockProvider
+}
+
+func (mockProvider *MockOidcProvider) Close() {
+	mockProvider.Server.Close()
+}
+
+func (mockProvider *MockOidcProvider) Config(name string, redirectUrl string) helper.OidcProviderConfig {
+	return helper.OidcProviderConfig{
+		Name:         name,
+		Issuer:       mockProvider.Issuer,
+		ClientId:     mockProvider.ClientId,
+		ClientSecret: mockProvider.ClientSecret,
+		RedirectUrl:  redirectUrl,
+	}
+}
+
+// Authorize plays the user's browser: it follows authUrl and returns the query
+// the provider redirected back with, holding the code and state.
+func (mockProvider *MockOidcProvider) Authorize(authUrl string) (url.Values, error) {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	httpResponse, errGet := client.Get(authUrl)
+
+	if errGet != nil {
+		return nil, errGet
+	}
+
+	defer httpResponse.Body.Close()
+
+	location, errLocation := httpResponse.Location()
+
+	if errLocation != nil {
+		return nil, errLocation
+	}
+
+	return location.Query(), nil
+}
+
+func (mockProvider *MockOidcProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 mockProvider.Issuer,
+		"authorization_endpoint": mockProvider.Issuer + "/authorize",
+		"token_endpoint":         mockProvider.Issuer + "/token",
+		"jwks_uri":               mockProvider.Issuer + "/jwks",
+	})
+}
+
+func (mockProvider *MockOidcProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("client_id") != mockProvider.ClientId || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+
+	mockProvider.mutex.Lock()
+	mockProvider.grants[code] = mockOidcGrant{
+		redirectUri:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	mockProvider.mutex.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (mockProvider *MockOidcProvider) token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	code := r.PostFormValue("code")
+
+	mockProvider.mutex.Lock()
+	grant, ok := mockProvider.grants[code]
+	delete(mockProvider.grants, code)
+	mockProvider.mutex.Unlock()
+
+	validClient := r.PostFormValue("client_id") == mockProvider.ClientId && r.PostFormValue("client_secret") == mockProvider.ClientSecret
+
+	if !ok || !validClient || r.PostFormValue("redirect_uri") != grant.redirectUri || helper.PkceChallenge(r.PostFormValue("code_verifier")) != grant.codeChallenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   mockProvider.Issuer,
+		"aud":   mockProvider.ClientId,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": grant.nonce,
+	}
+
+	for key, value := range mockProvider.Claims {
+		claims[key] = value
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	idToken.Header["kid"] = "mock-key"
+
+	idTokenStr, errSign := idToken.SignedString(mockProvider.privateKey)
+
+	if errSign != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"access_token": randomString(), "token_type": "Bearer", "id_token": idTokenStr})
+}
+
+func (mockProvider *MockOidcProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"kid": "mock-key",
+			"use": "sig",
+			"alg": "EdDSA",
+			"x":   base64.RawURLEncoding.EncodeToString(mockProvider.publicKey),
+		}},
+	})
+}
+
+func randomString() string {
+	randomBytes := make([]byte, 16)
+	rand.Read(randomBytes)
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
@@ -1,12 +1,20 @@
 package testhelper
 
 import (
+	"crypto/ed25519"
 	"database/sql"
 	"go_todo_api/internal/helper"
 	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func ResetDB(testDb *sql.DB) {
+	testDb.Exec("DELETE FROM jobs")
+	testDb.Exec("DELETE FROM outbox")
+	testDb.Exec("DELETE FROM reminders")
+	testDb.Exec("DELETE FROM push_subscriptions")
+	testDb.Exec("DELETE FROM notification_settings")
 	testDb.Exec("DELETE FROM todos")
 	testDb.Exec("DELETE FROM users")
 }
@@ -92,3 +100,22 @@ func InsertManyTodo(testDb *sql.DB, count int) {
 		}
 	}
 }
+
+// NewJwtKeySet returns a key set backed by a fresh Ed25519 key, so tests don't need config.env.
+func NewJwtKeySet(kid string) *helper.JwtKeySet {
+	publicKey, privateKey, errGenerateKey := ed25519.GenerateKey(nil)
+
+	if errGenerateKey != nil {
+		panic(errGenerateKey)
+	}
+
+	jwtKey := helper.JwtKey{Kid: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: privateKey, PublicKey: publicKey}
+
+	jwtKeySet, errKeySet := helper.NewJwtKeySet(kid, nil, jwtKey)
+
+	if errKeySet != nil {
+		panic(errKeySet)
+	}
+
+	return jwtKeySet
+}
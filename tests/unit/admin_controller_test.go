@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type AdminServiceMock struct {
+	mock.Mock
+}
+
+func (mock *AdminServiceMock) FindUsers(ctx context.Context, userSearchRequest request.UserSearchRequest) (response.PageResponse, error) {
+	args := mock.Called(ctx, userSearchRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.PageResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.PageResponse), nil
+}
+
+func (mock *AdminServiceMock) DisableUser(ctx context.Context, adminUserId int, userId int) error {
+	args := mock.Called(ctx, adminUserId, userId)
+	return args.Error(0)
+}
+
+func (mock *AdminServiceMock) EnableUser(ctx context.Context, userId int) error {
+	args := mock.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (mock *AdminServiceMock) ForceLogout(ctx context.Context, userId int) error {
+	args := mock.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (mock *AdminServiceMock) ResetPassword(ctx context.Context, passwordResetRequest request.PasswordResetRequest) error {
+	args := mock.Called(ctx, passwordResetRequest)
+	return args.Error(0)
+}
+
+func (mock *AdminServiceMock) UnlockUser(ctx context.Context, userId int) error {
+	args := mock.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func TestAdminControllerFindUsers(t *testing.T) {
+	userSearchRequest := request.UserSearchRequest{Query: "bu", Page: 2, PerPage: 20}
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/admin/users?q=bu&page=2", nil)
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	adminServiceMock := new(AdminServiceMock)
+	adminController := controller.NewAdminController(adminServiceMock)
+
+	pageResponse := response.PageResponse{
+		Items:   []response.UserResponse{{Id: 2, Username: "budi"}},
+		Page:    2,
+		PerPage: 20,
+		Total:   21,
+	}
+
+	adminServiceMock.On("FindUsers", request.Context(), userSearchRequest).Return(pageResponse, nil)
+
+	adminController.FindUsers(recorder, request, params)
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	page := standardResposne.Data.(map[string]any)
+
+	assert.Equal(t, float64(21), page["total"])
+	assert.Len(t, page["items"], 1)
+}
+
+func TestAdminControllerDisableUser(t *testing.T) {
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/admin/users/2/disable", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{{Key: "userId", Value: "2"}}
+
+	recorder := httptest.NewRecorder()
+
+	adminServiceMock := new(AdminServiceMock)
+	adminController := controller.NewAdminController(adminServiceMock)
+
+	adminServiceMock.On("DisableUser", request.Context(), 1, 2).Return(nil)
+
+	adminController.DisableUser(recorder, request, params)
+
+	assert.Equal(t, 204, recorder.Result().StatusCode)
+}
+
+func TestAdminControllerResetPassword(t *testing.T) {
+	passwordResetRequest := request.PasswordResetRequest{UserId: 2, Password: "new-secret"}
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/admin/users/2/password", strings.NewReader(`{"password": "new-secret"}`))
+	params := httprouter.Params{{Key: "userId", Value: "2"}}
+
+	recorder := httptest.NewRecorder()
+
+	adminServiceMock := new(AdminServiceMock)
+	adminController := controller.NewAdminController(adminServiceMock)
+
+	adminServiceMock.On("ResetPassword", request.Context(), passwordResetRequest).Return(nil)
+
+	adminController.ResetPassword(recorder, request, params)
+
+	assert.Equal(t, 204, recorder.Result().StatusCode)
+}
+
+func TestAdminControllerUnlockUser(t *testing.T) {
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/admin/users/2/unlock", nil)
+	params := httprouter.Params{{Key: "userId", Value: "2"}}
+
+	recorder := httptest.NewRecorder()
+
+	adminServiceMock := new(AdminServiceMock)
+	adminController := controller.NewAdminController(adminServiceMock)
+
+	adminServiceMock.On("UnlockUser", request.Context(), 2).Return(nil)
+
+	adminController.UnlockUser(recorder, request, params)
+
+	assert.Equal(t, 204, recorder.Result().StatusCode)
+}
@@ -0,0 +1,166 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/repository"
+	"go_todo_api/internal/service"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminServiceFindUsers(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	adminService := service.NewAdminService(db, userRepositoryMock, new(OauthRepositoryMock), validatorMock, hashPasswordMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	ctx := context.Background()
+	userSearchRequest := request.UserSearchRequest{Query: "bu", Page: 3, PerPage: 10}
+
+	users := []entity.User{
+		{Id: 21, Username: "budi", Name: "Budi", Role: helper.RoleUser},
+		{Id: 22, Username: "bunga", Name: "Bunga", Role: helper.RoleAdmin, IsDisabled: true},
+	}
+
+	validatorMock.On("StructCtx", ctx, userSearchRequest).Return(nil)
+	userRepositoryMock.On("Search", ctx, db, "bu", 10, 20).Return(users, nil)
+	userRepositoryMock.On("CountSearch", ctx, db, "bu").Return(22, nil)
+
+	pageResponse, err := adminService.FindUsers(ctx, userSearchRequest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pageResponse.Page)
+	assert.Equal(t, 10, pageResponse.PerPage)
+	assert.Equal(t, 22, pageResponse.Total)
+
+	userResponses := pageResponse.Items.([]response.UserResponse)
+
+	assert.Len(t, userResponses, 2)
+	assert.Equal(t, "bunga", userResponses[1].Username)
+	assert.True(t, userResponses[1].IsDisabled)
+}
+
+func TestAdminServiceDisableUser(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	adminService := service.NewAdminService(db, userRepositoryMock, oauthRepositoryMock, validatorMock, hashPasswordMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	ctx := context.Background()
+
+	userRepositoryMock.On("Get", ctx, db, 2).Return(entity.User{Id: 2}, nil)
+	userRepositoryMock.On("UpdateDisabled", ctx, db, 2, true).Return(nil)
+	userRepositoryMock.On("IncrementTokenVersion", ctx, db, 2).Return(nil)
+	oauthRepositoryMock.On("RevokeUserTokens", ctx, db, 2).Return(nil)
+
+	err := adminService.DisableUser(ctx, 1, 2)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertExpectations(t)
+	oauthRepositoryMock.AssertExpectations(t)
+
+	errSelf := adminService.DisableUser(ctx, 1, 1)
+
+	assert.ErrorIs(t, errSelf, helper.ErrForbidden)
+
+	userRepositoryMock.On("Get", ctx, db, 3).Return(entity.User{}, helper.ErrNotFound)
+
+	errNotFound := adminService.DisableUser(ctx, 1, 3)
+
+	assert.ErrorIs(t, errNotFound, helper.ErrNotFound)
+}
+
+func TestAdminServiceResetPassword(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	adminService := service.NewAdminService(db, userRepositoryMock, oauthRepositoryMock, validatorMock, hashPasswordMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	ctx := context.Background()
+	passwordResetRequest := request.PasswordResetRequest{UserId: 2, Password: "new-secret"}
+
+	validatorMock.On("StructCtx", ctx, passwordResetRequest).Return(nil)
+	userRepositoryMock.On("Get", ctx, db, 2).Return(entity.User{Id: 2}, nil)
+	userRepositoryMock.On("UpdatePassword", ctx, db, 2, "new-secret").Return(nil)
+	userRepositoryMock.On("IncrementTokenVersion", ctx, db, 2).Return(nil)
+	oauthRepositoryMock.On("RevokeUserTokens", ctx, db, 2).Return(nil)
+
+	err := adminService.ResetPassword(ctx, passwordResetRequest)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertExpectations(t)
+	oauthRepositoryMock.AssertExpectations(t)
+}
+
+func TestAdminServiceForceLogout(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	adminService := service.NewAdminService(db, userRepositoryMock, oauthRepositoryMock, validatorMock, hashPasswordMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	ctx := context.Background()
+
+	userRepositoryMock.On("Get", ctx, db, 2).Return(entity.User{Id: 2}, nil)
+	userRepositoryMock.On("IncrementTokenVersion", ctx, db, 2).Return(nil)
+	oauthRepositoryMock.On("RevokeUserTokens", ctx, db, 2).Return(nil)
+
+	err := adminService.ForceLogout(ctx, 2)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertExpectations(t)
+	oauthRepositoryMock.AssertExpectations(t)
+}
+
+func TestAdminServiceUnlockUser(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	loginThrottleConfig := service.DefaultLoginThrottleConfig()
+	loginThrottleConfig.Username.LockoutAfter = 1
+
+	loginThrottleService := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), loginThrottleConfig)
+
+	userRepositoryMock := new(UserRepositoryMock)
+	adminService := service.NewAdminService(db, userRepositoryMock, new(OauthRepositoryMock), validatorMock, hashPasswordMock, loginThrottleService)
+
+	ctx := context.Background()
+	userRepositoryMock.On("Get", ctx, db, 2).Return(entity.User{Id: 2, Username: "budi", Email: "budi@example.xyz"}, nil)
+
+	assert.NoError(t, loginThrottleService.Reserve(ctx, "budi", ""))
+	assert.NoError(t, loginThrottleService.Reserve(ctx, "budi@example.xyz", ""))
+	assert.Error(t, loginThrottleService.Reserve(ctx, "budi", ""))
+
+	err := adminService.UnlockUser(ctx, 2)
+
+	assert.NoError(t, err)
+	assert.NoError(t, loginThrottleService.Reserve(ctx, "budi", ""))
+	assert.NoError(t, loginThrottleService.Reserve(ctx, "budi@example.xyz", ""))
+}
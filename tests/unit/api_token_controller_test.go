@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ApiTokenServiceMock struct {
+	mock.Mock
+}
+
+func (mock *ApiTokenServiceMock) Create(ctx context.Context, apiTokenCreateRequest request.ApiTokenCreateRequest) (response.ApiTokenCreateResponse, error) {
+	args := mock.Called(ctx, apiTokenCreateRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.ApiTokenCreateResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.ApiTokenCreateResponse), nil
+}
+
+func (mock *ApiTokenServiceMock) FindUserTokens(ctx context.Context, username string) ([]response.ApiTokenResponse, error) {
+	args := mock.Called(ctx, username)
+
+	if args.Get(1) != nil {
+		return nil, args.Get(1).(error)
+	}
+
+	return args.Get(0).([]response.ApiTokenResponse), nil
+}
+
+func (mock *ApiTokenServiceMock) Revoke(ctx context.Context, username string, tokenId int) error {
+	args := mock.Called(ctx, username, tokenId)
+	return args.Error(0)
+}
+
+func (mock *ApiTokenServiceMock) Authenticate(ctx context.Context, token string) (helper.Principal, error) {
+	args := mock.Called(ctx, token)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(helper.Principal), args.Get(1).(error)
+	}
+
+	return args.Get(0).(helper.Principal), nil
+}
+
+func TestApiTokenControllerCreate(t *testing.T) {
+	apiTokenCreateRequest := request.ApiTokenCreateRequest{
+		Username:      "apollo",
+		Name:          "ci",
+		Scopes:        []string{"todos:read"},
+		ExpiresInDays: 30,
+	}
+
+	jsonRequest := strings.NewReader(`{"name": "ci", "scopes": ["todos:read"], "expires_in_days": 30}`)
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/me/tokens", jsonRequest)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	apiTokenServiceMock := new(ApiTokenServiceMock)
+	apiTokenController := controller.NewApiTokenController(apiTokenServiceMock)
+
+	apiTokenCreateResponse := response.ApiTokenCreateResponse{
+		ApiTokenResponse: response.ApiTokenResponse{Id: 1, Name: "ci", Scopes: []string{"todos:read"}},
+		Token:            "gta_unittest",
+	}
+
+	apiTokenServiceMock.On("Create", request.Context(), apiTokenCreateRequest).Return(apiTokenCreateResponse, nil)
+
+	apiTokenController.Create(recorder, request, params)
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 201, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	apiToken := standardResposne.Data.(map[string]any)
+
+	assert.Equal(t, float64(1), apiToken["id"])
+	assert.Equal(t, "gta_unittest", apiToken["token"])
+}
+
+func TestApiTokenControllerGetUserTokens(t *testing.T) {
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/me/tokens", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	apiTokenServiceMock := new(ApiTokenServiceMock)
+	apiTokenController := controller.NewApiTokenController(apiTokenServiceMock)
+
+	apiTokenResponses := []response.ApiTokenResponse{
+		{Id: 1, Name: "ci", Scopes: []string{"todos:read"}, LastUsedAt: "2024-01-02 10:00:00"},
+		{Id: 2, Name: "cli", Scopes: []string{"todos:write"}},
+	}
+
+	apiTokenServiceMock.On("FindUserTokens", request.Context(), "apollo").Return(apiTokenResponses, nil)
+
+	apiTokenController.GetUserTokens(recorder, request, params)
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	apiTokens := standardResposne.Data.([]any)
+
+	assert.Len(t, apiTokens, 2)
+	assert.Nil(t, apiTokens[0].(map[string]any)["token"])
+	assert.Equal(t, "2024-01-02 10:00:00", apiTokens[0].(map[string]any)["last_used_at"])
+}
+
+func TestApiTokenControllerRevoke(t *testing.T) {
+	request := httptest.NewRequest("DELETE", "http://localhost:8080/api/me/tokens/1", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{
+		{
+			Key:   "tokenId",
+			Value: "1",
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+
+	apiTokenServiceMock := new(ApiTokenServiceMock)
+	apiTokenController := controller.NewApiTokenController(apiTokenServiceMock)
+
+	apiTokenServiceMock.On("Revoke", request.Context(), "apollo", 1).Return(nil)
+
+	apiTokenController.Revoke(recorder, request, params)
+
+	assert.Equal(t, 204, recorder.Result().StatusCode)
+}
@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var apiTokenRepository = repository.NewApiTokenRepository()
+
+var apiTokenColumns = []string{"id", "user_id", "username", "locale", "name", "token_hash", "scopes", "expires_at", "last_used_at", "created_at"}
+
+func TestApiTokenRepositoryGetActiveByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(apiTokenColumns).AddRow(1, 1, "budi", "id", "ci", "hash", "todos:read todos:write", nil, nil, "2024-01-01 10:00:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM api_tokens JOIN users").ExpectQuery().WithArgs("hash").WillReturnRows(rows)
+
+	apiToken, errGetToken := apiTokenRepository.GetActiveByHash(context.Background(), db, "hash")
+
+	assert.NoError(t, errGetToken)
+	assert.Equal(t, 1, apiToken.Id)
+	assert.Equal(t, "budi", apiToken.Username)
+	assert.Equal(t, "id", apiToken.Locale)
+	assert.Equal(t, "todos:read todos:write", apiToken.Scopes)
+	assert.Equal(t, "", apiToken.ExpiresAt)
+	assert.Equal(t, "", apiToken.LastUsedAt)
+
+	mock.ExpectPrepare("SELECT (.+) FROM api_tokens JOIN users").ExpectQuery().WithArgs("unknown").WillReturnRows(sqlmock.NewRows(apiTokenColumns))
+
+	_, errNotFound := apiTokenRepository.GetActiveByHash(context.Background(), db, "unknown")
+
+	assert.ErrorIs(t, errNotFound, helper.ErrNotFound)
+}
+
+func TestApiTokenRepositoryGetUserTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(apiTokenColumns).
+		AddRow(1, 1, "budi", nil, "ci", "hash1", "todos:read", "2024-02-01 10:00:00", "2024-01-02 10:00:00", "2024-01-01 10:00:00").
+		AddRow(2, 1, "budi", nil, "cli", "hash2", "todos:write", nil, nil, "2024-01-01 10:00:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM api_tokens JOIN users").ExpectQuery().WithArgs(1).WillReturnRows(rows)
+
+	apiTokens, errGetTokens := apiTokenRepository.GetUserTokens(context.Background(), db, 1)
+
+	assert.NoError(t, errGetTokens)
+	assert.Len(t, apiTokens, 2)
+	assert.Equal(t, "2024-02-01 10:00:00", apiTokens[0].ExpiresAt)
+	assert.Equal(t, "2024-01-02 10:00:00", apiTokens[0].LastUsedAt)
+	assert.Equal(t, "cli", apiTokens[1].Name)
+}
+
+func TestApiTokenRepositoryInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	apiToken := entity.ApiToken{UserId: 1, Name: "ci", TokenHash: "hash", Scopes: "todos:read"}
+
+	mock.ExpectPrepare("INSERT INTO api_tokens").ExpectExec().WithArgs(1, "ci", "hash", "todos:read", 30, 30).WillReturnResult(sqlmock.NewResult(7, 1))
+
+	tokenId, errInsert := apiTokenRepository.Insert(context.Background(), db, apiToken, 30)
+
+	assert.NoError(t, errInsert)
+	assert.Equal(t, 7, tokenId)
+}
+
+func TestApiTokenRepositoryRevoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE api_tokens SET revoked_at").ExpectExec().WithArgs(7, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errRevoke := apiTokenRepository.Revoke(context.Background(), db, 1, 7)
+
+	assert.NoError(t, errRevoke)
+
+	mock.ExpectPrepare("UPDATE api_tokens SET revoked_at").ExpectExec().WithArgs(8, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	errNotOwned := apiTokenRepository.Revoke(context.Background(), db, 1, 8)
+
+	assert.ErrorIs(t, errNotOwned, helper.ErrRowsNotAffected)
+}
@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ApiTokenRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *ApiTokenRepositoryMock) GetActiveByHash(ctx context.Context, db *sql.DB, tokenHash string) (entity.ApiToken, error) {
+	args := mock.Called(ctx, db, tokenHash)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.ApiToken), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.ApiToken), nil
+}
+
+func (mock *ApiTokenRepositoryMock) GetUserTokens(ctx context.Context, db *sql.DB, userId int) ([]entity.ApiToken, error) {
+	args := mock.Called(ctx, db, userId)
+
+	if args.Get(1) != nil {
+		return nil, args.Get(1).(error)
+	}
+
+	return args.Get(0).([]entity.ApiToken), nil
+}
+
+func (mock *ApiTokenRepositoryMock) Get(ctx context.Context, db *sql.DB, tokenId int) (entity.ApiToken, error) {
+	args := mock.Called(ctx, db, tokenId)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.ApiToken), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.ApiToken), nil
+}
+
+func (mock *ApiTokenRepositoryMock) Insert(ctx context.Context, db *sql.DB, apiToken entity.ApiToken, expiresInDays int) (int, error) {
+	args := mock.Called(ctx, db, apiToken, expiresInDays)
+	return args.Int(0), args.Error(1)
+}
+
+func (mock *ApiTokenRepositoryMock) UpdateLastUsed(ctx context.Context, db *sql.DB, tokenId int) error {
+	args := mock.Called(ctx, db, tokenId)
+	return args.Error(0)
+}
+
+func (mock *ApiTokenRepositoryMock) Revoke(ctx context.Context, db *sql.DB, userId int, tokenId int) error {
+	args := mock.Called(ctx, db, userId, tokenId)
+	return args.Error(0)
+}
+
+func TestApiTokenServiceCreate(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	apiTokenRepositoryMock := new(ApiTokenRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	apiTokenService := service.NewApiTokenService(db, userRepositoryMock, apiTokenRepositoryMock, validatorMock)
+
+	apiTokenCreateRequest := request.ApiTokenCreateRequest{
+		Username:      "apollo",
+		Name:          "ci",
+		Scopes:        []string{"todos:read", "todos:write"},
+		ExpiresInDays: 30,
+	}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, apiTokenCreateRequest).Return(nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(entity.User{Id: 1, Username: "apollo"}, nil)
+	apiTokenRepositoryMock.On("Insert", ctx, db, mock.MatchedBy(func(apiToken entity.ApiToken) bool {
+		return apiToken.UserId == 1 && apiToken.Scopes == "todos:read todos:write" && len(apiToken.TokenHash) == 64
+	}), 30).Return(5, nil)
+	apiTokenRepositoryMock.On("Get", ctx, db, 5).Return(entity.ApiToken{Id: 5, UserId: 1, Name: "ci", Scopes: "todos:read todos:write", ExpiresAt: "2024-02-01 10:00:00", CreatedAt: "2024-01-01 10:00:00"}, nil)
+
+	apiTokenCreateResponse, err := apiTokenService.Create(ctx, apiTokenCreateRequest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, apiTokenCreateResponse.Id)
+	assert.True(t, strings.HasPrefix(apiTokenCreateResponse.Token, helper.ApiTokenPrefix))
+	assert.Equal(t, []string{"todos:read", "todos:write"}, apiTokenCreateResponse.Scopes)
+	assert.Equal(t, "2024-02-01 10:00:00", apiTokenCreateResponse.ExpiresAt)
+}
+
+func TestApiTokenServiceRevokeUnknownToken(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	apiTokenRepositoryMock := new(ApiTokenRepositoryMock)
+	apiTokenService := service.NewApiTokenService(db, userRepositoryMock, apiTokenRepositoryMock, new(ValidatorMock))
+
+	ctx := context.Background()
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(entity.User{Id: 1, Username: "apollo"}, nil)
+	apiTokenRepositoryMock.On("Revoke", ctx, db, 1, 9).Return(helper.ErrRowsNotAffected)
+
+	err := apiTokenService.Revoke(ctx, "apollo", 9)
+
+	assert.ErrorIs(t, err, helper.ErrNotFound)
+}
+
+func TestApiTokenServiceAuthenticate(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	apiTokenRepositoryMock := new(ApiTokenRepositoryMock)
+	apiTokenService := service.NewApiTokenService(db, new(UserRepositoryMock), apiTokenRepositoryMock, new(ValidatorMock))
+
+	ctx := context.Background()
+	token := helper.ApiTokenPrefix + "valid"
+	apiTokenRepositoryMock.On("GetActiveByHash", ctx, db, helper.HashToken(token)).Return(entity.ApiToken{Id: 3, UserId: 1, Username: "apollo", Scopes: "todos:read"}, nil)
+	apiTokenRepositoryMock.On("UpdateLastUsed", ctx, db, 3).Return(nil)
+
+	principal, err := apiTokenService.Authenticate(ctx, token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, principal.UserId)
+	assert.Equal(t, "apollo", principal.Username)
+	assert.Equal(t, helper.AuthMethodApiToken, principal.AuthMethod)
+	assert.True(t, principal.HasScope(helper.ScopeTodosRead))
+	assert.False(t, principal.HasScope(helper.ScopeTodosWrite))
+	apiTokenRepositoryMock.AssertCalled(t, "UpdateLastUsed", ctx, db, 3)
+
+	revokedToken := helper.ApiTokenPrefix + "revoked"
+	apiTokenRepositoryMock.On("GetActiveByHash", ctx, db, helper.HashToken(revokedToken)).Return(entity.ApiToken{}, helper.ErrNotFound)
+
+	_, errRevoked := apiTokenService.Authenticate(ctx, revokedToken)
+
+	assert.ErrorIs(t, errRevoked, helper.ErrorTokenInvalid)
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/model/response"
 	"io"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +33,16 @@ func (mock *AuthServiceMock) Login(ctx context.Context, loginRequest request.Use
 	return args.Get(0).(response.LoginResponse), nil
 }
 
+func (mock *AuthServiceMock) LoginMfa(ctx context.Context, mfaLoginRequest request.MfaLoginRequest) (response.LoginResponse, error) {
+	args := mock.Called(ctx, mfaLoginRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.LoginResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.LoginResponse), nil
+}
+
 func (mock *AuthServiceMock) RefreshToken(ctx context.Context, tokenRefreshRequest request.RefreshTokenRequest) (response.RefreshTokenResponse, error) {
 	args := mock.Called(ctx, tokenRefreshRequest)
 
@@ -40,6 +53,26 @@ func (mock *AuthServiceMock) RefreshToken(ctx context.Context, tokenRefreshReque
 	return args.Get(0).(response.RefreshTokenResponse), nil
 }
 
+func (mock *AuthServiceMock) Authenticate(ctx context.Context, token string) (helper.Principal, error) {
+	args := mock.Called(ctx, token)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(helper.Principal), args.Get(1).(error)
+	}
+
+	return args.Get(0).(helper.Principal), nil
+}
+
+func (mock *AuthServiceMock) LoginUser(ctx context.Context, user entity.User) (response.LoginResponse, error) {
+	args := mock.Called(ctx, user)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.LoginResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.LoginResponse), nil
+}
+
 func TestAuthControllerLogin(t *testing.T) {
 	jsonRequest := strings.NewReader(`{
 		"username": "apollo",
@@ -94,3 +127,125 @@ func TestAuthControllerLogin(t *testing.T) {
 	assert.Equal(t, loginResponse.AccessToken, user["access_token"])
 	assert.Equal(t, loginResponse.RefreshToken, user["refresh_token"])
 }
+
+func TestAuthControllerLoginMfaRequired(t *testing.T) {
+	jsonRequest := strings.NewReader(`{
+		"username": "apollo",
+		"password": "secret"
+	}`)
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/login", jsonRequest)
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	authServiceMock := new(AuthServiceMock)
+	authController := controller.NewAuthController(authServiceMock)
+
+	errMfaRequired := &helper.MfaRequiredError{
+		MfaToken:  "unittest.mfatoken",
+		ExpiresAt: 1700000000,
+	}
+
+	authServiceMock.On("Login", request.Context(), mock.AnythingOfType("request.UserLoginRequest")).Return(response.LoginResponse{}, errMfaRequired)
+
+	authController.Login(recorder, request, params)
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	challenge := standardResposne.Data.(map[string]any)
+
+	assert.Equal(t, "mfa required", standardResposne.Message)
+	assert.Equal(t, true, challenge["mfa_required"])
+	assert.Equal(t, errMfaRequired.MfaToken, challenge["mfa_token"])
+	assert.Nil(t, challenge["access_token"])
+}
+
+func TestAuthControllerLoginThrottled(t *testing.T) {
+	jsonRequest := strings.NewReader(`{
+		"username": "apollo",
+		"password": "guess"
+	}`)
+
+	fromClientIp := mock.MatchedBy(func(loginRequest request.UserLoginRequest) bool {
+		return loginRequest.IpAddress == "203.0.113.7"
+	})
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/login", jsonRequest)
+	request.RemoteAddr = "203.0.113.7:51234"
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	authServiceMock := new(AuthServiceMock)
+	authController := controller.NewAuthController(authServiceMock)
+
+	authServiceMock.On("Login", request.Context(), fromClientIp).Return(response.LoginResponse{}, &helper.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+
+	authController.Login(recorder, request, params)
+
+	result := recorder.Result()
+
+	assert.Equal(t, 429, result.StatusCode)
+	assert.Equal(t, "2", result.Header.Get("Retry-After"))
+}
+
+func TestAuthControllerLoginMfa(t *testing.T) {
+	mfaLoginRequest := request.MfaLoginRequest{MfaToken: "unittest.mfatoken", Code: "123456", IpAddress: "203.0.113.7"}
+
+	jsonRequest := strings.NewReader(`{
+		"mfa_token": "unittest.mfatoken",
+		"code": "123456"
+	}`)
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/login/mfa", jsonRequest)
+	request.RemoteAddr = "203.0.113.7:51234"
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	authServiceMock := new(AuthServiceMock)
+	authController := controller.NewAuthController(authServiceMock)
+
+	loginResponse := response.LoginResponse{
+		UserResponse: response.UserResponse{Id: 1, Username: "apollo"},
+		AccessToken:  "unittest.accesstoken",
+		RefreshToken: "unittest.refreshtoken",
+	}
+
+	authServiceMock.On("LoginMfa", mock.Anything, mfaLoginRequest).Return(loginResponse, nil)
+
+	authController.LoginMfa(recorder, request, params)
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	user := standardResposne.Data.(map[string]any)
+
+	assert.Equal(t, loginResponse.AccessToken, user["access_token"])
+	assert.Equal(t, loginResponse.RefreshToken, user["refresh_token"])
+
+	authServiceMock.On("LoginMfa", mock.Anything, mock.AnythingOfType("request.MfaLoginRequest")).Return(response.LoginResponse{}, helper.ErrMfaCodeInvalid)
+
+	request = httptest.NewRequest("POST", "http://localhost:8080/api/login/mfa", strings.NewReader(`{"mfa_token": "unittest.mfatoken", "code": "000000"}`))
+	recorder = httptest.NewRecorder()
+
+	authController.LoginMfa(recorder, request, params)
+
+	assert.Equal(t, 401, recorder.Result().StatusCode)
+}
@@ -0,0 +1,206 @@
+package unit
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func principalEchoHandler(t *testing.T, expected helper.Principal) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		principal, ok := helper.GetPrincipal(r.Context())
+
+		assert.True(t, ok)
+		assert.Equal(t, expected, principal)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestAuthMiddlewareAuthenticateJWT(t *testing.T) {
+	authServiceMock := new(AuthServiceMock)
+	apiTokenServiceMock := new(ApiTokenServiceMock)
+	authMiddleware := middleware.NewAuthMiddleware(authServiceMock, apiTokenServiceMock, new(OauthServiceMock))
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/todo/1", nil)
+	request.Header.Set("Authorization", "Bearer unittest.jwt")
+	recorder := httptest.NewRecorder()
+
+	authServiceMock.On("Authenticate", mock.Anything, "unittest.jwt").Return(apolloPrincipal, nil)
+
+	authMiddleware.Authenticate(principalEchoHandler(t, apolloPrincipal))(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+	apiTokenServiceMock.AssertNotCalled(t, "Authenticate", mock.Anything, mock.Anything)
+}
+
+func TestAuthMiddlewareAuthenticateApiToken(t *testing.T) {
+	authServiceMock := new(AuthServiceMock)
+	apiTokenServiceMock := new(ApiTokenServiceMock)
+	authMiddleware := middleware.NewAuthMiddleware(authServiceMock, apiTokenServiceMock, new(OauthServiceMock))
+
+	apiTokenPrincipal := helper.Principal{
+		UserId:     1,
+		Username:   "apollo",
+		AuthMethod: helper.AuthMethodApiToken,
+		Scopes:     []string{helper.ScopeTodosRead},
+		Roles:      []string{helper.RoleUser},
+	}
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/todo/1", nil)
+	request.Header.Set("Authorization", "Bearer gta_unittest")
+	recorder := httptest.NewRecorder()
+
+	apiTokenServiceMock.On("Authenticate", mock.Anything, "gta_unittest").Return(apiTokenPrincipal, nil)
+
+	authMiddleware.Authenticate(principalEchoHandler(t, apiTokenPrincipal))(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+	authServiceMock.AssertNotCalled(t, "Authenticate", mock.Anything, mock.Anything)
+}
+
+func TestAuthMiddlewareAuthenticateOauthToken(t *testing.T) {
+	authServiceMock := new(AuthServiceMock)
+	oauthServiceMock := new(OauthServiceMock)
+	authMiddleware := middleware.NewAuthMiddleware(authServiceMock, new(ApiTokenServiceMock), oauthServiceMock)
+
+	oauthPrincipal := helper.Principal{
+		UserId:     1,
+		Username:   "apollo",
+		AuthMethod: helper.AuthMethodOauth,
+		ClientId:   "unittest-client",
+		Scopes:     []string{helper.ScopeTodosRead},
+		Roles:      []string{helper.RoleUser},
+	}
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/todo/1", nil)
+	request.Header.Set("Authorization", "Bearer gto_unittest")
+	recorder := httptest.NewRecorder()
+
+	oauthServiceMock.On("Authenticate", mock.Anything, "gto_unittest").Return(oauthPrincipal, nil)
+
+	authMiddleware.Authenticate(principalEchoHandler(t, oauthPrincipal))(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+	authServiceMock.AssertNotCalled(t, "Authenticate", mock.Anything, mock.Anything)
+}
+
+func TestAuthMiddlewareAuthenticateMissingToken(t *testing.T) {
+	authMiddleware := middleware.NewAuthMiddleware(new(AuthServiceMock), new(ApiTokenServiceMock), new(OauthServiceMock))
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/todo/1", nil)
+	recorder := httptest.NewRecorder()
+
+	authMiddleware.Authenticate(principalEchoHandler(t, helper.Principal{}))(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 401, recorder.Result().StatusCode)
+}
+
+func TestAuthMiddlewareRequireScope(t *testing.T) {
+	readOnlyPrincipal := helper.Principal{
+		UserId:     1,
+		Username:   "apollo",
+		AuthMethod: helper.AuthMethodApiToken,
+		Scopes:     []string{helper.ScopeTodosRead},
+	}
+
+	handler := middleware.RequireScope(helper.ScopeTodosWrite)(principalEchoHandler(t, readOnlyPrincipal))
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/todo", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), readOnlyPrincipal))
+	recorder := httptest.NewRecorder()
+
+	handler(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+	assert.Contains(t, recorder.Body.String(), "missing required scope todos:write")
+
+	handler = middleware.RequireScope(helper.ScopeTodosRead)(principalEchoHandler(t, readOnlyPrincipal))
+	recorder = httptest.NewRecorder()
+
+	handler(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+}
+
+func TestAuthMiddlewareRequireSession(t *testing.T) {
+	handler := middleware.RequireSession(principalEchoHandler(t, apolloPrincipal))
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/me/tokens", nil)
+	recorder := httptest.NewRecorder()
+
+	handler(recorder, request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal)), httprouter.Params{})
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+
+	apiTokenPrincipal := apolloPrincipal
+	apiTokenPrincipal.AuthMethod = helper.AuthMethodApiToken
+	recorder = httptest.NewRecorder()
+
+	handler(recorder, request.WithContext(helper.SetPrincipal(request.Context(), apiTokenPrincipal)), httprouter.Params{})
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+}
+
+func TestAuthMiddlewareRequireRole(t *testing.T) {
+	adminPrincipal := apolloPrincipal
+	adminPrincipal.Roles = helper.RolesFor(helper.RoleAdmin)
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/admin/users", nil)
+	recorder := httptest.NewRecorder()
+
+	handler := middleware.RequireRole(helper.RoleAdmin)(principalEchoHandler(t, adminPrincipal))
+
+	handler(recorder, request.WithContext(helper.SetPrincipal(request.Context(), adminPrincipal)), httprouter.Params{})
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+
+	recorder = httptest.NewRecorder()
+
+	handler(recorder, request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal)), httprouter.Params{})
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+	assert.Contains(t, recorder.Body.String(), "missing required role admin")
+}
+
+func TestAuthMiddlewareRequireSelfOrAdmin(t *testing.T) {
+	adminPrincipal := apolloPrincipal
+	adminPrincipal.Roles = helper.RolesFor(helper.RoleAdmin)
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/user/2", nil)
+	params := httprouter.Params{{Key: "userId", Value: "2"}}
+
+	recorder := httptest.NewRecorder()
+
+	middleware.RequireSelfOrAdmin("userId")(principalEchoHandler(t, apolloPrincipal))(recorder, request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal)), params)
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+
+	recorder = httptest.NewRecorder()
+
+	middleware.RequireSelfOrAdmin("userId")(principalEchoHandler(t, adminPrincipal))(recorder, request.WithContext(helper.SetPrincipal(request.Context(), adminPrincipal)), params)
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+
+	recorder = httptest.NewRecorder()
+
+	selfParams := httprouter.Params{{Key: "userId", Value: "1"}}
+
+	middleware.RequireSelfOrAdmin("userId")(principalEchoHandler(t, apolloPrincipal))(recorder, request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal)), selfParams)
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+
+	// A client credentials token is nobody, not the user with id 0.
+	clientPrincipal := helper.Principal{ClientId: "reporting", AuthMethod: helper.AuthMethodOauth, Scopes: helper.AllScopes}
+	recorder = httptest.NewRecorder()
+
+	middleware.RequireSelfOrAdmin("userId")(principalEchoHandler(t, clientPrincipal))(recorder, request.WithContext(helper.SetPrincipal(request.Context(), clientPrincipal)), httprouter.Params{{Key: "userId", Value: "0"}})
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+}
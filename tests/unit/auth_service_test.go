@@ -2,14 +2,20 @@ package unit
 
 import (
 	"context"
+	"errors"
 	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/repository"
 	"go_todo_api/internal/service"
+	testhelper "go_todo_api/tests/test_helper"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAuthServiceLogin(t *testing.T) {
@@ -19,7 +25,8 @@ func TestAuthServiceLogin(t *testing.T) {
 	defer db.Close()
 
 	userRepositoryMock := new(UserRepositoryMock)
-	authService := service.NewAuthService(db, userRepositoryMock, validatorMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	authService := service.NewAuthService(db, userRepositoryMock, mfaRepositoryMock, validatorMock, testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
 
 	loginRequest := request.UserLoginRequest{
 		Username: "apollo",
@@ -42,6 +49,7 @@ func TestAuthServiceLogin(t *testing.T) {
 		UpdatedAt:   "2020-10-10 10:10:10",
 	}
 	userRepositoryMock.On("GetByUsername", ctx, db, loginRequest.Username).Return(expectedUser, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, expectedUser.Id).Return(entity.UserTotp{}, helper.ErrNotFound)
 
 	loginResponse, errLogin := authService.Login(ctx, loginRequest)
 	assert.NoError(t, errLogin)
@@ -55,3 +63,186 @@ func TestAuthServiceLogin(t *testing.T) {
 	assert.NotEmpty(t, loginResponse.AccessToken)
 	assert.NotEmpty(t, loginResponse.AccessToken)
 }
+
+func TestAuthServiceLoginThrottled(t *testing.T) {
+	db, _, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	loginThrottleConfig := service.DefaultLoginThrottleConfig()
+	loginThrottleConfig.Username.FreeAttempts = 1
+	loginThrottleConfig.Username.LockoutAfter = 2
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	authService := service.NewAuthService(db, userRepositoryMock, new(MfaRepositoryMock), validatorMock, testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), loginThrottleConfig))
+
+	hashedPassword, _ := helper.HashPassword("secret")
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, mock.Anything).Return(nil)
+	user := entity.User{Id: 1, Username: "apollo", Email: "apollo@example.xyz", Password: hashedPassword}
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(user, nil)
+	userRepositoryMock.On("GetByEmail", ctx, db, "apollo@example.xyz").Return(user, nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "nobody").Return(entity.User{}, helper.ErrNotFound)
+
+	_, errFirst := authService.Login(ctx, request.UserLoginRequest{Username: "apollo", Password: "guess", IpAddress: "203.0.113.7"})
+	assert.ErrorIs(t, errFirst, helper.ErrLoginFailed)
+
+	// Guessing under the email counts against the same account.
+	_, errSecond := authService.Login(ctx, request.UserLoginRequest{Username: "apollo@example.xyz", Password: "guess", IpAddress: "203.0.113.7"})
+	assert.ErrorIs(t, errSecond, helper.ErrLoginFailed)
+
+	// The account is locked now, even the right password is turned away.
+	_, errLocked := authService.Login(ctx, request.UserLoginRequest{Username: "Apollo", Password: "secret", IpAddress: "198.51.100.1"})
+
+	throttledError := &helper.LoginThrottledError{}
+	assert.ErrorAs(t, errLocked, &throttledError)
+	assert.Greater(t, throttledError.RetryAfter, 14*time.Minute)
+
+	// Unknown usernames are throttled like wrong passwords.
+	_, errUnknown := authService.Login(ctx, request.UserLoginRequest{Username: "nobody", Password: "guess"})
+	assert.ErrorIs(t, errUnknown, helper.ErrLoginFailed)
+
+	_, errUnknown = authService.Login(ctx, request.UserLoginRequest{Username: "nobody", Password: "guess"})
+	assert.ErrorIs(t, errUnknown, helper.ErrLoginFailed)
+
+	_, errUnknownLocked := authService.Login(ctx, request.UserLoginRequest{Username: "nobody", Password: "guess"})
+	assert.ErrorAs(t, errUnknownLocked, &throttledError)
+}
+
+func TestAuthServiceLoginThrottlesParallelGuesses(t *testing.T) {
+	db, _, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	loginThrottleConfig := service.DefaultLoginThrottleConfig()
+	loginThrottleConfig.Username.LockoutAfter = 4
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	authService := service.NewAuthService(db, userRepositoryMock, new(MfaRepositoryMock), validatorMock, testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), loginThrottleConfig))
+
+	hashedPassword, _ := helper.HashPassword("secret")
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, mock.Anything).Return(nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(entity.User{Id: 1, Username: "apollo", Password: hashedPassword}, nil)
+
+	var waitGroup sync.WaitGroup
+	errs := make(chan error, 20)
+
+	for i := 0; i < 20; i++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			_, err := authService.Login(ctx, request.UserLoginRequest{Username: "apollo", Password: "guess", IpAddress: "203.0.113.7"})
+			errs <- err
+		}()
+	}
+
+	waitGroup.Wait()
+	close(errs)
+
+	failed := 0
+	throttled := 0
+
+	for err := range errs {
+		if errors.Is(err, helper.ErrLoginFailed) {
+			failed++
+		}
+		if errors.As(err, new(*helper.LoginThrottledError)) {
+			throttled++
+		}
+	}
+
+	// Only the guesses before the lockout get their password checked.
+	assert.Equal(t, 4, failed)
+	assert.Equal(t, 16, throttled)
+}
+
+func TestAuthServiceLoginMfaThrottled(t *testing.T) {
+	db, _, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	loginThrottleConfig := service.DefaultLoginThrottleConfig()
+	loginThrottleConfig.Username.FreeAttempts = 1
+	loginThrottleConfig.Username.LockoutAfter = 2
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	authService := service.NewAuthService(db, userRepositoryMock, mfaRepositoryMock, validatorMock, testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), loginThrottleConfig))
+
+	hashedPassword, _ := helper.HashPassword("secret")
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, mock.Anything).Return(nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(entity.User{Id: 1, Username: "apollo", Password: hashedPassword}, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{UserId: 1, Secret: "JBSWY3DPEHPK3PXP", IsEnabled: true}, nil)
+	mfaRepositoryMock.On("UseRecoveryCode", ctx, db, 1, mock.Anything).Return(helper.ErrRowsNotAffected)
+
+	login := func() string {
+		_, errLogin := authService.Login(ctx, request.UserLoginRequest{Username: "apollo", Password: "secret"})
+
+		mfaRequiredError := &helper.MfaRequiredError{}
+		assert.ErrorAs(t, errLogin, &mfaRequiredError)
+
+		return mfaRequiredError.MfaToken
+	}
+
+	wrongCode := request.MfaLoginRequest{MfaToken: login(), Code: "not-a-code", IpAddress: "203.0.113.7"}
+
+	_, errFirst := authService.LoginMfa(ctx, wrongCode)
+	assert.ErrorIs(t, errFirst, helper.ErrMfaCodeInvalid)
+
+	_, errSecond := authService.LoginMfa(ctx, wrongCode)
+	assert.ErrorIs(t, errSecond, helper.ErrMfaCodeInvalid)
+
+	// Logging in with the password again doesn't give back the guesses at the code.
+	wrongCode.MfaToken = login()
+
+	_, errLocked := authService.LoginMfa(ctx, wrongCode)
+
+	throttledError := &helper.LoginThrottledError{}
+	assert.ErrorAs(t, errLocked, &throttledError)
+	mfaRepositoryMock.AssertNumberOfCalls(t, "UseRecoveryCode", 2)
+}
+
+func TestAuthServiceLoginByEmail(t *testing.T) {
+	db, _, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	authService := service.NewAuthService(db, userRepositoryMock, mfaRepositoryMock, validatorMock, testhelper.NewJwtKeySet("test"), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	hashedPassword, _ := helper.HashPassword("secret")
+	user := entity.User{Id: 1, Username: "apollo", Password: hashedPassword, Email: "apollo@example.xyz"}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, mock.Anything).Return(nil)
+	userRepositoryMock.On("GetByEmail", ctx, db, "apollo@example.xyz").Return(user, nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(user, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{}, helper.ErrNotFound)
+
+	loginResponse, errEmail := authService.Login(ctx, request.UserLoginRequest{Username: " Apollo@Example.xyz", Password: "secret"})
+
+	assert.NoError(t, errEmail)
+	assert.Equal(t, "apollo", loginResponse.Username)
+
+	_, errUsername := authService.Login(ctx, request.UserLoginRequest{Username: "APOLLO", Password: "secret"})
+
+	assert.NoError(t, errUsername)
+	userRepositoryMock.AssertNumberOfCalls(t, "GetByEmail", 1)
+	userRepositoryMock.AssertNumberOfCalls(t, "GetByUsername", 1)
+}
@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"go_todo_api/internal/helper"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	after := time.Date(2024, time.March, 20, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.March, 20, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.March, 20, 10, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, time.March, 21, 3, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * *", time.Date(2024, time.March, 20, 13, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 1,7", time.Date(2024, time.March, 24, 12, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{"0 0 31 * 5", time.Date(2024, time.March, 22, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.March, 20, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, time.March, 24, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		schedule, err := helper.ParseCronSchedule(test.spec)
+
+		assert.NoError(t, err, test.spec)
+		assert.Equal(t, test.next, schedule.Next(after), test.spec)
+	}
+}
+
+func TestCronScheduleNeverMatches(t *testing.T) {
+	schedule, err := helper.ParseCronSchedule("0 0 30 2 *")
+
+	assert.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestParseCronScheduleRejectsBadSpecs(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		_, err := helper.ParseCronSchedule(spec)
+
+		assert.Error(t, err, spec)
+	}
+}
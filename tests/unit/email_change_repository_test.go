@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var emailChangeRepository = repository.NewEmailChangeRepository()
+
+var emailChangeColumns = []string{"id", "user_id", "old_email", "new_email", "confirm_token_hash", "revert_token_hash", "confirmed_at"}
+
+func TestEmailChangeRepositoryInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	emailChange := entity.EmailChange{
+		UserId:           1,
+		OldEmail:         "budi@example.xyz",
+		NewEmail:         "budi@example.com",
+		ConfirmTokenHash: helper.HashToken("confirm"),
+		RevertTokenHash:  helper.HashToken("revert"),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("DELETE FROM email_changes WHERE user_id = (.+) AND confirmed_at IS NULL").ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("INSERT INTO email_changes").ExpectExec().WithArgs(1, "budi@example.xyz", "budi@example.com", emailChange.ConfirmTokenHash, emailChange.RevertTokenHash, 24, 7).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+	assert.NoError(t, emailChangeRepository.DeletePending(context.Background(), tx, 1))
+	assert.NoError(t, emailChangeRepository.Insert(context.Background(), tx, emailChange, 24, 7))
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailChangeRepositoryGetByRevertHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(emailChangeColumns).
+		AddRow(1, 1, "budi@example.xyz", "budi@example.com", helper.HashToken("confirm"), helper.HashToken("revert"), "2024-02-20 10:00:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM email_changes WHERE revert_token_hash").ExpectQuery().WithArgs(helper.HashToken("revert")).WillReturnRows(rows)
+
+	emailChange, errGet := emailChangeRepository.GetByRevertHash(context.Background(), db, helper.HashToken("revert"))
+
+	assert.NoError(t, errGet)
+	assert.Equal(t, "budi@example.xyz", emailChange.OldEmail)
+	assert.Equal(t, "2024-02-20 10:00:00", emailChange.ConfirmedAt)
+
+	mock.ExpectPrepare("SELECT (.+) FROM email_changes WHERE confirm_token_hash").ExpectQuery().WithArgs(helper.HashToken("unknown")).WillReturnRows(sqlmock.NewRows(emailChangeColumns))
+
+	_, errNotFound := emailChangeRepository.GetPendingByConfirmHash(context.Background(), db, helper.HashToken("unknown"))
+
+	assert.ErrorIs(t, errNotFound, helper.ErrNotFound)
+}
+
+func TestEmailChangeRepositoryMarkConfirmedTwice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE email_changes SET confirmed_at").ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+	assert.ErrorIs(t, emailChangeRepository.MarkConfirmed(context.Background(), tx, 1), helper.ErrRowsNotAffected)
+	assert.NoError(t, tx.Rollback())
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/response"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeProblem(t *testing.T, err error) (*httptest.ResponseRecorder, response.ProblemResponse) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set(helper.RequestIdHeader, "unittest-request")
+
+	helper.WriteErrorResponse(recorder, err)
+
+	problem := response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+
+	return recorder, problem
+}
+
+func TestWriteErrorResponseAppError(t *testing.T) {
+	recorder, problem := writeProblem(t, helper.ErrTodoNotFound)
+
+	assert.Equal(t, 404, recorder.Result().StatusCode)
+	assert.Equal(t, "application/problem+json", recorder.Result().Header.Get("Content-Type"))
+	assert.Equal(t, response.ProblemResponse{
+		Type:     "about:blank",
+		Title:    "Not Found",
+		Status:   404,
+		Detail:   "todo not found",
+		Instance: "urn:request:unittest-request",
+		Code:     "todo.not_found",
+	}, problem)
+	assert.ErrorIs(t, helper.ErrTodoNotFound, helper.ErrNotFound)
+}
+
+func TestWriteErrorResponseWrappedAppError(t *testing.T) {
+	recorder, problem := writeProblem(t, fmt.Errorf("%w: missing required scope todos:write", helper.ErrForbidden))
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+	assert.Equal(t, "auth.forbidden", problem.Code)
+	assert.Equal(t, "forbidden: missing required scope todos:write", problem.Detail)
+}
+
+func TestWriteErrorResponseConstraintError(t *testing.T) {
+	recorder, problem := writeProblem(t, helper.NewConflictError("username"))
+
+	assert.Equal(t, 409, recorder.Result().StatusCode)
+	assert.Equal(t, "resource.conflict", problem.Code)
+	assert.Equal(t, "username already exists", problem.Detail)
+	assert.Equal(t, []response.FieldErrorResponse{
+		{Field: "username", Rule: "unique", Message: "username already exists"},
+	}, problem.Errors)
+}
+
+func TestWriteErrorResponseHidesInternalErrors(t *testing.T) {
+	recorder, problem := writeProblem(t, errors.New("Error 1146 (42S02): Table 'todo.todos' doesn't exist"))
+
+	assert.Equal(t, 500, recorder.Result().StatusCode)
+	assert.Equal(t, "internal_error", problem.Code)
+	assert.Equal(t, "internal server error", problem.Detail)
+	assert.Equal(t, "urn:request:unittest-request", problem.Instance)
+	assert.NotContains(t, recorder.Body.String(), "42S02")
+}
+
+func TestWriteErrorResponseClassifiesCommonErrors(t *testing.T) {
+	_, errAtoi := strconv.Atoi("abc")
+
+	testCases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{errAtoi, 400, "request.invalid_parameter"},
+		{fmt.Errorf("%w: unexpected EOF", helper.ErrMalformedBody), 400, "request.malformed_body"},
+		{fmt.Errorf("%w: token has invalid claims: token is expired", helper.ErrTokenExpired), 401, "auth.token_expired"},
+		{&helper.LoginThrottledError{RetryAfter: 1500 * time.Millisecond}, 429, "auth.login_throttled"},
+		{helper.NewOauthError("invalid_request", "missing client_id"), 400, "oauth.invalid_request"},
+		{helper.ErrRowsNotAffected, 500, "internal_error"},
+	}
+
+	for _, testCase := range testCases {
+		recorder, problem := writeProblem(t, testCase.err)
+
+		assert.Equal(t, testCase.status, recorder.Result().StatusCode, testCase.code)
+		assert.Equal(t, testCase.code, problem.Code)
+	}
+}
@@ -0,0 +1,39 @@
+package unit
+
+import (
+	"go_todo_api/internal/helper"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	testCases := []struct {
+		ifMatch  string
+		required bool
+		version  int
+		err      error
+	}{
+		{"", false, 0, nil},
+		{"", true, 0, helper.ErrPreconditionRequired},
+		{"*", true, 0, nil},
+		{`"7"`, true, 7, nil},
+		{`W/"7"`, true, 0, helper.ErrPreconditionFailed},
+		{`"seven"`, true, 0, helper.ErrPreconditionFailed},
+		{"7", true, 0, helper.ErrPreconditionFailed},
+	}
+
+	for _, testCase := range testCases {
+		request := httptest.NewRequest("PUT", "http://localhost:8080/api/todo/1", nil)
+
+		if testCase.ifMatch != "" {
+			request.Header.Set("If-Match", testCase.ifMatch)
+		}
+
+		version, err := helper.IfMatchVersion(request, testCase.required)
+
+		assert.Equal(t, testCase.version, version, testCase.ifMatch)
+		assert.Equal(t, testCase.err, err, testCase.ifMatch)
+	}
+}
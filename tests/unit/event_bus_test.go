@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"go_todo_api/internal/helper"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryEventBusDeliversToUser(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+
+	subscription := eventBus.Subscribe(1, 0)
+	defer subscription.Close()
+
+	eventBus.Publish(11, 2, helper.EventTodoCreated, "other user")
+	eventBus.Publish(12, 1, helper.EventTodoCreated, "own")
+
+	event := <-subscription.Events
+
+	assert.Equal(t, int64(12), event.Id)
+	assert.Equal(t, helper.EventTodoCreated, event.Type)
+	assert.Equal(t, "own", event.Data)
+	assert.Empty(t, subscription.Events)
+}
+
+func TestMemoryEventBusResumes(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+
+	eventBus.Publish(1, 1, helper.EventTodoCreated, "first")
+	eventBus.Publish(2, 2, helper.EventTodoCreated, "other user")
+	eventBus.Publish(3, 1, helper.EventTodoUpdated, "second")
+
+	subscription := eventBus.Subscribe(1, 1)
+	defer subscription.Close()
+
+	assert.True(t, subscription.Resumed)
+	assert.Len(t, subscription.Backlog, 1)
+	assert.Equal(t, "second", subscription.Backlog[0].Data)
+
+	// An id the bus never got, e.g. from before it started.
+	stale := eventBus.Subscribe(1, 42)
+	defer stale.Close()
+
+	assert.False(t, stale.Resumed)
+	assert.Empty(t, stale.Backlog)
+}
+
+func TestMemoryEventBusResumesInPublishOrder(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+
+	// Event 5 committed after event 6, and reached the bus after it.
+	eventBus.Publish(4, 1, helper.EventTodoCreated, "fourth")
+	eventBus.Publish(6, 1, helper.EventTodoUpdated, "sixth")
+	eventBus.Publish(5, 1, helper.EventTodoCompleted, "fifth")
+
+	subscription := eventBus.Subscribe(1, 6)
+	defer subscription.Close()
+
+	assert.True(t, subscription.Resumed)
+	assert.Len(t, subscription.Backlog, 1)
+	assert.Equal(t, "fifth", subscription.Backlog[0].Data)
+}
+
+func TestMemoryEventBusResetsAfterRestart(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+
+	// The client last saw event 500 on a process that is gone. This one
+	// started after event 1200 and has fed the events since.
+	for eventId := int64(1201); eventId <= 1800; eventId++ {
+		eventBus.Publish(eventId, 1, helper.EventTodoUpdated, eventId)
+	}
+
+	subscription := eventBus.Subscribe(1, 500)
+	defer subscription.Close()
+
+	assert.False(t, subscription.Resumed)
+	assert.Empty(t, subscription.Backlog)
+}
+
+func TestMemoryEventBusForgetsOldEvents(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+
+	for eventId := int64(1); eventId <= 1002; eventId++ {
+		eventBus.Publish(eventId, 1, helper.EventTodoUpdated, eventId)
+	}
+
+	subscription := eventBus.Subscribe(1, 3)
+	defer subscription.Close()
+
+	assert.True(t, subscription.Resumed)
+	assert.Len(t, subscription.Backlog, 999)
+
+	// Event 2 already left the buffer.
+	lost := eventBus.Subscribe(1, 2)
+	defer lost.Close()
+
+	assert.False(t, lost.Resumed)
+	assert.Empty(t, lost.Backlog)
+}
+
+func TestMemoryEventBusDropsSlowSubscriber(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+
+	subscription := eventBus.Subscribe(1, 0)
+	defer subscription.Close()
+
+	for eventId := int64(1); eventId <= 65; eventId++ {
+		eventBus.Publish(eventId, 1, helper.EventTodoUpdated, eventId)
+	}
+
+	received := 0
+
+	for range subscription.Events {
+		received++
+	}
+
+	assert.Equal(t, 64, received)
+}
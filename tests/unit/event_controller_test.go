@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/response"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventControllerStreamsBacklog(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+	eventBus.Publish(1, 1, helper.EventTodoCreated, response.TodoResponse{Id: 1, Title: "Buy milk"})
+	eventBus.Publish(2, 1, helper.EventTodoDeleted, response.TodoDeletedResponse{Id: 1, Uuid: todoUuid})
+
+	eventController := controller.NewEventController(eventBus, controller.DefaultEventControllerConfig())
+
+	// Already gone, the stream sends what it has and ends.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/me/events", nil)
+	request.Header.Set("Last-Event-ID", "1")
+	request = request.WithContext(helper.SetPrincipal(ctx, apolloPrincipal))
+
+	recorder := httptest.NewRecorder()
+
+	eventController.Stream(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "id: 2\nevent: todo.deleted\ndata: {\"id\":1,\"uuid\":\""+todoUuid+"\"}\n\n", recorder.Body.String())
+	assert.True(t, recorder.Flushed)
+}
+
+func TestEventControllerResetsUnknownLastEventId(t *testing.T) {
+	eventController := controller.NewEventController(helper.NewMemoryEventBus(), controller.DefaultEventControllerConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/me/events", nil)
+	request.Header.Set("Last-Event-ID", "7")
+	request = request.WithContext(helper.SetPrincipal(ctx, apolloPrincipal))
+
+	recorder := httptest.NewRecorder()
+
+	eventController.Stream(recorder, request, httprouter.Params{})
+
+	assert.True(t, strings.HasPrefix(recorder.Body.String(), "event: reset\n"))
+}
+
+func TestEventControllerStreamsLiveEvents(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+	eventController := controller.NewEventController(eventBus, controller.EventControllerConfig{HeartbeatInterval: 10 * time.Millisecond})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventController.Stream(w, r.WithContext(helper.SetPrincipal(r.Context(), apolloPrincipal)), httprouter.Params{})
+	}))
+	defer server.Close()
+
+	result, err := http.Get(server.URL)
+
+	assert.NoError(t, err)
+
+	defer result.Body.Close()
+
+	// The headers arrive once the stream is subscribed.
+	eventBus.Publish(3, 1, helper.EventTodoCompleted, response.TodoResponse{Id: 3, IsDone: true})
+
+	reader := bufio.NewReader(result.Body)
+	lines := []string{}
+
+	for len(lines) < 4 {
+		line, errRead := reader.ReadString('\n')
+
+		assert.NoError(t, errRead)
+
+		if strings.HasPrefix(line, ":") || line == "\n" && len(lines) == 0 {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	assert.Equal(t, "id: 3\n", lines[0])
+	assert.Equal(t, "event: todo.completed\n", lines[1])
+	assert.Contains(t, lines[2], `"is_done":true`)
+}
+
+func TestEventControllerSendsHeartbeats(t *testing.T) {
+	eventController := controller.NewEventController(helper.NewMemoryEventBus(), controller.EventControllerConfig{HeartbeatInterval: 10 * time.Millisecond})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventController.Stream(w, r.WithContext(helper.SetPrincipal(r.Context(), apolloPrincipal)), httprouter.Params{})
+	}))
+	defer server.Close()
+
+	result, err := http.Get(server.URL)
+
+	assert.NoError(t, err)
+
+	defer result.Body.Close()
+
+	line, errRead := bufio.NewReader(result.Body).ReadString('\n')
+
+	assert.NoError(t, errRead)
+	assert.Equal(t, ": heartbeat\n", line)
+}
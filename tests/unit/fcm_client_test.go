@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"go_todo_api/internal/helper"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFcmStandIn serves the token endpoint and the send API of FCM. Sends to
+// the token "gone-token" fail the way FCM fails them for an uninstalled app.
+func newFcmStandIn(t *testing.T, key *rsa.PrivateKey, messages *[]map[string]any, tokenRequests *int) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		*tokenRequests++
+
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(r.FormValue("assertion"), claims, func(token *jwt.Token) (any, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "reminders@demo.iam.gserviceaccount.com", claims["iss"])
+		assert.Equal(t, "https://www.googleapis.com/auth/firebase.messaging", claims["scope"])
+
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "ya29.test", "expires_in": 3600, "token_type": "Bearer"})
+	})
+
+	mux.HandleFunc("POST /v1/projects/demo/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer ya29.test", r.Header.Get("Authorization"))
+
+		body := map[string]map[string]any{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if body["message"]["token"] == "gone-token" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"status":"NOT_FOUND","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`))
+			return
+		}
+
+		*messages = append(*messages, body["message"])
+		w.Write([]byte(`{"name":"projects/demo/messages/1"}`))
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func newTestFcmClient(t *testing.T, key *rsa.PrivateKey, server *httptest.Server) *helper.FcmClient {
+	keyBytes, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	serviceAccount, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "demo",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+		"client_email": "reminders@demo.iam.gserviceaccount.com",
+		"token_uri":    server.URL + "/token",
+	})
+
+	client, err := helper.NewFcmClient(serviceAccount, helper.FcmConfig{Endpoint: server.URL, Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+
+	return client
+}
+
+func TestFcmClientSend(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	messages := []map[string]any{}
+	tokenRequests := 0
+
+	server := newFcmStandIn(t, key, &messages, &tokenRequests)
+	defer server.Close()
+
+	client := newTestFcmClient(t, key, server)
+	message := helper.FcmMessage{
+		Token:       "device-token",
+		Title:       "Reminder: Pay rent",
+		Body:        "before the 5th",
+		Data:        map[string]string{"todo_id": "3"},
+		CollapseKey: "reminder-7",
+	}
+
+	assert.NoError(t, client.Send(context.Background(), message))
+	assert.NoError(t, client.Send(context.Background(), message))
+
+	// The access token is reused while it is valid.
+	assert.Equal(t, 1, tokenRequests)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "device-token", messages[0]["token"])
+	assert.Equal(t, map[string]any{"title": "Reminder: Pay rent", "body": "before the 5th"}, messages[0]["notification"])
+	assert.Equal(t, map[string]any{"todo_id": "3"}, messages[0]["data"])
+	assert.Equal(t, map[string]any{"collapse_key": "reminder-7", "priority": "HIGH"}, messages[0]["android"])
+}
+
+func TestFcmClientReportsUnregisteredTokens(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	messages := []map[string]any{}
+	tokenRequests := 0
+
+	server := newFcmStandIn(t, key, &messages, &tokenRequests)
+	defer server.Close()
+
+	client := newTestFcmClient(t, key, server)
+
+	err := client.Send(context.Background(), helper.FcmMessage{Token: "gone-token", Title: "Reminder: Pay rent"})
+
+	assert.ErrorIs(t, err, helper.ErrPushSubscriptionGone)
+	assert.Empty(t, messages)
+}
+
+func TestNewFcmClientRejectsIncompleteServiceAccounts(t *testing.T) {
+	_, errJson := helper.NewFcmClient([]byte("not json"), helper.FcmConfig{})
+	_, errFields := helper.NewFcmClient([]byte(`{"project_id":"demo"}`), helper.FcmConfig{})
+	_, errKey := helper.NewFcmClient([]byte(`{"project_id":"demo","client_email":"a@b","token_uri":"https://oauth2.googleapis.com/token","private_key":"nope"}`), helper.FcmConfig{})
+
+	assert.Error(t, errJson)
+	assert.Error(t, errFields)
+	assert.Error(t, errKey)
+}
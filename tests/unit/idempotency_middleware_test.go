@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/middleware"
+	"go_todo_api/internal/repository"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func newIdempotentRequest(principal helper.Principal, key string, body string) *http.Request {
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/todo", strings.NewReader(body))
+	request.Header.Set(middleware.IdempotencyKeyHeader, key)
+
+	return request.WithContext(helper.SetPrincipal(request.Context(), principal))
+}
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	calls := 0
+	handler := func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Location", "/api/todo/7")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}
+
+	idempotent := middleware.NewIdempotencyMiddleware(repository.NewMemoryIdempotencyStore()).Idempotent(handler)
+
+	first := httptest.NewRecorder()
+	idempotent(first, newIdempotentRequest(apolloPrincipal, "create-1", `{"title": "Buy milk"}`), nil)
+
+	replay := httptest.NewRecorder()
+	idempotent(replay, newIdempotentRequest(apolloPrincipal, "create-1", `{"title": "Buy milk"}`), nil)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 201, replay.Code)
+	assert.Equal(t, "/api/todo/7", replay.Header().Get("Location"))
+	assert.Equal(t, "true", replay.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, `{"title": "Buy milk"}`, replay.Body.String())
+
+	// Keys are per user.
+	otherUser := httptest.NewRecorder()
+	idempotent(otherUser, newIdempotentRequest(helper.Principal{UserId: 2}, "create-1", `{"title": "Buy milk"}`), nil)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, otherUser.Header().Get(middleware.IdempotentReplayedHeader))
+}
+
+func TestIdempotentRejectsReusedKey(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	idempotent := middleware.NewIdempotencyMiddleware(repository.NewMemoryIdempotencyStore()).Idempotent(handler)
+
+	idempotent(httptest.NewRecorder(), newIdempotentRequest(apolloPrincipal, "create-1", `{"title": "Buy milk"}`), nil)
+
+	recorder := httptest.NewRecorder()
+	idempotent(recorder, newIdempotentRequest(apolloPrincipal, "create-1", `{"title": "Buy bread"}`), nil)
+
+	assert.Equal(t, 422, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "request.idempotency_key_reused")
+}
+
+func TestIdempotentRejectsConcurrentRequest(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	var idempotent httprouter.Handle
+
+	handler := func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		// The same request arrives again while the first one is running.
+		idempotent(recorder, newIdempotentRequest(apolloPrincipal, "create-1", `{}`), nil)
+
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	idempotent = middleware.NewIdempotencyMiddleware(repository.NewMemoryIdempotencyStore()).Idempotent(handler)
+
+	idempotent(httptest.NewRecorder(), newIdempotentRequest(apolloPrincipal, "create-1", `{}`), nil)
+
+	assert.Equal(t, 409, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "request.idempotency_key_in_progress")
+}
+
+func TestIdempotentForgetsServerErrors(t *testing.T) {
+	calls := 0
+	handler := func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	idempotent := middleware.NewIdempotencyMiddleware(repository.NewMemoryIdempotencyStore()).Idempotent(handler)
+
+	idempotent(httptest.NewRecorder(), newIdempotentRequest(apolloPrincipal, "create-1", `{}`), nil)
+	idempotent(httptest.NewRecorder(), newIdempotentRequest(apolloPrincipal, "create-1", `{}`), nil)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotentRejectsLongKey(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	idempotent := middleware.NewIdempotencyMiddleware(repository.NewMemoryIdempotencyStore()).Idempotent(handler)
+
+	recorder := httptest.NewRecorder()
+	idempotent(recorder, newIdempotentRequest(apolloPrincipal, strings.Repeat("k", 256), `{}`), nil)
+
+	assert.Equal(t, 400, recorder.Code)
+}
@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryIdempotencyStoreReserve(t *testing.T) {
+	idempotencyStore := repository.NewMemoryIdempotencyStore()
+
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	_, reserved, err := idempotencyStore.Reserve(ctx, "key", "hash", now, time.Minute)
+
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	record, reserved, _ := idempotencyStore.Reserve(ctx, "key", "hash", now, time.Minute)
+
+	assert.False(t, reserved)
+	assert.Equal(t, 0, record.StatusCode)
+
+	completed := entity.IdempotencyRecord{Key: "key", RequestHash: "hash", StatusCode: 201, Body: []byte("{}"), ExpiresAt: now.Add(time.Hour).Unix()}
+
+	assert.NoError(t, idempotencyStore.Complete(ctx, completed))
+
+	record, reserved, _ = idempotencyStore.Reserve(ctx, "key", "hash", now.Add(30*time.Minute), time.Minute)
+
+	assert.False(t, reserved)
+	assert.Equal(t, completed, record)
+
+	// Once expired the key can be used again.
+	_, reserved, _ = idempotencyStore.Reserve(ctx, "key", "other", now.Add(2*time.Hour), time.Minute)
+
+	assert.True(t, reserved)
+
+	assert.NoError(t, idempotencyStore.Release(ctx, "key"))
+
+	_, reserved, _ = idempotencyStore.Reserve(ctx, "key", "other", now.Add(2*time.Hour), time.Minute)
+
+	assert.True(t, reserved)
+}
+
+func TestDbIdempotencyStoreReserveTakenKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	idempotencyStore := repository.NewDbIdempotencyStore(db)
+	now := time.Unix(1700000000, 0)
+
+	rows := sqlmock.NewRows([]string{"idempotency_key", "request_hash", "status_code", "response_header", "response_body", "expires_at"}).AddRow("key", "hash", 201, `{"Location":["/api/todo/7"]}`, []byte(`{"message":"new todo created"}`), now.Add(time.Hour).Unix())
+
+	mock.ExpectPrepare("DELETE FROM idempotency_keys WHERE idempotency_key = \\? AND expires_at < \\?").ExpectExec().WithArgs("key", now.Unix()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare("INSERT INTO idempotency_keys").ExpectExec().WithArgs("key", "hash", now.Add(time.Minute).Unix()).WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'key' for key 'idempotency_keys.PRIMARY'"})
+	mock.ExpectPrepare("SELECT (.+) FROM idempotency_keys WHERE idempotency_key = \\?").ExpectQuery().WithArgs("key").WillReturnRows(rows)
+
+	record, reserved, errReserve := idempotencyStore.Reserve(context.Background(), "key", "hash", now, time.Minute)
+
+	assert.NoError(t, errReserve)
+	assert.False(t, reserved)
+	assert.Equal(t, 201, record.StatusCode)
+	assert.Equal(t, []string{"/api/todo/7"}, record.Header["Location"])
+	assert.Equal(t, `{"message":"new todo created"}`, string(record.Body))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDbIdempotencyStoreComplete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	idempotencyStore := repository.NewDbIdempotencyStore(db)
+
+	record := entity.IdempotencyRecord{
+		Key:         "key",
+		RequestHash: "hash",
+		StatusCode:  201,
+		Header:      map[string][]string{"Etag": {`"1"`}},
+		Body:        []byte("{}"),
+		ExpiresAt:   1700086400,
+	}
+
+	mock.ExpectPrepare("UPDATE idempotency_keys SET status_code = \\?, response_header = \\?, response_body = \\?, expires_at = \\? WHERE idempotency_key = \\?").ExpectExec().WithArgs(201, []byte(`{"Etag":["\"1\""]}`), []byte("{}"), int64(1700086400), "key").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, idempotencyStore.Complete(context.Background(), record))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
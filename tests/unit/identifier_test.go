@@ -0,0 +1,26 @@
+package unit
+
+import (
+	"go_todo_api/internal/helper"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"budi":                "budi",
+		" Budi\t":             "budi",
+		"ＢＵＤＩ":                "budi",
+		"Straße":              "strasse",
+		"ﬁona":                "fiona",
+		"Budi@Example.XYZ":    "budi@example.xyz",
+		"ΟΔΥΣΣΕΥΣ@example.gr": "οδυσσευσ@example.gr",
+	}
+
+	for identifier, expected := range tests {
+		assert.Equal(t, expected, helper.NormalizeIdentifier(identifier), identifier)
+	}
+
+	assert.Equal(t, helper.NormalizeIdentifier("Budi"), helper.NormalizeIdentifier(helper.NormalizeIdentifier("Budi")))
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var jobRepository = repository.NewJobRepository()
+
+var jobColumns = []string{"id", "job_type", "payload", "unique_key", "status", "attempts", "run_at", "last_error", "finished_at", "created_at"}
+
+func TestJobRepositoryInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("INSERT INTO jobs (.+) ON DUPLICATE KEY UPDATE id = id").ExpectExec().WithArgs("cleanup", "null", "schedule:cleanup:2024-03-20T03:00:00Z", 0).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	errInsert := jobRepository.Insert(context.Background(), db, entity.Job{Type: "cleanup", Payload: "null", UniqueKey: "schedule:cleanup:2024-03-20T03:00:00Z"}, 0)
+
+	assert.NoError(t, errInsert)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepositoryGetDueForUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(jobColumns).
+		AddRow(1, "cleanup", "null", "schedule:cleanup:2024-03-20T03:00:00Z", entity.JobPending, 0, "2024-03-20 03:00:00", nil, nil, "2024-03-20 03:00:00").
+		AddRow(2, "reminder", `{"todo_id":3}`, nil, entity.JobRunning, 1, "2024-03-20 03:01:30", "context deadline exceeded", nil, "2024-03-20 03:00:00")
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT (.+) FROM jobs WHERE (.+) AND job_type IN \\(\\?, \\?\\) (.+) FOR UPDATE SKIP LOCKED").ExpectQuery().WithArgs(entity.JobPending, entity.JobRunning, "cleanup", "reminder", 4).WillReturnRows(rows)
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	jobs, errGet := jobRepository.GetDueForUpdate(context.Background(), tx, []string{"cleanup", "reminder"}, 4)
+
+	assert.NoError(t, errGet)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, "schedule:cleanup:2024-03-20T03:00:00Z", jobs[0].UniqueKey)
+	assert.Equal(t, "", jobs[1].UniqueKey)
+	assert.Equal(t, "context deadline exceeded", jobs[1].LastError)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepositoryGetDueForUpdateWithoutTypes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	jobs, errGet := jobRepository.GetDueForUpdate(context.Background(), tx, nil, 4)
+
+	assert.NoError(t, errGet)
+	assert.Empty(t, jobs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepositoryRecordFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE jobs SET status (.+) WHERE id = \\? AND status = \\? AND attempts = \\?").ExpectExec().WithArgs(entity.JobFailed, entity.JobFailed, entity.JobFailed, "timeout", 0, entity.JobFailed, entity.JobFailed, int64(1), entity.JobRunning, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errRecord := jobRepository.RecordFailure(context.Background(), db, entity.Job{Id: 1, Status: entity.JobFailed, Attempts: 5, LastError: "timeout"}, 0)
+
+	assert.NoError(t, errRecord)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepositoryCompleteAfterLostClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	// Another runner took the job over and is on its third attempt.
+	mock.ExpectPrepare("UPDATE jobs SET status = \\?, unique_key = NULL").ExpectExec().WithArgs(entity.JobSucceeded, int64(1), entity.JobRunning, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	errComplete := jobRepository.Complete(context.Background(), db, entity.Job{Id: 1, Status: entity.JobRunning, Attempts: 2})
+
+	assert.ErrorIs(t, errComplete, helper.ErrRowsNotAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepositoryDeleteFinished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("DELETE FROM jobs WHERE finished_at").ExpectExec().WithArgs(86400, 1000).WillReturnResult(sqlmock.NewResult(0, 12))
+
+	deleted, errDelete := jobRepository.DeleteFinished(context.Background(), db, 24*time.Hour, 1000)
+
+	assert.NoError(t, errDelete)
+	assert.Equal(t, int64(12), deleted)
+}
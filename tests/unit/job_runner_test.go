@@ -0,0 +1,356 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/service"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type JobRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *JobRepositoryMock) Insert(ctx context.Context, db *sql.DB, job entity.Job, delay time.Duration) error {
+	args := mock.Called(ctx, db, job, delay)
+	return args.Error(0)
+}
+
+func (mock *JobRepositoryMock) InsertInTx(ctx context.Context, tx *sql.Tx, job entity.Job, delay time.Duration) error {
+	args := mock.Called(ctx, tx, job, delay)
+	return args.Error(0)
+}
+
+func (mock *JobRepositoryMock) GetDueForUpdate(ctx context.Context, tx *sql.Tx, jobTypes []string, limit int) ([]entity.Job, error) {
+	args := mock.Called(ctx, tx, jobTypes, limit)
+	return args.Get(0).([]entity.Job), args.Error(1)
+}
+
+func (mock *JobRepositoryMock) Start(ctx context.Context, tx *sql.Tx, jobId int64, lease time.Duration) error {
+	args := mock.Called(ctx, tx, jobId, lease)
+	return args.Error(0)
+}
+
+func (mock *JobRepositoryMock) Complete(ctx context.Context, db *sql.DB, job entity.Job) error {
+	args := mock.Called(ctx, db, job)
+	return args.Error(0)
+}
+
+func (mock *JobRepositoryMock) RecordFailure(ctx context.Context, db *sql.DB, job entity.Job, retryIn time.Duration) error {
+	args := mock.Called(ctx, db, job, retryIn)
+	return args.Error(0)
+}
+
+func (mock *JobRepositoryMock) Release(ctx context.Context, db *sql.DB, job entity.Job) error {
+	args := mock.Called(ctx, db, job)
+	return args.Error(0)
+}
+
+func (mock *JobRepositoryMock) DeleteFinished(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error) {
+	args := mock.Called(ctx, db, olderThan, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (mock *JobRepositoryMock) InsertScheduleRun(ctx context.Context, tx *sql.Tx, scheduleName string, runAt time.Time) error {
+	args := mock.Called(ctx, tx, scheduleName, runAt)
+	return args.Error(0)
+}
+
+func (mock *JobRepositoryMock) DeleteScheduleRuns(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error) {
+	args := mock.Called(ctx, db, olderThan, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type jobHandlerStub struct {
+	jobType string
+	timeout time.Duration
+	handle  func(ctx context.Context, job entity.Job) error
+}
+
+func (handler *jobHandlerStub) Type() string {
+	return handler.jobType
+}
+
+func (handler *jobHandlerStub) Timeout() time.Duration {
+	return handler.timeout
+}
+
+func (handler *jobHandlerStub) Handle(ctx context.Context, job entity.Job) error {
+	return handler.handle(ctx, job)
+}
+
+// newTestJobRunner expects the runner to claim jobs once, for the handler's
+// timeout plus 30 seconds. Claims pass a live *sql.Tx, which testify prints
+// when asserting expectations while database/sql still writes to it, so tests
+// count the calls instead.
+func newTestJobRunner(t *testing.T, jobRepositoryMock *JobRepositoryMock, handler service.JobHandler, config service.JobConfig, jobs []entity.Job) service.JobRunner {
+	db, sqlMock, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+	t.Cleanup(func() { db.Close() })
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+
+	timeout := handler.Timeout()
+
+	if timeout == 0 {
+		timeout = config.Timeout
+	}
+
+	jobRepositoryMock.On("GetDueForUpdate", mock.Anything, mock.AnythingOfType("*sql.Tx"), []string{handler.Type()}, config.Concurrency).Return(jobs, nil).Once()
+	jobRepositoryMock.On("Start", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.AnythingOfType("int64"), timeout+30*time.Second).Return(nil)
+
+	return service.NewJobRunner(db, jobRepositoryMock, []service.JobHandler{handler}, nil, config)
+}
+
+// claimedJob matches the job as the runner claimed it, with the attempt it
+// is on.
+func claimedJob(jobId int64, attempts int) any {
+	return mock.MatchedBy(func(job entity.Job) bool {
+		return job.Id == jobId && job.Attempts == attempts
+	})
+}
+
+func TestJobRunnerRunDue(t *testing.T) {
+	config := service.DefaultJobConfig()
+	handler := &jobHandlerStub{jobType: "reminder", handle: func(ctx context.Context, job entity.Job) error {
+		if job.Id == 2 {
+			return errors.New("mail server down")
+		}
+
+		return nil
+	}}
+
+	jobRepositoryMock := new(JobRepositoryMock)
+	jobRunner := newTestJobRunner(t, jobRepositoryMock, handler, config, []entity.Job{
+		{Id: 1, Type: "reminder", Status: entity.JobPending},
+		{Id: 2, Type: "reminder", Status: entity.JobPending, Attempts: 2},
+	})
+
+	jobRepositoryMock.On("Complete", mock.Anything, mock.Anything, claimedJob(1, 1)).Return(nil)
+	jobRepositoryMock.On("RecordFailure", mock.Anything, mock.Anything, mock.MatchedBy(func(job entity.Job) bool {
+		return job.Id == 2 && job.Status == entity.JobPending && job.Attempts == 3 && job.LastError == "mail server down"
+	}), 4*config.InitialBackoff).Return(nil)
+
+	assert.NoError(t, jobRunner.RunDue(context.Background()))
+	jobRepositoryMock.AssertNumberOfCalls(t, "Start", 2)
+	jobRepositoryMock.AssertNumberOfCalls(t, "Complete", 1)
+	jobRepositoryMock.AssertNumberOfCalls(t, "RecordFailure", 1)
+}
+
+func TestJobRunnerFailsJobAfterMaxAttempts(t *testing.T) {
+	config := service.DefaultJobConfig()
+	handler := &jobHandlerStub{jobType: "reminder", handle: func(ctx context.Context, job entity.Job) error {
+		panic("nil map")
+	}}
+
+	jobRepositoryMock := new(JobRepositoryMock)
+	jobRunner := newTestJobRunner(t, jobRepositoryMock, handler, config, []entity.Job{
+		{Id: 1, Type: "reminder", Status: entity.JobRunning, Attempts: config.MaxAttempts - 1},
+	})
+
+	jobRepositoryMock.On("RecordFailure", mock.Anything, mock.Anything, mock.MatchedBy(func(job entity.Job) bool {
+		return job.Status == entity.JobFailed && job.LastError == "panic: nil map"
+	}), time.Duration(0)).Return(nil)
+
+	assert.NoError(t, jobRunner.RunDue(context.Background()))
+	jobRepositoryMock.AssertNumberOfCalls(t, "RecordFailure", 1)
+}
+
+func TestJobRunnerTimesOutJob(t *testing.T) {
+	config := service.DefaultJobConfig()
+	handler := &jobHandlerStub{jobType: "reminder", timeout: 10 * time.Millisecond, handle: func(ctx context.Context, job entity.Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	jobRepositoryMock := new(JobRepositoryMock)
+	jobRunner := newTestJobRunner(t, jobRepositoryMock, handler, config, []entity.Job{{Id: 1, Type: "reminder"}})
+
+	jobRepositoryMock.On("RecordFailure", mock.Anything, mock.Anything, mock.MatchedBy(func(job entity.Job) bool {
+		return job.Status == entity.JobPending && job.LastError == context.DeadlineExceeded.Error()
+	}), config.InitialBackoff).Return(nil)
+
+	assert.NoError(t, jobRunner.RunDue(context.Background()))
+	jobRepositoryMock.AssertNumberOfCalls(t, "Start", 1)
+	jobRepositoryMock.AssertNumberOfCalls(t, "RecordFailure", 1)
+}
+
+func TestJobRunnerDrainsRunningJobs(t *testing.T) {
+	config := service.DefaultJobConfig()
+	config.PollInterval = time.Hour
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handler := &jobHandlerStub{jobType: "reminder", handle: func(ctx context.Context, job entity.Job) error {
+		close(started)
+		<-finish
+		return ctx.Err()
+	}}
+
+	jobRepositoryMock := new(JobRepositoryMock)
+	jobRunner := newTestJobRunner(t, jobRepositoryMock, handler, config, []entity.Job{{Id: 1, Type: "reminder"}})
+
+	jobRepositoryMock.On("Complete", mock.Anything, mock.Anything, claimedJob(1, 1)).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	go func() {
+		jobRunner.Run(ctx)
+		close(stopped)
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case <-stopped:
+		t.Fatal("runner stopped before its job finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(finish)
+	<-stopped
+
+	jobRepositoryMock.AssertNumberOfCalls(t, "Complete", 1)
+}
+
+func TestJobRunnerReleasesJobsPastDrainTimeout(t *testing.T) {
+	config := service.DefaultJobConfig()
+	config.PollInterval = time.Hour
+	config.DrainTimeout = 10 * time.Millisecond
+
+	started := make(chan struct{})
+	handler := &jobHandlerStub{jobType: "reminder", handle: func(ctx context.Context, job entity.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	jobRepositoryMock := new(JobRepositoryMock)
+	jobRunner := newTestJobRunner(t, jobRepositoryMock, handler, config, []entity.Job{{Id: 1, Type: "reminder"}})
+
+	jobRepositoryMock.On("Release", mock.Anything, mock.Anything, claimedJob(1, 1)).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	jobRunner.Run(ctx)
+
+	jobRepositoryMock.AssertNumberOfCalls(t, "Release", 1)
+	jobRepositoryMock.AssertNumberOfCalls(t, "RecordFailure", 0)
+}
+
+func TestJobRunnerSchedule(t *testing.T) {
+	db, sqlMock, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	cron, errCron := helper.ParseCronSchedule("* * * * *")
+
+	assert.NoError(t, errCron)
+
+	jobRepositoryMock := new(JobRepositoryMock)
+	schedules := []service.JobSchedule{{Name: "cleanup", Cron: cron, JobType: service.CleanupJobType}}
+	jobRunner := service.NewJobRunner(db, jobRepositoryMock, nil, schedules, service.DefaultJobConfig())
+
+	runs := []time.Time{}
+	jobRepositoryMock.On("InsertScheduleRun", mock.Anything, mock.Anything, "cleanup", mock.Anything).Run(func(args mock.Arguments) {
+		runs = append(runs, args.Get(3).(time.Time))
+	}).Return(nil)
+
+	jobs := []entity.Job{}
+	jobRepositoryMock.On("InsertInTx", mock.Anything, mock.Anything, mock.Anything, time.Duration(0)).Run(func(args mock.Arguments) {
+		jobs = append(jobs, args.Get(2).(entity.Job))
+	}).Return(nil)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Not due before the next minute, and queued once when it is.
+	assert.NoError(t, jobRunner.Schedule(ctx, now.Add(-time.Minute)))
+	assert.NoError(t, jobRunner.Schedule(ctx, now.Add(2*time.Minute)))
+	assert.NoError(t, jobRunner.Schedule(ctx, now.Add(2*time.Minute)))
+
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, service.CleanupJobType, jobs[0].Type)
+	assert.Equal(t, "null", jobs[0].Payload)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, "schedule:cleanup:"+runs[0].Format(time.RFC3339), jobs[0].UniqueKey)
+	assert.True(t, runs[0].After(now) && runs[0].Before(now.Add(2*time.Minute)))
+	assert.Zero(t, runs[0].Second())
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestJobRunnerScheduleQueuedByAnotherInstance(t *testing.T) {
+	db, sqlMock, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	cron, errCron := helper.ParseCronSchedule("* * * * *")
+
+	assert.NoError(t, errCron)
+
+	jobRepositoryMock := new(JobRepositoryMock)
+	schedules := []service.JobSchedule{{Name: "cleanup", Cron: cron, JobType: service.CleanupJobType}}
+	jobRunner := service.NewJobRunner(db, jobRepositoryMock, nil, schedules, service.DefaultJobConfig())
+
+	// The run is still recorded after its job finished and lost its unique key.
+	jobRepositoryMock.On("InsertScheduleRun", mock.Anything, mock.Anything, "cleanup", mock.Anything).Return(helper.ErrConflict)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectRollback()
+
+	assert.NoError(t, jobRunner.Schedule(context.Background(), time.Now().Add(2*time.Minute)))
+	jobRepositoryMock.AssertNotCalled(t, "InsertInTx", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCleanupJob(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	config := service.DefaultCleanupConfig()
+	outboxRepositoryMock := new(OutboxRepositoryMock)
+	jobRepositoryMock := new(JobRepositoryMock)
+	reminderRepositoryMock := new(ReminderRepositoryMock)
+	cleanupJob := service.NewCleanupJob(db, outboxRepositoryMock, jobRepositoryMock, reminderRepositoryMock, config)
+
+	ctx := context.Background()
+
+	// A full batch means more may be left.
+	outboxRepositoryMock.On("DeletePublished", ctx, db, config.Retention, config.BatchSize).Return(int64(config.BatchSize), nil).Once()
+	outboxRepositoryMock.On("DeletePublished", ctx, db, config.Retention, config.BatchSize).Return(int64(3), nil).Once()
+	jobRepositoryMock.On("DeleteFinished", ctx, db, config.Retention, config.BatchSize).Return(int64(0), nil).Once()
+	jobRepositoryMock.On("DeleteScheduleRuns", ctx, db, config.Retention, config.BatchSize).Return(int64(7), nil).Once()
+	reminderRepositoryMock.On("DeleteOld", ctx, db, config.Retention, config.BatchSize).Return(int64(5), nil).Once()
+
+	assert.NoError(t, cleanupJob.Handle(ctx, entity.Job{Type: service.CleanupJobType}))
+	outboxRepositoryMock.AssertExpectations(t)
+	jobRepositoryMock.AssertExpectations(t)
+	reminderRepositoryMock.AssertExpectations(t)
+}
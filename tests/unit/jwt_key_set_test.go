@@ -0,0 +1,188 @@
+package unit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/response"
+	testhelper "go_todo_api/tests/test_helper"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func privateKeyPem(t *testing.T, privateKey any) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+
+	assert.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicKeyPem(t *testing.T, publicKey any) []byte {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+
+	assert.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestJwtKeySetSignAndValidate(t *testing.T) {
+	rsaKey, errRsa := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, errRsa)
+
+	_, edKey, errEd := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, errEd)
+
+	for kid, privateKey := range map[string]any{"rsa-1": rsaKey, "ed-1": edKey} {
+		jwtKey, errParse := helper.ParseJwtKey(kid, privateKeyPem(t, privateKey))
+		assert.NoError(t, errParse)
+
+		jwtKeySet, errKeySet := helper.NewJwtKeySet(kid, nil, jwtKey)
+		assert.NoError(t, errKeySet)
+
+		tokenStr, errGenerate := jwtKeySet.GenerateJWT("apollo", time.Now().Add(time.Minute).Unix(), jwt.MapClaims{"ver": 2})
+		assert.NoError(t, errGenerate)
+
+		token, errValidate := jwtKeySet.ValidateJWT(tokenStr)
+		assert.NoError(t, errValidate)
+
+		sub, _ := token.Claims.GetSubject()
+
+		assert.Equal(t, "apollo", sub)
+		assert.Equal(t, kid, token.Header["kid"])
+		assert.Equal(t, 2, helper.GetTokenVersion(token))
+	}
+}
+
+func TestJwtKeySetRotation(t *testing.T) {
+	oldPublicKey, oldPrivateKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newPrivateKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	oldKey, _ := helper.ParseJwtKey("2024-01", privateKeyPem(t, oldPrivateKey))
+	newKey, _ := helper.ParseJwtKey("2024-02", privateKeyPem(t, newPrivateKey))
+
+	oldKeySet, _ := helper.NewJwtKeySet("2024-01", nil, oldKey)
+
+	oldTokenStr, errGenerate := oldKeySet.GenerateJWT("apollo", time.Now().Add(time.Hour).Unix(), nil)
+	assert.NoError(t, errGenerate)
+
+	// After rotation only the public half of the old key is kept.
+	retiredKey, errRetired := helper.ParseJwtKey("2024-01", publicKeyPem(t, oldPublicKey))
+	assert.NoError(t, errRetired)
+
+	rotatedKeySet, errRotated := helper.NewJwtKeySet("2024-02", nil, retiredKey, newKey)
+	assert.NoError(t, errRotated)
+
+	_, errValidateOld := rotatedKeySet.ValidateJWT(oldTokenStr)
+	assert.NoError(t, errValidateOld)
+
+	newTokenStr, _ := rotatedKeySet.GenerateJWT("apollo", time.Now().Add(time.Hour).Unix(), nil)
+	newToken, _ := rotatedKeySet.ValidateJWT(newTokenStr)
+
+	assert.Equal(t, "2024-02", newToken.Header["kid"])
+
+	_, errRetiredSigning := helper.NewJwtKeySet("2024-01", nil, retiredKey, newKey)
+	assert.ErrorIs(t, errRetiredSigning, helper.ErrJwtKeyNotFound)
+
+	// Once the old key is dropped its tokens stop validating.
+	droppedKeySet, _ := helper.NewJwtKeySet("2024-02", nil, newKey)
+
+	_, errValidateDropped := droppedKeySet.ValidateJWT(oldTokenStr)
+	assert.ErrorIs(t, errValidateDropped, helper.ErrorTokenInvalid)
+}
+
+func TestJwtKeySetLegacyHmacKey(t *testing.T) {
+	legacyKeySet, errLegacy := helper.NewJwtKeySet("", []byte("secret"))
+	assert.NoError(t, errLegacy)
+
+	legacyTokenStr, _ := legacyKeySet.GenerateJWT("apollo", time.Now().Add(time.Hour).Unix(), nil)
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	jwtKey, _ := helper.ParseJwtKey("ed-1", privateKeyPem(t, edKey))
+
+	jwtKeySet, _ := helper.NewJwtKeySet("ed-1", []byte("secret"), jwtKey)
+
+	_, errValidateLegacy := jwtKeySet.ValidateJWT(legacyTokenStr)
+	assert.NoError(t, errValidateLegacy)
+
+	// An HS256 token claiming an asymmetric kid must not be accepted.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "apollo"})
+	forged.Header["kid"] = "ed-1"
+	forgedStr, _ := forged.SignedString([]byte("secret"))
+
+	_, errValidateForged := jwtKeySet.ValidateJWT(forgedStr)
+	assert.ErrorIs(t, errValidateForged, helper.ErrorTokenInvalid)
+
+	assert.Len(t, jwtKeySet.Jwks().Keys, 1)
+}
+
+func TestJwtKeySetExpiredToken(t *testing.T) {
+	jwtKeySet := testhelper.NewJwtKeySet("test")
+
+	tokenStr, _ := jwtKeySet.GenerateJWT("apollo", time.Now().Add(-time.Minute).Unix(), nil)
+
+	token, err := jwtKeySet.ValidateJWT(tokenStr)
+
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	assert.NotErrorIs(t, err, helper.ErrorTokenInvalid)
+
+	sub, _ := token.Claims.GetSubject()
+
+	assert.Equal(t, "apollo", sub)
+}
+
+func TestLoadJwtKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edPublicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "rsa-2.pem"), privateKeyPem(t, rsaKey), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ed-1.pem"), publicKeyPem(t, edPublicKey), 0600))
+
+	jwtKeySet, err := helper.LoadJwtKeySet(dir, "rsa-2", "")
+	assert.NoError(t, err)
+
+	jwks := jwtKeySet.Jwks()
+
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, response.Jwk{Kty: "OKP", Kid: "ed-1", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: jwks.Keys[0].X}, jwks.Keys[0])
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+
+	_, errMissingKid := helper.LoadJwtKeySet(dir, "rsa-3", "")
+	assert.ErrorIs(t, errMissingKid, helper.ErrJwtKeyNotFound)
+}
+
+func TestJwksControllerGetJwks(t *testing.T) {
+	jwksController := controller.NewJwksController(testhelper.NewJwtKeySet("test"))
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/.well-known/jwks.json", nil)
+	recorder := httptest.NewRecorder()
+
+	jwksController.GetJwks(recorder, request, httprouter.Params{})
+
+	result := recorder.Result()
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+
+	jwks := response.JwksResponse{}
+
+	assert.NoError(t, json.NewDecoder(result.Body).Decode(&jwks))
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "test", jwks.Keys[0].Kid)
+}
@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/middleware"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	customvalidator "go_todo_api/internal/validator"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNegotiateLocale(t *testing.T) {
+	testCases := map[string]string{
+		"":                           "en",
+		"id":                         "id",
+		"id-ID,id;q=0.9,en;q=0.8":    "id",
+		"en-US,en;q=0.9,id;q=0.8":    "en",
+		"fr-FR,fr;q=0.9":             "en",
+		"fr-FR,fr;q=0.9,id;q=0.5":    "id",
+		"this is not a language tag": "en",
+	}
+
+	for acceptLanguage, expected := range testCases {
+		assert.Equal(t, expected, helper.NegotiateLocale(acceptLanguage), acceptLanguage)
+	}
+}
+
+func TestMessageCatalogsHaveTheSameIds(t *testing.T) {
+	assert.ElementsMatch(t, helper.MessageIds(helper.LocaleEnglish), helper.MessageIds(helper.LocaleIndonesian))
+}
+
+func TestMessageFallsBack(t *testing.T) {
+	assert.Equal(t, "todo ditemukan", helper.Message("id", "todo.found"))
+	assert.Equal(t, "todo found", helper.Message("fr", "todo.found"))
+	assert.Equal(t, "unknown.message", helper.Message("id", "unknown.message"))
+}
+
+func TestWriteResponseTranslatesMessage(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set(helper.ContentLanguageHeader, "id")
+
+	helper.WriteResponse(recorder, helper.ResponseData{StatusCode: 200, Message: "todo.found"})
+
+	standardResponse := response.StandardResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&standardResponse))
+	assert.Equal(t, "todo ditemukan", standardResponse.Message)
+}
+
+func TestWriteErrorResponseTranslatesDetail(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set(helper.ContentLanguageHeader, "id")
+
+	helper.WriteErrorResponse(recorder, helper.ErrTodoNotFound)
+
+	problem := response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+	assert.Equal(t, "todo.not_found", problem.Code)
+	assert.Equal(t, "todo tidak ditemukan", problem.Detail)
+}
+
+func TestWriteErrorResponseTranslatesFieldErrors(t *testing.T) {
+	err := customvalidator.NewValidator().StructCtx(context.Background(), request.UserCreateRequest{
+		Username:    "budi",
+		Password:    "secret",
+		Email:       "budi@example.xyz",
+		PhoneNumber: "0512345",
+	})
+
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set(helper.ContentLanguageHeader, "id")
+
+	helper.WriteErrorResponse(recorder, err)
+
+	problem := response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+	assert.Equal(t, "validasi gagal", problem.Detail)
+	assert.Equal(t, []response.FieldErrorResponse{
+		{Field: "name", Rule: "required", Message: "name wajib diisi"},
+		{Field: "phone_number", Rule: "phone", Message: "phone_number harus berupa nomor telepon yang valid"},
+	}, problem.Errors)
+}
+
+func TestAuthMiddlewareUsesPreferredLocale(t *testing.T) {
+	authServiceMock := new(AuthServiceMock)
+	authMiddleware := middleware.NewAuthMiddleware(authServiceMock, new(ApiTokenServiceMock), new(OauthServiceMock))
+
+	principal := apolloPrincipal
+	principal.Locale = "id"
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/todo/1", nil)
+	request.Header.Set("Authorization", "Bearer unittest.jwt")
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set(helper.ContentLanguageHeader, "en")
+
+	authServiceMock.On("Authenticate", mock.Anything, "unittest.jwt").Return(principal, nil)
+
+	authMiddleware.Authenticate(func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		helper.WriteResponse(w, helper.ResponseData{StatusCode: 200, Message: "todo.found"})
+	})(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, "id", recorder.Result().Header.Get(helper.ContentLanguageHeader))
+	assert.Contains(t, recorder.Body.String(), "todo ditemukan")
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func lockAfterTwo(failures int) time.Duration {
+	if failures >= 2 {
+		return time.Minute
+	}
+
+	return 0
+}
+
+func TestMemoryLoginAttemptStoreWindow(t *testing.T) {
+	loginAttemptStore := repository.NewMemoryLoginAttemptStore()
+
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	_, errNotFound := loginAttemptStore.Get(ctx, "username:apollo")
+	assert.ErrorIs(t, errNotFound, helper.ErrNotFound)
+
+	loginAttemptStore.Reserve(ctx, "username:apollo", now, 15*time.Minute, lockAfterTwo)
+	loginAttempt, _ := loginAttemptStore.Reserve(ctx, "username:apollo", now.Add(time.Minute), 15*time.Minute, lockAfterTwo)
+
+	assert.Equal(t, 2, loginAttempt.Failures)
+	assert.Equal(t, now.Add(2*time.Minute).Unix(), loginAttempt.LockedUntil)
+
+	// A locked key isn't counted.
+	_, errLocked := loginAttemptStore.Reserve(ctx, "username:apollo", now.Add(90*time.Second), 15*time.Minute, lockAfterTwo)
+
+	throttledError := &helper.LoginThrottledError{}
+	assert.ErrorAs(t, errLocked, &throttledError)
+	assert.Equal(t, 30*time.Second, throttledError.RetryAfter)
+
+	assert.NoError(t, loginAttemptStore.Release(ctx, "username:apollo"))
+
+	loginAttempt, _ = loginAttemptStore.Get(ctx, "username:apollo")
+
+	assert.Equal(t, 1, loginAttempt.Failures)
+	assert.Equal(t, now.Add(2*time.Minute).Unix(), loginAttempt.LockedUntil)
+
+	// An attempt long after the last one starts the count over.
+	loginAttempt, _ = loginAttemptStore.Reserve(ctx, "username:apollo", now.Add(time.Hour), 15*time.Minute, lockAfterTwo)
+
+	assert.Equal(t, 1, loginAttempt.Failures)
+
+	assert.NoError(t, loginAttemptStore.Reset(ctx, "username:apollo"))
+
+	_, errReset := loginAttemptStore.Get(ctx, "username:apollo")
+	assert.ErrorIs(t, errReset, helper.ErrNotFound)
+}
+
+func TestDbLoginAttemptStoreReserve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	loginAttemptStore := repository.NewDbLoginAttemptStore(db)
+	now := time.Unix(1700000000, 0)
+
+	mock.ExpectPrepare("INSERT IGNORE INTO login_attempts").ExpectExec().WithArgs("ip:203.0.113.7").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+
+	rows := sqlmock.NewRows([]string{"attempt_key", "failures", "last_failure_at", "locked_until"}).AddRow("ip:203.0.113.7", 1, now.Add(-time.Minute).Unix(), 0)
+
+	mock.ExpectPrepare("SELECT (.+) FROM login_attempts WHERE attempt_key = \\? FOR UPDATE").ExpectQuery().WithArgs("ip:203.0.113.7").WillReturnRows(rows)
+	mock.ExpectPrepare("UPDATE login_attempts SET failures = \\?, last_failure_at = \\?, locked_until = \\?").ExpectExec().WithArgs(2, now.Unix(), now.Add(time.Minute).Unix(), "ip:203.0.113.7").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	loginAttempt, errReserve := loginAttemptStore.Reserve(context.Background(), "ip:203.0.113.7", now, 15*time.Minute, lockAfterTwo)
+
+	assert.NoError(t, errReserve)
+	assert.Equal(t, 2, loginAttempt.Failures)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDbLoginAttemptStoreReserveLocked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	loginAttemptStore := repository.NewDbLoginAttemptStore(db)
+	now := time.Unix(1700000000, 0)
+
+	mock.ExpectPrepare("INSERT IGNORE INTO login_attempts").ExpectExec().WithArgs("ip:203.0.113.7").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+
+	rows := sqlmock.NewRows([]string{"attempt_key", "failures", "last_failure_at", "locked_until"}).AddRow("ip:203.0.113.7", 2, now.Unix(), now.Add(time.Minute).Unix())
+
+	mock.ExpectPrepare("SELECT (.+) FROM login_attempts WHERE attempt_key = \\? FOR UPDATE").ExpectQuery().WithArgs("ip:203.0.113.7").WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	_, errReserve := loginAttemptStore.Reserve(context.Background(), "ip:203.0.113.7", now, 15*time.Minute, lockAfterTwo)
+
+	throttledError := &helper.LoginThrottledError{}
+	assert.ErrorAs(t, errReserve, &throttledError)
+	assert.Equal(t, time.Minute, throttledError.RetryAfter)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"go_todo_api/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// shiftedLoginAttemptStore moves the clock forward, so locks run out without
+// waiting for them.
+type shiftedLoginAttemptStore struct {
+	repository.LoginAttemptStore
+	shift time.Duration
+}
+
+func (store *shiftedLoginAttemptStore) Reserve(ctx context.Context, key string, now time.Time, window time.Duration, lockDuration func(failures int) time.Duration) (entity.LoginAttempt, error) {
+	return store.LoginAttemptStore.Reserve(ctx, key, now.Add(store.shift), window, lockDuration)
+}
+
+func TestLoginThrottleServiceBackoff(t *testing.T) {
+	loginAttemptStore := &shiftedLoginAttemptStore{LoginAttemptStore: repository.NewMemoryLoginAttemptStore()}
+	loginThrottleService := service.NewLoginThrottleService(loginAttemptStore, service.DefaultLoginThrottleConfig())
+
+	ctx := context.Background()
+
+	// The first three failures are free.
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, loginThrottleService.Reserve(ctx, "apollo", "203.0.113.7"))
+	}
+
+	throttledError := &helper.LoginThrottledError{}
+	expectedDelays := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second}
+
+	for _, expectedDelay := range expectedDelays {
+		assert.NoError(t, loginThrottleService.Reserve(ctx, "apollo", "203.0.113.7"))
+		assert.ErrorAs(t, loginThrottleService.Reserve(ctx, "apollo", "203.0.113.7"), &throttledError)
+		assert.InDelta(t, expectedDelay.Seconds(), throttledError.RetryAfter.Seconds(), 1)
+
+		loginAttemptStore.shift += expectedDelay
+	}
+
+	// The tenth failure locks the username out.
+	assert.NoError(t, loginThrottleService.Reserve(ctx, "apollo", "203.0.113.7"))
+	assert.ErrorAs(t, loginThrottleService.Reserve(ctx, "apollo", ""), &throttledError)
+	assert.InDelta(t, (15 * time.Minute).Seconds(), throttledError.RetryAfter.Seconds(), 1)
+
+	// The ip address is still well below its own limits.
+	assert.NoError(t, loginThrottleService.Reserve(ctx, "athena", "203.0.113.7"))
+
+	assert.NoError(t, loginThrottleService.Unlock(ctx, "APOLLO"))
+	assert.NoError(t, loginThrottleService.Reserve(ctx, "apollo", "203.0.113.7"))
+}
+
+func TestLoginThrottleServiceIpLockout(t *testing.T) {
+	loginThrottleConfig := service.DefaultLoginThrottleConfig()
+	loginThrottleConfig.Ip.FreeAttempts = 2
+	loginThrottleConfig.Ip.LockoutAfter = 3
+
+	loginThrottleService := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), loginThrottleConfig)
+
+	ctx := context.Background()
+
+	// Spraying one guess over many usernames is caught by the ip address counter.
+	for _, username := range []string{"apollo", "athena", "budi"} {
+		assert.NoError(t, loginThrottleService.Reserve(ctx, username, "203.0.113.7"))
+	}
+
+	throttledError := &helper.LoginThrottledError{}
+	assert.ErrorAs(t, loginThrottleService.Reserve(ctx, "zeus", "203.0.113.7"), &throttledError)
+	assert.NoError(t, loginThrottleService.Reserve(ctx, "zeus", "198.51.100.1"))
+
+	// A successful login doesn't clear the ip address counter.
+	assert.NoError(t, loginThrottleService.RecordSuccess(ctx, "apollo", "203.0.113.7"))
+	assert.ErrorAs(t, loginThrottleService.Reserve(ctx, "apollo", "203.0.113.7"), &throttledError)
+}
+
+func TestLoginThrottleServiceGivesBackSuccessfulAttempts(t *testing.T) {
+	loginThrottleConfig := service.DefaultLoginThrottleConfig()
+	loginThrottleConfig.Ip.FreeAttempts = 2
+	loginThrottleConfig.Ip.LockoutAfter = 3
+
+	loginThrottleService := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), loginThrottleConfig)
+
+	ctx := context.Background()
+
+	// Users sharing an address aren't locked out by logging in.
+	for _, username := range []string{"apollo", "athena", "budi", "zeus"} {
+		assert.NoError(t, loginThrottleService.Reserve(ctx, username, "203.0.113.7"))
+		assert.NoError(t, loginThrottleService.RecordSuccess(ctx, username, "203.0.113.7"))
+	}
+}
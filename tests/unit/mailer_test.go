@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/service"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type smtpEnvelope struct {
+	from string
+	to   []string
+	data string
+}
+
+// newFakeSmtpServer accepts mails on a local port, without TLS or auth, and
+// hands each one to received.
+func newFakeSmtpServer(t *testing.T) (string, <-chan smtpEnvelope) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan smtpEnvelope, 1)
+
+	go func() {
+		for {
+			conn, errAccept := listener.Accept()
+
+			if errAccept != nil {
+				return
+			}
+
+			go serveSmtp(conn, received)
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func serveSmtp(conn net.Conn, received chan<- smtpEnvelope) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	envelope := smtpEnvelope{}
+
+	reply("220 localhost fake smtp")
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			envelope.from = strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")
+			reply("250 ok")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			envelope.to = append(envelope.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 ok")
+		case command == "DATA":
+			reply("354 go ahead")
+
+			data := strings.Builder{}
+
+			for {
+				dataLine, errData := reader.ReadString('\n')
+
+				if errData != nil {
+					return
+				}
+
+				if dataLine == ".\r\n" {
+					break
+				}
+
+				data.WriteString(dataLine)
+			}
+
+			envelope.data = data.String()
+			received <- envelope
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmailChannelSendsThroughSmtp(t *testing.T) {
+	address, received := newFakeSmtpServer(t)
+	host, port, _ := net.SplitHostPort(address)
+
+	mailer := helper.NewSmtpMailer(helper.SmtpMailerConfig{Host: host, Port: port, From: "reminders@example.com"})
+	channel := service.NewEmailChannel(mailer)
+
+	targets, err := channel.Targets(context.Background(), entity.User{Id: 1, Email: "ahmad@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []service.NotificationTarget{{Key: "email", Email: "ahmad@example.com"}}, targets)
+
+	errSend := channel.Send(context.Background(), targets[0], service.Notification{Id: "reminder-7", Title: "Reminder: Pay rent", Body: "before the 5th"})
+
+	assert.NoError(t, errSend)
+
+	envelope := <-received
+
+	assert.Equal(t, "reminders@example.com", envelope.from)
+	assert.Equal(t, []string{"ahmad@example.com"}, envelope.to)
+	assert.Contains(t, envelope.data, "Subject: Reminder: Pay rent\r\n")
+	assert.Contains(t, envelope.data, "\r\n\r\nbefore the 5th")
+}
+
+func TestEmailChannelSkipsUsersWithoutEmail(t *testing.T) {
+	channel := service.NewEmailChannel(new(MailerMock))
+
+	targets, err := channel.Targets(context.Background(), entity.User{Id: 1})
+
+	assert.NoError(t, err)
+	assert.Empty(t, targets)
+}
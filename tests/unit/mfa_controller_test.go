@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MfaServiceMock struct {
+	mock.Mock
+}
+
+func (mock *MfaServiceMock) EnrollTotp(ctx context.Context, username string) (response.TotpEnrollResponse, error) {
+	args := mock.Called(ctx, username)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.TotpEnrollResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.TotpEnrollResponse), nil
+}
+
+func (mock *MfaServiceMock) ConfirmTotp(ctx context.Context, totpConfirmRequest request.TotpConfirmRequest) (response.RecoveryCodesResponse, error) {
+	args := mock.Called(ctx, totpConfirmRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.RecoveryCodesResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.RecoveryCodesResponse), nil
+}
+
+func (mock *MfaServiceMock) DisableTotp(ctx context.Context, totpDisableRequest request.TotpDisableRequest) error {
+	args := mock.Called(ctx, totpDisableRequest)
+	return args.Error(0)
+}
+
+var apolloPrincipal = helper.Principal{
+	UserId:     1,
+	Username:   "apollo",
+	AuthMethod: helper.AuthMethodSession,
+	Scopes:     helper.AllScopes,
+	Roles:      []string{helper.RoleUser},
+}
+
+func TestMfaControllerEnrollTotp(t *testing.T) {
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/me/mfa/totp", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	mfaServiceMock := new(MfaServiceMock)
+	mfaController := controller.NewMfaController(mfaServiceMock)
+
+	totpEnrollResponse := response.TotpEnrollResponse{
+		Secret:          "JBSWY3DPEHPK3PXP",
+		ProvisioningUri: "otpauth://totp/Go%20Todo%20API:apollo?secret=JBSWY3DPEHPK3PXP",
+	}
+
+	mfaServiceMock.On("EnrollTotp", request.Context(), "apollo").Return(totpEnrollResponse, nil)
+
+	mfaController.EnrollTotp(recorder, request, params)
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	enrollment := standardResposne.Data.(map[string]any)
+
+	assert.Equal(t, totpEnrollResponse.Secret, enrollment["secret"])
+	assert.Equal(t, totpEnrollResponse.ProvisioningUri, enrollment["provisioning_uri"])
+}
+
+func TestMfaControllerConfirmTotp(t *testing.T) {
+	totpConfirmRequest := request.TotpConfirmRequest{Username: "apollo", Code: "123456"}
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/me/mfa/totp/confirm", strings.NewReader(`{"code": "123456"}`))
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	mfaServiceMock := new(MfaServiceMock)
+	mfaController := controller.NewMfaController(mfaServiceMock)
+
+	recoveryCodesResponse := response.RecoveryCodesResponse{RecoveryCodes: []string{"abcd-efgh", "ijkl-mnop"}}
+
+	mfaServiceMock.On("ConfirmTotp", request.Context(), totpConfirmRequest).Return(recoveryCodesResponse, nil)
+
+	mfaController.ConfirmTotp(recorder, request, params)
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	recoveryCodes := standardResposne.Data.(map[string]any)["recovery_codes"].([]any)
+
+	assert.Len(t, recoveryCodes, 2)
+}
+
+func TestMfaControllerDisableTotp(t *testing.T) {
+	totpDisableRequest := request.TotpDisableRequest{Username: "apollo", Code: "abcd-efgh", IpAddress: "203.0.113.7"}
+
+	request := httptest.NewRequest("DELETE", "http://localhost:8080/api/me/mfa/totp", strings.NewReader(`{"code": "abcd-efgh"}`))
+	request.RemoteAddr = "203.0.113.7:51234"
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	mfaServiceMock := new(MfaServiceMock)
+	mfaController := controller.NewMfaController(mfaServiceMock)
+
+	mfaServiceMock.On("DisableTotp", request.Context(), totpDisableRequest).Return(nil)
+
+	mfaController.DisableTotp(recorder, request, params)
+
+	result := recorder.Result()
+
+	assert.Equal(t, 204, result.StatusCode)
+}
+
+func TestMfaControllerWithoutPrincipal(t *testing.T) {
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/me/mfa/totp", nil)
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	mfaController := controller.NewMfaController(new(MfaServiceMock))
+
+	mfaController.EnrollTotp(recorder, request, params)
+
+	assert.Equal(t, 401, recorder.Result().StatusCode)
+}
@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var mfaRepository = repository.NewMfaRepository()
+
+func TestMfaRepositoryGetTotp(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"user_id", "secret", "is_enabled", "last_used_step"}).AddRow(1, "JBSWY3DPEHPK3PXP", true, 100)
+
+	mock.ExpectPrepare("SELECT user_id, secret, is_enabled, last_used_step FROM user_totps").ExpectQuery().WithArgs(1).WillReturnRows(rows)
+
+	userTotp, errGetTotp := mfaRepository.GetTotp(context.Background(), db, 1)
+
+	assert.NoError(t, errGetTotp)
+	assert.Equal(t, 1, userTotp.UserId)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", userTotp.Secret)
+	assert.True(t, userTotp.IsEnabled)
+	assert.Equal(t, int64(100), userTotp.LastUsedStep)
+
+	mock.ExpectPrepare("SELECT user_id, secret, is_enabled, last_used_step FROM user_totps").ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"user_id", "secret", "is_enabled", "last_used_step"}))
+
+	_, errNotFound := mfaRepository.GetTotp(context.Background(), db, 2)
+
+	assert.ErrorIs(t, errNotFound, helper.ErrNotFound)
+}
+
+func TestMfaRepositorySaveTotp(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("INSERT INTO user_totps").ExpectExec().WithArgs(1, "JBSWY3DPEHPK3PXP").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errSaveTotp := mfaRepository.SaveTotp(context.Background(), db, entity.UserTotp{UserId: 1, Secret: "JBSWY3DPEHPK3PXP"})
+
+	assert.NoError(t, errSaveTotp)
+}
+
+func TestMfaRepositoryUpdateTotpLastUsedStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE user_totps SET last_used_step").ExpectExec().WithArgs(int64(100), 1, int64(100)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errUpdate := mfaRepository.UpdateTotpLastUsedStep(context.Background(), db, 1, 100)
+
+	assert.NoError(t, errUpdate)
+
+	mock.ExpectPrepare("UPDATE user_totps SET last_used_step").ExpectExec().WithArgs(int64(100), 1, int64(100)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	errReplay := mfaRepository.UpdateTotpLastUsedStep(context.Background(), db, 1, 100)
+
+	assert.ErrorIs(t, errReplay, helper.ErrRowsNotAffected)
+}
+
+func TestMfaRepositoryInsertRecoveryCodes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+
+	tx, errTx := db.Begin()
+
+	assert.NoError(t, errTx)
+
+	prepare := mock.ExpectPrepare("INSERT INTO user_recovery_codes")
+	prepare.ExpectExec().WithArgs(1, "hash1").WillReturnResult(sqlmock.NewResult(1, 1))
+	prepare.ExpectExec().WithArgs(1, "hash2").WillReturnResult(sqlmock.NewResult(2, 1))
+
+	errInsert := mfaRepository.InsertRecoveryCodes(context.Background(), tx, 1, []string{"hash1", "hash2"})
+
+	assert.NoError(t, errInsert)
+}
+
+func TestMfaRepositoryUseRecoveryCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE user_recovery_codes SET used_at").ExpectExec().WithArgs(1, "hash1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errUse := mfaRepository.UseRecoveryCode(context.Background(), db, 1, "hash1")
+
+	assert.NoError(t, errUse)
+
+	mock.ExpectPrepare("UPDATE user_recovery_codes SET used_at").ExpectExec().WithArgs(1, "hash1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	errUsedTwice := mfaRepository.UseRecoveryCode(context.Background(), db, 1, "hash1")
+
+	assert.ErrorIs(t, errUsedTwice, helper.ErrRowsNotAffected)
+}
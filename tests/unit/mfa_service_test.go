@@ -0,0 +1,279 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/repository"
+	"go_todo_api/internal/service"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MfaRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *MfaRepositoryMock) GetTotp(ctx context.Context, db *sql.DB, userId int) (entity.UserTotp, error) {
+	args := mock.Called(ctx, db, userId)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.UserTotp), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.UserTotp), nil
+}
+
+func (mock *MfaRepositoryMock) SaveTotp(ctx context.Context, db *sql.DB, userTotp entity.UserTotp) error {
+	args := mock.Called(ctx, db, userTotp)
+	return args.Error(0)
+}
+
+func (mock *MfaRepositoryMock) EnableTotp(ctx context.Context, tx *sql.Tx, userId int) error {
+	args := mock.Called(ctx, tx, userId)
+	return args.Error(0)
+}
+
+func (mock *MfaRepositoryMock) DeleteTotp(ctx context.Context, tx *sql.Tx, userId int) error {
+	args := mock.Called(ctx, tx, userId)
+	return args.Error(0)
+}
+
+func (mock *MfaRepositoryMock) UpdateTotpLastUsedStep(ctx context.Context, db *sql.DB, userId int, step int64) error {
+	args := mock.Called(ctx, db, userId, step)
+	return args.Error(0)
+}
+
+func (mock *MfaRepositoryMock) InsertRecoveryCodes(ctx context.Context, tx *sql.Tx, userId int, codeHashes []string) error {
+	args := mock.Called(ctx, tx, userId, codeHashes)
+	return args.Error(0)
+}
+
+func (mock *MfaRepositoryMock) DeleteRecoveryCodes(ctx context.Context, tx *sql.Tx, userId int) error {
+	args := mock.Called(ctx, tx, userId)
+	return args.Error(0)
+}
+
+func (mock *MfaRepositoryMock) UseRecoveryCode(ctx context.Context, db *sql.DB, userId int, codeHash string) error {
+	args := mock.Called(ctx, db, userId, codeHash)
+	return args.Error(0)
+}
+
+var mfaUser = entity.User{
+	Id:          1,
+	Username:    "apollo",
+	Password:    "secret",
+	Name:        "Apollo",
+	Email:       "apollo@example.xyz",
+	PhoneNumber: "081746219124",
+}
+
+func TestMfaServiceEnrollTotp(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	mfaService := service.NewMfaService(db, userRepositoryMock, mfaRepositoryMock, new(ValidatorMock), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	ctx := context.Background()
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(mfaUser, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{}, helper.ErrNotFound)
+	mfaRepositoryMock.On("SaveTotp", ctx, db, mock.AnythingOfType("entity.UserTotp")).Return(nil)
+
+	totpEnrollResponse, err := mfaService.EnrollTotp(ctx, "apollo")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, totpEnrollResponse.Secret)
+	assert.Contains(t, totpEnrollResponse.ProvisioningUri, "otpauth://totp/")
+	assert.Contains(t, totpEnrollResponse.ProvisioningUri, "secret="+totpEnrollResponse.Secret)
+}
+
+func TestMfaServiceEnrollTotpAlreadyEnabled(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	mfaService := service.NewMfaService(db, userRepositoryMock, mfaRepositoryMock, new(ValidatorMock), service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	ctx := context.Background()
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(mfaUser, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{UserId: 1, Secret: "JBSWY3DPEHPK3PXP", IsEnabled: true}, nil)
+
+	_, err := mfaService.EnrollTotp(ctx, "apollo")
+
+	assert.ErrorIs(t, err, helper.ErrMfaAlreadyEnabled)
+}
+
+func TestMfaServiceConfirmTotp(t *testing.T) {
+	db, mockDB, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	mfaService := service.NewMfaService(db, userRepositoryMock, mfaRepositoryMock, validatorMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	secret, _ := helper.GenerateTotpSecret()
+	step := helper.TotpStep(time.Now())
+	code, _ := helper.GenerateTotpCode(secret, step)
+
+	totpConfirmRequest := request.TotpConfirmRequest{Username: "apollo", Code: code}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, totpConfirmRequest).Return(nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(mfaUser, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{UserId: 1, Secret: secret}, nil)
+	mfaRepositoryMock.On("UpdateTotpLastUsedStep", ctx, db, 1, mock.AnythingOfType("int64")).Return(nil)
+	mfaRepositoryMock.On("EnableTotp", ctx, mock.AnythingOfType("*sql.Tx"), 1).Return(nil)
+	mfaRepositoryMock.On("DeleteRecoveryCodes", ctx, mock.AnythingOfType("*sql.Tx"), 1).Return(nil)
+	mfaRepositoryMock.On("InsertRecoveryCodes", ctx, mock.AnythingOfType("*sql.Tx"), 1, mock.AnythingOfType("[]string")).Return(nil)
+
+	recoveryCodesResponse, err := mfaService.ConfirmTotp(ctx, totpConfirmRequest)
+
+	assert.NoError(t, err)
+	assert.Len(t, recoveryCodesResponse.RecoveryCodes, helper.RecoveryCodeCount)
+
+	errMock := mockDB.ExpectationsWereMet()
+	assert.NoError(t, errMock)
+}
+
+func TestMfaServiceConfirmTotpInvalidCode(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	mfaService := service.NewMfaService(db, userRepositoryMock, mfaRepositoryMock, validatorMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	secret, _ := helper.GenerateTotpSecret()
+	totpConfirmRequest := request.TotpConfirmRequest{Username: "apollo", Code: "abcdef"}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, totpConfirmRequest).Return(nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(mfaUser, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{UserId: 1, Secret: secret}, nil)
+
+	_, err := mfaService.ConfirmTotp(ctx, totpConfirmRequest)
+
+	assert.ErrorIs(t, err, helper.ErrMfaCodeInvalid)
+}
+
+func TestMfaServiceDisableTotpWithRecoveryCode(t *testing.T) {
+	db, mockDB, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	mfaService := service.NewMfaService(db, userRepositoryMock, mfaRepositoryMock, validatorMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	secret, _ := helper.GenerateTotpSecret()
+	totpDisableRequest := request.TotpDisableRequest{Username: "apollo", Code: " ABCD-EFGH "}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, totpDisableRequest).Return(nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(mfaUser, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{UserId: 1, Secret: secret, IsEnabled: true}, nil)
+	mfaRepositoryMock.On("UseRecoveryCode", ctx, db, 1, helper.HashToken("abcd-efgh")).Return(nil)
+	mfaRepositoryMock.On("DeleteRecoveryCodes", ctx, mock.AnythingOfType("*sql.Tx"), 1).Return(nil)
+	mfaRepositoryMock.On("DeleteTotp", ctx, mock.AnythingOfType("*sql.Tx"), 1).Return(nil)
+
+	err := mfaService.DisableTotp(ctx, totpDisableRequest)
+
+	assert.NoError(t, err)
+
+	errMock := mockDB.ExpectationsWereMet()
+	assert.NoError(t, errMock)
+}
+
+func TestMfaServiceDisableTotpInvalidCode(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	mfaService := service.NewMfaService(db, userRepositoryMock, mfaRepositoryMock, validatorMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), service.DefaultLoginThrottleConfig()))
+
+	secret, _ := helper.GenerateTotpSecret()
+	totpDisableRequest := request.TotpDisableRequest{Username: "apollo", Code: "wxyz-wxyz"}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, totpDisableRequest).Return(nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(mfaUser, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{UserId: 1, Secret: secret, IsEnabled: true}, nil)
+	mfaRepositoryMock.On("UseRecoveryCode", ctx, db, 1, helper.HashToken("wxyz-wxyz")).Return(helper.ErrRowsNotAffected)
+
+	err := mfaService.DisableTotp(ctx, totpDisableRequest)
+
+	assert.ErrorIs(t, err, helper.ErrMfaCodeInvalid)
+}
+
+func TestMfaServiceDisableTotpThrottled(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	loginThrottleConfig := service.DefaultLoginThrottleConfig()
+	loginThrottleConfig.Username.FreeAttempts = 1
+	loginThrottleConfig.Username.LockoutAfter = 2
+
+	userRepositoryMock := new(UserRepositoryMock)
+	mfaRepositoryMock := new(MfaRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	mfaService := service.NewMfaService(db, userRepositoryMock, mfaRepositoryMock, validatorMock, service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), loginThrottleConfig))
+
+	secret, _ := helper.GenerateTotpSecret()
+	totpDisableRequest := request.TotpDisableRequest{Username: "apollo", Code: "wxyz-wxyz", IpAddress: "203.0.113.7"}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, totpDisableRequest).Return(nil)
+	userRepositoryMock.On("GetByUsername", ctx, db, "apollo").Return(mfaUser, nil)
+	mfaRepositoryMock.On("GetTotp", ctx, db, 1).Return(entity.UserTotp{UserId: 1, Secret: secret, IsEnabled: true}, nil)
+	mfaRepositoryMock.On("UseRecoveryCode", ctx, db, 1, helper.HashToken("wxyz-wxyz")).Return(helper.ErrRowsNotAffected)
+
+	assert.ErrorIs(t, mfaService.DisableTotp(ctx, totpDisableRequest), helper.ErrMfaCodeInvalid)
+	assert.ErrorIs(t, mfaService.DisableTotp(ctx, totpDisableRequest), helper.ErrMfaCodeInvalid)
+
+	// The codes are locked now, they aren't even looked at.
+	err := mfaService.DisableTotp(ctx, totpDisableRequest)
+
+	throttledError := &helper.LoginThrottledError{}
+	assert.ErrorAs(t, err, &throttledError)
+	mfaRepositoryMock.AssertNumberOfCalls(t, "UseRecoveryCode", 2)
+}
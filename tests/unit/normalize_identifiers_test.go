@@ -0,0 +1,44 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/database"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeUserIdentifiers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "username", "email", "phone_number"}).
+		AddRow(1, "Budi", "budi@example.xyz", "+6281234567001").
+		AddRow(2, "budi", "budi2@example.xyz", "+6281234567002").
+		AddRow(3, "Athena", "Athena@Example.xyz", nil).
+		AddRow(4, "apollo", "apollo@example.xyz", "081234567004").
+		AddRow(5, "hermes", "hermes@example.xyz", "0812-3456-7005").
+		AddRow(6, "ares", "ares@example.xyz", "+6281234567005")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, username, email, phone_number FROM users ORDER BY id FOR UPDATE").WillReturnRows(rows)
+	mock.ExpectPrepare("UPDATE users SET username = \\?, email = \\?, phone_number = \\? WHERE id = \\?")
+	mock.ExpectExec("UPDATE users").WithArgs("athena", "athena@example.xyz", nil, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users").WithArgs("apollo", "apollo@example.xyz", "+6281234567004", 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	report, errNormalize := database.NormalizeUserIdentifiers(context.Background(), db, false)
+
+	assert.NoError(t, errNormalize)
+	assert.Equal(t, 6, report.Checked)
+	assert.Equal(t, 2, report.Updated)
+	assert.Equal(t, []database.IdentifierCollision{
+		{Column: "username", Normalized: "budi", UserIds: []int{1, 2}},
+		{Column: "phone_number", Normalized: "+6281234567005", UserIds: []int{5, 6}},
+	}, report.Collisions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
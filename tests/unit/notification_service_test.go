@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/service"
+	customvalidator "go_todo_api/internal/validator"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationServiceFindSettingsDefaults(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	webPushChannel := service.NewWebPushChannel(db, notificationRepositoryMock, newTestWebPushClient(t))
+	channels := []service.NotificationChannel{service.NewEmailChannel(new(MailerMock)), webPushChannel}
+	notificationService := service.NewNotificationService(db, notificationRepositoryMock, channels, new(ValidatorMock))
+
+	ctx := context.Background()
+	notificationRepositoryMock.On("GetSettings", ctx, db, 1).Return(entity.NotificationSettings{}, helper.ErrNotFound).Once()
+
+	settings, err := notificationService.FindSettings(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, response.NotificationSettingsResponse{
+		Channels:          []string{"email", "web_push", "fcm"},
+		Timezone:          "UTC",
+		AvailableChannels: []string{"email", "web_push"},
+		WebPushPublicKey:  webPushChannel.PublicKey(),
+	}, settings)
+	notificationRepositoryMock.AssertExpectations(t)
+}
+
+func TestNotificationServiceSubscribeStoresFcmToken(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	channels := []service.NotificationChannel{&notificationChannelStub{name: "fcm"}}
+	notificationService := service.NewNotificationService(db, notificationRepositoryMock, channels, validatorMock)
+
+	ctx := context.Background()
+	subscribeRequest := request.PushSubscriptionCreateRequest{UserId: 1, Channel: "fcm", Token: "device-token"}
+	subscription := entity.PushSubscription{UserId: 1, Channel: "fcm", Endpoint: "device-token"}
+
+	validatorMock.On("StructCtx", ctx, subscribeRequest).Return(nil).Once()
+	notificationRepositoryMock.On("SaveSubscription", ctx, db, subscription).Return(4, nil).Once()
+	notificationRepositoryMock.On("GetSubscription", ctx, db, 1, 4).Return(entity.PushSubscription{Id: 4, UserId: 1, Channel: "fcm", Endpoint: "device-token", CreatedAt: "2024-04-01 10:00:00"}, nil).Once()
+
+	subscriptionResponse, err := notificationService.Subscribe(ctx, subscribeRequest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, response.PushSubscriptionResponse{Id: 4, Channel: "fcm", Endpoint: "device-token", CreatedAt: "2024-04-01 10:00:00"}, subscriptionResponse)
+	notificationRepositoryMock.AssertExpectations(t)
+}
+
+func TestNotificationServiceSubscribeToUnavailableChannel(t *testing.T) {
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	channels := []service.NotificationChannel{service.NewEmailChannel(new(MailerMock))}
+	notificationService := service.NewNotificationService(nil, notificationRepositoryMock, channels, validatorMock)
+
+	ctx := context.Background()
+	subscribeRequest := request.PushSubscriptionCreateRequest{UserId: 1, Channel: "fcm", Token: "device-token"}
+
+	validatorMock.On("StructCtx", ctx, subscribeRequest).Return(nil).Once()
+
+	_, err := notificationService.Subscribe(ctx, subscribeRequest)
+
+	assert.ErrorIs(t, err, helper.ErrNotificationChannelUnavailable)
+	notificationRepositoryMock.AssertNotCalled(t, "SaveSubscription")
+}
+
+func TestNotificationServiceUnsubscribeUnknownSubscription(t *testing.T) {
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	notificationService := service.NewNotificationService(nil, notificationRepositoryMock, nil, new(ValidatorMock))
+
+	ctx := context.Background()
+	notificationRepositoryMock.On("DeleteSubscription", ctx, (*sql.DB)(nil), 1, 9).Return(helper.ErrRowsNotAffected).Once()
+
+	err := notificationService.Unsubscribe(ctx, 1, 9)
+
+	assert.ErrorIs(t, err, helper.ErrSubscriptionNotFound)
+}
+
+func TestWebPushChannelRemovesGoneSubscriptions(t *testing.T) {
+	browser := newTestBrowser(t)
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	channel := service.NewWebPushChannel(nil, notificationRepositoryMock, newTestWebPushClient(t))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	subscription := entity.PushSubscription{Id: 5, UserId: 1, Channel: "web_push", Endpoint: server.URL, P256dh: browser.p256dh(), Auth: browser.auth()}
+
+	notificationRepositoryMock.On("GetUserSubscriptions", ctx, (*sql.DB)(nil), 1, "web_push").Return([]entity.PushSubscription{subscription}, nil).Once()
+	notificationRepositoryMock.On("DeleteSubscription", ctx, (*sql.DB)(nil), 1, 5).Return(nil).Once()
+
+	targets, err := channel.Targets(ctx, entity.User{Id: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []service.NotificationTarget{{Key: "web_push:5", Subscription: subscription}}, targets)
+
+	// The reminder counts as delivered, retrying would fail the same way.
+	assert.NoError(t, channel.Send(ctx, targets[0], service.Notification{Id: "reminder-7", Title: "Reminder: Pay rent"}))
+	notificationRepositoryMock.AssertExpectations(t)
+}
+
+func TestValidationOfNotificationRequests(t *testing.T) {
+	validate := customvalidator.NewValidator()
+	ctx := context.Background()
+	browser := newTestBrowser(t)
+
+	assert.NoError(t, validate.StructCtx(ctx, request.NotificationSettingsRequest{
+		UserId:          1,
+		Channels:        []string{"email", "web_push"},
+		Timezone:        "Asia/Jakarta",
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "07:00",
+	}))
+	assert.NoError(t, validate.StructCtx(ctx, request.PushSubscriptionCreateRequest{
+		UserId:   1,
+		Channel:  "web_push",
+		Endpoint: "https://push.example.com/abc",
+		Keys:     &request.PushSubscriptionKeys{P256dh: browser.p256dh(), Auth: browser.auth()},
+	}))
+
+	errSettings := validate.StructCtx(ctx, request.NotificationSettingsRequest{
+		UserId:          1,
+		Channels:        []string{"sms"},
+		Timezone:        "Mars/Olympus",
+		QuietHoursStart: "25:00",
+	})
+
+	recorder := httptest.NewRecorder()
+	helper.WriteErrorResponse(recorder, errSettings)
+
+	problem := response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+	assert.Equal(t, []string{"oneof", "timezone", "datetime", "required_with"}, problemRules(problem))
+
+	errSubscription := validate.StructCtx(ctx, request.PushSubscriptionCreateRequest{UserId: 1, Channel: "web_push"})
+
+	recorder = httptest.NewRecorder()
+	helper.WriteErrorResponse(recorder, errSubscription)
+
+	problem = response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+	assert.Equal(t, []string{"required_if", "required_if"}, problemRules(problem))
+}
+
+func problemRules(problem response.ProblemResponse) []string {
+	rules := []string{}
+
+	for _, fieldError := range problem.Errors {
+		rules = append(rules, fieldError.Rule)
+	}
+
+	return rules
+}
@@ -0,0 +1,278 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type OauthServiceMock struct {
+	mock.Mock
+}
+
+func (mock *OauthServiceMock) RegisterClient(ctx context.Context, oauthClientCreateRequest request.OauthClientCreateRequest) (response.OauthClientCreateResponse, error) {
+	args := mock.Called(ctx, oauthClientCreateRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.OauthClientCreateResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.OauthClientCreateResponse), nil
+}
+
+func (mock *OauthServiceMock) FindClients(ctx context.Context) ([]response.OauthClientResponse, error) {
+	args := mock.Called(ctx)
+
+	if args.Get(1) != nil {
+		return nil, args.Get(1).(error)
+	}
+
+	return args.Get(0).([]response.OauthClientResponse), nil
+}
+
+func (mock *OauthServiceMock) DeleteClient(ctx context.Context, clientId string) error {
+	args := mock.Called(ctx, clientId)
+	return args.Error(0)
+}
+
+func (mock *OauthServiceMock) GetConsent(ctx context.Context, oauthAuthorizeRequest request.OauthAuthorizeRequest) (response.OauthConsentResponse, error) {
+	args := mock.Called(ctx, oauthAuthorizeRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.OauthConsentResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.OauthConsentResponse), nil
+}
+
+func (mock *OauthServiceMock) Authorize(ctx context.Context, oauthAuthorizeRequest request.OauthAuthorizeRequest) (response.OauthAuthorizeResponse, error) {
+	args := mock.Called(ctx, oauthAuthorizeRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.OauthAuthorizeResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.OauthAuthorizeResponse), nil
+}
+
+func (mock *OauthServiceMock) Token(ctx context.Context, oauthTokenRequest request.OauthTokenRequest) (response.OauthTokenResponse, error) {
+	args := mock.Called(ctx, oauthTokenRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.OauthTokenResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.OauthTokenResponse), nil
+}
+
+func (mock *OauthServiceMock) Authenticate(ctx context.Context, token string) (helper.Principal, error) {
+	args := mock.Called(ctx, token)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(helper.Principal), args.Get(1).(error)
+	}
+
+	return args.Get(0).(helper.Principal), nil
+}
+
+func TestOauthControllerGetConsent(t *testing.T) {
+	oauthAuthorizeRequest := request.OauthAuthorizeRequest{
+		UserId:              1,
+		ResponseType:        "code",
+		ClientId:            "unittest-client",
+		RedirectUri:         "https://app.example.com/callback",
+		Scope:               "todos:read",
+		State:               "xyz",
+		CodeChallenge:       helper.PkceChallenge("unittest-verifier"),
+		CodeChallengeMethod: "S256",
+	}
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/oauth/authorize?response_type=code&client_id=unittest-client&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcallback&scope=todos%3Aread&state=xyz&code_challenge_method=S256&code_challenge="+oauthAuthorizeRequest.CodeChallenge, nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+
+	recorder := httptest.NewRecorder()
+
+	oauthServiceMock := new(OauthServiceMock)
+	oauthController := controller.NewOauthController(oauthServiceMock)
+
+	oauthConsentResponse := response.OauthConsentResponse{
+		ClientId:    "unittest-client",
+		ClientName:  "Unit Test App",
+		RedirectUri: "https://app.example.com/callback",
+		Scopes:      []string{"todos:read"},
+	}
+
+	oauthServiceMock.On("GetConsent", request.Context(), oauthAuthorizeRequest).Return(oauthConsentResponse, nil)
+
+	oauthController.GetConsent(recorder, request, httprouter.Params{})
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	consent := standardResposne.Data.(map[string]any)
+
+	assert.Equal(t, "Unit Test App", consent["client_name"])
+	assert.Equal(t, false, consent["previously_granted"])
+}
+
+func TestOauthControllerAuthorizeInvalidClient(t *testing.T) {
+	jsonRequest := strings.NewReader(`{"response_type": "code", "client_id": "unknown", "redirect_uri": "https://app.example.com/callback", "code_challenge": "x", "code_challenge_method": "S256", "approve": true}`)
+
+	approvedByApollo := mock.MatchedBy(func(oauthAuthorizeRequest request.OauthAuthorizeRequest) bool {
+		return oauthAuthorizeRequest.UserId == 1 && oauthAuthorizeRequest.Approve
+	})
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/oauth/authorize", jsonRequest)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+
+	recorder := httptest.NewRecorder()
+
+	oauthServiceMock := new(OauthServiceMock)
+	oauthController := controller.NewOauthController(oauthServiceMock)
+
+	oauthServiceMock.On("Authorize", request.Context(), approvedByApollo).Return(response.OauthAuthorizeResponse{}, helper.NewOauthError("invalid_client", "unknown client"))
+
+	oauthController.Authorize(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 400, recorder.Result().StatusCode)
+}
+
+func TestOauthControllerTokenBasicAuth(t *testing.T) {
+	oauthTokenRequest := request.OauthTokenRequest{
+		GrantType:    helper.GrantTypeClientCredentials,
+		Scope:        "todos:read",
+		ClientId:     "unittest-client",
+		ClientSecret: "gtcs_unittest",
+	}
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/oauth/token", strings.NewReader("grant_type=client_credentials&scope=todos%3Aread"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth("unittest-client", "gtcs_unittest")
+
+	recorder := httptest.NewRecorder()
+
+	oauthServiceMock := new(OauthServiceMock)
+	oauthController := controller.NewOauthController(oauthServiceMock)
+
+	oauthTokenResponse := response.OauthTokenResponse{
+		AccessToken: "gto_unittest",
+		TokenType:   "Bearer",
+		ExpiresIn:   3600,
+		Scope:       "todos:read",
+	}
+
+	oauthServiceMock.On("Token", request.Context(), oauthTokenRequest).Return(oauthTokenResponse, nil)
+
+	oauthController.Token(recorder, request, httprouter.Params{})
+
+	result := recorder.Result()
+	bytes, _ := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, "no-store", result.Header.Get("Cache-Control"))
+
+	tokenResponse := map[string]any{}
+
+	json.Unmarshal(bytes, &tokenResponse)
+
+	assert.Equal(t, "gto_unittest", tokenResponse["access_token"])
+	assert.Equal(t, "Bearer", tokenResponse["token_type"])
+	assert.NotContains(t, tokenResponse, "refresh_token")
+}
+
+func TestOauthControllerTokenInvalidClient(t *testing.T) {
+	request := httptest.NewRequest("POST", "http://localhost:8080/oauth/token", strings.NewReader("grant_type=client_credentials&client_id=unittest-client&client_secret=wrong"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	recorder := httptest.NewRecorder()
+
+	oauthServiceMock := new(OauthServiceMock)
+	oauthController := controller.NewOauthController(oauthServiceMock)
+
+	oauthServiceMock.On("Token", request.Context(), mock.Anything).Return(response.OauthTokenResponse{}, helper.NewOauthError("invalid_client", "client authentication failed"))
+
+	oauthController.Token(recorder, request, httprouter.Params{})
+
+	result := recorder.Result()
+	bytes, _ := io.ReadAll(result.Body)
+
+	assert.Equal(t, 401, result.StatusCode)
+	assert.NotEmpty(t, result.Header.Get("WWW-Authenticate"))
+	assert.Contains(t, string(bytes), `"error":"invalid_client"`)
+}
+
+func TestOauthControllerRegisterClient(t *testing.T) {
+	oauthClientCreateRequest := request.OauthClientCreateRequest{
+		Name:         "Unit Test App",
+		RedirectUris: []string{"https://app.example.com/callback"},
+		Scopes:       []string{"todos:read"},
+		GrantTypes:   []string{"authorization_code", "refresh_token"},
+		Confidential: true,
+	}
+
+	jsonRequest := strings.NewReader(`{"name": "Unit Test App", "redirect_uris": ["https://app.example.com/callback"], "scopes": ["todos:read"], "grant_types": ["authorization_code", "refresh_token"], "confidential": true}`)
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/admin/oauth/clients", jsonRequest)
+
+	recorder := httptest.NewRecorder()
+
+	oauthServiceMock := new(OauthServiceMock)
+	oauthController := controller.NewOauthController(oauthServiceMock)
+
+	oauthClientCreateResponse := response.OauthClientCreateResponse{
+		OauthClientResponse: response.OauthClientResponse{ClientId: "unittest-client", Name: "Unit Test App", Confidential: true},
+		ClientSecret:        "gtcs_unittest",
+	}
+
+	oauthServiceMock.On("RegisterClient", request.Context(), oauthClientCreateRequest).Return(oauthClientCreateResponse, nil)
+
+	oauthController.RegisterClient(recorder, request, httprouter.Params{})
+
+	result := recorder.Result()
+	bytes, _ := io.ReadAll(result.Body)
+
+	assert.Equal(t, 201, result.StatusCode)
+
+	standardResposne := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResposne)
+
+	oauthClient := standardResposne.Data.(map[string]any)
+
+	assert.Equal(t, "unittest-client", oauthClient["client_id"])
+	assert.Equal(t, "gtcs_unittest", oauthClient["client_secret"])
+}
+
+func TestOauthControllerDeleteClientNotFound(t *testing.T) {
+	request := httptest.NewRequest("DELETE", "http://localhost:8080/api/admin/oauth/clients/unknown", nil)
+	params := httprouter.Params{{Key: "clientId", Value: "unknown"}}
+
+	recorder := httptest.NewRecorder()
+
+	oauthServiceMock := new(OauthServiceMock)
+	oauthController := controller.NewOauthController(oauthServiceMock)
+
+	oauthServiceMock.On("DeleteClient", request.Context(), "unknown").Return(helper.ErrNotFound)
+
+	oauthController.DeleteClient(recorder, request, params)
+
+	assert.Equal(t, 404, recorder.Result().StatusCode)
+}
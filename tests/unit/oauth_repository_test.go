@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var oauthRepository = repository.NewOauthRepository()
+
+func TestOauthRepositoryGetClient(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	columns := []string{"id", "client_id", "client_secret_hash", "name", "redirect_uris", "scopes", "grant_types", "created_at"}
+	rows := sqlmock.NewRows(columns).AddRow(1, "public-client", nil, "Mobile App", "https://app.example.com/callback", "todos:read", "authorization_code", "2024-01-01 10:00:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM oauth_clients WHERE client_id = \\?").ExpectQuery().WithArgs("public-client").WillReturnRows(rows)
+
+	oauthClient, errGet := oauthRepository.GetClient(context.Background(), db, "public-client")
+
+	assert.NoError(t, errGet)
+	assert.Equal(t, "", oauthClient.ClientSecretHash)
+	assert.Equal(t, "authorization_code", oauthClient.GrantTypes)
+
+	mock.ExpectPrepare("SELECT (.+) FROM oauth_clients").ExpectQuery().WithArgs("unknown").WillReturnRows(sqlmock.NewRows(columns))
+
+	_, errNotFound := oauthRepository.GetClient(context.Background(), db, "unknown")
+
+	assert.ErrorIs(t, errNotFound, helper.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOauthRepositoryConsumeAuthorizationCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	columns := []string{"code_hash", "client_id", "user_id", "redirect_uri", "scopes", "code_challenge", "expires_at"}
+	rows := sqlmock.NewRows(columns).AddRow("hash", "client", 1, "https://app.example.com/callback", "todos:read", "challenge", "2024-01-01 10:10:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM oauth_authorization_codes WHERE code_hash = \\? AND expires_at > CURRENT_TIMESTAMP").ExpectQuery().WithArgs("hash").WillReturnRows(rows)
+	mock.ExpectPrepare("DELETE FROM oauth_authorization_codes").ExpectExec().WithArgs("hash").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	authorizationCode, errConsume := oauthRepository.ConsumeAuthorizationCode(context.Background(), db, "hash")
+
+	assert.NoError(t, errConsume)
+	assert.Equal(t, 1, authorizationCode.UserId)
+	assert.Equal(t, "challenge", authorizationCode.CodeChallenge)
+
+	// A concurrent exchange deleted the code first.
+	rows = sqlmock.NewRows(columns).AddRow("hash", "client", 1, "https://app.example.com/callback", "todos:read", "challenge", "2024-01-01 10:10:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM oauth_authorization_codes").ExpectQuery().WithArgs("hash").WillReturnRows(rows)
+	mock.ExpectPrepare("DELETE FROM oauth_authorization_codes").ExpectExec().WithArgs("hash").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, errConsumed := oauthRepository.ConsumeAuthorizationCode(context.Background(), db, "hash")
+
+	assert.ErrorIs(t, errConsumed, helper.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOauthRepositoryGetActiveTokenByAccessHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	columns := []string{"id", "client_id", "user_id", "username", "role", "locale", "scopes", "access_token_hash", "refresh_token_hash", "expires_at"}
+	rows := sqlmock.NewRows(columns).AddRow(3, "service-client", nil, nil, nil, nil, "todos:read", "hash", nil, "2024-01-01 11:00:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM oauth_tokens LEFT JOIN users (.+) WHERE oauth_tokens.access_token_hash = \\? AND oauth_tokens.revoked_at IS NULL").ExpectQuery().WithArgs("hash").WillReturnRows(rows)
+
+	oauthToken, errGet := oauthRepository.GetActiveTokenByAccessHash(context.Background(), db, "hash")
+
+	assert.NoError(t, errGet)
+	assert.Equal(t, 0, oauthToken.UserId)
+	assert.Equal(t, "", oauthToken.RefreshTokenHash)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOauthRepositoryRevokeTokenTwice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE oauth_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = \\? AND revoked_at IS NULL").ExpectExec().WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tx, _ := db.Begin()
+
+	errRevoke := oauthRepository.RevokeToken(context.Background(), tx, 3)
+
+	assert.ErrorIs(t, errRevoke, helper.ErrRowsNotAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOauthRepositoryRevokeUserTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE oauth_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = \\? AND revoked_at IS NULL").ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	errRevoke := oauthRepository.RevokeUserTokens(context.Background(), db, 2)
+
+	assert.NoError(t, errRevoke)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
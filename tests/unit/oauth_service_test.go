@@ -0,0 +1,440 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type OauthRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *OauthRepositoryMock) InsertClient(ctx context.Context, db *sql.DB, oauthClient entity.OauthClient) (int, error) {
+	args := mock.Called(ctx, db, oauthClient)
+	return args.Int(0), args.Error(1)
+}
+
+func (mock *OauthRepositoryMock) GetClient(ctx context.Context, db *sql.DB, clientId string) (entity.OauthClient, error) {
+	args := mock.Called(ctx, db, clientId)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.OauthClient), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.OauthClient), nil
+}
+
+func (mock *OauthRepositoryMock) GetClients(ctx context.Context, db *sql.DB) ([]entity.OauthClient, error) {
+	args := mock.Called(ctx, db)
+
+	if args.Get(1) != nil {
+		return nil, args.Get(1).(error)
+	}
+
+	return args.Get(0).([]entity.OauthClient), nil
+}
+
+func (mock *OauthRepositoryMock) DeleteClient(ctx context.Context, db *sql.DB, clientId string) error {
+	args := mock.Called(ctx, db, clientId)
+	return args.Error(0)
+}
+
+func (mock *OauthRepositoryMock) GetConsent(ctx context.Context, db *sql.DB, userId int, clientId string) (entity.OauthConsent, error) {
+	args := mock.Called(ctx, db, userId, clientId)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.OauthConsent), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.OauthConsent), nil
+}
+
+func (mock *OauthRepositoryMock) SaveConsent(ctx context.Context, db *sql.DB, oauthConsent entity.OauthConsent) error {
+	args := mock.Called(ctx, db, oauthConsent)
+	return args.Error(0)
+}
+
+func (mock *OauthRepositoryMock) InsertAuthorizationCode(ctx context.Context, db *sql.DB, authorizationCode entity.OauthAuthorizationCode) error {
+	args := mock.Called(ctx, db, authorizationCode)
+	return args.Error(0)
+}
+
+func (mock *OauthRepositoryMock) ConsumeAuthorizationCode(ctx context.Context, db *sql.DB, codeHash string) (entity.OauthAuthorizationCode, error) {
+	args := mock.Called(ctx, db, codeHash)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.OauthAuthorizationCode), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.OauthAuthorizationCode), nil
+}
+
+func (mock *OauthRepositoryMock) InsertToken(ctx context.Context, tx *sql.Tx, oauthToken entity.OauthToken, expiresInSeconds int, refreshExpiresInDays int) error {
+	args := mock.Called(ctx, tx, oauthToken, expiresInSeconds, refreshExpiresInDays)
+	return args.Error(0)
+}
+
+func (mock *OauthRepositoryMock) GetActiveTokenByAccessHash(ctx context.Context, db *sql.DB, accessTokenHash string) (entity.OauthToken, error) {
+	args := mock.Called(ctx, db, accessTokenHash)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.OauthToken), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.OauthToken), nil
+}
+
+func (mock *OauthRepositoryMock) GetActiveTokenByRefreshHash(ctx context.Context, db *sql.DB, refreshTokenHash string) (entity.OauthToken, error) {
+	args := mock.Called(ctx, db, refreshTokenHash)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.OauthToken), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.OauthToken), nil
+}
+
+func (mock *OauthRepositoryMock) RevokeToken(ctx context.Context, tx *sql.Tx, tokenId int) error {
+	args := mock.Called(ctx, tx, tokenId)
+	return args.Error(0)
+}
+
+func (mock *OauthRepositoryMock) RevokeUserTokens(ctx context.Context, db *sql.DB, userId int) error {
+	args := mock.Called(ctx, db, userId)
+	return args.Error(0)
+}
+
+var webOauthClient = entity.OauthClient{
+	Id:               1,
+	ClientId:         "unittest-client",
+	ClientSecretHash: helper.HashToken("gtcs_unittest"),
+	Name:             "Unit Test App",
+	RedirectUris:     "https://app.example.com/callback",
+	Scopes:           "todos:read todos:write",
+	GrantTypes:       "authorization_code refresh_token",
+}
+
+func TestOauthServiceRegisterClient(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	oauthService := service.NewOauthService(db, oauthRepositoryMock, validatorMock)
+
+	oauthClientCreateRequest := request.OauthClientCreateRequest{
+		Name:         "Unit Test App",
+		RedirectUris: []string{"https://app.example.com/callback"},
+		Scopes:       []string{"todos:read"},
+		GrantTypes:   []string{"authorization_code", "refresh_token"},
+		Confidential: true,
+	}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, mock.Anything).Return(nil)
+
+	var insertedClient entity.OauthClient
+
+	oauthRepositoryMock.On("InsertClient", ctx, db, mock.AnythingOfType("entity.OauthClient")).Run(func(args mock.Arguments) {
+		insertedClient = args.Get(2).(entity.OauthClient)
+	}).Return(1, nil)
+
+	oauthClientCreateResponse, err := oauthService.RegisterClient(ctx, oauthClientCreateRequest)
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(oauthClientCreateResponse.ClientSecret, helper.OauthClientSecretPrefix))
+	assert.Equal(t, helper.HashToken(oauthClientCreateResponse.ClientSecret), insertedClient.ClientSecretHash)
+	assert.Equal(t, insertedClient.ClientId, oauthClientCreateResponse.ClientId)
+	assert.True(t, oauthClientCreateResponse.Confidential)
+
+	// Public clients can't prove who they are without a user, so they can't use client_credentials.
+	oauthClientCreateRequest.Confidential = false
+	oauthClientCreateRequest.GrantTypes = []string{"client_credentials"}
+
+	_, errPublic := oauthService.RegisterClient(ctx, oauthClientCreateRequest)
+
+	oauthError := &helper.OauthError{}
+	assert.ErrorAs(t, errPublic, &oauthError)
+	assert.Equal(t, "invalid_client_metadata", oauthError.Code)
+}
+
+func TestOauthServiceAuthorize(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	oauthService := service.NewOauthService(db, oauthRepositoryMock, validatorMock)
+
+	oauthAuthorizeRequest := request.OauthAuthorizeRequest{
+		UserId:              1,
+		ResponseType:        "code",
+		ClientId:            "unittest-client",
+		RedirectUri:         "https://app.example.com/callback",
+		Scope:               "todos:read",
+		State:               "xyz",
+		CodeChallenge:       helper.PkceChallenge("unittest-verifier"),
+		CodeChallengeMethod: "S256",
+		Approve:             true,
+	}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, mock.Anything).Return(nil)
+	oauthRepositoryMock.On("GetClient", ctx, db, "unittest-client").Return(webOauthClient, nil)
+	oauthRepositoryMock.On("SaveConsent", ctx, db, entity.OauthConsent{UserId: 1, ClientId: "unittest-client", Scopes: "todos:read"}).Return(nil)
+
+	var insertedCode entity.OauthAuthorizationCode
+
+	oauthRepositoryMock.On("InsertAuthorizationCode", ctx, db, mock.AnythingOfType("entity.OauthAuthorizationCode")).Run(func(args mock.Arguments) {
+		insertedCode = args.Get(2).(entity.OauthAuthorizationCode)
+	}).Return(nil)
+
+	oauthAuthorizeResponse, err := oauthService.Authorize(ctx, oauthAuthorizeRequest)
+
+	assert.NoError(t, err)
+
+	redirectTo, errParse := url.Parse(oauthAuthorizeResponse.RedirectTo)
+
+	assert.NoError(t, errParse)
+	assert.Equal(t, "app.example.com", redirectTo.Host)
+	assert.Equal(t, "xyz", redirectTo.Query().Get("state"))
+	assert.Equal(t, helper.HashToken(redirectTo.Query().Get("code")), insertedCode.CodeHash)
+	assert.Equal(t, oauthAuthorizeRequest.CodeChallenge, insertedCode.CodeChallenge)
+
+	// Denying consent still redirects back to the client, with an error instead of a code.
+	oauthAuthorizeRequest.Approve = false
+
+	oauthAuthorizeResponse, err = oauthService.Authorize(ctx, oauthAuthorizeRequest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://app.example.com/callback?error=access_denied&state=xyz", oauthAuthorizeResponse.RedirectTo)
+
+	// An unregistered redirect uri is never redirected to.
+	oauthAuthorizeRequest.RedirectUri = "https://evil.example.com/callback"
+
+	_, errRedirect := oauthService.Authorize(ctx, oauthAuthorizeRequest)
+
+	oauthError := &helper.OauthError{}
+	assert.ErrorAs(t, errRedirect, &oauthError)
+	assert.Equal(t, "invalid_request", oauthError.Code)
+
+	oauthRepositoryMock.AssertNumberOfCalls(t, "InsertAuthorizationCode", 1)
+}
+
+func TestOauthServiceTokenAuthorizationCode(t *testing.T) {
+	db, dbMock, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	oauthService := service.NewOauthService(db, oauthRepositoryMock, new(ValidatorMock))
+
+	oauthTokenRequest := request.OauthTokenRequest{
+		GrantType:    helper.GrantTypeAuthorizationCode,
+		Code:         "unittest-code",
+		RedirectUri:  "https://app.example.com/callback",
+		CodeVerifier: "unittest-verifier",
+		ClientId:     "unittest-client",
+		ClientSecret: "gtcs_unittest",
+	}
+
+	authorizationCode := entity.OauthAuthorizationCode{
+		CodeHash:      helper.HashToken("unittest-code"),
+		ClientId:      "unittest-client",
+		UserId:        1,
+		RedirectUri:   "https://app.example.com/callback",
+		Scopes:        "todos:read",
+		CodeChallenge: helper.PkceChallenge("unittest-verifier"),
+	}
+
+	ctx := context.Background()
+	oauthRepositoryMock.On("GetClient", ctx, db, "unittest-client").Return(webOauthClient, nil)
+	oauthRepositoryMock.On("ConsumeAuthorizationCode", ctx, db, helper.HashToken("unittest-code")).Return(authorizationCode, nil).Once()
+	oauthRepositoryMock.On("InsertToken", ctx, mock.Anything, mock.MatchedBy(func(oauthToken entity.OauthToken) bool {
+		return oauthToken.UserId == 1 && oauthToken.Scopes == "todos:read" && oauthToken.RefreshTokenHash != ""
+	}), 3600, 30).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	oauthTokenResponse, err := oauthService.Token(ctx, oauthTokenRequest)
+
+	assert.NoError(t, err)
+	assert.True(t, helper.IsOauthAccessToken(oauthTokenResponse.AccessToken))
+	assert.True(t, strings.HasPrefix(oauthTokenResponse.RefreshToken, helper.OauthRefreshTokenPrefix))
+	assert.Equal(t, "Bearer", oauthTokenResponse.TokenType)
+	assert.Equal(t, "todos:read", oauthTokenResponse.Scope)
+
+	// The verifier has to match the challenge sent with the authorization request.
+	oauthRepositoryMock.On("ConsumeAuthorizationCode", ctx, db, helper.HashToken("unittest-code")).Return(authorizationCode, nil).Once()
+	oauthTokenRequest.CodeVerifier = "another-verifier"
+
+	_, errVerifier := oauthService.Token(ctx, oauthTokenRequest)
+
+	oauthError := &helper.OauthError{}
+	assert.ErrorAs(t, errVerifier, &oauthError)
+	assert.Equal(t, "invalid_grant", oauthError.Code)
+
+	// A wrong client secret fails before the code is looked at.
+	oauthTokenRequest.ClientSecret = "gtcs_wrong"
+
+	_, errClient := oauthService.Token(ctx, oauthTokenRequest)
+
+	assert.ErrorAs(t, errClient, &oauthError)
+	assert.Equal(t, "invalid_client", oauthError.Code)
+	oauthRepositoryMock.AssertNumberOfCalls(t, "ConsumeAuthorizationCode", 2)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestOauthServiceTokenRefreshRotates(t *testing.T) {
+	db, dbMock, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	oauthService := service.NewOauthService(db, oauthRepositoryMock, new(ValidatorMock))
+
+	oauthTokenRequest := request.OauthTokenRequest{
+		GrantType:    helper.GrantTypeRefreshToken,
+		RefreshToken: "gtr_unittest",
+		ClientId:     "unittest-client",
+		ClientSecret: "gtcs_unittest",
+	}
+
+	oldToken := entity.OauthToken{Id: 7, ClientId: "unittest-client", UserId: 1, Scopes: "todos:read todos:write"}
+
+	ctx := context.Background()
+	oauthRepositoryMock.On("GetClient", ctx, db, "unittest-client").Return(webOauthClient, nil)
+	oauthRepositoryMock.On("GetActiveTokenByRefreshHash", ctx, db, helper.HashToken("gtr_unittest")).Return(oldToken, nil)
+	oauthRepositoryMock.On("RevokeToken", ctx, mock.Anything, 7).Return(nil).Once()
+	oauthRepositoryMock.On("InsertToken", ctx, mock.Anything, mock.MatchedBy(func(oauthToken entity.OauthToken) bool {
+		return oauthToken.UserId == 1 && oauthToken.Scopes == "todos:read" && oauthToken.RefreshTokenHash != helper.HashToken("gtr_unittest")
+	}), 3600, 30).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	oauthTokenRequest.Scope = "todos:read"
+
+	oauthTokenResponse, err := oauthService.Token(ctx, oauthTokenRequest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "todos:read", oauthTokenResponse.Scope)
+	assert.NotEqual(t, "gtr_unittest", oauthTokenResponse.RefreshToken)
+
+	// A concurrent refresh revoked the token first.
+	oauthRepositoryMock.On("RevokeToken", ctx, mock.Anything, 7).Return(helper.ErrRowsNotAffected).Once()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	_, errReused := oauthService.Token(ctx, oauthTokenRequest)
+
+	oauthError := &helper.OauthError{}
+	assert.ErrorAs(t, errReused, &oauthError)
+	assert.Equal(t, "invalid_grant", oauthError.Code)
+
+	// Refreshing can't widen the original grant.
+	oauthTokenRequest.Scope = "todos:read user:write"
+
+	_, errScope := oauthService.Token(ctx, oauthTokenRequest)
+
+	assert.ErrorAs(t, errScope, &oauthError)
+	assert.Equal(t, "invalid_scope", oauthError.Code)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestOauthServiceTokenClientCredentials(t *testing.T) {
+	db, dbMock, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	oauthService := service.NewOauthService(db, oauthRepositoryMock, new(ValidatorMock))
+
+	serviceClient := entity.OauthClient{
+		ClientId:         "service-client",
+		ClientSecretHash: helper.HashToken("gtcs_service"),
+		Scopes:           "todos:read",
+		GrantTypes:       "client_credentials",
+	}
+
+	ctx := context.Background()
+	oauthRepositoryMock.On("GetClient", ctx, db, "service-client").Return(serviceClient, nil)
+	oauthRepositoryMock.On("GetClient", ctx, db, "unittest-client").Return(webOauthClient, nil)
+	oauthRepositoryMock.On("InsertToken", ctx, mock.Anything, mock.MatchedBy(func(oauthToken entity.OauthToken) bool {
+		return oauthToken.UserId == 0 && oauthToken.RefreshTokenHash == ""
+	}), 3600, 30).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	oauthTokenResponse, err := oauthService.Token(ctx, request.OauthTokenRequest{GrantType: helper.GrantTypeClientCredentials, ClientId: "service-client", ClientSecret: "gtcs_service"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, oauthTokenResponse.RefreshToken)
+	assert.Equal(t, "todos:read", oauthTokenResponse.Scope)
+
+	// The web client wasn't registered for client_credentials.
+	_, errGrant := oauthService.Token(ctx, request.OauthTokenRequest{GrantType: helper.GrantTypeClientCredentials, ClientId: "unittest-client", ClientSecret: "gtcs_unittest"})
+
+	oauthError := &helper.OauthError{}
+	assert.ErrorAs(t, errGrant, &oauthError)
+	assert.Equal(t, "unauthorized_client", oauthError.Code)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestOauthServiceAuthenticate(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	oauthRepositoryMock := new(OauthRepositoryMock)
+	oauthService := service.NewOauthService(db, oauthRepositoryMock, new(ValidatorMock))
+
+	ctx := context.Background()
+	oauthRepositoryMock.On("GetActiveTokenByAccessHash", ctx, db, helper.HashToken("gto_user")).Return(entity.OauthToken{ClientId: "unittest-client", UserId: 1, Username: "apollo", Role: helper.RoleAdmin, Scopes: "todos:read"}, nil)
+	oauthRepositoryMock.On("GetActiveTokenByAccessHash", ctx, db, helper.HashToken("gto_service")).Return(entity.OauthToken{ClientId: "service-client", Scopes: "todos:read"}, nil)
+	oauthRepositoryMock.On("GetActiveTokenByAccessHash", ctx, db, helper.HashToken("gto_revoked")).Return(entity.OauthToken{}, helper.ErrNotFound)
+
+	principal, err := oauthService.Authenticate(ctx, "gto_user")
+
+	assert.NoError(t, err)
+	assert.Equal(t, helper.Principal{
+		UserId:     1,
+		Username:   "apollo",
+		AuthMethod: helper.AuthMethodOauth,
+		ClientId:   "unittest-client",
+		Scopes:     []string{"todos:read"},
+		Roles:      []string{helper.RoleUser},
+	}, principal)
+
+	servicePrincipal, errService := oauthService.Authenticate(ctx, "gto_service")
+
+	assert.NoError(t, errService)
+	assert.Equal(t, 0, servicePrincipal.UserId)
+	assert.Empty(t, servicePrincipal.Roles)
+
+	_, errRevoked := oauthService.Authenticate(ctx, "gto_revoked")
+
+	assert.ErrorIs(t, errRevoked, helper.ErrorTokenInvalid)
+}
@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type OidcServiceMock struct {
+	mock.Mock
+}
+
+func (mock *OidcServiceMock) StartLogin(ctx context.Context, providerName string) (string, error) {
+	args := mock.Called(ctx, providerName)
+	return args.String(0), args.Error(1)
+}
+
+func (mock *OidcServiceMock) CompleteLogin(ctx context.Context, oidcCallbackRequest request.OidcCallbackRequest) (response.LoginResponse, error) {
+	args := mock.Called(ctx, oidcCallbackRequest)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(response.LoginResponse), args.Get(1).(error)
+	}
+
+	return args.Get(0).(response.LoginResponse), nil
+}
+
+func TestOidcControllerStartLogin(t *testing.T) {
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/login/oidc/google", nil)
+	params := httprouter.Params{{Key: "provider", Value: "google"}}
+
+	recorder := httptest.NewRecorder()
+
+	oidcServiceMock := new(OidcServiceMock)
+	oidcController := controller.NewOidcController(oidcServiceMock)
+
+	oidcServiceMock.On("StartLogin", request.Context(), "google").Return("https://accounts.example.xyz/authorize?state=abc", nil)
+
+	oidcController.StartLogin(recorder, request, params)
+
+	assert.Equal(t, 302, recorder.Result().StatusCode)
+	assert.Equal(t, "https://accounts.example.xyz/authorize?state=abc", recorder.Header().Get("Location"))
+}
+
+func TestOidcControllerCallback(t *testing.T) {
+	oidcCallbackRequest := request.OidcCallbackRequest{Provider: "google", Code: "code", State: "abc"}
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/login/oidc/google/callback?code=code&state=abc", nil)
+	params := httprouter.Params{{Key: "provider", Value: "google"}}
+
+	recorder := httptest.NewRecorder()
+
+	oidcServiceMock := new(OidcServiceMock)
+	oidcController := controller.NewOidcController(oidcServiceMock)
+
+	oidcServiceMock.On("CompleteLogin", request.Context(), oidcCallbackRequest).Return(response.LoginResponse{AccessToken: "access"}, nil)
+
+	oidcController.Callback(recorder, request, params)
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+	assert.Contains(t, recorder.Body.String(), "login success")
+
+	recorder = httptest.NewRecorder()
+
+	deniedRequest := httptest.NewRequest("GET", "http://localhost:8080/api/login/oidc/google/callback?error=access_denied&state=abc", nil)
+
+	oidcController.Callback(recorder, deniedRequest, params)
+
+	assert.Equal(t, 401, recorder.Result().StatusCode)
+	assert.Contains(t, recorder.Body.String(), helper.ErrOidcLoginFailed.Error())
+}
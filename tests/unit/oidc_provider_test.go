@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	testhelper "go_todo_api/tests/test_helper"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const oidcRedirectUrl = "http://localhost:8080/api/login/oidc/mock/callback"
+
+func TestOidcProviderLoginFlow(t *testing.T) {
+	mockProvider := testhelper.NewMockOidcProvider("todo-app", "client-secret")
+	defer mockProvider.Close()
+
+	mockProvider.Claims = jwt.MapClaims{"sub": "1234", "email": "apollo@example.xyz", "email_verified": true, "name": "Apollo"}
+
+	provider := helper.NewOidcProvider(mockProvider.Config("mock", oidcRedirectUrl), http.DefaultClient)
+	ctx := context.Background()
+
+	authUrl, errAuthUrl := provider.AuthCodeUrl(ctx, "state-1", "nonce-1", "verifier-with-enough-entropy")
+	assert.NoError(t, errAuthUrl)
+	assert.Contains(t, authUrl, "code_challenge_method=S256")
+
+	callbackQuery, errAuthorize := mockProvider.Authorize(authUrl)
+	assert.NoError(t, errAuthorize)
+	assert.Equal(t, "state-1", callbackQuery.Get("state"))
+
+	rawIdToken, errExchange := provider.Exchange(ctx, callbackQuery.Get("code"), "verifier-with-enough-entropy")
+	assert.NoError(t, errExchange)
+
+	claims, errVerify := provider.VerifyIdToken(ctx, rawIdToken, "nonce-1")
+	assert.NoError(t, errVerify)
+	assert.Equal(t, "1234", claims.Subject)
+	assert.Equal(t, "apollo@example.xyz", claims.Email)
+	assert.True(t, claims.EmailVerified)
+
+	_, errNonce := provider.VerifyIdToken(ctx, rawIdToken, "another-nonce")
+	assert.ErrorIs(t, errNonce, helper.ErrOidcLoginFailed)
+}
+
+func TestOidcProviderExchangeRejectsWrongVerifier(t *testing.T) {
+	mockProvider := testhelper.NewMockOidcProvider("todo-app", "client-secret")
+	defer mockProvider.Close()
+
+	provider := helper.NewOidcProvider(mockProvider.Config("mock", oidcRedirectUrl), http.DefaultClient)
+	ctx := context.Background()
+
+	authUrl, _ := provider.AuthCodeUrl(ctx, "state-1", "nonce-1", "verifier-with-enough-entropy")
+	callbackQuery, _ := mockProvider.Authorize(authUrl)
+
+	_, errExchange := provider.Exchange(ctx, callbackQuery.Get("code"), "stolen-code-wrong-verifier")
+
+	assert.ErrorIs(t, errExchange, helper.ErrOidcLoginFailed)
+	assert.Contains(t, errExchange.Error(), "invalid_grant")
+}
+
+func TestOidcProviderVerifyIdTokenRejectsOtherAudience(t *testing.T) {
+	mockProvider := testhelper.NewMockOidcProvider("todo-app", "client-secret")
+	defer mockProvider.Close()
+
+	mockProvider.Claims = jwt.MapClaims{"sub": "1234", "aud": "another-app"}
+
+	provider := helper.NewOidcProvider(mockProvider.Config("mock", oidcRedirectUrl), http.DefaultClient)
+	ctx := context.Background()
+
+	authUrl, _ := provider.AuthCodeUrl(ctx, "state-1", "nonce-1", "verifier-with-enough-entropy")
+	callbackQuery, _ := mockProvider.Authorize(authUrl)
+
+	rawIdToken, errExchange := provider.Exchange(ctx, callbackQuery.Get("code"), "verifier-with-enough-entropy")
+	assert.NoError(t, errExchange)
+
+	_, errVerify := provider.VerifyIdToken(ctx, rawIdToken, "nonce-1")
+	assert.ErrorIs(t, errVerify, helper.ErrOidcLoginFailed)
+}
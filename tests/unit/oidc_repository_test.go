@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var oidcRepository = repository.NewOidcRepository()
+
+func TestOidcRepositoryGetIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "provider", "subject", "email", "created_at"}).AddRow(1, 2, "google", "1234", "budi@example.xyz", "2024-01-01 10:00:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM user_identities").ExpectQuery().WithArgs("google", "1234").WillReturnRows(rows)
+
+	userIdentity, errGet := oidcRepository.GetIdentity(context.Background(), db, "google", "1234")
+
+	assert.NoError(t, errGet)
+	assert.Equal(t, 2, userIdentity.UserId)
+
+	mock.ExpectPrepare("SELECT (.+) FROM user_identities").ExpectQuery().WithArgs("google", "5678").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, errNotFound := oidcRepository.GetIdentity(context.Background(), db, "google", "5678")
+
+	assert.ErrorIs(t, errNotFound, helper.ErrNotFound)
+}
+
+func TestOidcRepositoryConsumeLoginState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"state", "provider", "nonce", "code_verifier", "expires_at"}).AddRow("state", "google", "nonce", "verifier", "2024-01-01 10:10:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM oidc_login_states WHERE state = \\? AND expires_at > CURRENT_TIMESTAMP").ExpectQuery().WithArgs("state").WillReturnRows(rows)
+	mock.ExpectPrepare("DELETE FROM oidc_login_states").ExpectExec().WithArgs("state").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	loginState, errConsume := oidcRepository.ConsumeLoginState(context.Background(), db, "state")
+
+	assert.NoError(t, errConsume)
+	assert.Equal(t, entity.OidcLoginState{State: "state", Provider: "google", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: "2024-01-01 10:10:00"}, loginState)
+
+	// A concurrent callback deleted the state first.
+	rows = sqlmock.NewRows([]string{"state", "provider", "nonce", "code_verifier", "expires_at"}).AddRow("state", "google", "nonce", "verifier", "2024-01-01 10:10:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM oidc_login_states").ExpectQuery().WithArgs("state").WillReturnRows(rows)
+	mock.ExpectPrepare("DELETE FROM oidc_login_states").ExpectExec().WithArgs("state").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, errConsumed := oidcRepository.ConsumeLoginState(context.Background(), db, "state")
+
+	assert.ErrorIs(t, errConsumed, helper.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
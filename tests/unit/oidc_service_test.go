@@ -0,0 +1,204 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/service"
+	testhelper "go_todo_api/tests/test_helper"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type OidcRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *OidcRepositoryMock) GetIdentity(ctx context.Context, db *sql.DB, provider string, subject string) (entity.UserIdentity, error) {
+	args := mock.Called(ctx, db, provider, subject)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.UserIdentity), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.UserIdentity), nil
+}
+
+func (mock *OidcRepositoryMock) InsertIdentity(ctx context.Context, tx *sql.Tx, userIdentity entity.UserIdentity) error {
+	args := mock.Called(ctx, tx, userIdentity)
+	return args.Error(0)
+}
+
+func (mock *OidcRepositoryMock) InsertLoginState(ctx context.Context, db *sql.DB, loginState entity.OidcLoginState) error {
+	args := mock.Called(ctx, db, loginState)
+	return args.Error(0)
+}
+
+func (mock *OidcRepositoryMock) ConsumeLoginState(ctx context.Context, db *sql.DB, state string) (entity.OidcLoginState, error) {
+	args := mock.Called(ctx, db, state)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.OidcLoginState), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.OidcLoginState), nil
+}
+
+// startOidcLogin runs the login up to the provider's redirect back to us and
+// returns the callback request the client would send.
+func startOidcLogin(t *testing.T, oidcService service.OidcService, oidcRepositoryMock *OidcRepositoryMock, mockProvider *testhelper.MockOidcProvider, db *sql.DB) request.OidcCallbackRequest {
+	ctx := context.Background()
+	loginState := entity.OidcLoginState{}
+
+	oidcRepositoryMock.On("InsertLoginState", ctx, db, mock.AnythingOfType("entity.OidcLoginState")).Run(func(args mock.Arguments) {
+		loginState = args.Get(2).(entity.OidcLoginState)
+	}).Return(nil).Once()
+
+	authUrl, errStart := oidcService.StartLogin(ctx, "mock")
+	assert.NoError(t, errStart)
+
+	callbackQuery, errAuthorize := mockProvider.Authorize(authUrl)
+	assert.NoError(t, errAuthorize)
+
+	oidcRepositoryMock.On("ConsumeLoginState", ctx, db, loginState.State).Return(loginState, nil).Once()
+
+	return request.OidcCallbackRequest{Provider: "mock", Code: callbackQuery.Get("code"), State: callbackQuery.Get("state")}
+}
+
+func newOidcTestService(db *sql.DB, userRepositoryMock *UserRepositoryMock, oidcRepositoryMock *OidcRepositoryMock, authServiceMock *AuthServiceMock, mockProvider *testhelper.MockOidcProvider) service.OidcService {
+	providers := helper.OidcProviders{
+		"mock": helper.NewOidcProvider(mockProvider.Config("mock", oidcRedirectUrl), http.DefaultClient),
+	}
+
+	return service.NewOidcService(db, userRepositoryMock, oidcRepositoryMock, authServiceMock, validatorMock, hashPasswordMock, providers)
+}
+
+func TestOidcServiceLoginLinkedIdentity(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockProvider := testhelper.NewMockOidcProvider("todo-app", "client-secret")
+	defer mockProvider.Close()
+
+	mockProvider.Claims = jwt.MapClaims{"sub": "1234", "email": "apollo@example.xyz", "email_verified": true}
+
+	userRepositoryMock := new(UserRepositoryMock)
+	oidcRepositoryMock := new(OidcRepositoryMock)
+	authServiceMock := new(AuthServiceMock)
+	oidcService := newOidcTestService(db, userRepositoryMock, oidcRepositoryMock, authServiceMock, mockProvider)
+
+	ctx := context.Background()
+	oidcCallbackRequest := startOidcLogin(t, oidcService, oidcRepositoryMock, mockProvider, db)
+
+	user := entity.User{Id: 1, Username: "apollo", Email: "apollo@example.xyz"}
+	loginResponse := response.LoginResponse{AccessToken: "access", RefreshToken: "refresh"}
+
+	validatorMock.On("StructCtx", ctx, oidcCallbackRequest).Return(nil)
+	oidcRepositoryMock.On("GetIdentity", ctx, db, "mock", "1234").Return(entity.UserIdentity{UserId: 1}, nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(user, nil)
+	authServiceMock.On("LoginUser", ctx, user).Return(loginResponse, nil)
+
+	result, err := oidcService.CompleteLogin(ctx, oidcCallbackRequest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, loginResponse, result)
+
+	// The state is consumed, replaying the callback fails.
+	oidcRepositoryMock.On("ConsumeLoginState", ctx, db, oidcCallbackRequest.State).Return(entity.OidcLoginState{}, helper.ErrNotFound)
+
+	_, errReplay := oidcService.CompleteLogin(ctx, oidcCallbackRequest)
+
+	assert.ErrorIs(t, errReplay, helper.ErrOidcLoginFailed)
+}
+
+func TestOidcServiceLoginCreatesUser(t *testing.T) {
+	db, dbMock, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockProvider := testhelper.NewMockOidcProvider("todo-app", "client-secret")
+	defer mockProvider.Close()
+
+	mockProvider.Claims = jwt.MapClaims{"sub": "5678", "email": "Athena.Pallas@example.xyz", "email_verified": true, "name": "Athena"}
+
+	userRepositoryMock := new(UserRepositoryMock)
+	oidcRepositoryMock := new(OidcRepositoryMock)
+	authServiceMock := new(AuthServiceMock)
+	oidcService := newOidcTestService(db, userRepositoryMock, oidcRepositoryMock, authServiceMock, mockProvider)
+
+	ctx := context.Background()
+	oidcCallbackRequest := startOidcLogin(t, oidcService, oidcRepositoryMock, mockProvider, db)
+
+	validatorMock.On("StructCtx", ctx, oidcCallbackRequest).Return(nil)
+	oidcRepositoryMock.On("GetIdentity", ctx, db, "mock", "5678").Return(entity.UserIdentity{}, helper.ErrNotFound)
+	userRepositoryMock.On("GetByEmail", ctx, db, "athena.pallas@example.xyz").Return(entity.User{}, helper.ErrNotFound)
+	userRepositoryMock.On("GetByUsername", ctx, db, "athena.pallas").Return(entity.User{}, helper.ErrNotFound)
+
+	dbMock.ExpectBegin()
+
+	userRepositoryMock.On("InsertUser", ctx, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(user entity.User) bool {
+		return user.Username == "athena.pallas" && user.Name == "Athena" && user.Email == "athena.pallas@example.xyz" && user.PhoneNumber == "" && user.Password != ""
+	})).Return(7, nil)
+	oidcRepositoryMock.On("InsertIdentity", ctx, mock.AnythingOfType("*sql.Tx"), entity.UserIdentity{UserId: 7, Provider: "mock", Subject: "5678", Email: "athena.pallas@example.xyz"}).Return(nil)
+
+	dbMock.ExpectCommit()
+
+	authServiceMock.On("LoginUser", ctx, mock.MatchedBy(func(user entity.User) bool { return user.Id == 7 })).Return(response.LoginResponse{AccessToken: "access"}, nil)
+
+	_, err := oidcService.CompleteLogin(ctx, oidcCallbackRequest)
+
+	assert.NoError(t, err)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	// Counted rather than asserted, testify would print the committed *sql.Tx
+	// while database/sql may still be writing to it.
+	userRepositoryMock.AssertNumberOfCalls(t, "InsertUser", 1)
+	oidcRepositoryMock.AssertNumberOfCalls(t, "InsertIdentity", 1)
+}
+
+func TestOidcServiceLoginUnverifiedEmailConflict(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockProvider := testhelper.NewMockOidcProvider("todo-app", "client-secret")
+	defer mockProvider.Close()
+
+	mockProvider.Claims = jwt.MapClaims{"sub": "9999", "email": "apollo@example.xyz", "email_verified": false}
+
+	userRepositoryMock := new(UserRepositoryMock)
+	oidcRepositoryMock := new(OidcRepositoryMock)
+	authServiceMock := new(AuthServiceMock)
+	oidcService := newOidcTestService(db, userRepositoryMock, oidcRepositoryMock, authServiceMock, mockProvider)
+
+	ctx := context.Background()
+	oidcCallbackRequest := startOidcLogin(t, oidcService, oidcRepositoryMock, mockProvider, db)
+
+	validatorMock.On("StructCtx", ctx, oidcCallbackRequest).Return(nil)
+	oidcRepositoryMock.On("GetIdentity", ctx, db, "mock", "9999").Return(entity.UserIdentity{}, helper.ErrNotFound)
+	userRepositoryMock.On("GetByEmail", ctx, db, "apollo@example.xyz").Return(entity.User{Id: 1}, nil)
+
+	_, err := oidcService.CompleteLogin(ctx, oidcCallbackRequest)
+
+	assert.ErrorIs(t, err, helper.ErrOidcEmailConflict)
+	authServiceMock.AssertNotCalled(t, "LoginUser", mock.Anything, mock.Anything)
+}
+
+func TestOidcServiceStartLoginUnknownProvider(t *testing.T) {
+	oidcService := service.NewOidcService(nil, new(UserRepositoryMock), new(OidcRepositoryMock), new(AuthServiceMock), validatorMock, hashPasswordMock, helper.OidcProviders{})
+
+	_, err := oidcService.StartLogin(context.Background(), "myspace")
+
+	assert.ErrorIs(t, err, helper.ErrNotFound)
+}
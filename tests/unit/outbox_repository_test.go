@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var outboxRepository = repository.NewOutboxRepository()
+
+var outboxColumns = []string{"id", "event_id", "user_id", "event_type", "payload", "published_to", "attempts", "next_attempt_at", "last_error", "published_at", "created_at"}
+
+func TestOutboxRepositoryInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO outbox").ExpectExec().WithArgs(todoUuid, 1, helper.EventTodoCreated, `{"id":3}`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	errInsert := outboxRepository.Insert(context.Background(), tx, entity.OutboxEvent{EventId: todoUuid, UserId: 1, EventType: helper.EventTodoCreated, Payload: `{"id":3}`})
+
+	assert.NoError(t, errInsert)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepositoryGetPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(outboxColumns).
+		AddRow(1, todoUuid, 1, helper.EventTodoCreated, `{"id":3}`, "", 0, "2024-03-20 10:00:00", nil, nil, "2024-03-20 10:00:00").
+		AddRow(2, otherTodoUuid, 1, helper.EventTodoUpdated, `{"id":3}`, "webhooks", 1, "2024-03-20 10:00:05", "redis: connection refused", nil, "2024-03-20 10:00:00")
+
+	mock.ExpectPrepare("SELECT (.+) FROM outbox WHERE published_at IS NULL").ExpectQuery().WithArgs(100).WillReturnRows(rows)
+
+	events, errGetPending := outboxRepository.GetPending(context.Background(), db, 100)
+
+	assert.NoError(t, errGetPending)
+	assert.Len(t, events, 2)
+	assert.Equal(t, int64(1), events[0].Id)
+	assert.Equal(t, "", events[0].LastError)
+	assert.Equal(t, "webhooks", events[1].PublishedTo)
+	assert.Equal(t, "redis: connection refused", events[1].LastError)
+}
+
+func TestOutboxRepositoryGetByIds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(outboxColumns).
+		AddRow(7, todoUuid, 1, helper.EventTodoCreated, `{"id":3}`, "webhooks", 0, "2024-03-20 10:00:00", nil, "2024-03-20 10:00:01", "2024-03-20 10:00:00")
+
+	mock.ExpectPrepare(`SELECT (.+) FROM outbox WHERE id IN \(\?, \?\) ORDER BY id`).ExpectQuery().WithArgs(int64(7), int64(9)).WillReturnRows(rows)
+
+	events, errGetByIds := outboxRepository.GetByIds(context.Background(), db, []int64{7, 9})
+
+	assert.NoError(t, errGetByIds)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "2024-03-20 10:00:01", events[0].PublishedAt)
+
+	// No ids, no query.
+	events, errGetByIds = outboxRepository.GetByIds(context.Background(), db, nil)
+
+	assert.NoError(t, errGetByIds)
+	assert.Empty(t, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepositoryClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE outbox SET next_attempt_at").ExpectExec().WithArgs(60, int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("UPDATE outbox SET next_attempt_at").ExpectExec().WithArgs(60, int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, outboxRepository.Claim(context.Background(), db, 1, time.Minute))
+	assert.ErrorIs(t, outboxRepository.Claim(context.Background(), db, 1, time.Minute), helper.ErrRowsNotAffected)
+}
@@ -0,0 +1,353 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/service"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type OutboxMock struct {
+	mock.Mock
+}
+
+func (mock *OutboxMock) Add(ctx context.Context, tx *sql.Tx, userId int, eventType string, data any) error {
+	args := mock.Called(ctx, tx, userId, eventType, data)
+	return args.Error(0)
+}
+
+func (mock *OutboxMock) Notify() {
+	mock.Called()
+}
+
+func (mock *OutboxMock) Run(ctx context.Context) {
+	mock.Called(ctx)
+}
+
+func (mock *OutboxMock) Relay(ctx context.Context) error {
+	args := mock.Called(ctx)
+	return args.Error(0)
+}
+
+func (mock *OutboxMock) Feed(ctx context.Context) error {
+	args := mock.Called(ctx)
+	return args.Error(0)
+}
+
+// newOutboxMock takes any number of Notify calls, the events tests care about
+// are expected with Add.
+func newOutboxMock() *OutboxMock {
+	outboxMock := new(OutboxMock)
+	outboxMock.On("Notify").Return().Maybe()
+
+	return outboxMock
+}
+
+type OutboxRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *OutboxRepositoryMock) Insert(ctx context.Context, tx *sql.Tx, event entity.OutboxEvent) error {
+	args := mock.Called(ctx, tx, event)
+	return args.Error(0)
+}
+
+func (mock *OutboxRepositoryMock) GetPending(ctx context.Context, db *sql.DB, limit int) ([]entity.OutboxEvent, error) {
+	args := mock.Called(ctx, db, limit)
+	return args.Get(0).([]entity.OutboxEvent), args.Error(1)
+}
+
+func (mock *OutboxRepositoryMock) GetLastId(ctx context.Context, db *sql.DB) (int64, error) {
+	args := mock.Called(ctx, db)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (mock *OutboxRepositoryMock) GetAfter(ctx context.Context, db *sql.DB, afterId int64, limit int) ([]entity.OutboxEvent, error) {
+	args := mock.Called(ctx, db, afterId, limit)
+	return args.Get(0).([]entity.OutboxEvent), args.Error(1)
+}
+
+func (mock *OutboxRepositoryMock) GetByIds(ctx context.Context, db *sql.DB, eventIds []int64) ([]entity.OutboxEvent, error) {
+	args := mock.Called(ctx, db, eventIds)
+	return args.Get(0).([]entity.OutboxEvent), args.Error(1)
+}
+
+func (mock *OutboxRepositoryMock) Claim(ctx context.Context, db *sql.DB, eventId int64, lease time.Duration) error {
+	args := mock.Called(ctx, db, eventId, lease)
+	return args.Error(0)
+}
+
+func (mock *OutboxRepositoryMock) MarkPublished(ctx context.Context, db *sql.DB, eventId int64) error {
+	args := mock.Called(ctx, db, eventId)
+	return args.Error(0)
+}
+
+func (mock *OutboxRepositoryMock) RecordFailure(ctx context.Context, db *sql.DB, event entity.OutboxEvent, retryIn time.Duration) error {
+	args := mock.Called(ctx, db, event, retryIn)
+	return args.Error(0)
+}
+
+func (mock *OutboxRepositoryMock) DeletePublished(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error) {
+	args := mock.Called(ctx, db, olderThan, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type outboxSinkStub struct {
+	name      string
+	err       error
+	published []int64
+}
+
+func (sink *outboxSinkStub) Name() string {
+	return sink.name
+}
+
+func (sink *outboxSinkStub) Publish(ctx context.Context, event entity.OutboxEvent) error {
+	if sink.err != nil {
+		return sink.err
+	}
+
+	sink.published = append(sink.published, event.Id)
+
+	return nil
+}
+
+func TestOutboxAdd(t *testing.T) {
+	db, sqlMock, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	sqlMock.ExpectBegin()
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	outboxRepositoryMock := new(OutboxRepositoryMock)
+	outbox := service.NewOutbox(db, outboxRepositoryMock, nil, nil, service.DefaultOutboxConfig())
+
+	ctx := context.Background()
+	outboxRepositoryMock.On("Insert", ctx, tx, mock.MatchedBy(func(event entity.OutboxEvent) bool {
+		return len(event.EventId) == 36 && event.UserId == 1 && event.EventType == helper.EventTodoDeleted && event.Payload == `{"id":3,"uuid":"`+todoUuid+`"}`
+	})).Return(nil)
+
+	err := outbox.Add(ctx, tx, 1, helper.EventTodoDeleted, response.TodoDeletedResponse{Id: 3, Uuid: todoUuid})
+
+	assert.NoError(t, err)
+	outboxRepositoryMock.AssertExpectations(t)
+}
+
+func TestOutboxRelay(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	config := service.DefaultOutboxConfig()
+	webhooks := &outboxSinkStub{name: "webhooks"}
+	broker := &outboxSinkStub{name: "redis"}
+
+	outboxRepositoryMock := new(OutboxRepositoryMock)
+	outbox := service.NewOutbox(db, outboxRepositoryMock, nil, []service.OutboxSink{webhooks, broker}, config)
+
+	events := []entity.OutboxEvent{
+		{Id: 1, EventId: todoUuid, UserId: 1, EventType: helper.EventTodoCreated},
+		{Id: 2, EventId: otherTodoUuid, UserId: 1, EventType: helper.EventTodoUpdated},
+		{Id: 3, UserId: 2, EventType: helper.EventTodoCompleted},
+	}
+
+	ctx := context.Background()
+	outboxRepositoryMock.On("GetPending", ctx, db, config.BatchSize).Return(events, nil)
+	outboxRepositoryMock.On("Claim", ctx, db, int64(1), config.Lease).Return(nil)
+	outboxRepositoryMock.On("Claim", ctx, db, int64(2), config.Lease).Return(nil)
+	outboxRepositoryMock.On("Claim", ctx, db, int64(3), config.Lease).Return(helper.ErrRowsNotAffected)
+	outboxRepositoryMock.On("MarkPublished", ctx, db, int64(1)).Return(nil)
+	outboxRepositoryMock.On("MarkPublished", ctx, db, int64(2)).Return(nil)
+
+	assert.NoError(t, outbox.Relay(ctx))
+
+	// Events go out in the order they were written, the one another relay
+	// claimed is left to it.
+	assert.Equal(t, []int64{1, 2}, webhooks.published)
+	assert.Equal(t, []int64{1, 2}, broker.published)
+	outboxRepositoryMock.AssertExpectations(t)
+}
+
+func TestOutboxRelayRetriesRefusingSink(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	config := service.DefaultOutboxConfig()
+	webhooks := &outboxSinkStub{name: "webhooks"}
+	archive := &outboxSinkStub{name: "archive"}
+	broker := &outboxSinkStub{name: "redis", err: errors.New("connection refused")}
+
+	outboxRepositoryMock := new(OutboxRepositoryMock)
+	outbox := service.NewOutbox(db, outboxRepositoryMock, nil, []service.OutboxSink{webhooks, archive, broker}, config)
+
+	// The webhooks took the event on an earlier attempt.
+	event := entity.OutboxEvent{Id: 1, EventId: todoUuid, UserId: 1, EventType: helper.EventTodoCreated, PublishedTo: "webhooks", Attempts: 2}
+
+	ctx := context.Background()
+	outboxRepositoryMock.On("GetPending", ctx, db, config.BatchSize).Return([]entity.OutboxEvent{event}, nil)
+	outboxRepositoryMock.On("Claim", ctx, db, int64(1), config.Lease).Return(nil)
+	outboxRepositoryMock.On("RecordFailure", ctx, db, mock.MatchedBy(func(event entity.OutboxEvent) bool {
+		return event.PublishedTo == "webhooks archive" && event.Attempts == 3 && event.LastError == "redis: connection refused"
+	}), 4*config.InitialBackoff).Return(nil)
+
+	assert.NoError(t, outbox.Relay(ctx))
+	assert.Empty(t, webhooks.published)
+	assert.Equal(t, []int64{1}, archive.published)
+	outboxRepositoryMock.AssertExpectations(t)
+	outboxRepositoryMock.AssertNotCalled(t, "MarkPublished", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOutboxFeed(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	config := service.DefaultOutboxConfig()
+	eventBus := helper.NewMemoryEventBus()
+	subscription := eventBus.Subscribe(1, 0)
+
+	defer subscription.Close()
+
+	outboxRepositoryMock := new(OutboxRepositoryMock)
+	outbox := service.NewOutbox(db, outboxRepositoryMock, eventBus, nil, config)
+
+	todoPayload, _ := json.Marshal(response.TodoResponse{Id: 3, UserId: 1, Title: "Buy milk"})
+	otherTodoPayload, _ := json.Marshal(response.TodoResponse{Id: 4, UserId: 1, Title: "Call mom"})
+
+	ctx := context.Background()
+	outboxRepositoryMock.On("GetLastId", ctx, db).Return(int64(5), nil).Once()
+	outboxRepositoryMock.On("GetByIds", ctx, db, []int64{}).Return([]entity.OutboxEvent{}, nil).Once()
+	outboxRepositoryMock.On("GetAfter", ctx, db, int64(5), config.BatchSize).Return([]entity.OutboxEvent{
+		{Id: 6, UserId: 1, EventType: helper.EventTodoUpdated, Payload: string(todoPayload)},
+		{Id: 8, UserId: 1, EventType: helper.EventTodoDeleted, Payload: `{"id":3,"uuid":"` + todoUuid + `"}`},
+	}, nil).Once()
+	// Event 7 committed after 8, it is looked for again.
+	outboxRepositoryMock.On("GetByIds", ctx, db, []int64{7}).Return([]entity.OutboxEvent{
+		{Id: 7, UserId: 1, EventType: helper.EventTodoCreated, Payload: string(otherTodoPayload)},
+	}, nil).Once()
+	outboxRepositoryMock.On("GetAfter", ctx, db, int64(8), config.BatchSize).Return([]entity.OutboxEvent{}, nil).Once()
+
+	// The first call only finds where the feed starts.
+	assert.NoError(t, outbox.Feed(ctx))
+	assert.NoError(t, outbox.Feed(ctx))
+	assert.NoError(t, outbox.Feed(ctx))
+
+	assert.Equal(t, helper.Event{Id: 6, UserId: 1, Type: helper.EventTodoUpdated, Data: response.TodoResponse{Id: 3, UserId: 1, Title: "Buy milk"}}, <-subscription.Events)
+	assert.Equal(t, helper.Event{Id: 8, UserId: 1, Type: helper.EventTodoDeleted, Data: response.TodoDeletedResponse{Id: 3, Uuid: todoUuid}}, <-subscription.Events)
+	assert.Equal(t, helper.Event{Id: 7, UserId: 1, Type: helper.EventTodoCreated, Data: response.TodoResponse{Id: 4, UserId: 1, Title: "Call mom"}}, <-subscription.Events)
+	outboxRepositoryMock.AssertExpectations(t)
+
+	// The ids are the outbox's, a client that saw event 8 on any instance
+	// resumes with the late event 7.
+	resumed := eventBus.Subscribe(1, 8)
+	defer resumed.Close()
+
+	assert.True(t, resumed.Resumed)
+	assert.Len(t, resumed.Backlog, 1)
+	assert.Equal(t, int64(7), resumed.Backlog[0].Id)
+}
+
+// serveRedis answers every command with reply and sends the commands it
+// read on the returned channel.
+func serveRedis(t *testing.T, reply string) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	commands := make(chan []string, 10)
+
+	go func() {
+		conn, err := listener.Accept()
+
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+
+		for {
+			line, err := reader.ReadString('\n')
+
+			if err != nil {
+				return
+			}
+
+			count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			command := []string{}
+
+			for i := 0; i < count; i++ {
+				reader.ReadString('\n')
+				arg, _ := reader.ReadString('\n')
+				command = append(command, strings.TrimSuffix(arg, "\r\n"))
+			}
+
+			commands <- command
+			conn.Write([]byte(reply))
+		}
+	}()
+
+	return listener.Addr().String(), commands
+}
+
+func TestRedisStreamSink(t *testing.T) {
+	address, commands := serveRedis(t, "$15\r\n1710928800000-0\r\n")
+
+	client := helper.NewRedisClient(address, "")
+	defer client.Close()
+
+	sink := service.NewRedisStreamSink(client, "todo-events")
+
+	event := entity.OutboxEvent{EventId: todoUuid, UserId: 1, EventType: helper.EventTodoCreated, Payload: `{"id":3}`, CreatedAt: "2024-03-20 10:00:00"}
+
+	assert.NoError(t, sink.Publish(context.Background(), event))
+	assert.Equal(t, []string{"XADD", "todo-events", "MAXLEN", "~", "100000", "*", "event_id", todoUuid, "event_type", "todo.created", "user_id", "1", "created_at", "2024-03-20 10:00:00", "payload", `{"id":3}`}, <-commands)
+}
+
+func TestRedisClientErrorReply(t *testing.T) {
+	address, _ := serveRedis(t, "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n")
+
+	client := helper.NewRedisClient(address, "")
+	defer client.Close()
+
+	_, err := client.Do(context.Background(), "XADD", "todo-events", "*", "a", "b")
+
+	redisError := helper.RedisError("")
+
+	assert.True(t, errors.As(err, &redisError))
+	assert.True(t, strings.HasPrefix(err.Error(), "WRONGTYPE"))
+
+	// The connection stays usable after an error reply.
+	_, errAgain := client.Do(context.Background(), "XADD", "todo-events", "*", "a", "b")
+
+	assert.True(t, errors.As(errAgain, &redisError))
+}
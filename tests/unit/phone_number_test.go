@@ -0,0 +1,72 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	customvalidator "go_todo_api/internal/validator"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	assert.Equal(t, "+6281234567890", helper.NormalizePhoneNumber("081234567890"))
+	assert.Equal(t, "+6281234567890", helper.NormalizePhoneNumber(" +62 812-3456-7890 "))
+	assert.Equal(t, "+6281234567890", helper.NormalizePhoneNumber("(0812) 3456 7890"))
+	assert.Equal(t, "+14155552671", helper.NormalizePhoneNumber("+1 415 555 2671"))
+	assert.Equal(t, "not a number", helper.NormalizePhoneNumber(" not a number "))
+}
+
+func TestIsValidPhoneNumber(t *testing.T) {
+	assert.True(t, helper.IsValidPhoneNumber("+6281234567890"))
+	assert.True(t, helper.IsValidPhoneNumber("081234567890"))
+	assert.True(t, helper.IsValidPhoneNumber("+14155552671"))
+	assert.False(t, helper.IsValidPhoneNumber("0512345"))
+	assert.False(t, helper.IsValidPhoneNumber("+62"))
+	assert.False(t, helper.IsValidPhoneNumber("not a number"))
+}
+
+func TestValidatorRejectsInvalidPhoneNumber(t *testing.T) {
+	validate := customvalidator.NewValidator()
+
+	userCreateRequest := request.UserCreateRequest{
+		Username:    "budi",
+		Password:    "secret",
+		Name:        "Budi",
+		Email:       "budi@example.xyz",
+		PhoneNumber: "0512345",
+	}
+
+	err := validate.StructCtx(context.Background(), userCreateRequest)
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+
+	assert.True(t, ok)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "phone_number", validationErrors[0].Field())
+	assert.Equal(t, "phone", validationErrors[0].Tag())
+
+	userCreateRequest.PhoneNumber = "+6281234567890"
+
+	assert.NoError(t, validate.StructCtx(context.Background(), userCreateRequest))
+}
+
+func TestValidatorAllowsUpdatesWithoutPhoneNumber(t *testing.T) {
+	validate := customvalidator.NewValidator()
+
+	// Users who signed in through an identity provider have no phone number.
+	userUpdateRequest := request.UserUpdateRequest{
+		Id:       1,
+		Username: "budi",
+		Name:     "Budi",
+		Email:    "budi@example.xyz",
+	}
+
+	assert.NoError(t, validate.StructCtx(context.Background(), userUpdateRequest))
+
+	userUpdateRequest.PhoneNumber = "0512345"
+
+	assert.Error(t, validate.StructCtx(context.Background(), userUpdateRequest))
+}
@@ -0,0 +1,45 @@
+package unit
+
+import (
+	"go_todo_api/internal/helper"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuietHoursDelay(t *testing.T) {
+	// 23:30 in Jakarta, 16:30 UTC.
+	now := time.Date(2024, time.April, 1, 16, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		start    string
+		end      string
+		timezone string
+		delay    time.Duration
+	}{
+		{"", "", "UTC", 0},
+		{"22:00", "22:00", "UTC", 0},
+		{"09:00", "17:00", "UTC", 30 * time.Minute},
+		{"09:00", "16:30", "UTC", 0},
+		{"17:00", "09:00", "UTC", 0},
+		// Spanning midnight, before and after it.
+		{"22:00", "07:00", "Asia/Jakarta", 7*time.Hour + 30*time.Minute},
+		{"16:00", "07:00", "UTC", 14*time.Hour + 30*time.Minute},
+		{"23:00", "17:00", "UTC", 30 * time.Minute},
+		// An unknown zone counts as UTC.
+		{"16:00", "17:00", "Mars/Olympus", 30 * time.Minute},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.delay, helper.QuietHoursDelay(test.start, test.end, test.timezone, now), test.start+"-"+test.end+" "+test.timezone)
+	}
+}
+
+func TestQuietHoursDelayAcrossDaylightSaving(t *testing.T) {
+	// Clocks in New York go forward at 02:00 on 10 March 2024, the night
+	// from 22:00 to 07:00 is an hour shorter.
+	now := time.Date(2024, time.March, 10, 3, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 8*time.Hour, helper.QuietHoursDelay("22:00", "07:00", "America/New_York", now))
+}
@@ -0,0 +1,315 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/service"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ReminderRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *ReminderRepositoryMock) GetDue(ctx context.Context, db *sql.DB, window time.Duration, limit int) ([]entity.Reminder, error) {
+	args := mock.Called(ctx, db, window, limit)
+	return args.Get(0).([]entity.Reminder), args.Error(1)
+}
+
+func (mock *ReminderRepositoryMock) Insert(ctx context.Context, tx *sql.Tx, reminder entity.Reminder) (int64, error) {
+	args := mock.Called(ctx, tx, reminder)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (mock *ReminderRepositoryMock) Get(ctx context.Context, db *sql.DB, reminderId int64) (entity.Reminder, error) {
+	args := mock.Called(ctx, db, reminderId)
+	return args.Get(0).(entity.Reminder), args.Error(1)
+}
+
+func (mock *ReminderRepositoryMock) Update(ctx context.Context, db *sql.DB, reminder entity.Reminder) error {
+	args := mock.Called(ctx, db, reminder)
+	return args.Error(0)
+}
+
+func (mock *ReminderRepositoryMock) AddSentTo(ctx context.Context, db *sql.DB, reminderId int64, targetKey string) error {
+	args := mock.Called(ctx, db, reminderId, targetKey)
+	return args.Error(0)
+}
+
+func (mock *ReminderRepositoryMock) DeleteOld(ctx context.Context, db *sql.DB, olderThan time.Duration, limit int) (int64, error) {
+	args := mock.Called(ctx, db, olderThan, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type NotificationRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *NotificationRepositoryMock) GetSettings(ctx context.Context, db *sql.DB, userId int) (entity.NotificationSettings, error) {
+	args := mock.Called(ctx, db, userId)
+	return args.Get(0).(entity.NotificationSettings), args.Error(1)
+}
+
+func (mock *NotificationRepositoryMock) SaveSettings(ctx context.Context, db *sql.DB, settings entity.NotificationSettings) error {
+	args := mock.Called(ctx, db, settings)
+	return args.Error(0)
+}
+
+func (mock *NotificationRepositoryMock) SaveSubscription(ctx context.Context, db *sql.DB, subscription entity.PushSubscription) (int, error) {
+	args := mock.Called(ctx, db, subscription)
+	return args.Int(0), args.Error(1)
+}
+
+func (mock *NotificationRepositoryMock) GetSubscription(ctx context.Context, db *sql.DB, userId int, subscriptionId int) (entity.PushSubscription, error) {
+	args := mock.Called(ctx, db, userId, subscriptionId)
+	return args.Get(0).(entity.PushSubscription), args.Error(1)
+}
+
+func (mock *NotificationRepositoryMock) GetUserSubscriptions(ctx context.Context, db *sql.DB, userId int, channel string) ([]entity.PushSubscription, error) {
+	args := mock.Called(ctx, db, userId, channel)
+	return args.Get(0).([]entity.PushSubscription), args.Error(1)
+}
+
+func (mock *NotificationRepositoryMock) DeleteSubscription(ctx context.Context, db *sql.DB, userId int, subscriptionId int) error {
+	args := mock.Called(ctx, db, userId, subscriptionId)
+	return args.Error(0)
+}
+
+// notificationChannelStub reaches the targets it was given and fails the
+// keys in failing.
+type notificationChannelStub struct {
+	name    string
+	targets []service.NotificationTarget
+	failing map[string]error
+	sent    []string
+}
+
+func (channel *notificationChannelStub) Name() string {
+	return channel.name
+}
+
+func (channel *notificationChannelStub) Targets(ctx context.Context, user entity.User) ([]service.NotificationTarget, error) {
+	return channel.targets, nil
+}
+
+func (channel *notificationChannelStub) Send(ctx context.Context, target service.NotificationTarget, notification service.Notification) error {
+	if err := channel.failing[target.Key]; err != nil {
+		return err
+	}
+
+	channel.sent = append(channel.sent, target.Key)
+
+	return nil
+}
+
+func TestReminderScanJobQueuesDueReminders(t *testing.T) {
+	db, sqlMock, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	config := service.DefaultReminderConfig()
+	reminderRepositoryMock := new(ReminderRepositoryMock)
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	jobRepositoryMock := new(JobRepositoryMock)
+	scanJob := service.NewReminderScanJob(db, reminderRepositoryMock, notificationRepositoryMock, jobRepositoryMock, config)
+
+	ctx := context.Background()
+	reminderRepositoryMock.On("GetDue", ctx, db, config.Window, config.BatchSize).Return([]entity.Reminder{
+		{TodoId: 3, UserId: 1, RemindAt: 1711965600, Status: entity.ReminderPending},
+		{TodoId: 4, UserId: 1, RemindAt: 1711965600, Status: entity.ReminderPending},
+		{TodoId: 5, UserId: 2, RemindAt: 1711965600, Status: entity.ReminderPending},
+	}, nil).Once()
+
+	// Settings are read once for each user.
+	notificationRepositoryMock.On("GetSettings", ctx, db, 1).Return(entity.NotificationSettings{}, helper.ErrNotFound).Once()
+	now := time.Now().UTC()
+	notificationRepositoryMock.On("GetSettings", ctx, db, 2).Return(entity.NotificationSettings{
+		UserId:          2,
+		Channels:        "email",
+		Timezone:        "UTC",
+		QuietHoursStart: now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:   now.Add(time.Hour).Format("15:04"),
+	}, nil).Once()
+
+	// Todo 4 was queued by another scan in between.
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectRollback()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+
+	reminderRepositoryMock.On("Insert", ctx, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(reminder entity.Reminder) bool { return reminder.TodoId == 3 })).Return(int64(7), nil)
+	reminderRepositoryMock.On("Insert", ctx, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(reminder entity.Reminder) bool { return reminder.TodoId == 4 })).Return(int64(0), helper.NewConflictError("todo_id"))
+	reminderRepositoryMock.On("Insert", ctx, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(reminder entity.Reminder) bool { return reminder.TodoId == 5 })).Return(int64(8), nil)
+
+	jobRepositoryMock.On("InsertInTx", ctx, mock.AnythingOfType("*sql.Tx"), entity.Job{Type: service.ReminderJobType, Payload: `{"reminder_id":7}`, UniqueKey: "reminder:7"}, time.Duration(0)).Return(nil)
+
+	// User 2 is in quiet hours, which end in about an hour.
+	jobRepositoryMock.On("InsertInTx", ctx, mock.AnythingOfType("*sql.Tx"), entity.Job{Type: service.ReminderJobType, Payload: `{"reminder_id":8}`, UniqueKey: "reminder:8"}, mock.MatchedBy(func(delay time.Duration) bool {
+		return delay > 59*time.Minute && delay <= time.Hour && delay%time.Second == 0
+	})).Return(nil)
+
+	assert.NoError(t, scanJob.Handle(ctx, entity.Job{Type: service.ReminderScanJobType}))
+	// Counted rather than asserted, testify would print the committed *sql.Tx
+	// while database/sql may still be writing to it.
+	reminderRepositoryMock.AssertNumberOfCalls(t, "GetDue", 1)
+	reminderRepositoryMock.AssertNumberOfCalls(t, "Insert", 3)
+	notificationRepositoryMock.AssertExpectations(t)
+	jobRepositoryMock.AssertNumberOfCalls(t, "InsertInTx", 2)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func newTestReminderJob(t *testing.T, reminderRepositoryMock *ReminderRepositoryMock, todoRepositoryMock *TodoRepositoryMock, userRepositoryMock *UserRepositoryMock, notificationRepositoryMock *NotificationRepositoryMock, channels ...service.NotificationChannel) (*service.ReminderJob, *sql.DB) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+	t.Cleanup(func() { db.Close() })
+
+	return service.NewReminderJob(db, reminderRepositoryMock, todoRepositoryMock, userRepositoryMock, notificationRepositoryMock, channels), db
+}
+
+var reminderJobEntity = entity.Job{Type: service.ReminderJobType, Payload: `{"reminder_id":7}`}
+
+func TestReminderJobSendsOnEnabledChannels(t *testing.T) {
+	email := &notificationChannelStub{name: entity.NotificationChannelEmail, targets: []service.NotificationTarget{{Key: "email", Email: "ahmad@example.com"}}}
+	webPush := &notificationChannelStub{name: entity.NotificationChannelWebPush, targets: []service.NotificationTarget{{Key: "web_push:1"}, {Key: "web_push:2"}}}
+	fcm := &notificationChannelStub{name: entity.NotificationChannelFcm, targets: []service.NotificationTarget{{Key: "fcm:3"}}}
+
+	reminderRepositoryMock := new(ReminderRepositoryMock)
+	todoRepositoryMock := new(TodoRepositoryMock)
+	userRepositoryMock := new(UserRepositoryMock)
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	reminderJob, db := newTestReminderJob(t, reminderRepositoryMock, todoRepositoryMock, userRepositoryMock, notificationRepositoryMock, email, webPush, fcm)
+
+	ctx := context.Background()
+	reminderRepositoryMock.On("Get", ctx, db, int64(7)).Return(entity.Reminder{Id: 7, TodoId: 3, UserId: 1, RemindAt: 1711965600, Status: entity.ReminderPending}, nil)
+	todoRepositoryMock.On("Get", ctx, db, 3).Return(entity.Todo{Id: 3, UserId: 1, Title: "Pay rent", RemindAt: 1711965600}, nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(entity.User{Id: 1, Email: "ahmad@example.com"}, nil)
+	notificationRepositoryMock.On("GetSettings", ctx, db, 1).Return(entity.NotificationSettings{UserId: 1, Channels: "email web_push", Timezone: "UTC"}, nil)
+	reminderRepositoryMock.On("AddSentTo", ctx, db, int64(7), "email").Return(nil).Once()
+	reminderRepositoryMock.On("AddSentTo", ctx, db, int64(7), "web_push:1").Return(nil).Once()
+	reminderRepositoryMock.On("AddSentTo", ctx, db, int64(7), "web_push:2").Return(nil).Once()
+	reminderRepositoryMock.On("Update", ctx, db, entity.Reminder{Id: 7, TodoId: 3, UserId: 1, RemindAt: 1711965600, Status: entity.ReminderSent, SentTo: "email web_push:1 web_push:2"}).Return(nil)
+
+	assert.NoError(t, reminderJob.Handle(ctx, reminderJobEntity))
+	assert.Equal(t, []string{"email"}, email.sent)
+	assert.Equal(t, []string{"web_push:1", "web_push:2"}, webPush.sent)
+	assert.Empty(t, fcm.sent)
+	reminderRepositoryMock.AssertExpectations(t)
+}
+
+func TestReminderJobRetriesOnlyFailedTargets(t *testing.T) {
+	email := &notificationChannelStub{name: entity.NotificationChannelEmail, targets: []service.NotificationTarget{{Key: "email"}}}
+	webPush := &notificationChannelStub{
+		name:    entity.NotificationChannelWebPush,
+		targets: []service.NotificationTarget{{Key: "web_push:1"}, {Key: "web_push:2"}},
+		failing: map[string]error{"web_push:2": errors.New("web push: unexpected status 503")},
+	}
+
+	reminderRepositoryMock := new(ReminderRepositoryMock)
+	todoRepositoryMock := new(TodoRepositoryMock)
+	userRepositoryMock := new(UserRepositoryMock)
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	reminderJob, db := newTestReminderJob(t, reminderRepositoryMock, todoRepositoryMock, userRepositoryMock, notificationRepositoryMock, email, webPush)
+
+	ctx := context.Background()
+
+	// The email went out on an earlier attempt.
+	reminderRepositoryMock.On("Get", ctx, db, int64(7)).Return(entity.Reminder{Id: 7, TodoId: 3, UserId: 1, RemindAt: 1711965600, Status: entity.ReminderPending, SentTo: "email"}, nil)
+	todoRepositoryMock.On("Get", ctx, db, 3).Return(entity.Todo{Id: 3, UserId: 1, Title: "Pay rent", RemindAt: 1711965600}, nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(entity.User{Id: 1, Email: "ahmad@example.com"}, nil)
+	notificationRepositoryMock.On("GetSettings", ctx, db, 1).Return(entity.NotificationSettings{}, helper.ErrNotFound)
+	reminderRepositoryMock.On("AddSentTo", ctx, db, int64(7), "web_push:1").Return(nil).Once()
+	reminderRepositoryMock.On("Update", ctx, db, entity.Reminder{Id: 7, TodoId: 3, UserId: 1, RemindAt: 1711965600, Status: entity.ReminderPending, SentTo: "email web_push:1", LastError: "web_push:2: web push: unexpected status 503"}).Return(nil)
+
+	err := reminderJob.Handle(ctx, reminderJobEntity)
+
+	assert.ErrorContains(t, err, "unexpected status 503")
+	assert.Empty(t, email.sent)
+	assert.Equal(t, []string{"web_push:1"}, webPush.sent)
+	reminderRepositoryMock.AssertExpectations(t)
+}
+
+func TestReminderJobSkipsChangedTodos(t *testing.T) {
+	tests := []struct {
+		name string
+		todo entity.Todo
+		err  error
+	}{
+		{"done", entity.Todo{Id: 3, IsDone: true, RemindAt: 1711965600}, nil},
+		{"moved", entity.Todo{Id: 3, RemindAt: 1711969200}, nil},
+		{"cleared", entity.Todo{Id: 3}, nil},
+		{"deleted", entity.Todo{}, helper.ErrNotFound},
+	}
+
+	for _, test := range tests {
+		email := &notificationChannelStub{name: entity.NotificationChannelEmail, targets: []service.NotificationTarget{{Key: "email"}}}
+
+		reminderRepositoryMock := new(ReminderRepositoryMock)
+		todoRepositoryMock := new(TodoRepositoryMock)
+		reminderJob, db := newTestReminderJob(t, reminderRepositoryMock, todoRepositoryMock, new(UserRepositoryMock), new(NotificationRepositoryMock), email)
+
+		ctx := context.Background()
+		reminderRepositoryMock.On("Get", ctx, db, int64(7)).Return(entity.Reminder{Id: 7, TodoId: 3, UserId: 1, RemindAt: 1711965600, Status: entity.ReminderPending}, nil)
+		todoRepositoryMock.On("Get", ctx, db, 3).Return(test.todo, test.err)
+		reminderRepositoryMock.On("Update", ctx, db, mock.MatchedBy(func(reminder entity.Reminder) bool {
+			return reminder.Status == entity.ReminderSkipped
+		})).Return(nil)
+
+		assert.NoError(t, reminderJob.Handle(ctx, reminderJobEntity), test.name)
+		assert.Empty(t, email.sent, test.name)
+		reminderRepositoryMock.AssertExpectations(t)
+	}
+}
+
+func TestReminderJobIgnoresFinishedReminders(t *testing.T) {
+	reminderRepositoryMock := new(ReminderRepositoryMock)
+	reminderJob, db := newTestReminderJob(t, reminderRepositoryMock, new(TodoRepositoryMock), new(UserRepositoryMock), new(NotificationRepositoryMock))
+
+	ctx := context.Background()
+	reminderRepositoryMock.On("Get", ctx, db, int64(7)).Return(entity.Reminder{Id: 7, Status: entity.ReminderSent}, nil).Once()
+	reminderRepositoryMock.On("Get", ctx, db, int64(7)).Return(entity.Reminder{}, helper.ErrNotFound).Once()
+
+	assert.NoError(t, reminderJob.Handle(ctx, reminderJobEntity))
+	assert.NoError(t, reminderJob.Handle(ctx, reminderJobEntity))
+	reminderRepositoryMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReminderJobSavesEachTargetOnceSent(t *testing.T) {
+	email := &notificationChannelStub{name: entity.NotificationChannelEmail, targets: []service.NotificationTarget{{Key: "email"}}}
+	webPush := &notificationChannelStub{name: entity.NotificationChannelWebPush, targets: []service.NotificationTarget{{Key: "web_push:1"}, {Key: "web_push:2"}}}
+
+	reminderRepositoryMock := new(ReminderRepositoryMock)
+	todoRepositoryMock := new(TodoRepositoryMock)
+	userRepositoryMock := new(UserRepositoryMock)
+	notificationRepositoryMock := new(NotificationRepositoryMock)
+	reminderJob, db := newTestReminderJob(t, reminderRepositoryMock, todoRepositoryMock, userRepositoryMock, notificationRepositoryMock, email, webPush)
+
+	ctx := context.Background()
+	errSave := errors.New("connection lost")
+
+	reminderRepositoryMock.On("Get", ctx, db, int64(7)).Return(entity.Reminder{Id: 7, TodoId: 3, UserId: 1, RemindAt: 1711965600, Status: entity.ReminderPending}, nil)
+	todoRepositoryMock.On("Get", ctx, db, 3).Return(entity.Todo{Id: 3, UserId: 1, Title: "Pay rent", RemindAt: 1711965600}, nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(entity.User{Id: 1, Email: "ahmad@example.com"}, nil)
+	notificationRepositoryMock.On("GetSettings", ctx, db, 1).Return(entity.NotificationSettings{}, helper.ErrNotFound)
+	reminderRepositoryMock.On("AddSentTo", ctx, db, int64(7), "email").Return(nil).Once()
+	reminderRepositoryMock.On("AddSentTo", ctx, db, int64(7), "web_push:1").Return(errSave).Once()
+
+	// The email is saved before the web push goes out, a failed save stops the
+	// run before it sends to anyone else.
+	assert.ErrorIs(t, reminderJob.Handle(ctx, reminderJobEntity), errSave)
+	assert.Equal(t, []string{"web_push:1"}, webPush.sent)
+	reminderRepositoryMock.AssertExpectations(t)
+	reminderRepositoryMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
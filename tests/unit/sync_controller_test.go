@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type SyncServiceMock struct {
+	mock.Mock
+}
+
+func (mock *SyncServiceMock) Pull(ctx context.Context, userId int, since string) (response.SyncPullResponse, error) {
+	args := mock.Called(ctx, userId, since)
+
+	if args.Get(1) != nil {
+		return response.SyncPullResponse{}, args.Error(1)
+	}
+
+	return args.Get(0).(response.SyncPullResponse), nil
+}
+
+func (mock *SyncServiceMock) Push(ctx context.Context, userId int, pushRequest request.SyncPushRequest) (response.SyncPushResponse, error) {
+	args := mock.Called(ctx, userId, pushRequest)
+
+	if args.Get(1) != nil {
+		return response.SyncPushResponse{}, args.Error(1)
+	}
+
+	return args.Get(0).(response.SyncPushResponse), nil
+}
+
+func TestSyncControllerPull(t *testing.T) {
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/sync?since=c2VxOjEw", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	syncServiceMock := new(SyncServiceMock)
+	syncController := controller.NewSyncController(syncServiceMock)
+
+	pullResponse := response.SyncPullResponse{
+		Todos:     []response.SyncTodoResponse{{Id: todoUuid, Title: "Buy milk", Version: 2}},
+		Deleted:   []string{},
+		NextToken: helper.EncodeSyncToken(11),
+	}
+
+	syncServiceMock.On("Pull", request.Context(), 1, "c2VxOjEw").Return(pullResponse, nil)
+
+	syncController.Pull(recorder, request, params)
+
+	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Nil(t, err)
+
+	standardResponse := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResponse)
+
+	data := standardResponse.Data.(map[string]any)
+
+	assert.Equal(t, helper.EncodeSyncToken(11), data["next_token"])
+	assert.Equal(t, false, data["has_more"])
+	assert.Len(t, data["todos"], 1)
+}
+
+func TestSyncControllerPush(t *testing.T) {
+	syncPushRequest := request.SyncPushRequest{
+		Mutations: []request.SyncMutationRequest{
+			{Op: request.SyncOpDelete, Id: todoUuid, BaseVersion: 2},
+		},
+	}
+
+	jsonRequest := strings.NewReader(`{"mutations": [{"op": "delete", "id": "` + todoUuid + `", "base_version": 2}]}`)
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/sync", jsonRequest)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{}
+
+	recorder := httptest.NewRecorder()
+
+	syncServiceMock := new(SyncServiceMock)
+	syncController := controller.NewSyncController(syncServiceMock)
+
+	pushResponse := response.SyncPushResponse{
+		Results: []response.SyncMutationResponse{{Id: todoUuid, Status: response.SyncStatusApplied}},
+	}
+
+	syncServiceMock.On("Push", request.Context(), 1, syncPushRequest).Return(pushResponse, nil)
+
+	syncController.Push(recorder, request, params)
+
+	result := recorder.Result()
+
+	assert.Equal(t, 200, result.StatusCode)
+	syncServiceMock.AssertExpectations(t)
+}
+
+func TestSyncControllerPullWithoutUser(t *testing.T) {
+	clientPrincipal := helper.Principal{ClientId: "reporting", AuthMethod: helper.AuthMethodOauth, Scopes: []string{helper.ScopeTodosRead}}
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/sync", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), clientPrincipal))
+
+	recorder := httptest.NewRecorder()
+
+	syncServiceMock := new(SyncServiceMock)
+	syncController := controller.NewSyncController(syncServiceMock)
+
+	syncController.Pull(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+	syncServiceMock.AssertNotCalled(t, "Pull", mock.Anything, mock.Anything, mock.Anything)
+}
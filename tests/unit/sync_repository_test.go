@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var syncRepository = repository.NewSyncRepository()
+
+func TestSyncRepositoryNextChangeSeq(t *testing.T) {
+	db, mock, errDBMock := sqlmock.New()
+
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO sync_sequences (.+) ON DUPLICATE KEY UPDATE last_seq = LAST_INSERT_ID\\(last_seq \\+ 1\\)").ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(42, 2))
+	mock.ExpectCommit()
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	changeSeq, err := syncRepository.NextChangeSeq(context.Background(), tx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), changeSeq)
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncRepositoryInsertTombstone(t *testing.T) {
+	db, mock, errDBMock := sqlmock.New()
+
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	tombstone := entity.TodoTombstone{UserId: 1, Uuid: todoUuid, ChangeSeq: 7}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO todo_tombstones (.+) ON DUPLICATE KEY UPDATE").ExpectExec().WithArgs(1, todoUuid, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("DELETE FROM todo_tombstones WHERE user_id = \\? AND uuid = \\?").ExpectExec().WithArgs(1, todoUuid).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+	assert.NoError(t, syncRepository.InsertTombstone(context.Background(), tx, tombstone))
+	assert.NoError(t, syncRepository.DeleteTombstone(context.Background(), tx, 1, todoUuid))
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncRepositoryGetTombstonesSince(t *testing.T) {
+	db, mock, errDBMock := sqlmock.New()
+
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"user_id", "uuid", "change_seq", "deleted_at"}).AddRow(1, todoUuid, 11, "2024-03-05 10:00:00")
+
+	mock.ExpectPrepare("SELECT user_id, uuid, change_seq, deleted_at FROM todo_tombstones WHERE user_id = \\? AND change_seq > \\? ORDER BY change_seq LIMIT \\?").ExpectQuery().WithArgs(1, 10, 501).WillReturnRows(rows)
+
+	tombstones, err := syncRepository.GetTombstonesSince(context.Background(), db, 1, 10, 501)
+
+	assert.NoError(t, err)
+	assert.Len(t, tombstones, 1)
+	assert.Equal(t, todoUuid, tombstones[0].Uuid)
+	assert.Equal(t, int64(11), tombstones[0].ChangeSeq)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,210 @@
+package unit
+
+import (
+	"context"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"go_todo_api/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const otherTodoUuid = "018e1c2a-7b3c-7d4e-8f90-cba987654321"
+
+func TestSyncTokenRoundTrip(t *testing.T) {
+	changeSeq, err := helper.DecodeSyncToken(helper.EncodeSyncToken(42))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), changeSeq)
+
+	changeSeq, err = helper.DecodeSyncToken("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), changeSeq)
+
+	for _, token := range []string{"42", "!!", helper.EncodeSyncToken(-1)} {
+		_, err := helper.DecodeSyncToken(token)
+
+		assert.ErrorIs(t, err, helper.ErrSyncTokenInvalid, token)
+	}
+}
+
+func TestIsUuidV7(t *testing.T) {
+	assert.True(t, helper.IsUuidV7(todoUuid))
+	assert.True(t, helper.IsUuidV7(strings.ToUpper(todoUuid)))
+	assert.False(t, helper.IsUuidV7("6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+	assert.False(t, helper.IsUuidV7("{"+todoUuid+"}"))
+	assert.False(t, helper.IsUuidV7("not-a-uuid"))
+}
+
+func TestSyncServicePull(t *testing.T) {
+	db, _, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	syncService := service.NewSyncService(db, todoRepositoryMock, syncRepositoryMock, newOutboxMock(), validatorMock)
+
+	ctx := context.Background()
+	todos := []entity.Todo{
+		{Id: 1, Uuid: todoUuid, UserId: 1, Title: "Buy milk", Version: 2, ChangeSeq: 11},
+		{Id: 3, Uuid: "018e1c2a-7b3c-7d4e-8f90-000000000003", UserId: 1, Title: "Call mom", Version: 1, ChangeSeq: 14},
+	}
+	tombstones := []entity.TodoTombstone{
+		{UserId: 1, Uuid: otherTodoUuid, ChangeSeq: 12},
+	}
+
+	todoRepositoryMock.On("GetChangedSince", ctx, db, 1, int64(10), 501).Return(todos, nil)
+	syncRepositoryMock.On("GetTombstonesSince", ctx, db, 1, int64(10), 501).Return(tombstones, nil)
+
+	pullResponse, err := syncService.Pull(ctx, 1, helper.EncodeSyncToken(10))
+
+	assert.NoError(t, err)
+	assert.Len(t, pullResponse.Todos, 2)
+	assert.Equal(t, todoUuid, pullResponse.Todos[0].Id)
+	assert.Equal(t, []string{otherTodoUuid}, pullResponse.Deleted)
+	assert.Equal(t, helper.EncodeSyncToken(14), pullResponse.NextToken)
+	assert.False(t, pullResponse.HasMore)
+}
+
+func TestSyncServicePullFirstSync(t *testing.T) {
+	db, _, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	syncService := service.NewSyncService(db, todoRepositoryMock, syncRepositoryMock, newOutboxMock(), validatorMock)
+
+	ctx := context.Background()
+	todos := []entity.Todo{}
+
+	for i := 1; i <= 501; i++ {
+		todos = append(todos, entity.Todo{Id: i, Uuid: todoUuid, UserId: 1, ChangeSeq: int64(i)})
+	}
+
+	todoRepositoryMock.On("GetChangedSince", ctx, db, 1, int64(0), 501).Return(todos, nil)
+
+	pullResponse, err := syncService.Pull(ctx, 1, "")
+
+	assert.NoError(t, err)
+	assert.Len(t, pullResponse.Todos, 500)
+	assert.Empty(t, pullResponse.Deleted)
+	assert.Equal(t, helper.EncodeSyncToken(500), pullResponse.NextToken)
+	assert.True(t, pullResponse.HasMore)
+	syncRepositoryMock.AssertNotCalled(t, "GetTombstonesSince", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSyncServicePullInvalidToken(t *testing.T) {
+	syncService := service.NewSyncService(nil, new(TodoRepositoryMock), new(SyncRepositoryMock), newOutboxMock(), validatorMock)
+
+	_, err := syncService.Pull(context.Background(), 1, "not-a-token")
+
+	assert.ErrorIs(t, err, helper.ErrSyncTokenInvalid)
+}
+
+func TestSyncServicePush(t *testing.T) {
+	db, sqlMock, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	outboxMock := newOutboxMock()
+	syncService := service.NewSyncService(db, todoRepositoryMock, syncRepositoryMock, outboxMock, validatorMock)
+
+	ctx := context.Background()
+	pushRequest := request.SyncPushRequest{
+		Mutations: []request.SyncMutationRequest{
+			// Stored lowercase, like ids picked through POST /api/todo.
+			{Op: request.SyncOpUpsert, Id: strings.ToUpper(otherTodoUuid), BaseVersion: 0, Title: "Created offline"},
+			{Op: request.SyncOpUpsert, Id: todoUuid, BaseVersion: 1, Title: "Edited offline"},
+		},
+	}
+
+	serverTodo := entity.Todo{Id: 1, Uuid: todoUuid, UserId: 1, Title: "Edited online", Version: 2}
+	createdTodo := entity.Todo{Id: 2, Uuid: otherTodoUuid, UserId: 1, Title: "Created offline", Version: 1}
+	newTodo := mock.MatchedBy(func(todo entity.Todo) bool {
+		return todo.Uuid == otherTodoUuid && todo.UserId == 1 && todo.ChangeSeq == 20
+	})
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	validatorMock.On("StructCtx", ctx, pushRequest).Return(nil)
+	todoRepositoryMock.On("GetByUuid", ctx, db, 1, otherTodoUuid).Return(entity.Todo{}, helper.ErrNotFound).Once()
+	syncRepositoryMock.On("NextChangeSeq", ctx, mock.Anything, 1).Return(int64(20), nil)
+	todoRepositoryMock.On("Insert", ctx, mock.Anything, newTodo).Return(2, nil)
+	syncRepositoryMock.On("DeleteTombstone", ctx, mock.Anything, 1, otherTodoUuid).Return(nil)
+	todoRepositoryMock.On("GetInTx", ctx, mock.Anything, 2).Return(createdTodo, nil)
+	outboxMock.On("Add", ctx, mock.AnythingOfType("*sql.Tx"), 1, helper.EventTodoCreated, mock.Anything).Return(nil)
+	todoRepositoryMock.On("GetByUuid", ctx, db, 1, otherTodoUuid).Return(createdTodo, nil)
+	todoRepositoryMock.On("GetByUuid", ctx, db, 1, todoUuid).Return(serverTodo, nil)
+
+	pushResponse, err := syncService.Push(ctx, 1, pushRequest)
+
+	assert.NoError(t, err)
+	assert.Len(t, pushResponse.Results, 2)
+	assert.Equal(t, response.SyncStatusApplied, pushResponse.Results[0].Status)
+	assert.Equal(t, 1, pushResponse.Results[0].Todo.Version)
+	assert.Equal(t, response.SyncStatusConflict, pushResponse.Results[1].Status)
+	assert.Equal(t, "Edited online", pushResponse.Results[1].Todo.Title)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	todoRepositoryMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	outboxMock.AssertNumberOfCalls(t, "Add", 1)
+}
+
+func TestSyncServicePushDelete(t *testing.T) {
+	db, sqlMock, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	outboxMock := newOutboxMock()
+	syncService := service.NewSyncService(db, todoRepositoryMock, syncRepositoryMock, outboxMock, validatorMock)
+
+	ctx := context.Background()
+	pushRequest := request.SyncPushRequest{
+		Mutations: []request.SyncMutationRequest{
+			{Op: request.SyncOpDelete, Id: todoUuid, BaseVersion: 3},
+			{Op: request.SyncOpDelete, Id: otherTodoUuid, BaseVersion: 1},
+		},
+	}
+
+	tombstone := entity.TodoTombstone{UserId: 1, Uuid: todoUuid, ChangeSeq: 21}
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	validatorMock.On("StructCtx", ctx, pushRequest).Return(nil)
+	todoRepositoryMock.On("GetByUuid", ctx, db, 1, todoUuid).Return(entity.Todo{Id: 1, Uuid: todoUuid, UserId: 1, Version: 3}, nil)
+	todoRepositoryMock.On("GetByUuid", ctx, db, 1, otherTodoUuid).Return(entity.Todo{}, helper.ErrNotFound)
+	syncRepositoryMock.On("NextChangeSeq", ctx, mock.AnythingOfType("*sql.Tx"), 1).Return(int64(21), nil)
+	todoRepositoryMock.On("Delete", ctx, mock.AnythingOfType("*sql.Tx"), 1, 3).Return(nil)
+	syncRepositoryMock.On("InsertTombstone", ctx, mock.AnythingOfType("*sql.Tx"), tombstone).Return(nil)
+	outboxMock.On("Add", ctx, mock.AnythingOfType("*sql.Tx"), 1, helper.EventTodoDeleted, response.TodoDeletedResponse{Id: 1, Uuid: todoUuid}).Return(nil)
+
+	pushResponse, err := syncService.Push(ctx, 1, pushRequest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, response.SyncStatusApplied, pushResponse.Results[0].Status)
+	assert.Equal(t, response.SyncStatusApplied, pushResponse.Results[1].Status)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	// Counted rather than asserted, testify would print the committed *sql.Tx
+	// while database/sql may still be writing to it.
+	syncRepositoryMock.AssertNumberOfCalls(t, "NextChangeSeq", 1)
+	syncRepositoryMock.AssertNumberOfCalls(t, "InsertTombstone", 1)
+	outboxMock.AssertNumberOfCalls(t, "Add", 1)
+}
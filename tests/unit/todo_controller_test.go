@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/model/response"
 	"io"
@@ -40,38 +41,38 @@ func (mock *TodoServiceMock) FindUserTodos(ctx context.Context, userId int) ([]r
 	return args.Get(0).([]response.TodoResponse), nil
 }
 
-func (mock *TodoServiceMock) Create(ctx context.Context, todo request.TodoCreateRequest) error {
+func (mock *TodoServiceMock) Create(ctx context.Context, todo request.TodoCreateRequest) (response.TodoResponse, error) {
 	args := mock.Called(ctx, todo)
 
-	if args.Get(0) != nil {
-		return args.Error(0)
+	if args.Get(1) != nil {
+		return response.TodoResponse{}, args.Error(1)
 	}
 
-	return nil
+	return args.Get(0).(response.TodoResponse), nil
 }
 
-func (mock *TodoServiceMock) Update(ctx context.Context, todo request.TodoUpdateRequest) error {
+func (mock *TodoServiceMock) Update(ctx context.Context, todo request.TodoUpdateRequest) (response.TodoResponse, error) {
 	args := mock.Called(ctx, todo)
 
-	if args.Get(0) != nil {
-		return args.Error(0)
+	if args.Get(1) != nil {
+		return response.TodoResponse{}, args.Error(1)
 	}
 
-	return nil
+	return args.Get(0).(response.TodoResponse), nil
 }
 
-func (mock *TodoServiceMock) UpdateTodoCompletion(ctx context.Context, todoId int) error {
-	args := mock.Called(ctx, todoId)
+func (mock *TodoServiceMock) UpdateTodoCompletion(ctx context.Context, todoId int, version int) (response.TodoResponse, error) {
+	args := mock.Called(ctx, todoId, version)
 
-	if args.Get(0) != nil {
-		return args.Error(0)
+	if args.Get(1) != nil {
+		return response.TodoResponse{}, args.Error(1)
 	}
 
-	return nil
+	return args.Get(0).(response.TodoResponse), nil
 }
 
-func (mock *TodoServiceMock) Remove(ctx context.Context, todoId int) error {
-	args := mock.Called(ctx, todoId)
+func (mock *TodoServiceMock) Remove(ctx context.Context, todoId int, version int) error {
+	args := mock.Called(ctx, todoId, version)
 
 	if args.Get(0) != nil {
 		return args.Error(0)
@@ -82,6 +83,8 @@ func (mock *TodoServiceMock) Remove(ctx context.Context, todoId int) error {
 
 var todoServiceMock = new(TodoServiceMock)
 
+var todoControllerConfig = controller.TodoControllerConfig{RequireIfMatch: true}
+
 func TestTodoControllerCreateTodo(t *testing.T) {
 	todoCreateRequest := request.TodoCreateRequest{
 		UserId:      1,
@@ -89,30 +92,50 @@ func TestTodoControllerCreateTodo(t *testing.T) {
 		Description: "Create todo test from todo controller",
 	}
 
-	jsonTodoCreateRequest, errMarshal := json.Marshal(todoCreateRequest)
-
-	assert.Nil(t, errMarshal)
-
-	requestBody := strings.NewReader(string(jsonTodoCreateRequest))
+	// The owner is the caller, whatever user the body names.
+	requestBody := strings.NewReader(`{"user_id": 2, "title": "Create Todo Test", "description": "Create todo test from todo controller"}`)
 
 	request := httptest.NewRequest("POST", "http://localhost:8080/api/todo", requestBody)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
 	params := httprouter.Params{}
 
 	recorder := httptest.NewRecorder()
 
-	todoController := controller.NewTodoController(todoServiceMock)
+	todoController := controller.NewTodoController(todoServiceMock, todoControllerConfig)
+
+	todoResponse := response.TodoResponse{
+		Id:      12,
+		Uuid:    todoUuid,
+		UserId:  1,
+		Title:   "Create Todo Test",
+		Version: 1,
+	}
 
-	todoServiceMock.On("Create", request.Context(), mock.AnythingOfType("request.TodoCreateRequest")).Return(nil)
+	todoServiceMock.On("Create", request.Context(), todoCreateRequest).Return(todoResponse, nil)
 
 	todoController.CreateTodo(recorder, request, params)
 
 	result := recorder.Result()
+	bytes, err := io.ReadAll(result.Body)
 
 	assert.Equal(t, 201, result.StatusCode)
+	assert.Nil(t, err)
+	assert.Equal(t, "/api/todo/12", result.Header.Get("Location"))
+	assert.Equal(t, `"1"`, result.Header.Get("ETag"))
+
+	standardResponse := response.StandardResponse{}
+
+	json.Unmarshal(bytes, &standardResponse)
+
+	todo := standardResponse.Data.(map[string]any)
+
+	assert.Equal(t, float64(12), todo["id"])
+	assert.Equal(t, todoUuid, todo["uuid"])
 }
 
 func TestTodoControllerGetById(t *testing.T) {
 	request := httptest.NewRequest("GET", "http://localhost:8080/api/todo/1", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
 	params := httprouter.Params{
 		{
 			Key:   "todoId",
@@ -122,13 +145,14 @@ func TestTodoControllerGetById(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 
-	todoController := controller.NewTodoController(todoServiceMock)
+	todoController := controller.NewTodoController(todoServiceMock, todoControllerConfig)
 	todoResponse := response.TodoResponse{
 		Id:          1,
 		UserId:      1,
 		Title:       "Todo Title",
 		Description: "Todo description",
 		IsDone:      false,
+		Version:     3,
 		CreatedAt:   "2024-01-01 11:11:11",
 		UpdatedAt:   "2024-01-01 11:11:11",
 	}
@@ -141,6 +165,7 @@ func TestTodoControllerGetById(t *testing.T) {
 	bytes, err := io.ReadAll(result.Body)
 
 	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, `"3"`, result.Header.Get("ETag"))
 	assert.Nil(t, err)
 
 	standardResposne := response.StandardResponse{}
@@ -156,10 +181,33 @@ func TestTodoControllerGetById(t *testing.T) {
 	assert.Equal(t, todoResponse.IsDone, todo["is_done"])
 	assert.Equal(t, todoResponse.CreatedAt, todo["created_at"])
 	assert.Equal(t, todoResponse.UpdatedAt, todo["updated_at"])
+	assert.Equal(t, float64(todoResponse.Version), todo["version"])
+}
+
+func TestTodoControllerGetNotModified(t *testing.T) {
+	todoServiceMock := new(TodoServiceMock)
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/api/todo/1", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	request.Header.Set("If-None-Match", `W/"2", "3"`)
+	params := httprouter.Params{{Key: "todoId", Value: "1"}}
+
+	recorder := httptest.NewRecorder()
+
+	todoController := controller.NewTodoController(todoServiceMock, todoControllerConfig)
+
+	todoServiceMock.On("Find", request.Context(), 1).Return(response.TodoResponse{Id: 1, UserId: 1, Version: 3}, nil)
+
+	todoController.Get(recorder, request, params)
+
+	assert.Equal(t, 304, recorder.Result().StatusCode)
+	assert.Equal(t, `"3"`, recorder.Result().Header.Get("ETag"))
+	assert.Empty(t, recorder.Body.String())
 }
 
 func TestTodoControllerGetUserTodos(t *testing.T) {
 	request := httptest.NewRequest("GET", "http://localhost:8080/api/user/1/todo", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
 	params := httprouter.Params{
 		{
 			Key:   "userId",
@@ -169,7 +217,7 @@ func TestTodoControllerGetUserTodos(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 
-	todoController := controller.NewTodoController(todoServiceMock)
+	todoController := controller.NewTodoController(todoServiceMock, todoControllerConfig)
 
 	todoResponses := []response.TodoResponse{}
 
@@ -207,6 +255,8 @@ func TestTodoControllerGetUserTodos(t *testing.T) {
 }
 
 func TestTodoControllerUpdate(t *testing.T) {
+	todoUpdateRequest := request.TodoUpdateRequest{Id: 1, Title: "Update Todo Test", Description: "Update todo test from todo controller", IsDone: true, Version: 3}
+
 	requestBody := strings.NewReader(`{
 		"title": "Update Todo Test",
 		"description": "Update todo test from todo controller",
@@ -214,6 +264,8 @@ func TestTodoControllerUpdate(t *testing.T) {
 	}`)
 
 	request := httptest.NewRequest("PUT", "http://localhost:8080/api/todo/1", requestBody)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	request.Header.Set("If-Match", `"3"`)
 	params := httprouter.Params{
 		{
 			Key:   "todoId",
@@ -223,19 +275,27 @@ func TestTodoControllerUpdate(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 
-	todoController := controller.NewTodoController(todoServiceMock)
+	todoController := controller.NewTodoController(todoServiceMock, todoControllerConfig)
 
-	todoServiceMock.On("Update", request.Context(), mock.AnythingOfType("request.TodoUpdateRequest")).Return(nil)
+	todoServiceMock.On("Find", request.Context(), 1).Return(response.TodoResponse{Id: 1, UserId: 1, Version: 3}, nil)
+	todoServiceMock.On("Update", request.Context(), todoUpdateRequest).Return(response.TodoResponse{Id: 1, UserId: 1, Title: "Update Todo Test", IsDone: true, Version: 4}, nil)
 
 	todoController.Update(recorder, request, params)
 
 	result := recorder.Result()
 
-	assert.Equal(t, 204, result.StatusCode)
+	standardResponse := response.StandardResponse{}
+	json.NewDecoder(result.Body).Decode(&standardResponse)
+
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, `"4"`, result.Header.Get("ETag"))
+	assert.Equal(t, "Update Todo Test", standardResponse.Data.(map[string]any)["title"])
 }
 
 func TestTodoControllerUpdateTodoCompletion(t *testing.T) {
 	request := httptest.NewRequest("PATCH", "http://localhost:8080/api/todo/completion/1", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	request.Header.Set("If-Match", `"3"`)
 	params := httprouter.Params{
 		{
 			Key:   "todoId",
@@ -245,19 +305,23 @@ func TestTodoControllerUpdateTodoCompletion(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 
-	todoController := controller.NewTodoController(todoServiceMock)
+	todoController := controller.NewTodoController(todoServiceMock, todoControllerConfig)
 
-	todoServiceMock.On("UpdateTodoCompletion", request.Context(), 1).Return(nil)
+	todoServiceMock.On("Find", request.Context(), 1).Return(response.TodoResponse{Id: 1, UserId: 1, Version: 3}, nil)
+	todoServiceMock.On("UpdateTodoCompletion", request.Context(), 1, 3).Return(response.TodoResponse{Id: 1, UserId: 1, IsDone: true, Version: 4}, nil)
 
 	todoController.UpdateTodoCompletion(recorder, request, params)
 
 	result := recorder.Result()
 
-	assert.Equal(t, 204, result.StatusCode)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, `"4"`, result.Header.Get("ETag"))
 }
 
 func TestTodoControllerRemove(t *testing.T) {
 	request := httptest.NewRequest("DELETE", "http://localhost:8080/api/todo/1", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	request.Header.Set("If-Match", "*")
 	params := httprouter.Params{
 		{
 			Key:   "todoId",
@@ -267,9 +331,10 @@ func TestTodoControllerRemove(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 
-	todoController := controller.NewTodoController(todoServiceMock)
+	todoController := controller.NewTodoController(todoServiceMock, todoControllerConfig)
 
-	todoServiceMock.On("Remove", request.Context(), 1).Return(nil)
+	todoServiceMock.On("Find", request.Context(), 1).Return(response.TodoResponse{Id: 1, UserId: 1, Version: 3}, nil)
+	todoServiceMock.On("Remove", request.Context(), 1, 0).Return(nil)
 
 	todoController.Remove(recorder, request, params)
 
@@ -277,3 +342,103 @@ func TestTodoControllerRemove(t *testing.T) {
 
 	assert.Equal(t, 204, result.StatusCode)
 }
+
+func TestTodoControllerUpdateRequiresIfMatch(t *testing.T) {
+	todoServiceMock := new(TodoServiceMock)
+
+	request := httptest.NewRequest("PUT", "http://localhost:8080/api/todo/1", strings.NewReader(`{"title": "Update Todo Test"}`))
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	params := httprouter.Params{{Key: "todoId", Value: "1"}}
+
+	recorder := httptest.NewRecorder()
+
+	todoServiceMock.On("Find", request.Context(), 1).Return(response.TodoResponse{Id: 1, UserId: 1, Version: 3}, nil)
+
+	controller.NewTodoController(todoServiceMock, todoControllerConfig).Update(recorder, request, params)
+
+	assert.Equal(t, 428, recorder.Result().StatusCode)
+	todoServiceMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	request = httptest.NewRequest("PUT", "http://localhost:8080/api/todo/1", strings.NewReader(`{"title": "Update Todo Test"}`))
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	recorder = httptest.NewRecorder()
+
+	todoServiceMock.On("Find", request.Context(), 1).Return(response.TodoResponse{Id: 1, UserId: 1, Version: 3}, nil)
+	todoServiceMock.On("Update", request.Context(), mock.AnythingOfType("request.TodoUpdateRequest")).Return(response.TodoResponse{Id: 1, UserId: 1, Version: 4}, nil)
+
+	controller.NewTodoController(todoServiceMock, controller.TodoControllerConfig{}).Update(recorder, request, params)
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+}
+
+func TestTodoControllerRemoveStaleVersion(t *testing.T) {
+	todoServiceMock := new(TodoServiceMock)
+
+	request := httptest.NewRequest("DELETE", "http://localhost:8080/api/todo/1", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	request.Header.Set("If-Match", `"2"`)
+	params := httprouter.Params{{Key: "todoId", Value: "1"}}
+
+	recorder := httptest.NewRecorder()
+
+	todoServiceMock.On("Find", request.Context(), 1).Return(response.TodoResponse{Id: 1, UserId: 1, Version: 3}, nil)
+	todoServiceMock.On("Remove", request.Context(), 1, 2).Return(helper.ErrPreconditionFailed)
+
+	controller.NewTodoController(todoServiceMock, todoControllerConfig).Remove(recorder, request, params)
+
+	assert.Equal(t, 412, recorder.Result().StatusCode)
+}
+
+func TestTodoControllerHidesTodosOfOtherUsers(t *testing.T) {
+	todoServiceMock := new(TodoServiceMock)
+
+	request := httptest.NewRequest("DELETE", "http://localhost:8080/api/todo/7", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), apolloPrincipal))
+	request.Header.Set("If-Match", "*")
+	params := httprouter.Params{{Key: "todoId", Value: "7"}}
+
+	recorder := httptest.NewRecorder()
+
+	todoServiceMock.On("Find", request.Context(), 7).Return(response.TodoResponse{Id: 7, UserId: 2, Version: 1}, nil)
+
+	controller.NewTodoController(todoServiceMock, todoControllerConfig).Remove(recorder, request, params)
+
+	assert.Equal(t, 404, recorder.Result().StatusCode)
+	todoServiceMock.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything, mock.Anything)
+
+	adminPrincipal := apolloPrincipal
+	adminPrincipal.Roles = helper.RolesFor(helper.RoleAdmin)
+
+	request = httptest.NewRequest("GET", "http://localhost:8080/api/todo/7", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), adminPrincipal))
+	recorder = httptest.NewRecorder()
+
+	todoServiceMock.On("Find", request.Context(), 7).Return(response.TodoResponse{Id: 7, UserId: 2, Version: 1}, nil)
+
+	controller.NewTodoController(todoServiceMock, todoControllerConfig).Get(recorder, request, params)
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+}
+
+func TestTodoControllerRejectsClientCredentials(t *testing.T) {
+	todoServiceMock := new(TodoServiceMock)
+	clientPrincipal := helper.Principal{ClientId: "reporting", AuthMethod: helper.AuthMethodOauth, Scopes: []string{helper.ScopeTodosRead, helper.ScopeTodosWrite}}
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/todo", strings.NewReader(`{"title": "Create Todo Test"}`))
+	request = request.WithContext(helper.SetPrincipal(request.Context(), clientPrincipal))
+	recorder := httptest.NewRecorder()
+
+	controller.NewTodoController(todoServiceMock, todoControllerConfig).CreateTodo(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+
+	request = httptest.NewRequest("GET", "http://localhost:8080/api/todo/1", nil)
+	request = request.WithContext(helper.SetPrincipal(request.Context(), clientPrincipal))
+	recorder = httptest.NewRecorder()
+
+	controller.NewTodoController(todoServiceMock, todoControllerConfig).Get(recorder, request, httprouter.Params{{Key: "todoId", Value: "1"}})
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+	todoServiceMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	todoServiceMock.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+}
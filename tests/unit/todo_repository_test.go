@@ -3,17 +3,25 @@ package unit
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/repository"
 	"strconv"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 )
 
 var todoRepository = repository.NewTodoRepository()
 
+var todoColumns = []string{"id", "uuid", "user_id", "title", "description", "is_done", "remind_at", "version", "change_seq", "created_at", "updated_at"}
+
+const todoUuid = "018e1c2a-7b3c-7d4e-8f90-123456789abc"
+
 func TestTodoRepositoryGet(t *testing.T) {
 	db, mock, errDBMock := sqlmock.New()
 
@@ -21,18 +29,22 @@ func TestTodoRepositoryGet(t *testing.T) {
 
 	defer db.Close()
 
-	row := sqlmock.NewRows([]string{"id", "user_id", "title", "description", "is_done", "created_at", "updated_at"}).AddRow(1, 1, "Todo Title", "Todo description", false, "2024-01-01", "2024-01-01")
+	row := sqlmock.NewRows(todoColumns).AddRow(1, todoUuid, 1, "Todo Title", "Todo description", false, 1711965600, 4, 9, "2024-01-01", "2024-01-01")
 
-	mock.ExpectPrepare("SELECT id, user_id, title, description, is_done, created_at, updated_at FROM todos").ExpectQuery().WithArgs(1).WillReturnRows(row)
+	mock.ExpectPrepare("SELECT id, uuid, user_id, title, description, is_done, UNIX_TIMESTAMP\\(remind_at\\), version, change_seq, created_at, updated_at FROM todos").ExpectQuery().WithArgs(1).WillReturnRows(row)
 
 	todo, errGetTodo := todoRepository.Get(context.Background(), db, 1)
 
 	assert.NoError(t, errGetTodo)
 	assert.Equal(t, 1, todo.Id)
+	assert.Equal(t, todoUuid, todo.Uuid)
 	assert.Equal(t, 1, todo.UserId)
 	assert.Equal(t, "Todo Title", todo.Title)
 	assert.Equal(t, "Todo description", todo.Description)
 	assert.False(t, todo.IsDone)
+	assert.Equal(t, int64(1711965600), todo.RemindAt)
+	assert.Equal(t, 4, todo.Version)
+	assert.Equal(t, int64(9), todo.ChangeSeq)
 	assert.Equal(t, "2024-01-01", todo.CreatedAt)
 	assert.Equal(t, "2024-01-01", todo.UpdatedAt)
 
@@ -40,6 +52,47 @@ func TestTodoRepositoryGet(t *testing.T) {
 	assert.NoError(t, errMockExpectations)
 }
 
+func TestTodoRepositoryGetInTx(t *testing.T) {
+	db, mock, errDBMock := sqlmock.New()
+
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	row := sqlmock.NewRows(todoColumns).AddRow(1, todoUuid, 1, "Todo Title", nil, true, nil, 5, 10, "2024-01-01", "2024-01-02")
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT (.+) FROM todos WHERE id = ?").ExpectQuery().WithArgs(1).WillReturnRows(row)
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	todo, errGetTodo := todoRepository.GetInTx(context.Background(), tx, 1)
+
+	assert.NoError(t, errGetTodo)
+	assert.True(t, todo.IsDone)
+	assert.Equal(t, 5, todo.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTodoRepositoryGetByUuidNotFound(t *testing.T) {
+	db, mock, errDBMock := sqlmock.New()
+
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("SELECT (.+) FROM todos WHERE user_id = \\? AND uuid = \\?").ExpectQuery().WithArgs(1, todoUuid).WillReturnRows(sqlmock.NewRows(todoColumns))
+
+	_, errGetTodo := todoRepository.GetByUuid(context.Background(), db, 1, todoUuid)
+
+	assert.ErrorIs(t, errGetTodo, helper.ErrNotFound)
+
+	errMockExpectations := mock.ExpectationsWereMet()
+	assert.NoError(t, errMockExpectations)
+}
+
 func TestTodoRepositoryGetUserTodos(t *testing.T) {
 	db, mock, errDBMock := sqlmock.New()
 
@@ -47,14 +100,14 @@ func TestTodoRepositoryGetUserTodos(t *testing.T) {
 
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "title", "description", "is_done", "created_at", "updated_at"})
+	rows := sqlmock.NewRows(todoColumns)
 
 	for i := 1; i <= 3; i++ {
-		value := []driver.Value{i, 1, "Todo Title " + strconv.Itoa(i), "Todo description" + strconv.Itoa(i), false, "2024-01-01", "2024-01-01"}
+		value := []driver.Value{i, todoUuid, 1, "Todo Title " + strconv.Itoa(i), "Todo description" + strconv.Itoa(i), false, nil, 1, i, "2024-01-01", "2024-01-01"}
 		rows.AddRows(value)
 	}
 
-	mock.ExpectPrepare("SELECT id, user_id, title, description, is_done, created_at, updated_at FROM todos").ExpectQuery().WithArgs(1).WillReturnRows(rows)
+	mock.ExpectPrepare("SELECT id, uuid, user_id, title, description, is_done, UNIX_TIMESTAMP\\(remind_at\\), version, change_seq, created_at, updated_at FROM todos").ExpectQuery().WithArgs(1).WillReturnRows(rows)
 
 	todos, errGetTodo := todoRepository.GetUserTodos(context.Background(), db, 1)
 
@@ -65,6 +118,28 @@ func TestTodoRepositoryGetUserTodos(t *testing.T) {
 	assert.NoError(t, errMockExpectations)
 }
 
+func TestTodoRepositoryGetChangedSince(t *testing.T) {
+	db, mock, errDBMock := sqlmock.New()
+
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(todoColumns).AddRow(1, todoUuid, 1, "Todo Title", "", true, nil, 2, 12, "2024-01-01", nil)
+
+	mock.ExpectPrepare("SELECT (.+) FROM todos WHERE user_id = \\? AND change_seq > \\? ORDER BY change_seq LIMIT \\?").ExpectQuery().WithArgs(1, 10, 501).WillReturnRows(rows)
+
+	todos, errGetTodos := todoRepository.GetChangedSince(context.Background(), db, 1, 10, 501)
+
+	assert.NoError(t, errGetTodos)
+	assert.Len(t, todos, 1)
+	assert.Equal(t, int64(12), todos[0].ChangeSeq)
+	assert.Equal(t, "", todos[0].UpdatedAt)
+
+	errMockExpectations := mock.ExpectationsWereMet()
+	assert.NoError(t, errMockExpectations)
+}
+
 func TestTodoRepositoryInsert(t *testing.T) {
 	db, mock, errDBMock := sqlmock.New()
 
@@ -72,21 +147,63 @@ func TestTodoRepositoryInsert(t *testing.T) {
 
 	defer db.Close()
 
-	todo := request.TodoCreateRequest{
+	todo := entity.Todo{
+		Uuid:        todoUuid,
 		UserId:      1,
 		Title:       "Todo Title",
 		Description: "Todo description",
+		RemindAt:    1711965600,
+		ChangeSeq:   3,
 	}
 
-	mock.ExpectPrepare("INSERT INTO todos").ExpectExec().WithArgs(todo.UserId, todo.Title, todo.Description).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO todos").ExpectExec().WithArgs(todoUuid, todo.UserId, todo.Title, todo.Description, false, int64(1711965600), 3).WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectCommit()
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	todoId, errInsertTodo := todoRepository.Insert(context.Background(), tx, todo)
 
-	errInsertTodo := todoRepository.Insert(context.Background(), db, todo)
 	assert.NoError(t, errInsertTodo)
+	assert.Equal(t, 7, todoId)
+	assert.NoError(t, tx.Commit())
 
 	errMockExpectations := mock.ExpectationsWereMet()
 	assert.NoError(t, errMockExpectations)
 }
 
+func TestTodoRepositoryInsertMissingUser(t *testing.T) {
+	db, mock, errDBMock := sqlmock.New()
+
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	todo := entity.Todo{
+		Uuid:        todoUuid,
+		UserId:      99,
+		Title:       "Todo Title",
+		Description: "Todo description",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO todos").ExpectExec().WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`todo`.`todos`, CONSTRAINT `todos_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"})
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	_, errInsertTodo := todoRepository.Insert(context.Background(), tx, todo)
+
+	constraintError := &helper.ConstraintError{}
+
+	assert.ErrorIs(t, errInsertTodo, helper.ErrReferenceNotFound)
+	assert.True(t, errors.As(errInsertTodo, &constraintError))
+	assert.Equal(t, "user_id", constraintError.Field)
+}
+
 func TestTodoRepositoryUpdate(t *testing.T) {
 	db, mock, errDBMock := sqlmock.New()
 
@@ -99,11 +216,18 @@ func TestTodoRepositoryUpdate(t *testing.T) {
 		Title:       "Update Todo Title",
 		Description: "Update todo description",
 		IsDone:      true,
+		RemindAt:    "2024-04-01T12:00:00+02:00",
+		Version:     2,
 	}
 
-	mock.ExpectPrepare("UPDATE todos SET").ExpectExec().WithArgs(todoUpdate.Title, todoUpdate.Description, todoUpdate.IsDone, todoUpdate.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE todos SET (.+) WHERE id=\\? AND \\(\\? = 0 OR version = \\?\\)").ExpectExec().WithArgs(todoUpdate.Title, todoUpdate.Description, todoUpdate.IsDone, int64(1711965600), 5, todoUpdate.Id, 2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, errBegin := db.Begin()
 
-	errUpdateTodo := todoRepository.Update(context.Background(), db, todoUpdate)
+	assert.NoError(t, errBegin)
+
+	errUpdateTodo := todoRepository.Update(context.Background(), tx, todoUpdate, 5)
 	assert.NoError(t, errUpdateTodo)
 
 	errMockExpectations := mock.ExpectationsWereMet()
@@ -117,9 +241,14 @@ func TestTodoRepositoryUpdateCompletion(t *testing.T) {
 
 	defer db.Close()
 
-	mock.ExpectPrepare("UPDATE todos SET").ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE todos SET").ExpectExec().WithArgs(6, 1, 0, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, errBegin := db.Begin()
 
-	errUpdateTodoCompletion := todoRepository.UpdateTodoCompletion(context.Background(), db, 1)
+	assert.NoError(t, errBegin)
+
+	errUpdateTodoCompletion := todoRepository.UpdateTodoCompletion(context.Background(), tx, 1, 0, 6)
 	assert.NoError(t, errUpdateTodoCompletion)
 
 	errMockExpectations := mock.ExpectationsWereMet()
@@ -133,10 +262,15 @@ func TestTodoRepositoryDelete(t *testing.T) {
 
 	defer db.Close()
 
-	mock.ExpectPrepare("DELETE FROM todos").ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare("DELETE FROM todos").ExpectExec().WithArgs(1, 3, 3).WillReturnResult(sqlmock.NewResult(0, 1))
 
-	errUpdateTodoCompletion := todoRepository.Delete(context.Background(), db, 1)
-	assert.NoError(t, errUpdateTodoCompletion)
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+
+	errDeleteTodo := todoRepository.Delete(context.Background(), tx, 1, 3)
+	assert.NoError(t, errDeleteTodo)
 
 	errMockExpectations := mock.ExpectationsWereMet()
 	assert.NoError(t, errMockExpectations)
@@ -3,10 +3,14 @@ package unit
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
 	"go_todo_api/internal/service"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -28,6 +32,26 @@ func (mock *TodoRepositoryMock) Get(ctx context.Context, db *sql.DB, todoId int)
 	return args.Get(0).(entity.Todo), nil
 }
 
+func (mock *TodoRepositoryMock) GetByUuid(ctx context.Context, db *sql.DB, userId int, uuid string) (entity.Todo, error) {
+	args := mock.Called(ctx, db, userId, uuid)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.Todo), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.Todo), nil
+}
+
+func (mock *TodoRepositoryMock) GetInTx(ctx context.Context, tx *sql.Tx, todoId int) (entity.Todo, error) {
+	args := mock.Called(ctx, tx, todoId)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.Todo), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.Todo), nil
+}
+
 func (mock *TodoRepositoryMock) GetUserTodos(ctx context.Context, db *sql.DB, userId int) ([]entity.Todo, error) {
 	args := mock.Called(ctx, db, userId)
 
@@ -38,8 +62,28 @@ func (mock *TodoRepositoryMock) GetUserTodos(ctx context.Context, db *sql.DB, us
 	return args.Get(0).([]entity.Todo), nil
 }
 
-func (mock *TodoRepositoryMock) Insert(ctx context.Context, db *sql.DB, todo request.TodoCreateRequest) error {
-	args := mock.Called(ctx, db, todo)
+func (mock *TodoRepositoryMock) GetChangedSince(ctx context.Context, db *sql.DB, userId int, since int64, limit int) ([]entity.Todo, error) {
+	args := mock.Called(ctx, db, userId, since, limit)
+
+	if args.Get(1) != nil {
+		return nil, args.Get(1).(error)
+	}
+
+	return args.Get(0).([]entity.Todo), nil
+}
+
+func (mock *TodoRepositoryMock) Insert(ctx context.Context, tx *sql.Tx, todo entity.Todo) (int, error) {
+	args := mock.Called(ctx, tx, todo)
+
+	if args.Get(1) != nil {
+		return 0, args.Error(1)
+	}
+
+	return args.Int(0), nil
+}
+
+func (mock *TodoRepositoryMock) Update(ctx context.Context, tx *sql.Tx, todo request.TodoUpdateRequest, changeSeq int64) error {
+	args := mock.Called(ctx, tx, todo, changeSeq)
 
 	if args.Get(0) != nil {
 		return args.Error(0)
@@ -48,8 +92,8 @@ func (mock *TodoRepositoryMock) Insert(ctx context.Context, db *sql.DB, todo req
 	return nil
 }
 
-func (mock *TodoRepositoryMock) Update(ctx context.Context, db *sql.DB, todo request.TodoUpdateRequest) error {
-	args := mock.Called(ctx, db, todo)
+func (mock *TodoRepositoryMock) UpdateTodoCompletion(ctx context.Context, tx *sql.Tx, todoId int, version int, changeSeq int64) error {
+	args := mock.Called(ctx, tx, todoId, version, changeSeq)
 
 	if args.Get(0) != nil {
 		return args.Error(0)
@@ -58,8 +102,8 @@ func (mock *TodoRepositoryMock) Update(ctx context.Context, db *sql.DB, todo req
 	return nil
 }
 
-func (mock *TodoRepositoryMock) UpdateTodoCompletion(ctx context.Context, db *sql.DB, todoId int) error {
-	args := mock.Called(ctx, db, todoId)
+func (mock *TodoRepositoryMock) Delete(ctx context.Context, tx *sql.Tx, todoId int, version int) error {
+	args := mock.Called(ctx, tx, todoId, version)
 
 	if args.Get(0) != nil {
 		return args.Error(0)
@@ -68,8 +112,32 @@ func (mock *TodoRepositoryMock) UpdateTodoCompletion(ctx context.Context, db *sq
 	return nil
 }
 
-func (mock *TodoRepositoryMock) Delete(ctx context.Context, db *sql.DB, todoId int) error {
-	args := mock.Called(ctx, db, todoId)
+type SyncRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *SyncRepositoryMock) NextChangeSeq(ctx context.Context, tx *sql.Tx, userId int) (int64, error) {
+	args := mock.Called(ctx, tx, userId)
+
+	if args.Get(1) != nil {
+		return 0, args.Error(1)
+	}
+
+	return args.Get(0).(int64), nil
+}
+
+func (mock *SyncRepositoryMock) InsertTombstone(ctx context.Context, tx *sql.Tx, tombstone entity.TodoTombstone) error {
+	args := mock.Called(ctx, tx, tombstone)
+
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+
+	return nil
+}
+
+func (mock *SyncRepositoryMock) DeleteTombstone(ctx context.Context, tx *sql.Tx, userId int, uuid string) error {
+	args := mock.Called(ctx, tx, userId, uuid)
 
 	if args.Get(0) != nil {
 		return args.Error(0)
@@ -78,7 +146,18 @@ func (mock *TodoRepositoryMock) Delete(ctx context.Context, db *sql.DB, todoId i
 	return nil
 }
 
+func (mock *SyncRepositoryMock) GetTombstonesSince(ctx context.Context, db *sql.DB, userId int, since int64, limit int) ([]entity.TodoTombstone, error) {
+	args := mock.Called(ctx, db, userId, since, limit)
+
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]entity.TodoTombstone), nil
+}
+
 var todoRepositoryMock = new(TodoRepositoryMock)
+var syncRepositoryMock = new(SyncRepositoryMock)
 var validatorMock = new(ValidatorMock)
 
 func TestTodoServiceFind(t *testing.T) {
@@ -87,7 +166,7 @@ func TestTodoServiceFind(t *testing.T) {
 
 	defer db.Close()
 
-	todoService := service.NewTodoService(db, todoRepositoryMock, validatorMock)
+	todoService := service.NewTodoService(db, todoRepositoryMock, syncRepositoryMock, newOutboxMock(), validatorMock)
 
 	ctx := context.Background()
 	expectedTodo := entity.Todo{
@@ -120,7 +199,7 @@ func TestTodoServiceFindUserTodos(t *testing.T) {
 
 	defer db.Close()
 
-	todoService := service.NewTodoService(db, todoRepositoryMock, validatorMock)
+	todoService := service.NewTodoService(db, todoRepositoryMock, syncRepositoryMock, newOutboxMock(), validatorMock)
 
 	ctx := context.Background()
 	expectedTodos := []entity.Todo{}
@@ -149,12 +228,15 @@ func TestTodoServiceFindUserTodos(t *testing.T) {
 }
 
 func TestTodoServiceCreate(t *testing.T) {
-	db, _, errDBMock := sqlmock.New()
+	db, sqlMock, errDBMock := sqlmock.New()
 	assert.NoError(t, errDBMock)
 
 	defer db.Close()
 
-	todoService := service.NewTodoService(db, todoRepositoryMock, validatorMock)
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	outboxMock := newOutboxMock()
+	todoService := service.NewTodoService(db, todoRepositoryMock, syncRepositoryMock, outboxMock, validatorMock)
 
 	ctx := context.Background()
 	todo := request.TodoCreateRequest{
@@ -163,20 +245,81 @@ func TestTodoServiceCreate(t *testing.T) {
 		Description: "Todo description",
 	}
 
-	validatorMock.On("StructCtx", ctx, todo).Return(nil)
-	todoRepositoryMock.On("Insert", ctx, db, todo).Return(nil)
+	newTodo := mock.MatchedBy(func(newTodo entity.Todo) bool {
+		return newTodo.Uuid != "" && newTodo.UserId == 1 && newTodo.Title == todo.Title && newTodo.ChangeSeq == 4
+	})
 
-	errCreateTodo := todoService.Create(ctx, todo)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	validatorMock.On("StructCtx", ctx, todo).Return(nil)
+	syncRepositoryMock.On("NextChangeSeq", ctx, mock.AnythingOfType("*sql.Tx"), 1).Return(int64(4), nil)
+	todoRepositoryMock.On("Insert", ctx, mock.AnythingOfType("*sql.Tx"), newTodo).Return(9, nil)
+	todoRepositoryMock.On("GetInTx", ctx, mock.AnythingOfType("*sql.Tx"), 9).Return(entity.Todo{Id: 9, Uuid: todoUuid, UserId: 1, Title: todo.Title, Version: 1}, nil)
+	outboxMock.On("Add", ctx, mock.AnythingOfType("*sql.Tx"), 1, helper.EventTodoCreated, mock.MatchedBy(func(todoResponse response.TodoResponse) bool {
+		return todoResponse.Id == 9 && todoResponse.Version == 1
+	})).Return(nil)
+	todoRepositoryMock.On("Get", ctx, db, 9).Return(entity.Todo{Id: 9, Uuid: todoUuid, UserId: 1, Title: todo.Title, Version: 1}, nil)
+
+	todoResponse, errCreateTodo := todoService.Create(ctx, todo)
 	assert.NoError(t, errCreateTodo)
+	assert.Equal(t, 9, todoResponse.Id)
+	assert.Equal(t, todoUuid, todoResponse.Uuid)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	// Counted rather than asserted, testify would print the committed *sql.Tx
+	// while database/sql may still be writing to it.
+	todoRepositoryMock.AssertNumberOfCalls(t, "Insert", 1)
+	todoRepositoryMock.AssertNumberOfCalls(t, "GetInTx", 1)
+	todoRepositoryMock.AssertNumberOfCalls(t, "Get", 1)
+	outboxMock.AssertNumberOfCalls(t, "Add", 1)
+}
+
+func TestTodoServiceCreateWithTakenId(t *testing.T) {
+	db, sqlMock, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	todoService := service.NewTodoService(db, todoRepositoryMock, syncRepositoryMock, newOutboxMock(), validatorMock)
+
+	ctx := context.Background()
+	todo := request.TodoCreateRequest{
+		Id:     strings.ToUpper(todoUuid),
+		UserId: 1,
+		Title:  "Todo Title",
+	}
+
+	newTodo := mock.MatchedBy(func(newTodo entity.Todo) bool {
+		return newTodo.Uuid == todoUuid
+	})
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectRollback()
+	validatorMock.On("StructCtx", ctx, todo).Return(nil)
+	syncRepositoryMock.On("NextChangeSeq", ctx, mock.Anything, 1).Return(int64(5), nil)
+	todoRepositoryMock.On("Insert", ctx, mock.Anything, newTodo).Return(0, helper.NewConflictError("uuid"))
+
+	_, errCreateTodo := todoService.Create(ctx, todo)
+
+	constraintError := &helper.ConstraintError{}
+
+	assert.ErrorIs(t, errCreateTodo, helper.ErrConflict)
+	assert.True(t, errors.As(errCreateTodo, &constraintError))
+	assert.Equal(t, "id", constraintError.Field)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
 
 func TestTodoServiceUpdate(t *testing.T) {
-	db, _, errDBMock := sqlmock.New()
+	db, sqlMock, errDBMock := sqlmock.New()
 	assert.NoError(t, errDBMock)
 
 	defer db.Close()
 
-	todoService := service.NewTodoService(db, todoRepositoryMock, validatorMock)
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	outboxMock := newOutboxMock()
+	todoService := service.NewTodoService(db, todoRepositoryMock, syncRepositoryMock, outboxMock, validatorMock)
 
 	ctx := context.Background()
 	todo := request.TodoUpdateRequest{
@@ -186,39 +329,103 @@ func TestTodoServiceUpdate(t *testing.T) {
 		IsDone:      true,
 	}
 
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
 	validatorMock.On("StructCtx", ctx, todo).Return(nil)
-	todoRepositoryMock.On("Update", ctx, db, todo).Return(nil)
+	todoRepositoryMock.On("Get", ctx, db, 1).Return(entity.Todo{Id: 1, UserId: 2, Version: 1}, nil)
+	syncRepositoryMock.On("NextChangeSeq", ctx, mock.Anything, 2).Return(int64(8), nil)
+	todoRepositoryMock.On("Update", ctx, mock.Anything, todo, int64(8)).Return(nil)
+	todoRepositoryMock.On("GetInTx", ctx, mock.Anything, 1).Return(entity.Todo{Id: 1, UserId: 2, Title: todo.Title, Version: 2}, nil)
+	outboxMock.On("Add", ctx, mock.AnythingOfType("*sql.Tx"), 2, helper.EventTodoUpdated, mock.Anything).Return(nil)
 
-	errUpdateTodo := todoService.Update(ctx, todo)
+	todoResponse, errUpdateTodo := todoService.Update(ctx, todo)
 	assert.NoError(t, errUpdateTodo)
+	assert.Equal(t, 2, todoResponse.Version)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	outboxMock.AssertNumberOfCalls(t, "Add", 1)
 }
 
 func TestTodoServiceUpdateTodoCompletion(t *testing.T) {
-	db, _, errDBMock := sqlmock.New()
+	db, sqlMock, errDBMock := sqlmock.New()
 	assert.NoError(t, errDBMock)
 
 	defer db.Close()
 
-	todoService := service.NewTodoService(db, todoRepositoryMock, validatorMock)
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	outboxMock := newOutboxMock()
+	todoService := service.NewTodoService(db, todoRepositoryMock, syncRepositoryMock, outboxMock, validatorMock)
 
 	ctx := context.Background()
-	todoRepositoryMock.On("UpdateTodoCompletion", ctx, db, 1).Return(nil)
 
-	errUpdateTodo := todoService.UpdateTodoCompletion(ctx, 1)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	todoRepositoryMock.On("Get", ctx, db, 1).Return(entity.Todo{Id: 1, UserId: 1, Version: 1}, nil)
+	syncRepositoryMock.On("NextChangeSeq", ctx, mock.Anything, 1).Return(int64(2), nil)
+	todoRepositoryMock.On("UpdateTodoCompletion", ctx, mock.Anything, 1, 0, int64(2)).Return(nil)
+	todoRepositoryMock.On("GetInTx", ctx, mock.Anything, 1).Return(entity.Todo{Id: 1, UserId: 1, IsDone: true, Version: 2}, nil)
+	outboxMock.On("Add", ctx, mock.AnythingOfType("*sql.Tx"), 1, helper.EventTodoCompleted, mock.MatchedBy(func(todoResponse response.TodoResponse) bool {
+		return todoResponse.IsDone
+	})).Return(nil)
+
+	todoResponse, errUpdateTodo := todoService.UpdateTodoCompletion(ctx, 1, 0)
 	assert.NoError(t, errUpdateTodo)
+	assert.True(t, todoResponse.IsDone)
+	assert.Equal(t, 2, todoResponse.Version)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	outboxMock.AssertNumberOfCalls(t, "Add", 1)
 }
 
 func TestTodoServiceRemove(t *testing.T) {
-	db, _, errDBMock := sqlmock.New()
+	db, sqlMock, errDBMock := sqlmock.New()
 	assert.NoError(t, errDBMock)
 
 	defer db.Close()
 
-	todoService := service.NewTodoService(db, todoRepositoryMock, validatorMock)
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	outboxMock := newOutboxMock()
+	todoService := service.NewTodoService(db, todoRepositoryMock, syncRepositoryMock, outboxMock, validatorMock)
 
 	ctx := context.Background()
-	todoRepositoryMock.On("Delete", ctx, db, 1).Return(nil)
+	tombstone := entity.TodoTombstone{UserId: 1, Uuid: todoUuid, ChangeSeq: 5}
 
-	errDeleteTodo := todoService.Remove(ctx, 1)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	todoRepositoryMock.On("Get", ctx, db, 1).Return(entity.Todo{Id: 1, Uuid: todoUuid, UserId: 1, Version: 2}, nil)
+	syncRepositoryMock.On("NextChangeSeq", ctx, mock.AnythingOfType("*sql.Tx"), 1).Return(int64(5), nil)
+	todoRepositoryMock.On("Delete", ctx, mock.AnythingOfType("*sql.Tx"), 1, 2).Return(nil)
+	syncRepositoryMock.On("InsertTombstone", ctx, mock.AnythingOfType("*sql.Tx"), tombstone).Return(nil)
+	outboxMock.On("Add", ctx, mock.AnythingOfType("*sql.Tx"), 1, helper.EventTodoDeleted, response.TodoDeletedResponse{Id: 1, Uuid: todoUuid}).Return(nil)
+
+	errDeleteTodo := todoService.Remove(ctx, 1, 2)
 	assert.NoError(t, errDeleteTodo)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	syncRepositoryMock.AssertNumberOfCalls(t, "NextChangeSeq", 1)
+	syncRepositoryMock.AssertNumberOfCalls(t, "InsertTombstone", 1)
+	outboxMock.AssertNumberOfCalls(t, "Add", 1)
+}
+
+func TestTodoServiceRemoveStaleVersion(t *testing.T) {
+	db, sqlMock, errDBMock := sqlmock.New()
+	assert.NoError(t, errDBMock)
+
+	defer db.Close()
+
+	todoRepositoryMock := new(TodoRepositoryMock)
+	syncRepositoryMock := new(SyncRepositoryMock)
+	todoService := service.NewTodoService(db, todoRepositoryMock, syncRepositoryMock, newOutboxMock(), validatorMock)
+
+	ctx := context.Background()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectRollback()
+	todoRepositoryMock.On("Get", ctx, db, 1).Return(entity.Todo{Id: 1, UserId: 1, Version: 3}, nil)
+	syncRepositoryMock.On("NextChangeSeq", ctx, mock.Anything, 1).Return(int64(6), nil)
+	todoRepositoryMock.On("Delete", ctx, mock.Anything, 1, 2).Return(helper.ErrRowsNotAffected)
+	todoRepositoryMock.On("Get", ctx, db, 2).Return(entity.Todo{}, helper.ErrNotFound)
+
+	assert.ErrorIs(t, todoService.Remove(ctx, 1, 2), helper.ErrPreconditionFailed)
+	assert.ErrorIs(t, todoService.Remove(ctx, 2, 1), helper.ErrTodoNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/model/response"
 	"io"
@@ -60,6 +61,16 @@ func (mock *UserServiceMock) Remove(ctx context.Context, userId int) error {
 	return nil
 }
 
+func (mock *UserServiceMock) ConfirmEmail(ctx context.Context, emailTokenRequest request.EmailTokenRequest) error {
+	args := mock.Called(ctx, emailTokenRequest)
+	return args.Error(0)
+}
+
+func (mock *UserServiceMock) RevertEmail(ctx context.Context, emailTokenRequest request.EmailTokenRequest) error {
+	args := mock.Called(ctx, emailTokenRequest)
+	return args.Error(0)
+}
+
 func TestUserControllerCreate(t *testing.T) {
 	requestBody := strings.NewReader(`
 	{
@@ -187,3 +198,37 @@ func TestUserControllerDelete(t *testing.T) {
 
 	assert.Equal(t, 204, result.StatusCode)
 }
+
+func TestUserControllerConfirmEmail(t *testing.T) {
+	withToken := mock.MatchedBy(func(emailTokenRequest request.EmailTokenRequest) bool {
+		return emailTokenRequest.Token == "unittest-token"
+	})
+
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/email/confirm", strings.NewReader(`{"token": "unittest-token"}`))
+
+	recorder := httptest.NewRecorder()
+
+	userServiceMock := new(UserServiceMock)
+	userController := controller.NewUserController(userServiceMock)
+
+	userServiceMock.On("ConfirmEmail", request.Context(), withToken).Return(nil)
+
+	userController.ConfirmEmail(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+}
+
+func TestUserControllerRevertEmailInvalidToken(t *testing.T) {
+	request := httptest.NewRequest("POST", "http://localhost:8080/api/email/revert", strings.NewReader(`{"token": "expired-token"}`))
+
+	recorder := httptest.NewRecorder()
+
+	userServiceMock := new(UserServiceMock)
+	userController := controller.NewUserController(userServiceMock)
+
+	userServiceMock.On("RevertEmail", request.Context(), mock.AnythingOfType("request.EmailTokenRequest")).Return(helper.ErrorTokenInvalid)
+
+	userController.RevertEmail(recorder, request, httprouter.Params{})
+
+	assert.Equal(t, 401, recorder.Result().StatusCode)
+}
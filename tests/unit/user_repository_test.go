@@ -8,11 +8,14 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 )
 
 var userRepository = repository.NewUserRepository()
 
+var userColumns = []string{"id", "username", "password", "name", "email", "pending_email", "phone_number", "locale", "role", "is_disabled", "token_version", "created_at", "updated_at"}
+
 func TestUserRepositoryGetById(t *testing.T) {
 	db, mock, err := sqlmock.New()
 
@@ -20,10 +23,10 @@ func TestUserRepositoryGetById(t *testing.T) {
 
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "username", "password", "name", "email", "phone_number", "created_at", "updated_at"}).
-		AddRow(1, "budi", "secret", "Budi", "budi@example.xyz", "087654321", "2024-01-01", "2024-01-01")
+	rows := sqlmock.NewRows(userColumns).
+		AddRow(1, "budi", "secret", "Budi", "budi@example.xyz", nil, "087654321", nil, "user", false, 0, "2024-01-01", "2024-01-01")
 
-	mock.ExpectPrepare("SELECT id, username, password, name, email, phone_number, created_at, updated_at FROM users").ExpectQuery().WithArgs(1).WillReturnRows(rows)
+	mock.ExpectPrepare("SELECT (.+) FROM users").ExpectQuery().WithArgs(1).WillReturnRows(rows)
 
 	user, errGetUser := userRepository.Get(context.Background(), db, 1)
 
@@ -31,7 +34,7 @@ func TestUserRepositoryGetById(t *testing.T) {
 	assert.Equal(t, 1, user.Id)
 	assert.Equal(t, "budi", user.Username)
 
-	mock.ExpectPrepare("SELECT id, username, password, name, email, phone_number, created_at, updated_at FROM users").ExpectQuery().WithArgs(2).WillReturnError(helper.ErrNotFound)
+	mock.ExpectPrepare("SELECT (.+) FROM users").ExpectQuery().WithArgs(2).WillReturnError(helper.ErrNotFound)
 
 	_, errUserNotFound := userRepository.Get(context.Background(), db, 2)
 
@@ -45,10 +48,10 @@ func TestUserRepositoryGetByUsername(t *testing.T) {
 
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "username", "password", "name", "email", "phone_number", "created_at", "updated_at"}).
-		AddRow(2, "apollo", "secret", "Apollo", "apolo@example.xyz", "09847218", "2024-01-02", "2024-01-02")
+	rows := sqlmock.NewRows(userColumns).
+		AddRow(2, "apollo", "secret", "Apollo", "apolo@example.xyz", "apollo@example.com", "09847218", "id", "admin", false, 3, "2024-01-02", "2024-01-02")
 
-	mock.ExpectPrepare("SELECT id, username, password, name, email, phone_number, created_at, updated_at FROM users").ExpectQuery().WithArgs("apollo").WillReturnRows(rows)
+	mock.ExpectPrepare("SELECT (.+) FROM users").ExpectQuery().WithArgs("apollo").WillReturnRows(rows)
 
 	user, errGetByUsername := userRepository.GetByUsername(context.Background(), db, "apollo")
 
@@ -56,8 +59,12 @@ func TestUserRepositoryGetByUsername(t *testing.T) {
 	assert.Equal(t, 2, user.Id)
 	assert.Equal(t, "apollo", user.Username)
 	assert.Equal(t, "Apollo", user.Name)
+	assert.Equal(t, "admin", user.Role)
+	assert.Equal(t, 3, user.TokenVersion)
+	assert.Equal(t, "apollo@example.com", user.PendingEmail)
+	assert.Equal(t, "id", user.Locale)
 
-	mock.ExpectPrepare("SELECT id, username, password, name, email, phone_number, created_at, updated_at FROM users").ExpectQuery().WithArgs("unknown_user").WillReturnError(helper.ErrNotFound)
+	mock.ExpectPrepare("SELECT (.+) FROM users").ExpectQuery().WithArgs("unknown_user").WillReturnError(helper.ErrNotFound)
 
 	_, errUserNotFound := userRepository.GetByUsername(context.Background(), db, "unknown_user")
 
@@ -86,6 +93,33 @@ func TestUserRepositoryInsert(t *testing.T) {
 	assert.NoError(t, errUserInsert)
 }
 
+func TestUserRepositoryInsertDuplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	userCreateRequest := request.UserCreateRequest{
+		Username:    "budi",
+		Password:    "secret",
+		Name:        "Budi",
+		Email:       "budi@example.xyz",
+		PhoneNumber: "+628582198125",
+	}
+
+	mock.ExpectPrepare("INSERT INTO users").ExpectExec().WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'budi@example.xyz' for key 'users.email'"})
+
+	errUserInsert := userRepository.Insert(context.Background(), db, userCreateRequest)
+
+	var constraintError *helper.ConstraintError
+
+	assert.ErrorIs(t, errUserInsert, helper.ErrConflict)
+	assert.ErrorAs(t, errUserInsert, &constraintError)
+	assert.Equal(t, "email", constraintError.Field)
+	assert.Equal(t, "email already exists", errUserInsert.Error())
+}
+
 func TestUserRepositoryUpdate(t *testing.T) {
 	db, mock, err := sqlmock.New()
 
@@ -101,7 +135,7 @@ func TestUserRepositoryUpdate(t *testing.T) {
 		PhoneNumber: "0123456789",
 	}
 
-	mock.ExpectPrepare("UPDATE users").ExpectExec().WithArgs(userUpdateRequest.Username, userUpdateRequest.Name, userUpdateRequest.Email, userUpdateRequest.PhoneNumber, userUpdateRequest.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("UPDATE users").ExpectExec().WithArgs(userUpdateRequest.Username, userUpdateRequest.Name, userUpdateRequest.Email, userUpdateRequest.PhoneNumber, userUpdateRequest.Locale, userUpdateRequest.Id).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	errUserUpdate := userRepository.Update(context.Background(), db, userUpdateRequest)
 
@@ -147,3 +181,87 @@ func TestUserRepositoryDeleteUserTodo(t *testing.T) {
 
 	assert.NoError(t, errUserTodoDelete)
 }
+
+func TestUserRepositorySearch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(userColumns).
+		AddRow(1, "budi_1", "secret", "Budi", "budi@example.xyz", nil, "087654321", nil, "user", false, 0, "2024-01-01", nil).
+		AddRow(2, "budi_2", "secret", "Budiman", "budiman@example.xyz", nil, "0123456789", nil, "user", true, 1, "2024-01-02", "2024-01-03")
+
+	mock.ExpectPrepare("SELECT (.+) FROM users WHERE username LIKE").ExpectQuery().WithArgs(`%budi\_%`, `%budi\_%`, `%budi\_%`, 20, 0).WillReturnRows(rows)
+
+	users, errSearch := userRepository.Search(context.Background(), db, "budi_", 20, 0)
+
+	assert.NoError(t, errSearch)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "", users[0].UpdatedAt)
+	assert.True(t, users[1].IsDisabled)
+
+	mock.ExpectPrepare("SELECT COUNT").ExpectQuery().WithArgs("%%", "%%", "%%").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(42))
+
+	total, errCount := userRepository.CountSearch(context.Background(), db, "")
+
+	assert.NoError(t, errCount)
+	assert.Equal(t, 42, total)
+}
+
+func TestUserRepositoryUpdatePassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE users SET password").ExpectExec().WithArgs("hashed", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errUpdate := userRepository.UpdatePassword(context.Background(), db, 1, "hashed")
+
+	assert.NoError(t, errUpdate)
+
+	mock.ExpectPrepare("UPDATE users SET password").ExpectExec().WithArgs("hashed", 2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	errNotAffected := userRepository.UpdatePassword(context.Background(), db, 2, "hashed")
+
+	assert.ErrorIs(t, errNotAffected, helper.ErrRowsNotAffected)
+}
+
+func TestUserRepositoryIncrementTokenVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE users SET token_version = token_version \\+ 1").ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errIncrement := userRepository.IncrementTokenVersion(context.Background(), db, 1)
+
+	assert.NoError(t, errIncrement)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepositoryUpdateEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE users SET email = (.+), pending_email = NULL").ExpectExec().WithArgs("budi@example.com", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("UPDATE users SET pending_email").ExpectExec().WithArgs(nil, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, errBegin := db.Begin()
+
+	assert.NoError(t, errBegin)
+	assert.NoError(t, userRepository.UpdateEmail(context.Background(), tx, 1, "budi@example.com"))
+	assert.NoError(t, userRepository.UpdatePendingEmail(context.Background(), tx, 2, ""))
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package unit
 import (
 	"context"
 	"database/sql"
+	"go_todo_api/internal/helper"
 	"go_todo_api/internal/model/entity"
 	"go_todo_api/internal/model/request"
 	"go_todo_api/internal/service"
@@ -37,11 +38,26 @@ func (mock *UserRepositoryMock) GetByUsername(ctx context.Context, db *sql.DB, u
 	return args.Get(0).(entity.User), nil
 }
 
+func (mock *UserRepositoryMock) GetByEmail(ctx context.Context, db *sql.DB, email string) (entity.User, error) {
+	args := mock.Called(ctx, db, email)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.User), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.User), nil
+}
+
 func (mock *UserRepositoryMock) Insert(ctx context.Context, db *sql.DB, user request.UserCreateRequest) error {
 	args := mock.Called(ctx, db, user)
 	return args.Error(0)
 }
 
+func (mock *UserRepositoryMock) InsertUser(ctx context.Context, tx *sql.Tx, user entity.User) (int, error) {
+	args := mock.Called(ctx, tx, user)
+	return args.Int(0), args.Error(1)
+}
+
 func (mock *UserRepositoryMock) Update(ctx context.Context, db *sql.DB, user request.UserUpdateRequest) error {
 	args := mock.Called(ctx, db, user)
 	return args.Error(0)
@@ -57,6 +73,46 @@ func (mock *UserRepositoryMock) DeleteUserTodo(ctx context.Context, tx *sql.Tx,
 	return args.Error(0)
 }
 
+func (mock *UserRepositoryMock) Search(ctx context.Context, db *sql.DB, query string, limit int, offset int) ([]entity.User, error) {
+	args := mock.Called(ctx, db, query, limit, offset)
+
+	if args.Get(1) != nil {
+		return args.Get(0).([]entity.User), args.Get(1).(error)
+	}
+
+	return args.Get(0).([]entity.User), nil
+}
+
+func (mock *UserRepositoryMock) CountSearch(ctx context.Context, db *sql.DB, query string) (int, error) {
+	args := mock.Called(ctx, db, query)
+	return args.Int(0), args.Error(1)
+}
+
+func (mock *UserRepositoryMock) UpdateDisabled(ctx context.Context, db *sql.DB, userId int, isDisabled bool) error {
+	args := mock.Called(ctx, db, userId, isDisabled)
+	return args.Error(0)
+}
+
+func (mock *UserRepositoryMock) UpdatePassword(ctx context.Context, db *sql.DB, userId int, password string) error {
+	args := mock.Called(ctx, db, userId, password)
+	return args.Error(0)
+}
+
+func (mock *UserRepositoryMock) IncrementTokenVersion(ctx context.Context, db *sql.DB, userId int) error {
+	args := mock.Called(ctx, db, userId)
+	return args.Error(0)
+}
+
+func (mock *UserRepositoryMock) UpdatePendingEmail(ctx context.Context, tx *sql.Tx, userId int, pendingEmail string) error {
+	args := mock.Called(ctx, tx, userId, pendingEmail)
+	return args.Error(0)
+}
+
+func (mock *UserRepositoryMock) UpdateEmail(ctx context.Context, tx *sql.Tx, userId int, email string) error {
+	args := mock.Called(ctx, tx, userId, email)
+	return args.Error(0)
+}
+
 type ValidatorMock struct {
 	mock.Mock
 }
@@ -70,6 +126,72 @@ func hashPasswordMock(password string) (string, error) {
 	return password, nil
 }
 
+type EmailChangeRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *EmailChangeRepositoryMock) Insert(ctx context.Context, tx *sql.Tx, emailChange entity.EmailChange, expiresInHours int, revertExpiresInDays int) error {
+	args := mock.Called(ctx, tx, emailChange, expiresInHours, revertExpiresInDays)
+	return args.Error(0)
+}
+
+func (mock *EmailChangeRepositoryMock) DeletePending(ctx context.Context, tx *sql.Tx, userId int) error {
+	args := mock.Called(ctx, tx, userId)
+	return args.Error(0)
+}
+
+func (mock *EmailChangeRepositoryMock) GetPendingByConfirmHash(ctx context.Context, db *sql.DB, confirmTokenHash string) (entity.EmailChange, error) {
+	args := mock.Called(ctx, db, confirmTokenHash)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.EmailChange), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.EmailChange), nil
+}
+
+func (mock *EmailChangeRepositoryMock) GetByRevertHash(ctx context.Context, db *sql.DB, revertTokenHash string) (entity.EmailChange, error) {
+	args := mock.Called(ctx, db, revertTokenHash)
+
+	if args.Get(1) != nil {
+		return args.Get(0).(entity.EmailChange), args.Get(1).(error)
+	}
+
+	return args.Get(0).(entity.EmailChange), nil
+}
+
+func (mock *EmailChangeRepositoryMock) MarkConfirmed(ctx context.Context, tx *sql.Tx, emailChangeId int) error {
+	args := mock.Called(ctx, tx, emailChangeId)
+	return args.Error(0)
+}
+
+func (mock *EmailChangeRepositoryMock) MarkReverted(ctx context.Context, tx *sql.Tx, emailChangeId int) error {
+	args := mock.Called(ctx, tx, emailChangeId)
+	return args.Error(0)
+}
+
+type MailerMock struct {
+	mock.Mock
+}
+
+func (mock *MailerMock) Send(ctx context.Context, mail helper.Mail) error {
+	args := mock.Called(ctx, mail)
+	return args.Error(0)
+}
+
+var emailChangeConfig = service.EmailChangeConfig{
+	ConfirmUrl: "https://app.example.com/email/confirm",
+	RevertUrl:  "https://app.example.com/email/revert",
+}
+
+var budiman = entity.User{
+	Id:          1,
+	Username:    "budiman",
+	Name:        "Budi",
+	Email:       "budiman@example.xyz",
+	PhoneNumber: "+6281234567890",
+}
+
 func TestUserServiceFind(t *testing.T) {
 	db, _, errSqlMock := sqlmock.New()
 
@@ -80,7 +202,7 @@ func TestUserServiceFind(t *testing.T) {
 	userRepositoryMock := new(UserRepositoryMock)
 	validatorMock := new(ValidatorMock)
 
-	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock)
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, new(EmailChangeRepositoryMock), new(MailerMock), emailChangeConfig)
 
 	expectedUser := entity.User{
 		Id:          1,
@@ -113,14 +235,14 @@ func TestUserServiceCreate(t *testing.T) {
 	userRepositoryMock := new(UserRepositoryMock)
 	validatorMock := new(ValidatorMock)
 
-	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock)
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, new(EmailChangeRepositoryMock), new(MailerMock), emailChangeConfig)
 
 	userCreateRequest := request.UserCreateRequest{
 		Username:    "anto",
 		Password:    "secret",
 		Name:        "Antonius",
 		Email:       "anto@example.xyz",
-		PhoneNumber: "08582198125",
+		PhoneNumber: "+628582198125",
 	}
 
 	ctx := context.Background()
@@ -132,6 +254,41 @@ func TestUserServiceCreate(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUserServiceCreateNormalizesIdentifiers(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, new(EmailChangeRepositoryMock), new(MailerMock), emailChangeConfig)
+
+	userCreateRequest := request.UserCreateRequest{
+		Username:    " Ｂｕｄｉ ",
+		Password:    "secret",
+		Name:        "Budi",
+		Email:       "Budi@Example.XYZ",
+		PhoneNumber: "0858-219-8126",
+	}
+
+	normalizedRequest := userCreateRequest
+	normalizedRequest.Username = "budi"
+	normalizedRequest.Email = "budi@example.xyz"
+	normalizedRequest.PhoneNumber = "+628582198126"
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, normalizedRequest).Return(nil)
+	userRepositoryMock.On("Insert", ctx, db, normalizedRequest).Return(nil)
+
+	err := userService.Create(ctx, userCreateRequest)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertExpectations(t)
+}
+
 func TestUserServiceUpdate(t *testing.T) {
 	db, _, errSqlMock := sqlmock.New()
 
@@ -142,23 +299,316 @@ func TestUserServiceUpdate(t *testing.T) {
 	userRepositoryMock := new(UserRepositoryMock)
 	validatorMock := new(ValidatorMock)
 
-	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock)
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, new(EmailChangeRepositoryMock), new(MailerMock), emailChangeConfig)
 
 	userUpdateRequest := request.UserUpdateRequest{
 		Id:          1,
 		Username:    "budiman",
 		Name:        "Budiman",
 		Email:       "budiman@example.xyz",
-		PhoneNumber: "0123456789",
+		PhoneNumber: "+6281234567890",
 	}
 
 	ctx := context.Background()
 	validatorMock.On("StructCtx", ctx, userUpdateRequest).Return(nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(budiman, nil)
 	userRepositoryMock.On("Update", ctx, db, userUpdateRequest).Return(nil)
 
 	err := userService.Update(ctx, userUpdateRequest)
 
 	assert.NoError(t, err)
+	userRepositoryMock.AssertExpectations(t)
+}
+
+func TestUserServiceUpdateKeepsLocale(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, new(EmailChangeRepositoryMock), new(MailerMock), emailChangeConfig)
+
+	budimanInIndonesian := budiman
+	budimanInIndonesian.Locale = "id"
+
+	userUpdateRequest := request.UserUpdateRequest{
+		Id:          1,
+		Username:    "budiman",
+		Name:        "Budi",
+		Email:       "budiman@example.xyz",
+		PhoneNumber: "+6281234567890",
+	}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, userUpdateRequest).Return(nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(budimanInIndonesian, nil)
+
+	err := userService.Update(ctx, userUpdateRequest)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserServiceUpdateKeepsPhoneNumber(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, new(EmailChangeRepositoryMock), new(MailerMock), emailChangeConfig)
+
+	userUpdateRequest := request.UserUpdateRequest{
+		Id:       1,
+		Username: "budiman",
+		Name:     "Budiman",
+		Email:    "budiman@example.xyz",
+	}
+
+	withPhoneNumber := mock.MatchedBy(func(user request.UserUpdateRequest) bool {
+		return user.Name == "Budiman" && user.PhoneNumber == budiman.PhoneNumber
+	})
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, userUpdateRequest).Return(nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(budiman, nil)
+	userRepositoryMock.On("Update", ctx, db, withPhoneNumber).Return(nil)
+
+	err := userService.Update(ctx, userUpdateRequest)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertExpectations(t)
+}
+
+func TestUserServiceUpdateEmailIsPending(t *testing.T) {
+	db, mockDB, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	emailChangeRepositoryMock := new(EmailChangeRepositoryMock)
+	mailerMock := new(MailerMock)
+
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, emailChangeRepositoryMock, mailerMock, emailChangeConfig)
+
+	userUpdateRequest := request.UserUpdateRequest{
+		Id:          1,
+		Username:    "budiman",
+		Name:        "Budi",
+		Email:       "Budi@Example.com",
+		PhoneNumber: "+6281234567890",
+	}
+
+	toNewEmail := mock.MatchedBy(func(emailChange entity.EmailChange) bool {
+		return emailChange.OldEmail == "budiman@example.xyz" && emailChange.NewEmail == "budi@example.com" && len(emailChange.ConfirmTokenHash) == 64
+	})
+
+	confirmTokenHash := ""
+	revertTokenHash := ""
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, mock.Anything).Return(nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(budiman, nil)
+	userRepositoryMock.On("GetByEmail", ctx, db, "budi@example.com").Return(entity.User{}, helper.ErrNotFound)
+	emailChangeRepositoryMock.On("DeletePending", ctx, mock.AnythingOfType("*sql.Tx"), 1).Return(nil)
+	emailChangeRepositoryMock.On("Insert", ctx, mock.AnythingOfType("*sql.Tx"), toNewEmail, 24, 7).Return(nil).Run(func(args mock.Arguments) {
+		emailChange := args.Get(2).(entity.EmailChange)
+		confirmTokenHash = emailChange.ConfirmTokenHash
+		revertTokenHash = emailChange.RevertTokenHash
+	})
+	userRepositoryMock.On("UpdatePendingEmail", ctx, mock.AnythingOfType("*sql.Tx"), 1, "budi@example.com").Return(nil)
+	mailerMock.On("Send", ctx, mock.AnythingOfType("helper.Mail")).Return(nil)
+
+	err := userService.Update(ctx, userUpdateRequest)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+
+	mailerMock.AssertNumberOfCalls(t, "Send", 2)
+
+	confirmMail := mailerMock.Calls[0].Arguments.Get(1).(helper.Mail)
+	noticeMail := mailerMock.Calls[1].Arguments.Get(1).(helper.Mail)
+
+	assert.Equal(t, "budi@example.com", confirmMail.To)
+	assert.Contains(t, confirmMail.Body, emailChangeConfig.ConfirmUrl+"?token=")
+	assert.Equal(t, "budiman@example.xyz", noticeMail.To)
+	assert.Contains(t, noticeMail.Body, emailChangeConfig.RevertUrl+"?token=")
+	assert.NotContains(t, confirmMail.Body, confirmTokenHash)
+	assert.NotContains(t, noticeMail.Body, revertTokenHash)
+}
+
+func TestUserServiceUpdateEmailTaken(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, new(EmailChangeRepositoryMock), new(MailerMock), emailChangeConfig)
+
+	userUpdateRequest := request.UserUpdateRequest{
+		Id:          1,
+		Username:    "budiman",
+		Name:        "Budi",
+		Email:       "apollo@example.xyz",
+		PhoneNumber: "+6281234567890",
+	}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, userUpdateRequest).Return(nil)
+	userRepositoryMock.On("Get", ctx, db, 1).Return(budiman, nil)
+	userRepositoryMock.On("GetByEmail", ctx, db, "apollo@example.xyz").Return(entity.User{Id: 2}, nil)
+
+	err := userService.Update(ctx, userUpdateRequest)
+
+	assert.ErrorIs(t, err, helper.ErrEmailTaken)
+}
+
+func TestUserServiceConfirmEmail(t *testing.T) {
+	db, mockDB, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	emailChangeRepositoryMock := new(EmailChangeRepositoryMock)
+
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, emailChangeRepositoryMock, new(MailerMock), emailChangeConfig)
+
+	emailTokenRequest := request.EmailTokenRequest{Token: "unittest-token"}
+
+	emailChange := entity.EmailChange{Id: 5, UserId: 1, OldEmail: "budiman@example.xyz", NewEmail: "budi@example.com"}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, emailTokenRequest).Return(nil)
+	emailChangeRepositoryMock.On("GetPendingByConfirmHash", ctx, db, helper.HashToken("unittest-token")).Return(emailChange, nil)
+	userRepositoryMock.On("GetByEmail", ctx, db, "budi@example.com").Return(entity.User{}, helper.ErrNotFound)
+	emailChangeRepositoryMock.On("MarkConfirmed", ctx, mock.AnythingOfType("*sql.Tx"), 5).Return(nil)
+	userRepositoryMock.On("UpdateEmail", ctx, mock.AnythingOfType("*sql.Tx"), 1, "budi@example.com").Return(nil)
+
+	err := userService.ConfirmEmail(ctx, emailTokenRequest)
+
+	assert.NoError(t, err)
+	// Counted rather than asserted, testify would print the *sql.Tx the
+	// email was updated in while database/sql may still be writing to it.
+	userRepositoryMock.AssertNumberOfCalls(t, "GetByEmail", 1)
+	userRepositoryMock.AssertNumberOfCalls(t, "UpdateEmail", 1)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestUserServiceConfirmEmailInvalidToken(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	validatorMock := new(ValidatorMock)
+	emailChangeRepositoryMock := new(EmailChangeRepositoryMock)
+
+	userService := service.NewUserService(db, new(UserRepositoryMock), validatorMock, hashPasswordMock, emailChangeRepositoryMock, new(MailerMock), emailChangeConfig)
+
+	emailTokenRequest := request.EmailTokenRequest{Token: "expired-token"}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, emailTokenRequest).Return(nil)
+	emailChangeRepositoryMock.On("GetPendingByConfirmHash", ctx, db, helper.HashToken("expired-token")).Return(entity.EmailChange{}, helper.ErrNotFound)
+
+	err := userService.ConfirmEmail(ctx, emailTokenRequest)
+
+	assert.ErrorIs(t, err, helper.ErrorTokenInvalid)
+}
+
+func TestUserServiceRevertConfirmedEmail(t *testing.T) {
+	db, mockDB, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	emailChangeRepositoryMock := new(EmailChangeRepositoryMock)
+
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, emailChangeRepositoryMock, new(MailerMock), emailChangeConfig)
+
+	emailTokenRequest := request.EmailTokenRequest{Token: "unittest-token"}
+
+	emailChange := entity.EmailChange{Id: 5, UserId: 1, OldEmail: "budiman@example.xyz", NewEmail: "budi@example.com", ConfirmedAt: "2024-02-20 10:00:00"}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, emailTokenRequest).Return(nil)
+	emailChangeRepositoryMock.On("GetByRevertHash", ctx, db, helper.HashToken("unittest-token")).Return(emailChange, nil)
+	emailChangeRepositoryMock.On("MarkReverted", ctx, mock.AnythingOfType("*sql.Tx"), 5).Return(nil)
+	userRepositoryMock.On("UpdateEmail", ctx, mock.AnythingOfType("*sql.Tx"), 1, "budiman@example.xyz").Return(nil)
+	userRepositoryMock.On("IncrementTokenVersion", ctx, db, 1).Return(nil)
+
+	err := userService.RevertEmail(ctx, emailTokenRequest)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertNumberOfCalls(t, "UpdateEmail", 1)
+	userRepositoryMock.AssertNumberOfCalls(t, "IncrementTokenVersion", 1)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestUserServiceRevertPendingEmail(t *testing.T) {
+	db, mockDB, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	userRepositoryMock := new(UserRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	emailChangeRepositoryMock := new(EmailChangeRepositoryMock)
+
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, emailChangeRepositoryMock, new(MailerMock), emailChangeConfig)
+
+	emailTokenRequest := request.EmailTokenRequest{Token: "unittest-token"}
+
+	emailChange := entity.EmailChange{Id: 5, UserId: 1, OldEmail: "budiman@example.xyz", NewEmail: "budi@example.com"}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, emailTokenRequest).Return(nil)
+	emailChangeRepositoryMock.On("GetByRevertHash", ctx, db, helper.HashToken("unittest-token")).Return(emailChange, nil)
+	emailChangeRepositoryMock.On("MarkReverted", ctx, mock.AnythingOfType("*sql.Tx"), 5).Return(nil)
+	userRepositoryMock.On("UpdatePendingEmail", ctx, mock.AnythingOfType("*sql.Tx"), 1, "").Return(nil)
+	userRepositoryMock.On("IncrementTokenVersion", ctx, db, 1).Return(nil)
+
+	err := userService.RevertEmail(ctx, emailTokenRequest)
+
+	assert.NoError(t, err)
+	userRepositoryMock.AssertNumberOfCalls(t, "UpdatePendingEmail", 1)
+	userRepositoryMock.AssertNumberOfCalls(t, "IncrementTokenVersion", 1)
+	userRepositoryMock.AssertNumberOfCalls(t, "UpdateEmail", 0)
 }
 
 func TestUserServiceDelete(t *testing.T) {
@@ -179,7 +629,7 @@ func TestUserServiceDelete(t *testing.T) {
 
 	validatorMock := new(ValidatorMock)
 
-	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock)
+	userService := service.NewUserService(db, userRepositoryMock, validatorMock, hashPasswordMock, new(EmailChangeRepositoryMock), new(MailerMock), emailChangeConfig)
 
 	err := userService.Remove(ctx, 1)
 	assert.NoError(t, err)
@@ -0,0 +1,137 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	customvalidator "go_todo_api/internal/validator"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteErrorResponseListsFieldErrors(t *testing.T) {
+	validate := customvalidator.NewValidator()
+
+	err := validate.StructCtx(context.Background(), request.UserCreateRequest{
+		Username:    "budi@example.xyz",
+		Password:    "secret",
+		Email:       "budi@example.xyz",
+		PhoneNumber: "0512345",
+	})
+
+	recorder := httptest.NewRecorder()
+
+	helper.WriteErrorResponse(recorder, err)
+
+	result := recorder.Result()
+
+	assert.Equal(t, 400, result.StatusCode)
+
+	problem := response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(result.Body).Decode(&problem))
+	assert.Equal(t, "request.validation_failed", problem.Code)
+	assert.Equal(t, []response.FieldErrorResponse{
+		{Field: "username", Rule: "excludes", Param: "@", Message: "username cannot contain the text '@'"},
+		{Field: "name", Rule: "required", Param: "", Message: "name is a required field"},
+		{Field: "phone_number", Rule: "phone", Param: "", Message: "phone_number must be a valid phone number"},
+	}, problem.Errors)
+}
+
+func TestValidationFieldNamesFollowJson(t *testing.T) {
+	validate := customvalidator.NewValidator()
+
+	err := validate.StructCtx(context.Background(), request.UserSearchRequest{Page: 0, PerPage: 500})
+
+	recorder := httptest.NewRecorder()
+
+	helper.WriteErrorResponse(recorder, err)
+
+	problem := response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+	assert.Len(t, problem.Errors, 2)
+	assert.Equal(t, "page", problem.Errors[0].Field)
+	assert.Equal(t, "per_page", problem.Errors[1].Field)
+	assert.Equal(t, "max", problem.Errors[1].Rule)
+	assert.Equal(t, "100", problem.Errors[1].Param)
+}
+
+func TestValidationRejectsTodoIdOtherThanUuidV7(t *testing.T) {
+	validate := customvalidator.NewValidator()
+
+	err := validate.StructCtx(context.Background(), request.TodoCreateRequest{
+		Id:     "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		UserId: 1,
+		Title:  "Todo Title",
+	})
+
+	recorder := httptest.NewRecorder()
+
+	helper.WriteErrorResponse(recorder, err)
+
+	problem := response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+	assert.Equal(t, []response.FieldErrorResponse{
+		{Field: "id", Rule: "uuid7", Param: "", Message: "id must be a version 7 UUID"},
+	}, problem.Errors)
+	assert.NoError(t, validate.StructCtx(context.Background(), request.TodoCreateRequest{UserId: 1, Title: "Todo Title"}))
+}
+
+func TestValidationRejectsInvalidEmails(t *testing.T) {
+	validate := customvalidator.NewValidator()
+
+	userCreateRequest := request.UserCreateRequest{Username: "budi", Password: "secret", Name: "Budi", Email: "budi", PhoneNumber: "+6281234567890"}
+	userUpdateRequest := request.UserUpdateRequest{Id: 1, Username: "budi", Name: "Budi", Email: "budi@"}
+
+	for _, userRequest := range []any{userCreateRequest, userUpdateRequest} {
+		err := validate.StructCtx(context.Background(), userRequest)
+
+		recorder := httptest.NewRecorder()
+
+		helper.WriteErrorResponse(recorder, err)
+
+		problem := response.ProblemResponse{}
+
+		assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+		assert.Len(t, problem.Errors, 1)
+		assert.Equal(t, "email", problem.Errors[0].Rule)
+	}
+
+	userUpdateRequest.Email = "budi@example.xyz"
+
+	assert.NoError(t, validate.StructCtx(context.Background(), userUpdateRequest))
+}
+
+func TestValidationRejectsOfflineTodoIdOtherThanUuidV7(t *testing.T) {
+	validate := customvalidator.NewValidator()
+
+	syncMutation := func(op string, id string, baseVersion int) request.SyncPushRequest {
+		return request.SyncPushRequest{Mutations: []request.SyncMutationRequest{{Op: op, Id: id, BaseVersion: baseVersion, Title: "Todo Title"}}}
+	}
+
+	err := validate.StructCtx(context.Background(), syncMutation(request.SyncOpUpsert, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", 0))
+
+	recorder := httptest.NewRecorder()
+
+	helper.WriteErrorResponse(recorder, err)
+
+	problem := response.ProblemResponse{}
+
+	assert.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&problem))
+	assert.Equal(t, []response.FieldErrorResponse{
+		{Field: "id", Rule: "uuid7", Param: "", Message: "id must be a version 7 UUID"},
+	}, problem.Errors)
+
+	assert.NoError(t, validate.StructCtx(context.Background(), syncMutation(request.SyncOpUpsert, "018E1C2A-7B3C-7D4E-8F90-123456789ABC", 0)))
+
+	// Todos from before version 7 ids can still be edited and deleted.
+	assert.NoError(t, validate.StructCtx(context.Background(), syncMutation(request.SyncOpUpsert, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", 2)))
+	assert.NoError(t, validate.StructCtx(context.Background(), syncMutation(request.SyncOpDelete, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", 2)))
+	assert.Error(t, validate.StructCtx(context.Background(), syncMutation(request.SyncOpDelete, "not-a-uuid", 2)))
+}
@@ -0,0 +1,196 @@
+package unit
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"go_todo_api/internal/helper"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/hkdf"
+)
+
+// testBrowser holds the keys a browser makes for a push subscription.
+type testBrowser struct {
+	privateKey *ecdh.PrivateKey
+	authSecret []byte
+}
+
+func newTestBrowser(t *testing.T) testBrowser {
+	privateKey, err := ecdh.P256().GenerateKey(rand.Reader)
+
+	assert.NoError(t, err)
+
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	return testBrowser{privateKey: privateKey, authSecret: authSecret}
+}
+
+func (browser testBrowser) p256dh() string {
+	return base64.RawURLEncoding.EncodeToString(browser.privateKey.PublicKey().Bytes())
+}
+
+func (browser testBrowser) auth() string {
+	return base64.RawURLEncoding.EncodeToString(browser.authSecret)
+}
+
+// decrypt undoes RFC 8291 encryption the way a browser does.
+func (browser testBrowser) decrypt(t *testing.T, body []byte) []byte {
+	salt := body[:16]
+	recordSize := binary.BigEndian.Uint32(body[16:20])
+	keyIdLength := int(body[20])
+	senderKeyBytes := body[21 : 21+keyIdLength]
+	ciphertext := body[21+keyIdLength:]
+
+	assert.Equal(t, uint32(4096), recordSize)
+
+	senderKey, err := ecdh.P256().NewPublicKey(senderKeyBytes)
+
+	assert.NoError(t, err)
+
+	sharedSecret, errShared := browser.privateKey.ECDH(senderKey)
+
+	assert.NoError(t, errShared)
+
+	keyInfo := append([]byte("WebPush: info\x00"), browser.privateKey.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, senderKeyBytes...)
+
+	inputKey := make([]byte, 32)
+	contentKey := make([]byte, 16)
+	nonce := make([]byte, 12)
+	io.ReadFull(hkdf.New(sha256.New, sharedSecret, browser.authSecret, keyInfo), inputKey)
+	io.ReadFull(hkdf.New(sha256.New, inputKey, salt, []byte("Content-Encoding: aes128gcm\x00")), contentKey)
+	io.ReadFull(hkdf.New(sha256.New, inputKey, salt, []byte("Content-Encoding: nonce\x00")), nonce)
+
+	block, _ := aes.NewCipher(contentKey)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, errOpen := gcm.Open(nil, nonce, ciphertext, nil)
+
+	assert.NoError(t, errOpen)
+	assert.Equal(t, byte(0x02), plaintext[len(plaintext)-1])
+
+	return plaintext[:len(plaintext)-1]
+}
+
+func newTestWebPushClient(t *testing.T) *helper.WebPushClient {
+	vapidKey, err := ecdh.P256().GenerateKey(rand.Reader)
+
+	assert.NoError(t, err)
+
+	client, errClient := helper.NewWebPushClient(helper.WebPushConfig{
+		VapidPrivateKey:      base64.RawURLEncoding.EncodeToString(vapidKey.Bytes()),
+		Subject:              "mailto:ops@example.com",
+		Timeout:              5 * time.Second,
+		Ttl:                  time.Hour,
+		AllowPrivateNetworks: true,
+	})
+
+	assert.NoError(t, errClient)
+
+	return client
+}
+
+func TestWebPushClientSend(t *testing.T) {
+	browser := newTestBrowser(t)
+	client := newTestWebPushClient(t)
+
+	var received *http.Request
+	var body []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	err := client.Send(context.Background(), helper.WebPushMessage{
+		Endpoint: server.URL + "/push/abc",
+		P256dh:   browser.p256dh(),
+		Auth:     browser.auth(),
+		Topic:    "reminder-7",
+		Payload:  []byte(`{"title":"Reminder: Pay rent"}`),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/push/abc", received.URL.Path)
+	assert.Equal(t, "aes128gcm", received.Header.Get("Content-Encoding"))
+	assert.Equal(t, "3600", received.Header.Get("TTL"))
+	assert.Equal(t, "reminder-7", received.Header.Get("Topic"))
+	assert.Equal(t, `{"title":"Reminder: Pay rent"}`, string(browser.decrypt(t, body)))
+
+	// The push service checks the VAPID token against the key in the header,
+	// which is the key browsers subscribed with.
+	token, publicKey, found := strings.Cut(strings.TrimPrefix(received.Header.Get("Authorization"), "vapid t="), ", k=")
+
+	assert.True(t, found)
+	assert.Equal(t, client.PublicKey(), publicKey)
+
+	rawPublicKey, _ := base64.RawURLEncoding.DecodeString(publicKey)
+	verificationKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(rawPublicKey[1:33]), Y: new(big.Int).SetBytes(rawPublicKey[33:])}
+
+	claims := jwt.MapClaims{}
+	_, errParse := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
+		return verificationKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(server.URL))
+
+	assert.NoError(t, errParse)
+	assert.Equal(t, "mailto:ops@example.com", claims["sub"])
+}
+
+func TestWebPushClientReportsGoneSubscriptions(t *testing.T) {
+	browser := newTestBrowser(t)
+	client := newTestWebPushClient(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	err := client.Send(context.Background(), helper.WebPushMessage{Endpoint: server.URL, P256dh: browser.p256dh(), Auth: browser.auth(), Payload: []byte("{}")})
+
+	assert.ErrorIs(t, err, helper.ErrPushSubscriptionGone)
+}
+
+func TestWebPushClientRefusesPrivateAddresses(t *testing.T) {
+	browser := newTestBrowser(t)
+	vapidKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	client, _ := helper.NewWebPushClient(helper.WebPushConfig{VapidPrivateKey: base64.RawURLEncoding.EncodeToString(vapidKey.Bytes()), Subject: "mailto:ops@example.com", Timeout: time.Second})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("push reached a loopback address")
+	}))
+	defer server.Close()
+
+	err := client.Send(context.Background(), helper.WebPushMessage{Endpoint: server.URL, P256dh: browser.p256dh(), Auth: browser.auth(), Payload: []byte("{}")})
+
+	assert.ErrorContains(t, err, "is not public")
+}
+
+func TestEncryptWebPushRejectsBadKeys(t *testing.T) {
+	browser := newTestBrowser(t)
+
+	_, errKey := helper.EncryptWebPush("not-a-key", browser.auth(), []byte("{}"))
+	_, errAuth := helper.EncryptWebPush(browser.p256dh(), "c2hvcnQ", []byte("{}"))
+	_, errSize := helper.EncryptWebPush(browser.p256dh(), browser.auth(), make([]byte, helper.WebPushMaxPayload+1))
+
+	assert.Error(t, errKey)
+	assert.Error(t, errAuth)
+	assert.Error(t, errSize)
+}
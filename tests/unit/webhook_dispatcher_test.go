@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestWebhookDispatcher(t *testing.T, webhookRepositoryMock *WebhookRepositoryMock, config service.WebhookConfig) service.WebhookDispatcher {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+	t.Cleanup(func() { db.Close() })
+
+	webhookRepositoryMock.On("ClaimDelivery", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	return service.NewWebhookDispatcher(db, webhookRepositoryMock, config)
+}
+
+func TestWebhookDispatcherPublish(t *testing.T) {
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	webhookDispatcher := newTestWebhookDispatcher(t, webhookRepositoryMock, service.DefaultWebhookConfig())
+
+	webhookRepositoryMock.On("GetActiveUserWebhooks", mock.Anything, mock.Anything, 1).Return([]entity.Webhook{
+		{Id: 4, UserId: 1, EventTypes: "todo.created todo.completed"},
+		{Id: 5, UserId: 1, EventTypes: "todo.deleted"},
+		{Id: 6, UserId: 1, EventTypes: "todo.completed"},
+	}, nil)
+
+	deliveries := []entity.WebhookDelivery{}
+	webhookRepositoryMock.On("InsertEventDelivery", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		deliveries = append(deliveries, args.Get(2).(entity.WebhookDelivery))
+	}).Return(nil)
+
+	event := entity.OutboxEvent{
+		EventId:   todoUuid,
+		UserId:    1,
+		EventType: helper.EventTodoCompleted,
+		Payload:   `{"id":3,"user_id":1,"is_done":true}`,
+		CreatedAt: "2024-03-20 10:00:00",
+	}
+
+	assert.NoError(t, webhookDispatcher.Publish(context.Background(), event))
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, 4, deliveries[0].WebhookId)
+	assert.Equal(t, 6, deliveries[1].WebhookId)
+
+	// Every webhook gets the event under its outbox id, which a receiver can
+	// drop duplicates by.
+	assert.Equal(t, todoUuid, deliveries[0].EventId)
+	assert.Equal(t, todoUuid, deliveries[1].EventId)
+	assert.Equal(t, deliveries[0].Payload, deliveries[1].Payload)
+
+	payload := map[string]any{}
+
+	assert.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+	assert.Equal(t, todoUuid, payload["id"])
+	assert.Equal(t, helper.EventTodoCompleted, payload["type"])
+	assert.Equal(t, "2024-03-20 10:00:00", payload["created_at"])
+	assert.Equal(t, true, payload["data"].(map[string]any)["is_done"])
+}
+
+func TestWebhookDispatcherDeliverDue(t *testing.T) {
+	payload := `{"id":"` + todoUuid + `","type":"todo.created"}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		assert.Equal(t, payload, string(body))
+		assert.Equal(t, todoUuid, r.Header.Get(helper.WebhookIdHeader))
+		assert.Equal(t, helper.EventTodoCreated, r.Header.Get(helper.WebhookEventHeader))
+
+		timestamp, _ := strings.CutPrefix(strings.Split(r.Header.Get(helper.WebhookSignatureHeader), ",")[0], "t=")
+		unixTimestamp, _ := strconv.ParseInt(timestamp, 10, 64)
+
+		assert.Equal(t, helper.SignWebhook("whsec_secret", unixTimestamp, body), r.Header.Get(helper.WebhookSignatureHeader))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := service.DefaultWebhookConfig()
+	config.AllowPrivateNetworks = true
+
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	webhookDispatcher := newTestWebhookDispatcher(t, webhookRepositoryMock, config)
+
+	delivery := entity.WebhookDelivery{Id: 8, WebhookId: 4, EventId: todoUuid, EventType: helper.EventTodoCreated, Payload: payload, Status: entity.WebhookDeliveryPending, Url: server.URL, Secret: "whsec_secret"}
+
+	webhookRepositoryMock.On("GetDueDeliveries", mock.Anything, mock.Anything, config.BatchSize).Return([]entity.WebhookDelivery{delivery}, nil)
+	webhookRepositoryMock.On("RecordSuccess", mock.Anything, mock.Anything, 4).Return(nil)
+	webhookRepositoryMock.On("UpdateDelivery", mock.Anything, mock.Anything, mock.MatchedBy(func(delivery entity.WebhookDelivery) bool {
+		return delivery.Status == entity.WebhookDeliverySucceeded && delivery.Attempts == 1 && delivery.ResponseStatus == http.StatusNoContent
+	}), time.Duration(0)).Return(nil)
+
+	assert.NoError(t, webhookDispatcher.DeliverDue(context.Background()))
+	webhookRepositoryMock.AssertExpectations(t)
+}
+
+func TestWebhookDispatcherRetriesFailedDelivery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := service.DefaultWebhookConfig()
+	config.AllowPrivateNetworks = true
+
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	webhookDispatcher := newTestWebhookDispatcher(t, webhookRepositoryMock, config)
+
+	webhookRepositoryMock.On("GetDueDeliveries", mock.Anything, mock.Anything, config.BatchSize).Return([]entity.WebhookDelivery{
+		{Id: 8, WebhookId: 4, Status: entity.WebhookDeliveryPending, Attempts: 2, Url: server.URL},
+		{Id: 9, WebhookId: 4, Status: entity.WebhookDeliveryPending, Attempts: 7, Url: server.URL},
+	}, nil)
+	webhookRepositoryMock.On("RecordFailure", mock.Anything, mock.Anything, 4, config.DisableAfter).Return(nil)
+
+	// The third attempt is retried after four times the initial backoff, the
+	// eighth is the last.
+	webhookRepositoryMock.On("UpdateDelivery", mock.Anything, mock.Anything, mock.MatchedBy(func(delivery entity.WebhookDelivery) bool {
+		return delivery.Id == 8 && delivery.Status == entity.WebhookDeliveryPending && delivery.Attempts == 3 && delivery.LastError == "unexpected status 500"
+	}), 2*time.Minute).Return(nil)
+	webhookRepositoryMock.On("UpdateDelivery", mock.Anything, mock.Anything, mock.MatchedBy(func(delivery entity.WebhookDelivery) bool {
+		return delivery.Id == 9 && delivery.Status == entity.WebhookDeliveryFailed && delivery.Attempts == 8
+	}), time.Duration(0)).Return(nil)
+
+	assert.NoError(t, webhookDispatcher.DeliverDue(context.Background()))
+	webhookRepositoryMock.AssertExpectations(t)
+	webhookRepositoryMock.AssertNumberOfCalls(t, "RecordFailure", 2)
+}
+
+func TestWebhookDispatcherRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("private address was called")
+	}))
+	defer server.Close()
+
+	config := service.DefaultWebhookConfig()
+
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	webhookDispatcher := newTestWebhookDispatcher(t, webhookRepositoryMock, config)
+
+	webhookRepositoryMock.On("GetDueDeliveries", mock.Anything, mock.Anything, config.BatchSize).Return([]entity.WebhookDelivery{
+		{Id: 8, WebhookId: 4, Status: entity.WebhookDeliveryPending, Url: server.URL},
+	}, nil)
+	webhookRepositoryMock.On("RecordFailure", mock.Anything, mock.Anything, 4, config.DisableAfter).Return(nil)
+	webhookRepositoryMock.On("UpdateDelivery", mock.Anything, mock.Anything, mock.MatchedBy(func(delivery entity.WebhookDelivery) bool {
+		return strings.Contains(delivery.LastError, "is not public")
+	}), config.InitialBackoff).Return(nil)
+
+	assert.NoError(t, webhookDispatcher.DeliverDue(context.Background()))
+	webhookRepositoryMock.AssertExpectations(t)
+}
+
+func TestWebhookDispatcherSkipsClaimedDelivery(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	webhookDispatcher := service.NewWebhookDispatcher(db, webhookRepositoryMock, service.DefaultWebhookConfig())
+
+	webhookRepositoryMock.On("GetDueDeliveries", mock.Anything, db, 20).Return([]entity.WebhookDelivery{{Id: 8, WebhookId: 4, Url: "http://127.0.0.1:1"}}, nil)
+	webhookRepositoryMock.On("ClaimDelivery", mock.Anything, db, 8, 40*time.Second).Return(helper.ErrRowsNotAffected)
+
+	assert.NoError(t, webhookDispatcher.DeliverDue(context.Background()))
+	webhookRepositoryMock.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSignWebhook(t *testing.T) {
+	signature := helper.SignWebhook("whsec_secret", 1710496800, []byte(`{"id":"1"}`))
+
+	assert.True(t, strings.HasPrefix(signature, "t=1710496800,v1="))
+	assert.Len(t, strings.TrimPrefix(signature, "t=1710496800,v1="), 64)
+	assert.NotEqual(t, signature, helper.SignWebhook("whsec_other", 1710496800, []byte(`{"id":"1"}`)))
+	assert.NotEqual(t, signature, helper.SignWebhook("whsec_secret", 1710496801, []byte(`{"id":"1"}`)))
+}
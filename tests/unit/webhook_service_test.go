@@ -0,0 +1,206 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/entity"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookRepositoryMock struct {
+	mock.Mock
+}
+
+func (mock *WebhookRepositoryMock) Insert(ctx context.Context, db *sql.DB, webhook entity.Webhook) (int, error) {
+	args := mock.Called(ctx, db, webhook)
+	return args.Int(0), args.Error(1)
+}
+
+func (mock *WebhookRepositoryMock) Get(ctx context.Context, db *sql.DB, userId int, webhookId int) (entity.Webhook, error) {
+	args := mock.Called(ctx, db, userId, webhookId)
+	return args.Get(0).(entity.Webhook), args.Error(1)
+}
+
+func (mock *WebhookRepositoryMock) GetUserWebhooks(ctx context.Context, db *sql.DB, userId int) ([]entity.Webhook, error) {
+	args := mock.Called(ctx, db, userId)
+	return args.Get(0).([]entity.Webhook), args.Error(1)
+}
+
+func (mock *WebhookRepositoryMock) GetActiveUserWebhooks(ctx context.Context, db *sql.DB, userId int) ([]entity.Webhook, error) {
+	args := mock.Called(ctx, db, userId)
+	return args.Get(0).([]entity.Webhook), args.Error(1)
+}
+
+func (mock *WebhookRepositoryMock) Delete(ctx context.Context, db *sql.DB, userId int, webhookId int) error {
+	args := mock.Called(ctx, db, userId, webhookId)
+	return args.Error(0)
+}
+
+func (mock *WebhookRepositoryMock) Enable(ctx context.Context, db *sql.DB, userId int, webhookId int) error {
+	args := mock.Called(ctx, db, userId, webhookId)
+	return args.Error(0)
+}
+
+func (mock *WebhookRepositoryMock) RecordSuccess(ctx context.Context, db *sql.DB, webhookId int) error {
+	args := mock.Called(ctx, db, webhookId)
+	return args.Error(0)
+}
+
+func (mock *WebhookRepositoryMock) RecordFailure(ctx context.Context, db *sql.DB, webhookId int, disableAfter int) error {
+	args := mock.Called(ctx, db, webhookId, disableAfter)
+	return args.Error(0)
+}
+
+func (mock *WebhookRepositoryMock) InsertDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery) (int, error) {
+	args := mock.Called(ctx, db, delivery)
+	return args.Int(0), args.Error(1)
+}
+
+func (mock *WebhookRepositoryMock) InsertEventDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery) error {
+	args := mock.Called(ctx, db, delivery)
+	return args.Error(0)
+}
+
+func (mock *WebhookRepositoryMock) GetDelivery(ctx context.Context, db *sql.DB, webhookId int, deliveryId int) (entity.WebhookDelivery, error) {
+	args := mock.Called(ctx, db, webhookId, deliveryId)
+	return args.Get(0).(entity.WebhookDelivery), args.Error(1)
+}
+
+func (mock *WebhookRepositoryMock) GetDeliveries(ctx context.Context, db *sql.DB, webhookId int, limit int) ([]entity.WebhookDelivery, error) {
+	args := mock.Called(ctx, db, webhookId, limit)
+	return args.Get(0).([]entity.WebhookDelivery), args.Error(1)
+}
+
+func (mock *WebhookRepositoryMock) GetDueDeliveries(ctx context.Context, db *sql.DB, limit int) ([]entity.WebhookDelivery, error) {
+	args := mock.Called(ctx, db, limit)
+	return args.Get(0).([]entity.WebhookDelivery), args.Error(1)
+}
+
+func (mock *WebhookRepositoryMock) ClaimDelivery(ctx context.Context, db *sql.DB, deliveryId int, lease time.Duration) error {
+	args := mock.Called(ctx, db, deliveryId, lease)
+	return args.Error(0)
+}
+
+func (mock *WebhookRepositoryMock) UpdateDelivery(ctx context.Context, db *sql.DB, delivery entity.WebhookDelivery, retryIn time.Duration) error {
+	args := mock.Called(ctx, db, delivery, retryIn)
+	return args.Error(0)
+}
+
+func TestWebhookServiceCreate(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	validatorMock := new(ValidatorMock)
+	webhookService := service.NewWebhookService(db, webhookRepositoryMock, validatorMock)
+
+	webhookCreateRequest := request.WebhookCreateRequest{
+		UserId:     1,
+		Url:        "https://example.com/hooks",
+		EventTypes: []string{helper.EventTodoCreated, helper.EventTodoCompleted},
+	}
+
+	ctx := context.Background()
+	validatorMock.On("StructCtx", ctx, webhookCreateRequest).Return(nil)
+	webhookRepositoryMock.On("Insert", ctx, db, mock.MatchedBy(func(webhook entity.Webhook) bool {
+		return webhook.UserId == 1 && webhook.EventTypes == "todo.created todo.completed" && strings.HasPrefix(webhook.Secret, helper.WebhookSecretPrefix)
+	})).Return(4, nil)
+	webhookRepositoryMock.On("Get", ctx, db, 1, 4).Return(entity.Webhook{Id: 4, UserId: 1, Url: "https://example.com/hooks", Secret: "whsec_stored", EventTypes: "todo.created todo.completed", CreatedAt: "2024-03-15 10:00:00"}, nil)
+
+	webhookCreateResponse, err := webhookService.Create(ctx, webhookCreateRequest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, webhookCreateResponse.Id)
+	assert.True(t, webhookCreateResponse.IsActive)
+	assert.Equal(t, []string{"todo.created", "todo.completed"}, webhookCreateResponse.EventTypes)
+	assert.True(t, strings.HasPrefix(webhookCreateResponse.Secret, helper.WebhookSecretPrefix))
+	assert.NotEqual(t, "whsec_stored", webhookCreateResponse.Secret)
+}
+
+func TestWebhookServiceRemoveUnknownWebhook(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	webhookService := service.NewWebhookService(db, webhookRepositoryMock, new(ValidatorMock))
+
+	ctx := context.Background()
+	webhookRepositoryMock.On("Delete", ctx, db, 1, 9).Return(helper.ErrRowsNotAffected)
+
+	assert.ErrorIs(t, webhookService.Remove(ctx, 1, 9), helper.ErrWebhookNotFound)
+}
+
+func TestWebhookServiceEnable(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	webhookService := service.NewWebhookService(db, webhookRepositoryMock, new(ValidatorMock))
+
+	ctx := context.Background()
+	webhookRepositoryMock.On("Get", ctx, db, 1, 4).Return(entity.Webhook{Id: 4, UserId: 1, FailureCount: 20, DisabledAt: "2024-03-16 10:00:00"}, nil).Once()
+	webhookRepositoryMock.On("Enable", ctx, db, 1, 4).Return(nil)
+	webhookRepositoryMock.On("Get", ctx, db, 1, 4).Return(entity.Webhook{Id: 4, UserId: 1}, nil).Once()
+
+	webhookResponse, err := webhookService.Enable(ctx, 1, 4)
+
+	assert.NoError(t, err)
+	assert.True(t, webhookResponse.IsActive)
+	assert.Equal(t, 0, webhookResponse.FailureCount)
+
+	webhookRepositoryMock.On("Get", ctx, db, 2, 4).Return(entity.Webhook{}, helper.ErrNotFound)
+
+	_, errNotFound := webhookService.Enable(ctx, 2, 4)
+
+	assert.ErrorIs(t, errNotFound, helper.ErrWebhookNotFound)
+	webhookRepositoryMock.AssertNumberOfCalls(t, "Enable", 1)
+}
+
+func TestWebhookServiceRedeliver(t *testing.T) {
+	db, _, errSqlMock := sqlmock.New()
+
+	assert.NoError(t, errSqlMock)
+
+	defer db.Close()
+
+	webhookRepositoryMock := new(WebhookRepositoryMock)
+	webhookService := service.NewWebhookService(db, webhookRepositoryMock, new(ValidatorMock))
+
+	delivery := entity.WebhookDelivery{Id: 8, WebhookId: 4, EventId: todoUuid, EventType: helper.EventTodoCreated, Payload: "{}", Status: entity.WebhookDeliveryFailed, Attempts: 8}
+
+	ctx := context.Background()
+	webhookRepositoryMock.On("Get", ctx, db, 1, 4).Return(entity.Webhook{Id: 4, UserId: 1}, nil)
+	webhookRepositoryMock.On("GetDelivery", ctx, db, 4, 8).Return(delivery, nil)
+	webhookRepositoryMock.On("InsertDelivery", ctx, db, delivery).Return(9, nil)
+	webhookRepositoryMock.On("GetDelivery", ctx, db, 4, 9).Return(entity.WebhookDelivery{Id: 9, WebhookId: 4, EventId: todoUuid, EventType: helper.EventTodoCreated, Status: entity.WebhookDeliveryPending}, nil)
+	webhookRepositoryMock.On("GetDelivery", ctx, db, 4, 10).Return(entity.WebhookDelivery{}, helper.ErrNotFound)
+
+	webhookDeliveryResponse, err := webhookService.Redeliver(ctx, 1, 4, 8)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9, webhookDeliveryResponse.Id)
+	assert.Equal(t, todoUuid, webhookDeliveryResponse.EventId)
+	assert.Equal(t, entity.WebhookDeliveryPending, webhookDeliveryResponse.Status)
+
+	_, errNotFound := webhookService.Redeliver(ctx, 1, 4, 10)
+
+	assert.ErrorIs(t, errNotFound, helper.ErrWebhookDeliveryNotFound)
+}
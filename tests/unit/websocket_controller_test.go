@@ -0,0 +1,219 @@
+package unit
+
+import (
+	"go_todo_api/internal/controller"
+	"go_todo_api/internal/helper"
+	"go_todo_api/internal/model/request"
+	"go_todo_api/internal/model/response"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/websocket"
+)
+
+type websocketMessage struct {
+	Type  string                    `json:"type"`
+	Id    string                    `json:"id"`
+	Topic string                    `json:"topic"`
+	Event string                    `json:"event"`
+	Data  map[string]any            `json:"data"`
+	Error *response.ProblemResponse `json:"error"`
+}
+
+func dialWebsocket(t *testing.T, websocketController controller.WebsocketController) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		websocketController.Connect(w, r.WithContext(helper.SetPrincipal(r.Context(), apolloPrincipal)), httprouter.Params{})
+	}))
+	t.Cleanup(server.Close)
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func receiveWebsocket(t *testing.T, conn *websocket.Conn) websocketMessage {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	for {
+		message := websocketMessage{}
+
+		assert.NoError(t, websocket.JSON.Receive(conn, &message))
+
+		if message.Type != response.WebsocketHeartbeat {
+			return message
+		}
+	}
+}
+
+func TestWebsocketControllerSubscribeTodos(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+	websocketController := controller.NewWebsocketController(&TodoServiceMock{}, eventBus, controller.TodoControllerConfig{}, controller.DefaultWebsocketControllerConfig())
+
+	conn := dialWebsocket(t, websocketController)
+
+	websocket.JSON.Send(conn, map[string]any{"id": "s1", "type": "subscribe", "topic": "todos"})
+
+	assert.Equal(t, websocketMessage{Type: "ack", Id: "s1"}, receiveWebsocket(t, conn))
+
+	eventBus.Publish(1, 2, helper.EventTodoCreated, response.TodoResponse{Id: 9, UserId: 2})
+	eventBus.Publish(2, 1, helper.EventTodoCompleted, response.TodoResponse{Id: 3, UserId: 1, IsDone: true})
+
+	message := receiveWebsocket(t, conn)
+
+	assert.Equal(t, "event", message.Type)
+	assert.Equal(t, "todos", message.Topic)
+	assert.Equal(t, helper.EventTodoCompleted, message.Event)
+	assert.Equal(t, float64(3), message.Data["id"])
+}
+
+func TestWebsocketControllerSubscribeTodo(t *testing.T) {
+	todoServiceMock := &TodoServiceMock{}
+	todoServiceMock.On("Find", mock.Anything, 3).Return(response.TodoResponse{Id: 3, UserId: 1}, nil)
+	todoServiceMock.On("Find", mock.Anything, 4).Return(response.TodoResponse{Id: 4, UserId: 2}, nil)
+
+	eventBus := helper.NewMemoryEventBus()
+	websocketController := controller.NewWebsocketController(todoServiceMock, eventBus, controller.TodoControllerConfig{}, controller.DefaultWebsocketControllerConfig())
+
+	conn := dialWebsocket(t, websocketController)
+
+	websocket.JSON.Send(conn, map[string]any{"id": "s1", "type": "subscribe", "topic": "todo:4"})
+
+	message := receiveWebsocket(t, conn)
+
+	assert.Equal(t, "error", message.Type)
+	assert.Equal(t, "todo.not_found", message.Error.Code)
+
+	websocket.JSON.Send(conn, map[string]any{"id": "s2", "type": "subscribe", "topic": "todo:3"})
+
+	assert.Equal(t, "ack", receiveWebsocket(t, conn).Type)
+
+	eventBus.Publish(1, 1, helper.EventTodoUpdated, response.TodoResponse{Id: 5, UserId: 1})
+	eventBus.Publish(2, 1, helper.EventTodoDeleted, response.TodoDeletedResponse{Id: 3, Uuid: todoUuid})
+
+	message = receiveWebsocket(t, conn)
+
+	assert.Equal(t, "todo:3", message.Topic)
+	assert.Equal(t, helper.EventTodoDeleted, message.Event)
+}
+
+func TestWebsocketControllerCreate(t *testing.T) {
+	todoCreateRequest := request.TodoCreateRequest{UserId: 1, Title: "Buy milk"}
+
+	todoServiceMock := &TodoServiceMock{}
+	todoServiceMock.On("Create", mock.Anything, todoCreateRequest).Return(response.TodoResponse{Id: 7, UserId: 1, Title: "Buy milk", Version: 1}, nil)
+
+	websocketController := controller.NewWebsocketController(todoServiceMock, helper.NewMemoryEventBus(), controller.TodoControllerConfig{}, controller.DefaultWebsocketControllerConfig())
+
+	conn := dialWebsocket(t, websocketController)
+
+	// The todo always belongs to the connected user.
+	websocket.JSON.Send(conn, map[string]any{"id": "c1", "type": "create", "data": map[string]any{"user_id": 2, "title": "Buy milk"}})
+
+	message := receiveWebsocket(t, conn)
+
+	assert.Equal(t, "ack", message.Type)
+	assert.Equal(t, "c1", message.Id)
+	assert.Equal(t, float64(7), message.Data["id"])
+	todoServiceMock.AssertExpectations(t)
+}
+
+func TestWebsocketControllerComplete(t *testing.T) {
+	todoServiceMock := &TodoServiceMock{}
+	todoServiceMock.On("Find", mock.Anything, 3).Return(response.TodoResponse{Id: 3, UserId: 1, Version: 2}, nil).Once()
+	todoServiceMock.On("UpdateTodoCompletion", mock.Anything, 3, 2).Return(response.TodoResponse{Id: 3, UserId: 1, IsDone: true, Version: 3}, nil)
+
+	websocketController := controller.NewWebsocketController(todoServiceMock, helper.NewMemoryEventBus(), controller.TodoControllerConfig{}, controller.DefaultWebsocketControllerConfig())
+
+	conn := dialWebsocket(t, websocketController)
+
+	websocket.JSON.Send(conn, map[string]any{"id": "c1", "type": "complete", "data": map[string]any{"id": 3, "version": 2}})
+
+	message := receiveWebsocket(t, conn)
+
+	assert.Equal(t, "ack", message.Type)
+	assert.Equal(t, true, message.Data["is_done"])
+	assert.Equal(t, float64(3), message.Data["version"])
+	todoServiceMock.AssertExpectations(t)
+}
+
+func TestWebsocketControllerUpdateWithoutVersion(t *testing.T) {
+	websocketController := controller.NewWebsocketController(&TodoServiceMock{}, helper.NewMemoryEventBus(), controller.TodoControllerConfig{RequireIfMatch: true}, controller.DefaultWebsocketControllerConfig())
+
+	conn := dialWebsocket(t, websocketController)
+
+	websocket.JSON.Send(conn, map[string]any{"id": "u1", "type": "update", "data": map[string]any{"id": 3, "title": "Buy oat milk"}})
+
+	message := receiveWebsocket(t, conn)
+
+	assert.Equal(t, "error", message.Type)
+	assert.Equal(t, "request.precondition_required", message.Error.Code)
+	assert.Equal(t, http.StatusPreconditionRequired, message.Error.Status)
+}
+
+func TestWebsocketControllerRejectsUnknownMessages(t *testing.T) {
+	websocketController := controller.NewWebsocketController(&TodoServiceMock{}, helper.NewMemoryEventBus(), controller.TodoControllerConfig{}, controller.DefaultWebsocketControllerConfig())
+
+	conn := dialWebsocket(t, websocketController)
+
+	websocket.Message.Send(conn, "{")
+
+	assert.Equal(t, "request.malformed_body", receiveWebsocket(t, conn).Error.Code)
+
+	websocket.JSON.Send(conn, map[string]any{"id": "d1", "type": "delete"})
+
+	message := receiveWebsocket(t, conn)
+
+	assert.Equal(t, "d1", message.Id)
+	assert.Equal(t, "websocket.message_invalid", message.Error.Code)
+
+	websocket.JSON.Send(conn, map[string]any{"id": "s1", "type": "subscribe", "topic": "users"})
+
+	assert.Equal(t, "websocket.message_invalid", receiveWebsocket(t, conn).Error.Code)
+}
+
+func TestWebsocketControllerDisconnectsSlowClient(t *testing.T) {
+	eventBus := helper.NewMemoryEventBus()
+
+	config := controller.DefaultWebsocketControllerConfig()
+	config.WriteTimeout = 100 * time.Millisecond
+
+	websocketController := controller.NewWebsocketController(&TodoServiceMock{}, eventBus, controller.TodoControllerConfig{}, config)
+
+	conn := dialWebsocket(t, websocketController)
+
+	websocket.JSON.Send(conn, map[string]any{"id": "s1", "type": "subscribe", "topic": "todos"})
+
+	assert.Equal(t, "ack", receiveWebsocket(t, conn).Type)
+
+	// Publishing never waits for the client, which isn't reading.
+	description := strings.Repeat("a", 1<<20)
+
+	for i := 0; i < 200; i++ {
+		eventBus.Publish(int64(i+1), 1, helper.EventTodoUpdated, response.TodoResponse{Id: i + 1, UserId: 1, Description: description})
+	}
+
+	received := 0
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+		message := websocketMessage{}
+
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			break
+		}
+
+		received++
+	}
+
+	assert.Less(t, received, 200)
+}
@@ -15,39 +15,168 @@ import (
 	"go_todo_api/internal/router"
 	"go_todo_api/internal/service"
 	"go_todo_api/internal/validator"
-	"net/http"
 )
 
 import (
 	_ "github.com/go-sql-driver/mysql"
+	_ "time/tzdata"
 )
 
 // Injectors from injector.go:
 
-func InitializeServer() (*http.Server, func()) {
+func InitializeServer() (*App, func(), error) {
 	db, cleanup := NewDB()
 	userRepository := repository.NewUserRepository()
+	mfaRepository := repository.NewMfaRepository()
 	customValidator := validator.NewValidator()
+	jwtKeySet, err := NewJwtKeySet()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	loginAttemptStore, err := NewLoginAttemptStore(db)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	loginThrottleConfig := service.DefaultLoginThrottleConfig()
+	loginThrottleService := service.NewLoginThrottleService(loginAttemptStore, loginThrottleConfig)
+	authService := service.NewAuthService(db, userRepository, mfaRepository, customValidator, jwtKeySet, loginThrottleService)
+	apiTokenRepository := repository.NewApiTokenRepository()
+	apiTokenService := service.NewApiTokenService(db, userRepository, apiTokenRepository, customValidator)
+	oauthRepository := repository.NewOauthRepository()
+	oauthService := service.NewOauthService(db, oauthRepository, customValidator)
+	authMiddleware := middleware.NewAuthMiddleware(authService, apiTokenService, oauthService)
+	idempotencyStore, err := NewIdempotencyStore(db)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(idempotencyStore)
 	v := helper.HashFunction()
-	userService := service.NewUserService(db, userRepository, customValidator, v)
+	emailChangeRepository := repository.NewEmailChangeRepository()
+	mailer, err := NewMailer()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	emailChangeConfig, err := NewEmailChangeConfig()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	userService := service.NewUserService(db, userRepository, customValidator, v, emailChangeRepository, mailer, emailChangeConfig)
 	userController := controller.NewUserController(userService)
 	todoRepository := repository.NewTodoRepository()
-	todoService := service.NewTodoService(db, todoRepository, customValidator)
-	todoController := controller.NewTodoController(todoService)
-	authService := service.NewAuthService(db, userRepository, customValidator)
+	syncRepository := repository.NewSyncRepository()
+	outboxRepository := repository.NewOutboxRepository()
+	eventBus := helper.NewMemoryEventBus()
+	webhookRepository := repository.NewWebhookRepository()
+	webhookConfig, err := NewWebhookConfig()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	webhookDispatcher := service.NewWebhookDispatcher(db, webhookRepository, webhookConfig)
+	v2, err := NewOutboxSinks(webhookDispatcher)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	outboxConfig := service.DefaultOutboxConfig()
+	outbox := service.NewOutbox(db, outboxRepository, eventBus, v2, outboxConfig)
+	todoService := service.NewTodoService(db, todoRepository, syncRepository, outbox, customValidator)
+	todoControllerConfig, err := NewTodoControllerConfig()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	todoController := controller.NewTodoController(todoService, todoControllerConfig)
 	authController := controller.NewAuthController(authService)
-	httprouterRouter := router.NewRouter(userController, todoController, authController)
+	mfaService := service.NewMfaService(db, userRepository, mfaRepository, customValidator, loginThrottleService)
+	mfaController := controller.NewMfaController(mfaService)
+	apiTokenController := controller.NewApiTokenController(apiTokenService)
+	adminService := service.NewAdminService(db, userRepository, oauthRepository, customValidator, v, loginThrottleService)
+	adminController := controller.NewAdminController(adminService)
+	jwksController := controller.NewJwksController(jwtKeySet)
+	oidcRepository := repository.NewOidcRepository()
+	oidcProviders, err := NewOidcProviders()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	oidcService := service.NewOidcService(db, userRepository, oidcRepository, authService, customValidator, v, oidcProviders)
+	oidcController := controller.NewOidcController(oidcService)
+	oauthController := controller.NewOauthController(oauthService)
+	syncService := service.NewSyncService(db, todoRepository, syncRepository, outbox, customValidator)
+	syncController := controller.NewSyncController(syncService)
+	eventControllerConfig := controller.DefaultEventControllerConfig()
+	eventController := controller.NewEventController(eventBus, eventControllerConfig)
+	websocketControllerConfig := controller.DefaultWebsocketControllerConfig()
+	websocketController := controller.NewWebsocketController(todoService, eventBus, todoControllerConfig, websocketControllerConfig)
+	webhookService := service.NewWebhookService(db, webhookRepository, customValidator)
+	webhookController := controller.NewWebhookController(webhookService)
+	notificationRepository := repository.NewNotificationRepository()
+	v3, err := NewNotificationChannels(db, notificationRepository, mailer)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	notificationService := service.NewNotificationService(db, notificationRepository, v3, customValidator)
+	notificationController := controller.NewNotificationController(notificationService)
+	httprouterRouter := router.NewRouter(authMiddleware, idempotencyMiddleware, userController, todoController, authController, mfaController, apiTokenController, adminController, jwksController, oidcController, oauthController, syncController, eventController, websocketController, webhookController, notificationController)
 	logMiddlewareHandler := middleware.NewLogMiddleware(httprouterRouter)
 	server := NewServer(logMiddlewareHandler)
-	return server, func() {
+	jobRepository := repository.NewJobRepository()
+	reminderRepository := repository.NewReminderRepository()
+	cleanupConfig := service.DefaultCleanupConfig()
+	cleanupJob := service.NewCleanupJob(db, outboxRepository, jobRepository, reminderRepository, cleanupConfig)
+	reminderConfig := service.DefaultReminderConfig()
+	reminderScanJob := service.NewReminderScanJob(db, reminderRepository, notificationRepository, jobRepository, reminderConfig)
+	reminderJob := service.NewReminderJob(db, reminderRepository, todoRepository, userRepository, notificationRepository, v3)
+	v4 := NewJobHandlers(cleanupJob, reminderScanJob, reminderJob)
+	v5, err := NewJobSchedules()
+	if err != nil {
 		cleanup()
+		return nil, nil, err
 	}
+	jobConfig := service.DefaultJobConfig()
+	jobRunner := service.NewJobRunner(db, jobRepository, v4, v5, jobConfig)
+	app := NewApp(server, outbox, webhookDispatcher, jobRunner)
+	return app, func() {
+		cleanup()
+	}, nil
 }
 
 // injector.go:
 
-var userSet = wire.NewSet(repository.NewUserRepository, helper.HashFunction, service.NewUserService, controller.NewUserController)
+var userSet = wire.NewSet(repository.NewUserRepository, helper.HashFunction, repository.NewEmailChangeRepository, NewMailer,
+	NewEmailChangeConfig, service.NewUserService, controller.NewUserController,
+)
+
+var authSet = wire.NewSet(
+	NewJwtKeySet,
+	NewLoginAttemptStore, service.DefaultLoginThrottleConfig, service.NewLoginThrottleService, controller.NewJwksController, repository.NewMfaRepository, service.NewAuthService, controller.NewAuthController, service.NewMfaService, controller.NewMfaController,
+)
+
+var oidcSet = wire.NewSet(
+	NewOidcProviders, repository.NewOidcRepository, service.NewOidcService, controller.NewOidcController,
+)
+
+var oauthSet = wire.NewSet(repository.NewOauthRepository, service.NewOauthService, controller.NewOauthController)
+
+var apiTokenSet = wire.NewSet(repository.NewApiTokenRepository, service.NewApiTokenService, controller.NewApiTokenController, middleware.NewAuthMiddleware, NewIdempotencyStore, middleware.NewIdempotencyMiddleware)
+
+var adminSet = wire.NewSet(service.NewAdminService, controller.NewAdminController)
 
-var authSet = wire.NewSet(service.NewAuthService, controller.NewAuthController)
+var webhookSet = wire.NewSet(repository.NewWebhookRepository, service.NewWebhookService, controller.NewWebhookController, NewWebhookConfig, service.NewWebhookDispatcher)
+
+var outboxSet = wire.NewSet(repository.NewOutboxRepository, NewOutboxSinks, service.DefaultOutboxConfig, service.NewOutbox)
+
+var notificationSet = wire.NewSet(repository.NewNotificationRepository, repository.NewReminderRepository, NewNotificationChannels, service.NewNotificationService, controller.NewNotificationController, service.DefaultReminderConfig, service.NewReminderScanJob, service.NewReminderJob)
+
+var jobSet = wire.NewSet(repository.NewJobRepository, service.DefaultCleanupConfig, service.NewCleanupJob, NewJobHandlers,
+	NewJobSchedules, service.DefaultJobConfig, service.NewJobRunner,
+)
 
-var todoSet = wire.NewSet(repository.NewTodoRepository, service.NewTodoService, controller.NewTodoController)
+var todoSet = wire.NewSet(repository.NewTodoRepository, repository.NewSyncRepository, helper.NewMemoryEventBus, service.NewTodoService, service.NewSyncService, NewTodoControllerConfig, controller.NewTodoController, controller.NewSyncController, controller.DefaultEventControllerConfig, controller.NewEventController, controller.DefaultWebsocketControllerConfig, controller.NewWebsocketController)